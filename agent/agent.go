@@ -0,0 +1,294 @@
+// Package agent runs pm upgrades in the background on a schedule, so
+// applications don't each reimplement an updater loop.
+//
+// An Agent periodically refreshes metadata (Update), lists the pending
+// upgrades (ListOutdated) and upgrades the packages a Policy allows
+// (Upgrade) on the backends of a pm.MultiManager, within a maintenance
+// window, and reports every run to a hook:
+//
+//	a := agent.New(multi, agent.Options{
+//		Interval: 24 * time.Hour,
+//		Policy: agent.Policy{
+//			Window:  &agent.Window{Start: 2 * time.Hour, End: 5 * time.Hour},
+//			Exclude: []string{"firefox"},
+//		},
+//		OnRun: func(ctx context.Context, run agent.Run) { log.Print(run) },
+//	})
+//	err := a.Start(ctx) // runs until ctx is cancelled
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/frostyard/pm"
+)
+
+// Window is a daily maintenance window, in local time. Start and End are
+// offsets from midnight; an End before Start wraps past midnight, so
+// {Start: 22h, End: 4h} runs from 22:00 to 04:00.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	offset := t.Sub(midnight(t))
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Next returns t if it falls inside the window, and otherwise the time the
+// window opens next.
+func (w Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	open := midnight(t).Add(w.Start)
+	if !open.After(t) {
+		open = midnight(t.AddDate(0, 0, 1)).Add(w.Start)
+	}
+	return open
+}
+
+// midnight returns the start of t's day in t's location.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// Policy restricts what an Agent upgrades.
+//
+// Exclude and SecurityOnly apply per package: the agent lists each
+// backend's pending upgrades and upgrades only the packages they allow,
+// holding the others back. A backend that cannot list its pending
+// upgrades (see pm.OutdatedLister) is updated but not upgraded under
+// either.
+type Policy struct {
+	// Backends lists the backends to update and upgrade; empty means all
+	// of the MultiManager's.
+	Backends []pm.BackendKind
+
+	// Exclude lists packages that must not be upgraded, by name or by
+	// identity (see pm.Identity), such as "firefox".
+	Exclude []string
+
+	// SecurityOnly restricts upgrades to those ListOutdated marks as
+	// security fixes (pm.OutdatedPackage.Security). brew, flatpak and snap
+	// mark none, so on them it holds every upgrade back.
+	SecurityOnly bool
+
+	// Window is the maintenance window runs are confined to; nil means any
+	// time.
+	Window *Window
+}
+
+// BackendResult is what a run did on one backend.
+type BackendResult struct {
+	Backend pm.BackendKind
+
+	// Updated reports that Update changed the backend's metadata.
+	Updated bool
+
+	// Upgraded lists the packages Upgrade changed.
+	Upgraded []pm.PackageRef
+
+	// Held lists the packages with pending upgrades that the policy held
+	// back.
+	Held []pm.PackageRef
+
+	// Skipped says why the backend was not upgraded, if it wasn't.
+	Skipped string
+
+	// Err is the backend's failure, if any.
+	Err error
+}
+
+// Run is the outcome of one run of an Agent.
+type Run struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Results has one entry per backend, in the MultiManager's order.
+	Results []BackendResult
+}
+
+// Err returns the failures of the run's backends joined, annotated with
+// the backend kind, or nil if none failed.
+func (r Run) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Backend, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String summarizes the run in one line, such as
+// "flatpak: 2 upgraded; snap: 0 upgraded, 1 held back".
+func (r Run) String() string {
+	var parts []string
+	for _, res := range r.Results {
+		switch {
+		case res.Err != nil:
+			parts = append(parts, fmt.Sprintf("%s: failed: %v", res.Backend, res.Err))
+		case res.Skipped != "":
+			parts = append(parts, fmt.Sprintf("%s: skipped (%s)", res.Backend, res.Skipped))
+		case len(res.Held) > 0:
+			parts = append(parts, fmt.Sprintf("%s: %d upgraded, %d held back", res.Backend, len(res.Upgraded), len(res.Held)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s: %d upgraded", res.Backend, len(res.Upgraded)))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Options configures an Agent.
+type Options struct {
+	// Interval is the time between the starts of runs; zero or less means
+	// 24 hours. A run that would fall outside the maintenance window waits
+	// for it to open.
+	Interval time.Duration
+
+	// Policy restricts what is upgraded.
+	Policy Policy
+
+	// Progress is passed to every operation the agent runs.
+	Progress pm.ProgressReporter
+
+	// OnRun, if set, is called after every run with its outcome.
+	OnRun func(ctx context.Context, run Run)
+}
+
+// Agent upgrades packages on a schedule. Create one with New.
+type Agent struct {
+	multi *pm.MultiManager
+	opts  Options
+
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+}
+
+// New creates an Agent for m's backends.
+func New(m *pm.MultiManager, opts Options) *Agent {
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	return &Agent{multi: m, opts: opts, now: time.Now, after: time.After}
+}
+
+// Start runs the agent until ctx is cancelled, then returns ctx's error.
+// The first run starts at once, or when the maintenance window opens;
+// each following one Interval after the previous one started, or when the
+// window next opens after that. Failures are reported to OnRun and don't
+// stop the agent.
+func (a *Agent) Start(ctx context.Context) error {
+	next := a.now()
+	for {
+		if a.opts.Policy.Window != nil {
+			next = a.opts.Policy.Window.Next(next)
+		}
+		if wait := next.Sub(a.now()); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-a.after(wait):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		run := a.RunOnce(ctx)
+		if a.opts.OnRun != nil {
+			a.opts.OnRun(ctx, run)
+		}
+		next = run.StartedAt.Add(a.opts.Interval)
+	}
+}
+
+// RunOnce updates and upgrades the backends now, regardless of the
+// maintenance window, and returns the outcome. It does not call OnRun.
+func (a *Agent) RunOnce(ctx context.Context) Run {
+	run := Run{StartedAt: a.now()}
+	for _, b := range a.multi.Backends() {
+		if len(a.opts.Policy.Backends) > 0 && !slices.Contains(a.opts.Policy.Backends, b.Kind) {
+			continue
+		}
+		run.Results = append(run.Results, a.runBackend(ctx, b))
+	}
+	run.FinishedAt = a.now()
+	return run
+}
+
+// runBackend updates and, as the policy allows, upgrades one backend.
+func (a *Agent) runBackend(ctx context.Context, b pm.Backend) BackendResult {
+	res := BackendResult{Backend: b.Kind}
+	if ok, err := b.Manager.Available(ctx); !ok || err != nil {
+		res.Skipped, res.Err = "not available", err
+		return res
+	}
+
+	if updater, ok := b.Manager.(pm.Updater); ok {
+		updated, err := updater.Update(ctx, pm.UpdateOptions{Progress: a.opts.Progress})
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		res.Updated = updated.Changed
+	}
+
+	upgrader, ok := b.Manager.(pm.Upgrader)
+	if !ok {
+		res.Skipped = "upgrades not supported"
+		return res
+	}
+	opts := pm.UpgradeOptions{Progress: a.opts.Progress}
+	lister, ok := b.Manager.(pm.OutdatedLister)
+	switch {
+	case ok:
+		outdated, err := lister.ListOutdated(ctx, pm.OutdatedOptions{Progress: a.opts.Progress})
+		if err != nil {
+			res.Err = err
+			return res
+		}
+		for _, p := range outdated {
+			if a.opts.Policy.allows(b.Kind, p) {
+				opts.Packages = append(opts.Packages, p.Ref)
+			} else {
+				res.Held = append(res.Held, p.Ref)
+			}
+		}
+		if len(opts.Packages) == 0 {
+			return res
+		}
+	case len(a.opts.Policy.Exclude) > 0 || a.opts.Policy.SecurityOnly:
+		res.Skipped = "pending upgrades cannot be listed to apply the policy"
+		return res
+	}
+
+	upgraded, err := upgrader.Upgrade(ctx, opts)
+	res.Upgraded, res.Err = upgraded.PackagesChanged, err
+	return res
+}
+
+// allows reports whether the policy lets pkg, pending on the backend kind,
+// be upgraded.
+func (p Policy) allows(kind pm.BackendKind, pkg pm.OutdatedPackage) bool {
+	if p.SecurityOnly && !pkg.Security {
+		return false
+	}
+	for _, name := range p.Exclude {
+		if strings.EqualFold(name, pkg.Ref.Name) || strings.EqualFold(name, pm.Identity(kind, pkg.Ref)) {
+			return false
+		}
+	}
+	return true
+}
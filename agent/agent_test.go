@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
+)
+
+func TestWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.UTC) }
+	night := Window{Start: 22 * time.Hour, End: 4 * time.Hour}
+	early := Window{Start: 2 * time.Hour, End: 5 * time.Hour}
+
+	tests := []struct {
+		w    Window
+		t    time.Time
+		want time.Time
+	}{
+		{early, at(3, 0), at(3, 0)},
+		{early, at(1, 0), at(2, 0)},
+		{early, at(5, 0), at(2, 0).AddDate(0, 0, 1)},
+		{night, at(23, 30), at(23, 30)},
+		{night, at(1, 0), at(1, 0)},
+		{night, at(12, 0), at(22, 0)},
+	}
+	for _, tt := range tests {
+		if got := tt.w.Next(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%+v.Next(%s) = %s, want %s", tt.w, tt.t.Format(time.Kitchen), got, tt.want)
+		}
+	}
+}
+
+func newFake(kind pm.BackendKind, installed ...string) *pmtest.FakeManager {
+	fake := pmtest.NewFakeManager()
+	fake.BackendKind = kind
+	for _, name := range installed {
+		fake.Installed = append(fake.Installed, pm.InstalledPackage{Ref: pm.PackageRef{Name: name}, Version: "1"})
+		fake.Upgrades = append(fake.Upgrades, pm.PackageRef{Name: name})
+	}
+	return fake
+}
+
+func TestAgent_RunOnce(t *testing.T) {
+	flatpak := newFake(pm.BackendFlatpak, "org.gimp.GIMP", "org.gnome.Maps")
+	snap := newFake(pm.BackendSnap, "firefox", "vlc")
+	brew := newFake(pm.BackendBrew)
+	brew.Fail("Update", errors.New("offline"))
+	multi := pm.NewMultiManager(
+		pm.Backend{Kind: pm.BackendFlatpak, Manager: flatpak},
+		pm.Backend{Kind: pm.BackendSnap, Manager: snap},
+		pm.Backend{Kind: pm.BackendBrew, Manager: brew},
+	)
+
+	run := New(multi, Options{Policy: Policy{Exclude: []string{"firefox"}}}).RunOnce(context.Background())
+	if len(run.Results) != 3 {
+		t.Fatalf("RunOnce() = %+v, want three results", run)
+	}
+	if res := run.Results[0]; len(res.Upgraded) != 2 || len(res.Held) != 0 || res.Skipped != "" || res.Err != nil {
+		t.Errorf("flatpak result = %+v, want two upgrades", res)
+	}
+	if res := run.Results[1]; len(res.Upgraded) != 1 || res.Upgraded[0].Name != "vlc" || len(res.Held) != 1 || res.Held[0].Name != "firefox" {
+		t.Errorf("snap result = %+v, want vlc upgraded and firefox held back", res)
+	}
+	if len(snap.Upgrades) != 1 || len(snap.CallsTo("Update")) != 1 {
+		t.Errorf("snap upgrades = %+v, want firefox still pending after an update", snap.Upgrades)
+	}
+	if res := run.Results[2]; res.Err == nil || len(brew.CallsTo("Upgrade")) != 0 {
+		t.Errorf("brew result = %+v, want the update failure", res)
+	}
+	if err := run.Err(); err == nil {
+		t.Error("Run.Err() = nil, want brew's failure")
+	}
+	if got, want := run.String(), "snap: 1 upgraded, 1 held back"; !strings.Contains(got, want) {
+		t.Errorf("Run.String() = %q, want it to contain %q", got, want)
+	}
+}
+
+// upgradeOnly is a backend that can update and upgrade but not list its
+// pending upgrades.
+type upgradeOnly struct {
+	pm.Manager
+	pm.Updater
+	pm.Upgrader
+}
+
+func TestAgent_Policy(t *testing.T) {
+	outdated := []pm.OutdatedPackage{
+		{Ref: pm.PackageRef{Name: "org.mozilla.firefox"}, Security: true},
+		{Ref: pm.PackageRef{Name: "org.gimp.GIMP"}, Security: true},
+		{Ref: pm.PackageRef{Name: "org.gnome.Maps"}},
+	}
+	tests := []struct {
+		name      string
+		policy    Policy
+		noLister  bool
+		upgraded  []string
+		held      int
+		skipped   bool
+		upgrading bool
+	}{
+		{name: "none", upgraded: []string{"org.mozilla.firefox", "org.gimp.GIMP", "org.gnome.Maps"}, upgrading: true},
+		{name: "exclude by name", policy: Policy{Exclude: []string{"org.gnome.Maps"}}, upgraded: []string{"org.mozilla.firefox", "org.gimp.GIMP"}, held: 1, upgrading: true},
+		{name: "exclude by identity", policy: Policy{Exclude: []string{"Firefox"}}, upgraded: []string{"org.gimp.GIMP", "org.gnome.Maps"}, held: 1, upgrading: true},
+		{name: "security only", policy: Policy{SecurityOnly: true}, upgraded: []string{"org.mozilla.firefox", "org.gimp.GIMP"}, held: 1, upgrading: true},
+		{name: "security only and exclude", policy: Policy{SecurityOnly: true, Exclude: []string{"firefox"}}, upgraded: []string{"org.gimp.GIMP"}, held: 2, upgrading: true},
+		{name: "everything held", policy: Policy{Exclude: []string{"firefox", "gimp", "maps"}}, held: 3},
+		{name: "unlisted without policy", noLister: true, upgrading: true},
+		{name: "unlisted with policy", policy: Policy{SecurityOnly: true}, noLister: true, skipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFake(pm.BackendFlatpak)
+			fake.ListOutdatedFunc = func(ctx context.Context, opts pm.OutdatedOptions) ([]pm.OutdatedPackage, error) {
+				return outdated, nil
+			}
+			var mgr pm.Manager = fake
+			if tt.noLister {
+				mgr = upgradeOnly{fake, fake, fake}
+			}
+			multi := pm.NewMultiManager(pm.Backend{Kind: pm.BackendFlatpak, Manager: mgr})
+
+			res := New(multi, Options{Policy: tt.policy}).RunOnce(context.Background()).Results[0]
+			if len(res.Held) != tt.held || (res.Skipped != "") != tt.skipped || res.Err != nil {
+				t.Errorf("result = %+v, want %d held back, skipped %t", res, tt.held, tt.skipped)
+			}
+			calls := fake.CallsTo("Upgrade")
+			if (len(calls) == 1) != tt.upgrading {
+				t.Fatalf("Upgrade calls = %+v, want upgrading %t", calls, tt.upgrading)
+			}
+			if len(calls) == 0 {
+				return
+			}
+			var names []string
+			for _, p := range calls[0].Packages {
+				names = append(names, p.Name)
+			}
+			if !slices.Equal(names, tt.upgraded) {
+				t.Errorf("Upgrade packages = %v, want %v", names, tt.upgraded)
+			}
+		})
+	}
+}
+
+func TestAgent_Start(t *testing.T) {
+	fake := newFake(pm.BackendFlatpak)
+	multi := pm.NewMultiManager(pm.Backend{Kind: pm.BackendFlatpak, Manager: fake})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var runs []time.Time
+	var waits []time.Duration
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a := New(multi, Options{
+		Interval: 6 * time.Hour,
+		Policy:   Policy{Window: &Window{Start: 2 * time.Hour, End: 5 * time.Hour}},
+		OnRun: func(ctx context.Context, run Run) {
+			runs = append(runs, run.StartedAt)
+			if len(runs) == 2 {
+				cancel()
+			}
+		},
+	})
+	a.now = func() time.Time { return now }
+	a.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		now = now.Add(d)
+		ch := make(chan time.Time, 1)
+		ch <- now
+		return ch
+	}
+
+	if err := a.Start(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Start() error = %v, want context.Canceled", err)
+	}
+	// 12:00 waits for 02:00; 08:00 is outside the window, so the next day
+	want := []time.Duration{14 * time.Hour, 24 * time.Hour}
+	if len(waits) < 2 || waits[0] != want[0] || waits[1] != want[1] {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+	if len(runs) != 2 || runs[0].Hour() != 2 || runs[1].Hour() != 2 {
+		t.Errorf("runs = %v, want two at 02:00", runs)
+	}
+}
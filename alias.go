@@ -0,0 +1,124 @@
+package pm
+
+import (
+	"strings"
+	"sync"
+)
+
+// AliasTable maps logical package names (e.g. "firefox") to the reference
+// each backend uses for that package (org.mozilla.firefox on flatpak,
+// firefox on snap, the firefox cask on brew).
+//
+// Logical names are matched case-insensitively. AliasTable is safe for
+// concurrent use.
+type AliasTable struct {
+	mu      sync.RWMutex
+	entries map[string]map[BackendKind]PackageRef
+}
+
+// NewAliasTable creates an alias table pre-populated with the built-in entries.
+func NewAliasTable() *AliasTable {
+	t := NewEmptyAliasTable()
+	for name, refs := range builtinAliases {
+		for kind, ref := range refs {
+			t.Add(name, kind, ref)
+		}
+	}
+	return t
+}
+
+// NewEmptyAliasTable creates an alias table with no entries.
+func NewEmptyAliasTable() *AliasTable {
+	return &AliasTable{entries: make(map[string]map[BackendKind]PackageRef)}
+}
+
+// Add maps a logical name to a package reference on one backend, replacing
+// any existing mapping for that name and backend.
+func (t *AliasTable) Add(name string, kind BackendKind, ref PackageRef) {
+	key := strings.ToLower(name)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries[key] == nil {
+		t.entries[key] = make(map[BackendKind]PackageRef)
+	}
+	t.entries[key][kind] = ref
+}
+
+// Remove deletes all mappings for a logical name.
+func (t *AliasTable) Remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, strings.ToLower(name))
+}
+
+// Resolve returns the per-backend references for a logical name.
+// The returned map is a copy and is empty if the name is unknown.
+func (t *AliasTable) Resolve(name string) map[BackendKind]PackageRef {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := make(map[BackendKind]PackageRef, len(t.entries[strings.ToLower(name)]))
+	for kind, ref := range t.entries[strings.ToLower(name)] {
+		out[kind] = ref
+	}
+	return out
+}
+
+// Lookup returns the reference for a logical name on one backend.
+func (t *AliasTable) Lookup(name string, kind BackendKind) (PackageRef, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ref, ok := t.entries[strings.ToLower(name)][kind]
+	return ref, ok
+}
+
+// Name returns the logical name whose entry for kind refers to the package
+// named pkgName. Package names are compared case-insensitively.
+func (t *AliasTable) Name(kind BackendKind, pkgName string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for name, refs := range t.entries {
+		if ref, ok := refs[kind]; ok && strings.EqualFold(ref.Name, pkgName) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// DefaultAliases is the alias table used by Resolve and by MultiManager
+// unless another table is configured. Applications may Add entries to it.
+var DefaultAliases = NewAliasTable()
+
+// Resolve returns the per-backend references for a logical package name
+// using DefaultAliases.
+func Resolve(name string) map[BackendKind]PackageRef {
+	return DefaultAliases.Resolve(name)
+}
+
+// builtinAliases covers common desktop applications available on more than one backend.
+var builtinAliases = map[string]map[BackendKind]PackageRef{
+	"firefox":     desktopApp("org.mozilla.firefox", "firefox", "firefox"),
+	"thunderbird": desktopApp("org.mozilla.Thunderbird", "thunderbird", "thunderbird"),
+	"chromium":    desktopApp("org.chromium.Chromium", "chromium", "chromium"),
+	"vlc":         desktopApp("org.videolan.VLC", "vlc", "vlc"),
+	"gimp":        desktopApp("org.gimp.GIMP", "gimp", "gimp"),
+	"inkscape":    desktopApp("org.inkscape.Inkscape", "inkscape", "inkscape"),
+	"vscode":      desktopApp("com.visualstudio.code", "code", "visual-studio-code"),
+	"spotify":     desktopApp("com.spotify.Client", "spotify", "spotify"),
+	"slack":       desktopApp("com.slack.Slack", "slack", "slack"),
+	"discord":     desktopApp("com.discordapp.Discord", "discord", "discord"),
+	"libreoffice": desktopApp("org.libreoffice.LibreOffice", "libreoffice", "libreoffice"),
+	"obs":         desktopApp("com.obsproject.Studio", "obs-studio", "obs"),
+	"blender":     desktopApp("org.blender.Blender", "blender", "blender"),
+	"telegram":    desktopApp("org.telegram.desktop", "telegram-desktop", "telegram"),
+	"steam":       desktopApp("com.valvesoftware.Steam", "steam", "steam"),
+}
+
+// desktopApp builds the alias entry for an app shipped as a flatpak app,
+// a snap, and a Homebrew cask.
+func desktopApp(flatpakID, snapName, caskName string) map[BackendKind]PackageRef {
+	return map[BackendKind]PackageRef{
+		BackendFlatpak: {Name: flatpakID, Kind: "app"},
+		BackendSnap:    {Name: snapName, Kind: "snap"},
+		BackendBrew:    {Name: caskName, Kind: "cask"},
+	}
+}
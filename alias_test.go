@@ -0,0 +1,66 @@
+package pm
+
+import (
+	"testing"
+)
+
+func TestAliasTable_Builtin(t *testing.T) {
+	refs := NewAliasTable().Resolve("Firefox")
+	want := map[BackendKind]string{
+		BackendFlatpak: "org.mozilla.firefox",
+		BackendSnap:    "firefox",
+		BackendBrew:    "firefox",
+	}
+	for kind, name := range want {
+		if refs[kind].Name != name {
+			t.Errorf("Resolve(firefox)[%s] = %q, want %q", kind, refs[kind].Name, name)
+		}
+	}
+	if refs[BackendBrew].Kind != "cask" {
+		t.Errorf("Expected brew alias to be a cask, got %q", refs[BackendBrew].Kind)
+	}
+}
+
+func TestAliasTable_AddAndRemove(t *testing.T) {
+	table := NewEmptyAliasTable()
+	table.Add("editor", BackendFlatpak, PackageRef{Name: "org.gnome.TextEditor", Kind: "app"})
+
+	if ref, ok := table.Lookup("EDITOR", BackendFlatpak); !ok || ref.Name != "org.gnome.TextEditor" {
+		t.Errorf("Lookup() = %+v, %v", ref, ok)
+	}
+	if _, ok := table.Lookup("editor", BackendSnap); ok {
+		t.Error("Expected no snap alias")
+	}
+
+	// Resolve returns a copy.
+	table.Resolve("editor")[BackendSnap] = PackageRef{Name: "oops"}
+	if _, ok := table.Lookup("editor", BackendSnap); ok {
+		t.Error("Mutating Resolve() result should not affect the table")
+	}
+
+	table.Remove("editor")
+	if refs := table.Resolve("editor"); len(refs) != 0 {
+		t.Errorf("Expected no entries after Remove, got %+v", refs)
+	}
+}
+
+func TestMultiManager_Resolve(t *testing.T) {
+	table := NewEmptyAliasTable()
+	table.Add("firefox", BackendFlatpak, PackageRef{Name: "org.mozilla.firefox", Kind: "app"})
+	table.Add("firefox", BackendSnap, PackageRef{Name: "firefox", Kind: "snap"})
+
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: &fakeManager{available: true}},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{available: true}},
+		Backend{Kind: BackendFlatpak, Manager: &fakeManager{available: true}},
+	)
+	m.SetAliases(table)
+
+	kind, ref, ok := m.Resolve("firefox")
+	if !ok || kind != BackendSnap || ref.Name != "firefox" {
+		t.Errorf("Resolve() = %s, %+v, %v; want snap/firefox", kind, ref, ok)
+	}
+	if _, _, ok := m.Resolve("unknown"); ok {
+		t.Error("Expected unknown name not to resolve")
+	}
+}
@@ -0,0 +1,48 @@
+package pm
+
+// ArchTargeter is implemented by managers that search and install packages
+// for a particular architecture. The managers returned by the constructors
+// implement it.
+type ArchTargeter interface {
+	// Arch returns the target architecture in Go's naming, such as "amd64"
+	// or "arm64": the one given to WithArch, or else Platform().Arch.
+	Arch() string
+}
+
+// WithArch sets the architecture packages are searched for and installed
+// for, in Go's naming (runtime.GOARCH), such as "amd64" or "arm64". By
+// default it is the architecture pm is running on.
+//
+// Search leaves out packages the backend knows are not built for it, and
+// Install fails with an UnsupportedArchError for them, before running
+// anything when it can tell in advance:
+//   - flatpak searches and installs refs for the architecture (--arch),
+//     so another architecture can be targeted, e.g. for an emulated
+//     installation
+//   - brew leaves out formulae that require another architecture, and
+//     Install rejects them when its formula index (see WithSearchIndex
+//     and WithFormulaeIndex) already knows them
+//   - snapd finds and installs snaps only for its own architecture;
+//     Install reports snaps built only for others
+func WithArch(arch string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.arch = arch
+	}
+}
+
+// targetArch returns the architecture given to WithArch, or else the one pm
+// is running on.
+func (c *backendConfig) targetArch() string {
+	if c.arch != "" {
+		return c.arch
+	}
+	return currentPlatform().Arch
+}
+
+// Arch implements ArchTargeter.
+func (a *backendAdapter) Arch() string {
+	if a.arch != "" {
+		return a.arch
+	}
+	return currentPlatform().Arch
+}
@@ -0,0 +1,41 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithArch(t *testing.T) {
+	withPlatform(t, PlatformInfo{OS: "linux", Arch: "amd64"}, "flatpak")
+
+	if arch := Must[ArchTargeter](NewFlatpak()).Arch(); arch != "amd64" {
+		t.Errorf("Arch() = %q, want the platform's amd64", arch)
+	}
+
+	// Offered only for x86_64, which flatpak runs on
+	var installs int
+	mgr := NewFlatpak(WithArch("arm64"), WithoutOperationLock(), WithRunner(RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		joined := strings.Join(args, " ")
+		switch {
+		case strings.HasPrefix(joined, "search --arch=aarch64"):
+			return "", "", nil
+		case strings.HasPrefix(joined, "search"):
+			return "com.valvesoftware.Steam\n", "", nil
+		}
+		installs++
+		return "", "", nil
+	})))
+	if arch := Must[ArchTargeter](mgr).Arch(); arch != "arm64" {
+		t.Errorf("Arch() = %q, want arm64", arch)
+	}
+	_, err := Must[Installer](mgr).Install(context.Background(), []PackageRef{{Name: "com.valvesoftware.Steam"}}, InstallOptions{})
+	var archErr *UnsupportedArchError
+	if !errors.As(err, &archErr) || !IsUnsupportedArch(err) || archErr.Backend != "flatpak" || archErr.Arch != "aarch64" {
+		t.Errorf("Install() error = %v, want an UnsupportedArchError", err)
+	}
+	if installs != 0 {
+		t.Errorf("Install() ran flatpak install %d times, want none", installs)
+	}
+}
@@ -0,0 +1,218 @@
+package pm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// Audit outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry records one mutating operation (Update, Upgrade, Install or Uninstall).
+type AuditEntry struct {
+	// Time is when the operation started.
+	Time time.Time `json:"time"`
+
+	// Duration is how long the operation took, excluding queue wait.
+	Duration time.Duration `json:"duration"`
+
+	// User is the account the process runs as, and UID its user ID.
+	User string `json:"user"`
+	UID  int    `json:"uid"`
+
+	// SudoUser is the invoking user when running under sudo, if any.
+	SudoUser string `json:"sudo_user,omitempty"`
+
+	// Host is the machine's hostname.
+	Host string `json:"host"`
+
+	// Backend and Operation identify what was run.
+	Backend   BackendKind `json:"backend"`
+	Operation Operation   `json:"operation"`
+
+	// Requested lists the packages the caller asked for (Install, Uninstall).
+	Requested []PackageRef `json:"requested,omitempty"`
+
+	// Changed lists the packages the operation reported as changed, with
+	// their resolved versions: the new version after Install and Upgrade,
+	// the removed version for Uninstall.
+	Changed []AuditPackage `json:"changed,omitempty"`
+
+	// Outcome is AuditSuccess or AuditFailure; Error holds the failure message.
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+
+	// Simulated is true if the backend ran in simulation mode (see WithSimulation).
+	Simulated bool `json:"simulated,omitempty"`
+
+	// RunID is the correlation ID from the operation's context (see
+	// ContextWithRunID), if any.
+	RunID string `json:"run_id,omitempty"`
+}
+
+// AuditPackage is a changed package and its resolved version.
+type AuditPackage struct {
+	Ref     PackageRef `json:"ref"`
+	Version string     `json:"version,omitempty"`
+}
+
+// AuditLogger receives audit entries.
+type AuditLogger interface {
+	Log(entry AuditEntry) error
+}
+
+// AuditFunc adapts a function to AuditLogger.
+type AuditFunc func(entry AuditEntry) error
+
+// Log calls f(entry).
+func (f AuditFunc) Log(entry AuditEntry) error {
+	return f(entry)
+}
+
+// jsonLinesAudit appends entries to a file, one JSON object per line.
+type jsonLinesAudit struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONLinesAuditLog returns an AuditLogger that appends each entry as a
+// line of JSON to the file at path, creating it with mode 0600 if needed.
+// The file is opened in append mode for every entry, so several processes
+// can share one log and external rotation is safe.
+func NewJSONLinesAuditLog(path string) AuditLogger {
+	return &jsonLinesAudit{path: path}
+}
+
+func (l *jsonLinesAudit) Log(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WithAuditLog records every mutating operation (Update, Upgrade, Install,
+// Uninstall) to l, including who ran it, the packages involved with their
+// resolved versions, and the outcome.
+//
+// If an entry cannot be written, the logging error is joined to the
+// operation's error so that gaps in the audit trail are never silent.
+func WithAuditLog(l AuditLogger) ConstructorOption {
+	return func(config *backendConfig) {
+		config.audit = l
+	}
+}
+
+// auditRecord is an audit entry for an operation in progress.
+type auditRecord struct {
+	entry AuditEntry
+
+	// start is when the operation started by the real clock, for the
+	// duration; entry.Time comes from WithClock, which may be fixed.
+	start time.Time
+}
+
+// auditStart captures the "who" part of an entry, or returns a zero record
+// when auditing is disabled. Lookups that fail leave fields empty rather
+// than blocking the operation.
+func (a *backendAdapter) auditStart(ctx context.Context, op Operation, requested []PackageRef) auditRecord {
+	if a.audit == nil {
+		return auditRecord{}
+	}
+	entry := AuditEntry{
+		Time:      a.now(),
+		UID:       os.Getuid(),
+		SudoUser:  os.Getenv("SUDO_USER"),
+		Backend:   a.kind,
+		Operation: op,
+		Requested: requested,
+		Simulated: a.simulate,
+		RunID:     RunIDFromContext(ctx),
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	entry.Host, _ = os.Hostname()
+	return auditRecord{entry: entry, start: time.Now()}
+}
+
+// installedVersions returns the installed versions of pkgs keyed by name,
+// for resolving versions in audit entries. It returns nil if the lookup
+// fails or finds none of pkgs.
+func (a *backendAdapter) installedVersions(ctx context.Context, pkgs []PackageRef) map[string]string {
+	installed := a.installedPackages(ctx, pkgs)
+	if len(installed) == 0 {
+		return nil
+	}
+	versions := make(map[string]string, len(installed))
+	for _, p := range installed {
+		versions[p.Ref.Name] = p.Version
+	}
+	return versions
+}
+
+// installedPackages returns which of pkgs are installed. It queries only
+// pkgs if the backend can, and otherwise lists everything installed. It
+// returns nil if the lookup fails or pkgs is empty.
+func (a *backendAdapter) installedPackages(ctx context.Context, pkgs []PackageRef) []types.InstalledPackage {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	var installed []types.InstalledPackage
+	var err error
+	if querier, ok := a.backend.(internalQuerier); ok {
+		refs := make([]types.PackageRef, len(pkgs))
+		for i, p := range pkgs {
+			refs[i] = types.PackageRef{Name: p.Name, Namespace: p.Namespace, Channel: p.Channel, Kind: p.Kind}
+		}
+		installed, err = querier.Query(ctx, refs, types.QueryOptions{})
+	} else {
+		installed, err = a.backend.ListInstalled(ctx, types.ListOptions{})
+	}
+	if err != nil {
+		return nil
+	}
+	return installed
+}
+
+// auditFinish completes and writes entry. It returns opErr joined with any
+// logging failure.
+func (a *backendAdapter) auditFinish(record auditRecord, changed []PackageRef, versions map[string]string, opErr error) error {
+	entry := record.entry
+	entry.Duration = time.Since(record.start)
+	for _, p := range changed {
+		entry.Changed = append(entry.Changed, AuditPackage{Ref: p, Version: versions[p.Name]})
+	}
+	entry.Outcome = AuditSuccess
+	if opErr != nil {
+		entry.Outcome = AuditFailure
+		entry.Error = opErr.Error()
+	}
+	if err := a.audit.Log(entry); err != nil {
+		return errors.Join(opErr, fmt.Errorf("audit log: %w", err))
+	}
+	return opErr
+}
@@ -0,0 +1,126 @@
+package pm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestWithAuditLog_RecordsInstall(t *testing.T) {
+	backend := &countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git"}, Version: "2.47.0"},
+	}}
+	var entries []AuditEntry
+	cfg := &backendConfig{}
+	WithAuditLog(AuditFunc(func(e AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	}))(cfg)
+	WithoutOperationLock()(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	if _, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Backend != BackendBrew || e.Operation != OperationInstall || e.Outcome != AuditSuccess {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+	if e.Time.IsZero() || e.UID != os.Getuid() {
+		t.Errorf("Expected who/when to be recorded, got %+v", e)
+	}
+	if len(e.Changed) != 1 || e.Changed[0].Version != "2.47.0" {
+		t.Errorf("Expected resolved version, got %+v", e.Changed)
+	}
+}
+
+func TestWithAuditLog_DurationIgnoresClock(t *testing.T) {
+	var entry AuditEntry
+	cfg := &backendConfig{}
+	WithAuditLog(AuditFunc(func(e AuditEntry) error {
+		entry = e
+		return nil
+	}))(cfg)
+	WithClock(func() time.Time { return time.Unix(0, 0) })(cfg)
+	WithoutOperationLock()(cfg)
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, cfg)
+
+	if _, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !entry.Time.Equal(time.Unix(0, 0)) {
+		t.Errorf("Time = %v, want the clock's", entry.Time)
+	}
+	if entry.Duration < 0 || entry.Duration > time.Minute {
+		t.Errorf("Duration = %v, want the time Install took", entry.Duration)
+	}
+}
+
+func TestWithAuditLog_RecordsFailureAndLogErrors(t *testing.T) {
+	backend := &countingBackend{installErr: errors.New("boom")}
+	logErr := errors.New("disk full")
+	var entry AuditEntry
+	cfg := &backendConfig{}
+	WithAuditLog(AuditFunc(func(e AuditEntry) error {
+		entry = e
+		return logErr
+	}))(cfg)
+	WithoutOperationLock()(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	_, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{})
+	if entry.Outcome != AuditFailure || entry.Error != "boom" {
+		t.Errorf("Expected failure entry, got %+v", entry)
+	}
+	if !errors.Is(err, logErr) {
+		t.Errorf("Expected audit log failure to be surfaced, got %v", err)
+	}
+}
+
+func TestJSONLinesAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log := NewJSONLinesAuditLog(path)
+	for _, op := range []Operation{OperationInstall, OperationUninstall} {
+		if err := log.Log(AuditEntry{Backend: BackendSnap, Operation: op, Outcome: AuditSuccess}); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var ops []Operation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		ops = append(ops, e.Operation)
+	}
+	if len(ops) != 2 || ops[0] != OperationInstall || ops[1] != OperationUninstall {
+		t.Errorf("Expected entries appended in order, got %v", ops)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,122 @@
+package pm
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultAvailabilityTTL is how long a backend's Available and Capabilities
+// results are reused when no TTL has been configured with
+// WithAvailabilityTTL.
+const DefaultAvailabilityTTL = 30 * time.Second
+
+// AvailabilityInvalidator is implemented by managers that reuse Available
+// and Capabilities results. The managers returned by the constructors implement it.
+type AvailabilityInvalidator interface {
+	// InvalidateAvailability forgets the cached results, so the next
+	// Available or Capabilities call probes the system again.
+	InvalidateAvailability()
+}
+
+// WithAvailabilityTTL sets how long the results of Available and
+// Capabilities (spawning a command or making an HTTP request) are reused
+// before the system is probed again. A TTL of zero or less disables reuse. Results of probes cut short
+// by a cancelled context are never reused.
+func WithAvailabilityTTL(ttl time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		config.availabilityTTL = &ttl
+	}
+}
+
+// probeCache holds the last result of probing the system, such as a
+// backend's Available or Capabilities.
+type probeCache[T any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	expires time.Time
+	value   T
+	err     error
+}
+
+func newProbeCache[T any](ttl *time.Duration) *probeCache[T] {
+	if ttl == nil {
+		return &probeCache[T]{ttl: DefaultAvailabilityTTL}
+	}
+	return &probeCache[T]{ttl: *ttl}
+}
+
+// get returns the cached result if it has not expired at now.
+func (c *probeCache[T]) get(now time.Time) (T, error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || !now.Before(c.expires) {
+		var zero T
+		return zero, nil, false
+	}
+	return c.value, c.err, true
+}
+
+// set caches a result obtained at now.
+func (c *probeCache[T]) set(now time.Time, value T, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return
+	}
+	c.expires = now.Add(c.ttl)
+	c.value, c.err = value, err
+}
+
+func (c *probeCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expires = time.Time{}
+}
+
+func (a *backendAdapter) Available(ctx context.Context) (bool, error) {
+	if available, err, ok := a.availability.get(a.now()); ok {
+		return available, err
+	}
+	ctx, cancel := a.operationContext(ctx, nil)
+	defer cancel()
+	available, err := a.backend.Available(ctx)
+	err = a.convertError(ctx, err)
+	if ctx.Err() == nil {
+		a.availability.set(a.now(), available, err)
+	}
+	return available, err
+}
+
+// InvalidateAvailability implements AvailabilityInvalidator. It also
+// forgets the cached Capabilities, which are probed alongside.
+func (a *backendAdapter) InvalidateAvailability() {
+	a.availability.invalidate()
+	a.capabilities.invalidate()
+}
+
+func (a *backendAdapter) Capabilities(ctx context.Context) ([]Capability, error) {
+	if caps, err, ok := a.capabilities.get(a.now()); ok {
+		return slices.Clone(caps), err
+	}
+	ctx, cancel := a.operationContext(ctx, nil)
+	defer cancel()
+	internalCaps, err := a.backend.Capabilities(ctx)
+	if err != nil {
+		return nil, a.convertError(ctx, err)
+	}
+	caps := make([]Capability, len(internalCaps))
+	for i, c := range internalCaps {
+		caps[i] = Capability{
+			Operation: Operation(c.Operation),
+			Supported: c.Supported,
+			Notes:     c.Notes,
+		}
+	}
+	if ctx.Err() == nil {
+		a.capabilities.set(a.now(), caps, nil)
+	}
+	return slices.Clone(caps), nil
+}
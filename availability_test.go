@@ -0,0 +1,141 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// probingBackend counts Available calls and returns a configurable result.
+type probingBackend struct {
+	countingBackend
+	probes int
+	err    error
+}
+
+func (b *probingBackend) Available(ctx context.Context) (bool, error) {
+	b.probes++
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return b.err == nil, b.err
+}
+
+func TestAvailabilityCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	backend := &probingBackend{}
+	cfg := newBackendConfig([]ConstructorOption{WithClock(func() time.Time { return now })})
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if ok, err := adapter.Available(ctx); !ok || err != nil {
+			t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+		}
+	}
+	if backend.probes != 1 {
+		t.Errorf("Probes = %d, want 1 within the TTL", backend.probes)
+	}
+
+	// A failed probe is reused too, until it expires.
+	backend.err = &types.NotAvailableError{Backend: "brew", Reason: "gone"}
+	now = now.Add(DefaultAvailabilityTTL)
+	if _, err := adapter.Available(ctx); !IsNotAvailable(err) {
+		t.Fatalf("Available() error = %v, want NotAvailableError", err)
+	}
+	backend.err = nil
+	if _, err := adapter.Available(ctx); !IsNotAvailable(err) {
+		t.Errorf("Available() error = %v, want the cached NotAvailableError", err)
+	}
+	if backend.probes != 2 {
+		t.Errorf("Probes = %d, want 2", backend.probes)
+	}
+
+	adapter.InvalidateAvailability()
+	if ok, err := adapter.Available(ctx); !ok || err != nil {
+		t.Errorf("Available() after invalidation = %v, %v; want true, nil", ok, err)
+	}
+	if backend.probes != 3 {
+		t.Errorf("Probes = %d, want 3", backend.probes)
+	}
+}
+
+func TestAvailabilityCache_Disabled(t *testing.T) {
+	backend := &probingBackend{}
+	adapter := newBackendAdapter(BackendBrew, backend, newBackendConfig([]ConstructorOption{WithAvailabilityTTL(0)}))
+
+	for i := 0; i < 2; i++ {
+		_, _ = adapter.Available(context.Background())
+	}
+	if backend.probes != 2 {
+		t.Errorf("Probes = %d, want 2 with caching disabled", backend.probes)
+	}
+}
+
+func TestAvailabilityCache_IgnoresCancelledProbes(t *testing.T) {
+	backend := &probingBackend{}
+	adapter := newBackendAdapter(BackendBrew, backend, newBackendConfig(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := adapter.Available(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Available() error = %v, want context.Canceled", err)
+	}
+	if ok, err := adapter.Available(context.Background()); !ok || err != nil {
+		t.Errorf("Available() = %v, %v; want a fresh probe", ok, err)
+	}
+}
+
+func TestMultiManager_InvalidateAvailability(t *testing.T) {
+	backend := &probingBackend{}
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, newBackendConfig(nil)))
+	multi := NewMultiManager(Backend{Kind: BackendBrew, Manager: mgr})
+	ctx := context.Background()
+
+	_, _ = multi.Available(ctx)
+	multi.InvalidateAvailability()
+	_, _ = multi.Available(ctx)
+	if backend.probes != 2 {
+		t.Errorf("Probes = %d, want 2 after invalidation through the wrapper", backend.probes)
+	}
+}
+
+// capsBackend counts Capabilities calls.
+type capsBackend struct {
+	countingBackend
+	probes int
+}
+
+func (b *capsBackend) Capabilities(ctx context.Context) ([]types.Capability, error) {
+	b.probes++
+	return []types.Capability{{Operation: types.OperationInstall, Supported: true, Notes: "probed"}}, nil
+}
+
+func TestCapabilitiesCache(t *testing.T) {
+	now := time.Unix(0, 0)
+	backend := &capsBackend{}
+	cfg := newBackendConfig([]ConstructorOption{WithClock(func() time.Time { return now })})
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+	ctx := context.Background()
+
+	caps, _ := adapter.Capabilities(ctx)
+	caps[0].Supported = false // callers may modify their copy
+	caps, err := adapter.Capabilities(ctx)
+	if err != nil || !Supports(caps, OperationInstall) {
+		t.Fatalf("Capabilities() = %v, %v; want Install supported", caps, err)
+	}
+	if backend.probes != 1 {
+		t.Errorf("Probes = %d, want 1 within the TTL", backend.probes)
+	}
+
+	adapter.InvalidateAvailability()
+	_, _ = adapter.Capabilities(ctx)
+	now = now.Add(DefaultAvailabilityTTL)
+	_, _ = adapter.Capabilities(ctx)
+	if backend.probes != 3 {
+		t.Errorf("Probes = %d, want 3 after invalidation and expiry", backend.probes)
+	}
+}
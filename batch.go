@@ -0,0 +1,190 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// installEach installs pkgs one at a time so that a failure does not stop
+// the remaining packages. It returns the merged result, the packages that
+// failed, and their errors joined, each wrapped in a *PackageError. A
+// package that turns out to be installed already is skipped, not failed.
+func (a *backendAdapter) installEach(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, []PackageRef, error) {
+	var merged types.InstallResult
+	var failed []PackageRef
+	var errs []error
+	for _, pkg := range pkgs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		res, err := a.backend.Install(ctx, []types.PackageRef{pkg}, opts)
+		merged.Changed = merged.Changed || res.Changed
+		merged.PackagesInstalled = append(merged.PackagesInstalled, res.PackagesInstalled...)
+		merged.Skipped = append(merged.Skipped, res.Skipped...)
+		merged.Messages = append(merged.Messages, res.Messages...)
+		switch {
+		case err == nil:
+		case len(res.Skipped) > 0:
+			// The backend reported the package as skipped; err says so.
+		case types.IsAlreadyInstalled(err):
+			merged.Skipped = append(merged.Skipped, err)
+		default:
+			failed = append(failed, convertPackageRef(pkg))
+			errs = append(errs, a.packageError(ctx, pkg, err))
+		}
+	}
+	return merged, failed, errors.Join(errs...)
+}
+
+// uninstallEach is the Uninstall counterpart of installEach. A package that
+// turns out not to be installed is skipped, not failed.
+func (a *backendAdapter) uninstallEach(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, []PackageRef, error) {
+	var merged types.UninstallResult
+	var failed []PackageRef
+	var errs []error
+	for _, pkg := range pkgs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		res, err := a.backend.Uninstall(ctx, []types.PackageRef{pkg}, opts)
+		merged.Changed = merged.Changed || res.Changed
+		merged.PackagesUninstalled = append(merged.PackagesUninstalled, res.PackagesUninstalled...)
+		merged.Skipped = append(merged.Skipped, res.Skipped...)
+		merged.Messages = append(merged.Messages, res.Messages...)
+		switch {
+		case err == nil:
+		case len(res.Skipped) > 0:
+			// The backend reported the package as skipped; err says so.
+		case types.IsNotInstalled(err):
+			merged.Skipped = append(merged.Skipped, err)
+		default:
+			failed = append(failed, convertPackageRef(pkg))
+			errs = append(errs, a.packageError(ctx, pkg, err))
+		}
+	}
+	return merged, failed, errors.Join(errs...)
+}
+
+// packageError converts err and attributes it to pkg.
+func (a *backendAdapter) packageError(ctx context.Context, pkg types.PackageRef, err error) error {
+	return &PackageError{
+		Ref:     convertPackageRef(pkg),
+		Backend: string(a.kind),
+		Err:     a.convertError(ctx, err),
+	}
+}
+
+// DefaultBatchSize is how many packages Install and Uninstall pass to one
+// backend command when no size has been configured with WithBatchSize.
+const DefaultBatchSize = 100
+
+// WithBatchSize sets how many packages Install and Uninstall pass to one
+// backend command. Longer lists are split into batches of at most n
+// packages, run one after another, so hundreds of packages stay within
+// argument-length limits. Each batch reports its own progress action, named
+// with a "(batch i/n)" suffix, and the results are merged. A size of zero
+// or less never splits.
+func WithBatchSize(n int) ConstructorOption {
+	return func(config *backendConfig) {
+		config.batchSize = &n
+	}
+}
+
+// batches splits pkgs into slices of at most size packages. A size of zero
+// or less yields pkgs as a single batch.
+func batches(pkgs []types.PackageRef, size int) [][]types.PackageRef {
+	if size <= 0 || len(pkgs) <= size {
+		return [][]types.PackageRef{pkgs}
+	}
+	var out [][]types.PackageRef
+	for len(pkgs) > size {
+		out = append(out, pkgs[:size:size])
+		pkgs = pkgs[size:]
+	}
+	return append(out, pkgs)
+}
+
+// installBatches installs pkgs in batches of at most a.batchSize packages.
+// Like a single command, it stops at the first batch that fails; the result
+// merges the batches that ran.
+func (a *backendAdapter) installBatches(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	chunks := batches(pkgs, a.batchSize)
+	if len(chunks) == 1 {
+		res, err := a.backend.Install(ctx, pkgs, opts)
+		return res, a.convertError(ctx, err)
+	}
+	var merged types.InstallResult
+	for i, chunk := range chunks {
+		batchOpts := opts
+		batchOpts.Progress = batchReporter(opts.Progress, i, len(chunks))
+		res, err := a.backend.Install(ctx, chunk, batchOpts)
+		merged.Changed = merged.Changed || res.Changed
+		merged.PackagesInstalled = append(merged.PackagesInstalled, res.PackagesInstalled...)
+		merged.Skipped = append(merged.Skipped, res.Skipped...)
+		merged.Messages = append(merged.Messages, res.Messages...)
+		if err != nil {
+			return merged, a.convertError(ctx, err)
+		}
+	}
+	return merged, nil
+}
+
+// uninstallBatches is the Uninstall counterpart of installBatches.
+func (a *backendAdapter) uninstallBatches(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error) {
+	chunks := batches(pkgs, a.batchSize)
+	if len(chunks) == 1 {
+		res, err := a.backend.Uninstall(ctx, pkgs, opts)
+		return res, a.convertError(ctx, err)
+	}
+	var merged types.UninstallResult
+	for i, chunk := range chunks {
+		batchOpts := opts
+		batchOpts.Progress = batchReporter(opts.Progress, i, len(chunks))
+		res, err := a.backend.Uninstall(ctx, chunk, batchOpts)
+		merged.Changed = merged.Changed || res.Changed
+		merged.PackagesUninstalled = append(merged.PackagesUninstalled, res.PackagesUninstalled...)
+		merged.Skipped = append(merged.Skipped, res.Skipped...)
+		merged.Messages = append(merged.Messages, res.Messages...)
+		if err != nil {
+			return merged, a.convertError(ctx, err)
+		}
+	}
+	return merged, nil
+}
+
+// batchReporter returns a reporter that names the actions of batch i of n
+// "<name> (batch i+1/n)" before passing them to pr.
+func batchReporter(pr types.ProgressReporter, i, n int) types.ProgressReporter {
+	if pr == nil {
+		return nil
+	}
+	return &batchLabeler{pr: pr, suffix: fmt.Sprintf(" (batch %d/%d)", i+1, n)}
+}
+
+type batchLabeler struct {
+	pr     types.ProgressReporter
+	suffix string
+}
+
+func (b *batchLabeler) OnAction(action types.ProgressAction) {
+	action.Name += b.suffix
+	b.pr.OnAction(action)
+}
+
+func (b *batchLabeler) OnTask(task types.ProgressTask)      { b.pr.OnTask(task) }
+func (b *batchLabeler) OnStep(step types.ProgressStep)      { b.pr.OnStep(step) }
+func (b *batchLabeler) OnMessage(msg types.ProgressMessage) { b.pr.OnMessage(msg) }
+
+// batchSizeOrDefault returns the size set with WithBatchSize, or
+// DefaultBatchSize.
+func (c *backendConfig) batchSizeOrDefault() int {
+	if c.batchSize == nil {
+		return DefaultBatchSize
+	}
+	return *c.batchSize
+}
@@ -0,0 +1,200 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// batchBackend fails Install and Uninstall for the packages in errs.
+type batchBackend struct {
+	countingBackend
+	errs  map[string]error
+	calls int
+}
+
+func (b *batchBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	b.calls++
+	for _, p := range pkgs {
+		if err := b.errs[p.Name]; err != nil {
+			return types.InstallResult{}, err
+		}
+	}
+	return types.InstallResult{Changed: true, PackagesInstalled: pkgs}, nil
+}
+
+func (b *batchBackend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error) {
+	b.calls++
+	for _, p := range pkgs {
+		if err := b.errs[p.Name]; err != nil {
+			return types.UninstallResult{}, err
+		}
+	}
+	return types.UninstallResult{Changed: true, PackagesUninstalled: pkgs}, nil
+}
+
+func newBatchAdapter(backend *batchBackend) *backendAdapter {
+	cfg := &backendConfig{}
+	WithoutOperationLock()(cfg)
+	return newBackendAdapter(BackendBrew, backend, cfg)
+}
+
+func TestInstall_ContinueOnError(t *testing.T) {
+	backend := &batchBackend{errs: map[string]error{
+		"nope":  &types.PackageNotFoundError{Ref: types.PackageRef{Name: "nope"}, Backend: "brew"},
+		"git":   &types.AlreadyInstalledError{Ref: types.PackageRef{Name: "git"}, Backend: "brew"},
+		"wedge": &types.ExternalFailureError{Operation: types.OperationInstall, Backend: "brew", Stderr: "Error: boom"},
+	}}
+	pkgs := []PackageRef{{Name: "jq"}, {Name: "nope"}, {Name: "git"}, {Name: "wedge"}, {Name: "ripgrep"}}
+
+	res, err := newBatchAdapter(backend).Install(context.Background(), pkgs, InstallOptions{ContinueOnError: true})
+	if backend.calls != len(pkgs) {
+		t.Errorf("Expected one call per package, got %d", backend.calls)
+	}
+	if !res.Changed || len(res.PackagesInstalled) != 2 || res.PackagesInstalled[1].Name != "ripgrep" {
+		t.Errorf("Unexpected installed packages: %+v", res.PackagesInstalled)
+	}
+	if len(res.Skipped) != 1 || !IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Expected git to be skipped, got %v", res.Skipped)
+	}
+	if len(res.Failed) != 2 || res.Failed[0].Name != "nope" || res.Failed[1].Name != "wedge" {
+		t.Errorf("Unexpected failed packages: %+v", res.Failed)
+	}
+
+	var notFound *PackageNotFoundError
+	if !errors.As(err, &notFound) || notFound.Ref.Name != "nope" {
+		t.Errorf("Expected the joined error to hold the not-found error, got %v", err)
+	}
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Stderr != "Error: boom" {
+		t.Errorf("Expected the joined error to hold the external failure, got %v", err)
+	}
+	var pkgErr *PackageError
+	if !errors.As(err, &pkgErr) || pkgErr.Backend != "brew" {
+		t.Errorf("Expected failures to be attributed to packages, got %v", err)
+	}
+}
+
+func TestInstall_StopsAtFirstErrorByDefault(t *testing.T) {
+	backend := &batchBackend{errs: map[string]error{"nope": errors.New("boom")}}
+	res, err := newBatchAdapter(backend).Install(context.Background(), []PackageRef{{Name: "nope"}, {Name: "jq"}}, InstallOptions{})
+	if err == nil || backend.calls != 1 || len(res.Failed) != 0 {
+		t.Errorf("Expected a single failing batch call, got %d calls, err %v, failed %v", backend.calls, err, res.Failed)
+	}
+}
+
+func TestUninstall_ContinueOnError(t *testing.T) {
+	backend := &batchBackend{errs: map[string]error{
+		"gone":  &types.NotInstalledError{Operation: types.OperationUninstall, Ref: types.PackageRef{Name: "gone"}, Backend: "brew"},
+		"stuck": errors.New("boom"),
+	}}
+	pkgs := []PackageRef{{Name: "gone"}, {Name: "stuck"}, {Name: "jq"}}
+
+	res, err := newBatchAdapter(backend).Uninstall(context.Background(), pkgs, UninstallOptions{ContinueOnError: true})
+	if len(res.PackagesUninstalled) != 1 || len(res.Skipped) != 1 || len(res.Failed) != 1 || res.Failed[0].Name != "stuck" {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if err == nil || IsNotInstalled(err) {
+		t.Errorf("Expected only the real failure to be returned, got %v", err)
+	}
+}
+
+func TestInstall_ContinueOnErrorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	backend := &batchBackend{}
+	_, err := newBatchAdapter(backend).Install(ctx, []PackageRef{{Name: "jq"}, {Name: "git"}}, InstallOptions{ContinueOnError: true})
+	if !errors.Is(err, context.Canceled) || backend.calls != 0 {
+		t.Errorf("Expected cancellation before any call, got %d calls, err %v", backend.calls, err)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	pkgs := []types.PackageRef{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{0, []int{5}},
+		{-1, []int{5}},
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{10, []int{5}},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, b := range batches(pkgs, tt.size) {
+			got = append(got, len(b))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("batches(size %d) sizes = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestInstall_Batches(t *testing.T) {
+	backend := &batchBackend{}
+	cfg := &backendConfig{}
+	WithoutOperationLock()(cfg)
+	WithBatchSize(2)(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+	pkgs := []PackageRef{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+
+	res, err := adapter.Install(context.Background(), pkgs, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if backend.calls != 3 {
+		t.Errorf("Backend calls = %d, want 3", backend.calls)
+	}
+	if !res.Changed || len(res.PackagesInstalled) != 5 {
+		t.Errorf("Install() = %+v, want all 5 packages installed", res)
+	}
+
+	// A failing batch stops the remaining ones, as a single command would.
+	backend = &batchBackend{errs: map[string]error{"c": &types.PackageNotFoundError{Ref: types.PackageRef{Name: "c"}, Backend: "brew"}}}
+	adapter = newBackendAdapter(BackendBrew, backend, cfg)
+	ures, err := adapter.Uninstall(context.Background(), pkgs, UninstallOptions{})
+	if !IsPackageNotFound(err) {
+		t.Fatalf("Uninstall() error = %v, want PackageNotFoundError", err)
+	}
+	if backend.calls != 2 || len(ures.PackagesUninstalled) != 2 {
+		t.Errorf("Uninstall() ran %d batches and removed %v, want 2 batches and [a b]", backend.calls, ures.PackagesUninstalled)
+	}
+}
+
+func TestInstall_BatchProgress(t *testing.T) {
+	var installs [][]string
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		switch {
+		case len(args) > 0 && args[0] == "install":
+			installs = append(installs, args)
+		case len(args) > 0 && args[0] == "info":
+			return "", "error: " + args[len(args)-1] + " not installed", errors.New("exit status 1")
+		}
+		return "", "", nil
+	})
+	rec := &actionRecorder{}
+	mgr := NewFlatpak(WithRunner(fake), WithBatchSize(2), WithProgress(rec), WithoutOperationLock())
+	pkgs := []PackageRef{{Name: "org.example.A"}, {Name: "org.example.B"}, {Name: "org.example.C"}}
+
+	if _, err := mgr.(Installer).Install(context.Background(), pkgs, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(installs) != 2 {
+		t.Fatalf("flatpak install ran %d times (%q), want 2", len(installs), installs)
+	}
+	var names []string
+	for _, a := range rec.actions {
+		if a.EndedAt.IsZero() {
+			names = append(names, a.Name)
+		}
+	}
+	want := []string{"Query (batch 1/2)", "Install (batch 1/2)", "Query (batch 2/2)", "Install (batch 2/2)"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Actions = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,70 @@
+package pm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// WithBrewPath runs the brew executable at path instead of looking it up.
+func WithBrewPath(path string) ConstructorOption {
+	return withBinaryPath(BackendBrew, path)
+}
+
+// WithFlatpakPath runs the flatpak executable at path instead of looking it
+// up.
+func WithFlatpakPath(path string) ConstructorOption {
+	return withBinaryPath(BackendFlatpak, path)
+}
+
+// WithSnapPath runs the snap executable at path instead of looking it up.
+func WithSnapPath(path string) ConstructorOption {
+	return withBinaryPath(BackendSnap, path)
+}
+
+// withBinaryPath overrides the executable of kind's command-line tool. An
+// empty path runs the tool by name from PATH, without discovery.
+func withBinaryPath(kind BackendKind, path string) ConstructorOption {
+	return func(config *backendConfig) {
+		if config.binaryPaths == nil {
+			config.binaryPaths = make(map[BackendKind]string)
+		}
+		config.binaryPaths[kind] = path
+	}
+}
+
+// binaryFallbacks lists where each backend's tool is commonly installed,
+// for services (systemd units, launchd daemons) whose PATH lacks it.
+var binaryFallbacks = map[BackendKind][]string{
+	BackendBrew: {
+		"/opt/homebrew/bin/brew",
+		"/usr/local/bin/brew",
+		"/home/linuxbrew/.linuxbrew/bin/brew",
+	},
+	BackendFlatpak: {"/usr/bin/flatpak", "/usr/local/bin/flatpak"},
+	BackendSnap:    {"/usr/bin/snap", "/snap/bin/snap"},
+}
+
+// lookPath is replaced in tests.
+var lookPath = exec.LookPath
+
+// discoverBinary finds kind's tool when it is not on PATH. It returns ""
+// when the tool is on PATH, so it keeps running by name, or when none of
+// the fallback locations has it.
+func discoverBinary(kind BackendKind) string {
+	if _, err := lookPath(string(kind)); err == nil {
+		return ""
+	}
+	candidates := binaryFallbacks[kind]
+	if kind == BackendBrew {
+		if home, err := os.UserHomeDir(); err == nil {
+			candidates = append(candidates[:len(candidates):len(candidates)], filepath.Join(home, ".linuxbrew", "bin", "brew"))
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0 {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithFlatpakPath(t *testing.T) {
+	var ran string
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		ran = name
+		return "Flatpak 1.14.4", "", nil
+	})
+
+	mgr := NewFlatpak(WithRunner(fake), WithFlatpakPath("/opt/flatpak/bin/flatpak"), WithoutOperationLock())
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+	if ran != "/opt/flatpak/bin/flatpak" {
+		t.Errorf("Ran %q, want the overridden path", ran)
+	}
+}
+
+func TestDiscoverBinary(t *testing.T) {
+	dir := t.TempDir()
+	flatpak := filepath.Join(dir, "flatpak")
+	if err := os.WriteFile(flatpak, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	notExecutable := filepath.Join(dir, "snap")
+	if err := os.WriteFile(notExecutable, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldLookPath, oldFallbacks := lookPath, binaryFallbacks
+	defer func() { lookPath, binaryFallbacks = oldLookPath, oldFallbacks }()
+	binaryFallbacks = map[BackendKind][]string{
+		BackendFlatpak: {filepath.Join(dir, "missing"), flatpak},
+		BackendSnap:    {notExecutable},
+	}
+
+	onPath := false
+	lookPath = func(name string) (string, error) {
+		if onPath {
+			return "/usr/bin/" + name, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	if got := discoverBinary(BackendFlatpak); got != flatpak {
+		t.Errorf("discoverBinary(flatpak) = %q, want %q", got, flatpak)
+	}
+	if got := discoverBinary(BackendSnap); got != "" {
+		t.Errorf("discoverBinary(snap) = %q, want \"\" for a non-executable file", got)
+	}
+	onPath = true
+	if got := discoverBinary(BackendFlatpak); got != "" {
+		t.Errorf("discoverBinary(flatpak) = %q, want \"\" when on PATH", got)
+	}
+}
@@ -0,0 +1,47 @@
+package pm
+
+// BrewEnv controls the environment brew commands run with. Its zero value
+// gives the fast, quiet and deterministic behavior CI wants: brew neither
+// updates itself, sends analytics nor prints hints.
+type BrewEnv struct {
+	// AutoUpdate lets brew update itself and its taps before commands such
+	// as install (unsets HOMEBREW_NO_AUTO_UPDATE). Update always runs
+	// `brew update`.
+	AutoUpdate bool
+
+	// Analytics lets brew send analytics (unsets HOMEBREW_NO_ANALYTICS).
+	Analytics bool
+
+	// EnvHints lets brew print hints about the HOMEBREW_* variables
+	// (unsets HOMEBREW_NO_ENV_HINTS).
+	EnvHints bool
+
+	// Env lists further KEY=VALUE entries, such as
+	// HOMEBREW_GITHUB_API_TOKEN=..., added after the variables above, so
+	// they take precedence.
+	Env []string
+}
+
+// vars returns the KEY=VALUE entries for env.
+func (env BrewEnv) vars() []string {
+	vars := []string{}
+	if !env.AutoUpdate {
+		vars = append(vars, "HOMEBREW_NO_AUTO_UPDATE=1")
+	}
+	if !env.Analytics {
+		vars = append(vars, "HOMEBREW_NO_ANALYTICS=1")
+	}
+	if !env.EnvHints {
+		vars = append(vars, "HOMEBREW_NO_ENV_HINTS=1")
+	}
+	return append(vars, env.Env...)
+}
+
+// WithBrewEnv sets the environment brew commands run with, on top of the
+// inherited one, instead of the default, which disables auto-updates and
+// hints but leaves analytics to the machine's configuration.
+func WithBrewEnv(env BrewEnv) ConstructorOption {
+	return func(config *backendConfig) {
+		config.brewEnv = &env
+	}
+}
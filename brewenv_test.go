@@ -0,0 +1,45 @@
+package pm
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestWithBrewEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ConstructorOption
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{"HOMEBREW_NO_AUTO_UPDATE=1", "HOMEBREW_NO_ENV_HINTS=1"},
+		},
+		{
+			name: "quiet",
+			opts: []ConstructorOption{WithBrewEnv(BrewEnv{Env: []string{"HOMEBREW_CURL_RETRIES=5"}})},
+			want: []string{"HOMEBREW_NO_AUTO_UPDATE=1", "HOMEBREW_NO_ANALYTICS=1", "HOMEBREW_NO_ENV_HINTS=1", "HOMEBREW_CURL_RETRIES=5"},
+		},
+		{
+			name: "auto-update",
+			opts: []ConstructorOption{WithBrewEnv(BrewEnv{AutoUpdate: true, Analytics: true})},
+			want: []string{"HOMEBREW_NO_ENV_HINTS=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env []string
+			opts := append(tt.opts, WithLocale(""), WithRunner(RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+				env = RunnerInvocation(ctx).Env
+				return "", "", nil
+			})))
+			if _, err := Must[Lister](NewBrew(opts...)).ListInstalled(context.Background(), ListOptions{}); err != nil {
+				t.Fatalf("ListInstalled() error = %v", err)
+			}
+			if !slices.Equal(env, tt.want) {
+				t.Errorf("brew ran with env %q, want %q", env, tt.want)
+			}
+		})
+	}
+}
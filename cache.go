@@ -0,0 +1,218 @@
+package pm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSearchCacheTTL is how long Search results are cached when no
+// per-operation TTL has been configured with WithCacheTTL.
+const DefaultSearchCacheTTL = 15 * time.Minute
+
+// Cache stores serialized results of read operations.
+//
+// Keys are namespaced by backend ("brew/search/git"), so a backend's entries
+// can be dropped with a single Invalidate call after it mutates the system.
+//
+// Implementations MUST be safe for concurrent use.
+type Cache interface {
+	// Get returns the value for key if present and not expired.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key for the given duration.
+	Set(key string, value []byte, ttl time.Duration)
+
+	// Invalidate removes every entry whose key starts with prefix.
+	Invalidate(prefix string)
+}
+
+// memoryCache is an in-process Cache.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewMemoryCache creates a Cache that holds entries in memory.
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.Expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.Value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.setUntil(key, value, time.Now().Add(ttl))
+}
+
+func (c *memoryCache) setUntil(key string, value []byte, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{Key: key, Value: value, Expires: expires}
+}
+
+func (c *memoryCache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// diskCache is a Cache that persists entries as files in a directory.
+type diskCache struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewDiskCache creates a Cache that persists entries under dir, so results
+// survive across processes. The directory is created on first write.
+func NewDiskCache(dir string) Cache {
+	return &diskCache{dir: dir}
+}
+
+func (c *diskCache) Get(key string) ([]byte, bool) {
+	e, ok := c.lookup(key)
+	return e.Value, ok
+}
+
+// lookup returns the full entry for key, including its expiry.
+func (c *diskCache) lookup(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path := c.path(key)
+	e, err := readCacheEntry(path)
+	if err != nil || e.Key != key {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(e.Expires) {
+		_ = os.Remove(path)
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (c *diskCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.Marshal(cacheEntry{Key: key, Value: value, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	// Write to a temp file and rename so readers never see a partial entry.
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (c *diskCache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, path := range matches {
+		e, err := readCacheEntry(path)
+		if err != nil || strings.HasPrefix(e.Key, prefix) {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+func (c *diskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func readCacheEntry(path string) (cacheEntry, error) {
+	var e cacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return e, err
+	}
+	err = json.Unmarshal(data, &e)
+	return e, err
+}
+
+// tieredCache checks memory before disk and copies disk hits into memory.
+type tieredCache struct {
+	fast *memoryCache
+	slow *diskCache
+}
+
+// NewDefaultCache creates an in-memory cache backed by a disk cache in dir.
+// If dir is empty, the user cache directory (e.g. ~/.cache/pm) is used; if
+// that cannot be determined, the cache is memory-only.
+func NewDefaultCache(dir string) Cache {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return NewMemoryCache()
+		}
+		dir = filepath.Join(base, "pm")
+	}
+	return &tieredCache{
+		fast: &memoryCache{entries: make(map[string]cacheEntry)},
+		slow: &diskCache{dir: dir},
+	}
+}
+
+func (c *tieredCache) Get(key string) ([]byte, bool) {
+	if v, ok := c.fast.Get(key); ok {
+		return v, true
+	}
+	e, ok := c.slow.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	c.fast.setUntil(key, e.Value, e.Expires)
+	return e.Value, true
+}
+
+func (c *tieredCache) Set(key string, value []byte, ttl time.Duration) {
+	c.fast.Set(key, value, ttl)
+	c.slow.Set(key, value, ttl)
+}
+
+func (c *tieredCache) Invalidate(prefix string) {
+	c.fast.Invalidate(prefix)
+	c.slow.Invalidate(prefix)
+}
@@ -0,0 +1,162 @@
+package pm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// countingBackend is an internalBackend that records how often it is called.
+type countingBackend struct {
+	searchCalls int
+	results     []types.PackageRef
+	installed   []types.InstalledPackage
+	installErr  error
+}
+
+func (b *countingBackend) Available(ctx context.Context) (bool, error) { return true, nil }
+func (b *countingBackend) Capabilities(ctx context.Context) ([]types.Capability, error) {
+	return nil, nil
+}
+func (b *countingBackend) Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error) {
+	return types.UpdateResult{}, nil
+}
+func (b *countingBackend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error) {
+	return types.UpgradeResult{}, nil
+}
+func (b *countingBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	if b.installErr != nil {
+		return types.InstallResult{}, b.installErr
+	}
+	return types.InstallResult{Changed: true, PackagesInstalled: pkgs}, nil
+}
+func (b *countingBackend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error) {
+	return types.UninstallResult{}, nil
+}
+func (b *countingBackend) Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error) {
+	b.searchCalls++
+	return b.results, nil
+}
+func (b *countingBackend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
+	return b.installed, nil
+}
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache()
+
+	c.Set("brew/Search/git", []byte("a"), time.Hour)
+	c.Set("snap/Search/git", []byte("b"), time.Hour)
+	c.Set("brew/Search/old", []byte("c"), -time.Second)
+
+	if v, ok := c.Get("brew/Search/git"); !ok || string(v) != "a" {
+		t.Errorf("Get() = %q, %v; want \"a\", true", v, ok)
+	}
+	if _, ok := c.Get("brew/Search/old"); ok {
+		t.Error("Expired entry should not be returned")
+	}
+
+	c.Invalidate("brew/")
+	if _, ok := c.Get("brew/Search/git"); ok {
+		t.Error("Invalidated entry should not be returned")
+	}
+	if _, ok := c.Get("snap/Search/git"); !ok {
+		t.Error("Entry for another backend should survive invalidation")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+
+	NewDiskCache(dir).Set("flatpak/Search/gimp", []byte("cached"), time.Hour)
+
+	// A fresh instance over the same directory sees the entry.
+	c := NewDiskCache(dir)
+	if v, ok := c.Get("flatpak/Search/gimp"); !ok || string(v) != "cached" {
+		t.Errorf("Get() = %q, %v; want \"cached\", true", v, ok)
+	}
+
+	c.Invalidate("flatpak/")
+	if _, ok := c.Get("flatpak/Search/gimp"); ok {
+		t.Error("Invalidated entry should not be returned")
+	}
+}
+
+func TestDefaultCache_FillsMemoryFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	NewDiskCache(dir).Set("brew/Search/wget", []byte("disk"), time.Hour)
+
+	c := NewDefaultCache(dir)
+	if v, ok := c.Get("brew/Search/wget"); !ok || string(v) != "disk" {
+		t.Fatalf("Get() = %q, %v; want \"disk\", true", v, ok)
+	}
+	if _, ok := c.(*tieredCache).fast.Get("brew/Search/wget"); !ok {
+		t.Error("Disk hit should populate the memory tier")
+	}
+}
+
+func TestBackendAdapter_SearchCache(t *testing.T) {
+	backend := &countingBackend{results: []types.PackageRef{{Name: "git", Kind: "formula"}}}
+	cfg := &backendConfig{}
+	WithCache(NewMemoryCache())(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := adapter.Search(ctx, "git", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		if len(res) != 1 || res[0].Name != "git" {
+			t.Fatalf("Unexpected results %+v", res)
+		}
+	}
+	if backend.searchCalls != 1 {
+		t.Errorf("Expected 1 backend search, got %d", backend.searchCalls)
+	}
+
+	// A mutating operation invalidates cached results for the backend.
+	if _, err := adapter.Install(ctx, []PackageRef{{Name: "git"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if _, err := adapter.Search(ctx, "git", SearchOptions{}); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if backend.searchCalls != 2 {
+		t.Errorf("Expected cache miss after Install, got %d backend searches", backend.searchCalls)
+	}
+}
+
+func TestBackendAdapter_SearchLimit(t *testing.T) {
+	backend := &countingBackend{results: []types.PackageRef{{Name: "git"}, {Name: "git-lfs"}, {Name: "lazygit"}}}
+	cfg := &backendConfig{}
+	WithCache(NewMemoryCache())(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+	ctx := context.Background()
+
+	if res, err := adapter.Search(ctx, "git", SearchOptions{Limit: 2}); err != nil || len(res) != 2 {
+		t.Fatalf("Search(Limit: 2) = %v, %v; want 2 results", res, err)
+	}
+	// The cache holds the full result, so a later unlimited search is not cut short.
+	if res, err := adapter.Search(ctx, "git", SearchOptions{}); err != nil || len(res) != 3 {
+		t.Errorf("Search() = %v, %v; want 3 results", res, err)
+	}
+	if backend.searchCalls != 1 {
+		t.Errorf("Expected 1 backend search, got %d", backend.searchCalls)
+	}
+}
+
+func TestBackendAdapter_CacheTTLDisabled(t *testing.T) {
+	backend := &countingBackend{}
+	cfg := &backendConfig{}
+	WithCache(NewMemoryCache())(cfg)
+	WithCacheTTL(OperationSearch, 0)(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	_, _ = adapter.Search(context.Background(), "git", SearchOptions{})
+	_, _ = adapter.Search(context.Background(), "git", SearchOptions{})
+	if backend.searchCalls != 2 {
+		t.Errorf("Expected caching disabled, got %d backend searches", backend.searchCalls)
+	}
+}
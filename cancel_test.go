@@ -0,0 +1,60 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// cancelButtonReporter presses Cancel as soon as a task starts.
+type cancelButtonReporter struct {
+	*CancelSignal
+}
+
+func (r *cancelButtonReporter) OnAction(ProgressAction) {}
+func (r *cancelButtonReporter) OnTask(task ProgressTask) {
+	if task.EndedAt.IsZero() {
+		r.Cancel()
+	}
+}
+func (r *cancelButtonReporter) OnStep(ProgressStep)       {}
+func (r *cancelButtonReporter) OnMessage(ProgressMessage) {}
+
+func TestCancelRequester(t *testing.T) {
+	blocking := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-time.After(5 * time.Second):
+			return "", "", nil
+		}
+	})
+	mgr := NewFlatpak(WithRunner(blocking), WithoutOperationLock())
+
+	reporter := &cancelButtonReporter{CancelSignal: NewCancelSignal()}
+	start := time.Now()
+	_, err := mgr.(Installer).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{Progress: reporter})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Install() error = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Install() was not cancelled promptly")
+	}
+}
+
+func TestCancelRequester_ConstructionReporter(t *testing.T) {
+	reporter := &cancelButtonReporter{CancelSignal: NewCancelSignal()}
+	reporter.Cancel()
+
+	a := &backendAdapter{progress: reporter}
+	ctx, cancel := a.operationContext(context.Background(), nil)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the construction-time reporter's request to cancel the operation")
+	}
+}
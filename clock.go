@@ -0,0 +1,147 @@
+package pm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/frostyard/pm/progress"
+)
+
+// WithClock sets the clock progress events and audit entries are stamped
+// with, instead of time.Now. Together with WithIDGenerator it makes
+// progress streams and audit logs deterministic, so tests can compare them
+// with snapshots:
+//
+//	mgr := pm.NewFlatpak(
+//		pm.WithClock(pm.StepClock(time.Unix(0, 0), time.Second)),
+//		pm.WithIDGenerator(pm.SequentialIDs("flatpak")),
+//	)
+//
+// Command timeouts and durations reported outside progress events still use
+// the real clock.
+func WithClock(now func() time.Time) ConstructorOption {
+	return func(config *backendConfig) {
+		config.clock = now
+	}
+}
+
+// WithIDGenerator sets the function action, task and step IDs in progress
+// events are taken from, instead of random UUIDs. The generator must return
+// unique IDs.
+func WithIDGenerator(newID func() string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.newID = newID
+	}
+}
+
+// SequentialIDs returns an ID generator yielding prefix-1, prefix-2, and so
+// on. It is safe for concurrent use.
+func SequentialIDs(prefix string) func() string {
+	return progress.SequentialIDs(prefix)
+}
+
+// StepClock returns a clock that reads start on its first call and
+// advances by step on every later one. It is safe for concurrent use.
+func StepClock(start time.Time, step time.Duration) func() time.Time {
+	return progress.StepClock(start, step)
+}
+
+// now returns the current time from the configured clock.
+func (a *backendAdapter) now() time.Time {
+	if a.clock != nil {
+		return a.clock()
+	}
+	return time.Now()
+}
+
+// stamping returns a reporter that restamps the events of one operation
+// with the adapter's clock and ID generator before passing them to pr.
+func (a *backendAdapter) stamping(pr ProgressReporter) ProgressReporter {
+	return &stampingReporter{a: a, pr: pr, ids: make(map[string]string), started: make(map[string]time.Time)}
+}
+
+// stampingReporter replaces event IDs and times. Backends generate their own
+// IDs and read time.Now; it maps each ID to a generated one, consistently
+// across events and references, and keeps an item's start time so end
+// events report the same StartedAt as start events.
+type stampingReporter struct {
+	a  *backendAdapter
+	pr ProgressReporter
+
+	mu      sync.Mutex
+	ids     map[string]string
+	started map[string]time.Time
+}
+
+// id maps a backend-generated ID; it must be called with mu held.
+func (r *stampingReporter) id(id string) string {
+	if id == "" || r.a.newID == nil {
+		return id
+	}
+	mapped, ok := r.ids[id]
+	if !ok {
+		mapped = r.a.newID()
+		r.ids[id] = mapped
+	}
+	return mapped
+}
+
+// times restamps the start and end times of an item; it must be called
+// with mu held.
+func (r *stampingReporter) times(id string, startedAt, endedAt *time.Time) {
+	if r.a.clock == nil {
+		return
+	}
+	start, ok := r.started[id]
+	if !ok {
+		start = r.a.clock()
+		r.started[id] = start
+	}
+	*startedAt = start
+	if !endedAt.IsZero() {
+		*endedAt = r.a.clock()
+	}
+}
+
+// completion restamps a completion update; it must be called with mu held.
+func (r *stampingReporter) completion(c *progress.Completion) {
+	if r.a.clock != nil && !c.UpdatedAt.IsZero() {
+		c.UpdatedAt = r.a.clock()
+	}
+}
+
+func (r *stampingReporter) OnAction(action ProgressAction) {
+	r.mu.Lock()
+	r.times(action.ID, &action.StartedAt, &action.EndedAt)
+	action.ID = r.id(action.ID)
+	r.mu.Unlock()
+	r.pr.OnAction(action)
+}
+
+func (r *stampingReporter) OnTask(task ProgressTask) {
+	r.mu.Lock()
+	r.times(task.ID, &task.StartedAt, &task.EndedAt)
+	r.completion(&task.Completion)
+	task.ID, task.ActionID = r.id(task.ID), r.id(task.ActionID)
+	r.mu.Unlock()
+	r.pr.OnTask(task)
+}
+
+func (r *stampingReporter) OnStep(step ProgressStep) {
+	r.mu.Lock()
+	r.times(step.ID, &step.StartedAt, &step.EndedAt)
+	r.completion(&step.Completion)
+	step.ID, step.TaskID = r.id(step.ID), r.id(step.TaskID)
+	r.mu.Unlock()
+	r.pr.OnStep(step)
+}
+
+func (r *stampingReporter) OnMessage(msg ProgressMessage) {
+	r.mu.Lock()
+	if r.a.clock != nil {
+		msg.Timestamp = r.a.clock()
+	}
+	msg.ActionID, msg.TaskID, msg.StepID = r.id(msg.ActionID), r.id(msg.TaskID), r.id(msg.StepID)
+	r.mu.Unlock()
+	r.pr.OnMessage(msg)
+}
@@ -0,0 +1,38 @@
+package pm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithClockAndIDGenerator(t *testing.T) {
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "Installing app/org.gimp.GIMP/x86_64/stable\n", "", nil
+	})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	run := func() string {
+		var buf bytes.Buffer
+		mgr := NewFlatpak(
+			WithRunner(fake),
+			WithoutOperationLock(),
+			WithProgress(NewJSONReporter(&buf)),
+			WithClock(StepClock(start, time.Second)),
+			WithIDGenerator(SequentialIDs("flatpak")),
+		)
+		if _, err := mgr.(Installer).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{}); err != nil {
+			t.Fatalf("Install() error = %v", err)
+		}
+		return buf.String()
+	}
+
+	first, second := run(), run()
+	if first != second {
+		t.Errorf("Progress streams differ:\n%s\n%s", first, second)
+	}
+	if !strings.Contains(first, `"ID":"flatpak-1"`) || !strings.Contains(first, `"StartedAt":"2024-01-02T03:04:05Z"`) {
+		t.Errorf("Expected generated IDs and clock times, got:\n%s", first)
+	}
+}
@@ -0,0 +1,770 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/progress/term"
+)
+
+// options holds the global command-line flags.
+type options struct {
+	backend  string
+	config   string
+	escalate string
+	json     bool
+	quiet    bool
+	dryRun   bool
+	descs    bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet("pm", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.backend, "backend", "auto", "backend to use: auto, brew, flatpak, or snap")
+	fs.StringVar(&opts.config, "config", "", "path to a YAML or TOML configuration file")
+	fs.StringVar(&opts.escalate, "escalate", "", "privilege escalation for mutating operations: none, sudo, doas, pkexec, or polkit")
+	fs.BoolVar(&opts.json, "json", false, "write results as JSON")
+	fs.BoolVar(&opts.quiet, "quiet", false, "suppress progress output")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print the commands that would change the system instead of running them")
+	fs.BoolVar(&opts.descs, "descriptions", false, "search also matches package descriptions, not just names")
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	command, cmdArgs := fs.Arg(0), fs.Args()[1:]
+
+	// The TUI renders progress itself; writing to stderr would corrupt the screen.
+	var ctorOpts []pm.ConstructorOption
+	if !opts.quiet && command != "tui" {
+		reporter := term.New(stderr)
+		defer reporter.Close()
+		ctorOpts = append(ctorOpts, pm.WithProgress(reporter))
+	}
+	if opts.escalate != "" {
+		ctorOpts = append(ctorOpts, pm.WithEscalation(pm.Escalation(opts.escalate)))
+	}
+	var sim *pm.SimulationRecorder
+	if opts.dryRun {
+		sim = pm.NewSimulationRecorder()
+		ctorOpts = append(ctorOpts, pm.WithSimulation(sim))
+	}
+
+	multi, err := openBackends(ctx, opts, ctorOpts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "pm: %v\n", err)
+		return 1
+	}
+
+	c := &cli{opts: opts, multi: multi, stdout: stdout, stderr: stderr}
+
+	switch command {
+	case "search":
+		err = c.search(ctx, cmdArgs)
+	case "install":
+		err = c.install(ctx, cmdArgs)
+	case "remove", "uninstall":
+		err = c.remove(ctx, cmdArgs)
+	case "update":
+		err = c.update(ctx)
+	case "upgrade":
+		err = c.upgrade(ctx)
+	case "list":
+		err = c.list(ctx)
+	case "outdated":
+		err = c.outdated(ctx)
+	case "info":
+		err = c.info(ctx, cmdArgs)
+	case "verify":
+		err = c.verify(ctx, cmdArgs)
+	case "launch":
+		err = c.launch(ctx, cmdArgs)
+	case "icon":
+		err = c.icon(ctx, cmdArgs)
+	case "watch":
+		err = c.watch(ctx)
+	case "capabilities":
+		err = c.capabilities(ctx)
+	case "tui":
+		err = c.tui(ctx)
+	default:
+		fmt.Fprintf(stderr, "pm: unknown command %q\n", command)
+		fs.Usage()
+		return 2
+	}
+
+	if sim != nil {
+		for _, a := range sim.Skipped() {
+			fmt.Fprintf(stderr, "pm: dry run: would run %s\n", a)
+		}
+	}
+
+	if err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintf(stderr, "Usage: pm %s\n", string(usage))
+			return 2
+		}
+		fmt.Fprintf(stderr, "pm: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: pm [flags] <command> [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  search <query>         Search for packages")
+	fmt.Fprintln(w, "  install <package>...   Install packages")
+	fmt.Fprintln(w, "  remove <package>...    Remove packages")
+	fmt.Fprintln(w, "  update                 Refresh package metadata")
+	fmt.Fprintln(w, "  upgrade                Upgrade installed packages")
+	fmt.Fprintln(w, "  list                   List installed packages")
+	fmt.Fprintln(w, "  outdated               List packages with available upgrades")
+	fmt.Fprintln(w, "  info <package>         Show package details")
+	fmt.Fprintln(w, "  verify <package>...    Show package provenance")
+	fmt.Fprintln(w, "  launch <package>       Start an installed application")
+	fmt.Fprintln(w, "  icon <package>         Print the path of an application's icon")
+	fmt.Fprintln(w, "  watch                  Print package changes as they happen")
+	fmt.Fprintln(w, "  capabilities           Show backend capabilities")
+	fmt.Fprintln(w, "  tui                    Browse, install, and remove packages interactively")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fs.PrintDefaults()
+}
+
+// usageError signals incorrect command arguments; its value is the usage line.
+type usageError string
+
+func (e usageError) Error() string { return "usage: pm " + string(e) }
+
+// openBackends builds the MultiManager from the configuration file, if
+// any, with --backend overriding the configured backend list.
+func openBackends(ctx context.Context, opts options, ctorOpts ...pm.ConstructorOption) (*pm.MultiManager, error) {
+	cfg := &pm.Config{}
+	if opts.config != "" {
+		var err error
+		if cfg, err = pm.LoadConfig(opts.config); err != nil {
+			return nil, err
+		}
+	}
+	if opts.backend != "auto" {
+		cfg.Backends = nil
+		for _, name := range strings.Split(opts.backend, ",") {
+			cfg.Backends = append(cfg.Backends, pm.BackendKind(strings.TrimSpace(name)))
+		}
+	}
+
+	multi, err := pm.NewFromConfig(ctx, cfg, ctorOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(multi.Backends()) == 0 {
+		return nil, errors.New("no supported package manager found")
+	}
+	return multi, nil
+}
+
+// cli carries state shared by all commands.
+type cli struct {
+	opts   options
+	multi  *pm.MultiManager
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *cli) search(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("search <query>")
+	}
+	results, err := c.multi.Search(ctx, args[0], pm.SearchOptions{IncludeDescriptions: c.opts.descs})
+	if len(results) == 0 && err != nil {
+		return err
+	}
+
+	// Backends that failed are left out of the results, and fail the
+	// command once the others are printed.
+	if c.opts.json {
+		if jerr := c.writeJSON(results); jerr != nil {
+			return jerr
+		}
+		return err
+	}
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s (%d):\n", b.Kind, len(pkgs))
+		for _, pkg := range pkgs {
+			fmt.Fprintf(c.stdout, "  %s\n", formatRef(pkg))
+		}
+	}
+	return err
+}
+
+func (c *cli) install(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("install <package>...")
+	}
+	groups, err := c.route(args, func(kind pm.BackendKind, name string) bool {
+		return c.provides(ctx, kind, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make(map[pm.BackendKind]pm.InstallResult)
+	var errs []error
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := groups[b.Kind]
+		if !ok {
+			continue
+		}
+		res, err := c.multi.Install(ctx, b.Kind, pkgs, pm.InstallOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Kind, err))
+			continue
+		}
+		results[b.Kind] = res
+	}
+
+	if c.opts.json {
+		if err := c.writeJSON(results); err != nil {
+			return err
+		}
+		return errors.Join(errs...)
+	}
+	for _, b := range c.multi.Backends() {
+		res, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		if res.Changed {
+			fmt.Fprintf(c.stdout, "%s: installed %d packages\n", b.Kind, len(res.PackagesInstalled))
+		} else {
+			fmt.Fprintf(c.stdout, "%s: no changes (already installed)\n", b.Kind)
+		}
+		for _, skipped := range res.Skipped {
+			fmt.Fprintf(c.stdout, "%s: skipped: %v\n", b.Kind, skipped)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *cli) remove(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("remove <package>...")
+	}
+	installed, err := c.multi.ListInstalled(ctx, pm.ListOptions{})
+	c.reportPartial(err, len(installed))
+	groups, err := c.route(args, func(kind pm.BackendKind, name string) bool {
+		return findInstalled(installed[kind], name) != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make(map[pm.BackendKind]pm.UninstallResult)
+	var errs []error
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := groups[b.Kind]
+		if !ok {
+			continue
+		}
+		res, err := c.multi.Uninstall(ctx, b.Kind, pkgs, pm.UninstallOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Kind, err))
+			continue
+		}
+		results[b.Kind] = res
+	}
+
+	if c.opts.json {
+		if err := c.writeJSON(results); err != nil {
+			return err
+		}
+		return errors.Join(errs...)
+	}
+	for _, b := range c.multi.Backends() {
+		res, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		if res.Changed {
+			fmt.Fprintf(c.stdout, "%s: removed %d packages\n", b.Kind, len(res.PackagesUninstalled))
+		} else {
+			fmt.Fprintf(c.stdout, "%s: no changes (not installed)\n", b.Kind)
+		}
+		for _, skipped := range res.Skipped {
+			fmt.Fprintf(c.stdout, "%s: skipped: %v\n", b.Kind, skipped)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *cli) update(ctx context.Context) error {
+	results, err := c.multi.Update(ctx, pm.UpdateOptions{})
+	if len(results) == 0 && err != nil {
+		return err
+	}
+
+	// Backends that failed are left out of the results, and fail the
+	// command once the others are printed.
+	if c.opts.json {
+		if jerr := c.writeJSON(results); jerr != nil {
+			return jerr
+		}
+		return err
+	}
+	for _, b := range c.multi.Backends() {
+		res, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		if res.Changed {
+			fmt.Fprintf(c.stdout, "%s: metadata updated\n", b.Kind)
+		} else {
+			fmt.Fprintf(c.stdout, "%s: metadata already up to date\n", b.Kind)
+		}
+	}
+	return err
+}
+
+func (c *cli) upgrade(ctx context.Context) error {
+	results, err := c.multi.Upgrade(ctx, pm.UpgradeOptions{})
+	if len(results) == 0 && err != nil {
+		return err
+	}
+
+	// Backends that failed are left out of the results, and fail the
+	// command once the others are printed.
+	if c.opts.json {
+		if jerr := c.writeJSON(results); jerr != nil {
+			return jerr
+		}
+		return err
+	}
+	for _, b := range c.multi.Backends() {
+		res, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		if !res.Changed {
+			fmt.Fprintf(c.stdout, "%s: all packages up to date\n", b.Kind)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s: upgraded %d packages\n", b.Kind, len(res.PackagesChanged))
+		for _, pkg := range res.PackagesChanged {
+			fmt.Fprintf(c.stdout, "  %s\n", formatRef(pkg))
+		}
+	}
+	return err
+}
+
+func (c *cli) list(ctx context.Context) error {
+	results, err := c.multi.ListInstalled(ctx, pm.ListOptions{})
+	if len(results) == 0 && err != nil {
+		return err
+	}
+
+	// Backends that failed are left out of the results, and fail the
+	// command once the others are printed.
+	if c.opts.json {
+		if jerr := c.writeJSON(results); jerr != nil {
+			return jerr
+		}
+		return err
+	}
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s (%d):\n", b.Kind, len(pkgs))
+		for _, pkg := range pkgs {
+			fmt.Fprintf(c.stdout, "  %-50s %s\n", formatRef(pkg.Ref), pkg.Version)
+		}
+	}
+	return err
+}
+
+func (c *cli) outdated(ctx context.Context) error {
+	results, err := c.multi.ListOutdated(ctx, pm.OutdatedOptions{})
+	if len(results) == 0 && err != nil {
+		return err
+	}
+
+	// Backends that failed are left out of the results, and fail the
+	// command once the others are printed.
+	if c.opts.json {
+		if jerr := c.writeJSON(results); jerr != nil {
+			return jerr
+		}
+		return err
+	}
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		if len(pkgs) == 0 {
+			fmt.Fprintf(c.stdout, "%s: all packages up to date\n", b.Kind)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s (%d):\n", b.Kind, len(pkgs))
+		for _, pkg := range pkgs {
+			fmt.Fprintf(c.stdout, "  %-50s %s -> %s\n", formatRef(pkg.Ref), pkg.InstalledVersion, pkg.AvailableVersion)
+		}
+	}
+	return err
+}
+
+// packageInfo is the per-backend view of a package printed by the info command.
+type packageInfo struct {
+	Backend   pm.BackendKind `json:"backend"`
+	Ref       pm.PackageRef  `json:"ref"`
+	Installed bool           `json:"installed"`
+	Version   string         `json:"version,omitempty"`
+	Available bool           `json:"available"`
+}
+
+func (c *cli) info(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("info <package>")
+	}
+	name := args[0]
+
+	installed, err := c.multi.ListInstalled(ctx, pm.ListOptions{})
+	c.reportPartial(err, len(installed))
+	found, err := c.multi.Search(ctx, name, pm.SearchOptions{Exact: true})
+	c.reportPartial(err, len(found))
+
+	var infos []packageInfo
+	for _, b := range c.multi.Backends() {
+		info := packageInfo{Backend: b.Kind, Ref: pm.PackageRef{Name: name}}
+		if pkg := findInstalled(installed[b.Kind], name); pkg != nil {
+			info.Ref = pkg.Ref
+			info.Installed = true
+			info.Version = pkg.Version
+		}
+		if ref := findRef(found[b.Kind], name); ref != nil {
+			info.Available = true
+			if !info.Installed {
+				info.Ref = *ref
+			}
+		}
+		if info.Installed || info.Available {
+			infos = append(infos, info)
+		}
+	}
+	if len(infos) == 0 {
+		return fmt.Errorf("package %q not found", name)
+	}
+
+	if c.opts.json {
+		return c.writeJSON(infos)
+	}
+	for _, info := range infos {
+		fmt.Fprintf(c.stdout, "%s:\n", info.Backend)
+		fmt.Fprintf(c.stdout, "  Name:      %s\n", formatRef(info.Ref))
+		if info.Installed {
+			fmt.Fprintf(c.stdout, "  Installed: %s\n", info.Version)
+		} else {
+			fmt.Fprintln(c.stdout, "  Installed: no")
+		}
+		fmt.Fprintf(c.stdout, "  Available: %t\n", info.Available)
+	}
+	return nil
+}
+
+func (c *cli) verify(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("verify <package>...")
+	}
+	groups, err := c.route(args, func(kind pm.BackendKind, name string) bool {
+		return c.provides(ctx, kind, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make(map[pm.BackendKind][]pm.Attestation)
+	var errs []error
+	for _, b := range c.multi.Backends() {
+		pkgs, ok := groups[b.Kind]
+		if !ok {
+			continue
+		}
+		attestations, err := c.multi.Verify(ctx, b.Kind, pkgs, pm.VerifyOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Kind, err))
+			continue
+		}
+		results[b.Kind] = attestations
+	}
+
+	if c.opts.json {
+		if err := c.writeJSON(results); err != nil {
+			return err
+		}
+		return errors.Join(errs...)
+	}
+	for _, b := range c.multi.Backends() {
+		for _, at := range results[b.Kind] {
+			fmt.Fprintf(c.stdout, "%s: %s %s\n", b.Kind, formatRef(at.Ref), at.Status)
+			if at.Source != "" {
+				fmt.Fprintf(c.stdout, "  Source:    %s\n", at.Source)
+			}
+			if at.Publisher != "" {
+				fmt.Fprintf(c.stdout, "  Publisher: %s (verified: %t)\n", at.Publisher, at.PublisherVerified)
+			}
+			if at.Digest != "" {
+				fmt.Fprintf(c.stdout, "  Digest:    %s\n", at.Digest)
+			}
+			for _, finding := range at.Findings {
+				fmt.Fprintf(c.stdout, "  ! %s\n", finding)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *cli) launch(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("launch <package> [arg...]")
+	}
+	installed, err := c.multi.ListInstalled(ctx, pm.ListOptions{})
+	c.reportPartial(err, len(installed))
+	groups, err := c.route(args[:1], func(kind pm.BackendKind, name string) bool {
+		return findInstalled(installed[kind], name) != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for kind, pkgs := range groups {
+		p, err := c.multi.Launch(ctx, kind, pkgs[0], pm.LaunchOptions{Args: args[1:]})
+		if err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+		if c.opts.json {
+			return c.writeJSON(p)
+		}
+		fmt.Fprintf(c.stdout, "%s: launched %s (pid %d)\n", kind, formatRef(p.Ref), p.PID)
+	}
+	return nil
+}
+
+func (c *cli) icon(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usageError("icon <package>")
+	}
+	installed, err := c.multi.ListInstalled(ctx, pm.ListOptions{})
+	c.reportPartial(err, len(installed))
+	groups, err := c.route(args, func(kind pm.BackendKind, name string) bool {
+		return findInstalled(installed[kind], name) != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for kind, pkgs := range groups {
+		path, err := c.multi.Icon(ctx, kind, pkgs[0], pm.IconOptions{})
+		if err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+		if c.opts.json {
+			return c.writeJSON(map[string]string{"path": path})
+		}
+		if path == "" {
+			return fmt.Errorf("%s: %s has no icon", kind, formatRef(pkgs[0]))
+		}
+		fmt.Fprintln(c.stdout, path)
+	}
+	return nil
+}
+
+func (c *cli) watch(ctx context.Context) error {
+	events, err := c.multi.Watch(ctx)
+	if events == nil {
+		return err
+	}
+	c.reportPartial(err, 1)
+	for ev := range events {
+		if c.opts.json {
+			if err := c.writeJSON(ev); err != nil {
+				return err
+			}
+			continue
+		}
+		switch ev.Type {
+		case pm.PackageUpgraded, pm.PackageDowngraded:
+			fmt.Fprintf(c.stdout, "%s: %s %s %s -> %s\n", ev.Backend, ev.Type, formatRef(ev.Package.Ref), ev.PreviousVersion, ev.Package.Version)
+		default:
+			fmt.Fprintf(c.stdout, "%s: %s %s %s\n", ev.Backend, ev.Type, formatRef(ev.Package.Ref), ev.Package.Version)
+		}
+	}
+	return nil
+}
+
+func (c *cli) capabilities(ctx context.Context) error {
+	results := make(map[pm.BackendKind][]pm.Capability)
+	var errs []error
+	for _, b := range c.multi.Backends() {
+		caps, err := b.Manager.Capabilities(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Kind, err))
+			continue
+		}
+		results[b.Kind] = caps
+	}
+
+	if c.opts.json {
+		if err := c.writeJSON(results); err != nil {
+			return err
+		}
+		return errors.Join(errs...)
+	}
+	for _, b := range c.multi.Backends() {
+		caps, ok := results[b.Kind]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s:\n", b.Kind)
+		for _, cap := range caps {
+			status := "✗"
+			if cap.Supported {
+				status = "✓"
+			}
+			notes := ""
+			if cap.Notes != "" {
+				notes = fmt.Sprintf(" (%s)", cap.Notes)
+			}
+			fmt.Fprintf(c.stdout, "  %s %s%s\n", status, cap.Operation, notes)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// route assigns each package name to a backend. Qualified references such
+// as snap:firefox/stable (see pm.ParseRef) go to the named backend. Logical
+// names found in the alias table (see pm.Resolve) are translated to each
+// backend's own package name first. With a single backend every package goes
+// there; otherwise the first backend for which match reports true wins.
+func (c *cli) route(names []string, match func(kind pm.BackendKind, name string) bool) (map[pm.BackendKind][]pm.PackageRef, error) {
+	backends := c.multi.Backends()
+	groups := make(map[pm.BackendKind][]pm.PackageRef)
+	for _, name := range names {
+		ref, err := pm.ParseRef(name)
+		if err != nil {
+			return nil, err
+		}
+		if ref.Backend != "" {
+			if _, ok := c.multi.Get(ref.Backend); !ok {
+				return nil, fmt.Errorf("%s: backend %s is not available", name, ref.Backend)
+			}
+			groups[ref.Backend] = append(groups[ref.Backend], ref.Package)
+			continue
+		}
+
+		routed := false
+		for _, b := range backends {
+			ref, ok := pm.DefaultAliases.Lookup(name, b.Kind)
+			if !ok {
+				ref = pm.PackageRef{Name: name}
+			}
+			if len(backends) == 1 || match(b.Kind, ref.Name) {
+				groups[b.Kind] = append(groups[b.Kind], ref)
+				routed = true
+				break
+			}
+		}
+		if !routed {
+			return nil, fmt.Errorf("no backend provides %q (use --backend to choose one)", name)
+		}
+	}
+	return groups, nil
+}
+
+// provides reports whether a search on the given backend returns an exact match for name.
+func (c *cli) provides(ctx context.Context, kind pm.BackendKind, name string) bool {
+	mgr, ok := c.multi.Get(kind)
+	if !ok {
+		return false
+	}
+	searcher, ok := mgr.(pm.Searcher)
+	if !ok {
+		return false
+	}
+	results, err := searcher.Search(ctx, name, pm.SearchOptions{})
+	if err != nil {
+		return false
+	}
+	return findRef(results, name) != nil
+}
+
+// reportPartial prints backend errors as warnings when some backends still produced results.
+func (c *cli) reportPartial(err error, results int) {
+	if err != nil && results > 0 {
+		fmt.Fprintf(c.stderr, "pm: warning: %v\n", err)
+	}
+}
+
+func (c *cli) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func findInstalled(pkgs []pm.InstalledPackage, name string) *pm.InstalledPackage {
+	for i := range pkgs {
+		if strings.EqualFold(pkgs[i].Ref.Name, name) {
+			return &pkgs[i]
+		}
+	}
+	return nil
+}
+
+func findRef(refs []pm.PackageRef, name string) *pm.PackageRef {
+	for i := range refs {
+		if strings.EqualFold(refs[i].Name, name) {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+func formatRef(ref pm.PackageRef) string {
+	s := ref.Name
+	if ref.Namespace != "" {
+		s += " [" + ref.Namespace + "]"
+	}
+	if ref.Kind != "" {
+		s += " (" + ref.Kind + ")"
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
+)
+
+func TestCLI_PartialFailureFails(t *testing.T) {
+	brew := pmtest.NewFakeManager()
+	brew.BackendKind = pm.BackendBrew
+	brew.Installed = []pm.InstalledPackage{{Ref: pm.PackageRef{Name: "jq"}, Version: "1.6"}}
+	flatpak := pmtest.NewFakeManager()
+	flatpak.BackendKind = pm.BackendFlatpak
+	boom := errors.New("flatpak is broken")
+
+	for _, asJSON := range []bool{false, true} {
+		var stdout bytes.Buffer
+		c := &cli{
+			opts: options{json: asJSON},
+			multi: pm.NewMultiManager(
+				pm.Backend{Kind: pm.BackendBrew, Manager: brew},
+				pm.Backend{Kind: pm.BackendFlatpak, Manager: flatpak},
+			),
+			stdout: &stdout,
+			stderr: &bytes.Buffer{},
+		}
+		flatpak.Fail("ListInstalled", boom)
+		err := c.list(context.Background())
+		if !errors.Is(err, boom) {
+			t.Errorf("json=%v: list() = %v, want the flatpak error", asJSON, err)
+		}
+		if !strings.Contains(stdout.String(), "jq") {
+			t.Errorf("json=%v: stdout = %q, want the brew packages printed", asJSON, stdout.String())
+		}
+	}
+}
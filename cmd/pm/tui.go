@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/frostyard/pm"
+)
+
+// tui runs the interactive package browser.
+func (c *cli) tui(ctx context.Context) error {
+	m := newTUIModel(ctx, c.multi)
+	p := tea.NewProgram(m, tea.WithContext(ctx), tea.WithAltScreen())
+	m.reporter.send = p.Send
+	_, err := p.Run()
+	return err
+}
+
+// tuiView selects which list the browser shows.
+type tuiView int
+
+const (
+	viewInstalled tuiView = iota
+	viewOutdated
+	viewSearch
+)
+
+// tuiRow is a single package line in the browser.
+type tuiRow struct {
+	backend   pm.BackendKind
+	ref       pm.PackageRef
+	version   string
+	installed bool
+}
+
+type installedLoadedMsg struct {
+	results map[pm.BackendKind][]pm.InstalledPackage
+	err     error
+}
+
+type outdatedLoadedMsg struct {
+	results map[pm.BackendKind][]pm.OutdatedPackage
+	err     error
+}
+
+type searchDoneMsg struct {
+	results map[pm.BackendKind][]pm.PackageRef
+	err     error
+}
+
+type operationDoneMsg struct {
+	summary string
+	err     error
+}
+
+type progressLineMsg string
+
+// tuiModel is the bubbletea model for the package browser.
+type tuiModel struct {
+	ctx      context.Context
+	multi    *pm.MultiManager
+	reporter *tuiReporter
+
+	view      tuiView
+	installed []tuiRow
+	outdated  []tuiRow
+	results   []tuiRow
+	cursor    int
+
+	searching bool
+	query     string
+
+	busy     bool
+	status   string
+	progress []string
+	err      error
+}
+
+func newTUIModel(ctx context.Context, multi *pm.MultiManager) *tuiModel {
+	return &tuiModel{
+		ctx:      ctx,
+		multi:    multi,
+		reporter: &tuiReporter{},
+		busy:     true,
+		status:   "Loading installed packages…",
+	}
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.loadInstalled(), m.loadOutdated())
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearchInput(msg)
+		}
+		return m.updateKeys(msg)
+
+	case installedLoadedMsg:
+		m.busy = false
+		m.err = msg.err
+		m.installed = installedRows(m.multi, msg.results)
+		m.status = fmt.Sprintf("%d installed packages", len(m.installed))
+		m.clampCursor()
+
+	case outdatedLoadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		m.outdated = outdatedRows(m.multi, msg.results)
+		m.clampCursor()
+
+	case searchDoneMsg:
+		m.busy = false
+		m.err = msg.err
+		m.results = searchRows(m.multi, msg.results, m.installed)
+		m.view = viewSearch
+		m.cursor = 0
+		m.status = fmt.Sprintf("%d results for %q", len(m.results), m.query)
+
+	case operationDoneMsg:
+		m.err = msg.err
+		m.status = msg.summary
+		return m, tea.Batch(m.loadInstalled(), m.loadOutdated())
+
+	case progressLineMsg:
+		m.progress = append(m.progress, string(msg))
+		if len(m.progress) > progressLines {
+			m.progress = m.progress[len(m.progress)-progressLines:]
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows())-1 {
+			m.cursor++
+		}
+	case "tab":
+		switch m.view {
+		case viewInstalled:
+			m.view = viewOutdated
+		case viewOutdated:
+			m.view = viewSearch
+		default:
+			m.view = viewInstalled
+		}
+		m.clampCursor()
+	case "/":
+		m.searching = true
+		m.query = ""
+	case "r":
+		if !m.busy {
+			m.busy = true
+			m.status = "Refreshing…"
+			return m, tea.Batch(m.loadInstalled(), m.loadOutdated())
+		}
+	case "i":
+		if row, ok := m.selected(); ok && !m.busy && !row.installed {
+			m.busy = true
+			m.progress = nil
+			return m, m.install(row)
+		}
+	case "x", "d":
+		if row, ok := m.selected(); ok && !m.busy && row.installed {
+			m.busy = true
+			m.progress = nil
+			return m, m.remove(row)
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) updateSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyEnter:
+		m.searching = false
+		if m.query != "" && !m.busy {
+			m.busy = true
+			m.status = fmt.Sprintf("Searching for %q…", m.query)
+			return m, m.search(m.query)
+		}
+	case tea.KeyBackspace:
+		if len(m.query) > 0 {
+			m.query = m.query[:len(m.query)-1]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	installedTab, outdatedTab, searchTab := " Installed ", fmt.Sprintf(" Outdated (%d) ", len(m.outdated)), " Search "
+	switch m.view {
+	case viewInstalled:
+		installedTab = "[Installed]"
+	case viewOutdated:
+		outdatedTab = fmt.Sprintf("[Outdated (%d)]", len(m.outdated))
+	default:
+		searchTab = "[Search]"
+	}
+	fmt.Fprintf(&b, "pm  %s %s %s\n\n", installedTab, outdatedTab, searchTab)
+
+	rows := m.rows()
+	if len(rows) == 0 {
+		b.WriteString("  (no packages)\n")
+	}
+	start, end := visibleWindow(m.cursor, len(rows), listHeight)
+	for i := start; i < end; i++ {
+		row := rows[i]
+		pointer := "  "
+		if i == m.cursor {
+			pointer = "> "
+		}
+		mark := " "
+		if row.installed {
+			mark = "●"
+		}
+		fmt.Fprintf(&b, "%s%s %-8s %-50s %s\n", pointer, mark, row.backend, formatRef(row.ref), row.version)
+	}
+
+	b.WriteString("\n")
+	if m.searching {
+		fmt.Fprintf(&b, "Search: %s▏\n", m.query)
+	} else {
+		fmt.Fprintf(&b, "%s\n", m.status)
+	}
+	if m.err != nil {
+		fmt.Fprintf(&b, "Error: %v\n", m.err)
+	}
+	for _, line := range m.progress {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	b.WriteString("\n↑/↓ move • / search • tab next view • i install • x remove • r refresh • q quit\n")
+	return b.String()
+}
+
+func (m *tuiModel) rows() []tuiRow {
+	switch m.view {
+	case viewOutdated:
+		return m.outdated
+	case viewSearch:
+		return m.results
+	}
+	return m.installed
+}
+
+func (m *tuiModel) selected() (tuiRow, bool) {
+	rows := m.rows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return tuiRow{}, false
+	}
+	return rows[m.cursor], true
+}
+
+func (m *tuiModel) clampCursor() {
+	if n := len(m.rows()); m.cursor >= n {
+		m.cursor = n - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *tuiModel) loadInstalled() tea.Cmd {
+	return func() tea.Msg {
+		results, err := m.multi.ListInstalled(m.ctx, pm.ListOptions{})
+		return installedLoadedMsg{results: results, err: err}
+	}
+}
+
+func (m *tuiModel) loadOutdated() tea.Cmd {
+	return func() tea.Msg {
+		results, err := m.multi.ListOutdated(m.ctx, pm.OutdatedOptions{})
+		return outdatedLoadedMsg{results: results, err: err}
+	}
+}
+
+func (m *tuiModel) search(query string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := m.multi.Search(m.ctx, query, pm.SearchOptions{})
+		return searchDoneMsg{results: results, err: err}
+	}
+}
+
+func (m *tuiModel) install(row tuiRow) tea.Cmd {
+	m.status = fmt.Sprintf("Installing %s via %s…", row.ref.Name, row.backend)
+	return func() tea.Msg {
+		res, err := m.multi.Install(m.ctx, row.backend, []pm.PackageRef{row.ref}, pm.InstallOptions{Progress: m.reporter})
+		if err != nil {
+			return operationDoneMsg{summary: "Install failed", err: err}
+		}
+		if !res.Changed {
+			return operationDoneMsg{summary: row.ref.Name + " was already installed"}
+		}
+		return operationDoneMsg{summary: "Installed " + row.ref.Name}
+	}
+}
+
+func (m *tuiModel) remove(row tuiRow) tea.Cmd {
+	m.status = fmt.Sprintf("Removing %s via %s…", row.ref.Name, row.backend)
+	return func() tea.Msg {
+		res, err := m.multi.Uninstall(m.ctx, row.backend, []pm.PackageRef{row.ref}, pm.UninstallOptions{Progress: m.reporter})
+		if err != nil {
+			return operationDoneMsg{summary: "Remove failed", err: err}
+		}
+		if !res.Changed {
+			return operationDoneMsg{summary: row.ref.Name + " was not installed"}
+		}
+		return operationDoneMsg{summary: "Removed " + row.ref.Name}
+	}
+}
+
+const (
+	listHeight    = 20
+	progressLines = 5
+)
+
+// visibleWindow returns the slice bounds of a scrolling window of size height
+// that keeps cursor in view.
+func visibleWindow(cursor, total, height int) (int, int) {
+	if total <= height {
+		return 0, total
+	}
+	start := cursor - height/2
+	if start < 0 {
+		start = 0
+	}
+	if start+height > total {
+		start = total - height
+	}
+	return start, start + height
+}
+
+func installedRows(multi *pm.MultiManager, results map[pm.BackendKind][]pm.InstalledPackage) []tuiRow {
+	var rows []tuiRow
+	for _, b := range multi.Backends() {
+		pkgs := results[b.Kind]
+		sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Ref.Name < pkgs[j].Ref.Name })
+		for _, pkg := range pkgs {
+			rows = append(rows, tuiRow{backend: b.Kind, ref: pkg.Ref, version: pkg.Version, installed: true})
+		}
+	}
+	return rows
+}
+
+// outdatedRows shows each package's installed and available versions.
+func outdatedRows(multi *pm.MultiManager, results map[pm.BackendKind][]pm.OutdatedPackage) []tuiRow {
+	var rows []tuiRow
+	for _, b := range multi.Backends() {
+		pkgs := results[b.Kind]
+		sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Ref.Name < pkgs[j].Ref.Name })
+		for _, pkg := range pkgs {
+			rows = append(rows, tuiRow{backend: b.Kind, ref: pkg.Ref, version: pkg.InstalledVersion + " → " + pkg.AvailableVersion, installed: true})
+		}
+	}
+	return rows
+}
+
+func searchRows(multi *pm.MultiManager, results map[pm.BackendKind][]pm.PackageRef, installed []tuiRow) []tuiRow {
+	isInstalled := make(map[string]bool)
+	for _, row := range installed {
+		isInstalled[string(row.backend)+"\x00"+row.ref.Name] = true
+	}
+
+	var rows []tuiRow
+	for _, b := range multi.Backends() {
+		for _, ref := range results[b.Kind] {
+			rows = append(rows, tuiRow{
+				backend:   b.Kind,
+				ref:       ref,
+				installed: isInstalled[string(b.Kind)+"\x00"+ref.Name],
+			})
+		}
+	}
+	return rows
+}
+
+// tuiReporter forwards progress events into the bubbletea program.
+type tuiReporter struct {
+	send func(tea.Msg)
+}
+
+func (r *tuiReporter) OnAction(action pm.ProgressAction) {
+	if action.EndedAt.IsZero() {
+		r.emit("→ " + action.Name)
+	}
+}
+
+func (r *tuiReporter) OnTask(task pm.ProgressTask) {
+	if task.EndedAt.IsZero() && task.UpdatedAt.IsZero() {
+		r.emit("• " + task.Name)
+	}
+}
+
+func (r *tuiReporter) OnStep(step pm.ProgressStep) {
+	if step.EndedAt.IsZero() && step.UpdatedAt.IsZero() {
+		r.emit("- " + step.Name)
+	}
+}
+
+func (r *tuiReporter) OnMessage(msg pm.ProgressMessage) {
+	r.emit(string(msg.Severity) + ": " + msg.Text)
+}
+
+func (r *tuiReporter) emit(line string) {
+	if r.send != nil {
+		r.send(progressLineMsg(line))
+	}
+}
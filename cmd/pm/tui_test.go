@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
+)
+
+// newTestTUI returns a browser over a fake brew with jq 1.6 installed, an
+// update to 1.7.1 pending, and wget in its catalog, with the installed and
+// outdated lists loaded.
+func newTestTUI(t *testing.T) (*tuiModel, *pmtest.FakeManager) {
+	t.Helper()
+	fake := pmtest.NewFakeManager()
+	fake.BackendKind = pm.BackendBrew
+	fake.Catalog = []pm.PackageRef{{Name: "jq"}, {Name: "wget"}}
+	fake.Installed = []pm.InstalledPackage{{Ref: pm.PackageRef{Name: "jq"}, Version: "1.6"}}
+	fake.ListOutdatedFunc = func(ctx context.Context, opts pm.OutdatedOptions) ([]pm.OutdatedPackage, error) {
+		return []pm.OutdatedPackage{{Ref: pm.PackageRef{Name: "jq"}, InstalledVersion: "1.6", AvailableVersion: "1.7.1"}}, nil
+	}
+	m := newTUIModel(context.Background(), pm.NewMultiManager(pm.Backend{Kind: pm.BackendBrew, Manager: fake}))
+	m.Update(m.loadInstalled()())
+	m.Update(m.loadOutdated()())
+	return m, fake
+}
+
+// press sends key to m and runs the command it returns, if any, feeding
+// the resulting message back.
+func press(m *tuiModel, key tea.KeyMsg) {
+	if _, cmd := m.Update(key); cmd != nil {
+		m.Update(cmd())
+	}
+}
+
+func keys(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestTUI_InstalledView(t *testing.T) {
+	m, _ := newTestTUI(t)
+	view := m.View()
+	if !strings.Contains(view, "[Installed]") || !strings.Contains(view, "jq") || !strings.Contains(view, "1.6") {
+		t.Errorf("View() = %q, want jq 1.6 on the installed tab", view)
+	}
+	if !strings.Contains(view, "1 installed packages") {
+		t.Errorf("View() = %q, want the installed count", view)
+	}
+}
+
+func TestTUI_OutdatedView(t *testing.T) {
+	m, _ := newTestTUI(t)
+	press(m, tea.KeyMsg{Type: tea.KeyTab})
+	if m.view != viewOutdated {
+		t.Fatalf("view = %d after tab, want the outdated view", m.view)
+	}
+	view := m.View()
+	if !strings.Contains(view, "[Outdated (1)]") || !strings.Contains(view, "1.6 → 1.7.1") {
+		t.Errorf("View() = %q, want jq 1.6 → 1.7.1 on the outdated tab", view)
+	}
+
+	press(m, tea.KeyMsg{Type: tea.KeyTab})
+	press(m, tea.KeyMsg{Type: tea.KeyTab})
+	if m.view != viewInstalled {
+		t.Errorf("view = %d after cycling, want the installed view", m.view)
+	}
+}
+
+func TestTUI_SearchAndInstall(t *testing.T) {
+	m, fake := newTestTUI(t)
+	press(m, keys("/"))
+	press(m, keys("wget"))
+	press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.view != viewSearch || len(m.results) != 1 || m.results[0].ref.Name != "wget" {
+		t.Fatalf("results = %+v in view %d, want wget in the search view", m.results, m.view)
+	}
+
+	m.busy = false
+	_, cmd := m.Update(keys("i"))
+	if cmd == nil {
+		t.Fatal("i did not start an install")
+	}
+	_, cmd = m.Update(cmd())
+	if m.status != "Installed wget" {
+		t.Errorf("status = %q, want Installed wget", m.status)
+	}
+	if calls := fake.CallsTo("Install"); len(calls) != 1 || calls[0].Packages[0].Name != "wget" {
+		t.Errorf("Install calls = %+v, want one for wget", calls)
+	}
+
+	// The reload after the install lists wget too
+	for _, msg := range cmd().(tea.BatchMsg) {
+		m.Update(msg())
+	}
+	if len(m.installed) != 2 {
+		t.Errorf("installed = %+v, want jq and wget", m.installed)
+	}
+}
@@ -253,13 +253,13 @@ func (p *progressReporter) OnAction(action pm.ProgressAction) {
 }
 
 func (p *progressReporter) OnTask(task pm.ProgressTask) {
-	if !task.StartedAt.IsZero() && task.EndedAt.IsZero() {
+	if !task.StartedAt.IsZero() && task.EndedAt.IsZero() && task.UpdatedAt.IsZero() {
 		fmt.Printf("  • %s\n", task.Name)
 	}
 }
 
 func (p *progressReporter) OnStep(step pm.ProgressStep) {
-	if !step.StartedAt.IsZero() && step.EndedAt.IsZero() {
+	if !step.StartedAt.IsZero() && step.EndedAt.IsZero() && step.UpdatedAt.IsZero() {
 		fmt.Printf("    - %s\n", step.Name)
 	}
 }
@@ -1,5 +1,16 @@
 package pm
 
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+)
+
 // BackendKind represents a package manager backend type.
 type BackendKind string
 
@@ -20,6 +31,55 @@ type ConstructorOption func(config *backendConfig)
 // backendConfig holds configuration for backend constructors.
 type backendConfig struct {
 	progress ProgressReporter
+	cache    Cache
+	cacheTTL map[Operation]time.Duration
+	retry    *RetryPolicy
+	timeout  time.Duration
+
+	runners          map[BackendKind]Runner
+	terminationGrace *time.Duration
+	hooks            []CommandHooks
+	outputLimits     map[BackendKind]int
+	redactPatterns   []*regexp.Regexp
+	noRedaction      bool
+
+	escalation map[BackendKind]Escalation
+	noLock     bool
+
+	simulate   bool
+	simulation *SimulationRecorder
+
+	audit          AuditLogger
+	nonInteractive bool
+	locale         *string
+
+	formulaeBaseURL   string
+	formulaeIndexDir  *string
+	searchIndexMaxAge time.Duration
+	snapdEndpoint     string
+	arch              string
+	brewEnv           *BrewEnv
+
+	client    *http.Client
+	proxy     func(*http.Request) (*url.URL, error)
+	tlsConfig *tls.Config
+
+	clock func() time.Time
+	newID func() string
+
+	availabilityTTL *time.Duration
+	batchSize       *int
+
+	changeListeners    []func(context.Context, ChangeEvent)
+	desktopIntegration bool
+	confirm            func(ChangePlan) (bool, error)
+	iconDir            string
+	watchInterval      time.Duration
+
+	// Set by NewFromConfig and, for binaryPaths, by WithBrewPath,
+	// WithFlatpakPath and WithSnapPath.
+	binaryPaths         map[BackendKind]string
+	flatpakInstallation string
 }
 
 // WithProgress sets a progress reporter for a backend.
@@ -28,3 +88,116 @@ func WithProgress(p ProgressReporter) ConstructorOption {
 		config.progress = p
 	}
 }
+
+// WithNonInteractive guarantees that operations never wait for user input.
+// Escalation strategies that may prompt (pkexec) fail with EscalationError
+// instead of showing an authentication dialog.
+func WithNonInteractive() ConstructorOption {
+	return func(config *backendConfig) {
+		config.nonInteractive = true
+	}
+}
+
+// WithLocale sets the locale commands run with (LC_ALL and LANG). By
+// default commands run with the "C" locale, because backends parse their
+// English output. An empty locale leaves the inherited environment alone,
+// which only makes sense for custom runners that pin the locale
+// themselves.
+func WithLocale(locale string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.locale = &locale
+	}
+}
+
+// WithCache enables caching of read operation results (currently Search).
+//
+// Cached entries for a backend are invalidated whenever that backend runs a
+// mutating operation (Update, Upgrade, Install, Uninstall). Use
+// NewDefaultCache for an in-memory cache backed by disk, or NewMemoryCache
+// for a process-local one.
+func WithCache(c Cache) ConstructorOption {
+	return func(config *backendConfig) {
+		config.cache = c
+	}
+}
+
+// WithCacheTTL overrides how long results of the given operation are cached.
+// A TTL of zero or less disables caching for that operation.
+func WithCacheTTL(op Operation, ttl time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		if config.cacheTTL == nil {
+			config.cacheTTL = make(map[Operation]time.Duration)
+		}
+		config.cacheTTL[op] = ttl
+	}
+}
+
+// runner builds the command runner for a backend, applying the command
+// locale, command hooks, its escalation strategy, simulation mode and
+// binary path, either overridden or discovered outside PATH.
+func (c *backendConfig) runner(kind BackendKind) runner.Runner {
+	var r runner.Runner = runner.NewRealRunner()
+	if c.terminationGrace != nil {
+		r = runner.NewRealRunnerWithGrace(*c.terminationGrace)
+	}
+	if custom, ok := c.runners[kind]; ok && custom != nil {
+		r = custom
+	}
+	locale := runner.DefaultLocale
+	if c.locale != nil {
+		locale = *c.locale
+	}
+	if locale != "" {
+		r = runner.NewLocaleRunner(r, locale)
+	}
+	if len(c.hooks) > 0 {
+		r = c.observingRunner(kind, r)
+	}
+	if s, ok := c.escalation[kind]; ok {
+		r = runner.NewEscalatingRunner(r, string(s), string(kind), !c.nonInteractive)
+	}
+	if c.simulate {
+		r = c.simulationRunner(kind, r)
+	}
+	if path := c.binaryPath(kind); path != "" {
+		r = runner.NewPathRunner(r, map[string]string{string(kind): path})
+	}
+	return r
+}
+
+// runnerTarget returns the Target of the runner given to WithRunner for
+// kind, or "" if there is none or it doesn't report one.
+func (c *backendConfig) runnerTarget(kind BackendKind) string {
+	if t, ok := c.runners[kind].(runner.Targeter); ok {
+		return t.Target()
+	}
+	return ""
+}
+
+// binaryPath returns the executable to run for kind's command, overridden
+// or discovered outside PATH, or "" to look it up on PATH.
+func (c *backendConfig) binaryPath(kind BackendKind) string {
+	if path, ok := c.binaryPaths[kind]; ok {
+		return path
+	}
+	if c.runners[kind] == nil {
+		// Only the local runner can use paths found on this machine.
+		return discoverBinary(kind)
+	}
+	return ""
+}
+
+// newBackendConfig builds a constructor configuration from the PM_*
+// environment variables (see EnvConfig) overlaid with opts, so explicit
+// options always take precedence over the environment.
+func newBackendConfig(opts []ConstructorOption) *backendConfig {
+	cfg := &backendConfig{}
+	env, _ := EnvConfig()
+	for _, opt := range env.Options() {
+		opt(cfg)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,299 @@
+package pm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes how to construct backends. It is usually loaded from a
+// YAML or TOML file with LoadConfig and turned into managers with
+// NewFromConfig, so that applications built on pm (and the pm CLI) share
+// one configuration:
+//
+//	backends: [flatpak, snap]
+//	paths:
+//	  brew: /home/linuxbrew/.linuxbrew/bin/brew
+//	scopes:
+//	  flatpak: user
+//	escalation:
+//	  snap: sudo
+//	cache:
+//	  dir: /var/cache/pm
+//	  ttl: 30m
+//	timeout: 10m
+//	timeouts:
+//	  Search: 30s
+//	noninteractive: true
+type Config struct {
+	// Backends lists the backends to enable, in priority order.
+	// If empty, every available backend is used (see Detect).
+	Backends []BackendKind `yaml:"backends" toml:"backends"`
+
+	// Paths overrides the executable used for a backend's command-line tool.
+	Paths map[BackendKind]string `yaml:"paths" toml:"paths"`
+
+	// Scopes sets the default installation scope per backend. Only flatpak
+	// has scopes: "user", "system", or the name of a custom installation.
+	Scopes map[BackendKind]string `yaml:"scopes" toml:"scopes"`
+
+	// Escalation sets the privilege-escalation strategy per backend.
+	Escalation map[BackendKind]Escalation `yaml:"escalation" toml:"escalation"`
+
+	// Cache configures result caching. Caching is enabled when Dir is set.
+	Cache CacheConfig `yaml:"cache" toml:"cache"`
+
+	// Timeout is the default deadline for every package operation whose
+	// context has none (see WithTimeout). Zero means no limit.
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+
+	// Timeouts overrides Timeout for individual operations.
+	Timeouts map[Operation]Duration `yaml:"timeouts" toml:"timeouts"`
+
+	// NonInteractive guarantees operations never wait for user input
+	// (see WithNonInteractive). Nil leaves it to the other layers, so
+	// that an overlay can turn it off with false.
+	NonInteractive *bool `yaml:"noninteractive" toml:"noninteractive"`
+}
+
+// CacheConfig configures result caching.
+type CacheConfig struct {
+	// Dir is the on-disk cache location (see NewDefaultCache).
+	Dir string `yaml:"dir" toml:"dir"`
+
+	// TTL is how long search results are cached. Zero uses DefaultSearchCacheTTL.
+	TTL Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// Duration is a time.Duration written in configuration files as a
+// time.ParseDuration string such as "30s" or "10m".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// LoadConfig reads a configuration file. The format is chosen by extension:
+// .yaml or .yml for YAML, .toml for TOML. Unknown keys are rejected so that
+// typos don't silently fall back to defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q (want .yaml, .yml or .toml)", path, ext)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks that the configuration refers only to known backends,
+// scopes, escalation strategies and operations.
+func (c *Config) Validate() error {
+	for _, kind := range c.Backends {
+		if !knownBackend(kind) {
+			return fmt.Errorf("backends: unknown backend %q", kind)
+		}
+	}
+	for kind := range c.Paths {
+		if !knownBackend(kind) {
+			return fmt.Errorf("paths: unknown backend %q", kind)
+		}
+	}
+	for kind := range c.Scopes {
+		if kind != BackendFlatpak {
+			return fmt.Errorf("scopes: backend %q has no installation scopes", kind)
+		}
+	}
+	for kind, s := range c.Escalation {
+		if !knownBackend(kind) {
+			return fmt.Errorf("escalation: unknown backend %q", kind)
+		}
+		switch s {
+		case EscalationNone, EscalationSudo, EscalationDoas, EscalationPkexec, EscalationPolkit:
+		default:
+			return fmt.Errorf("escalation: unknown strategy %q for %s", s, kind)
+		}
+	}
+	for op := range c.Timeouts {
+		switch op {
+		case OperationUpdateMetadata, OperationUpgradePackages, OperationInstall,
+			OperationUninstall, OperationSearch, OperationListInstalled:
+		default:
+			return fmt.Errorf("timeouts: unknown operation %q", op)
+		}
+	}
+	return nil
+}
+
+func knownBackend(kind BackendKind) bool {
+	for _, k := range AllBackends() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Options converts the configuration into constructor options.
+func (c *Config) Options() []ConstructorOption {
+	var opts []ConstructorOption
+	for kind, path := range c.Paths {
+		opts = append(opts, withBinaryPath(kind, path))
+	}
+	if c.Scopes[BackendFlatpak] != "" {
+		opts = append(opts, func(config *backendConfig) {
+			config.flatpakInstallation = c.Scopes[BackendFlatpak]
+		})
+	}
+	for kind, s := range c.Escalation {
+		opts = append(opts, WithEscalation(s, kind))
+	}
+	if c.NonInteractive != nil && *c.NonInteractive {
+		opts = append(opts, WithNonInteractive())
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, WithTimeout(time.Duration(c.Timeout)))
+	}
+	if c.Cache.Dir != "" {
+		opts = append(opts, WithCache(NewDefaultCache(c.Cache.Dir)))
+		if c.Cache.TTL > 0 {
+			opts = append(opts, WithCacheTTL(OperationSearch, time.Duration(c.Cache.TTL)))
+		}
+	}
+	return opts
+}
+
+// Merge returns a copy of c with every field set in overlay replacing the
+// corresponding field of c. Map entries are merged key by key.
+func (c *Config) Merge(overlay *Config) *Config {
+	out := *c
+	if len(overlay.Backends) > 0 {
+		out.Backends = overlay.Backends
+	}
+	out.Paths = mergeMap(c.Paths, overlay.Paths)
+	out.Scopes = mergeMap(c.Scopes, overlay.Scopes)
+	out.Escalation = mergeMap(c.Escalation, overlay.Escalation)
+	out.Timeouts = mergeMap(c.Timeouts, overlay.Timeouts)
+	if overlay.Cache.Dir != "" {
+		out.Cache.Dir = overlay.Cache.Dir
+	}
+	if overlay.Cache.TTL != 0 {
+		out.Cache.TTL = overlay.Cache.TTL
+	}
+	if overlay.Timeout != 0 {
+		out.Timeout = overlay.Timeout
+	}
+	if overlay.NonInteractive != nil {
+		out.NonInteractive = overlay.NonInteractive
+	}
+	return &out
+}
+
+func mergeMap[K comparable, V any](base, overlay map[K]V) map[K]V {
+	if len(overlay) == 0 {
+		return base
+	}
+	out := make(map[K]V, len(base)+len(overlay))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range overlay {
+		out[k] = v
+	}
+	return out
+}
+
+// NewFromConfig creates a MultiManager from cfg.
+//
+// Settings are layered: cfg is overridden by the PM_* environment variables
+// (see EnvConfig), which are in turn overridden by opts.
+//
+// If no backends are configured, the available ones are detected.
+// Per-operation timeouts are enforced by wrapping each backend (see Wrap).
+func NewFromConfig(ctx context.Context, cfg *Config, opts ...ConstructorOption) (*MultiManager, error) {
+	env, err := EnvConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg = cfg.Merge(env)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	all := append(cfg.Options(), opts...)
+
+	var backends []Backend
+	if len(cfg.Backends) == 0 {
+		backends = Detect(ctx, all...)
+	} else {
+		for _, kind := range cfg.Backends {
+			mgr, err := New(kind, all...)
+			if err != nil {
+				return nil, err
+			}
+			backends = append(backends, Backend{Kind: kind, Manager: mgr})
+		}
+	}
+
+	if len(cfg.Timeouts) > 0 {
+		for i := range backends {
+			backends[i].Manager = Wrap(backends[i].Manager, cfg.timeoutMiddleware)
+		}
+	}
+	return NewMultiManager(backends...), nil
+}
+
+// timeoutMiddleware bounds each call by its per-operation timeout. The
+// global Timeout is applied by the backends themselves (see WithTimeout).
+func (c *Config) timeoutMiddleware(next Handler) Handler {
+	return func(ctx context.Context, call *Call) (any, error) {
+		timeout := time.Duration(c.Timeouts[call.Operation])
+		if timeout <= 0 {
+			return next(ctx, call)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return next(ctx, call)
+	}
+}
@@ -0,0 +1,151 @@
+package pm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	path := writeConfig(t, "pm.yaml", `
+backends: [flatpak, snap]
+paths:
+  flatpak: /opt/flatpak/bin/flatpak
+scopes:
+  flatpak: user
+escalation:
+  snap: sudo
+cache:
+  dir: /tmp/pm-cache
+  ttl: 30m
+timeout: 10m
+timeouts:
+  Search: 30s
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[0] != BackendFlatpak {
+		t.Errorf("Unexpected backends %v", cfg.Backends)
+	}
+	if cfg.Paths[BackendFlatpak] != "/opt/flatpak/bin/flatpak" || cfg.Scopes[BackendFlatpak] != "user" {
+		t.Errorf("Unexpected paths/scopes %v %v", cfg.Paths, cfg.Scopes)
+	}
+	if cfg.Escalation[BackendSnap] != EscalationSudo {
+		t.Errorf("Unexpected escalation %v", cfg.Escalation)
+	}
+	if time.Duration(cfg.Cache.TTL) != 30*time.Minute || time.Duration(cfg.Timeout) != 10*time.Minute {
+		t.Errorf("Unexpected durations %v %v", cfg.Cache.TTL, cfg.Timeout)
+	}
+	if time.Duration(cfg.Timeouts[OperationSearch]) != 30*time.Second {
+		t.Errorf("Unexpected timeouts %v", cfg.Timeouts)
+	}
+}
+
+func TestLoadConfig_TOML(t *testing.T) {
+	path := writeConfig(t, "pm.toml", `
+backends = ["brew"]
+timeout = "5m"
+
+[paths]
+brew = "/home/linuxbrew/.linuxbrew/bin/brew"
+
+[cache]
+dir = "/tmp/pm-cache"
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(cfg.Backends) != 1 || cfg.Backends[0] != BackendBrew {
+		t.Errorf("Unexpected backends %v", cfg.Backends)
+	}
+	if cfg.Paths[BackendBrew] != "/home/linuxbrew/.linuxbrew/bin/brew" || cfg.Cache.Dir != "/tmp/pm-cache" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if time.Duration(cfg.Timeout) != 5*time.Minute {
+		t.Errorf("Unexpected timeout %v", cfg.Timeout)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := map[string]string{
+		"unknown.yaml":  "backend: [flatpak]\n",
+		"unknown.toml":  "backend = [\"flatpak\"]\n",
+		"badkind.yaml":  "backends: [apt]\n",
+		"scope.yaml":    "scopes:\n  snap: user\n",
+		"duration.yaml": "timeout: soon\n",
+		"strategy.yaml": "escalation:\n  snap: su\n",
+		"op.yaml":       "timeouts:\n  Reboot: 1m\n",
+		"pm.json":       "{}",
+	}
+	for name, content := range tests {
+		if _, err := LoadConfig(writeConfig(t, name, content)); err == nil {
+			t.Errorf("LoadConfig(%s) expected error", name)
+		}
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	cfg := &Config{
+		Backends: []BackendKind{BackendSnap, BackendFlatpak},
+		Scopes:   map[BackendKind]string{BackendFlatpak: "user"},
+		Timeouts: map[Operation]Duration{OperationSearch: Duration(time.Second)},
+	}
+	multi, err := NewFromConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+	backends := multi.Backends()
+	if len(backends) != 2 || backends[0].Kind != BackendSnap || backends[1].Kind != BackendFlatpak {
+		t.Fatalf("Unexpected backends %+v", backends)
+	}
+	if _, ok := backends[0].Manager.(*WrappedManager); !ok {
+		t.Errorf("Expected timeouts to wrap managers, got %T", backends[0].Manager)
+	}
+}
+
+func TestConfig_TimeoutMiddleware(t *testing.T) {
+	cfg := &Config{Timeout: Duration(time.Hour), Timeouts: map[Operation]Duration{OperationSearch: Duration(time.Millisecond)}}
+	var deadline time.Duration
+	var hasDeadline bool
+	h := cfg.timeoutMiddleware(func(ctx context.Context, call *Call) (any, error) {
+		var d time.Time
+		d, hasDeadline = ctx.Deadline()
+		deadline = time.Until(d)
+		return nil, nil
+	})
+
+	_, _ = h(context.Background(), &Call{Operation: OperationSearch})
+	if !hasDeadline || deadline > time.Millisecond {
+		t.Errorf("Expected per-operation timeout, got %v", deadline)
+	}
+	// The global timeout is left to the backends (see WithTimeout).
+	_, _ = h(context.Background(), &Call{Operation: OperationInstall})
+	if hasDeadline {
+		t.Errorf("Expected no deadline for operations without an override, got %v", deadline)
+	}
+}
+
+func TestConfig_OptionsTimeout(t *testing.T) {
+	cfg := &Config{Timeout: Duration(5 * time.Minute)}
+	bc := &backendConfig{}
+	for _, opt := range cfg.Options() {
+		opt(bc)
+	}
+	if bc.timeout != 5*time.Minute {
+		t.Errorf("timeout = %v, want 5m", bc.timeout)
+	}
+}
@@ -0,0 +1,137 @@
+package pm
+
+import (
+	"context"
+	"slices"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ChangePlan describes what an Install, Uninstall or Upgrade is about to
+// change, for the callback given to WithConfirm.
+type ChangePlan struct {
+	Backend   BackendKind
+	Operation Operation
+
+	// Changes has one entry per package passed to Install or Uninstall, in
+	// order. For Upgrade it lists the installed packages it was restricted
+	// to (see UpgradeOptions.Packages), or else every installed package.
+	Changes []PlannedChange
+}
+
+// PlannedChange is a package in a ChangePlan. Fields the backend could not
+// look up are left empty.
+type PlannedChange struct {
+	Ref PackageRef
+
+	// Installed reports whether the package is installed now, and
+	// CurrentVersion at which version.
+	Installed      bool
+	CurrentVersion string
+
+	// Version is the version Install would fetch, for packages that are
+	// not installed. brew and snap report it; flatpak does not.
+	Version string
+
+	// Size is the installed size in bytes, which Uninstall frees, or 0 if
+	// the package is not installed or the backend does not report it.
+	Size int64
+}
+
+// WithConfirm makes Install, Uninstall and Upgrade compute a ChangePlan
+// and pass it to confirm before changing anything, so interactive tools
+// can show a confirmation dialog. If confirm returns false the operation
+// fails with a DeclinedError; if it returns an error the operation fails
+// with that error. The plan is computed with read-only lookups (Query,
+// ListInstalled and Verify, where the backend has them) before the
+// operation lock is taken, so a dialog never blocks other operations.
+//
+// Non-interactive backends (WithNonInteractive) and simulations
+// (WithSimulation) never ask: they proceed as if confirmed.
+func WithConfirm(confirm func(plan ChangePlan) (bool, error)) ConstructorOption {
+	return func(config *backendConfig) {
+		config.confirm = confirm
+	}
+}
+
+// confirmer returns the confirm callback adapters should call, or nil if
+// they should not ask.
+func (c *backendConfig) confirmer() func(ChangePlan) (bool, error) {
+	if c.nonInteractive || c.simulate {
+		return nil
+	}
+	return c.confirm
+}
+
+// confirmed asks the confirm callback, if any, whether op may change pkgs.
+// It returns nil if the operation may go ahead.
+func (a *backendAdapter) confirmed(ctx context.Context, op Operation, pkgs []PackageRef) error {
+	if a.confirm == nil {
+		return nil
+	}
+	ok, err := a.confirm(a.plan(ctx, op, pkgs))
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return &DeclinedError{Operation: op, Backend: string(a.kind)}
+	}
+	return nil
+}
+
+// plan computes the ChangePlan for op on pkgs. Lookups that fail leave
+// fields empty rather than blocking the operation.
+func (a *backendAdapter) plan(ctx context.Context, op Operation, pkgs []PackageRef) ChangePlan {
+	plan := ChangePlan{Backend: a.kind, Operation: op}
+	if op == OperationUpgradePackages {
+		installed, _ := a.backend.ListInstalled(ctx, types.ListOptions{})
+		for _, p := range installed {
+			if len(pkgs) > 0 && !slices.ContainsFunc(pkgs, func(r PackageRef) bool { return r.Name == p.Ref.Name }) {
+				continue
+			}
+			plan.Changes = append(plan.Changes, PlannedChange{
+				Ref:            convertPackageRef(p.Ref),
+				Installed:      true,
+				CurrentVersion: p.Version,
+				Size:           p.Size,
+			})
+		}
+		return plan
+	}
+
+	current := make(map[string]types.InstalledPackage)
+	for _, p := range a.installedPackages(ctx, pkgs) {
+		current[p.Ref.Name] = p
+	}
+	var missing []types.PackageRef
+	for _, p := range pkgs {
+		change := PlannedChange{Ref: p}
+		if installed, ok := current[p.Name]; ok {
+			change.Installed, change.CurrentVersion, change.Size = true, installed.Version, installed.Size
+		} else {
+			missing = append(missing, types.PackageRef{Name: p.Name, Namespace: p.Namespace, Channel: p.Channel, Kind: p.Kind})
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	// Verify attests packages from what Install would fetch
+	verifier, ok := a.backend.(internalVerifier)
+	if op != OperationInstall || len(missing) == 0 || !ok {
+		return plan
+	}
+	attestations, err := verifier.Verify(ctx, missing, types.VerifyOptions{})
+	if err != nil || len(attestations) != len(missing) {
+		return plan
+	}
+	next := 0
+	for i := range plan.Changes {
+		if plan.Changes[i].Installed {
+			continue
+		}
+		if at := attestations[next]; at.Status != types.AttestationNotFound {
+			plan.Changes[i].Version = at.Version
+		}
+		next++
+	}
+	return plan
+}
@@ -0,0 +1,84 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// planBackend counts installs and attests every package at version 1.7.1.
+type planBackend struct {
+	countingBackend
+	installs int
+}
+
+func (b *planBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	b.installs++
+	return b.countingBackend.Install(ctx, pkgs, opts)
+}
+
+func (b *planBackend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	var out []types.Attestation
+	for _, p := range pkgs {
+		out = append(out, types.Attestation{Ref: p, Version: "1.7.1", Status: types.AttestationVerified})
+	}
+	return out, nil
+}
+
+func TestWithConfirm(t *testing.T) {
+	backend := &planBackend{countingBackend: countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git", Kind: "formula"}, Version: "2.47.0", Size: 4096},
+	}}}
+	var plans []ChangePlan
+	answer, answerErr := false, error(nil)
+	cfg := &backendConfig{confirm: func(plan ChangePlan) (bool, error) {
+		plans = append(plans, plan)
+		return answer, answerErr
+	}}
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, cfg))
+	pkgs := []PackageRef{{Name: "git"}, {Name: "jq"}}
+
+	_, err := mgr.Install(context.Background(), pkgs, InstallOptions{})
+	var declined *DeclinedError
+	if !errors.As(err, &declined) || !IsDeclined(err) || declined.Operation != OperationInstall {
+		t.Errorf("Install() error = %v, want a DeclinedError", err)
+	}
+	if backend.installs != 0 {
+		t.Errorf("Install() ran %d times after being declined", backend.installs)
+	}
+	want := ChangePlan{Backend: BackendBrew, Operation: OperationInstall, Changes: []PlannedChange{
+		{Ref: PackageRef{Name: "git"}, Installed: true, CurrentVersion: "2.47.0", Size: 4096},
+		{Ref: PackageRef{Name: "jq"}, Version: "1.7.1"},
+	}}
+	if len(plans) != 1 || !reflect.DeepEqual(plans[0], want) {
+		t.Errorf("plans = %+v, want %+v", plans, want)
+	}
+
+	answerErr = errors.New("dialog closed")
+	if _, err := mgr.Install(context.Background(), pkgs, InstallOptions{}); !errors.Is(err, answerErr) {
+		t.Errorf("Install() error = %v, want the callback's error", err)
+	}
+
+	answer, answerErr = true, nil
+	if _, err := mgr.Install(context.Background(), pkgs, InstallOptions{}); err != nil || backend.installs != 1 {
+		t.Errorf("Install() error = %v after %d installs, want one install", err, backend.installs)
+	}
+
+	plans = nil
+	if _, err := mgr.Upgrade(context.Background(), UpgradeOptions{}); err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(plans) != 1 || len(plans[0].Changes) != 1 || plans[0].Changes[0].Ref.Name != "git" {
+		t.Errorf("Upgrade() plans = %+v, want every installed package", plans)
+	}
+
+	cfg.nonInteractive = true
+	answer = false
+	mgr = Wrap(newBackendAdapter(BackendBrew, backend, cfg))
+	if _, err := mgr.Install(context.Background(), pkgs, InstallOptions{}); err != nil {
+		t.Errorf("Install() error = %v, want non-interactive installs not to ask", err)
+	}
+}
@@ -0,0 +1,343 @@
+// Package conformance provides a reusable test suite that checks a pm
+// backend against the contract documented on the pm interfaces, so in-tree
+// and third-party backends can prove compliance:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) pm.Manager {
+//			return mybackend.New(mybackend.WithRunner(fakeRunner(t)))
+//		}, conformance.WithPackages(pm.PackageRef{Name: "hello"}))
+//	}
+//
+// The suite calls every operation the backend implements, including
+// Upgrade and, with WithPackages, Install and Uninstall. Point it at a
+// backend built on fakes or at a disposable environment.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/frostyard/pm"
+)
+
+// Factory returns a fresh backend for one subtest. It may call t.Skip, for
+// example when the package manager is not installed.
+type Factory func(t *testing.T) pm.Manager
+
+// Option configures Run.
+type Option func(*config)
+
+type config struct {
+	packages []pm.PackageRef
+}
+
+// WithPackages names packages the suite may install and uninstall. Without
+// it, checks that need a package (install/uninstall round trips and their
+// cancellation) are skipped.
+func WithPackages(pkgs ...pm.PackageRef) Option {
+	return func(c *config) {
+		c.packages = append(c.packages, pkgs...)
+	}
+}
+
+// Run runs the conformance suite as subtests of t, calling factory for a
+// fresh backend in each.
+func Run(t *testing.T, factory Factory, opts ...Option) {
+	t.Helper()
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t.Run("Capabilities", func(t *testing.T) { testCapabilities(t, factory(t)) })
+	t.Run("NotSupported", func(t *testing.T) { testNotSupported(t, factory(t), cfg) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, factory(t)) })
+	t.Run("Upgrade", func(t *testing.T) { testUpgrade(t, factory(t)) })
+	t.Run("EmptyInput", func(t *testing.T) { testEmptyInput(t, factory(t)) })
+	t.Run("InstallUninstall", func(t *testing.T) { testRoundTrip(t, factory(t), cfg) })
+	t.Run("Cancellation", func(t *testing.T) { testCancellation(t, factory(t), cfg) })
+}
+
+// supported returns the operations the backend reports as supported.
+func supported(t *testing.T, m pm.Manager) map[pm.Operation]bool {
+	t.Helper()
+	caps, err := m.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	ops := make(map[pm.Operation]bool)
+	for _, c := range caps {
+		ops[c.Operation] = c.Supported
+	}
+	return ops
+}
+
+// call runs op on m if m implements it, reporting whether it did.
+func call(ctx context.Context, m pm.Manager, op pm.Operation, pkgs []pm.PackageRef) (bool, error) {
+	switch op {
+	case pm.OperationUpdateMetadata:
+		if u, ok := m.(pm.Updater); ok {
+			_, err := u.Update(ctx, pm.UpdateOptions{})
+			return true, err
+		}
+	case pm.OperationUpgradePackages:
+		if u, ok := m.(pm.Upgrader); ok {
+			_, err := u.Upgrade(ctx, pm.UpgradeOptions{})
+			return true, err
+		}
+	case pm.OperationInstall:
+		if i, ok := m.(pm.Installer); ok {
+			_, err := i.Install(ctx, pkgs, pm.InstallOptions{})
+			return true, err
+		}
+	case pm.OperationUninstall:
+		if u, ok := m.(pm.Uninstaller); ok {
+			_, err := u.Uninstall(ctx, pkgs, pm.UninstallOptions{})
+			return true, err
+		}
+	case pm.OperationSearch:
+		if s, ok := m.(pm.Searcher); ok {
+			_, err := s.Search(ctx, "conformance", pm.SearchOptions{})
+			return true, err
+		}
+	case pm.OperationListInstalled:
+		if l, ok := m.(pm.Lister); ok {
+			_, err := l.ListInstalled(ctx, pm.ListOptions{})
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// operations are the operations call knows how to run.
+var operations = []pm.Operation{
+	pm.OperationUpdateMetadata,
+	pm.OperationUpgradePackages,
+	pm.OperationInstall,
+	pm.OperationUninstall,
+	pm.OperationSearch,
+	pm.OperationListInstalled,
+}
+
+// testCapabilities checks that each operation is reported at most once.
+func testCapabilities(t *testing.T, m pm.Manager) {
+	caps, err := m.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	seen := make(map[pm.Operation]bool)
+	for _, c := range caps {
+		if seen[c.Operation] {
+			t.Errorf("Capabilities() reports %s more than once", c.Operation)
+		}
+		seen[c.Operation] = true
+	}
+}
+
+// testNotSupported checks that operations reported as unsupported fail with
+// ErrNotSupported, and that no supported operation does.
+func testNotSupported(t *testing.T, m pm.Manager, cfg *config) {
+	ops := supported(t, m)
+	for _, op := range operations {
+		if op == pm.OperationInstall || op == pm.OperationUninstall {
+			if len(cfg.packages) == 0 || ops[op] {
+				// Supported mutations are exercised by InstallUninstall.
+				continue
+			}
+		}
+		if op == pm.OperationUpgradePackages && ops[op] {
+			continue // exercised by Upgrade
+		}
+		implemented, err := call(context.Background(), m, op, cfg.packages)
+		if !implemented {
+			continue
+		}
+		if got := pm.IsNotSupported(err); got != !ops[op] {
+			t.Errorf("%s: supported = %v in Capabilities, but returned %v", op, ops[op], err)
+		}
+	}
+}
+
+// testUpdate checks that Update does not change installed packages.
+func testUpdate(t *testing.T, m pm.Manager) {
+	u, ok := m.(pm.Updater)
+	if !ok || !supported(t, m)[pm.OperationUpdateMetadata] {
+		t.Skip("Update not supported")
+	}
+	before := listed(t, m)
+	if _, err := u.Update(context.Background(), pm.UpdateOptions{}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if after := listed(t, m); before != nil && !slices.Equal(names(before), names(after)) {
+		t.Errorf("Update changed installed packages from %v to %v", names(before), names(after))
+	}
+}
+
+// testUpgrade checks that Changed agrees with PackagesChanged.
+func testUpgrade(t *testing.T, m pm.Manager) {
+	u, ok := m.(pm.Upgrader)
+	if !ok || !supported(t, m)[pm.OperationUpgradePackages] {
+		t.Skip("Upgrade not supported")
+	}
+	res, err := u.Upgrade(context.Background(), pm.UpgradeOptions{})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(res.PackagesChanged) > 0 && !res.Changed {
+		t.Errorf("Upgrade reported packages %v with Changed = false", res.PackagesChanged)
+	}
+	if !res.Changed && len(res.PackagesChanged) > 0 {
+		t.Errorf("Upgrade reported Changed = false with packages %v", res.PackagesChanged)
+	}
+}
+
+// testEmptyInput checks that empty requests are no-ops.
+func testEmptyInput(t *testing.T, m pm.Manager) {
+	ops := supported(t, m)
+	ctx := context.Background()
+	if i, ok := m.(pm.Installer); ok && ops[pm.OperationInstall] {
+		res, err := i.Install(ctx, nil, pm.InstallOptions{})
+		if err != nil || res.Changed || len(res.PackagesInstalled) > 0 {
+			t.Errorf("Install(nil) = %+v, %v; want no change and no error", res, err)
+		}
+	}
+	if u, ok := m.(pm.Uninstaller); ok && ops[pm.OperationUninstall] {
+		res, err := u.Uninstall(ctx, nil, pm.UninstallOptions{})
+		if err != nil || res.Changed || len(res.PackagesUninstalled) > 0 {
+			t.Errorf("Uninstall(nil) = %+v, %v; want no change and no error", res, err)
+		}
+	}
+	if s, ok := m.(pm.Searcher); ok && ops[pm.OperationSearch] {
+		results, err := s.Search(ctx, "", pm.SearchOptions{})
+		if err != nil || len(results) > 0 {
+			t.Errorf("Search(\"\") = %v, %v; want no results and no error", results, err)
+		}
+	}
+}
+
+// testRoundTrip installs and uninstalls the configured packages twice each,
+// checking result consistency, idempotence and, for Listers, the installed
+// list.
+func testRoundTrip(t *testing.T, m pm.Manager, cfg *config) {
+	i, iok := m.(pm.Installer)
+	u, uok := m.(pm.Uninstaller)
+	ops := supported(t, m)
+	if !iok || !uok || !ops[pm.OperationInstall] || !ops[pm.OperationUninstall] {
+		t.Skip("Install and Uninstall not supported")
+	}
+	if len(cfg.packages) == 0 {
+		t.Skip("no packages configured (see WithPackages)")
+	}
+	ctx := context.Background()
+
+	res, err := i.Install(ctx, cfg.packages, pm.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if res.Changed != (len(res.PackagesInstalled) > 0) {
+		t.Errorf("Install: Changed = %v with packages %v", res.Changed, res.PackagesInstalled)
+	}
+	checkSkipped(t, "Install", res.Skipped, pm.IsAlreadyInstalled)
+	if installed := listed(t, m); installed != nil {
+		for _, pkg := range cfg.packages {
+			if !slices.Contains(names(installed), pkg.Name) {
+				t.Errorf("%s not listed after Install", pkg.Name)
+			}
+		}
+	}
+
+	res, err = i.Install(ctx, cfg.packages, pm.InstallOptions{})
+	if err != nil && len(res.Skipped) < len(cfg.packages) {
+		t.Errorf("Second Install() error = %v, want nil or every package skipped", err)
+	}
+	if len(res.PackagesInstalled) > 0 {
+		t.Errorf("Second Install reinstalled %v", res.PackagesInstalled)
+	}
+
+	ures, err := u.Uninstall(ctx, cfg.packages, pm.UninstallOptions{})
+	if err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if ures.Changed != (len(ures.PackagesUninstalled) > 0) {
+		t.Errorf("Uninstall: Changed = %v with packages %v", ures.Changed, ures.PackagesUninstalled)
+	}
+	checkSkipped(t, "Uninstall", ures.Skipped, pm.IsNotInstalled)
+	if installed := listed(t, m); installed != nil {
+		for _, pkg := range cfg.packages {
+			if slices.Contains(names(installed), pkg.Name) {
+				t.Errorf("%s still listed after Uninstall", pkg.Name)
+			}
+		}
+	}
+
+	ures, err = u.Uninstall(ctx, cfg.packages, pm.UninstallOptions{})
+	if err != nil && len(ures.Skipped) < len(cfg.packages) {
+		t.Errorf("Second Uninstall() error = %v, want nil or every package skipped", err)
+	}
+	if len(ures.PackagesUninstalled) > 0 {
+		t.Errorf("Second Uninstall removed %v", ures.PackagesUninstalled)
+	}
+}
+
+// testCancellation checks that operations given a cancelled context fail
+// with the context's error and change nothing.
+func testCancellation(t *testing.T, m pm.Manager, cfg *config) {
+	ops := supported(t, m)
+	before := listed(t, m)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, op := range operations {
+		if !ops[op] {
+			continue
+		}
+		if (op == pm.OperationInstall || op == pm.OperationUninstall) && len(cfg.packages) == 0 {
+			continue
+		}
+		implemented, err := call(ctx, m, op, cfg.packages)
+		if implemented && !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with a cancelled context returned %v, want context.Canceled", op, err)
+		}
+	}
+	if after := listed(t, m); before != nil && !slices.Equal(names(before), names(after)) {
+		t.Errorf("Cancelled operations changed installed packages from %v to %v", names(before), names(after))
+	}
+}
+
+// checkSkipped checks that each skipped entry satisfies is.
+func checkSkipped(t *testing.T, op string, skipped []error, is func(error) bool) {
+	t.Helper()
+	for _, err := range skipped {
+		if !is(err) {
+			t.Errorf("%s: unexpected Skipped entry %v", op, err)
+		}
+	}
+}
+
+// listed returns the installed packages, or nil if m cannot list them.
+func listed(t *testing.T, m pm.Manager) []pm.InstalledPackage {
+	t.Helper()
+	l, ok := m.(pm.Lister)
+	if !ok || !supported(t, m)[pm.OperationListInstalled] {
+		return nil
+	}
+	installed, err := l.ListInstalled(context.Background(), pm.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListInstalled() error = %v", err)
+	}
+	if installed == nil {
+		installed = []pm.InstalledPackage{}
+	}
+	return installed
+}
+
+// names returns the sorted names of pkgs.
+func names(pkgs []pm.InstalledPackage) []string {
+	out := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		out = append(out, p.Ref.Name)
+	}
+	slices.Sort(out)
+	return out
+}
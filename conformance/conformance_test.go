@@ -0,0 +1,33 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/conformance"
+	"github.com/frostyard/pm/pmtest"
+)
+
+func TestFakeManager(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) pm.Manager {
+		fake := pmtest.NewFakeManager()
+		fake.Catalog = []pm.PackageRef{{Name: "hello"}}
+		return fake
+	}, conformance.WithPackages(pm.PackageRef{Name: "hello"}))
+}
+
+func TestFlatpak(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) pm.Manager {
+		fake := pm.RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+			if err := ctx.Err(); err != nil {
+				return "", "", err
+			}
+			if len(args) == 1 && args[0] == "--version" {
+				return "Flatpak 1.14.4\n", "", nil
+			}
+			return "", "", nil
+		})
+		return pm.NewFlatpak(pm.WithRunner(fake), pm.WithoutOperationLock())
+	})
+}
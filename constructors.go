@@ -2,35 +2,206 @@ package pm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http"
+	"reflect"
+	"time"
 
 	"github.com/frostyard/pm/internal/backend/brew"
 	"github.com/frostyard/pm/internal/backend/flatpak"
 	"github.com/frostyard/pm/internal/backend/snap"
 	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
+	"github.com/frostyard/pm/progress"
 )
 
+// internalBackend is the method set every internal backend implements.
+type internalBackend interface {
+	Available(ctx context.Context) (bool, error)
+	Capabilities(ctx context.Context) ([]types.Capability, error)
+	Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error)
+	Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error)
+	Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error)
+	Uninstall(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error)
+	Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error)
+	ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error)
+}
+
+// displayNames are the names Manager.Name reports for each backend kind.
+var displayNames = map[BackendKind]string{
+	BackendBrew:    "Homebrew",
+	BackendFlatpak: "Flatpak",
+	BackendSnap:    "Snap",
+}
+
 // backendAdapter wraps internal backend types to expose pm package types.
 type backendAdapter struct {
-	backend interface {
-		Available(ctx context.Context) (bool, error)
-		Capabilities(ctx context.Context) ([]types.Capability, error)
-		Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error)
-		Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error)
-		Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error)
-		Uninstall(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error)
-		Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error)
-		ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error)
+	backend  internalBackend
+	kind     BackendKind
+	cache    Cache
+	cacheTTL map[Operation]time.Duration
+	noLock   bool
+	audit    AuditLogger
+	simulate bool
+	timeout  time.Duration
+	progress ProgressReporter
+
+	outputLimit *int
+	redactions  []runner.Redaction
+
+	clock func() time.Time
+	newID func() string
+
+	availability *probeCache[bool]
+	capabilities *probeCache[[]Capability]
+	batchSize    int
+
+	// arch is the architecture given to WithArch, if any.
+	arch string
+
+	desktop         *desktopRefresher
+	changeListeners []func(context.Context, ChangeEvent)
+
+	// confirm is the callback given to WithConfirm, nil if there is none
+	// or the adapter is non-interactive.
+	confirm func(ChangePlan) (bool, error)
+
+	icons         iconStore
+	watchInterval time.Duration
+
+	// customRunner reports that the backend's commands run through a
+	// runner given to WithRunner, which may run them elsewhere.
+	customRunner bool
+
+	// lockTarget is where the commands run, for the operation lock: the
+	// custom runner's Target, or "" for the local machine.
+	lockTarget string
+
+	// binaryPath is the backend's executable, overridden or discovered
+	// outside PATH, or "" to look it up on PATH.
+	binaryPath string
+}
+
+// newBackendAdapter wraps an internal backend with the shared constructor configuration.
+func newBackendAdapter(kind BackendKind, backend internalBackend, cfg *backendConfig) *backendAdapter {
+	return &backendAdapter{
+		backend:  backend,
+		kind:     kind,
+		cache:    cfg.cache,
+		cacheTTL: cfg.cacheTTL,
+		noLock:   cfg.noLock,
+		audit:    cfg.audit,
+		simulate: cfg.simulate,
+		timeout:  cfg.timeout,
+		progress: cfg.progress,
+
+		outputLimit: cfg.outputLimit(kind),
+		redactions:  cfg.redactions(),
+
+		clock: cfg.clock,
+		newID: cfg.newID,
+
+		availability: newProbeCache[bool](cfg.availabilityTTL),
+		capabilities: newProbeCache[[]Capability](cfg.availabilityTTL),
+		batchSize:    cfg.batchSizeOrDefault(),
+
+		arch: cfg.arch,
+
+		desktop:         cfg.desktopRefresher(kind),
+		changeListeners: cfg.changeListeners,
+
+		confirm: cfg.confirmer(),
+
+		icons:         cfg.iconStore(kind),
+		watchInterval: cfg.watchInterval,
+		customRunner:  cfg.runners[kind] != nil,
+		lockTarget:    cfg.runnerTarget(kind),
+		binaryPath:    cfg.binaryPath(kind),
+	}
+}
+
+// Name implements Manager.
+func (a *backendAdapter) Name() string {
+	if name, ok := displayNames[a.kind]; ok {
+		return name
+	}
+	return string(a.kind)
+}
+
+// Kind implements Manager.
+func (a *backendAdapter) Kind() BackendKind {
+	return a.kind
+}
+
+// operationContext prepares ctx for one operation, applying the adapter's
+// output limit, redactions and default timeout, and cancelling it when the operation's
+// reporter (see progressFor) requests it. The returned cancel function must
+// always be called.
+func (a *backendAdapter) operationContext(ctx context.Context, override ProgressReporter) (context.Context, context.CancelFunc) {
+	if a.outputLimit != nil {
+		ctx = runner.WithOutputLimit(ctx, *a.outputLimit)
+	}
+	if a.redactions != nil {
+		ctx = runner.WithRedactions(ctx, a.redactions)
+	}
+	ctx, cancelDeadline := a.withDeadline(ctx)
+
+	requested := progress.CancelRequests(a.progressFor(ctx, override))
+	if requested == nil {
+		return ctx, cancelDeadline
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-requested:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		cancelDeadline()
+	}
+}
+
+// progressFor returns the reporter for one operation: override if set,
+// otherwise the reporter given at construction, otherwise the one ctx
+// carries (see ContextWithProgress).
+func (a *backendAdapter) progressFor(ctx context.Context, override ProgressReporter) ProgressReporter {
+	switch {
+	case override != nil:
+		return override
+	case a.progress != nil:
+		return a.progress
 	}
+	return ProgressFromContext(ctx)
 }
 
+// lock serializes mutating operations on this adapter's backend.
+// It returns a release function and the time spent waiting.
+func (a *backendAdapter) lock(ctx context.Context) (func(), time.Duration, error) {
+	if a.noLock {
+		return func() {}, 0, nil
+	}
+	return acquireBackendLock(ctx, a.kind, a.lockTarget)
+}
+
+// joinErrorType is the type of the errors returned by errors.Join.
+var joinErrorType = reflect.TypeOf(errors.Join(errors.New("")))
+
 // convertError converts internal error types to public error types.
 func convertError(err error) error {
 	if err == nil {
 		return nil
 	}
 
+	// Convert joined errors one by one, such as a command failure joined
+	// with the packages it skipped, so that the failure is kept.
+	if reflect.TypeOf(err) == joinErrorType {
+		return errors.Join(convertErrors(err.(interface{ Unwrap() []error }).Unwrap())...)
+	}
+
 	// Convert base errors
 	if err == types.ErrNotSupported {
 		return ErrNotSupported
@@ -63,6 +234,163 @@ func convertError(err error) error {
 		return ErrNotAvailable
 	}
 
+	if types.IsEscalationUnavailable(err) {
+		var escErr *types.EscalationError
+		if errors.As(err, &escErr) {
+			return &EscalationError{
+				Operation: Operation(escErr.Operation),
+				Backend:   escErr.Backend,
+				Strategy:  Escalation(escErr.Strategy),
+				Reason:    escErr.Reason,
+				Stderr:    escErr.Stderr,
+				Hint:      Escalation(escErr.Hint),
+			}
+		}
+		return ErrEscalationUnavailable
+	}
+
+	if types.IsPermissionDenied(err) {
+		var pdErr *types.PermissionDeniedError
+		if errors.As(err, &pdErr) {
+			return &PermissionDeniedError{
+				Operation: Operation(pdErr.Operation),
+				Backend:   pdErr.Backend,
+				Strategy:  Escalation(pdErr.Strategy),
+				Reason:    pdErr.Reason,
+				Stderr:    pdErr.Stderr,
+				Hint:      Escalation(pdErr.Hint),
+				Err:       convertError(pdErr.Err),
+			}
+		}
+		return ErrPermissionDenied
+	}
+
+	var conflictErr *types.ConflictError
+	if errors.As(err, &conflictErr) {
+		return &ConflictError{
+			Operation:  Operation(conflictErr.Operation),
+			Backend:    conflictErr.Backend,
+			Holder:     conflictErr.Holder,
+			RetryAfter: conflictErr.RetryAfter,
+			Err:        convertError(conflictErr.Err),
+		}
+	}
+
+	var timeoutErr *types.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return &TimeoutError{
+			Operation:     Operation(timeoutErr.Operation),
+			Backend:       timeoutErr.Backend,
+			Elapsed:       timeoutErr.Elapsed,
+			PartialStdout: timeoutErr.PartialStdout,
+			Err:           convertError(timeoutErr.Err),
+		}
+	}
+
+	var cancelledErr *types.CancelledError
+	if errors.As(err, &cancelledErr) {
+		return &CancelledError{
+			Operation: Operation(cancelledErr.Operation),
+			Backend:   cancelledErr.Backend,
+			Graceful:  cancelledErr.Graceful,
+			Err:       cancelledErr.Err,
+		}
+	}
+
+	if types.IsInteractionRequired(err) {
+		var irErr *types.InteractionRequiredError
+		if errors.As(err, &irErr) {
+			return &InteractionRequiredError{
+				Operation: Operation(irErr.Operation),
+				Backend:   irErr.Backend,
+				Prompt:    irErr.Prompt,
+				Expected:  irErr.Expected,
+			}
+		}
+		return ErrInteractionRequired
+	}
+
+	if types.IsPackageNotFound(err) {
+		var pnfErr *types.PackageNotFoundError
+		if errors.As(err, &pnfErr) {
+			return &PackageNotFoundError{
+				Ref:         convertPackageRef(pnfErr.Ref),
+				Backend:     pnfErr.Backend,
+				Suggestions: pnfErr.Suggestions,
+				Err:         convertError(pnfErr.Err),
+			}
+		}
+		return ErrPackageNotFound
+	}
+
+	if types.IsAlreadyInstalled(err) {
+		var aiErr *types.AlreadyInstalledError
+		if errors.As(err, &aiErr) {
+			return &AlreadyInstalledError{
+				Ref:     convertPackageRef(aiErr.Ref),
+				Backend: aiErr.Backend,
+				Err:     convertError(aiErr.Err),
+			}
+		}
+		return ErrAlreadyInstalled
+	}
+
+	if types.IsNotInstalled(err) {
+		var niErr *types.NotInstalledError
+		if errors.As(err, &niErr) {
+			return &NotInstalledError{
+				Operation: Operation(niErr.Operation),
+				Ref:       convertPackageRef(niErr.Ref),
+				Backend:   niErr.Backend,
+				Err:       convertError(niErr.Err),
+			}
+		}
+		return ErrNotInstalled
+	}
+
+	if types.IsUnsupportedArch(err) {
+		var archErr *types.UnsupportedArchError
+		if errors.As(err, &archErr) {
+			return &UnsupportedArchError{
+				Ref:       convertPackageRef(archErr.Ref),
+				Backend:   archErr.Backend,
+				Arch:      archErr.Arch,
+				Available: archErr.Available,
+				Err:       convertError(archErr.Err),
+			}
+		}
+		return ErrUnsupportedArch
+	}
+
+	if types.IsValidation(err) {
+		var valErr *types.ValidationError
+		if errors.As(err, &valErr) {
+			return &ValidationError{
+				Operation: Operation(valErr.Operation),
+				Backend:   valErr.Backend,
+				Ref:       convertPackageRef(valErr.Ref),
+				Field:     valErr.Field,
+				Value:     valErr.Value,
+				Reason:    valErr.Reason,
+			}
+		}
+		return ErrValidation
+	}
+
+	if types.IsNetwork(err) {
+		var netErr *types.NetworkError
+		if errors.As(err, &netErr) {
+			return &NetworkError{
+				Operation:  Operation(netErr.Operation),
+				Backend:    netErr.Backend,
+				Kind:       NetworkErrorKind(netErr.Kind),
+				StatusCode: netErr.StatusCode,
+				Err:        convertError(netErr.Err),
+			}
+		}
+		return ErrNetwork
+	}
+
 	if types.IsExternalFailure(err) {
 		var extFailErr *types.ExternalFailureError
 		if errors.As(err, &extFailErr) {
@@ -73,6 +401,9 @@ func convertError(err error) error {
 				Stderr:    extFailErr.Stderr,
 				Payload:   extFailErr.Payload,
 				Err:       extFailErr.Err,
+				ExitCode:  extFailErr.ExitCode,
+				Duration:  extFailErr.Duration,
+				Category:  ErrorCategory(extFailErr.Category),
 			}
 		}
 	}
@@ -81,30 +412,53 @@ func convertError(err error) error {
 	return err
 }
 
-func (a *backendAdapter) Available(ctx context.Context) (bool, error) {
-	available, err := a.backend.Available(ctx)
-	return available, convertError(err)
+// convertPackageRef converts an internal package reference.
+func convertPackageRef(ref types.PackageRef) PackageRef {
+	return PackageRef{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+		Channel:   ref.Channel,
+		Kind:      ref.Kind,
+	}
 }
 
-func (a *backendAdapter) Capabilities(ctx context.Context) ([]Capability, error) {
-	caps, err := a.backend.Capabilities(ctx)
-	if err != nil {
-		return nil, err
+func convertInstalledPackage(p types.InstalledPackage) InstalledPackage {
+	return InstalledPackage{
+		Ref:         convertPackageRef(p.Ref),
+		Version:     p.Version,
+		Status:      p.Status,
+		Size:        p.Size,
+		InstalledAt: p.InstalledAt,
 	}
-	result := make([]Capability, len(caps))
-	for i, c := range caps {
-		result[i] = Capability{
-			Operation: Operation(c.Operation),
-			Supported: c.Supported,
-			Notes:     c.Notes,
-		}
+}
+
+// convertErrors converts each of errs with convertError.
+func convertErrors(errs []error) []error {
+	var converted []error
+	for _, err := range errs {
+		converted = append(converted, convertError(err))
 	}
-	return result, nil
+	return converted
 }
 
 func (a *backendAdapter) Update(ctx context.Context, opts UpdateOptions) (UpdateResult, error) {
-	internalOpts := types.UpdateOptions{Progress: convertProgressReporter(opts.Progress)}
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	release, wait, err := a.lock(ctx)
+	if err != nil {
+		return UpdateResult{Meta: OperationMeta{QueueWait: wait}}, err
+	}
+	defer release()
+
+	record := a.auditStart(ctx, OperationUpdateMetadata, nil)
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.UpdateOptions{Progress: pr}
 	res, err := a.backend.Update(ctx, internalOpts)
+	a.invalidateCache()
+	err = a.convertError(ctx, err)
+	if a.audit != nil {
+		err = a.auditFinish(record, nil, nil, err)
+	}
 	var messages []ProgressMessage
 	for _, m := range res.Messages {
 		messages = append(messages, ProgressMessage{
@@ -114,14 +468,39 @@ func (a *backendAdapter) Update(ctx context.Context, opts UpdateOptions) (Update
 			ActionID:  m.ActionID,
 			TaskID:    m.TaskID,
 			StepID:    m.StepID,
+			RunID:     m.RunID,
 		})
 	}
-	return UpdateResult{Changed: res.Changed, Messages: messages}, convertError(err)
+	summary.finish(err, 0)
+	return UpdateResult{Changed: res.Changed, Messages: messages, Meta: OperationMeta{QueueWait: wait}}, err
 }
 
 func (a *backendAdapter) Upgrade(ctx context.Context, opts UpgradeOptions) (UpgradeResult, error) {
-	internalOpts := types.UpgradeOptions{Progress: convertProgressReporter(opts.Progress)}
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	if err := a.confirmed(ctx, OperationUpgradePackages, opts.Packages); err != nil {
+		return UpgradeResult{}, err
+	}
+	release, wait, err := a.lock(ctx)
+	if err != nil {
+		return UpgradeResult{Meta: OperationMeta{QueueWait: wait}}, err
+	}
+	defer release()
+
+	record := a.auditStart(ctx, OperationUpgradePackages, opts.Packages)
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.UpgradeOptions{Progress: pr}
+	for _, p := range opts.Packages {
+		internalOpts.Packages = append(internalOpts.Packages, types.PackageRef{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+			Channel:   p.Channel,
+			Kind:      p.Kind,
+		})
+	}
 	res, err := a.backend.Upgrade(ctx, internalOpts)
+	a.invalidateCache()
+	err = a.convertError(ctx, err)
 	var messages []ProgressMessage
 	var pkgs []PackageRef
 	for _, m := range res.Messages {
@@ -132,6 +511,7 @@ func (a *backendAdapter) Upgrade(ctx context.Context, opts UpgradeOptions) (Upgr
 			ActionID:  m.ActionID,
 			TaskID:    m.TaskID,
 			StepID:    m.StepID,
+			RunID:     m.RunID,
 		})
 	}
 	for _, p := range res.PackagesChanged {
@@ -142,10 +522,17 @@ func (a *backendAdapter) Upgrade(ctx context.Context, opts UpgradeOptions) (Upgr
 			Kind:      p.Kind,
 		})
 	}
-	return UpgradeResult{Changed: res.Changed, PackagesChanged: pkgs, Messages: messages}, convertError(err)
+	a.changed(ctx, OperationUpgradePackages, pkgs, pr)
+	if a.audit != nil {
+		err = a.auditFinish(record, pkgs, a.installedVersions(ctx, pkgs), err)
+	}
+	summary.finish(err, len(pkgs))
+	return UpgradeResult{Changed: res.Changed, PackagesChanged: pkgs, Messages: messages, Meta: OperationMeta{QueueWait: wait}}, err
 }
 
 func (a *backendAdapter) Install(ctx context.Context, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
 	internalPkgs := make([]types.PackageRef, len(pkgs))
 	for i, p := range pkgs {
 		internalPkgs[i] = types.PackageRef{
@@ -155,8 +542,26 @@ func (a *backendAdapter) Install(ctx context.Context, pkgs []PackageRef, opts In
 			Kind:      p.Kind,
 		}
 	}
-	internalOpts := types.InstallOptions{Progress: convertProgressReporter(opts.Progress)}
-	res, err := a.backend.Install(ctx, internalPkgs, internalOpts)
+	if err := a.confirmed(ctx, OperationInstall, pkgs); err != nil {
+		return InstallResult{}, err
+	}
+	release, wait, err := a.lock(ctx)
+	if err != nil {
+		return InstallResult{Meta: OperationMeta{QueueWait: wait}}, err
+	}
+	defer release()
+
+	record := a.auditStart(ctx, OperationInstall, pkgs)
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.InstallOptions{Progress: pr}
+	var res types.InstallResult
+	var failed []PackageRef
+	if opts.ContinueOnError && len(internalPkgs) > 1 {
+		res, failed, err = a.installEach(ctx, internalPkgs, internalOpts)
+	} else {
+		res, err = a.installBatches(ctx, internalPkgs, internalOpts)
+	}
+	a.invalidateCache()
 	var messages []ProgressMessage
 	var installed []PackageRef
 	for _, m := range res.Messages {
@@ -167,6 +572,7 @@ func (a *backendAdapter) Install(ctx context.Context, pkgs []PackageRef, opts In
 			ActionID:  m.ActionID,
 			TaskID:    m.TaskID,
 			StepID:    m.StepID,
+			RunID:     m.RunID,
 		})
 	}
 	for _, p := range res.PackagesInstalled {
@@ -177,10 +583,17 @@ func (a *backendAdapter) Install(ctx context.Context, pkgs []PackageRef, opts In
 			Kind:      p.Kind,
 		})
 	}
-	return InstallResult{Changed: res.Changed, PackagesInstalled: installed, Messages: messages}, convertError(err)
+	a.changed(ctx, OperationInstall, installed, pr)
+	if a.audit != nil {
+		err = a.auditFinish(record, installed, a.installedVersions(ctx, installed), err)
+	}
+	summary.finish(err, len(installed))
+	return InstallResult{Changed: res.Changed, PackagesInstalled: installed, Skipped: convertErrors(res.Skipped), Failed: failed, Messages: messages, Meta: OperationMeta{QueueWait: wait}}, err
 }
 
 func (a *backendAdapter) Uninstall(ctx context.Context, pkgs []PackageRef, opts UninstallOptions) (UninstallResult, error) {
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
 	internalPkgs := make([]types.PackageRef, len(pkgs))
 	for i, p := range pkgs {
 		internalPkgs[i] = types.PackageRef{
@@ -190,8 +603,31 @@ func (a *backendAdapter) Uninstall(ctx context.Context, pkgs []PackageRef, opts
 			Kind:      p.Kind,
 		}
 	}
-	internalOpts := types.UninstallOptions{Progress: convertProgressReporter(opts.Progress)}
-	res, err := a.backend.Uninstall(ctx, internalPkgs, internalOpts)
+	if err := a.confirmed(ctx, OperationUninstall, pkgs); err != nil {
+		return UninstallResult{}, err
+	}
+	release, wait, err := a.lock(ctx)
+	if err != nil {
+		return UninstallResult{Meta: OperationMeta{QueueWait: wait}}, err
+	}
+	defer release()
+
+	record := a.auditStart(ctx, OperationUninstall, pkgs)
+	var versions map[string]string
+	if a.audit != nil {
+		// Resolve versions before they are removed.
+		versions = a.installedVersions(ctx, pkgs)
+	}
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.UninstallOptions{Progress: pr}
+	var res types.UninstallResult
+	var failed []PackageRef
+	if opts.ContinueOnError && len(internalPkgs) > 1 {
+		res, failed, err = a.uninstallEach(ctx, internalPkgs, internalOpts)
+	} else {
+		res, err = a.uninstallBatches(ctx, internalPkgs, internalOpts)
+	}
+	a.invalidateCache()
 	var messages []ProgressMessage
 	var uninstalled []PackageRef
 	for _, m := range res.Messages {
@@ -202,6 +638,7 @@ func (a *backendAdapter) Uninstall(ctx context.Context, pkgs []PackageRef, opts
 			ActionID:  m.ActionID,
 			TaskID:    m.TaskID,
 			StepID:    m.StepID,
+			RunID:     m.RunID,
 		})
 	}
 	for _, p := range res.PackagesUninstalled {
@@ -212,14 +649,70 @@ func (a *backendAdapter) Uninstall(ctx context.Context, pkgs []PackageRef, opts
 			Kind:      p.Kind,
 		})
 	}
-	return UninstallResult{Changed: res.Changed, PackagesUninstalled: uninstalled, Messages: messages}, convertError(err)
+	a.changed(ctx, OperationUninstall, uninstalled, pr)
+	if a.audit != nil {
+		err = a.auditFinish(record, uninstalled, versions, err)
+	}
+	summary.finish(err, len(uninstalled))
+	return UninstallResult{Changed: res.Changed, PackagesUninstalled: uninstalled, Skipped: convertErrors(res.Skipped), Failed: failed, Messages: messages, Meta: OperationMeta{QueueWait: wait}}, err
 }
 
 func (a *backendAdapter) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
-	internalOpts := types.SearchOptions{Progress: convertProgressReporter(opts.Progress)}
+	return a.search(ctx, query, opts, nil)
+}
+
+// search implements Search, passing each result to onResult, if set, as
+// soon as the backend finds it. Cached results are passed on all at once.
+func (a *backendAdapter) search(ctx context.Context, query string, opts SearchOptions, onResult func(PackageRef)) ([]PackageRef, error) {
+	key := a.cacheKey(OperationSearch, query)
+	if opts.Exact {
+		key += "/exact"
+	}
+	if opts.IncludeDescriptions {
+		key += "/descriptions"
+	}
+	if opts.Unranked {
+		key += "/unranked"
+	}
+	if a.arch != "" {
+		key += "/arch=" + a.arch
+	}
+	ranked := !opts.Exact && !opts.Unranked
+	var cached []PackageRef
+	if a.cacheGet(OperationSearch, key, &cached) {
+		if ranked {
+			cached = a.rankRefs(ctx, query, cached)
+		}
+		cached = limitRefs(cached, opts.Limit)
+		if onResult != nil {
+			for _, ref := range cached {
+				onResult(ref)
+			}
+		}
+		return cached, nil
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.SearchOptions{Progress: pr, Exact: opts.Exact, Fuzzy: ranked, IncludeDescriptions: opts.IncludeDescriptions}
+	// The stream also passes on, once the search returns, any results a
+	// backend did not send itself.
+	var stream *types.ResultStream
+	if onResult != nil {
+		stream = types.NewResultStream(types.SearchOptions{OnResult: func(ref types.PackageRef) {
+			onResult(convertPackageRef(ref))
+		}})
+		internalOpts.OnResult = stream.Send
+	}
 	internalRes, err := a.backend.Search(ctx, query, internalOpts)
 	if err != nil {
-		return nil, convertError(err)
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	if stream != nil {
+		stream.Finish(internalRes)
 	}
 	result := make([]PackageRef, len(internalRes))
 	for i, p := range internalRes {
@@ -230,31 +723,106 @@ func (a *backendAdapter) Search(ctx context.Context, query string, opts SearchOp
 			Kind:      p.Kind,
 		}
 	}
-	return result, nil
+	a.cacheSet(OperationSearch, key, result)
+	if ranked {
+		result = a.rankRefs(ctx, query, result)
+	}
+	summary.finish(nil, 0)
+	return limitRefs(result, opts.Limit), nil
+}
+
+// limitRefs returns at most limit refs; limit <= 0 means all of them.
+func limitRefs(refs []PackageRef, limit int) []PackageRef {
+	if limit > 0 && len(refs) > limit {
+		return refs[:limit:limit]
+	}
+	return refs
 }
 
 func (a *backendAdapter) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
-	internalOpts := types.ListOptions{Progress: convertProgressReporter(opts.Progress)}
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalOpts := types.ListOptions{Progress: pr}
 	internalRes, err := a.backend.ListInstalled(ctx, internalOpts)
 	if err != nil {
-		return nil, convertError(err)
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
 	}
 	result := make([]InstalledPackage, len(internalRes))
 	for i, p := range internalRes {
-		result[i] = InstalledPackage{
-			Ref: PackageRef{
-				Name:      p.Ref.Name,
-				Namespace: p.Ref.Namespace,
-				Channel:   p.Ref.Channel,
-				Kind:      p.Ref.Kind,
-			},
-			Version: p.Version,
-			Status:  p.Status,
-		}
+		result[i] = convertInstalledPackage(p)
+	}
+	if err := a.sortInstalled(result, opts); err != nil {
+		summary.finish(err, 0)
+		return nil, err
 	}
+	summary.finish(nil, 0)
 	return result, nil
 }
 
+// sortInstalled applies opts.SortBy to pkgs, naming the backend in a
+// ValidationError for an unknown field.
+func (a *backendAdapter) sortInstalled(pkgs []InstalledPackage, opts ListOptions) error {
+	err := SortInstalled(pkgs, opts.SortBy, opts.Descending)
+	var valErr *ValidationError
+	if errors.As(err, &valErr) {
+		valErr.Backend = string(a.kind)
+	}
+	return err
+}
+
+// cacheKey builds a cache key namespaced by backend and operation.
+func (a *backendAdapter) cacheKey(op Operation, arg string) string {
+	return string(a.kind) + "/" + string(op) + "/" + arg
+}
+
+// cacheTTLFor returns the configured TTL for op, falling back to the defaults.
+func (a *backendAdapter) cacheTTLFor(op Operation) time.Duration {
+	if ttl, ok := a.cacheTTL[op]; ok {
+		return ttl
+	}
+	if op == OperationSearch {
+		return DefaultSearchCacheTTL
+	}
+	return 0
+}
+
+// cacheGet decodes a cached result for key into v and reports whether it was found.
+func (a *backendAdapter) cacheGet(op Operation, key string, v interface{}) bool {
+	if a.cache == nil || a.cacheTTLFor(op) <= 0 {
+		return false
+	}
+	data, ok := a.cache.Get(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// cacheSet stores v under key using the TTL configured for op.
+func (a *backendAdapter) cacheSet(op Operation, key string, v interface{}) {
+	ttl := a.cacheTTLFor(op)
+	if a.cache == nil || ttl <= 0 {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	a.cache.Set(key, data, ttl)
+}
+
+// invalidateCache drops all cached results for this backend. It is called
+// after every mutating operation, whether or not it succeeded, since a
+// failed operation may still have changed system state.
+func (a *backendAdapter) invalidateCache() {
+	if a.cache != nil {
+		a.cache.Invalidate(string(a.kind) + "/")
+	}
+}
+
 // convertProgressReporter wraps a pm.ProgressReporter to be a types.ProgressReporter.
 func convertProgressReporter(pr ProgressReporter) types.ProgressReporter {
 	if pr == nil {
@@ -287,41 +855,50 @@ func (a *progressReporterAdapter) OnMessage(msg types.ProgressMessage) {
 		ActionID:  msg.ActionID,
 		TaskID:    msg.TaskID,
 		StepID:    msg.StepID,
+		RunID:     msg.RunID,
+		Backend:   msg.Backend,
+		Operation: msg.Operation,
 	})
 }
 
 // NewBrew creates a new Brew backend that implements Manager and other interfaces.
 func NewBrew(opts ...ConstructorOption) Manager {
-	cfg := &backendConfig{}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newBackendConfig(opts)
 
-	return &backendAdapter{
-		backend: brew.New(nil, runner.NewRealRunner(), convertProgressReporter(cfg.progress)),
+	backend := brew.New(cfg.httpClient(BackendBrew, cfg.baseTransport()), cfg.runner(BackendBrew), convertProgressReporter(cfg.progress))
+	backend.SetBaseURL(cfg.formulaeBaseURL)
+	if cfg.formulaeIndexDir != nil {
+		backend.SetIndexDir(formulaeIndexDir(*cfg.formulaeIndexDir))
+	}
+	backend.SetSearchIndex(cfg.searchIndexMaxAge)
+	backend.SetArch(cfg.targetArch())
+	if cfg.brewEnv != nil {
+		backend.SetEnv(cfg.brewEnv.vars())
 	}
+	return newBackendAdapter(BackendBrew, cfg.gate(BackendBrew, backend), cfg)
 }
 
 // NewFlatpak creates a new Flatpak backend that implements Manager and other interfaces.
 func NewFlatpak(opts ...ConstructorOption) Manager {
-	cfg := &backendConfig{}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newBackendConfig(opts)
 
-	return &backendAdapter{
-		backend: flatpak.New(runner.NewRealRunner(), convertProgressReporter(cfg.progress)),
-	}
+	backend := flatpak.New(cfg.runner(BackendFlatpak), convertProgressReporter(cfg.progress))
+	backend.SetInstallation(cfg.flatpakInstallation)
+	backend.SetArch(cfg.arch)
+	return newBackendAdapter(BackendFlatpak, cfg.gate(BackendFlatpak, backend), cfg)
 }
 
 // NewSnap creates a new Snap backend that implements Manager and other interfaces.
 func NewSnap(opts ...ConstructorOption) Manager {
-	cfg := &backendConfig{}
-	for _, opt := range opts {
-		opt(cfg)
-	}
+	cfg := newBackendConfig(opts)
 
-	return &backendAdapter{
-		backend: snap.New(nil, runner.NewRealRunner(), convertProgressReporter(cfg.progress)),
+	transport, baseURL := snap.Endpoint(cfg.snapdEndpoint, cfg.baseTransport())
+	client := cfg.httpClient(BackendSnap, transport)
+	if client == nil {
+		client = &http.Client{Transport: transport}
 	}
+	backend := snap.New(client, cfg.runner(BackendSnap), convertProgressReporter(cfg.progress))
+	backend.SetBaseURL(baseURL)
+	backend.SetRemoteCommands(cfg.runners[BackendSnap] != nil)
+	return newBackendAdapter(BackendSnap, cfg.gate(BackendSnap, backend), cfg)
 }
@@ -0,0 +1,52 @@
+package pm
+
+import "github.com/frostyard/pm/internal/runner"
+
+// ContainerEngine selects the tool NewContainerRunner uses to enter a
+// container.
+type ContainerEngine string
+
+const (
+	// ContainerDocker runs commands with `docker exec`.
+	ContainerDocker ContainerEngine = runner.ContainerDocker
+
+	// ContainerPodman runs commands with `podman exec`.
+	ContainerPodman ContainerEngine = runner.ContainerPodman
+
+	// ContainerDistrobox runs commands with `distrobox enter`.
+	ContainerDistrobox ContainerEngine = runner.ContainerDistrobox
+
+	// ContainerToolbox runs commands with `toolbox run`.
+	ContainerToolbox ContainerEngine = runner.ContainerToolbox
+)
+
+// ContainerConfig describes a running container for NewContainerRunner.
+type ContainerConfig struct {
+	// Engine is the container tool to use.
+	Engine ContainerEngine
+
+	// Container is the container name or ID.
+	Container string
+
+	// User runs commands as this user (docker and podman only); empty uses
+	// the container's default.
+	User string
+}
+
+// NewContainerRunner returns a Runner that executes commands inside a
+// running container, dev container or toolbox. Use it with WithRunner:
+//
+//	box := pm.NewContainerRunner(pm.ContainerConfig{Engine: pm.ContainerDistrobox, Container: "fedora"})
+//	mgr := pm.NewBrew(pm.WithRunner(box))
+//
+// The container must already be running; an unknown engine makes every
+// command fail. As with NewSSHRunner, only commands run in the container:
+// brew searches and snap availability checks still use the Formulae API
+// and the local snapd socket.
+func NewContainerRunner(config ContainerConfig) Runner {
+	return runner.NewContainerRunner(runner.NewRealRunner(), runner.ContainerConfig{
+		Engine:    string(config.Engine),
+		Container: config.Container,
+		User:      config.User,
+	})
+}
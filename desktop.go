@@ -0,0 +1,149 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ChangeEvent describes the packages an Install, Uninstall or Upgrade
+// changed.
+type ChangeEvent struct {
+	Backend   BackendKind
+	Operation Operation
+
+	// Packages are the packages installed, uninstalled or upgraded.
+	Packages []PackageRef
+}
+
+// WithChangeListener registers fn to be called after every Install,
+// Uninstall or Upgrade that changed packages, including one that failed
+// for others, so software-center UIs can refresh their app grids at once
+// instead of polling. fn runs synchronously on the goroutine running the
+// operation, after desktop integration (see WithDesktopIntegration). The
+// option may be given several times; listeners run in the order they were
+// added. Simulated operations change nothing and are not reported.
+func WithChangeListener(fn func(ctx context.Context, ev ChangeEvent)) ConstructorOption {
+	return func(config *backendConfig) {
+		config.changeListeners = append(config.changeListeners, fn)
+	}
+}
+
+// WithDesktopIntegration makes flatpak and snap refresh the desktop's
+// caches after they change packages: update-desktop-database for the
+// directories their launchers are exported to, and gtk-update-icon-cache
+// for their icon theme, so menus and docks pick up new applications without
+// logging out. Directories that don't exist and tools that are not
+// installed are skipped. The commands run through the backend's runner,
+// with its escalation strategy; failures are reported as progress warnings
+// and don't fail the operation. brew installs no desktop entries and
+// ignores this option.
+func WithDesktopIntegration() ConstructorOption {
+	return func(config *backendConfig) {
+		config.desktopIntegration = true
+	}
+}
+
+// exportDirs returns the directories kind exports desktop entries
+// (applications) and icons (icons/hicolor) to.
+func (c *backendConfig) exportDirs(kind BackendKind) []string {
+	switch kind {
+	case BackendFlatpak:
+		var dirs []string
+		if c.flatpakInstallation == "" || c.flatpakInstallation == "user" {
+			if data := userDataDir(); data != "" {
+				dirs = append(dirs, filepath.Join(data, "flatpak", "exports", "share"))
+			}
+		}
+		if c.flatpakInstallation == "" || c.flatpakInstallation == "system" {
+			dirs = append(dirs, "/var/lib/flatpak/exports/share")
+		}
+		return dirs
+	case BackendSnap:
+		return []string{"/var/lib/snapd/desktop"}
+	}
+	return nil
+}
+
+// userDataDir returns $XDG_DATA_HOME, or ~/.local/share.
+func userDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+// desktopRefresher refreshes the desktop caches for a backend's export
+// directories.
+type desktopRefresher struct {
+	runner runner.Runner
+	dirs   []string
+}
+
+// desktopRefresher returns the refresher for kind, or nil if desktop
+// integration is off or does not apply to kind.
+func (c *backendConfig) desktopRefresher(kind BackendKind) *desktopRefresher {
+	if !c.desktopIntegration {
+		return nil
+	}
+	dirs := c.exportDirs(kind)
+	if len(dirs) == 0 {
+		return nil
+	}
+	return &desktopRefresher{runner: c.runner(kind), dirs: dirs}
+}
+
+// refresh runs the cache tools on the directories that exist. ctx carries
+// op, so the runner escalates as for the operation itself.
+func (d *desktopRefresher) refresh(ctx context.Context, op Operation) error {
+	ctx = runner.WithOperation(ctx, types.Operation(op))
+	var errs []error
+	run := func(name string, args ...string) {
+		if _, err := lookPath(name); err != nil {
+			return
+		}
+		if _, stderr, err := d.runner.Run(ctx, name, args...); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", name, err, stderr))
+		}
+	}
+	for _, dir := range d.dirs {
+		if apps := filepath.Join(dir, "applications"); isDir(apps) {
+			run("update-desktop-database", "-q", apps)
+		}
+		if icons := filepath.Join(dir, "icons", "hicolor"); isDir(icons) {
+			run("gtk-update-icon-cache", "-q", "-t", "-f", icons)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// changed runs desktop integration and the change listeners after op
+// changed pkgs. Integration failures are reported to pr as warnings.
+func (a *backendAdapter) changed(ctx context.Context, op Operation, pkgs []PackageRef, pr types.ProgressReporter) {
+	if len(pkgs) == 0 || a.simulate {
+		return
+	}
+	if a.desktop != nil {
+		if err := a.desktop.refresh(ctx, op); err != nil {
+			types.NewOperationProgressHelper(string(a.kind), types.Operation(op), nil, pr).Warning("Desktop integration failed: " + err.Error())
+		}
+	}
+	for _, fn := range a.changeListeners {
+		fn(ctx, ChangeEvent{Backend: a.kind, Operation: op, Packages: pkgs})
+	}
+}
@@ -0,0 +1,63 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDesktopIntegration(t *testing.T) {
+	withPlatform(t, PlatformInfo{OS: "linux", Arch: "amd64"}, "flatpak", "update-desktop-database", "gtk-update-icon-cache")
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	apps := filepath.Join(data, "flatpak", "exports", "share", "applications")
+	if err := os.MkdirAll(apps, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var commands []string
+	var events []ChangeEvent
+	mgr := NewFlatpak(
+		WithDesktopIntegration(),
+		WithChangeListener(func(ctx context.Context, ev ChangeEvent) { events = append(events, ev) }),
+		WithoutOperationLock(),
+		WithRunner(RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+			if args[0] == "info" {
+				return "", "error: org.gimp.GIMP/*unspecified*/* not installed", errors.New("exit status 1")
+			}
+			commands = append(commands, name+" "+strings.Join(args, " "))
+			return "Installing org.gimp.GIMP\n", "", nil
+		})),
+	)
+	_, err := Must[Installer](mgr).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the exported directories that exist are refreshed; the system
+	// installation's may exist on this machine too
+	if len(commands) < 2 || commands[0] != "flatpak install -y org.gimp.GIMP" || !slices.Contains(commands, "update-desktop-database -q "+apps) {
+		t.Errorf("Commands = %q, want the install followed by update-desktop-database for %s", commands, apps)
+	}
+	for _, cmd := range commands {
+		if strings.Contains(cmd, data) && strings.Contains(cmd, "icons") {
+			t.Errorf("Refreshed a missing icon theme: %s", cmd)
+		}
+	}
+	if len(events) != 1 || events[0].Backend != BackendFlatpak || events[0].Operation != OperationInstall || events[0].Packages[0].Name != "org.gimp.GIMP" {
+		t.Errorf("Events = %+v, want one Install of org.gimp.GIMP", events)
+	}
+
+	// Nothing changed, nothing reported
+	_, err = Must[Uninstaller](mgr).Uninstall(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, UninstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Errorf("Events = %+v, want none for an Uninstall that removed nothing", events[1:])
+	}
+}
@@ -0,0 +1,62 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+)
+
+// AllBackends returns every backend kind known to this library, in the
+// order Detect probes them.
+func AllBackends() []BackendKind {
+	return []BackendKind{BackendBrew, BackendFlatpak, BackendSnap}
+}
+
+// New creates a backend of the given kind.
+// Returns an error if the kind is not recognized.
+func New(kind BackendKind, opts ...ConstructorOption) (Manager, error) {
+	switch kind {
+	case BackendBrew:
+		return NewBrew(opts...), nil
+	case BackendFlatpak:
+		return NewFlatpak(opts...), nil
+	case BackendSnap:
+		return NewSnap(opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", kind)
+	}
+}
+
+// Detect probes every known backend concurrently and returns the ones that
+// report themselves available, in AllBackends order.
+//
+// Backends that are unavailable or fail their availability check are
+// silently skipped; an empty result means no supported package manager
+// was found on this system. If PM_BACKENDS is set, only the listed
+// backends are probed, and results follow its order.
+func Detect(ctx context.Context, opts ...ConstructorOption) []Backend {
+	kinds := AllBackends()
+	if env, _ := EnvConfig(); len(env.Backends) > 0 {
+		kinds = env.Backends
+	}
+
+	var candidates []Backend
+	for _, kind := range kinds {
+		mgr, err := New(kind, opts...)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, Backend{Kind: kind, Manager: mgr})
+	}
+
+	available, _ := Parallel(ctx, candidates, 0, func(ctx context.Context, b Backend) (bool, error) {
+		return b.Manager.Available(ctx)
+	})
+
+	var found []Backend
+	for _, b := range candidates {
+		if available[b.Kind] {
+			found = append(found, b)
+		}
+	}
+	return found
+}
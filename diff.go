@@ -0,0 +1,72 @@
+package pm
+
+import "github.com/frostyard/pm/version"
+
+// ChangeSet is the difference between two listings of a backend's installed
+// packages, as computed by Diff.
+type ChangeSet struct {
+	// Added are the packages installed since the first listing, in the
+	// order of the second.
+	Added []InstalledPackage
+
+	// Removed are the packages no longer installed, in the order of the
+	// first listing.
+	Removed []InstalledPackage
+
+	// Upgraded and Downgraded are the packages whose version changed, in
+	// the order of the second listing.
+	Upgraded   []VersionChange
+	Downgraded []VersionChange
+}
+
+// VersionChange is a package whose installed version changed.
+type VersionChange struct {
+	// Ref is the package reference from the second listing.
+	Ref PackageRef
+
+	// From and To are the versions before and after.
+	From, To string
+}
+
+// Empty reports whether the listings were the same.
+func (c ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Upgraded) == 0 && len(c.Downgraded) == 0
+}
+
+// Diff compares two listings of the same backend, such as two inventory
+// captures a day apart, to show what changed on the machine in between.
+// Packages are matched by name, kind and namespace; a changed channel alone
+// is not a change. A version that version.Compare orders lower is a
+// downgrade, and any other different version an upgrade.
+func Diff(before, after []InstalledPackage) ChangeSet {
+	type key struct{ name, kind, namespace string }
+	keyOf := func(p InstalledPackage) key { return key{p.Ref.Name, p.Ref.Kind, p.Ref.Namespace} }
+
+	old := make(map[key]InstalledPackage, len(before))
+	for _, p := range before {
+		old[keyOf(p)] = p
+	}
+
+	var c ChangeSet
+	seen := make(map[key]bool, len(after))
+	for _, p := range after {
+		k := keyOf(p)
+		seen[k] = true
+		prev, ok := old[k]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, p)
+		case prev.Version == p.Version:
+		case version.Compare(p.Version, prev.Version) < 0:
+			c.Downgraded = append(c.Downgraded, VersionChange{Ref: p.Ref, From: prev.Version, To: p.Version})
+		default:
+			c.Upgraded = append(c.Upgraded, VersionChange{Ref: p.Ref, From: prev.Version, To: p.Version})
+		}
+	}
+	for _, p := range before {
+		if !seen[keyOf(p)] {
+			c.Removed = append(c.Removed, p)
+		}
+	}
+	return c
+}
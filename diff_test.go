@@ -0,0 +1,35 @@
+package pm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := []InstalledPackage{
+		{Ref: PackageRef{Name: "firefox", Kind: "snap", Channel: "latest/stable"}, Version: "130.0"},
+		{Ref: PackageRef{Name: "core22", Kind: "snap"}, Version: "20240823"},
+		{Ref: PackageRef{Name: "vlc", Kind: "snap"}, Version: "3.0.21"},
+		{Ref: PackageRef{Name: "gimp", Kind: "snap"}, Version: "2.10.38"},
+	}
+	after := []InstalledPackage{
+		{Ref: PackageRef{Name: "firefox", Kind: "snap", Channel: "latest/beta"}, Version: "131.0b3"},
+		{Ref: PackageRef{Name: "core22", Kind: "snap"}, Version: "20240823"},
+		{Ref: PackageRef{Name: "gimp", Kind: "snap"}, Version: "2.10.36"},
+		{Ref: PackageRef{Name: "htop", Kind: "snap"}, Version: "3.3.0"},
+	}
+
+	got := Diff(before, after)
+	want := ChangeSet{
+		Added:      []InstalledPackage{after[3]},
+		Removed:    []InstalledPackage{before[2]},
+		Upgraded:   []VersionChange{{Ref: after[0].Ref, From: "130.0", To: "131.0b3"}},
+		Downgraded: []VersionChange{{Ref: after[2].Ref, From: "2.10.38", To: "2.10.36"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got.Empty() || !Diff(before, before).Empty() {
+		t.Error("Empty() is wrong")
+	}
+}
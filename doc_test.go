@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
 )
 
 // Example_basicUsage demonstrates basic package manager usage.
@@ -92,9 +93,12 @@ func Example_updateVsUpgrade() {
 
 // Example_errorHandling demonstrates error detection and handling.
 func Example_errorHandling() {
-	backend := pm.NewSnap()
+	// A fake stands in for a snap backend whose daemon isn't running, so
+	// the output doesn't depend on the host
+	backend := pmtest.NewFakeManager()
+	backend.Fail("Install", &pm.NotAvailableError{Backend: "snap", Reason: "snapd is not running"})
 
-	_, err := backend.(pm.Installer).Install(
+	_, err := backend.Install(
 		context.Background(),
 		[]pm.PackageRef{{Name: "example"}},
 		pm.InstallOptions{},
@@ -111,7 +115,7 @@ func Example_errorHandling() {
 		fmt.Printf("Other error: %v\n", err)
 	}
 
-	// Output: External command/API failed
+	// Output: Backend not available
 }
 
 // Example_progressReporting demonstrates progress reporting during operations.
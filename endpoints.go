@@ -0,0 +1,67 @@
+package pm
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithFormulaeBaseURL points the brew backend's Homebrew Formulae API
+// requests (Available and Search) at url instead of
+// https://formulae.brew.sh/api, such as a mirror or an httptest server.
+func WithFormulaeBaseURL(url string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.formulaeBaseURL = url
+	}
+}
+
+// WithFormulaeIndex makes the brew backend keep a trimmed copy of the
+// Formulae API's formula list in dir and revalidate it with a conditional
+// request, so searches from short-lived processes don't download the full
+// list every time. An empty dir uses pm/brew under the user cache
+// directory. Processes sharing dir share the index.
+func WithFormulaeIndex(dir string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.formulaeIndexDir = &dir
+	}
+}
+
+// WithSearchIndex makes Search answer repeated queries, such as those of a
+// typeahead UI, from an in-memory index of the backend's catalog instead of
+// fetching and filtering the catalog each time. The index is refreshed once
+// it is maxAge old; a maxAge of zero or less uses DefaultSearchCacheTTL.
+//
+// Only brew searches a catalog pm can hold (the Formulae API's formula
+// list); snap and flatpak search through their CLIs and ignore this option.
+func WithSearchIndex(maxAge time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		if maxAge <= 0 {
+			maxAge = DefaultSearchCacheTTL
+		}
+		config.searchIndexMaxAge = maxAge
+	}
+}
+
+// formulaeIndexDir resolves the directory given to WithFormulaeIndex. It
+// returns "" (no index) if dir is empty and there is no user cache
+// directory.
+func formulaeIndexDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cache, "pm", "brew")
+}
+
+// WithSnapdEndpoint points the snap backend's snapd API requests at
+// endpoint instead of /run/snapd.socket. An http:// or https:// URL, such
+// as an httptest server's, is used as is; anything else is the path of a
+// Unix socket.
+func WithSnapdEndpoint(endpoint string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.snapdEndpoint = endpoint
+	}
+}
@@ -0,0 +1,117 @@
+package pm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithFormulaeBaseURL(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer server.Close()
+
+	mgr := NewBrew(WithFormulaeBaseURL(server.URL+"/api"), WithoutOperationLock())
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+	if path != "/api/formula.json" {
+		t.Errorf("Requested %q, want /api/formula.json", path)
+	}
+}
+
+func TestWithSnapdEndpoint(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/system-info" {
+			http.NotFound(w, r)
+		}
+	})
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "", nil
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		mgr := NewSnap(WithSnapdEndpoint(server.URL), WithRunner(fake), WithoutOperationLock())
+		if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+			t.Errorf("Available() = %v, %v; want true, nil", ok, err)
+		}
+	})
+
+	t.Run("Unix socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "snapd.socket")
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Skipf("Unix sockets unavailable: %v", err)
+		}
+		server := httptest.NewUnstartedServer(handler)
+		server.Listener = l
+		server.Start()
+		defer server.Close()
+
+		mgr := NewSnap(WithSnapdEndpoint(socket), WithRunner(fake), WithoutOperationLock(), WithRetryPolicy(DefaultRetryPolicy()))
+		if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+			t.Errorf("Available() = %v, %v; want true, nil", ok, err)
+		}
+	})
+}
+
+func TestWithFormulaeIndex(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"name":"git"}]`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		mgr := NewBrew(WithFormulaeBaseURL(server.URL), WithFormulaeIndex(dir), WithoutOperationLock())
+		results, err := mgr.(Searcher).Search(context.Background(), "git", SearchOptions{})
+		if err != nil || len(results) != 1 {
+			t.Fatalf("Search() #%d = %v, %v; want 1 result", i+1, results, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Requests = %d, want 2", requests)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "formula-index.json")); err != nil {
+		t.Errorf("Index not written to %s: %v", dir, err)
+	}
+}
+
+func TestWithSearchIndex(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/formula.json" {
+			http.NotFound(w, r) // install analytics, for ranking
+			return
+		}
+		requests++
+		_, _ = w.Write([]byte(`[{"name":"git"},{"name":"git-lfs"}]`))
+	}))
+	defer server.Close()
+
+	mgr := NewBrew(WithFormulaeBaseURL(server.URL), WithSearchIndex(0), WithoutOperationLock())
+	for _, query := range []string{"g", "gi", "git", "git-"} {
+		if _, err := mgr.(Searcher).Search(context.Background(), query, SearchOptions{}); err != nil {
+			t.Fatalf("Search(%q) error = %v", query, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Requests = %d, want 1", requests)
+	}
+}
@@ -0,0 +1,129 @@
+package pm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by EnvConfig.
+const (
+	// EnvBackends is a comma-separated list of backends to use, in priority order.
+	EnvBackends = "PM_BACKENDS"
+
+	// EnvBrewPath, EnvFlatpakPath and EnvSnapPath override the executable
+	// used for each backend's command-line tool.
+	EnvBrewPath    = "PM_BREW_PATH"
+	EnvFlatpakPath = "PM_FLATPAK_PATH"
+	EnvSnapPath    = "PM_SNAP_PATH"
+
+	// EnvFlatpakInstallation selects the flatpak installation: user, system, or a name.
+	EnvFlatpakInstallation = "PM_FLATPAK_INSTALLATION"
+
+	// EnvEscalation sets the escalation strategy for flatpak and snap.
+	EnvEscalation = "PM_ESCALATION"
+
+	// EnvCacheDir enables caching in the given directory; EnvCacheTTL sets
+	// how long search results are kept (a time.ParseDuration string).
+	EnvCacheDir = "PM_CACHE_DIR"
+	EnvCacheTTL = "PM_CACHE_TTL"
+
+	// EnvTimeout sets the default deadline for every operation (see WithTimeout).
+	EnvTimeout = "PM_TIMEOUT"
+
+	// EnvNonInteractive enables non-interactive mode when set to a true
+	// value (1, true, yes).
+	EnvNonInteractive = "PM_NONINTERACTIVE"
+)
+
+// lookupEnv is os.LookupEnv; replaced in tests.
+var lookupEnv = os.LookupEnv
+
+// EnvConfig returns the configuration set by PM_* environment variables,
+// so containerized and CI usage can be configured without code changes.
+//
+// Constructors (NewBrew, NewFlatpak, NewSnap, New, Detect) apply it
+// beneath their explicit options; NewFromConfig layers it between the
+// configuration file and explicit options. Invalid values are skipped and
+// reported in the returned error, alongside the valid settings.
+func EnvConfig() (*Config, error) {
+	cfg := &Config{}
+	var errs []error
+	invalid := func(name, value string, err error) {
+		errs = append(errs, fmt.Errorf("%s=%q: %w", name, value, err))
+	}
+
+	if v, ok := lookupEnv(EnvBackends); ok && v != "" {
+		for _, name := range strings.Split(v, ",") {
+			kind := BackendKind(strings.TrimSpace(name))
+			if !knownBackend(kind) {
+				invalid(EnvBackends, v, fmt.Errorf("unknown backend %q", kind))
+				continue
+			}
+			cfg.Backends = append(cfg.Backends, kind)
+		}
+	}
+
+	for kind, name := range map[BackendKind]string{BackendBrew: EnvBrewPath, BackendFlatpak: EnvFlatpakPath, BackendSnap: EnvSnapPath} {
+		if v, ok := lookupEnv(name); ok && v != "" {
+			if cfg.Paths == nil {
+				cfg.Paths = make(map[BackendKind]string)
+			}
+			cfg.Paths[kind] = v
+		}
+	}
+
+	if v, ok := lookupEnv(EnvFlatpakInstallation); ok && v != "" {
+		cfg.Scopes = map[BackendKind]string{BackendFlatpak: v}
+	}
+
+	if v, ok := lookupEnv(EnvEscalation); ok && v != "" {
+		switch s := Escalation(v); s {
+		case EscalationNone, EscalationSudo, EscalationDoas, EscalationPkexec, EscalationPolkit:
+			cfg.Escalation = map[BackendKind]Escalation{BackendFlatpak: s, BackendSnap: s}
+		default:
+			invalid(EnvEscalation, v, errors.New("unknown strategy"))
+		}
+	}
+
+	if v, ok := lookupEnv(EnvCacheDir); ok && v != "" {
+		cfg.Cache.Dir = v
+	}
+	if v, ok := lookupEnv(EnvCacheTTL); ok && v != "" {
+		if d, err := time.ParseDuration(v); err != nil {
+			invalid(EnvCacheTTL, v, err)
+		} else {
+			cfg.Cache.TTL = Duration(d)
+		}
+	}
+	if v, ok := lookupEnv(EnvTimeout); ok && v != "" {
+		if d, err := time.ParseDuration(v); err != nil {
+			invalid(EnvTimeout, v, err)
+		} else {
+			cfg.Timeout = Duration(d)
+		}
+	}
+
+	if v, ok := lookupEnv(EnvNonInteractive); ok && v != "" {
+		switch strings.ToLower(v) {
+		case "yes", "y", "on":
+			b := true
+			cfg.NonInteractive = &b
+		case "no", "n", "off":
+			b := false
+			cfg.NonInteractive = &b
+		default:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				invalid(EnvNonInteractive, v, err)
+			} else {
+				cfg.NonInteractive = &b
+			}
+		}
+	}
+
+	return cfg, errors.Join(errs...)
+}
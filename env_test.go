@@ -0,0 +1,126 @@
+package pm
+
+import (
+	"testing"
+	"time"
+)
+
+// setEnv replaces lookupEnv with a fixed environment for the duration of the test.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	orig := lookupEnv
+	lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	t.Cleanup(func() { lookupEnv = orig })
+}
+
+func TestEnvConfig(t *testing.T) {
+	setEnv(t, map[string]string{
+		EnvBackends:            "snap, flatpak",
+		EnvBrewPath:            "/opt/brew/bin/brew",
+		EnvFlatpakInstallation: "user",
+		EnvEscalation:          "sudo",
+		EnvCacheDir:            "/tmp/pm",
+		EnvCacheTTL:            "1h",
+		EnvTimeout:             "5m",
+		EnvNonInteractive:      "1",
+	})
+
+	cfg, err := EnvConfig()
+	if err != nil {
+		t.Fatalf("EnvConfig() error = %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[0] != BackendSnap || cfg.Backends[1] != BackendFlatpak {
+		t.Errorf("Unexpected backends %v", cfg.Backends)
+	}
+	if cfg.Paths[BackendBrew] != "/opt/brew/bin/brew" || cfg.Scopes[BackendFlatpak] != "user" {
+		t.Errorf("Unexpected paths/scopes %v %v", cfg.Paths, cfg.Scopes)
+	}
+	if cfg.Escalation[BackendSnap] != EscalationSudo || cfg.Escalation[BackendFlatpak] != EscalationSudo {
+		t.Errorf("Unexpected escalation %v", cfg.Escalation)
+	}
+	if _, ok := cfg.Escalation[BackendBrew]; ok {
+		t.Error("Escalation should not apply to brew")
+	}
+	if cfg.Cache.Dir != "/tmp/pm" || time.Duration(cfg.Cache.TTL) != time.Hour || time.Duration(cfg.Timeout) != 5*time.Minute {
+		t.Errorf("Unexpected cache/timeout %+v %v", cfg.Cache, cfg.Timeout)
+	}
+	if cfg.NonInteractive == nil || !*cfg.NonInteractive {
+		t.Error("Expected NonInteractive")
+	}
+}
+
+func TestEnvConfig_InvalidValuesSkipped(t *testing.T) {
+	setEnv(t, map[string]string{
+		EnvBackends:       "apt,snap",
+		EnvCacheTTL:       "forever",
+		EnvNonInteractive: "maybe",
+	})
+
+	cfg, err := EnvConfig()
+	if err == nil {
+		t.Fatal("Expected error for invalid values")
+	}
+	if len(cfg.Backends) != 1 || cfg.Backends[0] != BackendSnap {
+		t.Errorf("Expected valid backends to be kept, got %v", cfg.Backends)
+	}
+	if cfg.Cache.TTL != 0 {
+		t.Errorf("Expected invalid TTL to be skipped, got %v", cfg.Cache.TTL)
+	}
+	if cfg.NonInteractive != nil {
+		t.Errorf("Expected invalid NonInteractive to be skipped, got %v", *cfg.NonInteractive)
+	}
+}
+
+func TestEnvConfig_LayeredUnderOptions(t *testing.T) {
+	setEnv(t, map[string]string{EnvEscalation: "pkexec", EnvNonInteractive: "true"})
+
+	cfg := newBackendConfig([]ConstructorOption{WithEscalation(EscalationSudo, BackendSnap)})
+	if cfg.escalation[BackendSnap] != EscalationSudo {
+		t.Errorf("Explicit option should override environment, got %q", cfg.escalation[BackendSnap])
+	}
+	if cfg.escalation[BackendFlatpak] != EscalationPkexec {
+		t.Errorf("Environment should apply where no option is given, got %q", cfg.escalation[BackendFlatpak])
+	}
+	if !cfg.nonInteractive {
+		t.Error("Expected non-interactive mode from environment")
+	}
+}
+
+func TestConfig_MergeEnvOverFile(t *testing.T) {
+	file := &Config{
+		Backends: []BackendKind{BackendBrew},
+		Paths:    map[BackendKind]string{BackendBrew: "/file/brew", BackendSnap: "/file/snap"},
+		Timeout:  Duration(time.Minute),
+	}
+	env := &Config{Paths: map[BackendKind]string{BackendBrew: "/env/brew"}}
+
+	merged := file.Merge(env)
+	if merged.Paths[BackendBrew] != "/env/brew" || merged.Paths[BackendSnap] != "/file/snap" {
+		t.Errorf("Unexpected merged paths %v", merged.Paths)
+	}
+	if len(merged.Backends) != 1 || merged.Timeout != Duration(time.Minute) {
+		t.Errorf("Expected unset overlay fields to keep file values, got %+v", merged)
+	}
+	if file.Paths[BackendBrew] != "/file/brew" {
+		t.Error("Merge should not modify the receiver")
+	}
+}
+
+func TestConfig_MergeNonInteractive(t *testing.T) {
+	on, off := true, false
+	file := &Config{NonInteractive: &on}
+
+	if merged := file.Merge(&Config{}); merged.NonInteractive == nil || !*merged.NonInteractive {
+		t.Error("Expected an unset overlay to keep NonInteractive")
+	}
+	merged := file.Merge(&Config{NonInteractive: &off})
+	if merged.NonInteractive == nil || *merged.NonInteractive {
+		t.Error("Expected the overlay to turn NonInteractive off")
+	}
+	if len(merged.Options()) != 0 {
+		t.Errorf("Expected no options once NonInteractive is off, got %d", len(merged.Options()))
+	}
+}
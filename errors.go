@@ -3,6 +3,8 @@ package pm
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
 var (
@@ -11,6 +13,55 @@ var (
 
 	// ErrNotAvailable is returned when a backend is not available (not installed/reachable).
 	ErrNotAvailable = errors.New("backend not available")
+
+	// ErrEscalationUnavailable is returned when an operation needs elevated
+	// privileges that the configured escalation strategy could not obtain.
+	ErrEscalationUnavailable = errors.New("privilege escalation unavailable")
+
+	// ErrInteractionRequired is returned when the underlying tool waits for
+	// user input, which pm never provides.
+	ErrInteractionRequired = errors.New("interaction required")
+
+	// ErrPermissionDenied is returned when the current user is not allowed
+	// to perform an operation, e.g. because sudo needs a password.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrPackageNotFound is returned when a requested package does not
+	// exist in any configured repository, remote or store.
+	ErrPackageNotFound = errors.New("package not found")
+
+	// ErrAlreadyInstalled is reported when Install is asked for a package
+	// that is already installed.
+	ErrAlreadyInstalled = errors.New("package already installed")
+
+	// ErrNotInstalled is reported when an operation targets a package that
+	// is not installed, e.g. Uninstall.
+	ErrNotInstalled = errors.New("package not installed")
+
+	// ErrNetwork is returned when an operation failed because a network
+	// request or a command's download failed.
+	ErrNetwork = errors.New("network error")
+
+	// ErrValidation is returned when a PackageRef is not valid for the
+	// backend it is passed to. No command is run in that case.
+	ErrValidation = errors.New("invalid package reference")
+
+	// ErrTimeout is returned when an operation's deadline passed while an
+	// external command was running.
+	ErrTimeout = errors.New("operation timed out")
+
+	// ErrConflict is returned when another operation holds the package
+	// manager's lock, e.g. a snapd change in progress or a running brew
+	// process. Retrying later may succeed.
+	ErrConflict = errors.New("conflicting operation in progress")
+
+	// ErrUnsupportedArch is returned when a package is not built for the
+	// architecture packages are installed for. See WithArch.
+	ErrUnsupportedArch = errors.New("package not available for this architecture")
+
+	// ErrDeclined is returned when the callback given to WithConfirm
+	// declines an operation. Nothing is changed in that case.
+	ErrDeclined = errors.New("operation declined")
 )
 
 // NotSupportedError wraps ErrNotSupported with additional context.
@@ -58,6 +109,528 @@ func IsNotAvailable(err error) bool {
 	return errors.Is(err, ErrNotAvailable)
 }
 
+// EscalationError wraps ErrEscalationUnavailable with additional context.
+type EscalationError struct {
+	Operation Operation
+	Backend   string
+	// Strategy is the escalation strategy that was attempted.
+	Strategy Escalation
+	// Reason explains why privileges could not be obtained.
+	Reason string
+	// Stderr captured from the command (sanitized).
+	Stderr string
+	// Hint is the escalation strategy that would likely succeed, or empty
+	// if none is known to be available.
+	Hint Escalation
+}
+
+func (e *EscalationError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s requires elevated privileges (strategy %s): %s", ErrEscalationUnavailable, e.Operation, e.Backend, e.Strategy, e.Reason)
+	if e.Hint != "" {
+		msg = fmt.Sprintf("%s (try escalation %s)", msg, e.Hint)
+	}
+	return msg
+}
+
+func (e *EscalationError) Unwrap() error {
+	return ErrEscalationUnavailable
+}
+
+// IsEscalationUnavailable checks if an error is an EscalationError.
+func IsEscalationUnavailable(err error) bool {
+	return errors.Is(err, ErrEscalationUnavailable)
+}
+
+// PermissionDeniedError wraps ErrPermissionDenied with additional context.
+//
+// Unlike EscalationError, which means no way to gain privileges was
+// available, PermissionDeniedError means the operation was refused: either
+// the escalation strategy is in place but refused this user, e.g. `sudo -n`
+// needing a password, or no strategy was configured and the tool reported a
+// permission failure ("permission denied", a polkit denial, snapd answering
+// 401 or 403). Hint names the strategy that would likely succeed, so tools
+// can prompt for it or re-run with WithEscalation.
+type PermissionDeniedError struct {
+	Operation Operation
+	Backend   string
+	// Strategy is the escalation strategy that was attempted, or empty if
+	// none was configured.
+	Strategy Escalation
+	// Reason explains why the operation was denied.
+	Reason string
+	// Stderr captured from the command (sanitized).
+	Stderr string
+	// Hint is the escalation strategy that would likely succeed, or empty
+	// if none is known to be available.
+	Hint Escalation
+	// Err is the underlying ExternalFailureError when the denial was
+	// detected in the output of a failed command, otherwise nil.
+	Err error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrPermissionDenied, e.Operation, e.Backend)
+	if e.Strategy != "" {
+		msg = fmt.Sprintf("%s (strategy %s)", msg, e.Strategy)
+	}
+	msg = fmt.Sprintf("%s: %s", msg, e.Reason)
+	if e.Hint != "" {
+		msg = fmt.Sprintf("%s (try escalation %s)", msg, e.Hint)
+	}
+	return msg
+}
+
+func (e *PermissionDeniedError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrPermissionDenied}
+	}
+	return []error{ErrPermissionDenied, e.Err}
+}
+
+// IsPermissionDenied checks if an error is a PermissionDeniedError.
+func IsPermissionDenied(err error) bool {
+	return errors.Is(err, ErrPermissionDenied)
+}
+
+// ConflictError wraps ErrConflict with additional context. It also
+// unwraps to the ExternalFailureError of the failed command.
+type ConflictError struct {
+	Operation Operation
+	Backend   string
+	// Holder describes what holds the lock, e.g.
+	// `snap "jq" has "install-snap" change`, or is empty if the tool did
+	// not say.
+	Holder string
+	// RetryAfter suggests how long to wait before retrying; zero if there
+	// is no suggestion.
+	RetryAfter time.Duration
+	// Err is the underlying failure.
+	Err error
+}
+
+func (e *ConflictError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrConflict, e.Operation, e.Backend)
+	if e.Holder != "" {
+		msg = fmt.Sprintf("%s (held by %s)", msg, e.Holder)
+	}
+	return msg
+}
+
+func (e *ConflictError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrConflict}
+	}
+	return []error{ErrConflict, e.Err}
+}
+
+// IsConflict checks if an error is a ConflictError.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// TimeoutError is returned instead of a bare context error when the
+// operation's deadline (from the context or WithTimeout) passes while an
+// external command is running, so callers can tell slowness from failure.
+//
+// It unwraps to ErrTimeout and to the underlying error, normally a
+// *CancelledError, so errors.Is(err, context.DeadlineExceeded) still holds.
+type TimeoutError struct {
+	Operation Operation
+	Backend   string
+	// Elapsed is how long the command ran before it was stopped.
+	Elapsed time.Duration
+	// PartialStdout is the output the command wrote before it was stopped
+	// (sanitized like ExternalFailureError.Stdout).
+	PartialStdout string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s on %s after %s", ErrTimeout, e.Operation, e.Backend, e.Elapsed.Round(time.Millisecond))
+}
+
+func (e *TimeoutError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrTimeout}
+	}
+	return []error{ErrTimeout, e.Err}
+}
+
+// IsTimeout checks if an error is a TimeoutError.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
+// CancelledError is returned when an operation's context is cancelled
+// while an external command is running; on a timeout it is wrapped in a
+// TimeoutError. It unwraps to the
+// context's error, so errors.Is(err, context.Canceled) and
+// errors.Is(err, context.DeadlineExceeded) work as usual.
+//
+// Cancelled commands are first sent SIGTERM so they can release locks and
+// clean up, and are killed only if they outlive the grace period (see
+// WithTerminationGrace).
+type CancelledError struct {
+	Operation Operation
+	Backend   string
+	// Graceful is true if the command exited after SIGTERM within the grace
+	// period, false if it had to be killed.
+	Graceful bool
+	// Err is the context's error.
+	Err error
+}
+
+func (e *CancelledError) Error() string {
+	cleanup := "process killed"
+	if e.Graceful {
+		cleanup = "cleanup attempted"
+	}
+	return fmt.Sprintf("%s on %s cancelled, %s: %v", e.Operation, e.Backend, cleanup, e.Err)
+}
+
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// InteractionRequiredError wraps ErrInteractionRequired with additional context.
+type InteractionRequiredError struct {
+	Operation Operation
+	Backend   string
+	// Prompt is the last line of output before the tool waited for input.
+	Prompt string
+	// Expected describes the input the tool asked for
+	// (e.g. "yes/no confirmation", "password", "selection from a list").
+	Expected string
+}
+
+func (e *InteractionRequiredError) Error() string {
+	return fmt.Sprintf("%s: %s on %s is waiting for %s (prompt: %q)", ErrInteractionRequired, e.Operation, e.Backend, e.Expected, e.Prompt)
+}
+
+func (e *InteractionRequiredError) Unwrap() error {
+	return ErrInteractionRequired
+}
+
+// IsInteractionRequired checks if an error is an InteractionRequiredError.
+func IsInteractionRequired(err error) bool {
+	return errors.Is(err, ErrInteractionRequired)
+}
+
+// PackageNotFoundError wraps ErrPackageNotFound with additional context.
+//
+// It also unwraps to the ExternalFailureError of the failed command, if
+// any, so its stderr and exit code remain available through errors.As.
+type PackageNotFoundError struct {
+	// Ref is the package that was not found.
+	Ref     PackageRef
+	Backend string
+	// Suggestions are close matches offered by the backend, if any.
+	Suggestions []string
+	// Err is the underlying failure.
+	Err error
+}
+
+func (e *PackageNotFoundError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrPackageNotFound, e.Ref.Name, e.Backend)
+	if len(e.Suggestions) > 0 {
+		msg = fmt.Sprintf("%s (did you mean %s?)", msg, strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+func (e *PackageNotFoundError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrPackageNotFound}
+	}
+	return []error{ErrPackageNotFound, e.Err}
+}
+
+// IsPackageNotFound checks if an error is a PackageNotFoundError.
+func IsPackageNotFound(err error) bool {
+	return errors.Is(err, ErrPackageNotFound)
+}
+
+// PackageError attributes a failure to one package of a batch operation.
+//
+// With ContinueOnError, Install and Uninstall join one PackageError per
+// failed package with errors.Join, so errors.Is and errors.As reach each
+// package's underlying typed error.
+type PackageError struct {
+	// Ref is the package the operation failed for.
+	Ref     PackageRef
+	Backend string
+	// Err is the failure.
+	Err error
+}
+
+func (e *PackageError) Error() string {
+	return fmt.Sprintf("%s on %s: %v", e.Ref.Name, e.Backend, e.Err)
+}
+
+func (e *PackageError) Unwrap() error {
+	return e.Err
+}
+
+// AlreadyInstalledError wraps ErrAlreadyInstalled with additional context.
+//
+// It is not a failure: when the backend carries on with the other
+// packages, it is listed in InstallResult.Skipped and Install succeeds.
+// When the backend refused the whole command it is still listed there, and
+// it is returned as the error if every requested package was skipped, in
+// which case callers wanting idempotent installs can treat it as a no-op.
+// Otherwise the command's error is returned, joined with the skipped ones.
+type AlreadyInstalledError struct {
+	// Ref is the package that is already installed.
+	Ref     PackageRef
+	Backend string
+	// Err is the underlying failure if the command failed, otherwise nil.
+	Err error
+}
+
+func (e *AlreadyInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s on %s", ErrAlreadyInstalled, e.Ref.Name, e.Backend)
+}
+
+func (e *AlreadyInstalledError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrAlreadyInstalled}
+	}
+	return []error{ErrAlreadyInstalled, e.Err}
+}
+
+// IsAlreadyInstalled checks if an error is an AlreadyInstalledError.
+func IsAlreadyInstalled(err error) bool {
+	return errors.Is(err, ErrAlreadyInstalled)
+}
+
+// NotInstalledError wraps ErrNotInstalled with additional context.
+//
+// Like AlreadyInstalledError it is not a failure: it is listed in
+// UninstallResult.Skipped, and returned as the error only when the backend
+// refused the whole command and every requested package was skipped.
+type NotInstalledError struct {
+	// Operation is the operation that targeted the package.
+	Operation Operation
+	// Ref is the package that is not installed.
+	Ref     PackageRef
+	Backend string
+	// Err is the underlying failure if the command failed, otherwise nil.
+	Err error
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s on %s (%s)", ErrNotInstalled, e.Ref.Name, e.Backend, e.Operation)
+}
+
+func (e *NotInstalledError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNotInstalled}
+	}
+	return []error{ErrNotInstalled, e.Err}
+}
+
+// IsNotInstalled checks if an error is a NotInstalledError.
+func IsNotInstalled(err error) bool {
+	return errors.Is(err, ErrNotInstalled)
+}
+
+// UnsupportedArchError wraps ErrUnsupportedArch with additional context.
+//
+// Install returns it before running anything when the backend already
+// knows the package is not built for the target architecture, and
+// otherwise when the backend's tool reports so, in which case Err is the
+// failed command's ExternalFailureError.
+type UnsupportedArchError struct {
+	// Ref is the package that is not available.
+	Ref     PackageRef
+	Backend string
+	// Arch is the target architecture, in the backend's naming, such as
+	// "x86_64" for flatpak and brew or "amd64" for snap.
+	Arch string
+	// Available are the architectures the package is available for, if
+	// the backend reports them.
+	Available []string
+	// Err is the underlying failure if the command failed, otherwise nil.
+	Err error
+}
+
+func (e *UnsupportedArchError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s is not available for %s", ErrUnsupportedArch, e.Ref.Name, e.Backend, e.Arch)
+	if len(e.Available) > 0 {
+		msg = fmt.Sprintf("%s (only for %s)", msg, strings.Join(e.Available, ", "))
+	}
+	return msg
+}
+
+func (e *UnsupportedArchError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrUnsupportedArch}
+	}
+	return []error{ErrUnsupportedArch, e.Err}
+}
+
+// IsUnsupportedArch checks if an error is an UnsupportedArchError.
+func IsUnsupportedArch(err error) bool {
+	return errors.Is(err, ErrUnsupportedArch)
+}
+
+// DeclinedError wraps ErrDeclined with the operation that was declined.
+type DeclinedError struct {
+	Operation Operation
+	Backend   string
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("%s: %s on %s", ErrDeclined, e.Operation, e.Backend)
+}
+
+func (e *DeclinedError) Unwrap() error {
+	return ErrDeclined
+}
+
+// IsDeclined checks if an error is a DeclinedError.
+func IsDeclined(err error) bool {
+	return errors.Is(err, ErrDeclined)
+}
+
+// NetworkErrorKind describes what kind of network failure occurred.
+type NetworkErrorKind string
+
+const (
+	// NetworkDNS means a host name could not be resolved.
+	NetworkDNS NetworkErrorKind = "dns"
+
+	// NetworkTimeout means a connection or request timed out.
+	NetworkTimeout NetworkErrorKind = "timeout"
+
+	// NetworkConnectionRefused means the server refused the connection.
+	NetworkConnectionRefused NetworkErrorKind = "connection-refused"
+
+	// NetworkConnectionReset means the connection was reset mid-transfer.
+	NetworkConnectionReset NetworkErrorKind = "connection-reset"
+
+	// NetworkUnreachable means there was no route to the network or host.
+	NetworkUnreachable NetworkErrorKind = "unreachable"
+
+	// NetworkTLS means the TLS handshake or certificate verification
+	// failed.
+	NetworkTLS NetworkErrorKind = "tls"
+
+	// NetworkHTTPStatus means the server answered with a request timeout,
+	// rate limit or server error status; see NetworkError.StatusCode.
+	NetworkHTTPStatus NetworkErrorKind = "http-status"
+)
+
+// NetworkError wraps ErrNetwork with additional context.
+//
+// It is returned for failures of the HTTP APIs (the Homebrew Formulae API)
+// and for commands whose output reports a network failure, such as
+// "Could not resolve host". It unwraps to the ExternalFailureError of the
+// failed request or command.
+type NetworkError struct {
+	Operation Operation
+	Backend   string
+	// Kind is the kind of failure.
+	Kind NetworkErrorKind
+	// StatusCode is the HTTP status for NetworkHTTPStatus, otherwise zero.
+	StatusCode int
+	// Err is the underlying failure.
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	msg := fmt.Sprintf("%s (%s): %s on %s", ErrNetwork, e.Kind, e.Operation, e.Backend)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *NetworkError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNetwork}
+	}
+	return []error{ErrNetwork, e.Err}
+}
+
+// Retryable reports whether the failure is likely transient, so retrying
+// the operation later may succeed. TLS failures and HTTP statuses other
+// than 408, 429 and 5xx are not retryable.
+func (e *NetworkError) Retryable() bool {
+	switch e.Kind {
+	case NetworkTLS:
+		return false
+	case NetworkHTTPStatus:
+		return e.StatusCode == 408 || e.StatusCode == 429 || e.StatusCode >= 500
+	}
+	return true
+}
+
+// IsNetwork checks if an error is a NetworkError.
+func IsNetwork(err error) bool {
+	return errors.Is(err, ErrNetwork)
+}
+
+// ValidationError wraps ErrValidation with additional context.
+type ValidationError struct {
+	Operation Operation
+	Backend   string
+	// Ref is the offending package reference.
+	Ref PackageRef
+	// Field names the offending PackageRef field, e.g. "Name" or "Channel",
+	// or option, e.g. "SortBy".
+	Field string
+	// Value is the rejected value of Field.
+	Value string
+	// Reason says what is wrong with Value.
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s %q on %s: %s", ErrValidation, e.Field, e.Value, e.Backend, e.Reason)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// IsValidation checks if an error is a ValidationError.
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// IsRetryable reports whether err is a ConflictError, or a NetworkError
+// whose failure is likely transient.
+func IsRetryable(err error) bool {
+	if IsConflict(err) {
+		return true
+	}
+	var netErr *NetworkError
+	return errors.As(err, &netErr) && netErr.Retryable()
+}
+
+// ErrorCategory is the semantic category of an ExternalFailureError,
+// derived from the backend's exit status and output.
+type ErrorCategory string
+
+const (
+	// CategoryNotFound means a requested package does not exist.
+	CategoryNotFound ErrorCategory = "not-found"
+
+	// CategoryLocked means another process holds the package manager's
+	// lock or has a conflicting change in progress; retrying later may
+	// succeed.
+	CategoryLocked ErrorCategory = "locked"
+
+	// CategoryNetwork means a download or API request failed.
+	CategoryNetwork ErrorCategory = "network"
+
+	// CategoryUsage means the tool rejected its arguments, which usually
+	// indicates a version of the tool pm does not support.
+	CategoryUsage ErrorCategory = "usage"
+)
+
 // ExternalFailureError represents a failure from an external command or API.
 type ExternalFailureError struct {
 	Operation Operation
@@ -66,10 +639,25 @@ type ExternalFailureError struct {
 	Stdout string
 	// Stderr captured from command (if applicable, sanitized).
 	Stderr string
-	// Payload is structured error data from an API (if applicable).
+	// Payload is structured error data from an API or a command's JSON
+	// output (if applicable). It holds whichever of "kind", "message" and
+	// "value" the error reported, e.g. snapd's "kind": "snap-not-found".
 	Payload map[string]interface{}
 	// Underlying error.
 	Err error
+	// RunID is the correlation ID from the operation's context (see
+	// ContextWithRunID), if any.
+	RunID string
+	// ExitCode is the command's exit status, which some tools use to
+	// report specific conditions. It is zero if the failure did not come
+	// from a command exiting, e.g. an API error or a command killed by a
+	// signal.
+	ExitCode int
+	// Duration is how long the command ran, zero for API failures.
+	Duration time.Duration
+	// Category is the semantic category of the failure, or "" if it could
+	// not be determined.
+	Category ErrorCategory
 }
 
 func (e *ExternalFailureError) Error() string {
@@ -80,6 +668,9 @@ func (e *ExternalFailureError) Error() string {
 	if e.Stderr != "" {
 		msg = fmt.Sprintf("%s (stderr: %s)", msg, e.Stderr)
 	}
+	if e.RunID != "" {
+		msg = fmt.Sprintf("%s [run %s]", msg, e.RunID)
+	}
 	return msg
 }
 
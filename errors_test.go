@@ -1,8 +1,13 @@
 package pm
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
 )
 
 func TestIsNotSupported(t *testing.T) {
@@ -351,3 +356,285 @@ func TestExternalFailureError_ErrorsAs(t *testing.T) {
 		t.Error("IsExternalFailure should return false for unrelated wrapped errors")
 	}
 }
+
+func TestEscalationError(t *testing.T) {
+	err := &EscalationError{
+		Operation: OperationInstall,
+		Backend:   "snap",
+		Strategy:  EscalationSudo,
+		Reason:    "sudo requires a password and cannot prompt (sudo -n)",
+	}
+
+	if !IsEscalationUnavailable(err) {
+		t.Error("IsEscalationUnavailable() should return true")
+	}
+	if !containsAll(err.Error(), "snap", "sudo", "Install") {
+		t.Errorf("Error message missing context: %s", err.Error())
+	}
+}
+
+func TestConvertError_Escalation(t *testing.T) {
+	internal := &types.EscalationError{
+		Operation: types.OperationUninstall,
+		Backend:   "flatpak",
+		Strategy:  "pkexec",
+		Reason:    "denied",
+	}
+
+	var escErr *EscalationError
+	if !errors.As(convertError(internal), &escErr) {
+		t.Fatal("Expected *EscalationError")
+	}
+	if escErr.Strategy != EscalationPkexec || escErr.Operation != OperationUninstall {
+		t.Errorf("Unexpected conversion: %+v", escErr)
+	}
+}
+
+func TestConvertError_PermissionDenied(t *testing.T) {
+	internal := &types.PermissionDeniedError{
+		Operation: types.OperationInstall,
+		Backend:   "snap",
+		Strategy:  "doas",
+		Reason:    "doas requires a password and cannot prompt (doas -n)",
+	}
+
+	err := convertError(internal)
+	var pdErr *PermissionDeniedError
+	if !errors.As(err, &pdErr) {
+		t.Fatal("Expected *PermissionDeniedError")
+	}
+	if !IsPermissionDenied(err) || IsEscalationUnavailable(err) {
+		t.Errorf("Unexpected classification for %v", err)
+	}
+	if pdErr.Strategy != EscalationDoas || pdErr.Operation != OperationInstall {
+		t.Errorf("Unexpected conversion: %+v", pdErr)
+	}
+}
+
+func TestConvertError_InteractionRequired(t *testing.T) {
+	internal := &types.InteractionRequiredError{
+		Operation: types.OperationInstall,
+		Backend:   "flatpak",
+		Prompt:    "Which do you want to use (0 to abort)? [0-2]:",
+		Expected:  "selection from a list",
+	}
+
+	err := convertError(internal)
+	var irErr *InteractionRequiredError
+	if !errors.As(err, &irErr) {
+		t.Fatal("Expected *InteractionRequiredError")
+	}
+	if !IsInteractionRequired(err) || irErr.Expected != "selection from a list" || irErr.Operation != OperationInstall {
+		t.Errorf("Unexpected conversion: %+v", irErr)
+	}
+	if !containsAll(err.Error(), "flatpak", "selection from a list", "0 to abort") {
+		t.Errorf("Error message missing context: %s", err.Error())
+	}
+}
+
+func TestConvertError_Cancelled(t *testing.T) {
+	internal := &types.CancelledError{
+		Operation: types.OperationInstall,
+		Backend:   "brew",
+		Graceful:  true,
+		Err:       context.DeadlineExceeded,
+	}
+
+	err := convertError(internal)
+	var cancelledErr *CancelledError
+	if !errors.As(err, &cancelledErr) {
+		t.Fatal("Expected *CancelledError")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !cancelledErr.Graceful {
+		t.Errorf("Unexpected conversion: %+v", cancelledErr)
+	}
+	if !containsAll(err.Error(), "Install on brew cancelled", "cleanup attempted") {
+		t.Errorf("Unexpected message: %s", err)
+	}
+}
+
+func TestConvertError_ExternalFailureExitCode(t *testing.T) {
+	internal := &types.ExternalFailureError{
+		Operation: types.OperationInstall,
+		Backend:   "flatpak",
+		Err:       errors.New("exit status 1"),
+		ExitCode:  1,
+		Duration:  2 * time.Second,
+	}
+
+	var extErr *ExternalFailureError
+	if !errors.As(convertError(internal), &extErr) {
+		t.Fatal("Expected *ExternalFailureError")
+	}
+	if extErr.ExitCode != 1 || extErr.Duration != 2*time.Second {
+		t.Errorf("Unexpected conversion: %+v", extErr)
+	}
+}
+
+func TestConvertError_PackageNotFound(t *testing.T) {
+	internal := &types.PackageNotFoundError{
+		Ref:         types.PackageRef{Name: "wgett", Kind: "formula"},
+		Backend:     "brew",
+		Suggestions: []string{"wget"},
+		Err: &types.ExternalFailureError{
+			Operation: types.OperationInstall,
+			Backend:   "brew",
+			Stderr:    `Warning: No available formula with the name "wgett". Did you mean wget?`,
+			Err:       errors.New("exit status 1"),
+			ExitCode:  1,
+		},
+	}
+
+	err := convertError(internal)
+	var pnfErr *PackageNotFoundError
+	if !errors.As(err, &pnfErr) {
+		t.Fatal("Expected *PackageNotFoundError")
+	}
+	if !IsPackageNotFound(err) || pnfErr.Ref.Kind != "formula" || pnfErr.Suggestions[0] != "wget" {
+		t.Errorf("Unexpected conversion: %+v", pnfErr)
+	}
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.ExitCode != 1 {
+		t.Error("Expected the converted ExternalFailureError to remain reachable")
+	}
+	if !containsAll(err.Error(), "package not found", "wgett", "brew", "did you mean wget") {
+		t.Errorf("Unexpected message: %s", err)
+	}
+}
+
+func TestConvertError_AlreadyAndNotInstalled(t *testing.T) {
+	err := convertError(&types.AlreadyInstalledError{Ref: types.PackageRef{Name: "wget"}, Backend: "brew"})
+	var aiErr *AlreadyInstalledError
+	if !errors.As(err, &aiErr) || !IsAlreadyInstalled(err) || aiErr.Ref.Name != "wget" {
+		t.Errorf("Unexpected conversion: %v", err)
+	}
+	if IsExternalFailure(err) {
+		t.Error("Expected a skipped package without a failure not to be an external failure")
+	}
+
+	err = convertError(&types.NotInstalledError{
+		Operation: types.OperationUninstall,
+		Ref:       types.PackageRef{Name: "org.gimp.GIMP"},
+		Backend:   "flatpak",
+		Err:       &types.ExternalFailureError{Operation: types.OperationUninstall, Backend: "flatpak", Err: errors.New("exit status 1")},
+	})
+	var niErr *NotInstalledError
+	if !errors.As(err, &niErr) || !IsNotInstalled(err) || niErr.Operation != OperationUninstall {
+		t.Errorf("Unexpected conversion: %v", err)
+	}
+	if !IsExternalFailure(err) {
+		t.Error("Expected the underlying external failure to remain reachable")
+	}
+}
+
+func TestConvertError_SkippedFailure(t *testing.T) {
+	failure := &types.ExternalFailureError{Operation: types.OperationUninstall, Backend: "brew", Stderr: "Error: Permission denied", Err: errors.New("exit status 1")}
+	err := convertError(errors.Join(failure, &types.NotInstalledError{
+		Operation: types.OperationUninstall,
+		Ref:       types.PackageRef{Name: "wget"},
+		Backend:   "brew",
+	}))
+
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Stderr != failure.Stderr {
+		t.Errorf("Expected the command failure to be kept, got %v", err)
+	}
+	var niErr *NotInstalledError
+	if !errors.As(err, &niErr) || niErr.Ref.Name != "wget" {
+		t.Errorf("Expected the skipped package to be converted too, got %v", err)
+	}
+}
+
+func TestConvertError_PermissionDeniedHint(t *testing.T) {
+	internal := &types.PermissionDeniedError{
+		Operation: types.OperationInstall,
+		Backend:   "snap",
+		Reason:    "the command was denied permission",
+		Hint:      "sudo",
+		Err:       &types.ExternalFailureError{Operation: types.OperationInstall, Backend: "snap", Err: errors.New("exit status 1")},
+	}
+
+	err := convertError(internal)
+	var pdErr *PermissionDeniedError
+	if !errors.As(err, &pdErr) || pdErr.Hint != EscalationSudo {
+		t.Fatalf("Expected a PermissionDeniedError with a sudo hint, got %v", err)
+	}
+	if !IsExternalFailure(err) {
+		t.Error("Expected the underlying external failure to remain reachable")
+	}
+	if !containsAll(err.Error(), "permission denied", "try escalation sudo") || strings.Contains(err.Error(), "strategy") {
+		t.Errorf("Unexpected message: %s", err)
+	}
+}
+
+func TestConvertError_Network(t *testing.T) {
+	internal := &types.NetworkError{
+		Operation:  types.OperationSearch,
+		Backend:    "brew",
+		Kind:       types.NetworkHTTPStatus,
+		StatusCode: 503,
+		Err:        &types.ExternalFailureError{Operation: types.OperationSearch, Backend: "brew", Err: errors.New("API returned status 503")},
+	}
+
+	err := convertError(internal)
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || netErr.Kind != NetworkHTTPStatus || netErr.StatusCode != 503 {
+		t.Fatalf("Unexpected conversion: %v", err)
+	}
+	if !IsNetwork(err) || !IsRetryable(err) || !IsExternalFailure(err) {
+		t.Errorf("Unexpected classification for %v", err)
+	}
+	if IsRetryable(&NetworkError{Kind: NetworkTLS}) || IsRetryable(errors.New("other")) {
+		t.Error("Expected TLS failures and other errors not to be retryable")
+	}
+}
+
+func TestConvertError_Category(t *testing.T) {
+	err := convertError(&types.ExternalFailureError{Operation: types.OperationInstall, Backend: "snap", ExitCode: 10, Category: types.CategoryLocked})
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Category != CategoryLocked {
+		t.Errorf("Expected the category to be preserved, got %#v", err)
+	}
+}
+
+func TestConvertError_Validation(t *testing.T) {
+	err := convertError(&types.ValidationError{
+		Operation: types.OperationInstall,
+		Backend:   "snap",
+		Ref:       types.PackageRef{Name: "vlc", Channel: "latest/nightly"},
+		Field:     "Channel",
+		Value:     "latest/nightly",
+		Reason:    "unknown risk",
+	})
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || valErr.Field != "Channel" || valErr.Ref.Channel != "latest/nightly" {
+		t.Fatalf("Unexpected conversion: %v", err)
+	}
+	if !IsValidation(err) {
+		t.Error("Expected IsValidation to match")
+	}
+}
+
+func TestConvertError_Conflict(t *testing.T) {
+	err := convertError(&types.ConflictError{
+		Operation:  types.OperationInstall,
+		Backend:    "brew",
+		Holder:     "`brew install jq` process",
+		RetryAfter: 10 * time.Second,
+		Err:        &types.ExternalFailureError{Operation: types.OperationInstall, Backend: "brew", Category: types.CategoryLocked},
+	})
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) || conflictErr.Holder != "`brew install jq` process" || conflictErr.RetryAfter != 10*time.Second {
+		t.Fatalf("Unexpected conversion: %v", err)
+	}
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Category != CategoryLocked {
+		t.Errorf("Expected the external failure to be converted too, got %v", err)
+	}
+	if !IsConflict(err) || !IsRetryable(err) {
+		t.Errorf("Expected a retryable conflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "held by `brew install jq` process") {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
@@ -0,0 +1,57 @@
+package pm
+
+import "github.com/frostyard/pm/internal/runner"
+
+// Escalation is a strategy for running operations that need root.
+//
+// Escalation applies only to mutating operations (Update, Upgrade, Install,
+// Uninstall); searches and listings always run as the current user. When the
+// process already runs as root, commands are never wrapped.
+type Escalation string
+
+const (
+	// EscalationNone runs commands as the current user. Failures caused by
+	// missing privileges are reported as EscalationError.
+	EscalationNone Escalation = runner.EscalationNone
+
+	// EscalationSudo runs commands through `sudo -n`. It never prompts; if a
+	// password would be required, the operation fails with
+	// PermissionDeniedError.
+	EscalationSudo Escalation = runner.EscalationSudo
+
+	// EscalationDoas runs commands through `doas -n`, with the same
+	// behavior as EscalationSudo.
+	EscalationDoas Escalation = runner.EscalationDoas
+
+	// EscalationPkexec runs commands through `pkexec`, which may show a
+	// graphical authentication prompt.
+	EscalationPkexec Escalation = runner.EscalationPkexec
+
+	// EscalationPolkit runs commands as the current user and relies on the
+	// tool's own polkit integration over D-Bus (the flatpak system helper
+	// and snapd both authorize this way). Denials are reported as EscalationError.
+	EscalationPolkit Escalation = runner.EscalationPolkit
+)
+
+// WithEscalation sets the privilege-escalation strategy. If kinds are given,
+// the strategy applies only to those backends, which allows a single option
+// list (e.g. for Detect) to configure each backend differently. Without
+// kinds it applies to every backend except brew, which refuses to run as root:
+//
+//	pm.Detect(ctx,
+//	    pm.WithEscalation(pm.EscalationSudo, pm.BackendSnap),
+//	    pm.WithEscalation(pm.EscalationPolkit, pm.BackendFlatpak),
+//	)
+func WithEscalation(s Escalation, kinds ...BackendKind) ConstructorOption {
+	return func(config *backendConfig) {
+		if config.escalation == nil {
+			config.escalation = make(map[BackendKind]Escalation)
+		}
+		if len(kinds) == 0 {
+			kinds = []BackendKind{BackendFlatpak, BackendSnap}
+		}
+		for _, kind := range kinds {
+			config.escalation[kind] = s
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pm
+
+import (
+	"context"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+)
+
+// CommandInfo describes an external command run by a backend.
+type CommandInfo struct {
+	// Backend is the backend that ran the command.
+	Backend BackendKind
+
+	// Operation is the operation the command belongs to; empty for
+	// commands not tied to one (e.g. availability checks).
+	Operation Operation
+
+	// Name and Args are the final command line, including any escalation
+	// prefix (e.g. "sudo", "-n", "snap", ...) and binary path override.
+	Name string
+	Args []string
+}
+
+// CommandExit describes how an external command finished.
+type CommandExit struct {
+	CommandInfo
+
+	// Duration is how long the command ran.
+	Duration time.Duration
+
+	// ExitCode is the exit status, or -1 if the command could not be
+	// started or was killed by a signal.
+	ExitCode int
+
+	// Err is the error returned by the runner, if any.
+	Err error
+}
+
+// CommandHooks observes the external commands pm executes. Either field
+// may be nil. Hooks are called synchronously on the goroutine running the
+// operation and must be safe for concurrent use across operations.
+type CommandHooks struct {
+	// OnStart is called before a command starts.
+	OnStart func(ctx context.Context, cmd CommandInfo)
+
+	// OnExit is called after a command finishes.
+	OnExit func(ctx context.Context, exit CommandExit)
+}
+
+// WithCommandHooks registers hooks that observe every external command, so
+// applications can log or trace them without patching backends. The option
+// may be given several times; hooks run in the order they were added.
+//
+// Commands skipped in simulation mode are not reported (see WithSimulation).
+func WithCommandHooks(h CommandHooks) ConstructorOption {
+	return func(config *backendConfig) {
+		config.hooks = append(config.hooks, h)
+	}
+}
+
+// observingRunner wraps r so that the configured hooks see its commands.
+func (c *backendConfig) observingRunner(kind BackendKind, r runner.Runner) runner.Runner {
+	hooks := c.hooks
+	info := func(cmd runner.Command) CommandInfo {
+		return CommandInfo{Backend: kind, Operation: Operation(cmd.Operation), Name: cmd.Name, Args: cmd.Args}
+	}
+	return runner.NewObservingRunner(r,
+		func(ctx context.Context, cmd runner.Command) {
+			for _, h := range hooks {
+				if h.OnStart != nil {
+					h.OnStart(ctx, info(cmd))
+				}
+			}
+		},
+		func(ctx context.Context, cmd runner.Command, res runner.CommandResult) {
+			exit := CommandExit{CommandInfo: info(cmd), Duration: res.Duration, ExitCode: res.ExitCode, Err: res.Err}
+			for _, h := range hooks {
+				if h.OnExit != nil {
+					h.OnExit(ctx, exit)
+				}
+			}
+		})
+}
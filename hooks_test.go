@@ -0,0 +1,72 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestWithCommandHooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	var exits []CommandExit
+	hooks := CommandHooks{
+		OnStart: func(ctx context.Context, cmd CommandInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "start "+cmd.Name)
+		},
+		OnExit: func(ctx context.Context, exit CommandExit) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "exit "+exit.Name)
+			exits = append(exits, exit)
+		},
+	}
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "error: no such app", errors.New("exit status 1")
+	})
+
+	mgr := NewFlatpak(WithRunner(fake), WithCommandHooks(hooks), WithoutOperationLock())
+	_, err := mgr.(Installer).Install(context.Background(), []PackageRef{{Name: "org.example.Nope"}}, InstallOptions{})
+	if err == nil {
+		t.Fatal("Expected Install to fail")
+	}
+
+	// flatpak info, which fails too, and then flatpak install
+	if len(events) != 4 || events[2] != "start flatpak" || events[3] != "exit flatpak" {
+		t.Fatalf("events = %q, want start and exit of flatpak info and install", events)
+	}
+	exit := exits[1]
+	if exit.Backend != BackendFlatpak || exit.Operation != OperationInstall {
+		t.Errorf("Unexpected command info %+v", exit.CommandInfo)
+	}
+	if len(exit.Args) == 0 || exit.Args[0] != "install" {
+		t.Errorf("Unexpected args %v", exit.Args)
+	}
+	if exit.Err == nil || exit.ExitCode != -1 {
+		t.Errorf("Expected error with unknown exit code, got %d %v", exit.ExitCode, exit.Err)
+	}
+}
+
+func TestWithCommandHooks_SkipsSimulated(t *testing.T) {
+	var started int
+	hooks := CommandHooks{OnStart: func(ctx context.Context, cmd CommandInfo) {
+		// Read-only commands, such as the query before the install, still run
+		if cmd.Operation == OperationInstall {
+			started++
+		}
+	}}
+	fake := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "error: org.gimp.GIMP/*unspecified*/* not installed", errors.New("exit status 1")
+	})
+
+	mgr := NewFlatpak(WithRunner(fake), WithCommandHooks(hooks), WithSimulation(nil), WithoutOperationLock())
+	if _, err := mgr.(Installer).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if started != 0 {
+		t.Errorf("Expected no hooks for skipped commands, got %d", started)
+	}
+}
@@ -0,0 +1,69 @@
+package pm
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+)
+
+// WithHTTPClient makes backends send their API requests (the Homebrew
+// Formulae API, and snapd when WithSnapdEndpoint gives an http:// or
+// https:// URL) through c. The client's Timeout, Jar and CheckRedirect are
+// kept; its Transport, or http.DefaultTransport if it has none, is wrapped
+// with the configured retry policy and simulation mode. Requests to the
+// snapd Unix socket always use their own transport.
+func WithHTTPClient(c *http.Client) ConstructorOption {
+	return func(config *backendConfig) {
+		config.client = c
+	}
+}
+
+// WithProxy sends backend API requests through the HTTP proxy at proxy
+// instead of the one named by the HTTPS_PROXY and HTTP_PROXY environment
+// variables. A nil proxy disables proxying.
+//
+// WithProxy and WithTLSConfig adjust a copy of the client's transport, so
+// they have no effect if WithHTTPClient gives a transport other than
+// *http.Transport.
+func WithProxy(proxy *url.URL) ConstructorOption {
+	return func(config *backendConfig) {
+		config.proxy = http.ProxyURL(proxy)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of backend API requests, such as
+// a RootCAs pool that trusts a corporate certificate authority.
+func WithTLSConfig(cfg *tls.Config) ConstructorOption {
+	return func(config *backendConfig) {
+		config.tlsConfig = cfg
+	}
+}
+
+// customHTTP reports whether any option changes how API requests are sent.
+func (c *backendConfig) customHTTP() bool {
+	return c.client != nil || c.proxy != nil || c.tlsConfig != nil
+}
+
+// baseTransport returns the transport API requests to remote endpoints are
+// sent through, with the proxy and TLS settings applied.
+func (c *backendConfig) baseTransport() http.RoundTripper {
+	base := http.DefaultTransport
+	if c.client != nil && c.client.Transport != nil {
+		base = c.client.Transport
+	}
+	if c.proxy == nil && c.tlsConfig == nil {
+		return base
+	}
+	t, ok := base.(*http.Transport)
+	if !ok {
+		return base
+	}
+	t = t.Clone()
+	if c.proxy != nil {
+		t.Proxy = c.proxy
+	}
+	if c.tlsConfig != nil {
+		t.TLSClientConfig = c.tlsConfig.Clone()
+	}
+	return t
+}
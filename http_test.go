@@ -0,0 +1,74 @@
+package pm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// countingTransport counts the requests it sends through http.DefaultTransport.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	client := &http.Client{Transport: transport}
+	mgr := NewBrew(WithHTTPClient(client), WithRetryPolicy(DefaultRetryPolicy()), WithFormulaeBaseURL(server.URL), WithoutOperationLock())
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+	if transport.requests.Load() != 1 {
+		t.Errorf("Expected 1 request through the client's transport, got %d", transport.requests.Load())
+	}
+	if client.Transport != transport {
+		t.Error("The caller's client should not be modified")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var host string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	mgr := NewBrew(WithProxy(proxyURL), WithFormulaeBaseURL("http://formulae.example/api"), WithoutOperationLock())
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+	if host != "formulae.example" {
+		t.Errorf("Proxy received a request for %q, want formulae.example", host)
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	mgr := NewBrew(WithTLSConfig(&tls.Config{RootCAs: pool}), WithFormulaeBaseURL(server.URL), WithoutOperationLock())
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+
+	mgr = NewBrew(WithFormulaeBaseURL(server.URL), WithoutOperationLock())
+	if ok, _ := mgr.Available(context.Background()); ok {
+		t.Error("Available() should fail without trusting the server's certificate")
+	}
+}
@@ -0,0 +1,205 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// DefaultIconSize is the icon size, in pixels, Icon prefers when
+// IconOptions.Size is not set.
+const DefaultIconSize = 128
+
+// maxIconBytes bounds the size of a downloaded icon.
+const maxIconBytes = 10 << 20
+
+// internalIconFetcher is implemented by backends that can find the icons
+// of applications. They say where an icon is; the adapter caches it.
+type internalIconFetcher interface {
+	IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error)
+}
+
+// WithIconCache makes Icon keep icons under dir instead of pm/icons under
+// the user cache directory. Processes sharing dir share the icons.
+func WithIconCache(dir string) ConstructorOption {
+	return func(config *backendConfig) {
+		config.iconDir = dir
+	}
+}
+
+// iconStore is where an adapter caches icons, and how it downloads them.
+type iconStore struct {
+	dir    string
+	client *http.Client
+}
+
+// iconStore returns the icon cache of kind's adapter. Downloads go through
+// the same transport, retry policy and simulation mode as API requests.
+func (c *backendConfig) iconStore(kind BackendKind) iconStore {
+	dir := c.iconDir
+	if dir == "" {
+		if cache, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(cache, "pm", "icons")
+		}
+	}
+	client := c.httpClient(kind, c.baseTransport())
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return iconStore{dir: dir, client: client}
+}
+
+// Icon implements IconFetcher. It fails with NotSupportedError if the
+// backend cannot find icons.
+func (a *backendAdapter) Icon(ctx context.Context, ref PackageRef, opts IconOptions) (string, error) {
+	fetcher, ok := a.backend.(internalIconFetcher)
+	if !ok {
+		return "", &NotSupportedError{Operation: OperationIcon, Backend: string(a.kind)}
+	}
+	if a.icons.dir == "" {
+		return "", &NotSupportedError{Operation: OperationIcon, Backend: string(a.kind), Reason: "no icon cache directory; set one with WithIconCache"}
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = DefaultIconSize
+	}
+	base := filepath.Join(a.icons.dir, string(a.kind), strconv.Itoa(size), url.PathEscape(ref.Name))
+	if cached := cachedIcons(base); len(cached) > 0 && !opts.Refresh {
+		return cached[0], nil
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	pr, summary := a.reporter(ctx, opts.Progress)
+	src, err := fetcher.IconSource(ctx, types.PackageRef{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+		Channel:   ref.Channel,
+		Kind:      ref.Kind,
+	}, types.IconOptions{Size: size, Progress: pr})
+	var dest string
+	switch {
+	case err != nil:
+	case src.Path != "":
+		dest, err = a.icons.copy(base+filepath.Ext(src.Path), src.Path)
+	case src.URL != "":
+		dest, err = a.icons.download(ctx, a.kind, base, src.URL)
+	}
+	if err != nil {
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return "", err
+	}
+	if opts.Refresh && dest != "" {
+		a.icons.prune(base, dest)
+	}
+	summary.finish(nil, 0)
+	return dest, nil
+}
+
+// cachedIcons returns the icons cached as base, with any extension.
+func cachedIcons(base string) []string {
+	cached, _ := filepath.Glob(base + ".*")
+	if _, err := os.Stat(base); err == nil {
+		cached = append(cached, base)
+	}
+	return cached
+}
+
+// prune removes the icons cached as base with an extension other than
+// dest's, left from before a refresh.
+func (s iconStore) prune(base, dest string) {
+	for _, path := range cachedIcons(base) {
+		if path != dest {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// copy caches the local icon file src as dest.
+func (s iconStore) copy(dest, src string) (string, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("read icon: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return dest, writeIcon(dest, f)
+}
+
+// download caches the icon at rawURL as base plus the extension of the
+// URL's path, or of the response's content type if the path has none.
+func (s iconStore) download(ctx context.Context, kind BackendKind, base, rawURL string) (string, error) {
+	fail := func(err error) *types.ExternalFailureError {
+		return &types.ExternalFailureError{
+			Operation: types.OperationIcon,
+			Backend:   string(kind),
+			Err:       fmt.Errorf("failed to download icon: %w", err),
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fail(err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", runner.ClassifyNetworkError(ctx, fail(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", runner.ClassifyHTTPStatus(resp.StatusCode, fail(fmt.Errorf("%s returned status %d", rawURL, resp.StatusCode)))
+	}
+
+	ext := path.Ext(req.URL.Path)
+	if ext == "" {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		ext = imageExtensions[mediaType]
+	}
+	dest := base + ext
+	if err := writeIcon(dest, io.LimitReader(resp.Body, maxIconBytes)); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// imageExtensions maps the content types of icons to file extensions.
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/svg+xml": ".svg",
+	"image/jpeg":    ".jpg",
+	"image/webp":    ".webp",
+	"image/x-icns":  ".icns",
+}
+
+// writeIcon writes r to dest through a temporary file, so that readers
+// never see a partial icon.
+func writeIcon(dest string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("cache icon: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".icon-*")
+	if err != nil {
+		return fmt.Errorf("cache icon: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cache icon: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cache icon: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("cache icon: %w", err)
+	}
+	return nil
+}
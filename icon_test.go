@@ -0,0 +1,127 @@
+package pm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// iconBackend finds every package's icon at src and counts the lookups.
+type iconBackend struct {
+	countingBackend
+	src     types.IconSource
+	lookups int
+	size    int
+}
+
+func (b *iconBackend) IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error) {
+	b.lookups++
+	b.size = opts.Size
+	return b.src, nil
+}
+
+func TestBackendAdapter_IconFromFile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "org.gimp.GIMP.png")
+	if err := os.WriteFile(src, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backend := &iconBackend{src: types.IconSource{Path: src}}
+	cache := t.TempDir()
+	mgr := Wrap(newBackendAdapter(BackendFlatpak, backend, &backendConfig{iconDir: cache}))
+	ref := PackageRef{Name: "org.gimp.GIMP"}
+
+	path, err := mgr.Icon(context.Background(), ref, IconOptions{})
+	if err != nil {
+		t.Fatalf("Icon() error = %v", err)
+	}
+	if want := filepath.Join(cache, "flatpak", "128", "org.gimp.GIMP.png"); path != want {
+		t.Errorf("Icon() = %q, want %q", path, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "png" {
+		t.Errorf("cached icon = %q", data)
+	}
+	if backend.size != DefaultIconSize {
+		t.Errorf("backend asked for size %d, want %d", backend.size, DefaultIconSize)
+	}
+
+	if again, err := mgr.Icon(context.Background(), ref, IconOptions{}); err != nil || again != path {
+		t.Errorf("second Icon() = %q, %v, want %q", again, err, path)
+	}
+	if backend.lookups != 1 {
+		t.Errorf("backend looked up %d icons, want 1 with the second from the cache", backend.lookups)
+	}
+	if _, err := mgr.Icon(context.Background(), ref, IconOptions{Refresh: true}); err != nil {
+		t.Fatalf("Icon(Refresh) error = %v", err)
+	}
+	if backend.lookups != 2 {
+		t.Errorf("backend looked up %d icons, want 2 after a refresh", backend.lookups)
+	}
+}
+
+func TestBackendAdapter_IconFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/firefox":
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			_, _ = w.Write([]byte("<svg/>"))
+		case "/firefox.png":
+			_, _ = w.Write([]byte("png"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend := &iconBackend{src: types.IconSource{URL: server.URL + "/firefox"}}
+	cache := t.TempDir()
+	mgr := Wrap(newBackendAdapter(BackendSnap, backend, &backendConfig{iconDir: cache}))
+	ref := PackageRef{Name: "firefox"}
+
+	path, err := mgr.Icon(context.Background(), ref, IconOptions{Size: 64})
+	if err != nil {
+		t.Fatalf("Icon() error = %v", err)
+	}
+	if want := filepath.Join(cache, "snap", "64", "firefox.svg"); path != want {
+		t.Errorf("Icon() = %q, want %q with the extension of the content type", path, want)
+	}
+
+	// A refreshed icon in another format replaces the cached one.
+	backend.src.URL = server.URL + "/firefox.png"
+	refreshed, err := mgr.Icon(context.Background(), ref, IconOptions{Size: 64, Refresh: true})
+	if err != nil {
+		t.Fatalf("Icon(Refresh) error = %v", err)
+	}
+	if want := filepath.Join(cache, "snap", "64", "firefox.png"); refreshed != want {
+		t.Errorf("Icon(Refresh) = %q, want %q", refreshed, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("old icon %s still cached after a refresh", path)
+	}
+
+	backend.src.URL = server.URL + "/missing.png"
+	if _, err := mgr.Icon(context.Background(), PackageRef{Name: "spotify"}, IconOptions{}); err == nil {
+		t.Error("Icon() of a missing download succeeded")
+	}
+}
+
+func TestBackendAdapter_IconNone(t *testing.T) {
+	backend := &iconBackend{}
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, &backendConfig{iconDir: t.TempDir()}))
+
+	path, err := mgr.Icon(context.Background(), PackageRef{Name: "jq"}, IconOptions{})
+	if err != nil || path != "" {
+		t.Errorf("Icon() = %q, %v, want no icon and no error", path, err)
+	}
+}
+
+func TestBackendAdapter_IconNotSupported(t *testing.T) {
+	adapter := newBackendAdapter(BackendFlatpak, &countingBackend{}, &backendConfig{iconDir: t.TempDir()})
+	if _, err := adapter.Icon(context.Background(), PackageRef{Name: "org.gimp.GIMP"}, IconOptions{}); !IsNotSupported(err) {
+		t.Errorf("Icon() error = %v, want NotSupportedError", err)
+	}
+}
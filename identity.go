@@ -0,0 +1,96 @@
+package pm
+
+import (
+	"context"
+	"strings"
+)
+
+// Identity returns a canonical, backend-independent identity for a package,
+// so the same application installed through different backends can be
+// recognized (e.g. flatpak org.mozilla.firefox and snap firefox are both
+// "firefox").
+//
+// Names found in DefaultAliases map to their logical name. Otherwise the
+// identity is derived heuristically: flatpak reverse-DNS IDs are reduced to
+// their application segment, and the result is lower-cased with common
+// packaging suffixes removed.
+func Identity(kind BackendKind, ref PackageRef) string {
+	return identity(DefaultAliases, kind, ref)
+}
+
+func identity(aliases *AliasTable, kind BackendKind, ref PackageRef) string {
+	if aliases != nil {
+		if name, ok := aliases.Name(kind, ref.Name); ok {
+			return name
+		}
+	}
+
+	name := strings.ToLower(ref.Name)
+	name = strings.TrimSuffix(name, ".desktop")
+	if kind == BackendFlatpak {
+		name = appSegment(name)
+	}
+	for _, suffix := range []string{"-desktop", "-bin"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+// appSegment extracts the application part of a reverse-DNS ID such as
+// org.gimp.gimp or com.spotify.client.
+func appSegment(id string) string {
+	parts := strings.Split(id, ".")
+	if len(parts) < 3 {
+		return id
+	}
+	last := parts[len(parts)-1]
+	switch last {
+	case "client", "desktop", "app":
+		return parts[len(parts)-2]
+	}
+	return last
+}
+
+// BackendPackage is an installed package together with the backend that provides it.
+type BackendPackage struct {
+	// Kind is the backend that installed the package.
+	Kind BackendKind
+
+	// Package is the installed package as reported by that backend.
+	Package InstalledPackage
+}
+
+// UniquePackage is one application in a deduplicated installed list. The
+// same application may be installed through several backends.
+type UniquePackage struct {
+	// Identity is the canonical identity shared by all installs (see Identity).
+	Identity string
+
+	// Installs lists each backend's copy, in backend priority order.
+	Installs []BackendPackage
+}
+
+// ListInstalledUnique lists installed packages on every backend that
+// implements Lister and merges entries that share a canonical identity.
+// Packages are returned in backend priority order, then in the order each
+// backend reported them. Like ListInstalled, errors from individual
+// backends are joined and returned alongside the merged results.
+func (m *MultiManager) ListInstalledUnique(ctx context.Context, opts ListOptions) ([]UniquePackage, error) {
+	results, err := m.ListInstalled(ctx, opts)
+
+	var unique []UniquePackage
+	index := make(map[string]int)
+	for _, b := range m.backends {
+		for _, pkg := range results[b.Kind] {
+			id := identity(m.aliases, b.Kind, pkg.Ref)
+			entry := BackendPackage{Kind: b.Kind, Package: pkg}
+			if i, ok := index[id]; ok {
+				unique[i].Installs = append(unique[i].Installs, entry)
+				continue
+			}
+			index[id] = len(unique)
+			unique = append(unique, UniquePackage{Identity: id, Installs: []BackendPackage{entry}})
+		}
+	}
+	return unique, err
+}
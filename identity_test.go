@@ -0,0 +1,59 @@
+package pm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	tests := []struct {
+		kind BackendKind
+		name string
+		want string
+	}{
+		{BackendFlatpak, "org.mozilla.firefox", "firefox"},
+		{BackendSnap, "firefox", "firefox"},
+		{BackendBrew, "visual-studio-code", "vscode"},
+		{BackendFlatpak, "org.example.Hello", "hello"},
+		{BackendFlatpak, "com.example.Notes.desktop", "notes"},
+		{BackendFlatpak, "org.example.Widget.Client", "widget"},
+		{BackendSnap, "widget-desktop", "widget"},
+		{BackendBrew, "wget", "wget"},
+	}
+	for _, tt := range tests {
+		if got := Identity(tt.kind, PackageRef{Name: tt.name}); got != tt.want {
+			t.Errorf("Identity(%s, %q) = %q, want %q", tt.kind, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMultiManager_ListInstalledUnique(t *testing.T) {
+	m := NewMultiManager(
+		Backend{Kind: BackendFlatpak, Manager: &fakeManager{available: true, installed: []InstalledPackage{
+			{Ref: PackageRef{Name: "org.mozilla.firefox"}, Version: "130.0"},
+			{Ref: PackageRef{Name: "org.gimp.GIMP"}, Version: "2.10"},
+		}}},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{available: true, installed: []InstalledPackage{
+			{Ref: PackageRef{Name: "firefox"}, Version: "131.0"},
+			{Ref: PackageRef{Name: "core22"}, Version: "20240111"},
+		}}},
+	)
+
+	unique, err := m.ListInstalledUnique(context.Background(), ListOptions{})
+	if err != nil {
+		t.Fatalf("ListInstalledUnique() error = %v", err)
+	}
+	if len(unique) != 3 {
+		t.Fatalf("Expected 3 unique packages, got %+v", unique)
+	}
+	firefox := unique[0]
+	if firefox.Identity != "firefox" || len(firefox.Installs) != 2 {
+		t.Fatalf("Expected firefox merged across backends, got %+v", firefox)
+	}
+	if firefox.Installs[0].Kind != BackendFlatpak || firefox.Installs[1].Kind != BackendSnap {
+		t.Errorf("Expected installs in backend priority order, got %+v", firefox.Installs)
+	}
+	if unique[1].Identity != "gimp" || unique[2].Identity != "core22" {
+		t.Errorf("Unexpected order %q, %q", unique[1].Identity, unique[2].Identity)
+	}
+}
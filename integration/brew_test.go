@@ -0,0 +1,17 @@
+//go:build integration
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/conformance"
+)
+
+func TestBrewConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) pm.Manager {
+		c := Start(t, Spec{Image: "homebrew/brew:latest", User: "linuxbrew"})
+		return pm.NewBrew(pm.WithRunner(c.Runner()), pm.WithoutOperationLock())
+	}, conformance.WithPackages(pm.PackageRef{Name: "hello"}))
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/conformance"
+)
+
+func flatpakContainer(t *testing.T) *Container {
+	return Start(t, Spec{
+		Image: "debian:bookworm",
+		Setup: []string{
+			"apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -qq -y flatpak",
+			"flatpak remote-add --if-not-exists flathub https://dl.flathub.org/repo/flathub.flatpakrepo",
+		},
+	})
+}
+
+func TestFlatpak(t *testing.T) {
+	c := flatpakContainer(t)
+	mgr := pm.NewFlatpak(pm.WithRunner(c.Runner()), pm.WithoutOperationLock())
+	ctx := context.Background()
+
+	if ok, err := mgr.Available(ctx); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v", ok, err)
+	}
+	results, err := mgr.(pm.Searcher).Search(ctx, "firefox", pm.SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	found := false
+	for _, ref := range results {
+		found = found || ref.Name == "org.mozilla.firefox"
+	}
+	if !found {
+		t.Errorf("Search(firefox) = %v, want org.mozilla.firefox", results)
+	}
+
+	_, err = mgr.(pm.Installer).Install(ctx, []pm.PackageRef{{Name: "org.example.DoesNotExist"}}, pm.InstallOptions{})
+	if !pm.IsPackageNotFound(err) {
+		t.Errorf("Install(missing) error = %v, want PackageNotFoundError", err)
+	}
+}
+
+func TestFlatpakConformance(t *testing.T) {
+	var opts []conformance.Option
+	if !testing.Short() {
+		// Pulls the GNOME runtime, several hundred megabytes.
+		opts = append(opts, conformance.WithPackages(pm.PackageRef{Name: "com.github.tchx84.Flatseal"}))
+	}
+	conformance.Run(t, func(t *testing.T) pm.Manager {
+		c := flatpakContainer(t)
+		mgr := pm.NewFlatpak(pm.WithRunner(c.Runner()), pm.WithoutOperationLock())
+		t.Cleanup(func() {
+			if t.Failed() {
+				t.Log(strings.TrimSpace(c.Exec(t, "flatpak list --columns=application,version || true")))
+			}
+		})
+		return mgr
+	}, opts...)
+}
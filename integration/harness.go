@@ -0,0 +1,126 @@
+//go:build integration
+
+// Package integration runs the backends end-to-end against real package
+// managers inside throwaway containers.
+//
+// The suite is built only with the integration build tag, and every test
+// skips unless PM_INTEGRATION is set, because it pulls images and packages
+// from the network:
+//
+//	PM_INTEGRATION=1 go test -tags integration ./integration
+//
+// PM_INTEGRATION_ENGINE selects docker (the default) or podman.
+package integration
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm"
+)
+
+// EnvEnable enables the integration suite when set to a non-empty value.
+const EnvEnable = "PM_INTEGRATION"
+
+// EnvEngine selects the container engine, docker or podman.
+const EnvEngine = "PM_INTEGRATION_ENGINE"
+
+// Spec describes a container to start.
+type Spec struct {
+	// Image is the image to run.
+	Image string
+
+	// User runs commands in the container as this user; empty uses the
+	// image's default.
+	User string
+
+	// Init keeps the image's own entrypoint, for images that boot systemd.
+	// Otherwise the container runs "sleep infinity".
+	Init bool
+
+	// Privileged runs the container privileged, as snapd and systemd
+	// require.
+	Privileged bool
+
+	// Setup lists shell commands run in the container, as root, before
+	// the test starts.
+	Setup []string
+}
+
+// Container is a running container that is removed when the test ends.
+type Container struct {
+	Engine pm.ContainerEngine
+	Name   string
+	User   string
+}
+
+// Start starts a container for spec and registers its removal with
+// t.Cleanup. It skips the test unless the suite is enabled and the
+// container engine is installed.
+func Start(t *testing.T, spec Spec) *Container {
+	t.Helper()
+	if os.Getenv(EnvEnable) == "" {
+		t.Skip("integration tests disabled; set " + EnvEnable + "=1 to run them")
+	}
+	engine := pm.ContainerDocker
+	if e := os.Getenv(EnvEngine); e != "" {
+		engine = pm.ContainerEngine(e)
+	}
+	if _, err := exec.LookPath(string(engine)); err != nil {
+		t.Skipf("%s not installed: %v", engine, err)
+	}
+
+	c := &Container{Engine: engine, Name: "pm-integration-" + suffix(t), User: spec.User}
+	args := []string{"run", "--detach", "--rm", "--name", c.Name}
+	if spec.Privileged {
+		args = append(args, "--privileged")
+	}
+	args = append(args, spec.Image)
+	if !spec.Init {
+		args = append(args, "sleep", "infinity")
+	}
+	c.engine(t, args...)
+	t.Cleanup(func() {
+		_ = exec.Command(string(c.Engine), "rm", "--force", c.Name).Run()
+	})
+
+	for _, cmd := range spec.Setup {
+		c.engine(t, "exec", "--user", "root", c.Name, "sh", "-c", cmd)
+	}
+	return c
+}
+
+// Runner returns a runner executing commands in the container, for use
+// with pm.WithRunner.
+func (c *Container) Runner() pm.Runner {
+	return pm.NewContainerRunner(pm.ContainerConfig{Engine: c.Engine, Container: c.Name, User: c.User})
+}
+
+// Exec runs a shell command in the container as root and returns its
+// output, failing the test if it fails.
+func (c *Container) Exec(t *testing.T, cmd string) string {
+	t.Helper()
+	return c.engine(t, "exec", "--user", "root", c.Name, "sh", "-c", cmd)
+}
+
+func (c *Container) engine(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command(string(c.Engine), args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %v\n%s", c.Engine, strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+// suffix returns a random suffix for container names.
+func suffix(t *testing.T) string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(b)
+}
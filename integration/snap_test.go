@@ -0,0 +1,52 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/conformance"
+)
+
+// EnvSnapdImage names an image that boots systemd with snapd installed.
+// snapd cannot run in an ordinary container, so the snap tests skip unless
+// it is set.
+const EnvSnapdImage = "PM_INTEGRATION_SNAPD_IMAGE"
+
+func snapContainer(t *testing.T) *Container {
+	image := os.Getenv(EnvSnapdImage)
+	if image == "" {
+		t.Skip("set " + EnvSnapdImage + " to an image that boots systemd with snapd")
+	}
+	return Start(t, Spec{
+		Image:      image,
+		Init:       true,
+		Privileged: true,
+		Setup:      []string{"snap wait system seed.loaded"},
+	})
+}
+
+// The snap backend checks availability over the local snapd socket, which
+// is not the container's, so these tests exercise the CLI operations only.
+
+func TestSnapConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) pm.Manager {
+		c := snapContainer(t)
+		return pm.NewSnap(pm.WithRunner(c.Runner()), pm.WithoutOperationLock())
+	}, conformance.WithPackages(pm.PackageRef{Name: "hello-world"}))
+}
+
+func TestSnapSearch(t *testing.T) {
+	c := snapContainer(t)
+	mgr := pm.NewSnap(pm.WithRunner(c.Runner()), pm.WithoutOperationLock())
+	results, err := mgr.(pm.Searcher).Search(context.Background(), "hello-world", pm.SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) == 0 || results[0].Name != "hello-world" {
+		t.Errorf("Search(hello-world) = %v", results)
+	}
+}
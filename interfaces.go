@@ -2,8 +2,16 @@ package pm
 
 import "context"
 
-// Manager provides core backend functionality: availability and capability introspection.
+// Manager provides core backend functionality: identification, availability
+// and capability introspection.
 type Manager interface {
+	// Name returns a human-readable name for the backend, such as
+	// "Homebrew", for logs and user-facing messages.
+	Name() string
+
+	// Kind returns the backend kind, such as BackendBrew.
+	Kind() BackendKind
+
 	// Available checks if the backend is available (installed/reachable).
 	Available(ctx context.Context) (bool, error)
 
@@ -68,3 +76,86 @@ type Searcher interface {
 type Lister interface {
 	ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error)
 }
+
+// OutdatedLister lists installed packages that have newer versions
+// available, without upgrading them.
+//
+// Semantics Contract:
+//   - ListOutdated MUST return only installed packages that Upgrade would
+//     change, each once; an empty list means Upgrade has nothing to do
+//   - ListOutdated MAY be answered from the metadata the last Update
+//     fetched, so call Update first for an up-to-date answer
+//   - ListOutdated MUST NOT change installed packages
+type OutdatedLister interface {
+	ListOutdated(ctx context.Context, opts OutdatedOptions) ([]OutdatedPackage, error)
+}
+
+// Querier reports the installed state of specific packages.
+//
+// Semantics Contract:
+//   - Query MUST return only the packages in pkgs that are installed, with
+//     their versions; packages that are not installed are left out, not
+//     reported as errors
+//   - Query SHOULD cost time proportional to len(pkgs), not to the number of
+//     installed packages
+//   - Query MUST NOT change system state
+type Querier interface {
+	Query(ctx context.Context, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error)
+}
+
+// Verifier reports the provenance of specific packages: who published
+// them, where they come from, and whether their content is checked
+// against a signature or checksum.
+//
+// Semantics Contract:
+//   - Verify MUST return one Attestation per package in pkgs, in order;
+//     packages the backend does not know have AttestationNotFound
+//   - Verify SHOULD attest packages that are not installed from what
+//     Install would fetch, so they can be checked before installing
+//   - Verify MUST NOT change system state
+type Verifier interface {
+	Verify(ctx context.Context, pkgs []PackageRef, opts VerifyOptions) ([]Attestation, error)
+}
+
+// Launcher starts installed applications, for "Open" buttons.
+//
+// Semantics Contract:
+//   - Launch MUST fail with NotInstalledError if the package is not
+//     installed, and with NotSupportedError, giving the reason, if it
+//     installs nothing that can be launched, such as a flatpak runtime, a
+//     base snap or a library formula
+//   - Launch MUST return once the application is started, without waiting
+//     for it to exit; cancelling ctx afterwards does not stop it
+//   - Launch MUST NOT change system state
+type Launcher interface {
+	Launch(ctx context.Context, ref PackageRef, opts LaunchOptions) (*Process, error)
+}
+
+// IconFetcher finds the icons of applications and keeps a copy in a local
+// cache directory, for GUIs that show packages with their icons.
+//
+// Semantics Contract:
+//   - Icon MUST return the path of a local copy of the icon, which stays
+//     valid after the package is uninstalled, or "" and no error if the
+//     backend knows no icon for the package
+//   - Icon SHOULD answer from the cache without running anything when it
+//     holds the icon, unless IconOptions.Refresh is set
+//   - Icon MUST NOT change system state outside the cache directory
+type IconFetcher interface {
+	Icon(ctx context.Context, ref PackageRef, opts IconOptions) (string, error)
+}
+
+// Watcher reports changes to installed packages as they happen, whoever
+// makes them: pm, the user running the backend's tool, or automatic
+// updates, so long-running UIs stay in sync.
+//
+// Semantics Contract:
+//   - Watch MUST fail if the installed packages cannot be listed when it is
+//     called, and otherwise return a channel of the changes made since
+//   - The channel MUST be closed once ctx is done, and not before
+//   - Changes made between two checks MAY be reported together, and a
+//     package installed and removed in between MAY not be reported at all
+//   - Watch MUST NOT change system state
+type Watcher interface {
+	Watch(ctx context.Context) (<-chan PackageEvent, error)
+}
@@ -0,0 +1,100 @@
+package brew
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// popularityMaxAge is how long install counts are kept. The Formulae API
+// publishes them once a day.
+const popularityMaxAge = 24 * time.Hour
+
+// popularityRetry is how long to go without counts after failing to fetch
+// them, so an unreachable endpoint does not cost every search a request.
+const popularityRetry = time.Hour
+
+// installAnalytics is the part of /analytics/install/30d.json used for
+// ranking. Counts are formatted with thousands separators.
+type installAnalytics struct {
+	Items []struct {
+		Formula string `json:"formula"`
+		Count   string `json:"count"`
+	} `json:"items"`
+}
+
+// Popularity returns each formula's install count over the last 30 days,
+// from the Formulae API's analytics. Counts are kept in memory for a day;
+// after a failure, Popularity returns no counts for an hour before trying
+// again.
+func (b *Backend) Popularity(ctx context.Context) (map[string]int, error) {
+	b.popularityMu.Lock()
+	defer b.popularityMu.Unlock()
+	if time.Now().Before(b.popularityExpires) {
+		return b.popularity, nil
+	}
+	popularity, err := b.fetchPopularity(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			b.popularity, b.popularityExpires = nil, time.Now().Add(popularityRetry)
+		}
+		return nil, err
+	}
+	b.popularity, b.popularityExpires = popularity, time.Now().Add(popularityMaxAge)
+	return popularity, nil
+}
+
+// fetchPopularity downloads the install counts.
+func (b *Backend) fetchPopularity(ctx context.Context) (map[string]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/analytics/install/30d.json", nil)
+	if err != nil {
+		return nil, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to create request: %w", err),
+		}
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, runner.ClassifyNetworkError(ctx, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to fetch install analytics: %w", err),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, runner.ClassifyHTTPStatus(resp.StatusCode, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("API returned status %d", resp.StatusCode),
+			Payload:   runner.ReadPayload(ctx, resp),
+		})
+	}
+
+	var analytics installAnalytics
+	if err := json.NewDecoder(resp.Body).Decode(&analytics); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to parse install analytics: %w", err),
+		}
+	}
+	popularity := make(map[string]int, len(analytics.Items))
+	for _, item := range analytics.Items {
+		if n, err := strconv.Atoi(strings.ReplaceAll(item.Count, ",", "")); err == nil {
+			popularity[item.Formula] = n
+		}
+	}
+	return popularity, nil
+}
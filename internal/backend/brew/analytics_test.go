@@ -0,0 +1,60 @@
+package brew
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackend_Popularity(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/analytics/install/30d.json" {
+			t.Errorf("Unexpected request for %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"category":"install","items":[
+			{"number":1,"formula":"git","count":"1,234,567","percent":"2.1"},
+			{"number":2,"formula":"wget","count":"89","percent":"0.1"},
+			{"number":3,"formula":"broken","count":"n/a","percent":"0"}]}`)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	for i := 0; i < 2; i++ {
+		popularity, err := b.Popularity(context.Background())
+		if err != nil {
+			t.Fatalf("Popularity() error = %v", err)
+		}
+		if popularity["git"] != 1234567 || popularity["wget"] != 89 || len(popularity) != 2 {
+			t.Errorf("Popularity() = %v", popularity)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Requests = %d, want 1", requests)
+	}
+}
+
+func TestBackend_PopularityBackoff(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	if _, err := b.Popularity(context.Background()); err == nil {
+		t.Fatal("Popularity() error = nil, want the HTTP failure")
+	}
+	if popularity, err := b.Popularity(context.Background()); err != nil || popularity != nil {
+		t.Errorf("Popularity() after a failure = %v, %v; want no counts", popularity, err)
+	}
+	if requests != 1 {
+		t.Errorf("Requests = %d, want 1", requests)
+	}
+}
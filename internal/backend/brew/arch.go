@@ -0,0 +1,65 @@
+package brew
+
+import (
+	"github.com/frostyard/pm/internal/types"
+)
+
+// archNames maps Go architecture names to Homebrew's. Homebrew runs only on
+// these two.
+var archNames = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "arm64",
+}
+
+// requirement is a formula requirement in the Formulae API. For the "arch"
+// requirement, Version is the architecture the formula needs, such as
+// "x86_64".
+type requirement struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// requiredArch returns the architecture reqs restrict a formula to, or ""
+// if it builds for any.
+func requiredArch(reqs []requirement) string {
+	for _, req := range reqs {
+		if req.Name == "arch" {
+			return req.Version
+		}
+	}
+	return ""
+}
+
+// targetArch returns the target architecture in Homebrew's naming, or ""
+// if there is none.
+func (b *Backend) targetArch() string {
+	if name, ok := archNames[b.arch]; ok {
+		return name
+	}
+	return b.arch
+}
+
+// supportsArch reports whether a formula requiring required can be
+// installed for the target architecture. Formulae without a bottle for it
+// are still built from source, so only the arch requirement counts.
+func (b *Backend) supportsArch(required string) bool {
+	return required == "" || b.arch == "" || required == b.targetArch()
+}
+
+// checkArch returns a *types.UnsupportedArchError for the first of pkgs the
+// formula index knows requires another architecture. Without an index it
+// returns nil and leaves brew to refuse.
+func (b *Backend) checkArch(pkgs []types.PackageRef) error {
+	idx := b.cachedIndex()
+	if idx == nil || b.arch == "" {
+		return nil
+	}
+	for _, pkg := range pkgs {
+		for _, f := range idx.Formulae {
+			if f.Name == pkg.Name && !b.supportsArch(f.Arch) {
+				return &types.UnsupportedArchError{Ref: pkg, Backend: "brew", Arch: b.targetArch(), Available: []string{f.Arch}}
+			}
+		}
+	}
+	return nil
+}
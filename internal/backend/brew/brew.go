@@ -2,8 +2,11 @@ package brew
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
@@ -12,8 +15,41 @@ import (
 // Backend implements the brew backend.
 type Backend struct {
 	httpClient *http.Client
+	baseURL    string
 	runner     runner.Runner
 	progress   types.ProgressReporter
+	arch       string
+	env        []string
+
+	// appDirs, if set, replaces the directories cask apps are looked for
+	// in.
+	appDirs []string
+
+	indexMu      sync.Mutex
+	indexDir     string
+	indexMaxAge  time.Duration
+	index        *formulaIndex
+	indexChecked time.Time
+
+	popularityMu      sync.Mutex
+	popularity        map[string]int
+	popularityExpires time.Time
+}
+
+// commandEnv keeps brew from updating itself or printing hints as a side
+// effect of other commands; Update runs `brew update` explicitly.
+var commandEnv = []string{
+	"HOMEBREW_NO_AUTO_UPDATE=1",
+	"HOMEBREW_NO_ENV_HINTS=1",
+}
+
+// withEnv sets the command environment (see SetEnv) on commands run with
+// the returned context.
+func (b *Backend) withEnv(ctx context.Context) context.Context {
+	if b.env != nil {
+		return runner.WithEnv(ctx, b.env...)
+	}
+	return runner.WithEnv(ctx, commandEnv...)
 }
 
 // New creates a new brew backend.
@@ -23,15 +59,41 @@ func New(httpClient *http.Client, r runner.Runner, progress types.ProgressReport
 	}
 	return &Backend{
 		httpClient: httpClient,
+		baseURL:    DefaultBaseURL,
 		runner:     r,
 		progress:   progress,
 	}
 }
 
+// SetBaseURL points Formulae API requests at url instead of
+// DefaultBaseURL, such as a mirror or a test server. An empty url restores
+// the default.
+func (b *Backend) SetBaseURL(url string) {
+	if url == "" {
+		url = DefaultBaseURL
+	}
+	b.baseURL = strings.TrimSuffix(url, "/")
+}
+
+// SetArch sets the target architecture, in Go's naming such as "amd64".
+// Searches leave out formulae that require another architecture, and
+// Install rejects those the formula index knows. An empty arch disables
+// both.
+func (b *Backend) SetArch(arch string) {
+	b.arch = arch
+}
+
+// SetEnv replaces the KEY=VALUE entries brew commands add to the inherited
+// environment, by default HOMEBREW_NO_AUTO_UPDATE=1 and
+// HOMEBREW_NO_ENV_HINTS=1. A nil env restores the default.
+func (b *Backend) SetEnv(env []string) {
+	b.env = env
+}
+
 // Available checks if brew is available by testing the Formulae API endpoint.
 func (b *Backend) Available(ctx context.Context) (bool, error) {
 	// Try a lightweight HEAD request to the formulae API
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, "https://formulae.brew.sh/api/formula.json", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, b.baseURL+"/formula.json", nil)
 	if err != nil {
 		return false, &types.NotAvailableError{Backend: "brew", Reason: "failed to create request: " + err.Error()}
 	}
@@ -49,33 +111,69 @@ func (b *Backend) Available(ctx context.Context) (bool, error) {
 	return false, &types.NotAvailableError{Backend: "brew", Reason: "formulae API returned non-2xx status"}
 }
 
-// Capabilities returns brew capabilities.
+// Capabilities probes `brew --version`. Search uses the Formulae API and
+// is always supported; the operations that run brew are unsupported, with
+// the reason in Notes, when brew cannot run here.
 func (b *Backend) Capabilities(ctx context.Context) ([]types.Capability, error) {
-	// Brew backend supports operations when runner is available
-	hasRunner := b.runner != nil
+	version, reason := b.probe(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cli := func(op types.Operation, notes string) types.Capability {
+		if version == "" {
+			return types.Capability{Operation: op, Notes: reason}
+		}
+		return types.Capability{Operation: op, Supported: true, Notes: notes + " (" + version + ")"}
+	}
 	return []types.Capability{
 		{Operation: types.OperationSearch, Supported: true, Notes: "via Formulae API"},
-		{Operation: types.OperationUpdateMetadata, Supported: hasRunner, Notes: "via brew update CLI"},
-		{Operation: types.OperationUpgradePackages, Supported: hasRunner, Notes: "via brew upgrade CLI"},
-		{Operation: types.OperationInstall, Supported: hasRunner, Notes: "via brew install CLI"},
-		{Operation: types.OperationUninstall, Supported: hasRunner, Notes: "via brew uninstall CLI"},
-		{Operation: types.OperationListInstalled, Supported: hasRunner, Notes: "via brew list CLI"},
+		cli(types.OperationUpdateMetadata, "via brew update CLI"),
+		cli(types.OperationUpgradePackages, "via brew upgrade CLI"),
+		cli(types.OperationInstall, "via brew install CLI"),
+		cli(types.OperationUninstall, "via brew uninstall CLI"),
+		cli(types.OperationListInstalled, "via brew list CLI"),
+		cli(types.OperationListOutdated, "via brew outdated CLI, as of the last brew update"),
+		cli(types.OperationQuery, "via brew list --versions CLI"),
+		cli(types.OperationVerify, "via brew info CLI"),
+		cli(types.OperationLaunch, "formula executables, and cask apps via open on macOS"),
+		cli(types.OperationIcon, "icons of installed cask apps on macOS; formulae have none"),
+		cli(types.OperationWatch, "by polling brew list CLI"),
 	}, nil
 }
 
+// probe returns the version brew reports, such as "Homebrew 4.3.1", or ""
+// and the reason brew cannot be used.
+func (b *Backend) probe(ctx context.Context) (version, reason string) {
+	if b.runner == nil {
+		return "", "no runner configured"
+	}
+	stdout, stderr, err := b.runner.Run(b.withEnv(ctx), "brew", "--version")
+	if err != nil {
+		if msg := strings.TrimSpace(stderr); msg != "" {
+			return "", "brew --version failed: " + msg
+		}
+		return "", "brew --version failed: " + err.Error()
+	}
+	version, _, _ = strings.Cut(strings.TrimSpace(stdout), "\n")
+	if version == "" {
+		return "", "brew --version returned no output"
+	}
+	return version, ""
+}
+
 // Update implements Updater using `brew update`.
 func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error) {
 	if b.runner == nil {
 		return types.UpdateResult{}, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("brew", types.OperationUpdateMetadata, b.progress, opts.Progress)
 	helper.BeginAction("Update")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running brew update")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(b.withEnv(ctx), helper),
 		b.runner,
 		types.OperationUpdateMetadata,
 		"brew",
@@ -96,24 +194,32 @@ func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.U
 	return types.UpdateResult{Changed: changed}, nil
 }
 
-// Upgrade implements Upgrader using `brew upgrade`.
+// Upgrade implements Upgrader using `brew upgrade`, naming opts.Packages
+// if given.
 func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error) {
 	if b.runner == nil {
 		return types.UpgradeResult{}, types.ErrNotSupported
 	}
+	if err := validate(types.OperationUpgradePackages, opts.Packages); err != nil {
+		return types.UpgradeResult{}, err
+	}
+	args := []string{"upgrade"}
+	for _, pkg := range opts.Packages {
+		args = append(args, pkg.Name)
+	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("brew", types.OperationUpgradePackages, b.progress, opts.Progress)
 	helper.BeginAction("Upgrade")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running brew upgrade")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(b.withEnv(ctx), helper),
 		b.runner,
 		types.OperationUpgradePackages,
 		"brew",
 		"brew",
-		"upgrade",
+		args...,
 	)
 	helper.EndTask()
 
@@ -126,17 +232,17 @@ func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types
 	var packagesChanged []types.PackageRef
 	changed := false
 
-	// Look for lines like "==> Upgrading <package>"
+	// Look for lines like "==> Upgrading <package>", skipping the
+	// "==> Upgrading N outdated packages:" summary that precedes them
 	lines := strings.Split(stdout, "\n")
 	for _, line := range lines {
-		if strings.Contains(line, "==> Upgrading") {
-			changed = true
+		if strings.Contains(line, "==> Upgrading") && !strings.HasSuffix(strings.TrimSpace(line), ":") {
 			// Extract package name after "Upgrading "
 			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				pkgName := parts[2]
+			if len(parts) >= 3 && namePattern.MatchString(parts[2]) {
+				changed = true
 				packagesChanged = append(packagesChanged, types.PackageRef{
-					Name: pkgName,
+					Name: parts[2],
 					Kind: "formula",
 				})
 			}
@@ -165,7 +271,24 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 		return types.InstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationInstall, pkgs); err != nil {
+		return types.InstallResult{}, err
+	}
+	if err := b.checkArch(pkgs); err != nil {
+		return types.InstallResult{}, err
+	}
+
+	// Leave out the packages Query finds installed already. If it fails, brew install
+	// still reports them.
+	var queried []error
+	if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+		pkgs, queried = types.SkipInstalled("brew", pkgs, installed)
+	}
+	if len(pkgs) == 0 {
+		return types.InstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationInstall, b.progress, opts.Progress)
 	helper.BeginAction("Install")
 	defer helper.EndAction()
 
@@ -177,8 +300,8 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	}
 
 	helper.BeginTask("Running brew install")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(b.withEnv(ctx), helper),
 		b.runner,
 		types.OperationInstall,
 		"brew",
@@ -188,9 +311,13 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	helper.EndTask()
 
 	if err != nil {
+		err = packageNotFound(err, stdout, stderr, pkgs)
+		skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Install failed: " + err.Error())
-		return types.InstallResult{}, err
+		return types.InstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were installed
 	var installed []types.PackageRef
@@ -206,7 +333,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 
 	// Assume all requested packages were installed
 	if changed {
-		installed = pkgs
+		installed = types.Unskipped(pkgs, skipped)
 		helper.Info("Install completed: installed packages")
 	} else {
 		helper.Info("Install completed: packages already installed")
@@ -215,6 +342,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	return types.InstallResult{
 		Changed:           changed,
 		PackagesInstalled: installed,
+		Skipped:           append(queried, skipped...),
 	}, nil
 }
 
@@ -228,7 +356,21 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 		return types.UninstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationUninstall, pkgs); err != nil {
+		return types.UninstallResult{}, err
+	}
+
+	// Leave out the packages Query finds not installed. If it fails, brew uninstall
+	// still reports them.
+	var queried []error
+	if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+		pkgs, queried = types.SkipNotInstalled("brew", pkgs, installed)
+	}
+	if len(pkgs) == 0 {
+		return types.UninstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationUninstall, b.progress, opts.Progress)
 	helper.BeginAction("Uninstall")
 	defer helper.EndAction()
 
@@ -240,8 +382,8 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	}
 
 	helper.BeginTask("Running brew uninstall")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(b.withEnv(ctx), helper),
 		b.runner,
 		types.OperationUninstall,
 		"brew",
@@ -251,9 +393,12 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	helper.EndTask()
 
 	if err != nil {
+		skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Uninstall failed: " + err.Error())
-		return types.UninstallResult{}, err
+		return types.UninstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were uninstalled
 	var uninstalled []types.PackageRef
@@ -269,7 +414,7 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 
 	// Assume all requested packages were uninstalled
 	if changed {
-		uninstalled = pkgs
+		uninstalled = types.Unskipped(pkgs, skipped)
 		helper.Info("Uninstall completed: uninstalled packages")
 	} else {
 		helper.Info("Uninstall completed: packages not found")
@@ -278,12 +423,13 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	return types.UninstallResult{
 		Changed:             changed,
 		PackagesUninstalled: uninstalled,
+		Skipped:             append(queried, skipped...),
 	}, nil
 }
 
 // Search implements Searcher using the Formulae API.
 func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error) {
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("brew", types.OperationSearch, b.progress, opts.Progress)
 	helper.BeginAction("Search")
 	defer helper.EndAction()
 
@@ -292,8 +438,16 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return []types.PackageRef{}, nil
 	}
 
-	helper.BeginTask("Fetch formulae")
-	results, err := b.searchFormulae(ctx, query)
+	stream := types.NewResultStream(opts)
+	var results []types.PackageRef
+	var err error
+	if opts.Exact {
+		helper.BeginTask("Fetch formula")
+		results, err = b.lookupFormula(ctx, query)
+	} else {
+		helper.BeginTask("Fetch formulae")
+		results, err = b.searchFormulae(ctx, query, opts, stream)
+	}
 	helper.EndTask()
 
 	if err != nil {
@@ -301,6 +455,7 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return nil, err
 	}
 
+	stream.Finish(results)
 	helper.Info("Search completed")
 	return results, nil
 }
@@ -311,13 +466,13 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		return nil, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("brew", types.OperationListInstalled, b.progress, opts.Progress)
 	helper.BeginAction("ListInstalled")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running brew list")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		b.withEnv(ctx),
 		b.runner,
 		types.OperationListInstalled,
 		"brew",
@@ -332,7 +487,71 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		return nil, err
 	}
 
-	// Parse output: each line is "package version"
+	installed := parseVersions(stdout)
+	helper.Info("ListInstalled completed")
+	return installed, nil
+}
+
+// Query implements Querier using `brew list --versions <names>`, which
+// checks only the named packages.
+func (b *Backend) Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	if len(pkgs) == 0 {
+		return []types.InstalledPackage{}, nil
+	}
+
+	if err := validate(types.OperationQuery, pkgs); err != nil {
+		return nil, err
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationQuery, b.progress, opts.Progress)
+	helper.BeginAction("Query")
+	defer helper.EndAction()
+
+	args := []string{"list", "--versions"}
+	for _, pkg := range pkgs {
+		args = append(args, pkg.Name)
+	}
+
+	helper.BeginTask("Running brew list")
+	stdout, _, err := runner.RunWithExternalError(
+		b.withEnv(ctx),
+		b.runner,
+		types.OperationQuery,
+		"brew",
+		"brew",
+		args...,
+	)
+	helper.EndTask()
+
+	// brew exits 1 if any of the packages is not installed, still listing
+	// those that are.
+	var extErr *types.ExternalFailureError
+	if err != nil && !(errors.As(err, &extErr) && extErr.ExitCode == 1) {
+		helper.Error("Query failed: " + err.Error())
+		return nil, err
+	}
+
+	installed := []types.InstalledPackage{}
+	for _, pkg := range parseVersions(stdout) {
+		ref := types.RefNamed(pkgs, pkg.Ref.Name)
+		if ref.Kind != "" {
+			pkg.Ref.Kind = ref.Kind
+		}
+		pkg.Ref.Namespace = ref.Namespace
+		installed = append(installed, pkg)
+	}
+
+	helper.Info("Query completed")
+	return installed, nil
+}
+
+// parseVersions parses `brew list --versions` output: each line is
+// "package version...".
+func parseVersions(stdout string) []types.InstalledPackage {
 	var installed []types.InstalledPackage
 	lines := strings.Split(stdout, "\n")
 	for _, line := range lines {
@@ -342,7 +561,7 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		}
 
 		parts := strings.Fields(line)
-		if len(parts) >= 1 {
+		if len(parts) >= 1 && namePattern.MatchString(parts[0]) {
 			pkg := types.InstalledPackage{
 				Ref: types.PackageRef{
 					Name: parts[0],
@@ -355,7 +574,5 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 			installed = append(installed, pkg)
 		}
 	}
-
-	helper.Info("ListInstalled completed")
-	return installed, nil
+	return installed
 }
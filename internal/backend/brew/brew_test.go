@@ -4,37 +4,46 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
+	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
 
 func TestBackend_Available(t *testing.T) {
-	t.Run("Returns true when API is reachable", func(t *testing.T) {
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}))
-		defer server.Close()
-
-		// Replace the default client with one that points to the test server
-		client := server.Client()
-		// We need to redirect the actual URL to our test server, which is tricky
-		// For simplicity, we'll just test that a backend with a valid client doesn't panic
-		b := New(client, nil, nil)
-		ctx := context.Background()
-
-		// This will fail because we're still hitting the real URL
-		// For proper testing, we'd need to make the URL configurable
-		_, err := b.Available(ctx)
-		// We expect an error since we can't reach the real API in tests
-		if err == nil {
-			t.Skip("Available() succeeded unexpectedly - network access in test")
-		}
-		// Verify the error is NotAvailable
-		if !types.IsNotAvailable(err) {
-			t.Errorf("Expected NotAvailable error, got %v", err)
-		}
-	})
+	tests := []struct {
+		name      string
+		status    int
+		available bool
+	}{
+		{"reachable", http.StatusOK, true},
+		{"server error", http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			b := New(server.Client(), nil, nil)
+			b.SetBaseURL(server.URL + "/api/")
+
+			available, err := b.Available(context.Background())
+			if available != tt.available {
+				t.Errorf("Available() = %v, %v; want %v", available, err, tt.available)
+			}
+			if !tt.available && !types.IsNotAvailable(err) {
+				t.Errorf("Expected NotAvailable error, got %v", err)
+			}
+			if path != "/api/formula.json" {
+				t.Errorf("Requested %q, want /api/formula.json", path)
+			}
+		})
+	}
 }
 
 func TestBackend_Capabilities(t *testing.T) {
@@ -110,3 +119,25 @@ func TestBackend_EmptyMethods(t *testing.T) {
 		}
 	})
 }
+
+// envRunner records the environment requested for each command.
+type envRunner struct {
+	env []string
+}
+
+func (r *envRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	r.env = runner.InvocationFromContext(ctx).Env
+	return "", "", nil
+}
+
+func TestBackend_CommandEnv(t *testing.T) {
+	r := &envRunner{}
+	b := New(nil, r, nil)
+
+	if _, err := b.Install(context.Background(), []types.PackageRef{{Name: "jq"}}, types.InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !slices.Contains(r.env, "HOMEBREW_NO_AUTO_UPDATE=1") {
+		t.Errorf("Expected HOMEBREW_NO_AUTO_UPDATE=1 in %q", r.env)
+	}
+}
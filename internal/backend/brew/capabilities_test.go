@@ -0,0 +1,58 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_CapabilitiesProbe(t *testing.T) {
+	t.Run("brew runs", func(t *testing.T) {
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+			Name:   "brew",
+			Args:   `^--version$`,
+			Stdout: "Homebrew 4.3.1\nHomebrew/homebrew-core (git revision 1a2b3c)\n",
+		}}}
+		caps, err := New(nil, fake, nil).Capabilities(context.Background())
+		if err != nil {
+			t.Fatalf("Capabilities() error = %v", err)
+		}
+		for _, c := range caps {
+			if !c.Supported {
+				t.Errorf("%s unsupported: %s", c.Operation, c.Notes)
+			}
+			if c.Operation == types.OperationInstall && !strings.Contains(c.Notes, "Homebrew 4.3.1") {
+				t.Errorf("Install notes %q should name the version", c.Notes)
+			}
+		}
+		if err := fake.Verify(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("brew missing", func(t *testing.T) {
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+			Name: "brew",
+			Err:  errors.New(`exec: "brew": executable file not found in $PATH`),
+		}}}
+		caps, err := New(nil, fake, nil).Capabilities(context.Background())
+		if err != nil {
+			t.Fatalf("Capabilities() error = %v", err)
+		}
+		for _, c := range caps {
+			if c.Operation == types.OperationSearch {
+				if !c.Supported {
+					t.Error("Search uses the Formulae API and should stay supported")
+				}
+				continue
+			}
+			if c.Supported || !strings.Contains(c.Notes, "executable file not found") {
+				t.Errorf("%s = %+v, want unsupported with the probe failure", c.Operation, c)
+			}
+		}
+	})
+}
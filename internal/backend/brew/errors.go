@@ -0,0 +1,114 @@
+package brew
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// notFoundPatterns match brew's messages for an unknown formula or cask;
+// the first group is the requested name.
+var notFoundPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`No available (?:formula or cask|formula|cask) with the name "([^"]+)"`),
+	regexp.MustCompile(`No formulae or casks found for "?([^"\s]+?)"?\.?(?:\s|$)`),
+	regexp.MustCompile(`Cask '([^']+)' is unavailable`),
+}
+
+// didYouMean matches brew's inline suggestion, e.g. "Did you mean wget?".
+var didYouMean = regexp.MustCompile(`Did you mean (?:one of these\?\s*)?([^?]+)\?`)
+
+// packageNotFound returns a *types.PackageNotFoundError wrapping err if
+// brew's output says one of pkgs does not exist, and err otherwise.
+func packageNotFound(err error, stdout, stderr string, pkgs []types.PackageRef) error {
+	if err == nil {
+		return nil
+	}
+	output := stderr + "\n" + stdout
+	for _, pattern := range notFoundPatterns {
+		m := pattern.FindStringSubmatch(output)
+		if m == nil {
+			continue
+		}
+		ref := types.RefNamed(pkgs, m[1])
+		return &types.PackageNotFoundError{
+			Ref:         ref,
+			Backend:     "brew",
+			Suggestions: suggestions(output),
+			Err:         err,
+		}
+	}
+	return err
+}
+
+// suggestions extracts the close matches brew lists after a failed
+// lookup, either inline ("Did you mean wget?") or in the "Searching for
+// similarly named formulae and casks" listing.
+func suggestions(output string) []string {
+	if m := didYouMean.FindStringSubmatch(output); m != nil {
+		var names []string
+		for _, name := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+			if name != "or" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	_, listing, ok := strings.Cut(output, "Searching for similarly named")
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(listing, "\n")[1:] {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "To install") {
+			break
+		}
+		if line == "" || strings.HasPrefix(line, "==>") {
+			continue
+		}
+		for _, name := range strings.Fields(line) {
+			if name != "✔" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// alreadyInstalledPatterns match brew's warnings for requested packages
+// that are already installed, e.g. "Warning: wget 1.24.5 is already
+// installed and up-to-date."
+var alreadyInstalledPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Warning: ([^\s']+) (?:[^\s']+ )?is already installed`),
+	regexp.MustCompile(`Warning: Cask '([^']+)' is already installed`),
+}
+
+// notInstalledPatterns match brew's errors for removing packages that are
+// not installed.
+var notInstalledPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`No such keg: \S*?([^/\s]+)(?:\s|$)`),
+	regexp.MustCompile(`No installed keg or cask with the name "([^"]+)"`),
+	regexp.MustCompile(`Cask '([^']+)' is not installed`),
+}
+
+// alreadyInstalled returns a *types.AlreadyInstalledError wrapping cause
+// for each of pkgs brew reports as already installed.
+func alreadyInstalled(output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, alreadyInstalledPatterns...) {
+		errs = append(errs, &types.AlreadyInstalledError{Ref: ref, Backend: "brew", Err: cause})
+	}
+	return errs
+}
+
+// notInstalled returns a *types.NotInstalledError wrapping cause for each
+// of pkgs brew reports as not installed.
+func notInstalled(op types.Operation, output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, notInstalledPatterns...) {
+		errs = append(errs, &types.NotInstalledError{Operation: op, Ref: ref, Backend: "brew", Err: cause})
+	}
+	return errs
+}
@@ -0,0 +1,189 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestPackageNotFound(t *testing.T) {
+	tests := []struct {
+		name        string
+		stderr      string
+		wantName    string
+		suggestions []string
+	}{
+		{
+			name:     "formula",
+			stderr:   `Error: No available formula with the name "nosuchthing".`,
+			wantName: "nosuchthing",
+		},
+		{
+			name:        "inline suggestion",
+			stderr:      `Warning: No available formula with the name "wgett". Did you mean wget?`,
+			wantName:    "wgett",
+			suggestions: []string{"wget"},
+		},
+		{
+			name: "similarly named listing",
+			stderr: `Warning: No available formula with the name "jqq".
+==> Searching for similarly named formulae and casks...
+==> Formulae
+jq ✔
+jqp
+
+To install jq ✔, run:
+  brew install jq ✔`,
+			wantName:    "jqq",
+			suggestions: []string{"jq", "jqp"},
+		},
+		{
+			name:     "search miss",
+			stderr:   `Error: No formulae or casks found for nosuchthing.`,
+			wantName: "nosuchthing",
+		},
+		{
+			name:     "cask",
+			stderr:   `Error: Cask 'nosuchcask' is unavailable: No Cask with this name exists.`,
+			wantName: "nosuchcask",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := errors.New("exit status 1")
+			err := packageNotFound(cause, "", tt.stderr, []types.PackageRef{{Name: tt.wantName, Kind: "formula"}})
+
+			var pnfErr *types.PackageNotFoundError
+			if !errors.As(err, &pnfErr) {
+				t.Fatalf("Expected *PackageNotFoundError, got %v", err)
+			}
+			if pnfErr.Ref.Name != tt.wantName || pnfErr.Ref.Kind != "formula" || pnfErr.Backend != "brew" {
+				t.Errorf("Unexpected error: %+v", pnfErr)
+			}
+			if !slices.Equal(pnfErr.Suggestions, tt.suggestions) {
+				t.Errorf("Suggestions = %q, want %q", pnfErr.Suggestions, tt.suggestions)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("Expected the underlying error to be preserved")
+			}
+		})
+	}
+}
+
+func TestPackageNotFound_OtherFailure(t *testing.T) {
+	cause := errors.New("exit status 1")
+	if err := packageNotFound(cause, "", "Error: Permission denied @ dir_s_mkdir", nil); err != cause {
+		t.Errorf("Expected unrelated failure unchanged, got %v", err)
+	}
+}
+
+// failingRunner fails every command with the given stderr.
+type failingRunner struct {
+	stderr string
+}
+
+func (r *failingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	return "", r.stderr, errors.New("exit status 1")
+}
+
+func TestBackend_InstallNotFound(t *testing.T) {
+	b := New(nil, &failingRunner{stderr: `Error: No available formula with the name "nosuchthing".`}, nil)
+
+	_, err := b.Install(context.Background(), []types.PackageRef{{Name: "nosuchthing"}}, types.InstallOptions{})
+	if !types.IsPackageNotFound(err) || !types.IsExternalFailure(err) {
+		t.Errorf("Expected a not-found external failure, got %v", err)
+	}
+}
+
+func TestAlreadyInstalled(t *testing.T) {
+	output := `Warning: wget 1.24.5 is already installed and up-to-date.
+To reinstall 1.24.5, run:
+  brew reinstall wget
+Warning: Cask 'firefox' is already installed.`
+	pkgs := []types.PackageRef{{Name: "wget", Kind: "formula"}, {Name: "firefox", Kind: "cask"}, {Name: "jq"}}
+
+	skipped := alreadyInstalled(output, pkgs, nil)
+	if len(skipped) != 2 {
+		t.Fatalf("Expected 2 skipped packages, got %v", skipped)
+	}
+	var aiErr *types.AlreadyInstalledError
+	if !errors.As(skipped[1], &aiErr) || aiErr.Ref.Kind != "cask" {
+		t.Errorf("Unexpected second entry: %v", skipped[1])
+	}
+	if rest := types.Unskipped(pkgs, skipped); len(rest) != 1 || rest[0].Name != "jq" {
+		t.Errorf("Unskipped() = %v, want [jq]", rest)
+	}
+}
+
+func TestNotInstalled(t *testing.T) {
+	tests := []string{
+		"Error: No such keg: /opt/homebrew/Cellar/wget",
+		`Error: No installed keg or cask with the name "wget"`,
+	}
+	for _, stderr := range tests {
+		skipped := notInstalled(types.OperationUninstall, stderr, []types.PackageRef{{Name: "wget"}}, nil)
+		if len(skipped) != 1 || !types.IsNotInstalled(skipped[0]) {
+			t.Errorf("notInstalled(%q) = %v", stderr, skipped)
+		}
+	}
+}
+
+// scriptedRunner returns fixed output for every command.
+type scriptedRunner struct {
+	stdout, stderr string
+	err            error
+}
+
+func (r *scriptedRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	return r.stdout, r.stderr, r.err
+}
+
+func TestBackend_InstallSkipsInstalled(t *testing.T) {
+	b := New(nil, &scriptedRunner{
+		stdout: "==> Downloading https://ghcr.io/v2/homebrew/core/jq/blobs/sha256:abc\n==> Installing jq\n",
+		stderr: "Warning: wget 1.24.5 is already installed and up-to-date.\n",
+	}, nil)
+
+	res, err := b.Install(context.Background(), []types.PackageRef{{Name: "wget"}, {Name: "jq"}}, types.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(res.Skipped) != 1 || !types.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want wget", res.Skipped)
+	}
+	if len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "jq" {
+		t.Errorf("PackagesInstalled = %v, want [jq]", res.PackagesInstalled)
+	}
+}
+
+func TestBackend_UninstallNotInstalled(t *testing.T) {
+	b := New(nil, &scriptedRunner{stderr: "Error: No such keg: /opt/homebrew/Cellar/wget\n", err: errors.New("exit status 1")}, nil)
+
+	_, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "wget"}}, types.UninstallOptions{})
+	if !types.IsNotInstalled(err) || !types.IsExternalFailure(err) {
+		t.Errorf("Expected a not-installed external failure, got %v", err)
+	}
+}
+
+func TestBackend_UninstallSomeNotInstalled(t *testing.T) {
+	b := New(nil, &scriptedRunner{
+		stderr: "Error: No such keg: /opt/homebrew/Cellar/wget\nError: Directory not empty @ dir_s_rmdir - /opt/homebrew/Cellar/jq/1.7.1\n",
+		err:    errors.New("exit status 1"),
+	}, nil)
+
+	res, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "wget"}, {Name: "jq"}}, types.UninstallOptions{})
+	if len(res.Skipped) != 1 || !types.IsNotInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want wget", res.Skipped)
+	}
+	if _, ok := err.(*types.NotInstalledError); ok || !types.IsExternalFailure(err) {
+		t.Errorf("Expected the failure of jq to be returned, got %v", err)
+	}
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) || !strings.Contains(extErr.Stderr, "Directory not empty") {
+		t.Errorf("Expected the error to keep the command's output, got %v", err)
+	}
+}
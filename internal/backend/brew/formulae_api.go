@@ -4,29 +4,42 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
+	"github.com/frostyard/pm/internal/rank"
+	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
 
-const (
-	formulaeAPIBase = "https://formulae.brew.sh/api"
-)
+// DefaultBaseURL is the base URL of the Homebrew Formulae API.
+const DefaultBaseURL = "https://formulae.brew.sh/api"
 
 // formulaInfo represents a formula from the Homebrew Formulae API.
 type formulaInfo struct {
 	Name     string `json:"name"`
 	FullName string `json:"full_name"`
 	Desc     string `json:"desc"`
+	Versions struct {
+		Stable string `json:"stable"`
+	} `json:"versions"`
+	Requirements []requirement `json:"requirements"`
 }
 
-// searchFormulae searches for formulae by name using the API.
-// Returns a list of matching package references.
-func (b *Backend) searchFormulae(ctx context.Context, query string) ([]types.PackageRef, error) {
+// searchFormulae searches for formulae by name, and with
+// opts.IncludeDescriptions by description, using the API. Formulae that
+// require another architecture than the target are left out.
+// Returns a list of matching package references, sending each to stream
+// as it is decoded.
+func (b *Backend) searchFormulae(ctx context.Context, query string, opts types.SearchOptions, stream *types.ResultStream) ([]types.PackageRef, error) {
+	if idx := b.freshIndex(); idx != nil {
+		return idx.searchWith(query, opts, b.supportsArch), nil
+	}
+
 	// The Formulae API provides /api/formula.json which lists all formulae
 	// We fetch it and filter client-side
-	url := formulaeAPIBase + "/formula.json"
+	url := b.baseURL + "/formula.json"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -36,46 +49,194 @@ func (b *Backend) searchFormulae(ctx context.Context, query string) ([]types.Pac
 			Err:       fmt.Errorf("failed to create request: %w", err),
 		}
 	}
+	cached := b.cachedIndex()
+	if cached != nil {
+		// Revalidate the on-disk index rather than downloading the full
+		// list again
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
 
 	resp, err := b.httpClient.Do(req)
 	if err != nil {
-		return nil, &types.ExternalFailureError{
+		return nil, runner.ClassifyNetworkError(ctx, &types.ExternalFailureError{
 			Operation: types.OperationSearch,
 			Backend:   "brew",
 			Err:       fmt.Errorf("failed to fetch formula list: %w", err),
-		}
+		})
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		b.revalidatedIndex()
+		return cached.searchWith(query, opts, b.supportsArch), nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, &types.ExternalFailureError{
+		return nil, runner.ClassifyHTTPStatus(resp.StatusCode, &types.ExternalFailureError{
 			Operation: types.OperationSearch,
 			Backend:   "brew",
 			Err:       fmt.Errorf("API returned status %d", resp.StatusCode),
+			Payload:   runner.ReadPayload(ctx, resp),
+		})
+	}
+
+	// The API returns an array of formula objects. Decode it one element at
+	// a time, keeping only matches (and, with an index directory, the
+	// trimmed index), so the multi-megabyte list is never held in memory at
+	// once.
+	var results []types.PackageRef
+	queryLower := strings.ToLower(query)
+	var index *formulaIndex
+	if b.keepsIndex() {
+		index = &formulaIndex{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	}
+	err = decodeFormulae(ctx, resp.Body, func(formula formulaInfo) {
+		arch := requiredArch(formula.Requirements)
+		if b.supportsArch(arch) && (matches(formula.Name, queryLower, opts.Fuzzy) || opts.IncludeDescriptions && matchesDesc(formula.Desc, queryLower)) {
+			ref := types.PackageRef{
+				Name: formula.Name,
+				Kind: "formula",
+			}
+			results = append(results, ref)
+			stream.Send(ref)
+		}
+		if index != nil {
+			index.Formulae = append(index.Formulae, indexEntry{Name: formula.Name, Desc: formula.Desc, Version: formula.Versions.Stable, Arch: arch})
+		}
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to parse response: %w", err),
+		}
+	}
+	if index != nil {
+		b.storeIndex(index)
+	}
+
+	return results, nil
+}
+
+// lookupFormula fetches the single formula named name, a few kilobytes
+// rather than the full formula list. A formula the API does not know, or
+// that requires another architecture than the target, yields no results.
+func (b *Backend) lookupFormula(ctx context.Context, name string) ([]types.PackageRef, error) {
+	if !namePattern.MatchString(name) {
+		// Not a formula name, and not safe to put in the URL path
+		return []types.PackageRef{}, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/formula/"+name+".json", nil)
+	if err != nil {
+		return nil, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to create request: %w", err),
 		}
 	}
 
-	// The API returns an array of formula objects
-	var formulae []formulaInfo
-	if err := json.NewDecoder(resp.Body).Decode(&formulae); err != nil {
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, runner.ClassifyNetworkError(ctx, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("failed to fetch formula: %w", err),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return []types.PackageRef{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, runner.ClassifyHTTPStatus(resp.StatusCode, &types.ExternalFailureError{
+			Operation: types.OperationSearch,
+			Backend:   "brew",
+			Err:       fmt.Errorf("API returned status %d", resp.StatusCode),
+			Payload:   runner.ReadPayload(ctx, resp),
+		})
+	}
+
+	var formula formulaInfo
+	if err := json.NewDecoder(resp.Body).Decode(&formula); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, &types.ExternalFailureError{
 			Operation: types.OperationSearch,
 			Backend:   "brew",
 			Err:       fmt.Errorf("failed to parse response: %w", err),
 		}
 	}
+	// The API resolves aliases and old names, so only report the formula
+	// if it really has the name asked for.
+	if formula.Name != name || !b.supportsArch(requiredArch(formula.Requirements)) {
+		return []types.PackageRef{}, nil
+	}
+	return []types.PackageRef{{Name: formula.Name, Kind: "formula"}}, nil
+}
+
+// decodeFormulae stream-decodes a JSON array of formulae from r, calling
+// visit for each.
+func decodeFormulae(ctx context.Context, r io.Reader, visit func(formulaInfo)) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("expected an array, got %v", tok)
+	}
 
-	// Filter formulae by query (case-insensitive substring match)
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var formula formulaInfo
+		if err := dec.Decode(&formula); err != nil {
+			return err
+		}
+		visit(formula)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// filterFormulae stream-decodes a JSON array of formulae from r and returns
+// those whose name contains query, case-insensitively.
+func filterFormulae(ctx context.Context, r io.Reader, query string) ([]types.PackageRef, error) {
 	var results []types.PackageRef
 	queryLower := strings.ToLower(query)
-	for _, formula := range formulae {
-		if strings.Contains(strings.ToLower(formula.Name), queryLower) {
+	err := decodeFormulae(ctx, r, func(formula formulaInfo) {
+		if matches(formula.Name, queryLower, false) {
 			results = append(results, types.PackageRef{
 				Name: formula.Name,
 				Kind: "formula",
 			})
 		}
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return results, nil
 }
+
+// matches reports whether name contains queryLower, case-insensitively, or,
+// with fuzzy set, is within a few typos of it.
+func matches(name, queryLower string, fuzzy bool) bool {
+	if strings.Contains(strings.ToLower(name), queryLower) {
+		return true
+	}
+	return fuzzy && rank.Fuzzy(name, queryLower)
+}
+
+// matchesDesc reports whether desc contains queryLower, case-insensitively.
+func matchesDesc(desc, queryLower string) bool {
+	return strings.Contains(strings.ToLower(desc), queryLower)
+}
@@ -2,7 +2,15 @@ package brew
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/frostyard/pm/internal/types"
 )
@@ -22,40 +30,232 @@ func TestBackend_Search_Integration(t *testing.T) {
 		}
 	})
 
-	// Note: Real API tests would require network access or a test server
-	// For deterministic tests, we'd need to make the API URL injectable
-	t.Run("Real API search", func(t *testing.T) {
-		// Skip unless we're running integration tests
-		if testing.Short() {
-			t.Skip("Skipping integration test in short mode")
-		}
+	t.Run("Filters the formula list", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `[{"name":"git","full_name":"git"},{"name":"git-lfs","full_name":"git-lfs"},{"name":"wget","full_name":"wget"}]`)
+		}))
+		defer server.Close()
 
-		b := New(nil, nil, nil)
-		ctx := context.Background()
+		b := New(server.Client(), nil, nil)
+		b.SetBaseURL(server.URL)
 
-		// This will hit the real API
-		results, err := b.Search(ctx, "git", types.SearchOptions{})
+		results, err := b.Search(context.Background(), "git", types.SearchOptions{})
 		if err != nil {
-			// It's okay if the API is unreachable in tests
-			if !types.IsExternalFailure(err) && !types.IsNotAvailable(err) {
-				t.Errorf("Expected ExternalFailure or NotAvailable, got %v", err)
+			t.Fatalf("Search() error = %v", err)
+		}
+		var names []string
+		for _, pkg := range results {
+			if pkg.Kind != "formula" {
+				t.Errorf("Expected Kind='formula', got %q", pkg.Kind)
 			}
-			t.Skipf("API unreachable: %v", err)
+			names = append(names, pkg.Name)
 		}
-
-		// Verify we got some results
-		if len(results) == 0 {
-			t.Error("Expected some results for 'git' query")
+		if strings.Join(names, ",") != "git,git-lfs" {
+			t.Errorf("Search() = %v, want git and git-lfs", names)
 		}
+	})
+}
 
-		// Verify result structure
-		for _, pkg := range results {
-			if pkg.Name == "" {
-				t.Error("Expected non-empty package name")
+// roundTripFunc serves HTTP requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBackend_Search_NetworkErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport roundTripFunc
+		retryable bool
+	}{
+		{"unavailable", func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		}, true},
+		{"dns", func(*http.Request) (*http.Response, error) {
+			return nil, &net.DNSError{Err: "no such host", Name: "formulae.brew.sh", IsNotFound: true}
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(&http.Client{Transport: tt.transport}, nil, nil)
+
+			_, err := b.Search(context.Background(), "git", types.SearchOptions{})
+			var netErr *types.NetworkError
+			if !errors.As(err, &netErr) || netErr.Retryable() != tt.retryable {
+				t.Errorf("Expected a network error, got %v", err)
 			}
-			if pkg.Kind != "formula" {
-				t.Errorf("Expected Kind='formula', got %q", pkg.Kind)
+		})
+	}
+}
+
+func TestBackend_Search_ErrorPayload(t *testing.T) {
+	transport := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       io.NopCloser(strings.NewReader(`{"error": "formula.json not found"}`)),
+		}, nil
+	})
+	b := New(&http.Client{Transport: transport}, nil, nil)
+
+	_, err := b.Search(context.Background(), "git", types.SearchOptions{})
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Payload["message"] != "formula.json not found" {
+		t.Errorf("Expected the API error payload, got %v", err)
+	}
+}
+
+func TestFilterFormulae(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{"matches", `[{"name":"git","desc":"VCS","versions":{"stable":"2.44.0"}},{"name":"Git-Cola"},{"name":"wget"}]`, []string{"git", "Git-Cola"}, false},
+		{"empty", `[]`, nil, false},
+		{"not an array", `{"name":"git"}`, nil, true},
+		{"truncated", `[{"name":"git"},{"name":"wg`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := filterFormulae(context.Background(), strings.NewReader(tt.body), "GIT")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filterFormulae() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, r := range results {
+				names = append(names, r.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filterFormulae() = %v, want %v", names, tt.want)
 			}
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := filterFormulae(ctx, strings.NewReader(`[{"name":"git"}]`), "git"); !errors.Is(err, context.Canceled) {
+			t.Errorf("filterFormulae() error = %v, want context.Canceled", err)
 		}
 	})
 }
+
+func BenchmarkFilterFormulae(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := range 5000 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"name":"formula%d","full_name":"formula%d","desc":"A formula with a long description","versions":{"stable":"1.%d"},"dependencies":["a","b","c"]}`, i, i, i)
+	}
+	sb.WriteString("]")
+	body := sb.String()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for b.Loop() {
+		if _, err := filterFormulae(context.Background(), strings.NewReader(body), "formula42"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBackend_SearchExact(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/formula/python@3.12.json":
+			_, _ = io.WriteString(w, `{"name":"python@3.12","full_name":"python@3.12","desc":"Interpreted, interactive, object-oriented programming language"}`)
+		case "/formula/python3.json":
+			// An alias resolves to the formula it points at
+			_, _ = io.WriteString(w, `{"name":"python@3.13","full_name":"python@3.13"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	ctx := context.Background()
+	exact := types.SearchOptions{Exact: true}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"python@3.12", []string{"python@3.12"}},
+		{"python3", nil},
+		{"nonexistent", nil},
+		{"../formula", nil},
+	}
+	for _, tt := range tests {
+		results, err := b.Search(ctx, tt.query, exact)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", tt.query, err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	want := []string{"/formula/python@3.12.json", "/formula/python3.json", "/formula/nonexistent.json"}
+	if fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("Requested %v, want %v (never the full list)", paths, want)
+	}
+}
+
+func TestBackend_SearchExactServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	if _, err := b.Search(context.Background(), "git", types.SearchOptions{Exact: true}); err == nil {
+		t.Error("Expected an error for a 503 response")
+	}
+}
+
+func TestBackend_SearchOnResult(t *testing.T) {
+	first := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"git"},`)
+		w.(http.Flusher).Flush()
+		// The rest of the list is sent only once the first match has been
+		// passed on, so a search that waits for the whole body would stall.
+		select {
+		case <-first:
+		case <-time.After(5 * time.Second):
+			t.Error("First result was not passed on before the list was complete")
+		}
+		_, _ = io.WriteString(w, `{"name":"git-lfs"},{"name":"wget"}]`)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	var streamed []string
+	opts := types.SearchOptions{OnResult: func(ref types.PackageRef) {
+		if len(streamed) == 0 {
+			close(first)
+		}
+		streamed = append(streamed, ref.Name)
+	}}
+	results, err := b.Search(context.Background(), "git", opts)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 || fmt.Sprint(streamed) != "[git git-lfs]" {
+		t.Errorf("OnResult got %v for results %+v, want each result once", streamed, results)
+	}
+}
@@ -0,0 +1,67 @@
+package brew
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/golden"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// addCorpus seeds f with the golden fixtures of one operation.
+func addCorpus(f *testing.F, op string) {
+	paths, _ := filepath.Glob(filepath.Join("testdata/golden", op, "*.stdout"))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+}
+
+// checkRef fails if ref could not have come from a line of stdout.
+func checkRef(t *testing.T, stdout string, ref types.PackageRef) {
+	t.Helper()
+	if !namePattern.MatchString(ref.Name) || !strings.Contains(stdout, ref.Name) {
+		t.Errorf("Parsed invalid formula name %q", ref.Name)
+	}
+}
+
+func FuzzListInstalled(f *testing.F) {
+	addCorpus(f, "list")
+	f.Fuzz(func(t *testing.T, stdout string) {
+		pkgs, err := New(nil, golden.Fixture{Stdout: stdout}, nil).ListInstalled(context.Background(), types.ListOptions{})
+		if err != nil {
+			t.Fatalf("ListInstalled() error = %v", err)
+		}
+		if len(pkgs) > strings.Count(stdout, "\n")+1 {
+			t.Errorf("Parsed %d packages from %d lines", len(pkgs), strings.Count(stdout, "\n")+1)
+		}
+		for _, pkg := range pkgs {
+			checkRef(t, stdout, pkg.Ref)
+			if strings.ContainsAny(pkg.Version, " \t\n") {
+				t.Errorf("Parsed invalid version %q", pkg.Version)
+			}
+		}
+	})
+}
+
+func FuzzUpgrade(f *testing.F) {
+	addCorpus(f, "update")
+	f.Fuzz(func(t *testing.T, stdout string) {
+		res, err := New(nil, golden.Fixture{Stdout: stdout}, nil).Upgrade(context.Background(), types.UpgradeOptions{})
+		if err != nil {
+			t.Fatalf("Upgrade() error = %v", err)
+		}
+		if res.Changed != (len(res.PackagesChanged) > 0) {
+			t.Errorf("Changed = %v with packages %v", res.Changed, res.PackagesChanged)
+		}
+		for _, ref := range res.PackagesChanged {
+			checkRef(t, stdout, ref)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package brew
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/golden"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestGolden(t *testing.T) {
+	ctx := context.Background()
+	t.Run("list", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/list", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).ListInstalled(ctx, types.ListOptions{})
+		})
+	})
+	t.Run("upgrade", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/upgrade", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).Upgrade(ctx, types.UpgradeOptions{})
+		})
+	})
+	t.Run("install", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/install", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).Install(ctx, []types.PackageRef{{Name: "jq"}}, types.InstallOptions{})
+		})
+	})
+}
@@ -0,0 +1,84 @@
+package brew
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// IconSource implements IconFetcher for casks: the .icns file an installed
+// cask's app bundle names as its CFBundleIconFile, which `defaults read`
+// reads from the bundle's Info.plist. The bundle is looked for in
+// /Applications and ~/Applications. Formulae, and casks that are not
+// installed or install no app, have no icon. ICNS files hold every size,
+// so opts.Size is ignored.
+func (b *Backend) IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error) {
+	if b.runner == nil {
+		return types.IconSource{}, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationIcon, []types.PackageRef{pkg}); err != nil {
+		return types.IconSource{}, err
+	}
+	if pkg.Kind == "formula" {
+		return types.IconSource{}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationIcon, b.progress, opts.Progress)
+	helper.BeginAction("Icon")
+	defer helper.EndAction()
+
+	info, err := b.info(ctx, helper, types.OperationIcon, pkg)
+	if err != nil {
+		helper.Error("Icon failed: " + err.Error())
+		return types.IconSource{}, err
+	}
+	if len(info.Formulae) > 0 || len(info.Casks) == 0 || info.Casks[0].Installed == nil {
+		return types.IconSource{}, nil
+	}
+	bundle := b.appBundle(caskApp(info.Casks[0].Artifacts))
+	if bundle == "" {
+		return types.IconSource{}, nil
+	}
+
+	helper.BeginTask("Reading the icon of " + filepath.Base(bundle))
+	stdout, _, err := b.runner.Run(ctx, "defaults", "read", filepath.Join(bundle, "Contents", "Info"), "CFBundleIconFile")
+	helper.EndTask()
+	if err != nil {
+		// Bundles without CFBundleIconFile show the generic icon.
+		return types.IconSource{}, ctx.Err()
+	}
+	name := strings.TrimSpace(stdout)
+	if filepath.Ext(name) == "" {
+		name += ".icns"
+	}
+	path := filepath.Join(bundle, "Contents", "Resources", name)
+	if _, err := os.Stat(path); err != nil {
+		return types.IconSource{}, nil
+	}
+	return types.IconSource{Path: path}, nil
+}
+
+// appBundle returns the path of the installed app bundle called app, such
+// as "/Applications/Firefox.app", or "" if app is empty or not found.
+func (b *Backend) appBundle(app string) string {
+	if app == "" {
+		return ""
+	}
+	dirs := b.appDirs
+	if dirs == nil {
+		dirs = []string{"/Applications"}
+		if home, err := os.UserHomeDir(); err == nil {
+			dirs = append(dirs, filepath.Join(home, "Applications"))
+		}
+	}
+	for _, dir := range dirs {
+		if info, err := os.Stat(filepath.Join(dir, app)); err == nil && info.IsDir() {
+			return filepath.Join(dir, app)
+		}
+	}
+	return ""
+}
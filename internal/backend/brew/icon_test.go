@@ -0,0 +1,61 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_IconSource(t *testing.T) {
+	apps := t.TempDir()
+	resources := filepath.Join(apps, "Firefox.app", "Contents", "Resources")
+	if err := os.MkdirAll(resources, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	icon := filepath.Join(resources, "firefox.icns")
+	if err := os.WriteFile(icon, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(apps, "Plain.app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "brew", Args: `^info --json=v2 --cask firefox$`, Stdout: `{"formulae":[],"casks":[{"token":"firefox","installed":"125.0","artifacts":[{"app":["Firefox.app"]}]}]}`},
+		{Name: "defaults", Args: `^read .*/Firefox\.app/Contents/Info CFBundleIconFile$`, Stdout: "firefox\n"},
+		{Name: "brew", Args: `^info --json=v2 plain$`, Stdout: `{"formulae":[],"casks":[{"token":"plain","installed":"1.0","artifacts":[{"app":["Plain.app"]}]}]}`},
+		{Name: "defaults", Args: `^read .*/Plain\.app/Contents/Info CFBundleIconFile$`, Stderr: "The domain/default pair does not exist", Err: errors.New("exit status 1")},
+		{Name: "brew", Args: `^info --json=v2 --cask slack$`, Stdout: `{"formulae":[],"casks":[{"token":"slack","installed":null,"artifacts":[{"app":["Slack.app"]}]}]}`},
+		{Name: "brew", Args: `^info --json=v2 jq$`, Stdout: `{"formulae":[{"name":"jq","installed":[{"version":"1.7.1"}]}],"casks":[]}`},
+	}}
+	b := New(nil, fake, nil)
+	b.appDirs = []string{apps}
+
+	tests := []struct {
+		ref  types.PackageRef
+		want string
+	}{
+		{types.PackageRef{Name: "firefox", Kind: "cask"}, icon},
+		{types.PackageRef{Name: "plain"}, ""},
+		{types.PackageRef{Name: "slack", Kind: "cask"}, ""},
+		{types.PackageRef{Name: "jq"}, ""},
+		{types.PackageRef{Name: "wget", Kind: "formula"}, ""},
+	}
+	for _, tt := range tests {
+		src, err := b.IconSource(context.Background(), tt.ref, types.IconOptions{})
+		if err != nil {
+			t.Fatalf("IconSource(%s) error = %v", tt.ref.Name, err)
+		}
+		if src.Path != tt.want || src.URL != "" {
+			t.Errorf("IconSource(%s) = %+v, want %s", tt.ref.Name, src, tt.want)
+		}
+	}
+	if err := fake.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
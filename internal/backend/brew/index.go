@@ -0,0 +1,253 @@
+package brew
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frostyard/pm/internal/rank"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// indexFile is the name of the on-disk formula index.
+const indexFile = "formula-index.json"
+
+// formulaIndex is the trimmed formula list kept between searches and, with
+// an index directory, between processes. It is revalidated with the ETag
+// and Last-Modified headers of the response it was built from.
+type formulaIndex struct {
+	ETag         string       `json:"etag,omitempty"`
+	LastModified string       `json:"last_modified,omitempty"`
+	Formulae     []indexEntry `json:"formulae"`
+
+	// Built on the first search: lower-cased names, and for each trigram
+	// of them the positions of the names containing it, in order.
+	build    sync.Once
+	lower    []string
+	trigrams map[string][]int32
+}
+
+// indexEntry is the part of a formula search needs.
+type indexEntry struct {
+	Name    string `json:"name"`
+	Desc    string `json:"desc,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Arch is the architecture the formula requires, if any.
+	Arch string `json:"arch,omitempty"`
+}
+
+// SetIndexDir keeps a trimmed copy of the formula list in dir. Searches
+// then send a conditional request and reuse the copy while the API reports
+// it unchanged, instead of downloading the full list every time. An empty
+// dir disables the index.
+func (b *Backend) SetIndexDir(dir string) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	b.indexDir = dir
+	b.index = nil
+}
+
+// SetSearchIndex keeps the formula list in memory, indexed by trigrams of
+// the formula names, and answers searches from it without contacting the
+// API until it is maxAge old. It is then revalidated with a conditional
+// request. This suits callers that search on every keystroke. A maxAge of
+// zero or less disables it.
+func (b *Backend) SetSearchIndex(maxAge time.Duration) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	b.indexMaxAge = maxAge
+}
+
+// keepsIndex reports whether searches should build and keep the index.
+func (b *Backend) keepsIndex() bool {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	return b.indexDir != "" || b.indexMaxAge > 0
+}
+
+// freshIndex returns the in-memory index if the search index is enabled and
+// the index was fetched or revalidated less than its maximum age ago.
+func (b *Backend) freshIndex() *formulaIndex {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	if b.indexMaxAge <= 0 || b.index == nil || time.Since(b.indexChecked) >= b.indexMaxAge {
+		return nil
+	}
+	return b.index
+}
+
+// revalidatedIndex records that the API reported the index unchanged.
+func (b *Backend) revalidatedIndex() {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	b.indexChecked = time.Now()
+}
+
+// cachedIndex returns the index from memory or, on first use, from disk. It
+// returns nil if no index is kept or none could be read.
+func (b *Backend) cachedIndex() *formulaIndex {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	if b.index != nil {
+		return b.index
+	}
+	if b.indexDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(b.indexDir, indexFile))
+	if err != nil {
+		return nil
+	}
+	var idx formulaIndex
+	if err := json.Unmarshal(data, &idx); err != nil || idx.ETag == "" && idx.LastModified == "" {
+		return nil
+	}
+	b.index = &idx
+	return b.index
+}
+
+// storeIndex replaces the index in memory and on disk. The index is a
+// cache, so failing to write it is not an error; the next search simply
+// downloads the list again.
+func (b *Backend) storeIndex(idx *formulaIndex) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	if b.indexDir == "" && b.indexMaxAge <= 0 {
+		return
+	}
+	b.index = idx
+	b.indexChecked = time.Now()
+	if b.indexDir == "" {
+		return
+	}
+	if idx.ETag == "" && idx.LastModified == "" {
+		return // cannot be revalidated
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(b.indexDir, 0o755); err != nil {
+		return
+	}
+	// Write to a temporary file and rename it, so concurrent processes
+	// never read a partial index.
+	tmp, err := os.CreateTemp(b.indexDir, indexFile+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(b.indexDir, indexFile))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// search returns the formulae whose name contains query, case-insensitively.
+// Only names containing every trigram of the query are compared, so a
+// typical query checks a handful of names rather than the whole list.
+func (idx *formulaIndex) search(query string) []types.PackageRef {
+	idx.build.Do(idx.buildTrigrams)
+	var results []types.PackageRef
+	queryLower := strings.ToLower(query)
+	add := func(i int) {
+		if strings.Contains(idx.lower[i], queryLower) {
+			results = append(results, types.PackageRef{
+				Name: idx.Formulae[i].Name,
+				Kind: "formula",
+			})
+		}
+	}
+
+	if len(queryLower) < 3 {
+		for i := range idx.Formulae {
+			add(i)
+		}
+		return results
+	}
+	// Every match contains all of the query's trigrams, so the shortest
+	// posting list holds all the candidates.
+	var candidates []int32
+	for j := 0; j+3 <= len(queryLower); j++ {
+		list, ok := idx.trigrams[queryLower[j:j+3]]
+		if !ok {
+			return nil
+		}
+		if candidates == nil || len(list) < len(candidates) {
+			candidates = list
+		}
+	}
+	for _, i := range candidates {
+		add(int(i))
+	}
+	return results
+}
+
+// searchWith is search, followed by the formulae that match query only
+// within a few typos of their name, with opts.Fuzzy set, or only by their
+// description, with opts.IncludeDescriptions set. The trigrams cannot
+// narrow either, so both compare every formula. Formulae whose required
+// architecture supports rejects are left out.
+func (idx *formulaIndex) searchWith(query string, opts types.SearchOptions, supports func(arch string) bool) []types.PackageRef {
+	results := idx.search(query)
+	if unsupported := idx.unsupported(supports); len(unsupported) > 0 {
+		results = slices.DeleteFunc(results, func(ref types.PackageRef) bool { return unsupported[ref.Name] })
+	}
+	if !opts.Fuzzy && !opts.IncludeDescriptions {
+		return results
+	}
+	queryLower := strings.ToLower(query)
+	for i, name := range idx.lower {
+		if strings.Contains(name, queryLower) {
+			continue
+		}
+		if !supports(idx.Formulae[i].Arch) {
+			continue
+		}
+		if opts.Fuzzy && rank.Fuzzy(name, queryLower) || opts.IncludeDescriptions && matchesDesc(idx.Formulae[i].Desc, queryLower) {
+			results = append(results, types.PackageRef{Name: idx.Formulae[i].Name, Kind: "formula"})
+		}
+	}
+	return results
+}
+
+// unsupported returns the names of the formulae whose required
+// architecture supports rejects. Few formulae have one.
+func (idx *formulaIndex) unsupported(supports func(arch string) bool) map[string]bool {
+	var names map[string]bool
+	for _, f := range idx.Formulae {
+		if f.Arch != "" && !supports(f.Arch) {
+			if names == nil {
+				names = make(map[string]bool)
+			}
+			names[f.Name] = true
+		}
+	}
+	return names
+}
+
+// buildTrigrams fills in idx.lower and idx.trigrams.
+func (idx *formulaIndex) buildTrigrams() {
+	idx.lower = make([]string, len(idx.Formulae))
+	idx.trigrams = make(map[string][]int32)
+	for i, f := range idx.Formulae {
+		name := strings.ToLower(f.Name)
+		idx.lower[i] = name
+		for j := 0; j+3 <= len(name); j++ {
+			t := name[j : j+3]
+			list := idx.trigrams[t]
+			if n := len(list); n == 0 || list[n-1] != int32(i) {
+				idx.trigrams[t] = append(list, int32(i))
+			}
+		}
+	}
+}
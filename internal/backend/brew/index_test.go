@@ -0,0 +1,332 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestSearch_Index(t *testing.T) {
+	const etag = `"v1"`
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", etag)
+		_, _ = io.WriteString(w, `[{"name":"git","desc":"Version control","versions":{"stable":"2.44.0"}},{"name":"wget","versions":{"stable":"1.24.5"}}]`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	search := func(b *Backend, query string) []types.PackageRef {
+		t.Helper()
+		results, err := b.Search(context.Background(), query, types.SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		return results
+	}
+
+	first := New(server.Client(), nil, nil)
+	first.SetBaseURL(server.URL)
+	first.SetIndexDir(dir)
+	if results := search(first, "git"); len(results) != 1 || results[0].Name != "git" {
+		t.Fatalf("Search(git) = %v, want [git]", results)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		t.Fatalf("Index not written: %v", err)
+	}
+	want := `{"etag":"\"v1\"","formulae":[{"name":"git","desc":"Version control","version":"2.44.0"},{"name":"wget","version":"1.24.5"}]}`
+	if string(data) != want {
+		t.Errorf("Index = %s\nwant %s", data, want)
+	}
+
+	// A new backend, as in a new process, revalidates the stored index
+	// instead of downloading the list again.
+	second := New(server.Client(), nil, nil)
+	second.SetBaseURL(server.URL)
+	second.SetIndexDir(dir)
+	if results := search(second, "WGET"); len(results) != 1 || results[0].Name != "wget" {
+		t.Fatalf("Search(WGET) = %v, want [wget]", results)
+	}
+	if got := full.Load(); got != 1 {
+		t.Errorf("Full downloads = %d, want 1", got)
+	}
+	if got := notModified.Load(); got != 1 {
+		t.Errorf("Not Modified responses = %d, want 1", got)
+	}
+}
+
+func TestSearch_IndexChanged(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version.Load() == 0 {
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+			_, _ = io.WriteString(w, `[{"name":"git"}]`)
+			return
+		}
+		if r.Header.Get("If-Modified-Since") != "Mon, 01 Jan 2024 00:00:00 GMT" {
+			t.Errorf("If-Modified-Since = %q", r.Header.Get("If-Modified-Since"))
+		}
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 00:00:00 GMT")
+		_, _ = io.WriteString(w, `[{"name":"git"},{"name":"git-lfs"}]`)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	b.SetIndexDir(t.TempDir())
+	ctx := context.Background()
+
+	if results, err := b.Search(ctx, "git", types.SearchOptions{}); err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %v, %v; want 1 result", results, err)
+	}
+	version.Store(1)
+	if results, err := b.Search(ctx, "git", types.SearchOptions{}); err != nil || len(results) != 2 {
+		t.Fatalf("Search() after change = %v, %v; want 2 results", results, err)
+	}
+	if got := b.cachedIndex().LastModified; got != "Tue, 02 Jan 2024 00:00:00 GMT" {
+		t.Errorf("Index Last-Modified = %q, want the new value", got)
+	}
+}
+
+func TestSearch_IndexUnwritable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `[{"name":"git"}]`)
+	}))
+	defer server.Close()
+
+	// A regular file where the directory should be
+	dir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	b.SetIndexDir(dir)
+	if results, err := b.Search(context.Background(), "git", types.SearchOptions{}); err != nil || len(results) != 1 {
+		t.Errorf("Search() = %v, %v; want 1 result and no error", results, err)
+	}
+}
+
+func TestFormulaIndex_SearchMatchesScan(t *testing.T) {
+	idx := &formulaIndex{}
+	for _, name := range []string{"git", "git-lfs", "lazygit", "GitHub-CLI", "python@3.12", "gtk+3", "go", "golang-migrate", "tig", "digit"} {
+		idx.Formulae = append(idx.Formulae, indexEntry{Name: name})
+	}
+
+	for _, query := range []string{"git", "GIT", "g", "it", "", "python@3", "k+3", "zzz", "gitt", "lang-m", "digit"} {
+		var want []string
+		for _, f := range idx.Formulae {
+			if matches(f.Name, strings.ToLower(query), false) {
+				want = append(want, f.Name)
+			}
+		}
+		var got []string
+		for _, r := range idx.search(query) {
+			got = append(got, r.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("search(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestSearch_SearchIndex(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `[{"name":"git"},{"name":"git-lfs"},{"name":"wget"}]`)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	b.SetSearchIndex(time.Minute)
+	ctx := context.Background()
+
+	for _, query := range []string{"g", "gi", "git", "git-", "wget"} {
+		if _, err := b.Search(ctx, query, types.SearchOptions{}); err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Requests = %d, want 1 while the index is fresh", got)
+	}
+
+	// Once the index is older than its maximum age, it is revalidated.
+	b.indexMu.Lock()
+	b.indexChecked = time.Now().Add(-time.Hour)
+	b.indexMu.Unlock()
+	results, err := b.Search(ctx, "git", types.SearchOptions{})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Search(git) = %v, %v; want 2 results", results, err)
+	}
+	if got := notModified.Load(); got != 1 {
+		t.Errorf("Not Modified responses = %d, want 1", got)
+	}
+	if _, err := b.Search(ctx, "wget", types.SearchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Requests = %d, want 2 after revalidation", got)
+	}
+}
+
+func TestSearch_Fuzzy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"htop"},{"name":"btop"},{"name":"wget"}]`)
+	}))
+	defer server.Close()
+
+	for _, indexed := range []bool{false, true} {
+		b := New(server.Client(), nil, nil)
+		b.SetBaseURL(server.URL)
+		if indexed {
+			b.SetSearchIndex(time.Minute)
+			if _, err := b.Search(context.Background(), "warm up", types.SearchOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, fuzzy := range []bool{false, true} {
+			results, err := b.Search(context.Background(), "hotp", types.SearchOptions{Fuzzy: fuzzy})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var names []string
+			for _, r := range results {
+				names = append(names, r.Name)
+			}
+			want := "[]"
+			if fuzzy {
+				want = "[htop]"
+			}
+			if fmt.Sprint(names) != want {
+				t.Errorf("Search(hotp, indexed %v, fuzzy %v) = %v, want %v", indexed, fuzzy, names, want)
+			}
+		}
+	}
+}
+
+func TestSearch_IncludeDescriptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"jq","desc":"Lightweight and flexible command-line JSON processor"},{"name":"json-c","desc":"JSON parser for C"}]`)
+	}))
+	defer server.Close()
+
+	for _, indexed := range []bool{false, true} {
+		b := New(server.Client(), nil, nil)
+		b.SetBaseURL(server.URL)
+		if indexed {
+			b.SetSearchIndex(time.Minute)
+			if _, err := b.Search(context.Background(), "warm up", types.SearchOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, descriptions := range []bool{false, true} {
+			results, err := b.Search(context.Background(), "JSON", types.SearchOptions{IncludeDescriptions: descriptions})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var names []string
+			for _, r := range results {
+				names = append(names, r.Name)
+			}
+			slices.Sort(names)
+			want := "[json-c]"
+			if descriptions {
+				want = "[jq json-c]"
+			}
+			if fmt.Sprint(names) != want {
+				t.Errorf("Search(JSON, indexed %v, IncludeDescriptions %v) = %v, want %v", indexed, descriptions, names, want)
+			}
+		}
+	}
+}
+
+func BenchmarkFormulaIndex_Search(b *testing.B) {
+	idx := &formulaIndex{}
+	for i := 0; i < 7000; i++ {
+		idx.Formulae = append(idx.Formulae, indexEntry{Name: fmt.Sprintf("formula-%d-%c%c", i, 'a'+i%26, 'a'+i/26%26)})
+	}
+	idx.search("warm up")
+
+	b.Run("Trigram", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			idx.search("123-")
+		}
+	})
+	b.Run("Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var n int
+			for _, f := range idx.Formulae {
+				if matches(f.Name, "123-", false) {
+					n++
+				}
+			}
+		}
+	})
+}
+
+func TestSearch_Arch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"wine-stable","requirements":[{"name":"arch","version":"x86_64"}]},{"name":"wineskin","requirements":[{"name":"macos","version":"catalina"}]}]`)
+	}))
+	defer server.Close()
+
+	for _, indexed := range []bool{false, true} {
+		fake := &runner.FakeRunner{}
+		b := New(server.Client(), fake, nil)
+		b.SetBaseURL(server.URL)
+		b.SetArch("arm64")
+		if indexed {
+			b.SetSearchIndex(time.Minute)
+			if _, err := b.Search(context.Background(), "warm up", types.SearchOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		results, err := b.Search(context.Background(), "wine", types.SearchOptions{})
+		if err != nil || len(results) != 1 || results[0].Name != "wineskin" {
+			t.Errorf("Search(wine, indexed %v) = %v, %v; want only wineskin", indexed, results, err)
+		}
+		if !indexed {
+			continue
+		}
+
+		_, err = b.Install(context.Background(), []types.PackageRef{{Name: "wine-stable"}}, types.InstallOptions{})
+		var archErr *types.UnsupportedArchError
+		if !errors.As(err, &archErr) || archErr.Arch != "arm64" || !slices.Equal(archErr.Available, []string{"x86_64"}) {
+			t.Errorf("Install() error = %v, want an UnsupportedArchError", err)
+		}
+		if fake.LastCommand != "" {
+			t.Errorf("Install() ran %s, want nothing run", fake.LastCommand)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package brew
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// LaunchCommand implements Launcher. `brew info --json=v2` tells whether
+// pkg is an installed formula or cask. A cask's app bundle is opened with
+// `open -a`, on macOS only; a formula's executable, the one named after it
+// or else the first one `brew list --formula` shows in its bin directory,
+// is run directly. Formulae without executables, such as libraries, and
+// casks without an app cannot be launched.
+func (b *Backend) LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error) {
+	if b.runner == nil {
+		return types.LaunchCommand{}, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationLaunch, []types.PackageRef{pkg}); err != nil {
+		return types.LaunchCommand{}, err
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationLaunch, b.progress, opts.Progress)
+	helper.BeginAction("Launch")
+	defer helper.EndAction()
+
+	cmd, err := b.launchCommand(ctx, helper, pkg, opts.Args)
+	if err != nil {
+		helper.Error("Launch failed: " + err.Error())
+		return types.LaunchCommand{}, err
+	}
+	helper.Info("Launching " + pkg.Name)
+	return cmd, nil
+}
+
+// launchCommand returns the command that starts pkg with appArgs.
+func (b *Backend) launchCommand(ctx context.Context, helper *types.ProgressHelper, pkg types.PackageRef, appArgs []string) (types.LaunchCommand, error) {
+	info, err := b.info(ctx, helper, types.OperationLaunch, pkg)
+	if err != nil {
+		return types.LaunchCommand{}, err
+	}
+
+	notInstalled := &types.NotInstalledError{Operation: types.OperationLaunch, Ref: pkg, Backend: "brew"}
+	switch {
+	case len(info.Formulae) > 0:
+		if len(info.Formulae[0].Installed) == 0 {
+			return types.LaunchCommand{}, notInstalled
+		}
+		executable, err := b.executable(ctx, helper, pkg)
+		if err != nil {
+			return types.LaunchCommand{}, err
+		}
+		return types.LaunchCommand{Name: executable, Args: appArgs}, nil
+	case len(info.Casks) > 0:
+		c := info.Casks[0]
+		if c.Installed == nil {
+			return types.LaunchCommand{}, notInstalled
+		}
+		app := caskApp(c.Artifacts)
+		switch {
+		case app == "":
+			return types.LaunchCommand{}, notLaunchable(pkg.Name + " installs no application")
+		case runtime.GOOS != "darwin":
+			return types.LaunchCommand{}, notLaunchable("cask applications can be opened only on macOS")
+		}
+		args := []string{"-a", app}
+		if len(appArgs) > 0 {
+			args = append(append(args, "--args"), appArgs...)
+		}
+		return types.LaunchCommand{Name: "open", Args: args}, nil
+	}
+	return types.LaunchCommand{}, &types.PackageNotFoundError{Ref: pkg, Backend: "brew"}
+}
+
+// info runs `brew info --json=v2` for pkg, restricted to formulae or casks
+// if pkg's Kind says which.
+func (b *Backend) info(ctx context.Context, helper *types.ProgressHelper, op types.Operation, pkg types.PackageRef) (*infoV2, error) {
+	args := []string{"info", "--json=v2"}
+	if pkg.Kind != "" {
+		args = append(args, "--"+pkg.Kind)
+	}
+	helper.BeginTask("Running brew info " + pkg.Name)
+	stdout, stderr, err := runner.RunWithExternalError(
+		b.withEnv(ctx),
+		b.runner,
+		op,
+		"brew",
+		"brew",
+		append(args, pkg.Name)...,
+	)
+	helper.EndTask()
+	if err != nil {
+		return nil, packageNotFound(err, stdout, stderr, []types.PackageRef{pkg})
+	}
+	var info infoV2
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		return nil, &types.ExternalFailureError{
+			Operation: op,
+			Backend:   "brew",
+			Stdout:    stdout,
+			Err:       fmt.Errorf("failed to parse brew info: %w", err),
+		}
+	}
+	return &info, nil
+}
+
+// executable returns the path of the installed formula pkg's executable.
+func (b *Backend) executable(ctx context.Context, helper *types.ProgressHelper, pkg types.PackageRef) (string, error) {
+	helper.BeginTask("Running brew list " + pkg.Name)
+	stdout, _, err := runner.RunWithExternalError(
+		b.withEnv(ctx),
+		b.runner,
+		types.OperationLaunch,
+		"brew",
+		"brew",
+		"list", "--formula", pkg.Name,
+	)
+	helper.EndTask()
+	if err != nil {
+		return "", err
+	}
+	executable := ""
+	for _, line := range strings.Split(stdout, "\n") {
+		file := strings.TrimSpace(line)
+		if path.Base(path.Dir(file)) != "bin" {
+			continue
+		}
+		if path.Base(file) == pkg.Name {
+			executable = file
+			break
+		}
+		if executable == "" {
+			executable = file
+		}
+	}
+	if executable == "" {
+		return "", notLaunchable(pkg.Name + " installs no executables")
+	}
+	return executable, nil
+}
+
+// caskApp returns the name of the app bundle among a cask's artifacts,
+// such as "Firefox.app", or "" if it installs none. An app entry is the
+// bundle's name, optionally followed by {"target": ...} if it is installed
+// under another name.
+func caskApp(artifacts []map[string]json.RawMessage) string {
+	for _, artifact := range artifacts {
+		raw, ok := artifact["app"]
+		if !ok {
+			continue
+		}
+		var entries []any
+		if err := json.Unmarshal(raw, &entries); err != nil || len(entries) == 0 {
+			continue
+		}
+		app, _ := entries[0].(string)
+		for _, entry := range entries[1:] {
+			if opts, ok := entry.(map[string]any); ok {
+				if target, ok := opts["target"].(string); ok {
+					app = path.Base(target)
+				}
+			}
+		}
+		if app != "" {
+			return app
+		}
+	}
+	return ""
+}
+
+// notLaunchable reports that a package cannot be launched, for reason.
+func notLaunchable(reason string) error {
+	return &types.NotSupportedError{Operation: types.OperationLaunch, Backend: "brew", Reason: reason}
+}
@@ -0,0 +1,88 @@
+package brew
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_LaunchCommand(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "brew", Args: `^info --json=v2 jq$`, Stdout: `{"formulae":[{"name":"jq","installed":[{"version":"1.7.1"}]}],"casks":[]}`},
+		{Name: "brew", Args: `^list --formula jq$`, Stdout: "/opt/homebrew/Cellar/jq/1.7.1/bin/jq\n/opt/homebrew/Cellar/jq/1.7.1/lib/libjq.1.dylib\n"},
+		{Name: "brew", Args: `^info --json=v2 openssl@3$`, Stdout: `{"formulae":[{"name":"openssl@3","installed":[{"version":"3.3.0"}]}],"casks":[]}`},
+		{Name: "brew", Args: `^list --formula openssl@3$`, Stdout: "/opt/homebrew/Cellar/openssl@3/3.3.0/bin/c_rehash\n/opt/homebrew/Cellar/openssl@3/3.3.0/bin/openssl\n"},
+		{Name: "brew", Args: `^info --json=v2 libyaml$`, Stdout: `{"formulae":[{"name":"libyaml","installed":[{"version":"0.2.5"}]}],"casks":[]}`},
+		{Name: "brew", Args: `^list --formula libyaml$`, Stdout: "/opt/homebrew/Cellar/libyaml/0.2.5/lib/libyaml.dylib\n"},
+		{Name: "brew", Args: `^info --json=v2 wget$`, Stdout: `{"formulae":[{"name":"wget","installed":[]}],"casks":[]}`},
+	}}
+	b := New(nil, fake, nil)
+
+	cmd, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "jq"}, types.LaunchOptions{Args: []string{"--version"}})
+	if err != nil {
+		t.Fatalf("LaunchCommand(jq) error = %v", err)
+	}
+	if got, want := cmd.Name+" "+strings.Join(cmd.Args, " "), "/opt/homebrew/Cellar/jq/1.7.1/bin/jq --version"; got != want {
+		t.Errorf("LaunchCommand(jq) = %q, want %q", got, want)
+	}
+	// The executable named after the formula, ignoring the @version suffix,
+	// is not there, so the first one is used.
+	if cmd, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "openssl@3"}, types.LaunchOptions{}); err != nil || !strings.HasSuffix(cmd.Name, "/bin/c_rehash") {
+		t.Errorf("LaunchCommand(openssl@3) = %+v, %v, want its first executable", cmd, err)
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "libyaml"}, types.LaunchOptions{}); !types.IsNotSupported(err) {
+		t.Errorf("LaunchCommand(libyaml) error = %v, want NotSupportedError", err)
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "wget"}, types.LaunchOptions{}); !types.IsNotInstalled(err) {
+		t.Errorf("LaunchCommand(wget) error = %v, want NotInstalledError", err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_LaunchCommandCask(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "brew", Args: `^info --json=v2 --cask visual-studio-code$`, Stdout: `{"formulae":[],"casks":[{"token":"visual-studio-code","installed":"1.88.1","artifacts":[{"app":["Visual Studio Code.app"]},{"binary":["{{appdir}}/Visual Studio Code.app/Contents/Resources/app/bin/code"]}]}]}`},
+	}}
+	b := New(nil, fake, nil)
+
+	cmd, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "visual-studio-code", Kind: "cask"}, types.LaunchOptions{Args: []string{"."}})
+	if runtime.GOOS != "darwin" {
+		if !types.IsNotSupported(err) {
+			t.Errorf("LaunchCommand(cask) error = %v, want NotSupportedError off macOS", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("LaunchCommand(cask) error = %v", err)
+	}
+	if got, want := cmd.Name+" "+strings.Join(cmd.Args, " "), "open -a Visual Studio Code.app --args ."; got != want {
+		t.Errorf("LaunchCommand(cask) = %q, want %q", got, want)
+	}
+}
+
+func TestCaskApp(t *testing.T) {
+	tests := []struct {
+		artifacts string
+		want      string
+	}{
+		{`[{"app":["Firefox.app"]}]`, "Firefox.app"},
+		{`[{"uninstall":[{"quit":"org.x"}]},{"app":["Foo Beta.app",{"target":"Foo.app"}]}]`, "Foo.app"},
+		{`[{"binary":["bin/tool"]}]`, ""},
+	}
+	for _, tt := range tests {
+		var info infoV2
+		if err := json.Unmarshal([]byte(`{"casks":[{"artifacts":`+tt.artifacts+`}]}`), &info); err != nil {
+			t.Fatal(err)
+		}
+		if got := caskApp(info.Casks[0].Artifacts); got != tt.want {
+			t.Errorf("caskApp(%s) = %q, want %q", tt.artifacts, got, tt.want)
+		}
+	}
+}
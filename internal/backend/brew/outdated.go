@@ -0,0 +1,91 @@
+package brew
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// outdatedV2 is the output of `brew outdated --json=v2`.
+type outdatedV2 struct {
+	Formulae []outdatedEntry `json:"formulae"`
+	Casks    []outdatedEntry `json:"casks"`
+}
+
+type outdatedEntry struct {
+	Name              string   `json:"name"`
+	InstalledVersions []string `json:"installed_versions"`
+	CurrentVersion    string   `json:"current_version"`
+}
+
+// ListOutdated implements OutdatedLister using `brew outdated --json=v2`,
+// which compares the installed formulae and casks with the local copy of
+// the index, as of the last `brew update`.
+func (b *Backend) ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationListOutdated, b.progress, opts.Progress)
+	helper.BeginAction("ListOutdated")
+	defer helper.EndAction()
+
+	helper.BeginTask("Running brew outdated")
+	stdout, _, err := runner.RunWithExternalError(
+		b.withEnv(ctx),
+		b.runner,
+		types.OperationListOutdated,
+		"brew",
+		"brew",
+		"outdated", "--json=v2",
+	)
+	helper.EndTask()
+
+	// Some brew versions exit 1 when anything is outdated, still printing
+	// the list.
+	var extErr *types.ExternalFailureError
+	if err != nil && !(errors.As(err, &extErr) && extErr.ExitCode == 1 && stdout != "") {
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+
+	var out outdatedV2
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		err = &types.ExternalFailureError{
+			Operation: types.OperationListOutdated,
+			Backend:   "brew",
+			Stdout:    stdout,
+			Err:       fmt.Errorf("failed to parse brew outdated: %w", err),
+		}
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+
+	outdated := []types.OutdatedPackage{}
+	for _, e := range out.Formulae {
+		outdated = append(outdated, e.outdated("formula"))
+	}
+	for _, e := range out.Casks {
+		outdated = append(outdated, e.outdated("cask"))
+	}
+
+	helper.Info("ListOutdated completed")
+	return outdated, nil
+}
+
+// outdated converts e, reporting the newest installed version when several
+// are kept.
+func (e outdatedEntry) outdated(kind string) types.OutdatedPackage {
+	pkg := types.OutdatedPackage{
+		Ref:              types.PackageRef{Name: e.Name, Kind: kind},
+		AvailableVersion: e.CurrentVersion,
+	}
+	if n := len(e.InstalledVersions); n > 0 {
+		pkg.InstalledVersion = e.InstalledVersions[n-1]
+	}
+	return pkg
+}
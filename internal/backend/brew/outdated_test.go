@@ -0,0 +1,78 @@
+package brew
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_ListOutdated(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+		Name: "brew",
+		Args: `^outdated --json=v2$`,
+		Stdout: `{"formulae":[{"name":"jq","installed_versions":["1.6","1.7"],"current_version":"1.7.1","pinned":false,"pinned_version":null}],` +
+			`"casks":[{"name":"firefox","installed_versions":["124.0"],"current_version":"125.0.1"}]}`,
+	}}}
+	b := New(nil, fake, nil)
+
+	got, err := b.ListOutdated(context.Background(), types.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	want := []types.OutdatedPackage{
+		{Ref: types.PackageRef{Name: "jq", Kind: "formula"}, InstalledVersion: "1.7", AvailableVersion: "1.7.1"},
+		{Ref: types.PackageRef{Name: "firefox", Kind: "cask"}, InstalledVersion: "124.0", AvailableVersion: "125.0.1"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ListOutdated() = %+v, want %+v", got, want)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_ListOutdatedErrors(t *testing.T) {
+	t.Run("Failure", func(t *testing.T) {
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+			Name:   "brew",
+			Stderr: "Error: Permission denied",
+			Err:    &runner.ReplayedError{Message: "exit status 1", Code: 1},
+		}}}
+		if _, err := New(nil, fake, nil).ListOutdated(context.Background(), types.OutdatedOptions{}); err == nil {
+			t.Error("Expected an error when brew outdated fails without output")
+		}
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{{Name: "brew", Stdout: "jq (1.6) < 1.7.1\n"}}}
+		if _, err := New(nil, fake, nil).ListOutdated(context.Background(), types.OutdatedOptions{}); !types.IsExternalFailure(err) {
+			t.Errorf("ListOutdated() error = %v, want an ExternalFailureError", err)
+		}
+	})
+}
+
+func TestBackend_UpgradePackages(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+		Name:   "brew",
+		Args:   `^upgrade jq$`,
+		Stdout: "==> Upgrading jq\n  1.7 -> 1.7.1\n",
+	}}}
+	b := New(nil, fake, nil)
+
+	res, err := b.Upgrade(context.Background(), types.UpgradeOptions{Packages: []types.PackageRef{{Name: "jq"}}})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(res.PackagesChanged) != 1 || res.PackagesChanged[0].Name != "jq" {
+		t.Errorf("Upgrade() = %+v, want jq upgraded", res)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := b.Upgrade(context.Background(), types.UpgradeOptions{Packages: []types.PackageRef{{Name: "--force"}}}); !types.IsValidation(err) {
+		t.Errorf("Upgrade(--force) error = %v, want a ValidationError", err)
+	}
+}
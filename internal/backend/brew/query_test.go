@@ -0,0 +1,90 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_Query(t *testing.T) {
+	// brew exits 1 because wget is not installed, but still lists the rest.
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+		Name:   "brew",
+		Args:   `^list --versions git wget firefox$`,
+		Stdout: "git 2.44.0\nfirefox 124.0.1\n",
+		Err:    &runner.ReplayedError{Message: "exit status 1", Code: 1},
+	}}}
+	b := New(nil, fake, nil)
+
+	pkgs := []types.PackageRef{{Name: "git"}, {Name: "wget"}, {Name: "firefox", Kind: "cask"}}
+	got, err := b.Query(context.Background(), pkgs, types.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git", Kind: "formula"}, Version: "2.44.0"},
+		{Ref: types.PackageRef{Name: "firefox", Kind: "cask"}, Version: "124.0.1"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Query() = %+v, want %+v", got, want)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_QueryErrors(t *testing.T) {
+	t.Run("Other exit codes fail", func(t *testing.T) {
+		fake := &runner.FakeRunner{ErrResponse: &runner.ReplayedError{Message: "exit status 2", Code: 2}}
+		if _, err := New(nil, fake, nil).Query(context.Background(), []types.PackageRef{{Name: "git"}}, types.QueryOptions{}); err == nil {
+			t.Error("Expected an error for exit status 2")
+		}
+	})
+
+	t.Run("Invalid names are rejected before running brew", func(t *testing.T) {
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{}}
+		_, err := New(nil, fake, nil).Query(context.Background(), []types.PackageRef{{Name: "--force"}}, types.QueryOptions{})
+		if !types.IsValidation(err) {
+			t.Errorf("Query() error = %v, want ValidationError", err)
+		}
+	})
+
+	t.Run("No runner", func(t *testing.T) {
+		_, err := New(nil, nil, nil).Query(context.Background(), []types.PackageRef{{Name: "git"}}, types.QueryOptions{})
+		if !errors.Is(err, types.ErrNotSupported) {
+			t.Errorf("Query() error = %v, want ErrNotSupported", err)
+		}
+	})
+}
+
+func TestBackend_InstallSkipsQueried(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "brew", Args: `^list --versions git wget$`, Stdout: "git 2.44.0\n", Err: &runner.ReplayedError{Message: "exit status 1", Code: 1}},
+		{Name: "brew", Args: `^install wget$`, Stdout: "==> Installing wget\n"},
+		{Name: "brew", Args: `^list --versions jq$`, Err: &runner.ReplayedError{Message: "exit status 1", Code: 1}},
+	}}
+	b := New(nil, fake, nil)
+
+	res, err := b.Install(context.Background(), []types.PackageRef{{Name: "git"}, {Name: "wget"}}, types.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(res.Skipped) != 1 || !types.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want git", res.Skipped)
+	}
+	if len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "wget" {
+		t.Errorf("PackagesInstalled = %v, want [wget]", res.PackagesInstalled)
+	}
+
+	// Nothing to remove, so brew uninstall does not run
+	ures, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "jq"}}, types.UninstallOptions{})
+	if err != nil || ures.Changed || len(ures.Skipped) != 1 || !types.IsNotInstalled(ures.Skipped[0]) {
+		t.Errorf("Uninstall() = %+v, %v, want jq skipped", ures, err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
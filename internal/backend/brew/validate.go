@@ -0,0 +1,47 @@
+package brew
+
+import (
+	"regexp"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+var (
+	// namePattern matches formula and cask names such as python@3.12 or
+	// gtk+3.
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@+._-]*$`)
+
+	// tapPattern matches tap names of the form user/repo.
+	tapPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+/[A-Za-z0-9_.-]+$`)
+)
+
+// validate checks pkgs before any command is run, so mistakes are reported
+// as a ValidationError rather than as a confusing brew failure.
+func validate(op types.Operation, pkgs []types.PackageRef) error {
+	for _, pkg := range pkgs {
+		if !namePattern.MatchString(pkg.Name) {
+			return invalid(op, pkg, "Name", pkg.Name, "must be a formula or cask name")
+		}
+		if pkg.Namespace != "" && !tapPattern.MatchString(pkg.Namespace) {
+			return invalid(op, pkg, "Namespace", pkg.Namespace, "must be a tap of the form user/repo")
+		}
+		if pkg.Kind != "" && pkg.Kind != "formula" && pkg.Kind != "cask" {
+			return invalid(op, pkg, "Kind", pkg.Kind, "must be formula or cask")
+		}
+		if pkg.Channel != "" {
+			return invalid(op, pkg, "Channel", pkg.Channel, "brew has no channels")
+		}
+	}
+	return nil
+}
+
+func invalid(op types.Operation, pkg types.PackageRef, field, value, reason string) error {
+	return &types.ValidationError{
+		Operation: op,
+		Backend:   "brew",
+		Ref:       pkg,
+		Field:     field,
+		Value:     value,
+		Reason:    reason,
+	}
+}
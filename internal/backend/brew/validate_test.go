@@ -0,0 +1,50 @@
+package brew
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		ref       types.PackageRef
+		wantField string
+	}{
+		{types.PackageRef{Name: "jq"}, ""},
+		{types.PackageRef{Name: "python@3.12", Kind: "formula"}, ""},
+		{types.PackageRef{Name: "gtk+3"}, ""},
+		{types.PackageRef{Name: "font-fira-code", Namespace: "homebrew/cask-fonts", Kind: "cask"}, ""},
+		{types.PackageRef{Name: "--force"}, "Name"},
+		{types.PackageRef{Name: "jq wget"}, "Name"},
+		{types.PackageRef{Name: ""}, "Name"},
+		{types.PackageRef{Name: "jq", Namespace: "homebrew"}, "Namespace"},
+		{types.PackageRef{Name: "jq", Kind: "bottle"}, "Kind"},
+		{types.PackageRef{Name: "jq", Channel: "stable"}, "Channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref.Name, func(t *testing.T) {
+			err := validate(types.OperationInstall, []types.PackageRef{tt.ref})
+			var valErr *types.ValidationError
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Unexpected error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &valErr) || valErr.Field != tt.wantField {
+				t.Errorf("Expected a %s validation error, got %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+func TestBackend_InstallValidatesFirst(t *testing.T) {
+	b := New(nil, &failingRunner{stderr: "Error: boom"}, nil)
+	_, err := b.Install(context.Background(), []types.PackageRef{{Name: "jq"}, {Name: "--HEAD"}}, types.InstallOptions{})
+	if !types.IsValidation(err) || types.IsExternalFailure(err) {
+		t.Errorf("Expected a validation error before running brew, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package brew
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// officialTaps are the taps Homebrew itself maintains and builds bottles
+// for.
+var officialTaps = map[string]bool{
+	"homebrew/core": true,
+	"homebrew/cask": true,
+}
+
+// infoV2 is the output of `brew info --json=v2`.
+type infoV2 struct {
+	Formulae []struct {
+		Name     string `json:"name"`
+		Tap      string `json:"tap"`
+		Versions struct {
+			Stable string `json:"stable"`
+		} `json:"versions"`
+		Bottle struct {
+			Stable *struct {
+				Files map[string]struct {
+					SHA256 string `json:"sha256"`
+				} `json:"files"`
+			} `json:"stable"`
+		} `json:"bottle"`
+		Installed []struct {
+			Version          string `json:"version"`
+			PouredFromBottle bool   `json:"poured_from_bottle"`
+		} `json:"installed"`
+	} `json:"formulae"`
+	Casks []struct {
+		Token     string  `json:"token"`
+		Tap       string  `json:"tap"`
+		Version   string  `json:"version"`
+		SHA256    string  `json:"sha256"`
+		Installed *string `json:"installed"`
+		// Artifacts are what the cask installs, one kind per entry, such
+		// as {"app": ["Firefox.app"]}.
+		Artifacts []map[string]json.RawMessage `json:"artifacts"`
+	} `json:"casks"`
+}
+
+// Verify implements Verifier using `brew info --json=v2` for each package.
+// brew checks every bottle and cask download against the SHA-256 its
+// formula or cask records, so a formula poured from a bottle, or one with a
+// bottle for this platform if not installed, is signed; one built from
+// source is not. Casks are signed unless they skip the check (no_check).
+func (b *Backend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	attestations := []types.Attestation{}
+	if len(pkgs) == 0 {
+		return attestations, nil
+	}
+
+	if err := validate(types.OperationVerify, pkgs); err != nil {
+		return nil, err
+	}
+
+	helper := types.NewOperationProgressHelper("brew", types.OperationVerify, b.progress, opts.Progress)
+	helper.BeginAction("Verify")
+	defer helper.EndAction()
+
+	for _, pkg := range pkgs {
+		helper.BeginTask("Running brew info " + pkg.Name)
+		stdout, stderr, err := runner.RunWithExternalError(
+			b.withEnv(ctx),
+			b.runner,
+			types.OperationVerify,
+			"brew",
+			"brew",
+			"info", "--json=v2", pkg.Name,
+		)
+		helper.EndTask()
+
+		if err != nil {
+			if err = packageNotFound(err, stdout, stderr, pkgs); types.IsPackageNotFound(err) {
+				attestations = append(attestations, types.Attestation{Ref: pkg, Status: types.AttestationNotFound})
+				continue
+			}
+			helper.Error("Verify failed: " + err.Error())
+			return nil, err
+		}
+		var info infoV2
+		if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+			err = &types.ExternalFailureError{
+				Operation: types.OperationVerify,
+				Backend:   "brew",
+				Stdout:    stdout,
+				Err:       fmt.Errorf("failed to parse brew info: %w", err),
+			}
+			helper.Error("Verify failed: " + err.Error())
+			return nil, err
+		}
+		attestations = append(attestations, b.attest(pkg, &info))
+	}
+
+	helper.Info("Verify completed")
+	return attestations, nil
+}
+
+// attest builds the attestation for pkg from its `brew info` output.
+func (b *Backend) attest(pkg types.PackageRef, info *infoV2) types.Attestation {
+	at := types.Attestation{Ref: pkg}
+	switch {
+	case len(info.Formulae) > 0:
+		f := info.Formulae[0]
+		at.Ref.Kind = "formula"
+		at.Source = f.Tap
+		at.Version = f.Versions.Stable
+		if f.Bottle.Stable != nil {
+			if file, ok := f.Bottle.Stable.Files[b.bottleTag()]; ok {
+				at.Digest = file.SHA256
+			} else if file, ok := f.Bottle.Stable.Files["all"]; ok {
+				at.Digest = file.SHA256
+			}
+		}
+		if len(f.Installed) > 0 {
+			at.Installed = true
+			at.Version = f.Installed[len(f.Installed)-1].Version
+			at.Signed = f.Installed[len(f.Installed)-1].PouredFromBottle
+		} else {
+			at.Signed = at.Digest != ""
+		}
+		if !at.Signed {
+			at.Findings = append(at.Findings, "built from source, without a bottle checksum")
+		}
+	case len(info.Casks) > 0:
+		c := info.Casks[0]
+		at.Ref.Kind = "cask"
+		at.Source = c.Tap
+		at.Version = c.Version
+		if c.Installed != nil {
+			at.Installed = true
+			at.Version = *c.Installed
+		}
+		at.Signed = c.SHA256 != "" && c.SHA256 != "no_check"
+		if at.Signed {
+			at.Digest = c.SHA256
+		} else {
+			at.Findings = append(at.Findings, "cask downloads are not checksummed")
+		}
+	default:
+		at.Status = types.AttestationNotFound
+		return at
+	}
+	if at.Source != "" && !officialTaps[at.Source] {
+		at.Findings = append(at.Findings, "from third-party tap "+at.Source)
+	}
+	at.Settle()
+	return at
+}
+
+// bottleTag returns the bottle tag of this platform on Linux, such as
+// "x86_64_linux". macOS tags name the OS release, which brew alone knows,
+// so there it returns "" and only bottles for all platforms are matched.
+func (b *Backend) bottleTag() string {
+	if runtime.GOOS != "linux" || b.targetArch() == "" {
+		return ""
+	}
+	return b.targetArch() + "_linux"
+}
@@ -0,0 +1,50 @@
+package brew
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_Verify(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "brew", Args: `^info --json=v2 jq$`, Stdout: `{"formulae":[{"name":"jq","tap":"homebrew/core","versions":{"stable":"1.7.1"},"bottle":{"stable":{"files":{"x86_64_linux":{"sha256":"abc"},"arm64_linux":{"sha256":"def"}}}},"installed":[{"version":"1.7.1","poured_from_bottle":true}]}],"casks":[]}`},
+		{Name: "brew", Args: `^info --json=v2 mytool$`, Stdout: `{"formulae":[{"name":"mytool","tap":"acme/tools","versions":{"stable":"0.3"},"bottle":{},"installed":[{"version":"0.3","poured_from_bottle":false}]}],"casks":[]}`},
+		{Name: "brew", Args: `^info --json=v2 firefox$`, Stdout: `{"formulae":[],"casks":[{"token":"firefox","tap":"homebrew/cask","version":"124.0.1","sha256":"0123","installed":null}]}`},
+		{Name: "brew", Args: `^info --json=v2 nosuch$`, Stderr: `Error: No available formula with the name "nosuch".`, Err: &runner.ReplayedError{Message: "exit status 1", Code: 1}},
+	}}
+	b := New(nil, fake, nil)
+	b.SetArch("arm64")
+
+	pkgs := []types.PackageRef{{Name: "jq"}, {Name: "mytool"}, {Name: "firefox"}, {Name: "nosuch"}}
+	got, err := b.Verify(context.Background(), pkgs, types.VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind      string
+		installed bool
+		status    types.AttestationStatus
+		findings  int
+	}{
+		{"formula", true, types.AttestationVerified, 0},
+		// Built from source, from a third-party tap
+		{"formula", true, types.AttestationUnverified, 2},
+		{"cask", false, types.AttestationVerified, 0},
+		{"", false, types.AttestationNotFound, 0},
+	}
+	for i, tt := range tests {
+		at := got[i]
+		if at.Ref.Kind != tt.kind || at.Installed != tt.installed || at.Status != tt.status || len(at.Findings) != tt.findings {
+			t.Errorf("Verify(%s) = %+v", pkgs[i].Name, at)
+		}
+	}
+	if got[2].Digest != "0123" {
+		t.Errorf("Cask digest = %q, want its sha256", got[2].Digest)
+	}
+}
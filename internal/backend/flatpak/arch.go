@@ -0,0 +1,76 @@
+package flatpak
+
+import (
+	"context"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// archNames maps Go architecture names to flatpak's.
+var archNames = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"386":   "i386",
+	"arm":   "arm",
+}
+
+// SetArch makes searches and installs target arch, in Go's naming such as
+// "arm64", instead of the architecture flatpak runs on. Install then
+// checks that a remote offers each app for it first. An empty arch leaves
+// the choice to flatpak.
+func (b *Backend) SetArch(arch string) {
+	b.arch = arch
+}
+
+// targetArch returns the target architecture in flatpak's naming, or "" if
+// flatpak chooses.
+func (b *Backend) targetArch() string {
+	if name, ok := archNames[b.arch]; ok {
+		return name
+	}
+	return b.arch
+}
+
+// archArgs returns the --arch option for the target architecture, if any.
+func (b *Backend) archArgs() []string {
+	if b.arch == "" {
+		return nil
+	}
+	return []string{"--arch=" + b.targetArch()}
+}
+
+// checkArch returns a *types.UnsupportedArchError for the first of pkgs
+// that no remote offers for the target architecture but one offers for
+// flatpak's own. Apps offered for neither are left for flatpak install to
+// report as not found, and a failed search to flatpak install as well.
+func (b *Backend) checkArch(ctx context.Context, pkgs []types.PackageRef) error {
+	if b.arch == "" {
+		return nil
+	}
+	for _, pkg := range pkgs {
+		if found, ok := b.offered(ctx, pkg.Name, b.archArgs()...); found || !ok {
+			continue
+		}
+		if found, ok := b.offered(ctx, pkg.Name); found && ok {
+			return &types.UnsupportedArchError{Ref: pkg, Backend: "flatpak", Arch: b.targetArch()}
+		}
+	}
+	return nil
+}
+
+// offered reports whether `flatpak search` with args finds the app id; ok
+// is false if the search failed.
+func (b *Backend) offered(ctx context.Context, id string, args ...string) (found, ok bool) {
+	args = append(append([]string{"search"}, args...), "--columns=application", id)
+	stdout, _, err := b.runner.Run(ctx, "flatpak", args...)
+	if err != nil {
+		return false, false
+	}
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(line) == id {
+			return true, true
+		}
+	}
+	return false, true
+}
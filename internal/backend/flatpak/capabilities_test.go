@@ -0,0 +1,75 @@
+package flatpak
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_CapabilitiesProbe(t *testing.T) {
+	probe := func(version, installation string) []types.Capability {
+		t.Helper()
+		fake := &runner.FakeRunner{Script: []runner.FakeCall{{Name: "flatpak", Args: `^--version$`, Stdout: version}}}
+		b := New(fake, nil)
+		b.SetInstallation(installation)
+		caps, err := b.Capabilities(context.Background())
+		if err != nil {
+			t.Fatalf("Capabilities() error = %v", err)
+		}
+		return caps
+	}
+	find := func(caps []types.Capability, op types.Operation) types.Capability {
+		for _, c := range caps {
+			if c.Operation == op {
+				return c
+			}
+		}
+		t.Fatalf("No %s capability in %v", op, caps)
+		return types.Capability{}
+	}
+
+	caps := probe("Flatpak 1.14.4\n", "user")
+	for _, c := range caps {
+		if !c.Supported {
+			t.Errorf("%s unsupported: %s", c.Operation, c.Notes)
+		}
+	}
+	if notes := find(caps, types.OperationInstall).Notes; !strings.Contains(notes, "1.14.4") || !strings.Contains(notes, "user installation") {
+		t.Errorf("Install notes %q should name the version and installation", notes)
+	}
+
+	caps = probe("Flatpak 1.0.9\n", "")
+	if c := find(caps, types.OperationSearch); c.Supported || !strings.Contains(c.Notes, "--columns") {
+		t.Errorf("Search on flatpak 1.0.9 = %+v, want unsupported for lack of --columns", c)
+	}
+	if c := find(caps, types.OperationInstall); !c.Supported || !strings.Contains(c.Notes, "polkit") {
+		t.Errorf("Install on the default installation = %+v, want supported with a polkit note", c)
+	}
+
+	caps = probe("", "")
+	if c := find(caps, types.OperationUpdateMetadata); c.Supported {
+		t.Errorf("Update = %+v, want unsupported when flatpak prints no version", c)
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.2.0", true},
+		{"1.14.4", true},
+		{"2.0", true},
+		{"1.1.99", false},
+		{"0.11.8", false},
+		{"1.2", true},
+	}
+	for _, tt := range tests {
+		if got := atLeast(tt.version, columnsVersion); got != tt.want {
+			t.Errorf("atLeast(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package flatpak
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// notFoundPatterns match flatpak's messages for an unknown ref; the first
+// group is the requested ID. Older versions quote it with ‘’, newer ones
+// not at all.
+var notFoundPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Nothing matches (\S+) in (?:remote|local repository)`),
+	regexp.MustCompile(`No remote refs found (?:similar to|for) ['‘"]?([^'’"\s]+)`),
+	regexp.MustCompile(`Ref ['‘"]?([^'’"\s]+?)['’"]? not found`),
+}
+
+// similarRef matches one entry of flatpak's "Similar refs found" list,
+// e.g. "   1) app/org.gimp.GIMP/x86_64/stable"; the group is the app ID.
+var similarRef = regexp.MustCompile(`(?m)^\s*\d+\)\s+(?:app|runtime)/([^/\s]+)/`)
+
+// packageNotFound returns a *types.PackageNotFoundError wrapping err if
+// flatpak's output says one of pkgs does not exist, and err otherwise.
+func packageNotFound(err error, stdout, stderr string, pkgs []types.PackageRef) error {
+	if err == nil {
+		return nil
+	}
+	output := stderr + "\n" + stdout
+	for _, pattern := range notFoundPatterns {
+		m := pattern.FindStringSubmatch(output)
+		if m == nil {
+			continue
+		}
+		ref := types.RefNamed(pkgs, m[1])
+		var suggestions []string
+		for _, s := range similarRef.FindAllStringSubmatch(output, -1) {
+			if !strings.EqualFold(s[1], ref.Name) {
+				suggestions = append(suggestions, s[1])
+			}
+		}
+		return &types.PackageNotFoundError{
+			Ref:         ref,
+			Backend:     "flatpak",
+			Suggestions: suggestions,
+			Err:         err,
+		}
+	}
+	return err
+}
+
+// alreadyInstalledPatterns match flatpak's messages for refs that are
+// already installed, which are warnings ("Skipping: org.gimp.GIMP/x86_64/
+// stable is already installed") or, in older versions, errors.
+var alreadyInstalledPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:app/|runtime/)?([A-Za-z0-9_.-]+)(?:/\S*)? (?:is )?already installed`),
+}
+
+// notInstalledPatterns match flatpak's messages for removing refs that are
+// not installed.
+var notInstalledPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:app/|runtime/)?([A-Za-z0-9_.-]+)(?:/\S*)? (?:is )?not installed`),
+	regexp.MustCompile(`No installed refs found for ['‘"]?([^'’"\s]+)`),
+}
+
+// alreadyInstalled returns a *types.AlreadyInstalledError wrapping cause
+// for each of pkgs flatpak reports as already installed.
+func alreadyInstalled(output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, alreadyInstalledPatterns...) {
+		errs = append(errs, &types.AlreadyInstalledError{Ref: ref, Backend: "flatpak", Err: cause})
+	}
+	return errs
+}
+
+// notInstalled returns a *types.NotInstalledError wrapping cause for each
+// of pkgs flatpak reports as not installed.
+func notInstalled(op types.Operation, output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, notInstalledPatterns...) {
+		errs = append(errs, &types.NotInstalledError{Operation: op, Ref: ref, Backend: "flatpak", Err: cause})
+	}
+	return errs
+}
@@ -0,0 +1,107 @@
+package flatpak
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestPackageNotFound(t *testing.T) {
+	tests := []struct {
+		name        string
+		stderr      string
+		wantName    string
+		suggestions []string
+	}{
+		{
+			name:     "remote",
+			stderr:   "error: Nothing matches bad.id in remote flathub",
+			wantName: "bad.id",
+		},
+		{
+			name:     "older flatpak",
+			stderr:   "error: No remote refs found similar to ‘bad.id’",
+			wantName: "bad.id",
+		},
+		{
+			name: "similar refs",
+			stderr: `Similar refs found for ‘gimp’ in remote ‘flathub’:
+
+   1) app/org.gimp.GIMP/x86_64/stable
+   2) runtime/org.gimp.GIMP.Manual/x86_64/2.10
+
+error: Nothing matches gimp in remote flathub`,
+			wantName:    "gimp",
+			suggestions: []string{"org.gimp.GIMP", "org.gimp.GIMP.Manual"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := errors.New("exit status 1")
+			err := packageNotFound(cause, "", tt.stderr, []types.PackageRef{{Name: tt.wantName}})
+
+			var pnfErr *types.PackageNotFoundError
+			if !errors.As(err, &pnfErr) {
+				t.Fatalf("Expected *PackageNotFoundError, got %v", err)
+			}
+			if pnfErr.Ref.Name != tt.wantName || pnfErr.Backend != "flatpak" {
+				t.Errorf("Unexpected error: %+v", pnfErr)
+			}
+			if !slices.Equal(pnfErr.Suggestions, tt.suggestions) {
+				t.Errorf("Suggestions = %q, want %q", pnfErr.Suggestions, tt.suggestions)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("Expected the underlying error to be preserved")
+			}
+		})
+	}
+}
+
+func TestBackend_InstallNotFound(t *testing.T) {
+	b := New(&mockRunner{stderr: "error: Nothing matches org.bad.id in remote flathub", err: errors.New("exit status 1")}, nil)
+
+	_, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.bad.id"}}, types.InstallOptions{})
+	if !types.IsPackageNotFound(err) || !types.IsExternalFailure(err) {
+		t.Errorf("Expected a not-found external failure, got %v", err)
+	}
+
+	b = New(&mockRunner{stderr: "error: Unable to connect to system bus", err: errors.New("exit status 1")}, nil)
+	if _, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.bad.id"}}, types.InstallOptions{}); types.IsPackageNotFound(err) {
+		t.Errorf("Expected an unrelated failure, got %v", err)
+	}
+}
+
+func TestBackend_InstallSkipsInstalled(t *testing.T) {
+	b := New(&mockRunner{stdout: "Skipping: org.gimp.GIMP/x86_64/stable is already installed\n"}, nil)
+
+	res, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.gimp.GIMP"}}, types.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if res.Changed || len(res.PackagesInstalled) != 0 {
+		t.Errorf("Expected no change, got %+v", res)
+	}
+	if len(res.Skipped) != 1 || !types.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want org.gimp.GIMP", res.Skipped)
+	}
+}
+
+func TestBackend_UninstallNotInstalled(t *testing.T) {
+	tests := []string{
+		"error: org.gimp.GIMP/*unspecified*/* not installed",
+		"error: No installed refs found for ‘org.gimp.GIMP’",
+	}
+	for _, stderr := range tests {
+		// Installed when queried, but gone by the time of the uninstall
+		b := New(&mockRunner{stderr: stderr, err: errors.New("exit status 1"), installed: []string{"org.gimp.GIMP"}}, nil)
+		_, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "org.gimp.GIMP"}}, types.UninstallOptions{})
+
+		var niErr *types.NotInstalledError
+		if !errors.As(err, &niErr) || niErr.Ref.Name != "org.gimp.GIMP" || niErr.Operation != types.OperationUninstall {
+			t.Errorf("Uninstall() with %q error = %v", stderr, err)
+		}
+	}
+}
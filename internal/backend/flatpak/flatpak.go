@@ -2,18 +2,37 @@ package flatpak
 
 import (
 	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/frostyard/pm/internal/rank"
 	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
 
 // Backend implements the flatpak backend.
 type Backend struct {
-	runner   runner.Runner
-	progress types.ProgressReporter
+	runner       runner.Runner
+	progress     types.ProgressReporter
+	installation string
+	arch         string
+
+	// roots, if set, replaces the installation directories icons and
+	// changes are looked for in.
+	roots []string
 }
 
+// transactionRow matches a row of the table flatpak prints before applying
+// a transaction, such as " 1. [✓] org.gimp.GIMP  stable  u  flathub  98 MB".
+// The status column is only present when stdout is a terminal. Op is "i"
+// for installs (including new dependencies) and "u" for updates.
+var transactionRow = regexp.MustCompile(`^\d+\.\s+(?:\[(.)\]\s+)?(\S+)\s+\S+\s+[iu]\s`)
+
 // New creates a new flatpak backend.
 func New(r runner.Runner, progress types.ProgressReporter) *Backend {
 	return &Backend{
@@ -22,6 +41,27 @@ func New(r runner.Runner, progress types.ProgressReporter) *Backend {
 	}
 }
 
+// SetInstallation selects the flatpak installation that commands operate
+// on: "user", "system", or the name of a custom installation. An empty
+// value leaves the choice to flatpak.
+func (b *Backend) SetInstallation(installation string) {
+	b.installation = installation
+}
+
+// command builds the arguments for a flatpak subcommand, adding the
+// installation selector after it.
+func (b *Backend) command(subcommand string, args ...string) []string {
+	out := []string{subcommand}
+	switch b.installation {
+	case "":
+	case "user", "system":
+		out = append(out, "--"+b.installation)
+	default:
+		out = append(out, "--installation="+b.installation)
+	}
+	return append(out, args...)
+}
+
 // Available checks if flatpak is available by running `flatpak --version`.
 func (b *Backend) Available(ctx context.Context) (bool, error) {
 	if b.runner == nil {
@@ -41,39 +81,124 @@ func (b *Backend) Available(ctx context.Context) (bool, error) {
 	return false, &types.NotAvailableError{Backend: "flatpak", Reason: "flatpak --version returned no output"}
 }
 
-// Capabilities returns flatpak capabilities.
+// Capabilities probes `flatpak --version`. Operations are unsupported,
+// with the reason in Notes, when flatpak cannot run here; Search and
+// ListInstalled also need a flatpak whose list and search accept --columns.
+// Notes name the flatpak version and, for operations that change an
+// installation, which one.
 func (b *Backend) Capabilities(ctx context.Context) ([]types.Capability, error) {
-	// Flatpak backend supports operations when runner is available
-	hasRunner := b.runner != nil
+	version, reason := b.probe(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	columns := version != "" && atLeast(version, columnsVersion)
+	cli := func(op types.Operation, notes string, needsColumns bool) types.Capability {
+		switch {
+		case version == "":
+			return types.Capability{Operation: op, Notes: reason}
+		case needsColumns && !columns:
+			return types.Capability{Operation: op, Notes: "needs flatpak " + joinVersion(columnsVersion) + " or later for --columns (found " + version + ")"}
+		}
+		return types.Capability{Operation: op, Supported: true, Notes: notes + " (flatpak " + version + ")"}
+	}
+	scope := "; " + b.scopeNote()
 	return []types.Capability{
-		{Operation: types.OperationSearch, Supported: hasRunner, Notes: "via flatpak search CLI"},
-		{Operation: types.OperationUpdateMetadata, Supported: hasRunner, Notes: "via flatpak update CLI"},
-		{Operation: types.OperationUpgradePackages, Supported: hasRunner, Notes: "via flatpak update CLI"},
-		{Operation: types.OperationInstall, Supported: hasRunner, Notes: "via flatpak install CLI"},
-		{Operation: types.OperationUninstall, Supported: hasRunner, Notes: "via flatpak uninstall CLI"},
-		{Operation: types.OperationListInstalled, Supported: hasRunner, Notes: "via flatpak list CLI"},
+		cli(types.OperationSearch, "via flatpak search CLI", true),
+		cli(types.OperationUpdateMetadata, "via flatpak update CLI", false),
+		cli(types.OperationUpgradePackages, "via flatpak update CLI"+scope, false),
+		cli(types.OperationInstall, "via flatpak install CLI"+scope, false),
+		cli(types.OperationUninstall, "via flatpak uninstall CLI"+scope, false),
+		cli(types.OperationListInstalled, "via flatpak list CLI", true),
+		cli(types.OperationListOutdated, "via flatpak remote-ls --updates CLI", true),
+		cli(types.OperationQuery, "via flatpak info CLI", false),
+		cli(types.OperationVerify, "via flatpak remotes and info CLI", true),
+		cli(types.OperationLaunch, "via flatpak run CLI; applications only, not runtimes", false),
+		cli(types.OperationIcon, "from exported icons and appstream data", false),
+		cli(types.OperationWatch, "via the installations' .changed files and flatpak list CLI", true),
 	}, nil
 }
 
+// columnsVersion is the first flatpak release whose list and search
+// commands accept --columns.
+var columnsVersion = []int{1, 2, 0}
+
+// probe returns the version flatpak reports, such as "1.14.4", or "" and
+// the reason flatpak cannot be used.
+func (b *Backend) probe(ctx context.Context) (version, reason string) {
+	if b.runner == nil {
+		return "", "no runner configured"
+	}
+	stdout, stderr, err := b.runner.Run(ctx, "flatpak", "--version")
+	if err != nil {
+		if msg := strings.TrimSpace(stderr); msg != "" {
+			return "", "flatpak --version failed: " + msg
+		}
+		return "", "flatpak --version failed: " + err.Error()
+	}
+	fields := strings.Fields(stdout)
+	if len(fields) == 0 {
+		return "", "flatpak --version returned no output"
+	}
+	return fields[len(fields)-1], ""
+}
+
+// scopeNote describes the installation that changes go to.
+func (b *Backend) scopeNote() string {
+	switch b.installation {
+	case "user":
+		return "user installation"
+	case "system":
+		return "system installation, which needs root or polkit authorization"
+	case "":
+		return "flatpak's default installation, usually the system one, which needs root or polkit authorization"
+	default:
+		return "installation " + b.installation
+	}
+}
+
+// atLeast reports whether the dotted version is min or later. Missing or
+// non-numeric components count as zero.
+func atLeast(version string, min []int) bool {
+	parts := strings.Split(version, ".")
+	for i, want := range min {
+		got := 0
+		if i < len(parts) {
+			got, _ = strconv.Atoi(parts[i])
+		}
+		if got != want {
+			return got > want
+		}
+	}
+	return true
+}
+
+// joinVersion formats a version such as columnsVersion.
+func joinVersion(v []int) string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
 // Update implements Updater using `flatpak update --appstream`.
 func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error) {
 	if b.runner == nil {
 		return types.UpdateResult{}, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationUpdateMetadata, b.progress, opts.Progress)
 	helper.BeginAction("Update")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running flatpak update --appstream")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(ctx, helper),
 		b.runner,
 		types.OperationUpdateMetadata,
 		"flatpak",
 		"flatpak",
-		"update",
-		"--appstream",
+		b.command("update", "--appstream")...,
 	)
 	helper.EndTask()
 
@@ -89,25 +214,32 @@ func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.U
 	return types.UpdateResult{Changed: changed}, nil
 }
 
-// Upgrade implements Upgrader using `flatpak update`.
+// Upgrade implements Upgrader using `flatpak update`, naming opts.Packages
+// if given.
 func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error) {
 	if b.runner == nil {
 		return types.UpgradeResult{}, types.ErrNotSupported
 	}
+	if err := validate(types.OperationUpgradePackages, opts.Packages); err != nil {
+		return types.UpgradeResult{}, err
+	}
+	args := []string{"-y"}
+	for _, pkg := range opts.Packages {
+		args = append(args, pkg.Name)
+	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationUpgradePackages, b.progress, opts.Progress)
 	helper.BeginAction("Upgrade")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running flatpak update")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(ctx, helper),
 		b.runner,
 		types.OperationUpgradePackages,
 		"flatpak",
 		"flatpak",
-		"update",
-		"-y",
+		b.command("update", args...)...,
 	)
 	helper.EndTask()
 
@@ -120,23 +252,27 @@ func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types
 	var packagesChanged []types.PackageRef
 	changed := false
 
-	// Look for lines indicating updates
-	lines := strings.Split(stdout, "\n")
-	for _, line := range lines {
+	// Look for rows of the transaction table flatpak prints before
+	// updating, and for the "Updating <app-id>" lines of older versions
+	for _, line := range strings.Split(stdout, "\n") {
 		line = strings.TrimSpace(line)
-		// Flatpak shows updating apps like "Updating <app-id>"
-		if strings.HasPrefix(line, "Updating") {
-			changed = true
-			// Extract app ID
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				appID := parts[1]
-				packagesChanged = append(packagesChanged, types.PackageRef{
-					Name: appID,
-					Kind: "app",
-				})
+		var appID string
+		if m := transactionRow.FindStringSubmatch(line); m != nil {
+			if m[1] != "" && m[1] != "✓" {
+				continue // failed or skipped
 			}
+			appID = m[2]
+		} else if rest, ok := strings.CutPrefix(line, "Updating "); ok {
+			appID, _, _ = strings.Cut(rest, " ")
+		}
+		if !appIDPattern.MatchString(appID) || slices.ContainsFunc(packagesChanged, func(p types.PackageRef) bool { return p.Name == appID }) {
+			continue
 		}
+		changed = true
+		packagesChanged = append(packagesChanged, types.PackageRef{
+			Name: appID,
+			Kind: "app",
+		})
 	}
 
 	if changed {
@@ -161,20 +297,36 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 		return types.InstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationInstall, pkgs); err != nil {
+		return types.InstallResult{}, err
+	}
+	if err := b.checkArch(ctx, pkgs); err != nil {
+		return types.InstallResult{}, err
+	}
+
+	// Leave out the packages Query finds installed already. If it fails, flatpak install
+	// still reports them.
+	var queried []error
+	if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+		pkgs, queried = types.SkipInstalled("flatpak", pkgs, installed)
+	}
+	if len(pkgs) == 0 {
+		return types.InstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationInstall, b.progress, opts.Progress)
 	helper.BeginAction("Install")
 	defer helper.EndAction()
 
 	// Build package list - flatpak install requires app IDs
-	pkgNames := make([]string, 0, len(pkgs)+2)
-	pkgNames = append(pkgNames, "install", "-y")
+	pkgNames := append(b.command("install", "-y"), b.archArgs()...)
 	for _, pkg := range pkgs {
 		pkgNames = append(pkgNames, pkg.Name)
 	}
 
 	helper.BeginTask("Running flatpak install")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(ctx, helper),
 		b.runner,
 		types.OperationInstall,
 		"flatpak",
@@ -184,9 +336,13 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	helper.EndTask()
 
 	if err != nil {
+		err = packageNotFound(err, stdout, stderr, pkgs)
+		skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Install failed: " + err.Error())
-		return types.InstallResult{}, err
+		return types.InstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were installed
 	var installed []types.PackageRef
@@ -196,6 +352,9 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	lines := strings.Split(stdout, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		if strings.Contains(line, "already installed") {
+			continue
+		}
 		if strings.Contains(line, "Installing") || strings.Contains(line, "installed") {
 			changed = true
 			// Try to extract app ID from the line
@@ -210,7 +369,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 
 	// If we couldn't parse specific packages but the command succeeded, mark all as installed
 	if changed && len(installed) == 0 {
-		installed = pkgs
+		installed = types.Unskipped(pkgs, skipped)
 	}
 
 	if changed {
@@ -222,6 +381,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	return types.InstallResult{
 		Changed:           changed,
 		PackagesInstalled: installed,
+		Skipped:           append(queried, skipped...),
 	}, nil
 }
 
@@ -235,20 +395,33 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 		return types.UninstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationUninstall, pkgs); err != nil {
+		return types.UninstallResult{}, err
+	}
+
+	// Leave out the packages Query finds not installed. If it fails, flatpak uninstall
+	// still reports them.
+	var queried []error
+	if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+		pkgs, queried = types.SkipNotInstalled("flatpak", pkgs, installed)
+	}
+	if len(pkgs) == 0 {
+		return types.UninstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationUninstall, b.progress, opts.Progress)
 	helper.BeginAction("Uninstall")
 	defer helper.EndAction()
 
 	// Build package list
-	pkgNames := make([]string, 0, len(pkgs)+2)
-	pkgNames = append(pkgNames, "uninstall", "-y")
+	pkgNames := b.command("uninstall", "-y")
 	for _, pkg := range pkgs {
 		pkgNames = append(pkgNames, pkg.Name)
 	}
 
 	helper.BeginTask("Running flatpak uninstall")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(ctx, helper),
 		b.runner,
 		types.OperationUninstall,
 		"flatpak",
@@ -258,9 +431,12 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	helper.EndTask()
 
 	if err != nil {
+		skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Uninstall failed: " + err.Error())
-		return types.UninstallResult{}, err
+		return types.UninstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were uninstalled
 	var uninstalled []types.PackageRef
@@ -284,7 +460,7 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 
 	// If we couldn't parse specific packages but the command succeeded, mark all as uninstalled
 	if changed && len(uninstalled) == 0 {
-		uninstalled = pkgs
+		uninstalled = types.Unskipped(pkgs, skipped)
 	}
 
 	if changed {
@@ -296,6 +472,7 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	return types.UninstallResult{
 		Changed:             changed,
 		PackagesUninstalled: uninstalled,
+		Skipped:             append(queried, skipped...),
 	}, nil
 }
 
@@ -309,10 +486,21 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return []types.PackageRef{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationSearch, b.progress, opts.Progress)
 	helper.BeginAction("Search")
 	defer helper.EndAction()
 
+	// Pass results on as flatpak prints them, for callers that render
+	// results incrementally.
+	stream := types.NewResultStream(opts)
+	if opts.OnResult != nil {
+		ctx = runner.WithLineFunc(ctx, func(line string, stderr bool) {
+			if ref, ok := searchResult(line, query, opts); ok && !stderr {
+				stream.Send(ref)
+			}
+		})
+	}
+
 	helper.BeginTask("Running flatpak search")
 	stdout, _, err := runner.RunWithExternalError(
 		ctx,
@@ -320,8 +508,7 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		types.OperationSearch,
 		"flatpak",
 		"flatpak",
-		"search",
-		query,
+		slices.Concat([]string{"search"}, b.archArgs(), []string{"--columns=application,name", query})...,
 	)
 	helper.EndTask()
 
@@ -330,38 +517,100 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return nil, err
 	}
 
-	// Parse search results
-	// Flatpak search output format:
-	// Name          Description                     Application ID          Version Branch Remotes
-	// Firefox       Web Browser                     org.mozilla.firefox     ...     ...    flathub
 	var results []types.PackageRef
-	lines := strings.Split(stdout, "\n")
-
-	// Skip header line
-	for i, line := range lines {
-		if i == 0 {
-			continue // Skip header
+	for _, line := range strings.Split(stdout, "\n") {
+		if ref, ok := searchResult(line, query, opts); ok {
+			results = append(results, ref)
 		}
+	}
+	stream.Finish(results)
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+	helper.Info("Search completed")
+	return results, nil
+}
 
-		// Parse fields - split by whitespace but handle multiple spaces
-		fields := strings.Fields(line)
-		if len(fields) >= 3 {
-			appID := fields[2]
+// searchResult parses a line of `flatpak search --columns=application,name`
+// output: an application ID and its name per line. A header line, printed
+// when stdout is a terminal, is not a valid ID and is skipped. flatpak
+// search also matches summaries and keywords, so unless
+// opts.IncludeDescriptions is set only applications whose ID or name
+// matches are kept.
+func searchResult(line, query string, opts types.SearchOptions) (types.PackageRef, bool) {
+	appID, name, _ := strings.Cut(strings.TrimSpace(line), "\t")
+	if !appIDPattern.MatchString(appID) || opts.Exact && appID != query {
+		return types.PackageRef{}, false
+	}
+	if !opts.IncludeDescriptions && !rank.Matches(appID, query, opts.Fuzzy) && !rank.Matches(name, query, opts.Fuzzy) {
+		return types.PackageRef{}, false
+	}
+	return types.PackageRef{Name: appID, Kind: "app"}, true
+}
 
-			results = append(results, types.PackageRef{
-				Name: appID,
-				Kind: "app",
-			})
+// Query implements Querier using `flatpak info <id>` for each package,
+// which checks only that package.
+func (b *Backend) Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationQuery, pkgs); err != nil {
+		return nil, err
+	}
+
+	installed := []types.InstalledPackage{}
+	if len(pkgs) == 0 {
+		return installed, nil
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationQuery, b.progress, opts.Progress)
+	helper.BeginAction("Query")
+	defer helper.EndAction()
+
+	for _, pkg := range pkgs {
+		helper.BeginTask("Running flatpak info " + pkg.Name)
+		stdout, stderr, err := runner.RunWithExternalError(
+			ctx,
+			b.runner,
+			types.OperationQuery,
+			"flatpak",
+			"flatpak",
+			b.command("info", pkg.Name)...,
+		)
+		helper.EndTask()
+
+		if err != nil {
+			if strings.Contains(stderr, "not installed") {
+				continue
+			}
+			helper.Error("Query failed: " + err.Error())
+			return nil, err
 		}
+		installed = append(installed, parseInfo(pkg, stdout))
 	}
 
-	helper.Info("Search completed")
-	return results, nil
+	helper.Info("Query completed")
+	return installed, nil
+}
+
+// parseInfo reads the version and installation of pkg from `flatpak info`
+// output, whose fields are "Key: value" lines.
+func parseInfo(pkg types.PackageRef, stdout string) types.InstalledPackage {
+	info := types.InstalledPackage{
+		Ref: types.PackageRef{Name: pkg.Name, Kind: "app"},
+	}
+	for _, line := range strings.Split(stdout, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Version":
+			info.Version = strings.TrimSpace(value)
+		case "Installation":
+			info.Ref.Namespace = strings.TrimSpace(value)
+		}
+	}
+	return info
 }
 
 // ListInstalled implements Lister using `flatpak list`.
@@ -370,7 +619,7 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		return nil, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationListInstalled, b.progress, opts.Progress)
 	helper.BeginAction("ListInstalled")
 	defer helper.EndAction()
 
@@ -381,9 +630,7 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		types.OperationListInstalled,
 		"flatpak",
 		"flatpak",
-		"list",
-		"--app",
-		"--columns=name,application,version,installation",
+		b.command("list", "--app", "--columns=name,application,version,installation,size")...,
 	)
 	helper.EndTask()
 
@@ -392,7 +639,8 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 		return nil, err
 	}
 
-	// Parse output: columns are name, application ID, version, installation
+	// Parse output: columns are name, application ID, version, installation,
+	// size
 	var packages []types.InstalledPackage
 	lines := strings.Split(stdout, "\n")
 
@@ -402,56 +650,105 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 			continue
 		}
 
-		// Split by tab (flatpak uses tabs for column separation with --columns)
+		// Split by tab (flatpak uses tabs for column separation with
+		// --columns), falling back to whitespace if tabs are not present.
+		// The installation column is missing on old versions, and sizes
+		// contain a space.
 		fields := strings.Split(line, "\t")
-		if len(fields) >= 4 {
-			appID := strings.TrimSpace(fields[1])
-			version := strings.TrimSpace(fields[2])
-			installation := strings.TrimSpace(fields[3])
-
-			packages = append(packages, types.InstalledPackage{
-				Ref: types.PackageRef{
-					Name:      appID,
-					Kind:      "app",
-					Namespace: installation, // "user" or "system"
-				},
-				Version: version,
-			})
-		} else if len(fields) >= 3 {
-			// Fallback: if installation column is missing, still parse what we can
-			appID := strings.TrimSpace(fields[1])
-			version := strings.TrimSpace(fields[2])
-
-			packages = append(packages, types.InstalledPackage{
-				Ref: types.PackageRef{
-					Name: appID,
-					Kind: "app",
-				},
-				Version: version,
-			})
-		} else {
-			// Fallback: split by whitespace if tabs not present
+		if len(fields) < 3 {
 			fields = strings.Fields(line)
-			if len(fields) >= 3 {
-				appID := fields[1]
-				version := fields[2]
-				installation := ""
-				if len(fields) >= 4 {
-					installation = fields[3]
-				}
-
-				packages = append(packages, types.InstalledPackage{
-					Ref: types.PackageRef{
-						Name:      appID,
-						Kind:      "app",
-						Namespace: installation,
-					},
-					Version: version,
-				})
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		appID := strings.TrimSpace(fields[1])
+		if !appIDPattern.MatchString(appID) {
+			// Not an application row, such as a header or a name
+			// containing spaces in whitespace-separated output
+			continue
+		}
+		installation, size := "", int64(0)
+		if len(fields) >= 4 {
+			rest := strings.Join(fields[3:], " ")
+			if n, ok := parseSize(rest); ok {
+				size = n // no installation column
+			} else {
+				installation = strings.TrimSpace(fields[3]) // "user" or "system"
+				size, _ = parseSize(strings.Join(fields[4:], " "))
 			}
 		}
+
+		packages = append(packages, types.InstalledPackage{
+			Ref: types.PackageRef{
+				Name:      appID,
+				Kind:      "app",
+				Namespace: installation,
+			},
+			Version: strings.TrimSpace(fields[2]),
+			Size:    size,
+		})
 	}
 
 	helper.Info("ListInstalled completed")
 	return packages, nil
 }
+
+// sizeUnits are the units flatpak prints sizes in, as GLib's
+// g_format_size does.
+var sizeUnits = map[string]float64{
+	"byte":  1,
+	"bytes": 1,
+	"kB":    1e3,
+	"MB":    1e6,
+	"GB":    1e9,
+	"TB":    1e12,
+	"KiB":   1 << 10,
+	"MiB":   1 << 20,
+	"GiB":   1 << 30,
+	"TiB":   1 << 40,
+}
+
+// parseSize parses a size from the size column, such as "1.2 GB", into
+// bytes.
+func parseSize(s string) (int64, bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(fields[0], 64)
+	unit, ok := sizeUnits[fields[1]]
+	if err != nil || !ok || n < 0 {
+		return 0, false
+	}
+	return int64(math.Round(n * unit)), true
+}
+
+// installRoots returns the directories of the user and system
+// installations the backend works with. Custom installations are left out.
+func (b *Backend) installRoots() []string {
+	if b.roots != nil {
+		return b.roots
+	}
+	var roots []string
+	if b.installation == "" || b.installation == "user" {
+		if data := userDataDir(); data != "" {
+			roots = append(roots, filepath.Join(data, "flatpak"))
+		}
+	}
+	if b.installation == "" || b.installation == "system" {
+		roots = append(roots, "/var/lib/flatpak")
+	}
+	return roots
+}
+
+// userDataDir returns $XDG_DATA_HOME, or ~/.local/share.
+func userDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share")
+}
@@ -2,8 +2,15 @@ package flatpak
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
 
@@ -12,9 +19,23 @@ type mockRunner struct {
 	stdout string
 	stderr string
 	err    error
+
+	// installed lists the refs `flatpak info` finds; it reports the others
+	// as not installed.
+	installed []string
+
+	lastArgs []string
 }
 
 func (m *mockRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	if slices.Contains(args, "info") {
+		ref := args[len(args)-1]
+		if slices.Contains(m.installed, ref) {
+			return "Ref: app/" + ref + "/x86_64/stable\n", "", nil
+		}
+		return "", "error: " + ref + "/*unspecified*/* not installed\n", errors.New("exit status 1")
+	}
+	m.lastArgs = args
 	return m.stdout, m.stderr, m.err
 }
 
@@ -184,3 +205,398 @@ func TestBackend_ListInstalled(t *testing.T) {
 		}
 	})
 }
+
+func TestBackend_SetInstallation(t *testing.T) {
+	tests := []struct {
+		installation string
+		want         string
+	}{
+		{"user", "--user"},
+		{"system", "--system"},
+		{"extra", "--installation=extra"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.installation, func(t *testing.T) {
+			mockRnr := &mockRunner{}
+			b := New(mockRnr, nil)
+			b.SetInstallation(tt.installation)
+
+			_, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.gimp.GIMP"}}, types.InstallOptions{})
+			if err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			want := []string{"install", tt.want, "-y", "org.gimp.GIMP"}
+			if strings.Join(mockRnr.lastArgs, " ") != strings.Join(want, " ") {
+				t.Errorf("args = %v, want %v", mockRnr.lastArgs, want)
+			}
+		})
+	}
+}
+
+func TestBackend_SetArch(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^search --arch=aarch64 --columns=application,name gimp$`, Stdout: "org.gimp.GIMP\tGNU Image Manipulation Program\n"},
+		{Name: "flatpak", Args: `^search --arch=aarch64 --columns=application org\.gimp\.GIMP$`, Stdout: "org.gimp.GIMP\n"},
+		{Name: "flatpak", Args: `^info org\.gimp\.GIMP$`, Stderr: "error: org.gimp.GIMP/*unspecified*/* not installed\n", Err: errors.New("exit status 1")},
+		{Name: "flatpak", Args: `^install -y --arch=aarch64 org\.gimp\.GIMP$`, Stdout: "Installing org.gimp.GIMP\n"},
+		// Offered only for flatpak's own architecture
+		{Name: "flatpak", Args: `^search --arch=aarch64 --columns=application com\.valvesoftware\.Steam$`},
+		{Name: "flatpak", Args: `^search --columns=application com\.valvesoftware\.Steam$`, Stdout: "com.valvesoftware.Steam\n"},
+	}}
+	b := New(fake, nil)
+	b.SetArch("arm64")
+
+	if results, err := b.Search(context.Background(), "gimp", types.SearchOptions{}); err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %v, %v", results, err)
+	}
+	if _, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.gimp.GIMP"}}, types.InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	_, err := b.Install(context.Background(), []types.PackageRef{{Name: "com.valvesoftware.Steam"}}, types.InstallOptions{})
+	var archErr *types.UnsupportedArchError
+	if !errors.As(err, &archErr) || archErr.Arch != "aarch64" || archErr.Ref.Name != "com.valvesoftware.Steam" {
+		t.Errorf("Install() error = %v, want an UnsupportedArchError for aarch64", err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_Search(t *testing.T) {
+	m := &mockRunner{stdout: "Application ID\norg.gimp.GIMP\norg.gimp.GIMP.Manual\n\n"}
+	b := New(m, nil)
+
+	results, err := b.Search(context.Background(), "gimp", types.SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "org.gimp.GIMP" || results[1].Name != "org.gimp.GIMP.Manual" {
+		t.Errorf("Unexpected results %+v", results)
+	}
+	if !slices.Contains(m.lastArgs, "--columns=application,name") {
+		t.Errorf("Expected machine-readable columns, got %v", m.lastArgs)
+	}
+}
+
+func TestBackend_SearchIncludeDescriptions(t *testing.T) {
+	m := &mockRunner{stdout: "com.visualstudio.code\tVisual Studio Code\norg.gnome.TextEditor\tText Editor\n"}
+	b := New(m, nil)
+
+	for _, tt := range []struct {
+		descriptions bool
+		want         string
+	}{
+		// The text editor matches only on its keywords
+		{false, "[com.visualstudio.code]"},
+		{true, "[com.visualstudio.code org.gnome.TextEditor]"},
+	} {
+		results, err := b.Search(context.Background(), "studio", types.SearchOptions{IncludeDescriptions: tt.descriptions})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		var names []string
+		for _, r := range results {
+			names = append(names, r.Name)
+		}
+		if fmt.Sprint(names) != tt.want {
+			t.Errorf("Search(studio, IncludeDescriptions %v) = %v, want %v", tt.descriptions, names, tt.want)
+		}
+	}
+}
+
+func TestBackend_SearchExact(t *testing.T) {
+	m := &mockRunner{stdout: "org.gimp.GIMP\norg.gimp.GIMP.Manual\n"}
+	b := New(m, nil)
+
+	results, err := b.Search(context.Background(), "org.gimp.GIMP", types.SearchOptions{Exact: true})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "org.gimp.GIMP" {
+		t.Errorf("Unexpected results %+v", results)
+	}
+}
+
+// streamingRunner writes its output to the context's line function, like
+// the real runner, before returning it.
+type streamingRunner struct {
+	stdout string
+}
+
+func (r *streamingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	if fn, ok := runner.LineFuncFromContext(ctx); ok {
+		for _, line := range strings.Split(r.stdout, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fn(line, false)
+			}
+		}
+	}
+	return r.stdout, "", nil
+}
+
+func TestBackend_SearchOnResult(t *testing.T) {
+	stdout := "Application ID\norg.gimp.GIMP\norg.gimp.GIMP.Manual\n"
+	for name, r := range map[string]runner.Runner{
+		"streamed": &streamingRunner{stdout: stdout},
+		"at exit":  &mockRunner{stdout: stdout},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var streamed []string
+			opts := types.SearchOptions{OnResult: func(ref types.PackageRef) {
+				streamed = append(streamed, ref.Name)
+			}}
+			results, err := New(r, nil).Search(context.Background(), "gimp", opts)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if len(results) != 2 || !slices.Equal(streamed, []string{"org.gimp.GIMP", "org.gimp.GIMP.Manual"}) {
+				t.Errorf("OnResult got %v for results %+v, want each result once", streamed, results)
+			}
+		})
+	}
+}
+
+func TestBackend_Query(t *testing.T) {
+	info := `
+Firefox - Fast, Private & Safe Web Browser
+
+          ID: org.mozilla.firefox
+         Ref: app/org.mozilla.firefox/x86_64/stable
+        Arch: x86_64
+      Branch: stable
+     Version: 124.0.1
+      Origin: flathub
+Installation: system
+`
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^info --user org\.mozilla\.firefox$`, Stdout: info},
+		{Name: "flatpak", Args: `^info --user org\.gimp\.GIMP$`, Stderr: "error: org.gimp.GIMP/*unspecified*/*unspecified* not installed", Err: errors.New("exit status 1")},
+	}}
+	b := New(fake, nil)
+	b.SetInstallation("user")
+
+	got, err := b.Query(context.Background(), []types.PackageRef{{Name: "org.mozilla.firefox"}, {Name: "org.gimp.GIMP"}}, types.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := types.InstalledPackage{Ref: types.PackageRef{Name: "org.mozilla.firefox", Kind: "app", Namespace: "system"}, Version: "124.0.1"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Query() = %+v, want [%+v]", got, want)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_InstallSkipsQueried(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^info org\.mozilla\.firefox$`, Stdout: "ID: org.mozilla.firefox\nVersion: 124.0.1\n"},
+		{Name: "flatpak", Args: `^info org\.gimp\.GIMP$`, Stderr: "error: org.gimp.GIMP/*unspecified*/*unspecified* not installed", Err: errors.New("exit status 1")},
+		{Name: "flatpak", Args: `^install -y org\.gimp\.GIMP$`, Stdout: "Installing org.gimp.GIMP\n"},
+		{Name: "flatpak", Args: `^info org\.gimp\.GIMP$`, Stderr: "error: org.gimp.GIMP/*unspecified*/*unspecified* not installed", Err: errors.New("exit status 1")},
+	}}
+	b := New(fake, nil)
+
+	res, err := b.Install(context.Background(), []types.PackageRef{{Name: "org.mozilla.firefox"}, {Name: "org.gimp.GIMP"}}, types.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(res.Skipped) != 1 || !types.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want firefox", res.Skipped)
+	}
+	if len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "org.gimp.GIMP" {
+		t.Errorf("PackagesInstalled = %v, want [org.gimp.GIMP]", res.PackagesInstalled)
+	}
+
+	// Nothing to remove, so flatpak uninstall does not run
+	ures, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "org.gimp.GIMP"}}, types.UninstallOptions{})
+	if err != nil || ures.Changed || len(ures.Skipped) != 1 || !types.IsNotInstalled(ures.Skipped[0]) {
+		t.Errorf("Uninstall() = %+v, %v, want org.gimp.GIMP skipped", ures, err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_ListOutdated(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^remote-ls --user --updates --columns=ref,version$`, Stdout: "app/org.gimp.GIMP/x86_64/stable\t2.10.38\nruntime/org.gnome.Platform/x86_64/46\t\n"},
+		{Name: "flatpak", Args: `^list --user --columns=ref,version$`, Stdout: "app/org.gimp.GIMP/x86_64/stable\t2.10.36\nruntime/org.gnome.Platform/x86_64/46\t\napp/org.mozilla.firefox/x86_64/stable\t125.0\n"},
+	}}
+	b := New(fake, nil)
+	b.SetInstallation("user")
+
+	got, err := b.ListOutdated(context.Background(), types.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	want := []types.OutdatedPackage{
+		{Ref: types.PackageRef{Name: "org.gimp.GIMP", Kind: "app"}, InstalledVersion: "2.10.36", AvailableVersion: "2.10.38"},
+		{Ref: types.PackageRef{Name: "org.gnome.Platform", Kind: "runtime"}},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ListOutdated() = %+v, want %+v", got, want)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	// Nothing to update, so nothing else is listed
+	fake = &runner.FakeRunner{Script: []runner.FakeCall{{Name: "flatpak", Args: `^remote-ls --updates`}}}
+	if got, err := New(fake, nil).ListOutdated(context.Background(), types.OutdatedOptions{}); err != nil || len(got) != 0 {
+		t.Errorf("ListOutdated() = %+v, %v, want none", got, err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_UpgradePackages(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+		Name:   "flatpak",
+		Args:   `^update --user -y org\.gimp\.GIMP$`,
+		Stdout: " 1. [✓] org.gimp.GIMP stable u flathub 42.1 MB / 98.3 MB\n",
+	}}}
+	b := New(fake, nil)
+	b.SetInstallation("user")
+
+	res, err := b.Upgrade(context.Background(), types.UpgradeOptions{Packages: []types.PackageRef{{Name: "org.gimp.GIMP"}}})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(res.PackagesChanged) != 1 || res.PackagesChanged[0].Name != "org.gimp.GIMP" {
+		t.Errorf("Upgrade() = %+v, want org.gimp.GIMP upgraded", res)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_Verify(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^remotes --columns=name,options$`, Stdout: "flathub\tsystem\nlocal-repo\tsystem,no-gpg-verify\nold\tsystem,disabled,no-gpg-verify\n"},
+		{Name: "flatpak", Args: `^info org\.mozilla\.firefox$`, Stdout: "ID: org.mozilla.firefox\nVersion: 124.0.1\nOrigin: flathub\nCommit: 3f2a9c\n"},
+		{Name: "flatpak", Args: `^info com\.example\.Tool$`, Stdout: "ID: com.example.Tool\nOrigin: local-repo\nCommit: 77aa01\n"},
+		{Name: "flatpak", Args: `^info org\.gimp\.GIMP$`, Stderr: "error: org.gimp.GIMP/*unspecified*/*unspecified* not installed", Err: errors.New("exit status 1")},
+		{Name: "flatpak", Args: `^search --columns=application org\.gimp\.GIMP$`, Stdout: "org.gimp.GIMP\n"},
+	}}
+	b := New(fake, nil)
+
+	pkgs := []types.PackageRef{{Name: "org.mozilla.firefox"}, {Name: "com.example.Tool"}, {Name: "org.gimp.GIMP"}}
+	got, err := b.Verify(context.Background(), pkgs, types.VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if at := got[0]; !at.Installed || at.Status != types.AttestationVerified || at.Source != "flathub" || at.Digest != "3f2a9c" {
+		t.Errorf("Verify(firefox) = %+v", at)
+	}
+	if at := got[1]; at.Status != types.AttestationUnverified || len(at.Findings) != 1 {
+		t.Errorf("Verify(Tool) = %+v, want unverified from a remote without GPG verification", at)
+	}
+	// Not installed, so any enabled remote could provide it
+	if at := got[2]; at.Installed || at.Status != types.AttestationUnverified || len(at.Findings) != 1 {
+		t.Errorf("Verify(GIMP) = %+v, want unverified because of local-repo", at)
+	}
+}
+
+func TestBackend_LaunchCommand(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "flatpak", Args: `^info --user org\.gimp\.GIMP$`, Stdout: "ID: org.gimp.GIMP\nRef: app/org.gimp.GIMP/x86_64/beta\n"},
+		{Name: "flatpak", Args: `^info --user org\.gnome\.Platform$`, Stdout: "ID: org.gnome.Platform\nRef: runtime/org.gnome.Platform/x86_64/46\n"},
+		{Name: "flatpak", Args: `^info --user org\.mozilla\.firefox$`, Stderr: "error: org.mozilla.firefox/*unspecified*/*unspecified* not installed", Err: errors.New("exit status 1")},
+	}}
+	b := New(fake, nil)
+	b.SetInstallation("user")
+
+	cmd, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "org.gimp.GIMP", Channel: "beta"}, types.LaunchOptions{Args: []string{"photo.png"}})
+	if err != nil {
+		t.Fatalf("LaunchCommand(GIMP) error = %v", err)
+	}
+	if got, want := cmd.Name+" "+strings.Join(cmd.Args, " "), "flatpak run --user --branch=beta org.gimp.GIMP photo.png"; got != want {
+		t.Errorf("LaunchCommand(GIMP) = %q, want %q", got, want)
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "org.gnome.Platform"}, types.LaunchOptions{}); !types.IsNotSupported(err) || !strings.Contains(err.Error(), "runtime") {
+		t.Errorf("LaunchCommand(runtime) error = %v, want NotSupportedError naming the runtime", err)
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "org.mozilla.firefox"}, types.LaunchOptions{}); !types.IsNotInstalled(err) {
+		t.Errorf("LaunchCommand(firefox) error = %v, want NotInstalledError", err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_IconSource(t *testing.T) {
+	root := t.TempDir()
+	touch := func(parts ...string) string {
+		path := filepath.Join(append([]string{root}, parts...)...)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	hicolor := []string{"exports", "share", "icons", "hicolor"}
+	gimp64 := touch(append(hicolor, "64x64", "apps", "org.gimp.GIMP.png")...)
+	gimp256 := touch(append(hicolor, "256x256", "apps", "org.gimp.GIMP.png")...)
+	inkscape := touch(append(hicolor, "scalable", "apps", "org.inkscape.Inkscape.svg")...)
+	firefox := touch("appstream", "flathub", "x86_64", "active", "icons", "128x128", "org.mozilla.firefox.png")
+
+	b := New(&runner.FakeRunner{}, nil)
+	b.SetArch("amd64")
+	b.roots = []string{root}
+
+	tests := []struct {
+		name string
+		size int
+		want string
+	}{
+		{"org.gimp.GIMP", 48, gimp64},
+		{"org.gimp.GIMP", 128, gimp256},
+		{"org.gimp.GIMP", 1024, gimp256},
+		{"org.inkscape.Inkscape", 128, inkscape},
+		{"org.mozilla.firefox", 64, firefox},
+		{"org.videolan.VLC", 128, ""},
+	}
+	for _, tt := range tests {
+		src, err := b.IconSource(context.Background(), types.PackageRef{Name: tt.name}, types.IconOptions{Size: tt.size})
+		if err != nil {
+			t.Fatalf("IconSource(%s, %d) error = %v", tt.name, tt.size, err)
+		}
+		if src.Path != tt.want || src.URL != "" {
+			t.Errorf("IconSource(%s, %d) = %+v, want %s", tt.name, tt.size, src, tt.want)
+		}
+	}
+	// Appstream icons are looked for in the remote named by Namespace.
+	if src, _ := b.IconSource(context.Background(), types.PackageRef{Name: "org.mozilla.firefox", Namespace: "fedora"}, types.IconOptions{}); src.Path != "" {
+		t.Errorf("IconSource(firefox from fedora) = %+v, want none", src)
+	}
+}
+
+func TestBackend_ChangeToken(t *testing.T) {
+	user, system := t.TempDir(), t.TempDir()
+	b := New(&runner.FakeRunner{}, nil)
+	b.roots = []string{user, system}
+
+	token, err := b.ChangeToken(context.Background())
+	if err != nil {
+		t.Fatalf("ChangeToken() error = %v", err)
+	}
+	if again, _ := b.ChangeToken(context.Background()); again != token {
+		t.Errorf("ChangeToken() = %q then %q without changes", token, again)
+	}
+
+	// flatpak touches .changed after every transaction.
+	if err := os.WriteFile(filepath.Join(system, ".changed"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	next, err := b.ChangeToken(context.Background())
+	if err != nil || next == token {
+		t.Errorf("ChangeToken() = %q, %v after a transaction, want a new token", next, err)
+	}
+}
@@ -0,0 +1,28 @@
+package flatpak
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/golden"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestGolden(t *testing.T) {
+	ctx := context.Background()
+	t.Run("list", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/list", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(f, nil).ListInstalled(ctx, types.ListOptions{})
+		})
+	})
+	t.Run("search", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/search", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(f, nil).Search(ctx, "query", types.SearchOptions{IncludeDescriptions: true})
+		})
+	})
+	t.Run("update", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/update", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(f, nil).Upgrade(ctx, types.UpgradeOptions{})
+		})
+	})
+}
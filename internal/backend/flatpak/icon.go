@@ -0,0 +1,91 @@
+package flatpak
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// exportedIconSizes are the hicolor sizes apps export icons in.
+var exportedIconSizes = []int{16, 22, 24, 32, 48, 64, 96, 128, 256, 512}
+
+// appstreamIconSizes are the sizes flatpak keeps icons from its remotes'
+// appstream data in.
+var appstreamIconSizes = []int{64, 128}
+
+// IconSource implements IconFetcher with files flatpak already keeps: the
+// icon an installed app exports, or else the icon from the appstream data
+// of its remotes, which `flatpak update --appstream` downloads and which
+// also covers apps that are not installed. The size closest to opts.Size,
+// preferring larger ones, is chosen; exported scalable (SVG) icons are
+// used when no PNG is large enough. Custom installations are not looked
+// in.
+func (b *Backend) IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error) {
+	if err := validate(types.OperationIcon, []types.PackageRef{pkg}); err != nil {
+		return types.IconSource{}, err
+	}
+
+	for _, root := range b.installRoots() {
+		hicolor := filepath.Join(root, "exports", "share", "icons", "hicolor")
+		for _, size := range bySize(exportedIconSizes, opts.Size) {
+			if path := filepath.Join(hicolor, sizeDir(size), "apps", pkg.Name+".png"); isFile(path) {
+				return types.IconSource{Path: path}, nil
+			}
+		}
+		if path := filepath.Join(hicolor, "scalable", "apps", pkg.Name+".svg"); isFile(path) {
+			return types.IconSource{Path: path}, nil
+		}
+	}
+
+	remote := pkg.Namespace
+	if remote == "" {
+		remote = "*"
+	}
+	arch := b.targetArch()
+	if arch == "" {
+		arch = archNames[runtime.GOARCH]
+	}
+	for _, root := range b.installRoots() {
+		for _, size := range bySize(appstreamIconSizes, opts.Size) {
+			pattern := filepath.Join(root, "appstream", remote, arch, "active", "icons", sizeDir(size), pkg.Name+".png")
+			if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+				return types.IconSource{Path: matches[0]}, nil
+			}
+		}
+	}
+	return types.IconSource{}, ctx.Err()
+}
+
+// bySize orders sizes by preference for an icon of the wanted size: the
+// sizes at least as large, smallest first, then the smaller ones, largest
+// first. A want of zero or less prefers the largest.
+func bySize(sizes []int, want int) []int {
+	var larger, smaller []int
+	for _, size := range sizes {
+		if want > 0 && size >= want {
+			larger = append(larger, size)
+		} else {
+			smaller = append(smaller, size)
+		}
+	}
+	slices.Sort(larger)
+	slices.Sort(smaller)
+	slices.Reverse(smaller)
+	return append(larger, smaller...)
+}
+
+// sizeDir returns the name of the directory icons of size are kept in,
+// such as "128x128".
+func sizeDir(size int) string {
+	return strconv.Itoa(size) + "x" + strconv.Itoa(size)
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
@@ -0,0 +1,76 @@
+package flatpak
+
+import (
+	"context"
+	"strings"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// LaunchCommand implements Launcher. `flatpak info` checks that pkg is an
+// installed application, since runtimes and extensions cannot be run, and
+// the application is started with `flatpak run`, on pkg's branch if it
+// names one.
+func (b *Backend) LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error) {
+	if b.runner == nil {
+		return types.LaunchCommand{}, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationLaunch, []types.PackageRef{pkg}); err != nil {
+		return types.LaunchCommand{}, err
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationLaunch, b.progress, opts.Progress)
+	helper.BeginAction("Launch")
+	defer helper.EndAction()
+
+	helper.BeginTask("Running flatpak info " + pkg.Name)
+	stdout, stderr, err := runner.RunWithExternalError(
+		ctx,
+		b.runner,
+		types.OperationLaunch,
+		"flatpak",
+		"flatpak",
+		b.command("info", pkg.Name)...,
+	)
+	helper.EndTask()
+
+	if err != nil {
+		if strings.Contains(stderr, "not installed") {
+			err = &types.NotInstalledError{Operation: types.OperationLaunch, Ref: pkg, Backend: "flatpak", Err: err}
+		}
+		helper.Error("Launch failed: " + err.Error())
+		return types.LaunchCommand{}, err
+	}
+	if kind := refKind(stdout); kind != "" && kind != "app" {
+		err := &types.NotSupportedError{
+			Operation: types.OperationLaunch,
+			Backend:   "flatpak",
+			Reason:    pkg.Name + " is a " + kind + ", not an application",
+		}
+		helper.Error("Launch failed: " + err.Error())
+		return types.LaunchCommand{}, err
+	}
+
+	args := []string{pkg.Name}
+	if pkg.Channel != "" {
+		args = []string{"--branch=" + pkg.Channel, pkg.Name}
+	}
+	cmd := types.LaunchCommand{Name: "flatpak", Args: append(b.command("run", args...), opts.Args...)}
+	helper.Info("Launching " + pkg.Name)
+	return cmd, nil
+}
+
+// refKind returns the kind of ref `flatpak info` describes, "app" or
+// "runtime", from its "Ref: app/org.gimp.GIMP/x86_64/stable" line.
+func refKind(stdout string) string {
+	for _, line := range strings.Split(stdout, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && key == "Ref" {
+			kind, _, _ := strings.Cut(strings.TrimSpace(value), "/")
+			return kind
+		}
+	}
+	return ""
+}
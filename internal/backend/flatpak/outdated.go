@@ -0,0 +1,100 @@
+package flatpak
+
+import (
+	"context"
+	"strings"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ListOutdated implements OutdatedLister using `flatpak remote-ls
+// --updates`, which asks the remotes which installed refs, applications
+// and runtimes, have updates. Installed versions come from `flatpak list`.
+func (b *Backend) ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationListOutdated, b.progress, opts.Progress)
+	helper.BeginAction("ListOutdated")
+	defer helper.EndAction()
+
+	helper.BeginTask("Running flatpak remote-ls --updates")
+	stdout, _, err := runner.RunWithExternalError(
+		ctx,
+		b.runner,
+		types.OperationListOutdated,
+		"flatpak",
+		"flatpak",
+		b.command("remote-ls", "--updates", "--columns=ref,version")...,
+	)
+	helper.EndTask()
+	if err != nil {
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+	updates := parseRefVersions(stdout)
+
+	outdated := []types.OutdatedPackage{}
+	if len(updates) == 0 {
+		helper.Info("ListOutdated completed")
+		return outdated, nil
+	}
+
+	helper.BeginTask("Running flatpak list")
+	stdout, _, err = runner.RunWithExternalError(
+		ctx,
+		b.runner,
+		types.OperationListOutdated,
+		"flatpak",
+		"flatpak",
+		b.command("list", "--columns=ref,version")...,
+	)
+	helper.EndTask()
+	if err != nil {
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+	installed := make(map[string]string)
+	for _, rv := range parseRefVersions(stdout) {
+		installed[rv.ref] = rv.version
+	}
+
+	for _, rv := range updates {
+		// Refs are "kind/id/arch/branch", such as
+		// "app/org.gimp.GIMP/x86_64/stable".
+		parts := strings.Split(rv.ref, "/")
+		if len(parts) < 2 {
+			continue
+		}
+		outdated = append(outdated, types.OutdatedPackage{
+			Ref:              types.PackageRef{Name: parts[1], Kind: parts[0]},
+			InstalledVersion: installed[rv.ref],
+			AvailableVersion: rv.version,
+		})
+	}
+
+	helper.Info("ListOutdated completed")
+	return outdated, nil
+}
+
+type refVersion struct {
+	ref, version string
+}
+
+// parseRefVersions parses the output of flatpak commands run with
+// --columns=ref,version. Rows that are not refs, such as headers, are
+// skipped; the version is empty for refs without one, as most runtimes.
+func parseRefVersions(stdout string) []refVersion {
+	var rows []refVersion
+	for _, line := range strings.Split(stdout, "\n") {
+		ref, version, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		ref = strings.TrimSpace(ref)
+		if strings.Count(ref, "/") != 3 {
+			continue
+		}
+		rows = append(rows, refVersion{ref: ref, version: strings.TrimSpace(version)})
+	}
+	return rows
+}
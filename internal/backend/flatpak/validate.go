@@ -0,0 +1,45 @@
+package flatpak
+
+import (
+	"regexp"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+var (
+	// appIDPattern matches reverse-DNS application IDs: at least three
+	// dot-separated elements of letters, digits, underscores and hyphens,
+	// none starting with a digit.
+	appIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(?:\.[A-Za-z_][A-Za-z0-9_-]*){2,}$`)
+
+	// tokenPattern matches remote, installation and branch names.
+	tokenPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+)
+
+// validate checks pkgs before any command is run, so mistakes are reported
+// as a ValidationError rather than as a confusing flatpak failure.
+func validate(op types.Operation, pkgs []types.PackageRef) error {
+	for _, pkg := range pkgs {
+		if len(pkg.Name) > 255 || !appIDPattern.MatchString(pkg.Name) {
+			return invalid(op, pkg, "Name", pkg.Name, "must be a reverse-DNS application ID such as org.gimp.GIMP")
+		}
+		if pkg.Namespace != "" && !tokenPattern.MatchString(pkg.Namespace) {
+			return invalid(op, pkg, "Namespace", pkg.Namespace, "must be a remote or installation name")
+		}
+		if pkg.Channel != "" && !tokenPattern.MatchString(pkg.Channel) {
+			return invalid(op, pkg, "Channel", pkg.Channel, "must be a branch name such as stable")
+		}
+	}
+	return nil
+}
+
+func invalid(op types.Operation, pkg types.PackageRef, field, value, reason string) error {
+	return &types.ValidationError{
+		Operation: op,
+		Backend:   "flatpak",
+		Ref:       pkg,
+		Field:     field,
+		Value:     value,
+		Reason:    reason,
+	}
+}
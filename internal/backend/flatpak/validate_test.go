@@ -0,0 +1,53 @@
+package flatpak
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		ref       types.PackageRef
+		wantField string
+	}{
+		{types.PackageRef{Name: "org.gimp.GIMP"}, ""},
+		{types.PackageRef{Name: "org.freedesktop.Platform.GL.default", Namespace: "flathub", Channel: "23.08"}, ""},
+		{types.PackageRef{Name: "com.example.my-app", Namespace: "user"}, ""},
+		{types.PackageRef{Name: "gimp"}, "Name"},
+		{types.PackageRef{Name: "org.gimp"}, "Name"},
+		{types.PackageRef{Name: "org.7zip.App"}, "Name"},
+		{types.PackageRef{Name: "org.gimp.GIMP/x86_64/stable"}, "Name"},
+		{types.PackageRef{Name: "org.gimp.GIMP", Namespace: "--user"}, "Namespace"},
+		{types.PackageRef{Name: "org.gimp.GIMP", Channel: "stable/beta"}, "Channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref.Name, func(t *testing.T) {
+			err := validate(types.OperationInstall, []types.PackageRef{tt.ref})
+			var valErr *types.ValidationError
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Unexpected error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &valErr) || valErr.Field != tt.wantField || valErr.Ref != tt.ref {
+				t.Errorf("Expected a %s validation error, got %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+func TestBackend_UninstallValidatesFirst(t *testing.T) {
+	m := &mockRunner{}
+	b := New(m, nil)
+	_, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "gimp"}}, types.UninstallOptions{})
+	if !types.IsValidation(err) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	if m.lastArgs != nil {
+		t.Errorf("Expected no command to run, got %v", m.lastArgs)
+	}
+}
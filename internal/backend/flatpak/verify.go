@@ -0,0 +1,130 @@
+package flatpak
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// Verify implements Verifier. flatpak checks every commit it pulls against
+// its remote's GPG keys unless the remote was added with --no-gpg-verify,
+// so an installed app is signed if its origin remote verifies, and an app
+// that is not installed if every remote that could provide it does.
+// `flatpak remotes` lists the remotes and `flatpak info` each app's origin
+// and commit.
+func (b *Backend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	if b.runner == nil {
+		return nil, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationVerify, pkgs); err != nil {
+		return nil, err
+	}
+
+	attestations := []types.Attestation{}
+	if len(pkgs) == 0 {
+		return attestations, nil
+	}
+
+	helper := types.NewOperationProgressHelper("flatpak", types.OperationVerify, b.progress, opts.Progress)
+	helper.BeginAction("Verify")
+	defer helper.EndAction()
+
+	helper.BeginTask("Running flatpak remotes")
+	stdout, _, err := runner.RunWithExternalError(
+		ctx,
+		b.runner,
+		types.OperationVerify,
+		"flatpak",
+		"flatpak",
+		b.command("remotes", "--columns=name,options")...,
+	)
+	helper.EndTask()
+	if err != nil {
+		helper.Error("Verify failed: " + err.Error())
+		return nil, err
+	}
+	remotes := parseRemotes(stdout)
+
+	for _, pkg := range pkgs {
+		helper.BeginTask("Running flatpak info " + pkg.Name)
+		stdout, stderr, err := runner.RunWithExternalError(
+			ctx,
+			b.runner,
+			types.OperationVerify,
+			"flatpak",
+			"flatpak",
+			b.command("info", pkg.Name)...,
+		)
+		helper.EndTask()
+
+		at := types.Attestation{Ref: types.PackageRef{Name: pkg.Name, Kind: "app"}}
+		switch {
+		case err == nil:
+			at.Installed = true
+			info := parseInfoFields(stdout)
+			at.Version, at.Source, at.Digest = info["Version"], info["Origin"], info["Commit"]
+			at.Signed = remotes[at.Source]
+			if !at.Signed {
+				at.Findings = append(at.Findings, "remote "+at.Source+" does not verify GPG signatures")
+			}
+		case strings.Contains(stderr, "not installed"):
+			if found, ok := b.offered(ctx, pkg.Name, b.archArgs()...); ok && !found {
+				at.Status = types.AttestationNotFound
+				break
+			}
+			at.Signed = len(remotes) > 0
+			for _, name := range slices.Sorted(maps.Keys(remotes)) {
+				if !remotes[name] {
+					at.Signed = false
+					at.Findings = append(at.Findings, "remote "+name+" does not verify GPG signatures")
+				}
+			}
+		default:
+			helper.Error("Verify failed: " + err.Error())
+			return nil, err
+		}
+		at.Settle()
+		attestations = append(attestations, at)
+	}
+
+	helper.Info("Verify completed")
+	return attestations, nil
+}
+
+// parseRemotes reads `flatpak remotes --columns=name,options` output into
+// whether each remote verifies GPG signatures. Disabled remotes provide
+// nothing and are left out.
+func parseRemotes(stdout string) map[string]bool {
+	remotes := make(map[string]bool)
+	for _, line := range strings.Split(stdout, "\n") {
+		name, options, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if name == "" || name == "Name" {
+			continue
+		}
+		opts := strings.Split(options, ",")
+		if slices.Contains(opts, "disabled") {
+			continue
+		}
+		// The same remote may be configured in several installations
+		verified, seen := remotes[name]
+		remotes[name] = !slices.Contains(opts, "no-gpg-verify") && (verified || !seen)
+	}
+	return remotes
+}
+
+// parseInfoFields reads the "Key: value" lines of `flatpak info` output.
+func parseInfoFields(stdout string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(stdout, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
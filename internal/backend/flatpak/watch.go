@@ -0,0 +1,33 @@
+package flatpak
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ChangeToken returns a token that changes whenever packages are installed,
+// removed or updated: the modification times of the .changed file flatpak
+// touches in an installation after every transaction, whether pm, the
+// flatpak CLI or a software center ran it. Reading them runs nothing, so
+// installations can be checked often. Custom installations are not looked
+// in, so with one set ChangeToken fails with ErrNotSupported.
+func (b *Backend) ChangeToken(ctx context.Context) (string, error) {
+	roots := b.installRoots()
+	if len(roots) == 0 {
+		return "", types.ErrNotSupported
+	}
+	var stamps []string
+	for _, root := range roots {
+		stamp := "-"
+		if info, err := os.Stat(filepath.Join(root, ".changed")); err == nil {
+			stamp = strconv.FormatInt(info.ModTime().UnixNano(), 10)
+		}
+		stamps = append(stamps, stamp)
+	}
+	return strings.Join(stamps, ","), ctx.Err()
+}
@@ -0,0 +1,80 @@
+package snap
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// notFoundPattern matches snap's message for an unknown snap, e.g.
+// `error: snap "nosuchthing" not found`; the group is the requested name.
+var notFoundPattern = regexp.MustCompile(`snap "([^"]+)" not found`)
+
+// packageNotFound returns a *types.PackageNotFoundError wrapping err if
+// snap's output says one of pkgs does not exist, and err otherwise. snap
+// does not suggest alternatives.
+func packageNotFound(err error, stdout, stderr string, pkgs []types.PackageRef) error {
+	if err == nil {
+		return nil
+	}
+	m := notFoundPattern.FindStringSubmatch(stderr + "\n" + stdout)
+	if m == nil {
+		return err
+	}
+	return &types.PackageNotFoundError{Ref: types.RefNamed(pkgs, m[1]), Backend: "snap", Err: err}
+}
+
+// alreadyInstalledPattern matches snap's notice for a snap that is already
+// installed, e.g. `snap "hello" is already installed, see 'snap help
+// refresh'`.
+var alreadyInstalledPattern = regexp.MustCompile(`snap "([^"]+)" is already installed`)
+
+// notInstalledPattern matches snap's notice for removing a snap that is
+// not installed, e.g. `snap "hello" is not installed`.
+var notInstalledPattern = regexp.MustCompile(`snap "([^"]+)" is not installed`)
+
+// alreadyInstalled returns a *types.AlreadyInstalledError wrapping cause
+// for each of pkgs snap reports as already installed.
+func alreadyInstalled(output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, alreadyInstalledPattern) {
+		errs = append(errs, &types.AlreadyInstalledError{Ref: ref, Backend: "snap", Err: cause})
+	}
+	return errs
+}
+
+// notInstalled returns a *types.NotInstalledError wrapping cause for each
+// of pkgs snap reports as not installed.
+func notInstalled(op types.Operation, output string, pkgs []types.PackageRef, cause error) []error {
+	var errs []error
+	for _, ref := range types.MatchRefs(output, pkgs, notInstalledPattern) {
+		errs = append(errs, &types.NotInstalledError{Operation: op, Ref: ref, Backend: "snap", Err: cause})
+	}
+	return errs
+}
+
+// unsupportedArchPattern matches snap's message for a snap built only for
+// other architectures, e.g. `snap "foo" is not available on stable for
+// this architecture (arm64) but exists on other architectures (amd64,
+// i386).`, which snap may wrap. The groups are the requested name, the
+// architecture and the others.
+var unsupportedArchPattern = regexp.MustCompile(`snap "([^"]+)" is not available\s+on\s+\S+\s+for\s+this\s+architecture\s+\(([^)]+)\)\s+but\s+exists\s+on\s+other\s+architectures\s+\(([^)]+)\)`)
+
+// unsupportedArch returns a *types.UnsupportedArchError wrapping err if
+// snap's output says one of pkgs is not built for this architecture, and
+// err otherwise.
+func unsupportedArch(err error, stdout, stderr string, pkgs []types.PackageRef) error {
+	if err == nil {
+		return nil
+	}
+	m := unsupportedArchPattern.FindStringSubmatch(stderr + "\n" + stdout)
+	if m == nil {
+		return err
+	}
+	var available []string
+	for _, arch := range strings.Split(m[3], ",") {
+		available = append(available, strings.TrimSpace(arch))
+	}
+	return &types.UnsupportedArchError{Ref: types.RefNamed(pkgs, m[1]), Backend: "snap", Arch: m[2], Available: available, Err: err}
+}
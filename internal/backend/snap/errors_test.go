@@ -0,0 +1,62 @@
+package snap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestPackageNotFound(t *testing.T) {
+	cause := errors.New("exit status 1")
+	err := packageNotFound(cause, "", `error: snap "nosuchthing" not found`, []types.PackageRef{{Name: "nosuchthing", Channel: "edge"}})
+
+	var pnfErr *types.PackageNotFoundError
+	if !errors.As(err, &pnfErr) {
+		t.Fatalf("Expected *PackageNotFoundError, got %v", err)
+	}
+	if pnfErr.Ref.Name != "nosuchthing" || pnfErr.Ref.Channel != "edge" || pnfErr.Backend != "snap" {
+		t.Errorf("Unexpected error: %+v", pnfErr)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the underlying error to be preserved")
+	}
+
+	if err := packageNotFound(cause, "", `error: cannot communicate with server`, nil); err != cause {
+		t.Errorf("Expected unrelated failure unchanged, got %v", err)
+	}
+}
+
+func TestAlreadyAndNotInstalled(t *testing.T) {
+	pkgs := []types.PackageRef{{Name: "hello"}, {Name: "jq"}}
+
+	skipped := alreadyInstalled(`snap "hello" is already installed, see 'snap help refresh'`, pkgs, nil)
+	if len(skipped) != 1 || !types.IsAlreadyInstalled(skipped[0]) {
+		t.Errorf("alreadyInstalled() = %v", skipped)
+	}
+
+	skipped = notInstalled(types.OperationUninstall, "snap \"hello\" is not installed\njq removed\n", pkgs, nil)
+	if len(skipped) != 1 || !types.IsNotInstalled(skipped[0]) {
+		t.Errorf("notInstalled() = %v", skipped)
+	}
+	if rest := types.Unskipped(pkgs, skipped); len(rest) != 1 || rest[0].Name != "jq" {
+		t.Errorf("Unskipped() = %v, want [jq]", rest)
+	}
+}
+
+func TestUnsupportedArch(t *testing.T) {
+	cause := errors.New("exit status 1")
+	stderr := "error: snap \"spotify\" is not available on stable for this architecture (arm64) but\n       exists on other architectures (amd64, i386).\n"
+	err := unsupportedArch(cause, "", stderr, []types.PackageRef{{Name: "spotify"}})
+
+	var archErr *types.UnsupportedArchError
+	if !errors.As(err, &archErr) {
+		t.Fatalf("Expected *UnsupportedArchError, got %v", err)
+	}
+	if archErr.Ref.Name != "spotify" || archErr.Arch != "arm64" || len(archErr.Available) != 2 || archErr.Available[1] != "i386" {
+		t.Errorf("Unexpected error: %+v", archErr)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the underlying error to be preserved")
+	}
+}
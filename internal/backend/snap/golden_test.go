@@ -0,0 +1,28 @@
+package snap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/golden"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestGolden(t *testing.T) {
+	ctx := context.Background()
+	t.Run("list", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/list", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).ListInstalled(ctx, types.ListOptions{})
+		})
+	})
+	t.Run("find", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/find", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).Search(ctx, "firefox", types.SearchOptions{IncludeDescriptions: true})
+		})
+	})
+	t.Run("refresh", func(t *testing.T) {
+		golden.Replay(t, "testdata/golden/refresh", func(t *testing.T, f golden.Fixture) (any, error) {
+			return New(nil, f, nil).Upgrade(ctx, types.UpgradeOptions{})
+		})
+	})
+}
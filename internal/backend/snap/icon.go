@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// IconSource implements IconFetcher with the icon among the snap's store
+// media, which snapd reports for installed snaps and /v2/find for the
+// others. Snaps the store does not know, such as sideloaded ones, have
+// none. Store icons come in one size, so opts.Size is ignored.
+func (b *Backend) IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error) {
+	if err := validate(types.OperationIcon, []types.PackageRef{pkg}); err != nil {
+		return types.IconSource{}, err
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationIcon, b.progress, opts.Progress)
+	helper.BeginAction("Icon")
+	defer helper.EndAction()
+
+	helper.BeginTask("Querying snapd for " + pkg.Name)
+	info, err := b.snap(ctx, types.OperationIcon, pkg.Name)
+	if err == nil && iconURL(info) == "" {
+		info, err = b.findSnap(ctx, types.OperationIcon, pkg.Name)
+	}
+	helper.EndTask()
+
+	if err != nil {
+		helper.Error("Icon failed: " + err.Error())
+		return types.IconSource{}, err
+	}
+	return types.IconSource{URL: iconURL(info)}, nil
+}
+
+// iconURL returns the URL of the icon among info's media, or "" if info
+// is nil or has none.
+func iconURL(info *snapInfo) string {
+	if info == nil {
+		return ""
+	}
+	for _, m := range info.Media {
+		if m.Type == "icon" {
+			return m.URL
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_IconSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path + "?" + r.URL.RawQuery {
+		case "/v2/snaps/firefox?":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"firefox","media":[{"type":"screenshot","url":"https://dashboard.snapcraft.io/site_media/appmedia/shot.png"},{"type":"icon","url":"https://dashboard.snapcraft.io/site_media/appmedia/firefox.png"}]}}`)
+		case "/v2/snaps/mytool?":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"mytool","revision":"x1"}}`)
+		case "/v2/find?name=spotify":
+			_, _ = io.WriteString(w, `{"type":"sync","result":[{"name":"spotify","media":[{"type":"icon","url":"https://dashboard.snapcraft.io/site_media/appmedia/spotify.png"}]}]}`)
+		case "/v2/find?name=mytool":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","result":{"message":"snap not found","kind":"snap-not-found"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","result":{"message":"snap not installed","kind":"snap-not-found"}}`)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), &runner.FakeRunner{}, nil)
+	b.SetBaseURL(server.URL)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"firefox", "https://dashboard.snapcraft.io/site_media/appmedia/firefox.png"},
+		{"spotify", "https://dashboard.snapcraft.io/site_media/appmedia/spotify.png"},
+		{"mytool", ""},
+	}
+	for _, tt := range tests {
+		src, err := b.IconSource(context.Background(), types.PackageRef{Name: tt.name}, types.IconOptions{Size: 64})
+		if err != nil {
+			t.Fatalf("IconSource(%s) error = %v", tt.name, err)
+		}
+		if src.URL != tt.want || src.Path != "" {
+			t.Errorf("IconSource(%s) = %+v, want %s", tt.name, src, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package snap
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// LaunchCommand implements Launcher. snapd describes the installed snap's
+// apps; the one named after the snap is started with `snap run <snap>`,
+// and otherwise the first one that is not a service, with
+// `snap run <snap>.<app>`. Snaps without such apps, like bases and the
+// core snaps, cannot be launched.
+func (b *Backend) LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error) {
+	if b.runner == nil {
+		return types.LaunchCommand{}, types.ErrNotSupported
+	}
+
+	if err := validate(types.OperationLaunch, []types.PackageRef{pkg}); err != nil {
+		return types.LaunchCommand{}, err
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationLaunch, b.progress, opts.Progress)
+	helper.BeginAction("Launch")
+	defer helper.EndAction()
+
+	helper.BeginTask("Querying snapd for " + pkg.Name)
+	info, err := b.snap(ctx, types.OperationLaunch, pkg.Name)
+	helper.EndTask()
+
+	if err == nil && info == nil {
+		err = &types.NotInstalledError{Operation: types.OperationLaunch, Ref: pkg, Backend: "snap"}
+	}
+	if err != nil {
+		helper.Error("Launch failed: " + err.Error())
+		return types.LaunchCommand{}, err
+	}
+
+	app := launchApp(info)
+	if app == "" {
+		err := &types.NotSupportedError{
+			Operation: types.OperationLaunch,
+			Backend:   "snap",
+			Reason:    pkg.Name + " has no applications to run",
+		}
+		helper.Error("Launch failed: " + err.Error())
+		return types.LaunchCommand{}, err
+	}
+	helper.Info("Launching " + app)
+	return types.LaunchCommand{Name: "snap", Args: append([]string{"run", app}, opts.Args...)}, nil
+}
+
+// launchApp returns the name `snap run` starts info's main app by, or ""
+// if it has only services.
+func launchApp(info *snapInfo) string {
+	first := ""
+	for _, app := range info.Apps {
+		if app.Daemon != "" {
+			continue
+		}
+		if app.Name == info.Name {
+			return info.Name
+		}
+		if first == "" {
+			first = info.Name + "." + app.Name
+		}
+	}
+	return first
+}
@@ -0,0 +1,56 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_LaunchCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps/firefox":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"firefox","apps":[{"snap":"firefox","name":"geckodriver"},{"snap":"firefox","name":"firefox","desktop-file":"/var/lib/snapd/desktop/applications/firefox_firefox.desktop"}]}}`)
+		case "/v2/snaps/mytool":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"mytool","apps":[{"snap":"mytool","name":"daemon","daemon":"simple"},{"snap":"mytool","name":"ctl"}]}}`)
+		case "/v2/snaps/core22":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"core22"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","result":{"message":"snap not installed","kind":"snap-not-found"}}`)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), &runner.FakeRunner{}, nil)
+	b.SetBaseURL(server.URL)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"firefox", "snap run firefox --private-window"},
+		{"mytool", "snap run mytool.ctl --private-window"},
+	}
+	for _, tt := range tests {
+		cmd, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: tt.name}, types.LaunchOptions{Args: []string{"--private-window"}})
+		if err != nil {
+			t.Fatalf("LaunchCommand(%s) error = %v", tt.name, err)
+		}
+		if got := cmd.Name + " " + strings.Join(cmd.Args, " "); got != tt.want {
+			t.Errorf("LaunchCommand(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "core22"}, types.LaunchOptions{}); !types.IsNotSupported(err) {
+		t.Errorf("LaunchCommand(core22) error = %v, want NotSupportedError", err)
+	}
+	if _, err := b.LaunchCommand(context.Background(), types.PackageRef{Name: "spotify"}, types.LaunchOptions{}); !types.IsNotInstalled(err) {
+		t.Errorf("LaunchCommand(spotify) error = %v, want NotInstalledError", err)
+	}
+}
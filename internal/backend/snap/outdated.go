@@ -0,0 +1,61 @@
+package snap
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ListOutdated implements OutdatedLister using the snapd API:
+// /v2/find?select=refresh asks the store which installed snaps have
+// updates on the channels they track, and /v2/snaps gives their installed
+// versions.
+func (b *Backend) ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error) {
+	helper := types.NewOperationProgressHelper("snap", types.OperationListOutdated, b.progress, opts.Progress)
+	helper.BeginAction("ListOutdated")
+	defer helper.EndAction()
+
+	helper.BeginTask("Querying snapd for refreshes")
+	var updates []snapInfo
+	_, err := b.getResult(ctx, types.OperationListOutdated, "/v2/find?select=refresh", &updates)
+	helper.EndTask()
+	if err != nil {
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+
+	outdated := []types.OutdatedPackage{}
+	if len(updates) == 0 {
+		helper.Info("ListOutdated completed")
+		return outdated, nil
+	}
+
+	helper.BeginTask("Querying snapd for installed snaps")
+	var installed []snapInfo
+	_, err = b.getResult(ctx, types.OperationListOutdated, "/v2/snaps", &installed)
+	helper.EndTask()
+	if err != nil {
+		helper.Error("ListOutdated failed: " + err.Error())
+		return nil, err
+	}
+	current := make(map[string]snapInfo, len(installed))
+	for _, s := range installed {
+		current[s.Name] = s
+	}
+
+	for _, s := range updates {
+		outdated = append(outdated, types.OutdatedPackage{
+			Ref: types.PackageRef{
+				Name:      s.Name,
+				Namespace: s.Publisher.Username,
+				Channel:   current[s.Name].TrackingChannel,
+				Kind:      "snap",
+			},
+			InstalledVersion: current[s.Name].Version,
+			AvailableVersion: s.Version,
+		})
+	}
+
+	helper.Info("ListOutdated completed")
+	return outdated, nil
+}
@@ -0,0 +1,67 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_ListOutdated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/find":
+			if r.URL.Query().Get("select") != "refresh" {
+				t.Errorf("find query = %q, want select=refresh", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"type":"sync","status-code":200,"result":[{"name":"firefox","version":"125.0.1-1","revision":"4173","channel":"stable","publisher":{"username":"mozilla"}}]}`)
+		case "/v2/snaps":
+			_, _ = io.WriteString(w, `{"type":"sync","status-code":200,"result":[`+
+				`{"name":"firefox","version":"124.0.1-1","tracking-channel":"latest/stable","publisher":{"username":"mozilla"}},`+
+				`{"name":"core22","version":"20240111","tracking-channel":"latest/stable","publisher":{"username":"canonical"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+
+	got, err := b.ListOutdated(context.Background(), types.OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	want := types.OutdatedPackage{
+		Ref:              types.PackageRef{Name: "firefox", Namespace: "mozilla", Channel: "latest/stable", Kind: "snap"},
+		InstalledVersion: "124.0.1-1",
+		AvailableVersion: "125.0.1-1",
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ListOutdated() = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestBackend_UpgradePackages(t *testing.T) {
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{{
+		Name:   "snap",
+		Args:   `^refresh firefox$`,
+		Stdout: "firefox 125.0.1-1 from Mozilla✓ refreshed\n",
+	}}}
+	b := New(nil, fake, nil)
+
+	res, err := b.Upgrade(context.Background(), types.UpgradeOptions{Packages: []types.PackageRef{{Name: "firefox"}}})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(res.PackagesChanged) != 1 || res.PackagesChanged[0].Name != "firefox" {
+		t.Errorf("Upgrade() = %+v, want firefox refreshed", res)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,48 @@
+package snap
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/frostyard/pm/internal/golden"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestSearchExact(t *testing.T) {
+	stdout, err := os.ReadFile("testdata/golden/find/2.61-en_US.stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := New(nil, golden.Fixture{Stdout: string(stdout)}, nil)
+
+	results, err := b.Search(context.Background(), "firefox", types.SearchOptions{Exact: true})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "firefox" {
+		t.Errorf("Search(firefox, Exact) = %+v, want only firefox", results)
+	}
+}
+
+func TestSearchIncludeDescriptions(t *testing.T) {
+	stdout, err := os.ReadFile("testdata/golden/find/2.61-en_US.stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := New(nil, golden.Fixture{Stdout: string(stdout)}, nil)
+
+	for _, tt := range []struct {
+		descriptions bool
+		want         int
+	}{{false, 2}, {true, 3}} {
+		results, err := b.Search(context.Background(), "firefox", types.SearchOptions{IncludeDescriptions: tt.descriptions})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		// librewolf matches only on its summary
+		if len(results) != tt.want {
+			t.Errorf("Search(firefox, IncludeDescriptions %v) = %+v, want %d results", tt.descriptions, results, tt.want)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package snap
 
 import (
 	"context"
+	"errors"
 	"net"
 	"net/http"
 	"strings"
 
+	"github.com/frostyard/pm/internal/rank"
 	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
@@ -13,29 +15,84 @@ import (
 // Backend implements the snap backend.
 type Backend struct {
 	httpClient *http.Client
+	baseURL    string
 	runner     runner.Runner
 	progress   types.ProgressReporter
+
+	// remoteCommands is set when the runner may run snap elsewhere than
+	// the snapd the client reaches.
+	remoteCommands bool
+}
+
+// DefaultSocket is the path of the snapd Unix socket.
+const DefaultSocket = "/run/snapd.socket"
+
+// defaultBaseURL is the base URL of requests sent over the Unix socket;
+// its host is ignored.
+const defaultBaseURL = "http://localhost"
+
+// NewSocketTransport returns an HTTP transport that connects to the snapd
+// Unix socket at path.
+func NewSocketTransport(path string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}
+}
+
+// Endpoint returns the transport and base URL for reaching snapd at
+// endpoint. An http:// or https:// URL is used as is and reached through
+// remote (http.DefaultTransport if nil); anything else is the path of a
+// Unix socket. An empty endpoint means DefaultSocket.
+func Endpoint(endpoint string, remote http.RoundTripper) (http.RoundTripper, string) {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		if remote == nil {
+			remote = http.DefaultTransport
+		}
+		return remote, strings.TrimSuffix(endpoint, "/")
+	}
+	if endpoint == "" {
+		endpoint = DefaultSocket
+	}
+	return NewSocketTransport(endpoint), defaultBaseURL
+}
+
+// withPTY runs snap on a pseudo-terminal: without one, snap prints nothing
+// until a change completes, so long installs would show no progress.
+func withPTY(ctx context.Context) context.Context {
+	return runner.WithPTY(ctx)
 }
 
 // New creates a new snap backend.
 func New(httpClient *http.Client, r runner.Runner, progress types.ProgressReporter) *Backend {
 	if httpClient == nil {
 		// Create an HTTP client that connects to the snapd Unix socket
-		httpClient = &http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-					return (&net.Dialer{}).DialContext(ctx, "unix", "/run/snapd.socket")
-				},
-			},
-		}
+		httpClient = &http.Client{Transport: NewSocketTransport(DefaultSocket)}
 	}
 	return &Backend{
 		httpClient: httpClient,
+		baseURL:    defaultBaseURL,
 		runner:     r,
 		progress:   progress,
 	}
 }
 
+// SetRemoteCommands records that the runner may run snap on another
+// machine or in a container, whose installed snaps the snapd API client
+// cannot see. Install and Uninstall then leave it to the snap command to
+// report packages installed or missing already, instead of querying snapd
+// first.
+func (b *Backend) SetRemoteCommands(remote bool) {
+	b.remoteCommands = remote
+}
+
+// SetBaseURL sets the base URL of snapd API requests. It must match the
+// client's transport; see Endpoint.
+func (b *Backend) SetBaseURL(url string) {
+	b.baseURL = url
+}
+
 // Available checks if snapd is available by querying /v2/system-info.
 func (b *Backend) Available(ctx context.Context) (bool, error) {
 	if b.runner == nil {
@@ -43,7 +100,7 @@ func (b *Backend) Available(ctx context.Context) (bool, error) {
 	}
 
 	// Query the snapd API via Unix socket
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/v2/system-info", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/v2/system-info", nil)
 	if err != nil {
 		return false, &types.NotAvailableError{Backend: "snap", Reason: "failed to create request: " + err.Error()}
 	}
@@ -57,37 +114,85 @@ func (b *Backend) Available(ctx context.Context) (bool, error) {
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		return true, nil
 	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return false, &types.PermissionDeniedError{
+			Backend: "snap",
+			Reason:  "snapd API returned " + resp.Status,
+			Hint:    runner.SuggestEscalation("snap", ""),
+			Err: &types.ExternalFailureError{
+				Backend: "snap",
+				Err:     errors.New("snapd API returned " + resp.Status),
+				Payload: runner.ReadPayload(ctx, resp),
+			},
+		}
+	}
 
 	return false, &types.NotAvailableError{Backend: "snap", Reason: "snapd API returned non-2xx status"}
 }
 
-// Capabilities returns snap capabilities.
+// Capabilities asks snapd for /v2/system-info. Operations are unsupported,
+// with the reason in Notes, when snapd cannot be reached. Notes name the
+// snapd version, and Install's notes say when strict confinement or classic
+// snaps are unavailable on this system.
 func (b *Backend) Capabilities(ctx context.Context) ([]types.Capability, error) {
-	// Snap backend supports operations when runner is available
-	hasRunner := b.runner != nil
+	info, reason := b.probe(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cli := func(op types.Operation, notes string) types.Capability {
+		if info == nil {
+			return types.Capability{Operation: op, Notes: reason}
+		}
+		return types.Capability{Operation: op, Supported: true, Notes: notes + " (snapd " + info.Version + ")"}
+	}
+	install := cli(types.OperationInstall, "via snap install CLI")
+	if info != nil {
+		for _, limit := range info.limits() {
+			install.Notes += "; " + limit
+		}
+	}
 	return []types.Capability{
-		{Operation: types.OperationSearch, Supported: hasRunner, Notes: "via snap find CLI"},
-		{Operation: types.OperationUpdateMetadata, Supported: hasRunner, Notes: "via snap refresh CLI"},
-		{Operation: types.OperationUpgradePackages, Supported: hasRunner, Notes: "via snap refresh CLI"},
-		{Operation: types.OperationInstall, Supported: hasRunner, Notes: "via snap install CLI"},
-		{Operation: types.OperationUninstall, Supported: hasRunner, Notes: "via snap remove CLI"},
-		{Operation: types.OperationListInstalled, Supported: hasRunner, Notes: "via snap list CLI"},
+		cli(types.OperationSearch, "via snap find CLI"),
+		cli(types.OperationUpdateMetadata, "via snap refresh CLI"),
+		cli(types.OperationUpgradePackages, "via snap refresh CLI"),
+		install,
+		cli(types.OperationUninstall, "via snap remove CLI"),
+		cli(types.OperationListInstalled, "via snap list CLI"),
+		cli(types.OperationListOutdated, "via snapd snaps and find API"),
+		cli(types.OperationQuery, "via snapd snaps API"),
+		cli(types.OperationVerify, "via snapd snaps and find API"),
+		cli(types.OperationLaunch, "via snap run CLI; snaps with applications only, not bases or services"),
+		cli(types.OperationIcon, "from snap store media"),
+		cli(types.OperationWatch, "via snapd changes API and snap list CLI"),
 	}, nil
 }
 
+// probe fetches snapd's system information, or returns nil and the reason
+// snap cannot be used.
+func (b *Backend) probe(ctx context.Context) (*systemInfo, string) {
+	if b.runner == nil {
+		return nil, "no runner configured"
+	}
+	info, err := b.systemInfo(ctx)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return info, ""
+}
+
 // Update implements Updater using `snap refresh --list`.
 func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error) {
 	if b.runner == nil {
 		return types.UpdateResult{}, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("snap", types.OperationUpdateMetadata, b.progress, opts.Progress)
 	helper.BeginAction("Update")
 	defer helper.EndAction()
 
 	helper.BeginTask("Checking for snap updates")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(ctx, helper),
 		b.runner,
 		types.OperationUpdateMetadata,
 		"snap",
@@ -109,24 +214,32 @@ func (b *Backend) Update(ctx context.Context, opts types.UpdateOptions) (types.U
 	return types.UpdateResult{Changed: changed}, nil
 }
 
-// Upgrade implements Upgrader using `snap refresh`.
+// Upgrade implements Upgrader using `snap refresh`, naming opts.Packages
+// if given.
 func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error) {
 	if b.runner == nil {
 		return types.UpgradeResult{}, types.ErrNotSupported
 	}
+	if err := validate(types.OperationUpgradePackages, opts.Packages); err != nil {
+		return types.UpgradeResult{}, err
+	}
+	args := []string{"refresh"}
+	for _, pkg := range opts.Packages {
+		args = append(args, pkg.Name)
+	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("snap", types.OperationUpgradePackages, b.progress, opts.Progress)
 	helper.BeginAction("Upgrade")
 	defer helper.EndAction()
 
 	helper.BeginTask("Running snap refresh")
 	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+		runner.StreamToProgress(withPTY(ctx), helper),
 		b.runner,
 		types.OperationUpgradePackages,
 		"snap",
 		"snap",
-		"refresh",
+		args...,
 	)
 	helper.EndTask()
 
@@ -139,28 +252,24 @@ func (b *Backend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types
 	var packagesChanged []types.PackageRef
 	changed := false
 
-	// Look for lines indicating refreshes
+	// Look for lines indicating refreshes, such as
+	// "<snap-name> <version> from <publisher> refreshed"; a base or
+	// dependency pulled in by a refresh is reported as "installed"
 	lines := strings.Split(stdout, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		// Snap shows refreshed snaps like "<snap-name> <version> from <publisher> refreshed"
-		if strings.Contains(line, "refreshed") || strings.Contains(line, "installed") {
-			changed = true
-			// Extract snap name (first field)
-			fields := strings.Fields(line)
-			if len(fields) >= 1 {
-				snapName := fields[0]
-				packagesChanged = append(packagesChanged, types.PackageRef{
-					Name: snapName,
-					Kind: "snap",
-				})
-			}
+		if !strings.HasSuffix(line, " refreshed") && !strings.HasSuffix(line, " installed") {
+			continue
 		}
-	}
-
-	// Also check for "All snaps up to date" message
-	if strings.Contains(stdout, "All snaps up to date") {
-		changed = false
+		snapName, _, _ := strings.Cut(line, " ")
+		if !snapNamePattern.MatchString(snapName) {
+			continue
+		}
+		changed = true
+		packagesChanged = append(packagesChanged, types.PackageRef{
+			Name: snapName,
+			Kind: "snap",
+		})
 	}
 
 	if changed {
@@ -185,7 +294,23 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 		return types.InstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationInstall, pkgs); err != nil {
+		return types.InstallResult{}, err
+	}
+
+	// Leave out the packages Query finds installed already. If it fails, or
+	// snapd is not where snap runs, snap install still reports them.
+	var queried []error
+	if !b.remoteCommands {
+		if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+			pkgs, queried = types.SkipInstalled("snap", pkgs, installed)
+		}
+	}
+	if len(pkgs) == 0 {
+		return types.InstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationInstall, b.progress, opts.Progress)
 	helper.BeginAction("Install")
 	defer helper.EndAction()
 
@@ -197,8 +322,8 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	}
 
 	helper.BeginTask("Running snap install")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(withPTY(ctx), helper),
 		b.runner,
 		types.OperationInstall,
 		"snap",
@@ -208,9 +333,13 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	helper.EndTask()
 
 	if err != nil {
+		err = unsupportedArch(packageNotFound(err, stdout, stderr, pkgs), stdout, stderr, pkgs)
+		skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Install failed: " + err.Error())
-		return types.InstallResult{}, err
+		return types.InstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := alreadyInstalled(stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were installed
 	var installed []types.PackageRef
@@ -220,6 +349,9 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	lines := strings.Split(stdout, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
+		if strings.Contains(line, "already installed") {
+			continue
+		}
 		if strings.Contains(line, "installed") {
 			changed = true
 			// Try to extract snap name from the line
@@ -234,7 +366,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 
 	// If we couldn't parse specific packages but the command succeeded, mark all as installed
 	if changed && len(installed) == 0 {
-		installed = pkgs
+		installed = types.Unskipped(pkgs, skipped)
 	}
 
 	if changed {
@@ -246,6 +378,7 @@ func (b *Backend) Install(ctx context.Context, pkgs []types.PackageRef, opts typ
 	return types.InstallResult{
 		Changed:           changed,
 		PackagesInstalled: installed,
+		Skipped:           append(queried, skipped...),
 	}, nil
 }
 
@@ -259,7 +392,23 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 		return types.UninstallResult{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	if err := validate(types.OperationUninstall, pkgs); err != nil {
+		return types.UninstallResult{}, err
+	}
+
+	// Leave out the packages Query finds not installed. If it fails, or
+	// snapd is not where snap runs, snap remove still reports them.
+	var queried []error
+	if !b.remoteCommands {
+		if installed, err := b.Query(ctx, pkgs, types.QueryOptions{Progress: opts.Progress}); err == nil {
+			pkgs, queried = types.SkipNotInstalled("snap", pkgs, installed)
+		}
+	}
+	if len(pkgs) == 0 {
+		return types.UninstallResult{Skipped: queried}, nil
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationUninstall, b.progress, opts.Progress)
 	helper.BeginAction("Uninstall")
 	defer helper.EndAction()
 
@@ -271,8 +420,8 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	}
 
 	helper.BeginTask("Running snap remove")
-	stdout, _, err := runner.RunWithExternalError(
-		ctx,
+	stdout, stderr, err := runner.RunWithExternalError(
+		runner.StreamToProgress(withPTY(ctx), helper),
 		b.runner,
 		types.OperationUninstall,
 		"snap",
@@ -282,9 +431,12 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	helper.EndTask()
 
 	if err != nil {
+		skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
+		err = types.SkippedFailure(err, pkgs, skipped)
 		helper.Error("Uninstall failed: " + err.Error())
-		return types.UninstallResult{}, err
+		return types.UninstallResult{Skipped: append(queried, skipped...)}, err
 	}
+	skipped := notInstalled(types.OperationUninstall, stderr+"\n"+stdout, pkgs, nil)
 
 	// Check if packages were uninstalled
 	var uninstalled []types.PackageRef
@@ -308,7 +460,7 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 
 	// If we couldn't parse specific packages but the command succeeded, mark all as uninstalled
 	if changed && len(uninstalled) == 0 {
-		uninstalled = pkgs
+		uninstalled = types.Unskipped(pkgs, skipped)
 	}
 
 	if changed {
@@ -320,6 +472,7 @@ func (b *Backend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts t
 	return types.UninstallResult{
 		Changed:             changed,
 		PackagesUninstalled: uninstalled,
+		Skipped:             append(queried, skipped...),
 	}, nil
 }
 
@@ -333,10 +486,29 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return []types.PackageRef{}, nil
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("snap", types.OperationSearch, b.progress, opts.Progress)
 	helper.BeginAction("Search")
 	defer helper.EndAction()
 
+	// Pass results on as snap prints them, for callers that render results
+	// incrementally. The first line of stdout is the table header.
+	stream := types.NewResultStream(opts)
+	if opts.OnResult != nil {
+		header := true
+		ctx = runner.WithLineFunc(ctx, func(line string, stderr bool) {
+			if stderr {
+				return
+			}
+			if header {
+				header = false
+				return
+			}
+			if ref, ok := findResult(line, query, opts); ok {
+				stream.Send(ref)
+			}
+		})
+	}
+
 	helper.BeginTask("Running snap find")
 	stdout, _, err := runner.RunWithExternalError(
 		ctx,
@@ -354,10 +526,6 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		return nil, err
 	}
 
-	// Parse search results
-	// Snap find output format:
-	// Name       Version    Publisher    Notes  Summary
-	// firefox    123.0      mozilla✓     -      Mozilla Firefox web browser
 	var results []types.PackageRef
 	lines := strings.Split(stdout, "\n")
 
@@ -366,35 +534,41 @@ func (b *Backend) Search(ctx context.Context, query string, opts types.SearchOpt
 		if i == 0 {
 			continue // Skip header
 		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse fields - split by whitespace
-		fields := strings.Fields(line)
-		if len(fields) >= 1 {
-			snapName := fields[0]
-
-			results = append(results, types.PackageRef{
-				Name: snapName,
-				Kind: "snap",
-			})
+		if ref, ok := findResult(line, query, opts); ok {
+			results = append(results, ref)
 		}
 	}
+	stream.Finish(results)
 
 	helper.Info("Search completed")
 	return results, nil
 }
 
+// findResult parses a row of `snap find` output:
+//
+//	Name       Version    Publisher    Notes  Summary
+//	firefox    123.0      mozilla✓     -      Mozilla Firefox web browser
+//
+// snap find also matches summaries and descriptions, so unless
+// opts.IncludeDescriptions is set only rows whose name matches are kept.
+func findResult(line, query string, opts types.SearchOptions) (types.PackageRef, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !snapNamePattern.MatchString(fields[0]) {
+		return types.PackageRef{}, false
+	}
+	if opts.Exact && fields[0] != query || !opts.IncludeDescriptions && !rank.Matches(fields[0], query, opts.Fuzzy) {
+		return types.PackageRef{}, false
+	}
+	return types.PackageRef{Name: fields[0], Kind: "snap"}, true
+}
+
 // ListInstalled implements Lister using `snap list`.
 func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
 	if b.runner == nil {
 		return nil, types.ErrNotSupported
 	}
 
-	helper := types.NewProgressHelper(b.progress, opts.Progress)
+	helper := types.NewOperationProgressHelper("snap", types.OperationListInstalled, b.progress, opts.Progress)
 	helper.BeginAction("ListInstalled")
 	defer helper.EndAction()
 
@@ -431,7 +605,7 @@ func (b *Backend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]
 
 		// Split by whitespace
 		fields := strings.Fields(line)
-		if len(fields) >= 2 {
+		if len(fields) >= 2 && snapNamePattern.MatchString(fields[0]) {
 			snapName := fields[0]
 			version := fields[1]
 
@@ -2,8 +2,13 @@ package snap
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/frostyard/pm/internal/runner"
 	"github.com/frostyard/pm/internal/types"
 )
 
@@ -89,3 +94,30 @@ func TestBackend_EmptyMethods(t *testing.T) {
 		}
 	})
 }
+
+// roundTripFunc serves HTTP requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBackend_AvailableForbidden(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Status:     "403 Forbidden",
+			Body:       io.NopCloser(strings.NewReader(`{"type":"error","status-code":403,"result":{"message":"access denied","kind":"login-required"}}`)),
+		}, nil
+	})}
+	b := New(client, runner.NewRealRunner(), nil)
+
+	available, err := b.Available(context.Background())
+	if available || !types.IsPermissionDenied(err) {
+		t.Errorf("Available() = %v, %v; want a PermissionDeniedError", available, err)
+	}
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Payload["kind"] != "login-required" || extErr.Payload["message"] != "access denied" {
+		t.Errorf("Expected the snapd error payload, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package snap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// snapInfo is an installed snap as reported by the snapd API.
+type snapInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Publisher struct {
+		Username string `json:"username"`
+		// Validation is "verified" or "starred" for publishers whose
+		// identity the store has checked, and "unproven" otherwise.
+		Validation string `json:"validation"`
+	} `json:"publisher"`
+	TrackingChannel string    `json:"tracking-channel"`
+	InstalledSize   int64     `json:"installed-size"`
+	InstallDate     time.Time `json:"install-date"`
+	Media           []struct {
+		// Type is "icon", "screenshot", "banner" or "video".
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"media"`
+	Apps []struct {
+		Name string `json:"name"`
+		// Daemon is the kind of service the app is, such as "simple",
+		// and empty for commands.
+		Daemon string `json:"daemon"`
+	} `json:"apps"`
+}
+
+// Query implements Querier using the snapd API's /v2/snaps/<name>, which
+// looks up only the named snap.
+func (b *Backend) Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error) {
+	if err := validate(types.OperationQuery, pkgs); err != nil {
+		return nil, err
+	}
+
+	installed := []types.InstalledPackage{}
+	if len(pkgs) == 0 {
+		return installed, nil
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationQuery, b.progress, opts.Progress)
+	helper.BeginAction("Query")
+	defer helper.EndAction()
+
+	for _, pkg := range pkgs {
+		helper.BeginTask("Querying snapd for " + pkg.Name)
+		info, err := b.snap(ctx, types.OperationQuery, pkg.Name)
+		helper.EndTask()
+
+		if err != nil {
+			helper.Error("Query failed: " + err.Error())
+			return nil, err
+		}
+		if info == nil {
+			continue
+		}
+		installed = append(installed, types.InstalledPackage{
+			Ref: types.PackageRef{
+				Name:      info.Name,
+				Namespace: info.Publisher.Username,
+				Channel:   info.TrackingChannel,
+				Kind:      "snap",
+			},
+			Version:     info.Version,
+			Size:        info.InstalledSize,
+			InstalledAt: info.InstallDate,
+		})
+	}
+
+	helper.Info("Query completed")
+	return installed, nil
+}
+
+// snap fetches the installed snap called name from snapd. It returns nil
+// and no error if the snap is not installed.
+func (b *Backend) snap(ctx context.Context, op types.Operation, name string) (*snapInfo, error) {
+	var info snapInfo
+	found, err := b.getResult(ctx, op, "/v2/snaps/"+url.PathEscape(name), &info)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// getResult fetches path from snapd and decodes the "result" of its
+// response into v. It reports false and no error if snapd answers 404 Not
+// Found.
+func (b *Backend) getResult(ctx context.Context, op types.Operation, path string, v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+path, nil)
+	if err != nil {
+		return false, &types.ExternalFailureError{
+			Operation: op,
+			Backend:   "snap",
+			Err:       fmt.Errorf("failed to create request: %w", err),
+		}
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return false, runner.ClassifyNetworkError(ctx, &types.ExternalFailureError{
+			Operation: op,
+			Backend:   "snap",
+			Err:       fmt.Errorf("failed to reach snapd API: %w", err),
+		})
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, runner.ClassifyHTTPStatus(resp.StatusCode, &types.ExternalFailureError{
+			Operation: op,
+			Backend:   "snap",
+			Err:       fmt.Errorf("snapd API returned status %d", resp.StatusCode),
+			Payload:   runner.ReadPayload(ctx, resp),
+		})
+	}
+
+	body := struct {
+		Result any `json:"result"`
+	}{Result: v}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, &types.ExternalFailureError{
+			Operation: op,
+			Backend:   "snap",
+			Err:       fmt.Errorf("failed to parse response: %w", err),
+		}
+	}
+	return true, nil
+}
+
+// systemInfo is snapd's description of the system it runs on.
+type systemInfo struct {
+	Version     string `json:"version"`
+	Confinement string `json:"confinement"`
+	Locations   struct {
+		SnapMountDir string `json:"snap-mount-dir"`
+	} `json:"locations"`
+}
+
+// classicDir is where classic snaps expect to be mounted; replaced in tests.
+var classicDir = "/snap"
+
+// limits describes what snaps cannot do on this system: "partial"
+// confinement means strict confinement is unavailable, and classic snaps
+// need their mount directory reachable at classicDir.
+func (i *systemInfo) limits() []string {
+	var limits []string
+	if i.Confinement == "partial" {
+		limits = append(limits, "strict confinement unavailable, snaps run with partial confinement")
+	}
+	if dir := i.Locations.SnapMountDir; dir != "" && dir != classicDir {
+		if _, err := os.Stat(classicDir); err != nil {
+			limits = append(limits, "classic snaps unsupported: "+classicDir+" does not link to "+dir)
+		}
+	}
+	return limits
+}
+
+// systemInfo fetches /v2/system-info from snapd.
+func (b *Backend) systemInfo(ctx context.Context) (*systemInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"/v2/system-info", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach snapd API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("snapd API returned %s", resp.Status)
+	}
+
+	var body struct {
+		Result systemInfo `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &body.Result, nil
+}
@@ -0,0 +1,144 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_Query(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v2/snaps/firefox":
+			_, _ = io.WriteString(w, `{"type":"sync","status-code":200,"result":{"name":"firefox","version":"124.0.1-1","revision":"4090","channel":"latest/stable","tracking-channel":"latest/stable","publisher":{"id":"xyz","username":"mozilla"},"status":"active","installed-size":262774784,"install-date":"2024-03-28T09:14:02.123456789Z"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","status-code":404,"result":{"message":"snap not installed","kind":"snap-not-found"}}`)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+
+	got, err := b.Query(context.Background(), []types.PackageRef{{Name: "firefox"}, {Name: "hello-world"}}, types.QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := types.InstalledPackage{
+		Ref:         types.PackageRef{Name: "firefox", Namespace: "mozilla", Channel: "latest/stable", Kind: "snap"},
+		Version:     "124.0.1-1",
+		Size:        262774784,
+		InstalledAt: time.Date(2024, 3, 28, 9, 14, 2, 123456789, time.UTC),
+	}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Query() = %+v, want [%+v]", got, want)
+	}
+	if len(paths) != 2 {
+		t.Errorf("Requested %v, want one request per snap", paths)
+	}
+}
+
+func TestBackend_InstallSkipsQueried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/snaps/firefox" {
+			_, _ = io.WriteString(w, `{"type":"sync","status-code":200,"result":{"name":"firefox","version":"124.0.1-1","publisher":{"username":"mozilla"}}}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"type":"error","status-code":404,"result":{"message":"snap not installed","kind":"snap-not-found"}}`)
+	}))
+	defer server.Close()
+	fake := &runner.FakeRunner{Script: []runner.FakeCall{
+		{Name: "snap", Args: `^install hello-world$`, Stdout: "hello-world 6.4 from Canonical✓ installed\n"},
+	}}
+	b := New(server.Client(), fake, nil)
+	b.SetBaseURL(server.URL)
+
+	res, err := b.Install(context.Background(), []types.PackageRef{{Name: "firefox"}, {Name: "hello-world"}}, types.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(res.Skipped) != 1 || !types.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Skipped = %v, want firefox", res.Skipped)
+	}
+	if len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "hello-world" {
+		t.Errorf("PackagesInstalled = %v, want [hello-world]", res.PackagesInstalled)
+	}
+
+	// Nothing to remove, so snap remove does not run
+	ures, err := b.Uninstall(context.Background(), []types.PackageRef{{Name: "hello-world"}}, types.UninstallOptions{})
+	if err != nil || ures.Changed || len(ures.Skipped) != 1 || !types.IsNotInstalled(ures.Skipped[0]) {
+		t.Errorf("Uninstall() = %+v, %v, want hello-world skipped", ures, err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBackend_QueryServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+	if _, err := b.Query(context.Background(), []types.PackageRef{{Name: "firefox"}}, types.QueryOptions{}); err == nil {
+		t.Error("Expected an error for a 500 response")
+	}
+}
+
+func TestBackend_CapabilitiesProbe(t *testing.T) {
+	systemInfo := `{"type":"sync","status-code":200,"result":{"series":"16","version":"2.61","confinement":"partial","locations":{"snap-mount-dir":"/var/lib/snapd/snap"}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/system-info" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, systemInfo)
+	}))
+	defer server.Close()
+
+	oldClassicDir := classicDir
+	defer func() { classicDir = oldClassicDir }()
+	classicDir = filepath.Join(t.TempDir(), "snap")
+
+	b := New(server.Client(), &runner.FakeRunner{}, nil)
+	b.SetBaseURL(server.URL)
+	caps, err := b.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	for _, c := range caps {
+		if !c.Supported || !strings.Contains(c.Notes, "snapd 2.61") {
+			t.Errorf("%s = %+v, want supported with the snapd version", c.Operation, c)
+		}
+		if c.Operation == types.OperationInstall {
+			if !strings.Contains(c.Notes, "classic snaps unsupported") || !strings.Contains(c.Notes, "partial confinement") {
+				t.Errorf("Install notes %q should report the classic and confinement limits", c.Notes)
+			}
+		}
+	}
+
+	b.SetBaseURL(server.URL + "/missing")
+	caps, err = b.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	for _, c := range caps {
+		if c.Supported {
+			t.Errorf("%s supported although snapd failed: %+v", c.Operation, c)
+		}
+	}
+}
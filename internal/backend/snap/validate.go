@@ -0,0 +1,68 @@
+package snap
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+var (
+	// snapNamePattern matches snap names: lowercase letters, digits and
+	// single hyphens, at least one letter, no leading or trailing hyphen,
+	// and an optional _instance key for parallel installs.
+	snapNamePattern = regexp.MustCompile(`^(?:[a-z0-9]+-?)*[a-z](?:-?[a-z0-9])*(?:_[a-z0-9]{1,10})?$`)
+
+	// channelPartPattern matches a track or branch of a channel.
+	channelPartPattern = regexp.MustCompile(`^[a-zA-Z0-9](?:[_.-]?[a-zA-Z0-9])*$`)
+)
+
+// risks are the risk levels a channel can name.
+var risks = []string{"stable", "candidate", "beta", "edge"}
+
+// validate checks pkgs before any command is run, so mistakes are reported
+// as a ValidationError rather than as a confusing snap failure.
+func validate(op types.Operation, pkgs []types.PackageRef) error {
+	for _, pkg := range pkgs {
+		name, _, _ := strings.Cut(pkg.Name, "_")
+		if len(name) < 2 || len(name) > 40 || !snapNamePattern.MatchString(pkg.Name) {
+			return invalid(op, pkg, "Name", pkg.Name, "must be 2 to 40 lowercase letters, digits or hyphens")
+		}
+		if pkg.Channel != "" && !validChannel(pkg.Channel) {
+			return invalid(op, pkg, "Channel", pkg.Channel, "must be [track/]risk[/branch] with risk one of "+strings.Join(risks, ", "))
+		}
+	}
+	return nil
+}
+
+// validChannel reports whether channel has the form [track/]risk[/branch].
+// A track on its own is accepted too, as snap reads it as track/stable.
+func validChannel(channel string) bool {
+	parts := strings.Split(channel, "/")
+	for _, part := range parts {
+		if !channelPartPattern.MatchString(part) {
+			return false
+		}
+	}
+	switch len(parts) {
+	case 1:
+		return true
+	case 2:
+		return slices.Contains(risks, parts[0]) || slices.Contains(risks, parts[1])
+	case 3:
+		return slices.Contains(risks, parts[1])
+	}
+	return false
+}
+
+func invalid(op types.Operation, pkg types.PackageRef, field, value, reason string) error {
+	return &types.ValidationError{
+		Operation: op,
+		Backend:   "snap",
+		Ref:       pkg,
+		Field:     field,
+		Value:     value,
+		Reason:    reason,
+	}
+}
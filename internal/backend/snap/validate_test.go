@@ -0,0 +1,61 @@
+package snap
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		ref       types.PackageRef
+		wantField string
+	}{
+		{types.PackageRef{Name: "firefox"}, ""},
+		{types.PackageRef{Name: "go", Channel: "1.22/stable"}, ""},
+		{types.PackageRef{Name: "lxd", Channel: "5.21/candidate/fix-1"}, ""},
+		{types.PackageRef{Name: "vlc", Channel: "edge/hotfix"}, ""},
+		{types.PackageRef{Name: "core22", Channel: "latest"}, ""},
+		{types.PackageRef{Name: "hello-world_foo"}, ""},
+		{types.PackageRef{Name: "Firefox"}, "Name"},
+		{types.PackageRef{Name: "x"}, "Name"},
+		{types.PackageRef{Name: "-vlc"}, "Name"},
+		{types.PackageRef{Name: "vlc--nightly"}, "Name"},
+		{types.PackageRef{Name: "123"}, "Name"},
+		{types.PackageRef{Name: "vlc", Channel: "latest/nightly"}, "Channel"},
+		{types.PackageRef{Name: "vlc", Channel: "a/b/c"}, "Channel"},
+		{types.PackageRef{Name: "vlc", Channel: "stable/"}, "Channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref.Name+"/"+tt.ref.Channel, func(t *testing.T) {
+			err := validate(types.OperationInstall, []types.PackageRef{tt.ref})
+			var valErr *types.ValidationError
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Unexpected error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &valErr) || valErr.Field != tt.wantField || valErr.Backend != "snap" {
+				t.Errorf("Expected a %s validation error, got %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+// failingRunner fails every command.
+type failingRunner struct{}
+
+func (failingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	return "", "error: boom", errors.New("exit status 1")
+}
+
+func TestBackend_InstallValidatesFirst(t *testing.T) {
+	b := New(nil, failingRunner{}, nil)
+	_, err := b.Install(context.Background(), []types.PackageRef{{Name: "firefox"}, {Name: "VLC"}}, types.InstallOptions{})
+	if !types.IsValidation(err) || types.IsExternalFailure(err) {
+		t.Errorf("Expected a validation error before running snap, got %v", err)
+	}
+}
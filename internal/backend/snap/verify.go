@@ -0,0 +1,91 @@
+package snap
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// Verify implements Verifier using the snapd API. snapd installs store
+// revisions only after checking their signed assertions, so an installed
+// snap is signed unless its revision is unasserted ("x1", "x2", ...): it
+// was sideloaded with --dangerous. A snap that is not installed is looked
+// up in the store with /v2/find?name=<name>.
+func (b *Backend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	if err := validate(types.OperationVerify, pkgs); err != nil {
+		return nil, err
+	}
+
+	attestations := []types.Attestation{}
+	if len(pkgs) == 0 {
+		return attestations, nil
+	}
+
+	helper := types.NewOperationProgressHelper("snap", types.OperationVerify, b.progress, opts.Progress)
+	helper.BeginAction("Verify")
+	defer helper.EndAction()
+
+	for _, pkg := range pkgs {
+		helper.BeginTask("Querying snapd for " + pkg.Name)
+		info, err := b.snap(ctx, types.OperationVerify, pkg.Name)
+		installed := info != nil
+		if err == nil && !installed {
+			info, err = b.findSnap(ctx, types.OperationVerify, pkg.Name)
+		}
+		helper.EndTask()
+
+		if err != nil {
+			helper.Error("Verify failed: " + err.Error())
+			return nil, err
+		}
+		attestations = append(attestations, attest(pkg, info, installed))
+	}
+
+	helper.Info("Verify completed")
+	return attestations, nil
+}
+
+// findSnap looks up the snap called name in the store. It returns nil and
+// no error if the store has no such snap.
+func (b *Backend) findSnap(ctx context.Context, op types.Operation, name string) (*snapInfo, error) {
+	var found []snapInfo
+	ok, err := b.getResult(ctx, op, "/v2/find?name="+url.QueryEscape(name), &found)
+	if !ok || err != nil {
+		return nil, err
+	}
+	for i := range found {
+		if found[i].Name == name {
+			return &found[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// attest builds the attestation for pkg from snapd's description of it,
+// nil if neither installed nor in the store.
+func attest(pkg types.PackageRef, info *snapInfo, installed bool) types.Attestation {
+	at := types.Attestation{Ref: types.PackageRef{Name: pkg.Name, Kind: "snap"}, Installed: installed}
+	if info == nil {
+		at.Status = types.AttestationNotFound
+		return at
+	}
+	at.Ref.Namespace = info.Publisher.Username
+	at.Ref.Channel = info.TrackingChannel
+	at.Version = info.Version
+	at.Publisher = info.Publisher.Username
+	at.PublisherVerified = info.Publisher.Validation == "verified" || info.Publisher.Validation == "starred"
+	at.Source = "snap store"
+	at.Signed = true
+	if strings.HasPrefix(info.Revision, "x") {
+		at.Source = "local"
+		at.Signed = false
+		at.Findings = append(at.Findings, "revision "+info.Revision+" was installed without assertions (--dangerous)")
+	}
+	if at.Publisher != "" && !at.PublisherVerified {
+		at.Findings = append(at.Findings, "publisher "+at.Publisher+" is not verified by the store")
+	}
+	at.Settle()
+	return at
+}
@@ -0,0 +1,59 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestBackend_Verify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/v2/snaps/firefox":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"firefox","version":"124.0.1-1","revision":"4090","tracking-channel":"latest/stable","publisher":{"username":"mozilla","validation":"verified"}}}`)
+		case "/v2/snaps/mytool":
+			_, _ = io.WriteString(w, `{"type":"sync","result":{"name":"mytool","version":"0.1","revision":"x1","publisher":{"username":""}}}`)
+		case "/v2/find?name=spotify":
+			_, _ = io.WriteString(w, `{"type":"sync","result":[{"name":"spotify","version":"1.2.31","revision":"75","publisher":{"username":"spotify","validation":"unproven"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"type":"error","result":{"message":"snap not found","kind":"snap-not-found"}}`)
+		}
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), nil, nil)
+	b.SetBaseURL(server.URL)
+
+	pkgs := []types.PackageRef{{Name: "firefox"}, {Name: "mytool"}, {Name: "spotify"}, {Name: "nosuchsnap"}}
+	got, err := b.Verify(context.Background(), pkgs, types.VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(got) != len(pkgs) {
+		t.Fatalf("Verify() = %+v, want one attestation per package", got)
+	}
+	tests := []struct {
+		installed, signed, publisherVerified bool
+		status                               types.AttestationStatus
+		findings                             int
+	}{
+		{true, true, true, types.AttestationVerified, 0},
+		{true, false, false, types.AttestationUnverified, 1},
+		{false, true, false, types.AttestationVerified, 1},
+		{false, false, false, types.AttestationNotFound, 0},
+	}
+	for i, tt := range tests {
+		at := got[i]
+		if at.Installed != tt.installed || at.Signed != tt.signed || at.PublisherVerified != tt.publisherVerified || at.Status != tt.status || len(at.Findings) != tt.findings {
+			t.Errorf("Verify(%s) = %+v", pkgs[i].Name, at)
+		}
+	}
+	if got[1].Source != "local" {
+		t.Errorf("Source of a sideloaded snap = %q, want local", got[1].Source)
+	}
+}
@@ -0,0 +1,28 @@
+package snap
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// ChangeToken returns a token that changes whenever snaps are installed,
+// removed or refreshed, including by snapd's automatic refreshes: the
+// number of finished changes snapd reports and the newest one's ID, so that
+// a change finishing after a newer one is noticed too.
+func (b *Backend) ChangeToken(ctx context.Context) (string, error) {
+	var changes []struct {
+		ID string `json:"id"`
+	}
+	if _, err := b.getResult(ctx, types.OperationWatch, "/v2/changes?select=ready", &changes); err != nil {
+		return "", err
+	}
+	newest := 0
+	for _, c := range changes {
+		if id, err := strconv.Atoi(c.ID); err == nil && id > newest {
+			newest = id
+		}
+	}
+	return strconv.Itoa(len(changes)) + "/" + strconv.Itoa(newest), nil
+}
@@ -0,0 +1,40 @@
+package snap
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frostyard/pm/internal/runner"
+)
+
+func TestBackend_ChangeToken(t *testing.T) {
+	changes := `[{"id":"41","kind":"refresh-snap","status":"Done"},{"id":"40","kind":"install-snap","status":"Done"}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/changes" || r.URL.Query().Get("select") != "ready" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"type":"sync","result":`+changes+`}`)
+	}))
+	defer server.Close()
+
+	b := New(server.Client(), &runner.FakeRunner{}, nil)
+	b.SetBaseURL(server.URL)
+
+	token, err := b.ChangeToken(context.Background())
+	if err != nil {
+		t.Fatalf("ChangeToken() error = %v", err)
+	}
+	if again, _ := b.ChangeToken(context.Background()); again != token {
+		t.Errorf("ChangeToken() = %q then %q without changes", token, again)
+	}
+
+	// An older change finishing after a newer one changes the token too.
+	changes = `[{"id":"41","status":"Done"},{"id":"40","status":"Done"},{"id":"39","status":"Error"}]`
+	if next, _ := b.ChangeToken(context.Background()); next == token {
+		t.Errorf("ChangeToken() = %q after a change finished", next)
+	}
+}
@@ -0,0 +1,110 @@
+// Package golden replays captured package manager output through backend
+// parsers and compares the results with checked-in golden files.
+//
+// A corpus is a directory of fixtures. Each fixture is a captured stdout
+// file, NAME.stdout, with optional NAME.stderr and NAME.err files holding
+// the command's stderr and, for failed commands, the error. The parsed
+// result is compared with NAME.golden. Run the tests with -update to
+// rewrite golden files after an intended parser change, and review the diff.
+package golden
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the parsed results")
+
+// Fixture is captured output of one command.
+type Fixture struct {
+	// Name is the fixture's file name without extension.
+	Name string
+
+	// Stdout and Stderr are the captured output streams.
+	Stdout string
+	Stderr string
+
+	// Err is the command's error, or nil if it succeeded.
+	Err error
+}
+
+// Run implements runner.Runner by replaying the fixture for any command.
+func (f Fixture) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	return f.Stdout, f.Stderr, f.Err
+}
+
+// result is the golden file format.
+type result struct {
+	Result any    `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Replay runs parse for each fixture in dir as a subtest and compares what
+// it returns with the fixture's golden file. It fails if dir has no
+// fixtures, so a misspelled directory cannot pass silently.
+func Replay(t *testing.T, dir string, parse func(t *testing.T, f Fixture) (any, error)) {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("No fixtures in %s", dir)
+	}
+
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, ".stdout")
+		f := Fixture{Name: filepath.Base(base)}
+		t.Run(f.Name, func(t *testing.T) {
+			f.Stdout = read(t, path)
+			f.Stderr = read(t, base+".stderr")
+			if msg := read(t, base+".err"); msg != "" {
+				f.Err = errors.New(strings.TrimSpace(msg))
+			}
+
+			got := result{}
+			got.Result, err = parse(t, f)
+			if err != nil {
+				got.Error = err.Error()
+			}
+			data, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			if *update {
+				if err := os.WriteFile(base+".golden", data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(base + ".golden")
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if string(data) != string(want) {
+				t.Errorf("Parsed %s differs from %s.golden:\ngot:\n%s\nwant:\n%s", f.Name, base, data, want)
+			}
+		})
+	}
+}
+
+// read returns the content of path, or "" if it does not exist.
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,147 @@
+// Package httpretry provides an http.RoundTripper that retries transient
+// failures with exponential backoff.
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy configures retry behavior.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 1 are treated as 1 (no retries).
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+
+	// Multiplier scales the delay after each attempt. Values below 1 are treated as 2.
+	Multiplier float64
+
+	// RetryableStatusCodes lists HTTP status codes that trigger a retry.
+	RetryableStatusCodes []int
+}
+
+// Transport retries idempotent requests (GET, HEAD, OPTIONS) that fail with a
+// transport error or a retryable status code.
+type Transport struct {
+	Base   http.RoundTripper
+	Policy Policy
+
+	// sleep waits for d or until ctx is done; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Wrap returns a Transport that retries requests made through base.
+// If base is nil, http.DefaultTransport is used.
+func Wrap(base http.RoundTripper, p Policy) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Policy: p, sleep: sleepContext}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := t.Policy.MaxAttempts
+	if attempts < 1 || !idempotent(req.Method) {
+		attempts = 1
+	}
+
+	backoff := t.Policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if attempt >= attempts || !t.retryable(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay := backoff
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			// Drain so the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		if t.Policy.MaxBackoff > 0 && delay > t.Policy.MaxBackoff {
+			delay = t.Policy.MaxBackoff
+		}
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		backoff = t.next(backoff)
+	}
+}
+
+// retryable reports whether an attempt's outcome warrants another attempt.
+func (t *Transport) retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// Never retry once the caller has given up.
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return true
+	}
+	for _, code := range t.Policy.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transport) next(d time.Duration) time.Duration {
+	m := t.Policy.Multiplier
+	if m < 1 {
+		m = 2
+	}
+	d = time.Duration(float64(d) * m)
+	if t.Policy.MaxBackoff > 0 && d > t.Policy.MaxBackoff {
+		d = t.Policy.MaxBackoff
+	}
+	return d
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
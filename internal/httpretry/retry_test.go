@@ -0,0 +1,182 @@
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSleeps replaces the transport's sleep with one that records delays.
+func recordSleeps(t *Transport) *[]time.Duration {
+	var delays []time.Duration
+	t.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return &delays
+}
+
+func testPolicy() Policy {
+	return Policy{
+		MaxAttempts:          3,
+		InitialBackoff:       100 * time.Millisecond,
+		MaxBackoff:           150 * time.Millisecond,
+		Multiplier:           2,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+}
+
+func TestTransport_RetriesRetryableStatus(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tr := Wrap(nil, testPolicy())
+	delays := recordSleeps(tr)
+	client := &http.Client{Transport: tr}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after retries, got %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	want := []time.Duration{100 * time.Millisecond, 150 * time.Millisecond}
+	if len(*delays) != len(want) || (*delays)[0] != want[0] || (*delays)[1] != want[1] {
+		t.Errorf("Expected backoff %v, got %v", want, *delays)
+	}
+}
+
+func TestTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tr := Wrap(nil, testPolicy())
+	recordSleeps(tr)
+
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected final 503 to be returned, got %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestTransport_DoesNotRetryNonIdempotent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tr := Wrap(nil, testPolicy())
+	recordSleeps(tr)
+
+	resp, err := (&http.Client{Transport: tr}).Post(srv.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("Expected POST not to be retried, got %d attempts", calls)
+	}
+}
+
+func TestTransport_RetriesTransportErrors(t *testing.T) {
+	var calls int
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection reset")
+	})
+
+	tr := Wrap(base, testPolicy())
+	recordSleeps(tr)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	if _, err := tr.RoundTrip(req); err == nil {
+		t.Fatal("Expected error")
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestTransport_StopsOnCancelledContext(t *testing.T) {
+	var calls int
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return nil, context.Canceled
+	})
+
+	tr := Wrap(base, testPolicy())
+	recordSleeps(tr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+	_, _ = tr.RoundTrip(req)
+	if calls != 1 {
+		t.Errorf("Expected no retries after cancellation, got %d attempts", calls)
+	}
+}
+
+func TestTransport_HonorsRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	p := testPolicy()
+	p.MaxBackoff = 0
+	tr := Wrap(nil, p)
+	delays := recordSleeps(tr)
+
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if len(*delays) != 1 || (*delays)[0] != time.Second {
+		t.Errorf("Expected a single 1s delay from Retry-After, got %v", *delays)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
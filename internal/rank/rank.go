@@ -0,0 +1,127 @@
+// Package rank orders search results by how well their names match a query:
+// exact matches first, then prefix, substring and fuzzy matches, with
+// popularity breaking ties within a tier.
+package rank
+
+import (
+	"slices"
+	"strings"
+)
+
+// Match tiers, best first.
+const (
+	TierExact = iota
+	TierPrefix
+	TierSubstring
+	TierFuzzy
+	// TierOther holds results a backend matched on something other than
+	// the name, such as a summary.
+	TierOther
+)
+
+// Tier returns the tier of name for query, case-insensitively. Names with
+// dots, like flatpak's reverse-DNS IDs, are also matched on their last
+// component, so "firefox" matches org.mozilla.firefox exactly.
+func Tier(name, query string) int {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	t := tier(name, query)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		t = min(t, tier(name[i+1:], query))
+	}
+	return t
+}
+
+func tier(name, query string) int {
+	switch {
+	case name == query:
+		return TierExact
+	case strings.HasPrefix(name, query):
+		return TierPrefix
+	case strings.Contains(name, query):
+		return TierSubstring
+	case Fuzzy(name, query):
+		return TierFuzzy
+	}
+	return TierOther
+}
+
+// Matches reports whether name is in a tier better than TierOther for
+// query, counting TierFuzzy only with fuzzy set.
+// Backends whose native search also matches descriptions use it to keep
+// only name matches.
+func Matches(name, query string, fuzzy bool) bool {
+	t := Tier(name, query)
+	return t < TierFuzzy || fuzzy && t == TierFuzzy
+}
+
+// Fuzzy reports whether name is within a few typos of query,
+// case-insensitively. A typo is an inserted, deleted, substituted or
+// transposed character; queries shorter than four characters allow none,
+// and queries of eight or more allow two.
+func Fuzzy(name, query string) bool {
+	q := []rune(strings.ToLower(query))
+	limit := maxEdits(len(q))
+	if limit == 0 {
+		return false
+	}
+	n := []rune(strings.ToLower(name))
+	if d := len(n) - len(q); d > limit || -d > limit {
+		return false
+	}
+	return distance(n, q) <= limit
+}
+
+func maxEdits(queryLen int) int {
+	switch {
+	case queryLen < 4:
+		return 0
+	case queryLen < 8:
+		return 1
+	}
+	return 2
+}
+
+// distance is the optimal string alignment distance between a and b: the
+// Levenshtein distance, counting a transposition of adjacent characters as
+// one edit.
+func distance(a, b []rune) int {
+	// Three rows are enough: transpositions look two rows back
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// Sort orders items by the tier of their name for query, then by
+// descending popularity, keyed by name; names without a popularity count as
+// zero. Items that tie keep their order.
+func Sort[T any](items []T, name func(T) string, query string, popularity map[string]int) {
+	tiers := make(map[string]int, len(items))
+	for _, item := range items {
+		tiers[name(item)] = Tier(name(item), query)
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		an, bn := name(a), name(b)
+		if d := tiers[an] - tiers[bn]; d != 0 {
+			return d
+		}
+		return popularity[bn] - popularity[an]
+	})
+}
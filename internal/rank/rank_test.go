@@ -0,0 +1,91 @@
+package rank
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestTier(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        int
+	}{
+		{"git", "git", TierExact},
+		{"Git", "gIT", TierExact},
+		{"org.mozilla.firefox", "firefox", TierExact},
+		{"git-lfs", "git", TierPrefix},
+		{"org.gnome.Gitg", "git", TierPrefix},
+		{"lazygit", "git", TierSubstring},
+		{"firefox", "fierfox", TierFuzzy},
+		{"firefox", "firefx", TierFuzzy},
+		{"jq", "jx", TierOther},
+		{"wget", "curl", TierOther},
+	}
+	for _, tt := range tests {
+		if got := Tier(tt.name, tt.query); got != tt.want {
+			t.Errorf("Tier(%q, %q) = %d, want %d", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzy(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        bool
+	}{
+		{"firefox", "firefox", true},
+		{"firefox", "friefox", true},   // transposition
+		{"firefox", "firfox", true},    // deletion
+		{"firefox", "firreefox", true}, // two edits, long query
+		{"firefox", "fxrefxx", false},
+		{"htop", "htpo", true},
+		{"git", "gti", false}, // too short for typos
+		{"ripgrep", "rg", false},
+	}
+	for _, tt := range tests {
+		if got := Fuzzy(tt.name, tt.query); got != tt.want {
+			t.Errorf("Fuzzy(%q, %q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name, query string
+		fuzzy       bool
+		want        bool
+	}{
+		{"org.mozilla.firefox", "Firefox", false, true},
+		{"librewolf", "firefox", true, false},
+		{"firefox", "fierfox", false, false},
+		{"firefox", "fierfox", true, true},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.name, tt.query, tt.fuzzy); got != tt.want {
+			t.Errorf("Matches(%q, %q, %v) = %v, want %v", tt.name, tt.query, tt.fuzzy, got, tt.want)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	refs := []types.PackageRef{
+		{Name: "lazygit"},
+		{Name: "gti"},
+		{Name: "git-lfs"},
+		{Name: "legit"},
+		{Name: "git"},
+		{Name: "git-delta"},
+	}
+	Sort(refs, func(ref types.PackageRef) string { return ref.Name }, "git", map[string]int{"git-delta": 100, "git-lfs": 50, "legit": 10})
+
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.Name)
+	}
+	want := "[git git-delta git-lfs legit lazygit gti]"
+	if fmt.Sprint(names) != want {
+		t.Errorf("Sort() = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// exitCodeCategories maps exit statuses with a documented meaning to a
+// failure category, per backend. brew and flatpak exit 1 for every
+// failure, so for them (and for statuses not listed) the category is
+// inferred from the command's output.
+var exitCodeCategories = map[string]map[int]string{
+	// snap exits 10 for errors its client considers retryable, which are
+	// conflicts with a change already in progress.
+	"snap": {10: types.CategoryLocked},
+}
+
+// categoryMarkers map lowercase output fragments to failure categories.
+// Earlier entries win.
+var categoryMarkers = []struct {
+	marker   string
+	category string
+}{
+	{"could not get lock", types.CategoryLocked},
+	{"another active homebrew", types.CategoryLocked},
+	{"has already locked", types.CategoryLocked},
+	{"change in progress", types.CategoryLocked},
+	{"unable to lock", types.CategoryLocked},
+	{"no available formula", types.CategoryNotFound},
+	{"no available cask", types.CategoryNotFound},
+	{"no formulae or casks found", types.CategoryNotFound},
+	{"is unavailable: no cask", types.CategoryNotFound},
+	{"nothing matches", types.CategoryNotFound},
+	{"no remote refs found", types.CategoryNotFound},
+	{"\" not found", types.CategoryNotFound},
+	{"unknown option", types.CategoryUsage},
+	{"unknown flag", types.CategoryUsage},
+	{"invalid option", types.CategoryUsage},
+	{"unknown command", types.CategoryUsage},
+}
+
+// categorize returns the failure category of a command of backend that
+// exited with code and printed output, or "" if it is unknown.
+func categorize(backend string, code int, output string) string {
+	if category, ok := exitCodeCategories[backend][code]; ok {
+		return category
+	}
+	if _, ok := networkKindFromOutput(output); ok {
+		return types.CategoryNetwork
+	}
+	lower := strings.ToLower(output)
+	for _, m := range categoryMarkers {
+		if strings.Contains(lower, m.marker) {
+			return m.category
+		}
+	}
+	return ""
+}
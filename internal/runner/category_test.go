@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		code    int
+		output  string
+		want    string
+	}{
+		{"snap conflict", "snap", 10, `error: snap "jq" has "install-snap" change in progress`, types.CategoryLocked},
+		{"snap exit code only", "snap", 10, "", types.CategoryLocked},
+		{"brew lock", "brew", 1, "Error: A `brew install jq` process has already locked /opt/homebrew/Cellar/jq", types.CategoryLocked},
+		{"flatpak lock", "flatpak", 1, "error: Unable to lock system installation", types.CategoryLocked},
+		{"brew not found", "brew", 1, `Error: No available formula with the name "nosuchthing".`, types.CategoryNotFound},
+		{"flatpak not found", "flatpak", 1, "error: Nothing matches org.example.Nope in remote flathub", types.CategoryNotFound},
+		{"snap not found", "snap", 1, `error: snap "nosuchthing" not found`, types.CategoryNotFound},
+		{"usage", "brew", 1, "Error: invalid option: --nope", types.CategoryUsage},
+		{"network", "flatpak", 1, "error: Could not resolve hostname dl.flathub.org", types.CategoryNetwork},
+		{"unknown", "brew", 1, "Error: something else", ""},
+		{"table is per backend", "flatpak", 10, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := categorize(tt.backend, tt.code, tt.output); got != tt.want {
+				t.Errorf("categorize(%q, %d, %q) = %q, want %q", tt.backend, tt.code, tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithExternalError_Category(t *testing.T) {
+	fake := &FakeRunner{StderrResponse: "Error: Another active Homebrew update process is already in progress.", ErrResponse: errors.New("exit status 1")}
+	_, _, err := RunWithExternalError(context.Background(), fake, types.OperationUpgradePackages, "brew", "brew", "upgrade")
+
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Category != types.CategoryLocked {
+		t.Errorf("Expected a locked failure, got %#v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"regexp"
+	"time"
+)
+
+// conflictPatterns describe the lock holder in the output of a command that
+// failed because another operation is in progress. The first submatch is
+// the holder; wait is how long such conflicts usually last.
+var conflictPatterns = []struct {
+	pattern *regexp.Regexp
+	wait    time.Duration
+}{
+	// snap: error: snap "jq" has "install-snap" change in progress
+	{regexp.MustCompile(`(snap "[^"]+" has "[^"]+" change) in progress`), 30 * time.Second},
+	// brew: Error: A `brew install jq` process has already locked /opt/homebrew/Cellar/jq.
+	{regexp.MustCompile("A (`[^`]+` process) has already locked"), 10 * time.Second},
+	// brew: Error: Another active Homebrew update process is already in progress.
+	{regexp.MustCompile(`Another active (Homebrew update process)`), 30 * time.Second},
+	// dpkg/apt: Could not get lock /var/lib/dpkg/lock-frontend. It is held by process 1234 (apt-get)
+	{regexp.MustCompile(`held by (process \d+(?: \([^)]+\))?)`), 10 * time.Second},
+}
+
+// conflictHolder describes what holds the lock according to output, and
+// suggests how long to wait before retrying. Both are zero if output does
+// not say.
+func conflictHolder(output string) (holder string, retryAfter time.Duration) {
+	for _, c := range conflictPatterns {
+		if m := c.pattern.FindStringSubmatch(output); m != nil {
+			return m[1], c.wait
+		}
+	}
+	return "", 0
+}
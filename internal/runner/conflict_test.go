@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestConflictHolder(t *testing.T) {
+	tests := []struct {
+		output     string
+		holder     string
+		retryAfter time.Duration
+	}{
+		{`error: snap "jq" has "install-snap" change in progress`, `snap "jq" has "install-snap" change`, 30 * time.Second},
+		{"Error: A `brew install jq` process has already locked /opt/homebrew/Cellar/jq.", "`brew install jq` process", 10 * time.Second},
+		{"Error: Another active Homebrew update process is already in progress.", "Homebrew update process", 30 * time.Second},
+		{"E: Could not get lock /var/lib/dpkg/lock-frontend. It is held by process 1234 (apt-get)", "process 1234 (apt-get)", 10 * time.Second},
+		{"error: Unable to lock system installation", "", 0},
+	}
+	for _, tt := range tests {
+		holder, retryAfter := conflictHolder(tt.output)
+		if holder != tt.holder || retryAfter != tt.retryAfter {
+			t.Errorf("conflictHolder(%q) = %q, %v; want %q, %v", tt.output, holder, retryAfter, tt.holder, tt.retryAfter)
+		}
+	}
+}
+
+func TestRunWithExternalError_Conflict(t *testing.T) {
+	fake := &FakeRunner{StderrResponse: `error: snap "firefox" has "refresh-snap" change in progress`, ErrResponse: errors.New("exit status 10")}
+	_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "snap", "snap", "install", "firefox")
+
+	var conflictErr *types.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if conflictErr.Holder != `snap "firefox" has "refresh-snap" change` || conflictErr.RetryAfter == 0 || conflictErr.Backend != "snap" {
+		t.Errorf("Unexpected %+v", conflictErr)
+	}
+	if !types.IsExternalFailure(err) {
+		t.Error("Expected the command failure to stay reachable")
+	}
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// Container engines supported by NewContainerRunner.
+const (
+	ContainerDocker    = "docker"
+	ContainerPodman    = "podman"
+	ContainerDistrobox = "distrobox"
+	ContainerToolbox   = "toolbox"
+)
+
+// ContainerConfig describes a running container to execute commands in.
+type ContainerConfig struct {
+	// Engine is ContainerDocker, ContainerPodman, ContainerDistrobox or
+	// ContainerToolbox.
+	Engine string
+
+	// Container is the container name or ID.
+	Container string
+
+	// User runs commands as this user (docker and podman only); empty uses
+	// the container's default.
+	User string
+}
+
+// containerRunner runs commands inside a container through its engine's CLI.
+type containerRunner struct {
+	base   Runner
+	config ContainerConfig
+}
+
+// NewContainerRunner wraps base so that commands run inside a container:
+// base is asked to run e.g. `podman exec box flatpak list`.
+//
+// The environment and working directory requested with WithEnv and WithDir
+// are applied inside the container, stdin is forwarded, and WithPTY
+// allocates a terminal in the container.
+func NewContainerRunner(base Runner, config ContainerConfig) Runner {
+	return &containerRunner{base: base, config: config}
+}
+
+// Target implements Targeter.
+func (r *containerRunner) Target() string {
+	return r.config.Engine + ":" + r.config.Container
+}
+
+func (r *containerRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	inv := InvocationFromContext(ctx)
+	engine, engineArgs, err := r.command(inv, name, args)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Env and Dir apply inside the container, not to the engine's client.
+	ctx = withInvocation(ctx, func(inv *Invocation) {
+		inv.Env, inv.Dir = nil, ""
+	})
+	return r.base.Run(ctx, engine, engineArgs...)
+}
+
+// command returns the engine command line that runs name and args.
+func (r *containerRunner) command(inv Invocation, name string, args []string) (string, []string, error) {
+	c := r.config
+	switch c.Engine {
+	case ContainerDocker, ContainerPodman:
+		out := []string{"exec"}
+		if inv.Stdin != "" {
+			out = append(out, "-i")
+		}
+		if inv.PTY {
+			out = append(out, "-t")
+		}
+		if c.User != "" {
+			out = append(out, "-u", c.User)
+		}
+		if inv.Dir != "" {
+			out = append(out, "-w", inv.Dir)
+		}
+		for _, kv := range inv.Env {
+			out = append(out, "-e", kv)
+		}
+		out = append(out, c.Container, name)
+		return c.Engine, append(out, args...), nil
+
+	case ContainerDistrobox:
+		out := []string{"enter"}
+		if !inv.PTY {
+			out = append(out, "--no-tty")
+		}
+		out = append(out, c.Container, "--")
+		return c.Engine, append(out, shellCommand(inv, name, args)...), nil
+
+	case ContainerToolbox:
+		out := []string{"run", "--container", c.Container}
+		return c.Engine, append(out, shellCommand(inv, name, args)...), nil
+	}
+	return "", nil, fmt.Errorf("unsupported container engine %q", c.Engine)
+}
+
+// shellCommand returns name and args, wrapped in `sh -c` when the
+// environment or working directory has to be set first.
+func shellCommand(inv Invocation, name string, args []string) []string {
+	if len(inv.Env) == 0 && inv.Dir == "" {
+		return append([]string{name}, args...)
+	}
+	return []string{"sh", "-c", remoteCommand(inv, name, args)}
+}
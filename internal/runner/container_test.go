@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestContainerRunner(t *testing.T) {
+	ctx := WithEnv(context.Background(), "HOMEBREW_NO_AUTO_UPDATE=1")
+	ctx = WithDir(ctx, "/work")
+
+	tests := []struct {
+		name   string
+		config ContainerConfig
+		ctx    context.Context
+		want   []string
+	}{
+		{
+			name:   "podman",
+			config: ContainerConfig{Engine: ContainerPodman, Container: "box"},
+			ctx:    context.Background(),
+			want:   []string{"podman", "exec", "box", "brew", "install", "wget"},
+		},
+		{
+			name:   "docker with user, env and dir",
+			config: ContainerConfig{Engine: ContainerDocker, Container: "dev", User: "linuxbrew"},
+			ctx:    ctx,
+			want: []string{"docker", "exec", "-u", "linuxbrew", "-w", "/work",
+				"-e", "HOMEBREW_NO_AUTO_UPDATE=1", "dev", "brew", "install", "wget"},
+		},
+		{
+			name:   "docker with stdin and pty",
+			config: ContainerConfig{Engine: ContainerDocker, Container: "dev"},
+			ctx:    WithPTY(WithStdin(context.Background(), "y\n")),
+			want:   []string{"docker", "exec", "-i", "-t", "dev", "brew", "install", "wget"},
+		},
+		{
+			name:   "distrobox",
+			config: ContainerConfig{Engine: ContainerDistrobox, Container: "fedora"},
+			ctx:    context.Background(),
+			want:   []string{"distrobox", "enter", "--no-tty", "fedora", "--", "brew", "install", "wget"},
+		},
+		{
+			name:   "distrobox with env and dir",
+			config: ContainerConfig{Engine: ContainerDistrobox, Container: "fedora"},
+			ctx:    ctx,
+			want: []string{"distrobox", "enter", "--no-tty", "fedora", "--", "sh", "-c",
+				"cd /work && env HOMEBREW_NO_AUTO_UPDATE=1 brew install wget"},
+		},
+		{
+			name:   "toolbox",
+			config: ContainerConfig{Engine: ContainerToolbox, Container: "fedora-toolbox-42"},
+			ctx:    context.Background(),
+			want:   []string{"toolbox", "run", "--container", "fedora-toolbox-42", "brew", "install", "wget"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingFake{}
+			r := NewContainerRunner(rec, tt.config)
+			if _, _, err := r.Run(tt.ctx, "brew", "install", "wget"); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			got := append([]string{rec.name}, rec.args...)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+			if rec.inv.Env != nil || rec.inv.Dir != "" {
+				t.Errorf("engine client got env %q, dir %q; want none", rec.inv.Env, rec.inv.Dir)
+			}
+		})
+	}
+}
+
+func TestContainerRunner_UnknownEngine(t *testing.T) {
+	r := NewContainerRunner(&recordingFake{}, ContainerConfig{Engine: "lxc", Container: "x"})
+	if _, _, err := r.Run(context.Background(), "true"); err == nil {
+		t.Error("expected error for unsupported engine")
+	}
+}
+
+func TestContainerRunner_Target(t *testing.T) {
+	r := NewContainerRunner(&recordingFake{}, ContainerConfig{Engine: ContainerPodman, Container: "box"})
+	if got := r.(Targeter).Target(); got != "podman:box" {
+		t.Errorf("Target() = %q, want podman:box", got)
+	}
+}
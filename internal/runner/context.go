@@ -0,0 +1,21 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+type operationKey struct{}
+
+// WithOperation records the operation a command is being run for, so that
+// runner wrappers can adapt their behavior (e.g. only escalate mutations).
+func WithOperation(ctx context.Context, op types.Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext returns the operation recorded by WithOperation, if any.
+func OperationFromContext(ctx context.Context) (types.Operation, bool) {
+	op, ok := ctx.Value(operationKey{}).(types.Operation)
+	return op, ok
+}
@@ -0,0 +1,220 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// Escalation strategies for commands that need root.
+const (
+	// EscalationNone runs commands as the current user.
+	EscalationNone = "none"
+
+	// EscalationSudo prefixes commands with non-interactive `sudo -n`.
+	EscalationSudo = "sudo"
+
+	// EscalationDoas prefixes commands with non-interactive `doas -n`.
+	EscalationDoas = "doas"
+
+	// EscalationPkexec prefixes commands with `pkexec`.
+	EscalationPkexec = "pkexec"
+
+	// EscalationPolkit runs commands as the current user and relies on the
+	// tool's own polkit authorization over D-Bus (flatpak system helper, snapd).
+	EscalationPolkit = "polkit"
+)
+
+// permissionDeniedMarkers are stderr fragments that indicate a command
+// failed because it lacked privileges.
+var permissionDeniedMarkers = []string{
+	"permission denied",
+	"access denied",
+	"not authorized",
+	"requires root",
+	"must be run as root",
+	"operation not permitted",
+	"not allowed for user",
+	"interactive authentication required",
+}
+
+// passwordRequiredMarkers are stderr fragments printed by `sudo -n` and
+// `doas -n` when they would have to ask for a password.
+var passwordRequiredMarkers = []string{
+	"password is required",
+	"a terminal is required",
+	"authentication required",
+	"authorization required",
+}
+
+// lookPath and geteuid are replaced in tests.
+var (
+	lookPath = exec.LookPath
+	geteuid  = os.Geteuid
+)
+
+// SuggestEscalation returns the escalation strategy most likely to let a
+// command of backend that was denied under the failed strategy succeed. If
+// sudo or doas needed a password, that is pkexec, which can ask for one;
+// otherwise it is the first of sudo, doas and pkexec that is installed. It
+// returns "" when running as root, for brew (which refuses to run as root),
+// or when no other strategy is available.
+func SuggestEscalation(backend, failed string) string {
+	if geteuid() == 0 || backend == "brew" {
+		return ""
+	}
+	candidates := []string{EscalationSudo, EscalationDoas, EscalationPkexec}
+	if failed == EscalationSudo || failed == EscalationDoas {
+		candidates = []string{EscalationPkexec}
+	}
+	for _, strategy := range candidates {
+		if strategy == failed {
+			continue
+		}
+		if _, err := lookPath(strategy); err == nil {
+			return strategy
+		}
+	}
+	return ""
+}
+
+// escalatingRunner wraps mutating commands with a privilege-escalation strategy.
+type escalatingRunner struct {
+	base        Runner
+	strategy    string
+	backend     string
+	interactive bool
+	euid        func() int
+}
+
+// NewEscalatingRunner wraps base so that mutating operations (as recorded by
+// WithOperation) run with elevated privileges using strategy. Read-only
+// commands and commands run by root are passed through unchanged.
+//
+// If interactive is false, strategies that may prompt the user (pkexec) are
+// refused rather than run.
+//
+// Failures caused by missing privileges are returned as *types.EscalationError,
+// except that sudo and doas asking for a password is reported as
+// *types.PermissionDeniedError: escalation is set up, but this user may not
+// use it without authenticating.
+func NewEscalatingRunner(base Runner, strategy, backend string, interactive bool) Runner {
+	return &escalatingRunner{base: base, strategy: strategy, backend: backend, interactive: interactive, euid: os.Geteuid}
+}
+
+// Run executes the command, escalating it if the operation requires root.
+func (r *escalatingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	op, _ := OperationFromContext(ctx)
+	if !mutating(op) || r.euid() == 0 {
+		return r.base.Run(ctx, name, args...)
+	}
+
+	switch r.strategy {
+	case EscalationSudo, EscalationDoas:
+		tool := r.strategy
+		stdout, stderr, err := r.base.Run(ctx, tool, append([]string{"-n", name}, args...)...)
+		if err == nil {
+			return stdout, stderr, nil
+		}
+		if notFound(err) {
+			return stdout, stderr, r.escalationErr(ctx, op, tool+" is not installed", stderr)
+		}
+		if passwordRequired(stderr) {
+			return stdout, stderr, &types.PermissionDeniedError{
+				Operation: op,
+				Backend:   r.backend,
+				Strategy:  r.strategy,
+				Reason:    tool + " requires a password and cannot prompt (" + tool + " -n)",
+				Stderr:    sanitize(ctx, stderr),
+				Hint:      SuggestEscalation(r.backend, r.strategy),
+			}
+		}
+		return stdout, stderr, err
+
+	case EscalationPkexec:
+		if !r.interactive {
+			return "", "", r.escalationErr(ctx, op, "pkexec may prompt for authentication and non-interactive mode is enabled", "")
+		}
+		stdout, stderr, err := r.base.Run(ctx, "pkexec", append([]string{name}, args...)...)
+		if err == nil {
+			return stdout, stderr, nil
+		}
+		if notFound(err) {
+			return stdout, stderr, r.escalationErr(ctx, op, "pkexec is not installed", stderr)
+		}
+		// pkexec exits 126 when authorization is dismissed and 127 when it is denied.
+		if code := exitCode(err); code == 126 || code == 127 || deniedStderr(stderr) {
+			return stdout, stderr, r.escalationErr(ctx, op, "pkexec authorization was denied or dismissed", stderr)
+		}
+		return stdout, stderr, err
+
+	default:
+		stdout, stderr, err := r.base.Run(ctx, name, args...)
+		if err != nil && deniedStderr(stderr) {
+			reason := "operation requires elevated privileges and escalation is disabled"
+			if r.strategy == EscalationPolkit {
+				reason = "polkit denied authorization"
+			}
+			return stdout, stderr, r.escalationErr(ctx, op, reason, stderr)
+		}
+		return stdout, stderr, err
+	}
+}
+
+func (r *escalatingRunner) escalationErr(ctx context.Context, op types.Operation, reason, stderr string) error {
+	return &types.EscalationError{
+		Operation: op,
+		Backend:   r.backend,
+		Strategy:  r.strategy,
+		Reason:    reason,
+		Stderr:    sanitize(ctx, stderr),
+		Hint:      SuggestEscalation(r.backend, r.strategy),
+	}
+}
+
+// mutating reports whether op changes system state and therefore needs root.
+func mutating(op types.Operation) bool {
+	switch op {
+	case types.OperationUpdateMetadata, types.OperationUpgradePackages, types.OperationInstall, types.OperationUninstall:
+		return true
+	}
+	return false
+}
+
+func deniedStderr(stderr string) bool {
+	lower := strings.ToLower(stderr)
+	for _, marker := range permissionDeniedMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func passwordRequired(stderr string) bool {
+	lower := strings.ToLower(stderr)
+	for _, marker := range passwordRequiredMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func notFound(err error) bool {
+	return errors.Is(err, exec.ErrNotFound)
+}
+
+// exitCode returns the exit status carried by err (*exec.ExitError or a
+// replayed failure), or -1 if there is none.
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
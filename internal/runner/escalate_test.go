@@ -0,0 +1,279 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func newTestEscalatingRunner(base Runner, strategy string, euid int) *escalatingRunner {
+	r := NewEscalatingRunner(base, strategy, "snap", true).(*escalatingRunner)
+	r.euid = func() int { return euid }
+	return r
+}
+
+func TestEscalatingRunner_WrapsMutatingOperations(t *testing.T) {
+	tests := []struct {
+		strategy    string
+		wantCommand string
+		wantArgs    []string
+	}{
+		{EscalationSudo, "sudo", []string{"-n", "snap", "install", "hello"}},
+		{EscalationPkexec, "pkexec", []string{"snap", "install", "hello"}},
+		{EscalationPolkit, "snap", []string{"install", "hello"}},
+		{EscalationNone, "snap", []string{"install", "hello"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			fake := &FakeRunner{}
+			r := newTestEscalatingRunner(fake, tt.strategy, 1000)
+
+			ctx := WithOperation(context.Background(), types.OperationInstall)
+			if _, _, err := r.Run(ctx, "snap", "install", "hello"); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if fake.LastCommand != tt.wantCommand {
+				t.Errorf("Expected command %q, got %q", tt.wantCommand, fake.LastCommand)
+			}
+			if len(fake.LastArgs) != len(tt.wantArgs) {
+				t.Fatalf("Expected args %v, got %v", tt.wantArgs, fake.LastArgs)
+			}
+			for i := range tt.wantArgs {
+				if fake.LastArgs[i] != tt.wantArgs[i] {
+					t.Errorf("Expected args %v, got %v", tt.wantArgs, fake.LastArgs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestEscalatingRunner_PassesThroughReads(t *testing.T) {
+	fake := &FakeRunner{}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 1000)
+
+	ctx := WithOperation(context.Background(), types.OperationSearch)
+	_, _, _ = r.Run(ctx, "snap", "find", "hello")
+	if fake.LastCommand != "snap" {
+		t.Errorf("Search should not be escalated, ran %q", fake.LastCommand)
+	}
+
+	_, _, _ = r.Run(context.Background(), "snap", "--version")
+	if fake.LastCommand != "snap" {
+		t.Errorf("Commands without an operation should not be escalated, ran %q", fake.LastCommand)
+	}
+}
+
+func TestEscalatingRunner_PassesThroughAsRoot(t *testing.T) {
+	fake := &FakeRunner{}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 0)
+
+	ctx := WithOperation(context.Background(), types.OperationInstall)
+	_, _, _ = r.Run(ctx, "snap", "install", "hello")
+	if fake.LastCommand != "snap" {
+		t.Errorf("Root should not escalate, ran %q", fake.LastCommand)
+	}
+}
+
+func TestEscalatingRunner_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		stderr   string
+		err      error
+	}{
+		{"sudo missing", EscalationSudo, "", exec.ErrNotFound},
+		{"doas missing", EscalationDoas, "", exec.ErrNotFound},
+		{"pkexec denied", EscalationPkexec, "Error executing command as another user: Not authorized", errors.New("exit status 127")},
+		{"none denied", EscalationNone, "error: access denied (try with sudo)", errors.New("exit status 1")},
+		{"polkit denied", EscalationPolkit, "error: Not authorized to perform operation", errors.New("exit status 1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRunner{StderrResponse: tt.stderr, ErrResponse: tt.err}
+			r := newTestEscalatingRunner(fake, tt.strategy, 1000)
+
+			ctx := WithOperation(context.Background(), types.OperationInstall)
+			_, _, err := r.Run(ctx, "snap", "install", "hello")
+
+			var escErr *types.EscalationError
+			if !errors.As(err, &escErr) {
+				t.Fatalf("Expected EscalationError, got %v", err)
+			}
+			if escErr.Strategy != tt.strategy || escErr.Backend != "snap" || escErr.Operation != types.OperationInstall {
+				t.Errorf("Unexpected error context: %+v", escErr)
+			}
+		})
+	}
+}
+
+func TestEscalatingRunner_OtherFailuresUnchanged(t *testing.T) {
+	fake := &FakeRunner{StderrResponse: "error: snap \"nope\" not found", ErrResponse: errors.New("exit status 1")}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 1000)
+
+	_, _, err := RunWithExternalError(context.Background(), r, types.OperationInstall, "snap", "snap", "install", "nope")
+	if !types.IsExternalFailure(err) {
+		t.Errorf("Expected ExternalFailureError, got %v", err)
+	}
+	if types.IsEscalationUnavailable(err) {
+		t.Error("Unrelated failures should not be reported as escalation errors")
+	}
+}
+
+func TestEscalatingRunner_PasswordRequired(t *testing.T) {
+	tests := []struct {
+		strategy string
+		stderr   string
+	}{
+		{EscalationSudo, "sudo: a password is required"},
+		{EscalationSudo, "sudo: a terminal is required to read the password"},
+		{EscalationDoas, "doas: Authentication required"},
+		{EscalationDoas, "doas: Authorization required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			fake := &FakeRunner{StderrResponse: tt.stderr, ErrResponse: errors.New("exit status 1")}
+			r := newTestEscalatingRunner(fake, tt.strategy, 1000)
+
+			ctx := WithOperation(context.Background(), types.OperationInstall)
+			_, _, err := r.Run(ctx, "snap", "install", "hello")
+
+			var pdErr *types.PermissionDeniedError
+			if !errors.As(err, &pdErr) {
+				t.Fatalf("Expected PermissionDeniedError, got %v", err)
+			}
+			if pdErr.Strategy != tt.strategy || pdErr.Backend != "snap" || pdErr.Operation != types.OperationInstall {
+				t.Errorf("Unexpected error context: %+v", pdErr)
+			}
+			if fake.LastCommand != tt.strategy || fake.LastArgs[0] != "-n" {
+				t.Errorf("Expected %s -n prefix, got %s %v", tt.strategy, fake.LastCommand, fake.LastArgs)
+			}
+		})
+	}
+}
+
+func TestRunWithExternalError_PreservesEscalationError(t *testing.T) {
+	fake := &FakeRunner{ErrResponse: exec.ErrNotFound}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 1000)
+
+	_, _, err := RunWithExternalError(context.Background(), r, types.OperationUninstall, "snap", "snap", "remove", "hello")
+	if !types.IsEscalationUnavailable(err) {
+		t.Errorf("Expected EscalationError to pass through, got %v", err)
+	}
+}
+
+func TestRunWithExternalError_PreservesPermissionDenied(t *testing.T) {
+	fake := &FakeRunner{StderrResponse: "sudo: a password is required", ErrResponse: errors.New("exit status 1")}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 1000)
+
+	_, _, err := RunWithExternalError(context.Background(), r, types.OperationUninstall, "snap", "snap", "remove", "hello")
+	if !types.IsPermissionDenied(err) {
+		t.Errorf("Expected PermissionDeniedError to pass through, got %v", err)
+	}
+}
+
+func TestEscalatingRunner_NonInteractiveRefusesPkexec(t *testing.T) {
+	base := &FakeRunner{}
+	r := NewEscalatingRunner(base, EscalationPkexec, "flatpak", false).(*escalatingRunner)
+	r.euid = func() int { return 1000 }
+
+	_, _, err := r.Run(WithOperation(context.Background(), types.OperationInstall), "flatpak", "install", "-y", "org.gimp.GIMP")
+	if !types.IsEscalationUnavailable(err) {
+		t.Errorf("Expected EscalationError, got %v", err)
+	}
+	if base.LastCommand != "" {
+		t.Errorf("Expected nothing to run, got %q", base.LastCommand)
+	}
+}
+
+// stubEscalationTools makes SuggestEscalation see only the given tools and
+// the given effective user ID.
+func stubEscalationTools(t *testing.T, euid int, tools ...string) {
+	t.Helper()
+	oldLookPath, oldGeteuid := lookPath, geteuid
+	t.Cleanup(func() { lookPath, geteuid = oldLookPath, oldGeteuid })
+	lookPath = func(file string) (string, error) {
+		for _, tool := range tools {
+			if tool == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	geteuid = func() int { return euid }
+}
+
+func TestSuggestEscalation(t *testing.T) {
+	tests := []struct {
+		name    string
+		euid    int
+		tools   []string
+		backend string
+		failed  string
+		want    string
+	}{
+		{"no strategy", 1000, []string{"sudo", "pkexec"}, "snap", "", EscalationSudo},
+		{"doas only", 1000, []string{"doas"}, "flatpak", EscalationPolkit, EscalationDoas},
+		{"sudo needs password", 1000, []string{"sudo", "pkexec"}, "snap", EscalationSudo, EscalationPkexec},
+		{"pkexec failed", 1000, []string{"pkexec"}, "snap", EscalationPkexec, ""},
+		{"nothing installed", 1000, nil, "snap", "", ""},
+		{"root", 0, []string{"sudo"}, "snap", "", ""},
+		{"brew", 1000, []string{"sudo"}, "brew", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubEscalationTools(t, tt.euid, tt.tools...)
+			if got := SuggestEscalation(tt.backend, tt.failed); got != tt.want {
+				t.Errorf("SuggestEscalation(%q, %q) = %q, want %q", tt.backend, tt.failed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscalatingRunner_PasswordRequiredHint(t *testing.T) {
+	stubEscalationTools(t, 1000, "sudo", "pkexec")
+	fake := &FakeRunner{StderrResponse: "sudo: a password is required", ErrResponse: errors.New("exit status 1")}
+	r := newTestEscalatingRunner(fake, EscalationSudo, 1000)
+
+	_, _, err := r.Run(WithOperation(context.Background(), types.OperationInstall), "snap", "install", "hello")
+	var pdErr *types.PermissionDeniedError
+	if !errors.As(err, &pdErr) || pdErr.Hint != EscalationPkexec {
+		t.Errorf("Expected a pkexec hint, got %v", err)
+	}
+}
+
+func TestRunWithExternalError_DetectsPermissionDenied(t *testing.T) {
+	stubEscalationTools(t, 1000, "sudo")
+	tests := []struct {
+		name           string
+		stdout, stderr string
+	}{
+		{"snap", "", "error: access denied (try with sudo)"},
+		{"polkit", "", "error: Flatpak system operation Deploy not allowed for user"},
+		{"pseudo-terminal", "error: access denied (try with sudo)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRunner{StdoutResponse: tt.stdout, StderrResponse: tt.stderr, ErrResponse: errors.New("exit status 1")}
+
+			_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "snap", "snap", "install", "hello")
+			var pdErr *types.PermissionDeniedError
+			if !errors.As(err, &pdErr) {
+				t.Fatalf("Expected PermissionDeniedError, got %v", err)
+			}
+			if pdErr.Strategy != "" || pdErr.Hint != EscalationSudo || pdErr.Operation != types.OperationInstall {
+				t.Errorf("Unexpected error context: %+v", pdErr)
+			}
+			var extErr *types.ExternalFailureError
+			if !errors.As(err, &extErr) || extErr.Backend != "snap" {
+				t.Errorf("Expected the external failure to be wrapped, got %v", err)
+			}
+		})
+	}
+}
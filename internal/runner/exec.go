@@ -2,30 +2,188 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"io"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/frostyard/pm/internal/types"
 )
 
+// DefaultPromptIdle is how long a command may produce no output while its
+// last line looks like a prompt before it is assumed to be waiting for input.
+const DefaultPromptIdle = 10 * time.Second
+
+// DefaultTerminationGrace is how long a cancelled command is given to exit
+// after SIGTERM before it is killed.
+const DefaultTerminationGrace = 10 * time.Second
+
 // realRunner implements Runner using os/exec.
-type realRunner struct{}
+type realRunner struct {
+	promptIdle time.Duration
+	grace      time.Duration
+}
 
 // NewRealRunner creates a Runner that executes real commands using os/exec.
+//
+// Commands never receive input unless given with WithStdin: stdin is
+// otherwise connected to the null device, so tools that read an answer see
+// end-of-file. As a safeguard against tools that keep waiting anyway, a
+// watchdog stops a command that has been silent for DefaultPromptIdle after
+// printing what looks like a prompt, and returns
+// *types.InteractionRequiredError.
+//
+// When ctx is cancelled, the command receives SIGTERM so that it can clean
+// up (release dpkg or brew locks, remove partial downloads), and is killed
+// only if it is still running after DefaultTerminationGrace. The error is
+// then a *types.CancelledError.
+//
+// Environment and working directory can be set per command with WithEnv
+// and WithDir, and WithPTY runs a command on a pseudo-terminal. If ctx
+// carries a LineFunc (see WithLineFunc), output is streamed to it line by
+// line while the command runs, in addition to being returned.
 func NewRealRunner() Runner {
-	return &realRunner{}
+	return NewRealRunnerWithGrace(DefaultTerminationGrace)
+}
+
+// NewRealRunnerWithGrace is NewRealRunner with a custom termination grace
+// period. A grace of zero or less kills cancelled commands immediately.
+func NewRealRunnerWithGrace(grace time.Duration) Runner {
+	return &realRunner{promptIdle: DefaultPromptIdle, grace: grace}
 }
 
 // Run executes a command using os/exec and returns stdout, stderr, and error.
-func (r *realRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+func (r *realRunner) Run(parent context.Context, name string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
 	cmd := exec.CommandContext(ctx, name, args...)
+	if r.grace > 0 {
+		cmd.Cancel = func() error { return terminate(cmd.Process) }
+		// Also bounds how long grandchildren holding the output pipes can
+		// keep Wait blocked.
+		cmd.WaitDelay = r.grace
+	} else {
+		// Don't let grandchildren holding the output pipes keep Wait
+		// blocked after the command is stopped.
+		cmd.WaitDelay = time.Second
+	}
+	cmd.Stdin = nil
+	inv := InvocationFromContext(ctx)
+	inv.apply(cmd)
 
 	var stdout, stderr strings.Builder
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	w := &activityWriter{last: time.Now()}
+	outW := io.MultiWriter(&stdout, w)
+	errW := io.MultiWriter(&stderr, w)
+	if fn, ok := LineFuncFromContext(ctx); ok {
+		var mu sync.Mutex
+		outLines := &lineWriter{mu: &mu, fn: fn}
+		errLines := &lineWriter{mu: &mu, fn: fn, stderr: true}
+		outW = io.MultiWriter(outW, outLines)
+		errW = io.MultiWriter(errW, errLines)
+		defer outLines.Flush()
+		defer errLines.Flush()
+	}
+
+	var term *terminal
+	if inv.PTY {
+		term = startTerminal(cmd, outW)
+	}
+	if term == nil {
+		cmd.Stdout, cmd.Stderr = outW, errW
+	}
+
+	if err := cmd.Start(); err != nil {
+		term.close(0)
+		return "", "", err
+	}
+	term.started()
+
+	var prompted *types.InteractionRequiredError
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(r.promptIdle / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				tail, idle := w.state()
+				if idle < r.promptIdle {
+					continue
+				}
+				if prompt, expected, ok := detectPrompt(tail); ok {
+					prompted = &types.InteractionRequiredError{Prompt: prompt, Expected: expected}
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	term.close(cmd.WaitDelay)
+	close(done)
+	<-stopped
+
+	out := stdout.String()
+	if term != nil {
+		out = collapseRedraws(out)
+	}
+	if prompted != nil {
+		return out, stderr.String(), prompted
+	}
+	if err != nil && parent.Err() != nil {
+		return out, stderr.String(), &types.CancelledError{
+			Graceful: r.grace > 0 && !killed(cmd.ProcessState),
+			Err:      parent.Err(),
+		}
+	}
+	return out, stderr.String(), err
+}
 
-	err := cmd.Run()
-	return stdout.String(), stderr.String(), err
+// activityWriter tracks when a command last wrote output and keeps the
+// tail of that output for prompt detection.
+type activityWriter struct {
+	mu   sync.Mutex
+	tail []byte
+	last time.Time
+}
+
+const activityTail = 512
+
+func (w *activityWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tail = append(w.tail, p...)
+	if len(w.tail) > activityTail {
+		w.tail = w.tail[len(w.tail)-activityTail:]
+	}
+	w.last = time.Now()
+	return len(p), nil
+}
+
+// state returns the output tail and how long ago output was last written.
+func (w *activityWriter) state() (string, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.tail), time.Since(w.last)
+}
+
+// timeoutErr reports a command stopped by its context's deadline after
+// elapsed, keeping the output it wrote until then.
+func timeoutErr(ctx context.Context, operation types.Operation, backend string, elapsed time.Duration, stdout string, err error) error {
+	return &types.TimeoutError{
+		Operation:     operation,
+		Backend:       backend,
+		Elapsed:       elapsed,
+		PartialStdout: sanitize(ctx, stdout),
+		Err:           err,
+	}
 }
 
 // RunWithExternalError executes a command and wraps failures in ExternalFailureError.
@@ -42,7 +200,15 @@ func (r *realRunner) Run(ctx context.Context, name string, args ...string) (stri
 // Returns:
 //   - stdout: Captured standard output
 //   - stderr: Captured standard error
-//   - error: nil on success, ExternalFailureError on failure
+//   - error: nil on success, EscalationError or PermissionDeniedError if the
+//     runner could not obtain required privileges, PermissionDeniedError
+//     wrapping an ExternalFailureError if the command itself reported a
+//     permission failure, NetworkError wrapping an ExternalFailureError if it
+//     reported a network failure, ConflictError wrapping an
+//     ExternalFailureError if another operation held the package manager's
+//     lock, TimeoutError if the context's deadline
+//     passed while it ran, ExternalFailureError (with the exit code and
+//     duration) on any other failure
 func RunWithExternalError(
 	ctx context.Context,
 	runner Runner,
@@ -51,28 +217,86 @@ func RunWithExternalError(
 	name string,
 	args ...string,
 ) (stdout, stderr string, err error) {
-	stdout, stderr, err = runner.Run(ctx, name, args...)
+	start := time.Now()
+	stdout, stderr, err = runner.Run(WithOperation(ctx, operation), name, args...)
+	duration := time.Since(start)
 
+	if types.IsEscalationUnavailable(err) || types.IsPermissionDenied(err) {
+		return stdout, stderr, err
+	}
+	var irErr *types.InteractionRequiredError
+	if errors.As(err, &irErr) {
+		irErr.Operation, irErr.Backend = operation, backend
+		return stdout, stderr, irErr
+	}
+	var cancelledErr *types.CancelledError
+	if errors.As(err, &cancelledErr) {
+		cancelledErr.Operation, cancelledErr.Backend = operation, backend
+		if errors.Is(cancelledErr.Err, context.DeadlineExceeded) {
+			return stdout, stderr, timeoutErr(ctx, operation, backend, duration, stdout, cancelledErr)
+		}
+		return stdout, stderr, cancelledErr
+	}
+	// Custom runners may return whatever the killed command reported.
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return stdout, stderr, timeoutErr(ctx, operation, backend, duration, stdout, err)
+	}
 	if err != nil {
-		return stdout, stderr, &types.ExternalFailureError{
+		// A tool that read end-of-file from stdin while asking a question
+		// usually fails right away; report that as the prompt it was.
+		prompt, expected, ok := detectPrompt(stdout)
+		if !ok {
+			prompt, expected, ok = detectPrompt(stderr)
+		}
+		if ok {
+			return stdout, stderr, &types.InteractionRequiredError{
+				Operation: operation,
+				Backend:   backend,
+				Prompt:    prompt,
+				Expected:  expected,
+			}
+		}
+		extErr := &types.ExternalFailureError{
 			Operation: operation,
 			Backend:   backend,
-			Stdout:    sanitize(stdout),
-			Stderr:    sanitize(stderr),
+			Stdout:    sanitize(ctx, stdout),
+			Stderr:    sanitize(ctx, stderr),
 			Err:       err,
+			ExitCode:  max(exitCode(err), 0),
+			Duration:  duration,
+			Category:  categorize(backend, exitCode(err), stderr+"\n"+stdout),
+		}
+		// Tools asked for JSON output may report the error as JSON too.
+		if extErr.Payload = ParsePayload([]byte(stderr)); extErr.Payload == nil {
+			extErr.Payload = ParsePayload([]byte(stdout))
+		}
+		extErr.Payload = redactPayload(ctx, extErr.Payload)
+		// Tools on a pseudo-terminal print their errors to stdout.
+		if deniedStderr(stderr) || (stderr == "" && deniedStderr(stdout)) {
+			return stdout, stderr, &types.PermissionDeniedError{
+				Operation: operation,
+				Backend:   backend,
+				Reason:    "the command was denied permission",
+				Stderr:    extErr.Stderr,
+				Hint:      SuggestEscalation(backend, ""),
+				Err:       extErr,
+			}
 		}
+		if kind, ok := networkKindFromOutput(stderr + "\n" + stdout); ok {
+			return stdout, stderr, &types.NetworkError{Operation: operation, Backend: backend, Kind: kind, Err: extErr}
+		}
+		if extErr.Category == types.CategoryLocked {
+			holder, retryAfter := conflictHolder(stderr + "\n" + stdout)
+			return stdout, stderr, &types.ConflictError{
+				Operation:  operation,
+				Backend:    backend,
+				Holder:     holder,
+				RetryAfter: retryAfter,
+				Err:        extErr,
+			}
+		}
+		return stdout, stderr, extErr
 	}
 
 	return stdout, stderr, nil
 }
-
-// sanitize removes sensitive information from command output.
-// For now, this is a simple length limiter to prevent huge error messages.
-// In production, you might want to filter passwords, tokens, etc.
-func sanitize(s string) string {
-	const maxLen = 500
-	if len(s) > maxLen {
-		return s[:maxLen] + "... (truncated)"
-	}
-	return s
-}
@@ -2,7 +2,10 @@ package runner
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/frostyard/pm/internal/types"
 )
@@ -88,7 +91,7 @@ func TestRunWithExternalError_Failure(t *testing.T) {
 
 func TestRunWithExternalError_Sanitization(t *testing.T) {
 	// Create a very long output to test truncation
-	longOutput := make([]byte, 1000)
+	longOutput := make([]byte, 2*DefaultOutputLimit)
 	for i := range longOutput {
 		longOutput[i] = 'x'
 	}
@@ -114,54 +117,50 @@ func TestRunWithExternalError_Sanitization(t *testing.T) {
 	extErr := err.(*types.ExternalFailureError)
 
 	// Check that output was truncated
-	if len(extErr.Stdout) > 520 { // 500 + "... (truncated)" length tolerance
+	if len(extErr.Stdout) > DefaultOutputLimit+50 {
 		t.Errorf("Expected stdout to be truncated, got length: %d", len(extErr.Stdout))
 	}
-	if len(extErr.Stderr) > 520 {
+	if len(extErr.Stderr) > DefaultOutputLimit+50 {
 		t.Errorf("Expected stderr to be truncated, got length: %d", len(extErr.Stderr))
 	}
 }
 
-func TestSanitize(t *testing.T) {
+func TestTruncate(t *testing.T) {
 	tests := []struct {
-		name      string
-		input     string
-		maxLen    int
-		wantLen   int
-		wantTrunc bool
+		name  string
+		input string
+		limit int
+		want  string
 	}{
-		{
-			name:      "short string",
-			input:     "short",
-			wantLen:   5,
-			wantTrunc: false,
-		},
-		{
-			name:      "exactly at limit",
-			input:     string(make([]byte, 500)),
-			wantLen:   500,
-			wantTrunc: false,
-		},
-		{
-			name:      "over limit",
-			input:     string(make([]byte, 600)),
-			wantLen:   500 + len("... (truncated)"),
-			wantTrunc: true,
-		},
+		{"short string", "short", 10, "short"},
+		{"exactly at limit", "0123456789", 10, "0123456789"},
+		{"over limit keeps head and tail", "headXXXXXXXXXXtail", 8, "head\n... (10 bytes truncated) ...\ntail"},
+		{"unlimited", strings.Repeat("x", 100), 0, strings.Repeat("x", 100)},
+		{"rune boundaries", "ééééé", 4, "é\n... (6 bytes truncated) ...\né"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := sanitize(tt.input)
-			if len(result) > 520 && tt.wantTrunc {
-				// Allow some tolerance for truncation suffix
-			} else if len(result) != tt.wantLen && !tt.wantTrunc {
-				t.Errorf("Expected length %d, got %d", tt.wantLen, len(result))
+			if got := truncate(tt.input, tt.limit); got != tt.want {
+				t.Errorf("truncate() = %q, want %q", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestSanitize_OutputLimit(t *testing.T) {
+	long := strings.Repeat("x", DefaultOutputLimit+100)
+	if got := sanitize(context.Background(), long); len(got) >= len(long) {
+		t.Errorf("Expected default limit to truncate, got %d bytes", len(got))
+	}
+	if got := sanitize(WithOutputLimit(context.Background(), 0), long); got != long {
+		t.Error("Expected a limit of 0 to keep all output")
+	}
+	if got := sanitize(WithOutputLimit(context.Background(), 10), long); !strings.Contains(got, "bytes truncated") || len(got) > 60 {
+		t.Errorf("Expected custom limit to apply, got %q", got)
+	}
+}
+
 // fakeError is a simple error for testing.
 type fakeError struct {
 	msg string
@@ -170,3 +169,38 @@ type fakeError struct {
 func (e *fakeError) Error() string {
 	return e.msg
 }
+
+func TestRunWithExternalError_ExitCodeAndDuration(t *testing.T) {
+	_, _, err := RunWithExternalError(
+		context.Background(),
+		NewRealRunner(),
+		types.OperationInstall,
+		"test-backend",
+		"sh", "-c", "sleep 0.05; exit 3",
+	)
+
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) {
+		t.Fatalf("Expected *ExternalFailureError, got: %T", err)
+	}
+	if extErr.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", extErr.ExitCode)
+	}
+	if extErr.Duration < 50*time.Millisecond {
+		t.Errorf("Duration = %v, want at least 50ms", extErr.Duration)
+	}
+}
+
+func TestRunWithExternalError_NoExitCode(t *testing.T) {
+	runner := &FakeRunner{ErrResponse: &fakeError{msg: "failed"}}
+
+	_, _, err := RunWithExternalError(context.Background(), runner, types.OperationInstall, "test-backend", "x")
+
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) {
+		t.Fatalf("Expected *ExternalFailureError, got: %T", err)
+	}
+	if extErr.ExitCode != 0 {
+		t.Errorf("ExitCode = %d, want 0 for an error without exit status", extErr.ExitCode)
+	}
+}
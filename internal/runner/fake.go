@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// FakeRunner is a deterministic fake runner for unit tests.
+//
+// By default every call returns the same canned response. To model flows
+// that run several commands, or retries, set Script instead: each call then
+// consumes the next expected invocation and returns its output.
+type FakeRunner struct {
+	// StdoutResponse is the stdout to return.
+	StdoutResponse string
+
+	// StderrResponse is the stderr to return.
+	StderrResponse string
+
+	// ErrResponse is the error to return.
+	ErrResponse error
+
+	// Script lists the expected invocations in order. When it is set, the
+	// canned responses above are ignored; a call that does not match the
+	// next entry, or comes after the last one, fails with an error that
+	// Verify also reports.
+	Script []FakeCall
+
+	// LastCommand captures the last command executed for assertions.
+	LastCommand string
+
+	// LastArgs captures the last args for assertions.
+	LastArgs []string
+
+	mu         sync.Mutex
+	next       int
+	mismatches []error
+}
+
+// FakeCall is one expected invocation in a FakeRunner script.
+type FakeCall struct {
+	// Name is the expected command name; empty matches any command.
+	Name string
+
+	// Args is a regular expression the space-separated arguments must
+	// match; empty matches any arguments.
+	Args string
+
+	// Stdout, Stderr and Err are returned by the call.
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// Run executes the fake command.
+func (f *FakeRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.LastCommand = name
+	f.LastArgs = args
+	if f.Script == nil {
+		return f.StdoutResponse, f.StderrResponse, f.ErrResponse
+	}
+
+	if f.next >= len(f.Script) {
+		return "", "", f.mismatch(fmt.Errorf("unexpected call %d %q: script has %d calls", f.next+1, command(name, args), len(f.Script)))
+	}
+	call := f.Script[f.next]
+	f.next++
+	if call.Name != "" && call.Name != name {
+		return "", "", f.mismatch(fmt.Errorf("call %d: got %q, want command %q", f.next, command(name, args), call.Name))
+	}
+	if call.Args != "" && !regexp.MustCompile(call.Args).MatchString(strings.Join(args, " ")) {
+		return "", "", f.mismatch(fmt.Errorf("call %d: got %q, want arguments matching %q", f.next, command(name, args), call.Args))
+	}
+	return call.Stdout, call.Stderr, call.Err
+}
+
+// Verify reports calls that did not match the script and scripted calls
+// that were never made. It returns nil without a script.
+func (f *FakeRunner) Verify() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	errs := f.mismatches
+	if f.next < len(f.Script) {
+		errs = append(errs, fmt.Errorf("%d of %d scripted calls not made", len(f.Script)-f.next, len(f.Script)))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FakeRunner) mismatch(err error) error {
+	err = fmt.Errorf("fake runner: %w", err)
+	f.mismatches = append(f.mismatches, err)
+	return err
+}
+
+func command(name string, args []string) string {
+	return strings.TrimSpace(name + " " + strings.Join(args, " "))
+}
@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFakeRunner_Script(t *testing.T) {
+	fake := &FakeRunner{Script: []FakeCall{
+		{Name: "snap", Args: `^refresh --list$`, Stdout: "Name Version\nfirefox 125.0\n"},
+		{Name: "snap", Args: `^refresh\b`, Stderr: "error: snap \"firefox\" has \"install-snap\" change in progress", Err: errors.New("exit status 1")},
+		{Name: "snap", Args: `^refresh\b`, Stdout: "firefox 125.0 from Mozilla✓ refreshed\n"},
+	}}
+	ctx := context.Background()
+
+	if stdout, _, err := fake.Run(ctx, "snap", "refresh", "--list"); err != nil || !strings.Contains(stdout, "firefox") {
+		t.Errorf("Call 1 = %q, %v", stdout, err)
+	}
+	if _, _, err := fake.Run(ctx, "snap", "refresh"); err == nil {
+		t.Error("Call 2 should fail")
+	}
+	if stdout, _, err := fake.Run(ctx, "snap", "refresh"); err != nil || !strings.Contains(stdout, "refreshed") {
+		t.Errorf("Call 3 = %q, %v", stdout, err)
+	}
+	if err := fake.Verify(); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+}
+
+func TestFakeRunner_ScriptMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls [][]string
+		want  string
+	}{
+		{"wrong command", [][]string{{"flatpak", "update"}}, `want command "snap"`},
+		{"wrong arguments", [][]string{{"snap", "remove", "firefox"}}, `want arguments matching`},
+		{"too many calls", [][]string{{"snap", "refresh"}, {"snap", "refresh"}}, "unexpected call 2"},
+		{"too few calls", nil, "1 of 1 scripted calls not made"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &FakeRunner{Script: []FakeCall{{Name: "snap", Args: `^refresh`}}}
+			for _, call := range tt.calls {
+				_, _, _ = fake.Run(context.Background(), call[0], call[1:]...)
+			}
+			if err := fake.Verify(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeRunner_Canned(t *testing.T) {
+	fake := &FakeRunner{StdoutResponse: "ok"}
+	for range 2 {
+		if stdout, _, _ := fake.Run(context.Background(), "brew", "list"); stdout != "ok" {
+			t.Errorf("Run() = %q, want ok", stdout)
+		}
+	}
+	if err := fake.Verify(); err != nil {
+		t.Errorf("Verify() = %v without a script", err)
+	}
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Invocation holds per-command execution settings.
+type Invocation struct {
+	// Env lists KEY=VALUE entries added to the inherited environment;
+	// later entries override earlier ones and the inherited values.
+	Env []string
+
+	// Dir is the working directory. Empty means the current directory.
+	Dir string
+
+	// Stdin is fed to the command's standard input. Empty means no input
+	// (the null device).
+	Stdin string
+
+	// PTY runs the command with stdout and stderr on a pseudo-terminal, for
+	// tools that only report progress when writing to a terminal. Both
+	// streams are then returned as stdout.
+	PTY bool
+}
+
+type invocationKey struct{}
+
+// InvocationFromContext returns the settings recorded by WithEnv, WithDir
+// and WithStdin.
+func InvocationFromContext(ctx context.Context) Invocation {
+	inv, _ := ctx.Value(invocationKey{}).(Invocation)
+	return inv
+}
+
+func withInvocation(ctx context.Context, update func(*Invocation)) context.Context {
+	inv := InvocationFromContext(ctx)
+	inv.Env = append([]string(nil), inv.Env...)
+	update(&inv)
+	return context.WithValue(ctx, invocationKey{}, inv)
+}
+
+// WithEnv returns a copy of ctx that adds env (KEY=VALUE entries) to the
+// environment of commands run with it.
+func WithEnv(ctx context.Context, env ...string) context.Context {
+	return withInvocation(ctx, func(inv *Invocation) {
+		inv.Env = append(inv.Env, env...)
+	})
+}
+
+// WithDir returns a copy of ctx that runs commands in dir.
+func WithDir(ctx context.Context, dir string) context.Context {
+	return withInvocation(ctx, func(inv *Invocation) {
+		inv.Dir = dir
+	})
+}
+
+// WithStdin returns a copy of ctx that feeds stdin to commands run with it.
+func WithStdin(ctx context.Context, stdin string) context.Context {
+	return withInvocation(ctx, func(inv *Invocation) {
+		inv.Stdin = stdin
+	})
+}
+
+// WithPTY returns a copy of ctx that runs commands on a pseudo-terminal
+// where one is available.
+func WithPTY(ctx context.Context) context.Context {
+	return withInvocation(ctx, func(inv *Invocation) {
+		inv.PTY = true
+	})
+}
+
+// apply configures cmd according to inv.
+func (inv Invocation) apply(cmd *exec.Cmd) {
+	if len(inv.Env) > 0 {
+		cmd.Env = append(os.Environ(), inv.Env...)
+	}
+	cmd.Dir = inv.Dir
+	if inv.Stdin != "" {
+		cmd.Stdin = strings.NewReader(inv.Stdin)
+	}
+}
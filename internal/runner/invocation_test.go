@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInvocationFromContext(t *testing.T) {
+	ctx := WithEnv(context.Background(), "A=1")
+	child := WithDir(WithEnv(ctx, "B=2"), "/tmp")
+	child = WithStdin(child, "input")
+
+	want := Invocation{Env: []string{"A=1", "B=2"}, Dir: "/tmp", Stdin: "input"}
+	if got := InvocationFromContext(child); !reflect.DeepEqual(got, want) {
+		t.Errorf("InvocationFromContext() = %+v, want %+v", got, want)
+	}
+	if got := InvocationFromContext(ctx); !reflect.DeepEqual(got.Env, []string{"A=1"}) || got.Dir != "" {
+		t.Errorf("Parent context was modified: %+v", got)
+	}
+}
+
+func TestRealRunner_Invocation(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithStdin(WithDir(WithEnv(context.Background(), "PM_TEST_VALUE=hello"), dir), "from stdin\n")
+
+	stdout, _, err := NewRealRunner().Run(ctx, "sh", "-c", `echo "$PM_TEST_VALUE"; pwd; cat`)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	want := []string{"hello", dir, "from stdin"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("output = %q, want %q", lines, want)
+	}
+}
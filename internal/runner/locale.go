@@ -0,0 +1,29 @@
+package runner
+
+import "context"
+
+// DefaultLocale is the locale commands run with unless configured
+// otherwise. Backends parse English output, so commands must not be
+// localized.
+const DefaultLocale = "C"
+
+// localeRunner runs commands with a fixed locale.
+type localeRunner struct {
+	base   Runner
+	locale string
+}
+
+// NewLocaleRunner returns a Runner that runs commands of base with LC_ALL
+// and LANG set to locale, so their output does not depend on the user's
+// language settings. Environment entries requested with WithEnv still take
+// precedence.
+func NewLocaleRunner(base Runner, locale string) Runner {
+	return &localeRunner{base: base, locale: locale}
+}
+
+func (r *localeRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	ctx = withInvocation(ctx, func(inv *Invocation) {
+		inv.Env = append([]string{"LC_ALL=" + r.locale, "LANG=" + r.locale}, inv.Env...)
+	})
+	return r.base.Run(ctx, name, args...)
+}
@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// envCapture records the environment requested for the last command.
+type envCapture struct {
+	env []string
+}
+
+func (r *envCapture) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	r.env = InvocationFromContext(ctx).Env
+	return "", "", nil
+}
+
+func TestLocaleRunner(t *testing.T) {
+	base := &envCapture{}
+	r := NewLocaleRunner(base, DefaultLocale)
+
+	if _, _, err := r.Run(WithEnv(context.Background(), "LANG=de_DE.UTF-8"), "flatpak", "list"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"LC_ALL=C", "LANG=C", "LANG=de_DE.UTF-8"}
+	if !slices.Equal(base.env, want) {
+		t.Errorf("Env = %v, want %v (explicit entries last, so they win)", base.env, want)
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// networkMarkers map lowercase output fragments of failed commands to the
+// kind of network failure they indicate. Earlier entries win.
+var networkMarkers = []struct {
+	marker string
+	kind   string
+}{
+	{"could not resolve host", types.NetworkDNS},
+	{"temporary failure in name resolution", types.NetworkDNS},
+	{"name or service not known", types.NetworkDNS},
+	{"nodename nor servname provided", types.NetworkDNS},
+	{"certificate verify failed", types.NetworkTLS},
+	{"ssl certificate problem", types.NetworkTLS},
+	{"tls handshake", types.NetworkTLS},
+	{"connection refused", types.NetworkConnectionRefused},
+	{"connection reset", types.NetworkConnectionReset},
+	{"timed out", types.NetworkTimeout},
+	{"timeout was reached", types.NetworkTimeout},
+	{"network is unreachable", types.NetworkUnreachable},
+	{"no route to host", types.NetworkUnreachable},
+	{"unable to contact snap store", types.NetworkUnreachable},
+}
+
+// networkKindFromOutput returns the kind of network failure reported in a
+// failed command's output.
+func networkKindFromOutput(output string) (string, bool) {
+	lower := strings.ToLower(output)
+	for _, m := range networkMarkers {
+		if strings.Contains(lower, m.marker) {
+			return m.kind, true
+		}
+	}
+	return "", false
+}
+
+// networkKind returns the kind of network failure err is, if any.
+func networkKind(err error) (string, bool) {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var authErr x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return types.NetworkDNS, true
+	case errors.As(err, &certErr), errors.As(err, &authErr), errors.As(err, &hostErr):
+		return types.NetworkTLS, true
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return types.NetworkConnectionRefused, true
+	case errors.Is(err, syscall.ECONNRESET):
+		return types.NetworkConnectionReset, true
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return types.NetworkUnreachable, true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return types.NetworkTimeout, true
+	}
+	return "", false
+}
+
+// ClassifyNetworkError returns a *types.NetworkError wrapping extErr if
+// the error it carries is a DNS, connection, TLS or timeout failure, and
+// extErr otherwise. Failures caused by ctx being done are never network
+// errors.
+func ClassifyNetworkError(ctx context.Context, extErr *types.ExternalFailureError) error {
+	if ctx.Err() != nil {
+		return extErr
+	}
+	kind, ok := networkKind(extErr.Err)
+	if !ok {
+		return extErr
+	}
+	extErr.Category = types.CategoryNetwork
+	return &types.NetworkError{Operation: extErr.Operation, Backend: extErr.Backend, Kind: kind, Err: extErr}
+}
+
+// ClassifyHTTPStatus returns a *types.NetworkError wrapping extErr if
+// status is a request timeout, rate limit or server error, and extErr
+// otherwise.
+func ClassifyHTTPStatus(status int, extErr *types.ExternalFailureError) error {
+	if status != http.StatusRequestTimeout && status != http.StatusTooManyRequests && status < 500 {
+		return extErr
+	}
+	extErr.Category = types.CategoryNetwork
+	return &types.NetworkError{
+		Operation:  extErr.Operation,
+		Backend:    extErr.Backend,
+		Kind:       types.NetworkHTTPStatus,
+		StatusCode: status,
+		Err:        extErr,
+	}
+}
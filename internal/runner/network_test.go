@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestClassifyNetworkError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "formulae.brew.sh", IsNotFound: true}, types.NetworkDNS},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, types.NetworkConnectionRefused},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, types.NetworkConnectionReset},
+		{"unreachable", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}, types.NetworkUnreachable},
+		{"tls", x509.UnknownAuthorityError{}, types.NetworkTLS},
+		{"timeout", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, types.NetworkTimeout},
+		{"other", errors.New("unexpected EOF"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extErr := &types.ExternalFailureError{Operation: types.OperationSearch, Backend: "brew", Err: tt.err}
+			err := ClassifyNetworkError(context.Background(), extErr)
+
+			var netErr *types.NetworkError
+			if tt.want == "" {
+				if errors.As(err, &netErr) {
+					t.Errorf("Expected no network error, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &netErr) || netErr.Kind != tt.want {
+				t.Fatalf("Expected %s network error, got %v", tt.want, err)
+			}
+			if netErr.Retryable() == (tt.want == types.NetworkTLS) {
+				t.Errorf("Retryable() = %v for %s", netErr.Retryable(), tt.want)
+			}
+			if !types.IsExternalFailure(err) {
+				t.Error("Expected the external failure to be wrapped")
+			}
+		})
+	}
+}
+
+func TestClassifyNetworkError_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	extErr := &types.ExternalFailureError{Err: &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}}
+	if err := ClassifyNetworkError(ctx, extErr); types.IsNetwork(err) {
+		t.Errorf("Expected cancellation not to be a network error, got %v", err)
+	}
+}
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		network   bool
+		retryable bool
+	}{
+		{429, true, true},
+		{503, true, true},
+		{408, true, true},
+		{404, false, false},
+	}
+	for _, tt := range tests {
+		err := ClassifyHTTPStatus(tt.status, &types.ExternalFailureError{Backend: "brew"})
+		var netErr *types.NetworkError
+		if errors.As(err, &netErr) != tt.network {
+			t.Errorf("ClassifyHTTPStatus(%d) = %v, want network error %v", tt.status, err, tt.network)
+			continue
+		}
+		if tt.network && (netErr.StatusCode != tt.status || netErr.Retryable() != tt.retryable) {
+			t.Errorf("ClassifyHTTPStatus(%d) = %+v", tt.status, netErr)
+		}
+	}
+}
+
+func TestRunWithExternalError_DetectsNetworkFailure(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   string
+	}{
+		{"curl: (6) Could not resolve host: formulae.brew.sh", types.NetworkDNS},
+		{"error: Unable to connect to dl.flathub.org: Connection refused", types.NetworkConnectionRefused},
+		{"curl: (28) Operation timed out after 30000 milliseconds", types.NetworkTimeout},
+		{"error: unable to contact snap store", types.NetworkUnreachable},
+		{"curl: (60) SSL certificate problem: unable to get local issuer certificate", types.NetworkTLS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			fake := &FakeRunner{StderrResponse: tt.stderr, ErrResponse: errors.New("exit status 1")}
+			_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "brew", "brew", "install", "jq")
+
+			var netErr *types.NetworkError
+			if !errors.As(err, &netErr) || netErr.Kind != tt.want || netErr.Backend != "brew" {
+				t.Errorf("Expected %s network error, got %v", tt.want, err)
+			}
+		})
+	}
+}
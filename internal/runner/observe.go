@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// Command describes one command run through an observing runner.
+type Command struct {
+	Operation types.Operation
+	Name      string
+	Args      []string
+}
+
+// CommandResult describes how an observed command finished.
+type CommandResult struct {
+	Duration time.Duration
+	// ExitCode is the process exit status, or -1 if the command could not
+	// be started or was killed by a signal.
+	ExitCode int
+	Err      error
+}
+
+// observingRunner reports every command to start and exit callbacks.
+type observingRunner struct {
+	base    Runner
+	onStart func(ctx context.Context, cmd Command)
+	onExit  func(ctx context.Context, cmd Command, res CommandResult)
+}
+
+// NewObservingRunner wraps base so that onStart is called before and onExit
+// after every command it runs. Either callback may be nil.
+func NewObservingRunner(base Runner, onStart func(ctx context.Context, cmd Command), onExit func(ctx context.Context, cmd Command, res CommandResult)) Runner {
+	return &observingRunner{base: base, onStart: onStart, onExit: onExit}
+}
+
+func (r *observingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	op, _ := OperationFromContext(ctx)
+	cmd := Command{Operation: op, Name: name, Args: append([]string(nil), args...)}
+	if r.onStart != nil {
+		r.onStart(ctx, cmd)
+	}
+	start := time.Now()
+	stdout, stderr, err := r.base.Run(ctx, name, args...)
+	if r.onExit != nil {
+		code := 0
+		if err != nil {
+			code = exitCode(err)
+		}
+		r.onExit(ctx, cmd, CommandResult{Duration: time.Since(start), ExitCode: code, Err: err})
+	}
+	return stdout, stderr, err
+}
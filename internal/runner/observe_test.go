@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestObservingRunner(t *testing.T) {
+	var started Command
+	var result CommandResult
+	r := NewObservingRunner(NewRealRunner(),
+		func(ctx context.Context, cmd Command) { started = cmd },
+		func(ctx context.Context, cmd Command, res CommandResult) { result = res },
+	)
+
+	ctx := WithOperation(context.Background(), types.OperationInstall)
+	_, _, err := r.Run(ctx, "sh", "-c", "exit 3")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if started.Name != "sh" || started.Operation != types.OperationInstall || len(started.Args) != 2 {
+		t.Errorf("Unexpected start %+v", started)
+	}
+	if result.ExitCode != 3 || result.Err != err || result.Duration <= 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if _, _, err := r.Run(ctx, "true"); err != nil || result.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d (%v)", result.ExitCode, err)
+	}
+}
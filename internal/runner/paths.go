@@ -0,0 +1,23 @@
+package runner
+
+import "context"
+
+// pathRunner substitutes configured executables for command names.
+type pathRunner struct {
+	base  Runner
+	paths map[string]string
+}
+
+// NewPathRunner wraps base so that commands named in paths (e.g. "brew")
+// run the configured executable (e.g. "/home/linuxbrew/.linuxbrew/bin/brew")
+// instead of the one found on PATH.
+func NewPathRunner(base Runner, paths map[string]string) Runner {
+	return &pathRunner{base: base, paths: paths}
+}
+
+func (r *pathRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	if path, ok := r.paths[name]; ok && path != "" {
+		name = path
+	}
+	return r.base.Run(ctx, name, args...)
+}
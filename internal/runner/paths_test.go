@@ -0,0 +1,21 @@
+package runner
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPathRunner(t *testing.T) {
+	base := &FakeRunner{}
+	r := NewPathRunner(base, map[string]string{"brew": "/opt/brew/bin/brew"})
+
+	_, _, _ = r.Run(context.Background(), "brew", "list")
+	if base.LastCommand != "/opt/brew/bin/brew" {
+		t.Errorf("Expected configured path, got %q", base.LastCommand)
+	}
+
+	_, _, _ = r.Run(context.Background(), "flatpak", "list")
+	if base.LastCommand != "flatpak" {
+		t.Errorf("Expected unconfigured command unchanged, got %q", base.LastCommand)
+	}
+}
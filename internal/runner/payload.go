@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// maxPayloadBody limits how much of an HTTP error response is read.
+const maxPayloadBody = 64 << 10
+
+// ParsePayload extracts a structured error from a JSON body, for
+// ExternalFailureError.Payload. It understands snapd's error envelope,
+//
+//	{"type": "error", "result": {"kind": "snap-not-found", "message": "...", "value": "foo"}}
+//
+// and objects carrying "kind", "message" (or "error") and "value" at the top
+// level, as returned by web APIs and CLIs asked for JSON output. The result
+// holds whichever of "kind", "message" and "value" are present. It returns
+// nil if body is not such an object.
+func ParsePayload(body []byte) map[string]interface{} {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil
+	}
+	if result, ok := obj["result"].(map[string]interface{}); ok && obj["type"] == "error" {
+		obj = result
+	}
+
+	payload := make(map[string]interface{})
+	if kind, ok := obj["kind"].(string); ok && kind != "" {
+		payload["kind"] = kind
+	}
+	if msg, ok := obj["message"].(string); ok && msg != "" {
+		payload["message"] = msg
+	} else if msg, ok := obj["error"].(string); ok && msg != "" {
+		payload["message"] = msg
+	}
+	if value, ok := obj["value"]; ok && value != nil {
+		payload["value"] = value
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return payload
+}
+
+// ReadPayload parses the body of an HTTP error response with ParsePayload,
+// reading at most 64 KiB of it, and redacts secrets from it.
+func ReadPayload(ctx context.Context, resp *http.Response) map[string]interface{} {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPayloadBody))
+	if err != nil {
+		return nil
+	}
+	return redactPayload(ctx, ParsePayload(body))
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestParsePayload(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "snapd envelope",
+			body: `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"snap not found","kind":"snap-not-found","value":"nosuchsnap"}}`,
+			want: map[string]interface{}{"kind": "snap-not-found", "message": "snap not found", "value": "nosuchsnap"},
+		},
+		{
+			name: "top level",
+			body: "  {\"kind\": \"rate-limited\", \"message\": \"slow down\"}\n",
+			want: map[string]interface{}{"kind": "rate-limited", "message": "slow down"},
+		},
+		{
+			name: "error field",
+			body: `{"error": "Not Found"}`,
+			want: map[string]interface{}{"message": "Not Found"},
+		},
+		{name: "snapd success", body: `{"type":"sync","result":{"version":"2.61"}}`},
+		{name: "not json", body: "error: snap \"x\" not found"},
+		{name: "array", body: `[{"message": "x"}]`},
+		{name: "empty", body: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParsePayload([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePayload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithExternalError_Payload(t *testing.T) {
+	fake := &FakeRunner{StderrResponse: `{"kind": "snap-not-found", "message": "snap \"x\" not found"}`, ErrResponse: errors.New("exit status 1")}
+	_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "snap", "snap", "install", "x")
+
+	var extErr *types.ExternalFailureError
+	if !errors.As(err, &extErr) || extErr.Payload["kind"] != "snap-not-found" {
+		t.Errorf("Expected a payload, got %#v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"strings"
+)
+
+// promptPatterns maps fragments of a prompt line (lower-cased) to a
+// description of the input the tool is waiting for.
+var promptPatterns = []struct {
+	fragment string
+	expected string
+}{
+	{"password", "password"},
+	{"passphrase", "password"},
+	{"which do you want to use", "selection from a list"},
+	{"(0 to abort)", "selection from a list"},
+	{"[y/n]", "yes/no confirmation"},
+	{"(y/n)", "yes/no confirmation"},
+	{"[yes/no]", "yes/no confirmation"},
+	{"(yes/no)", "yes/no confirmation"},
+	{"do you want to continue", "yes/no confirmation"},
+	{"do you want to proceed", "yes/no confirmation"},
+	{"press enter", "key press"},
+	{"press return", "key press"},
+	{"press any key", "key press"},
+}
+
+// detectPrompt reports whether output ends with a line asking for user
+// input. It returns that line and a description of the expected input.
+func detectPrompt(output string) (prompt, expected string, ok bool) {
+	prompt = lastLine(output)
+	if prompt == "" {
+		return "", "", false
+	}
+	lower := strings.ToLower(prompt)
+	for _, p := range promptPatterns {
+		if !strings.Contains(lower, p.fragment) {
+			continue
+		}
+		// Questions end like one; "press enter to continue..." need not.
+		if p.expected == "key press" || strings.ContainsAny(prompt[len(prompt)-1:], ":?])") {
+			return prompt, p.expected, true
+		}
+	}
+	return "", "", false
+}
+
+// lastLine returns the last non-blank line of s, trimmed.
+func lastLine(s string) string {
+	s = strings.TrimRight(s, " \t\r\n")
+	if i := strings.LastIndexAny(s, "\r\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestDetectPrompt(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"Looking for matches…\nWhich do you want to use (0 to abort)? [0-2]: ", "selection from a list"},
+		{"Proceed with these changes to the system installation? [Y/n]: ", "yes/no confirmation"},
+		{"Password:", "password"},
+		{"==> Caveats\nPress RETURN to continue or any other key to abort", "key press"},
+		{"Installing... done.\n", ""},
+		{"error: wrong password supplied\n", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		_, expected, ok := detectPrompt(tt.output)
+		if ok != (tt.expected != "") || expected != tt.expected {
+			t.Errorf("detectPrompt(%q) = %q, %v; want %q", tt.output, expected, ok, tt.expected)
+		}
+	}
+}
+
+func TestRealRunner_WatchdogStopsPrompt(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	r := &realRunner{promptIdle: 100 * time.Millisecond}
+
+	start := time.Now()
+	// `sleep` rather than `read`: a tool that ignores EOF on stdin and keeps waiting.
+	_, _, err := r.Run(context.Background(), "sh", "-c", `printf 'Do you want to continue? [Y/n] '; sleep 10`)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Watchdog took too long: %v", elapsed)
+	}
+	var irErr *types.InteractionRequiredError
+	if !errors.As(err, &irErr) || irErr.Expected != "yes/no confirmation" {
+		t.Fatalf("Expected InteractionRequiredError, got %v", err)
+	}
+}
+
+func TestRealRunner_StdinIsClosed(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	r := NewRealRunner()
+	stdout, _, err := r.Run(context.Background(), "sh", "-c", `read answer || echo eof`)
+	if err != nil || stdout != "eof\n" {
+		t.Errorf("Expected read to see EOF, got %q, %v", stdout, err)
+	}
+}
+
+func TestRunWithExternalError_PromptOnFailure(t *testing.T) {
+	runner := &FakeRunner{
+		StdoutResponse: "Which do you want to use (0 to abort)? [0-2]: ",
+		ErrResponse:    errors.New("exit status 1"),
+	}
+
+	_, _, err := RunWithExternalError(context.Background(), runner, types.OperationInstall, "flatpak", "flatpak", "install", "gimp")
+	var irErr *types.InteractionRequiredError
+	if !errors.As(err, &irErr) {
+		t.Fatalf("Expected InteractionRequiredError, got %v", err)
+	}
+	if irErr.Backend != "flatpak" || irErr.Operation != types.OperationInstall || irErr.Expected != "selection from a list" {
+		t.Errorf("Unexpected error fields %+v", irErr)
+	}
+}
@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// terminal attaches a command's stdout and stderr to a pseudo-terminal and
+// copies what the command writes to an io.Writer, with terminal escape
+// sequences removed.
+type terminal struct {
+	master, slave *os.File
+	copied        chan struct{}
+}
+
+// startTerminal prepares cmd to run on a new pseudo-terminal. It returns
+// nil if no pseudo-terminal is available, in which case cmd is unchanged.
+func startTerminal(cmd *exec.Cmd, out io.Writer) *terminal {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil
+	}
+	cmd.Stdout, cmd.Stderr = slave, slave
+	detachTerminal(cmd)
+
+	t := &terminal{master: master, slave: slave, copied: make(chan struct{})}
+	go func() {
+		defer close(t.copied)
+		// Reading fails with EIO once every writer has closed the slave.
+		_, _ = io.Copy(&ansiStripper{w: out}, master)
+	}()
+	return t
+}
+
+// started releases the parent's copy of the slave, so that reads from the
+// master end when the command exits.
+func (t *terminal) started() {
+	if t != nil {
+		_ = t.slave.Close()
+	}
+}
+
+// close waits up to delay for the command's output to be copied, then
+// closes the master. Output still held open by grandchildren is dropped.
+func (t *terminal) close(delay time.Duration) {
+	if t == nil {
+		return
+	}
+	_ = t.slave.Close()
+	select {
+	case <-t.copied:
+	case <-time.After(delay):
+	}
+	_ = t.master.Close()
+	<-t.copied
+}
+
+// ansiStripper removes terminal escape sequences (colors, cursor movement,
+// window titles) from the output written through it.
+type ansiStripper struct {
+	w     io.Writer
+	state int
+	buf   []byte
+}
+
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+	ansiOSC
+	ansiOSCEscape
+)
+
+func (s *ansiStripper) Write(p []byte) (int, error) {
+	s.buf = s.buf[:0]
+	for _, c := range p {
+		switch s.state {
+		case ansiText:
+			if c == 0x1b {
+				s.state = ansiEscape
+			} else {
+				s.buf = append(s.buf, c)
+			}
+		case ansiEscape:
+			switch c {
+			case '[':
+				s.state = ansiCSI
+			case ']':
+				s.state = ansiOSC
+			default:
+				s.state = ansiText
+			}
+		case ansiCSI:
+			if c >= 0x40 && c <= 0x7e {
+				s.state = ansiText
+			}
+		case ansiOSC:
+			if c == 0x07 {
+				s.state = ansiText
+			} else if c == 0x1b {
+				s.state = ansiOSCEscape
+			}
+		case ansiOSCEscape:
+			s.state = ansiText
+		}
+	}
+	if _, err := s.w.Write(s.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// collapseRedraws turns terminal output into plain lines: CRLF line endings
+// become LF, and a line redrawn with carriage returns (a progress bar)
+// keeps only its final text.
+func collapseRedraws(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// openPTY reports that pseudo-terminals are not supported on this platform;
+// commands fall back to plain pipes.
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on this platform")
+}
+
+func detachTerminal(cmd *exec.Cmd) {}
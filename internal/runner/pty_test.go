@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAnsiStripper(t *testing.T) {
+	var out strings.Builder
+	s := &ansiStripper{w: &out}
+
+	_, _ = s.Write([]byte("\x1b[1mbold\x1b[0m \x1b]0;title\x07plain \x1b"))
+	_, _ = s.Write([]byte("[32mgreen\x1b[0m"))
+
+	if got := out.String(); got != "bold plain green" {
+		t.Errorf("stripped = %q, want %q", got, "bold plain green")
+	}
+}
+
+func TestCollapseRedraws(t *testing.T) {
+	in := "Download 10%\rDownload 90%\rDownload done\r\nfirefox 123 installed\r\n"
+	want := "Download done\nfirefox 123 installed\n"
+	if got := collapseRedraws(in); got != want {
+		t.Errorf("collapseRedraws() = %q, want %q", got, want)
+	}
+}
+
+func TestRealRunner_PTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are only supported on linux")
+	}
+	master, slave, err := openPTY()
+	if err != nil {
+		t.Skipf("no pseudo-terminal available: %v", err)
+	}
+	_ = master.Close()
+	_ = slave.Close()
+
+	var lines []string
+	ctx := WithLineFunc(WithPTY(context.Background()), func(line string, stderr bool) {
+		lines = append(lines, line)
+	})
+	script := `if [ -t 1 ]; then echo tty; else echo pipe; fi; printf '\033[32m50%%\r100%%\033[0m\n'; echo err >&2`
+	stdout, stderr, err := NewRealRunner().Run(ctx, "sh", "-c", script)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := "tty\n100%\nerr\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+	if stderr != "" {
+		t.Errorf("stderr = %q, want it merged into stdout", stderr)
+	}
+	if strings.Join(lines, ",") != "tty,50%,100%,err" {
+		t.Errorf("lines = %q", lines)
+	}
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Interaction is one recorded command and its result.
+type Interaction struct {
+	Operation string   `json:"operation,omitempty"`
+	Command   []string `json:"command"`
+	Stdout    string   `json:"stdout"`
+	Stderr    string   `json:"stderr"`
+	ExitCode  int      `json:"exit_code"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Cassette is the golden file format shared by the recording and replay
+// runners.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette written by a recording runner.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// recordingRunner runs commands through base and saves every invocation.
+type recordingRunner struct {
+	base Runner
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingRunner wraps base so that every command and its output are
+// written to the cassette at path. The file is rewritten after each
+// command, so a recording survives an interrupted run.
+func NewRecordingRunner(base Runner, path string) Runner {
+	return &recordingRunner{base: base, path: path}
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	stdout, stderr, err := r.base.Run(ctx, name, args...)
+
+	op, _ := OperationFromContext(ctx)
+	in := Interaction{
+		Operation: string(op),
+		Command:   append([]string{name}, args...),
+		Stdout:    stdout,
+		Stderr:    stderr,
+	}
+	if err != nil {
+		in.ExitCode = exitCode(err)
+		in.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	data, jsonErr := json.MarshalIndent(&r.cassette, "", "  ")
+	if jsonErr == nil {
+		jsonErr = os.WriteFile(r.path, append(data, '\n'), 0o644)
+	}
+	if jsonErr != nil {
+		return stdout, stderr, errors.Join(err, fmt.Errorf("recording %s: %w", r.path, jsonErr))
+	}
+	return stdout, stderr, err
+}
+
+// replayRunner answers commands from a cassette without running anything.
+type replayRunner struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// NewReplayRunner returns a Runner that serves the interactions of c.
+//
+// A command is answered by the first unused interaction with the same
+// command line; once all of them are used, the last one is repeated.
+// Commands that were never recorded fail with an error naming them.
+func NewReplayRunner(c *Cassette) Runner {
+	return &replayRunner{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	cmd := append([]string{name}, args...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, in := range r.cassette.Interactions {
+		if !slices.Equal(in.Command, cmd) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return in.result()
+		}
+		last = i
+	}
+	if last >= 0 {
+		return r.cassette.Interactions[last].result()
+	}
+	return "", "", fmt.Errorf("replay: no recorded interaction for %q", strings.Join(cmd, " "))
+}
+
+func (in Interaction) result() (string, string, error) {
+	if in.Error == "" {
+		return in.Stdout, in.Stderr, nil
+	}
+	return in.Stdout, in.Stderr, &ReplayedError{Message: in.Error, Code: in.ExitCode}
+}
+
+// ReplayedError is the error returned for a recorded command that failed.
+type ReplayedError struct {
+	Message string
+	Code    int
+}
+
+func (e *ReplayedError) Error() string {
+	return e.Message
+}
+
+// ExitCode returns the recorded exit status, like (*exec.ExitError).ExitCode.
+func (e *ReplayedError) ExitCode() int {
+	return e.Code
+}
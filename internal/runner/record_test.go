@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	base := scriptedRunner{
+		"flatpak list --app":  {stdout: "org.gimp.GIMP\n"},
+		"flatpak install foo": {stderr: "error: foo not found\n", err: errors.New("exit status 1")},
+	}
+
+	ctx := WithOperation(context.Background(), "list")
+	rec := NewRecordingRunner(base, path)
+	if _, _, err := rec.Run(ctx, "flatpak", "list", "--app"); err != nil {
+		t.Fatalf("record list: %v", err)
+	}
+	if _, _, err := rec.Run(ctx, "flatpak", "install", "foo"); err == nil {
+		t.Fatal("record install: expected error")
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette: %v", err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("got %d interactions, want 2", len(c.Interactions))
+	}
+	if c.Interactions[0].Operation != "list" {
+		t.Errorf("Operation = %q, want list", c.Interactions[0].Operation)
+	}
+
+	replay := NewReplayRunner(c)
+	for i := 0; i < 2; i++ {
+		stdout, _, err := replay.Run(context.Background(), "flatpak", "list", "--app")
+		if err != nil || stdout != "org.gimp.GIMP\n" {
+			t.Errorf("replay list #%d = %q, %v", i, stdout, err)
+		}
+	}
+
+	_, stderr, err := replay.Run(context.Background(), "flatpak", "install", "foo")
+	if err == nil || err.Error() != "exit status 1" {
+		t.Errorf("replay install err = %v, want exit status 1", err)
+	}
+	if stderr != "error: foo not found\n" {
+		t.Errorf("replay install stderr = %q", stderr)
+	}
+
+	if _, _, err := replay.Run(context.Background(), "flatpak", "remove", "foo"); err == nil {
+		t.Error("expected error for unrecorded command")
+	}
+}
+
+func TestReplay_InOrder(t *testing.T) {
+	replay := NewReplayRunner(&Cassette{Interactions: []Interaction{
+		{Command: []string{"snap", "changes"}, Stdout: "first"},
+		{Command: []string{"snap", "changes"}, Stdout: "second"},
+	}})
+
+	for _, want := range []string{"first", "second", "second"} {
+		got, _, _ := replay.Run(context.Background(), "snap", "changes")
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestReplayedError_ExitCode(t *testing.T) {
+	replay := NewReplayRunner(&Cassette{Interactions: []Interaction{
+		{Command: []string{"brew", "install", "x"}, ExitCode: 3, Error: "exit status 3"},
+	}})
+
+	_, _, err := replay.Run(context.Background(), "brew", "install", "x")
+	if got := exitCode(err); got != 3 {
+		t.Errorf("exitCode = %d, want 3", got)
+	}
+}
+
+type scriptedResult struct {
+	stdout, stderr string
+	err            error
+}
+
+// scriptedRunner answers commands by their space-joined command line.
+type scriptedRunner map[string]scriptedResult
+
+func (s scriptedRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	r := s[strings.Join(append([]string{name}, args...), " ")]
+	return r.stdout, r.stderr, r.err
+}
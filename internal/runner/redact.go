@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"regexp"
+)
+
+// Redacted replaces secrets removed from command output.
+const Redacted = "[REDACTED]"
+
+// Redaction replaces matches of Pattern with Replacement, which may refer
+// to submatches as in regexp.Regexp.ReplaceAllString.
+type Redaction struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// DefaultRedactions remove common secrets from command output: credentials
+// in URLs, Authorization headers, token query parameters, secret-looking
+// environment assignments such as HOMEBREW_GITHUB_API_TOKEN=..., and
+// GitHub tokens.
+var DefaultRedactions = []Redaction{
+	{regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@`), "${1}" + Redacted + "@"},
+	{regexp.MustCompile(`(?i)\b((?:proxy-)?authorization\s*[:=]\s*)(?:(?:bearer|basic|token)\s+)?[^\s"',]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)([?&](?:access_token|token|api_key|apikey|password|secret)=)[^&\s"']+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)\b([a-z0-9_]*(?:token|secret|password|passwd|api_key|apikey)[a-z0-9_]*=)[^\s"'&]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), Redacted},
+}
+
+type redactionsKey struct{}
+
+// WithRedactions returns a copy of ctx that applies redactions, instead of
+// DefaultRedactions, to command output attached to errors and reported as
+// progress. An empty list turns redaction off.
+func WithRedactions(ctx context.Context, redactions []Redaction) context.Context {
+	return context.WithValue(ctx, redactionsKey{}, redactions)
+}
+
+// Redact applies the context's redactions to s.
+func Redact(ctx context.Context, s string) string {
+	redactions, ok := ctx.Value(redactionsKey{}).([]Redaction)
+	if !ok {
+		redactions = DefaultRedactions
+	}
+	for _, r := range redactions {
+		s = r.Pattern.ReplaceAllString(s, r.Replacement)
+	}
+	return s
+}
+
+// redactPayload applies the context's redactions to the string values of
+// payload, in place, and returns it.
+func redactPayload(ctx context.Context, payload map[string]interface{}) map[string]interface{} {
+	for k, v := range payload {
+		if s, ok := v.(string); ok {
+			payload[k] = Redact(ctx, s)
+		}
+	}
+	return payload
+}
@@ -8,3 +8,11 @@ type Runner interface {
 	// Run executes a command and returns stdout, stderr, and any error.
 	Run(ctx context.Context, name string, args ...string) (stdout, stderr string, err error)
 }
+
+// Targeter is implemented by runners that run commands on another machine
+// or in a container. Target names where, e.g. "ssh:admin@web1" or
+// "podman:box"; runners that report the same target share that place's
+// package manager state.
+type Targeter interface {
+	Target() string
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// simulatingRunner skips mutating commands and reports every command it sees.
+type simulatingRunner struct {
+	base   Runner
+	record func(op types.Operation, cmd []string, executed bool)
+}
+
+// NewSimulatingRunner wraps base so that mutating operations (as recorded by
+// WithOperation) are not executed; they succeed immediately with empty
+// output, which backends interpret as "nothing changed". Read-only commands
+// still run through base. Every command is passed to record, along with
+// whether it was actually executed.
+func NewSimulatingRunner(base Runner, record func(op types.Operation, cmd []string, executed bool)) Runner {
+	return &simulatingRunner{base: base, record: record}
+}
+
+func (r *simulatingRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	op, _ := OperationFromContext(ctx)
+	cmd := append([]string{name}, args...)
+	if mutating(op) {
+		r.record(op, cmd, false)
+		return "", "", nil
+	}
+	r.record(op, cmd, true)
+	return r.base.Run(ctx, name, args...)
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestSimulatingRunner(t *testing.T) {
+	base := &FakeRunner{StdoutResponse: "listing"}
+	var recorded []string
+	r := NewSimulatingRunner(base, func(op types.Operation, cmd []string, executed bool) {
+		recorded = append(recorded, strings.Join(cmd, " ")+map[bool]string{true: " (ran)", false: " (skipped)"}[executed])
+	})
+
+	stdout, _, err := r.Run(WithOperation(context.Background(), types.OperationInstall), "flatpak", "install", "-y", "org.gimp.GIMP")
+	if err != nil || stdout != "" {
+		t.Errorf("Expected empty successful result for simulated install, got %q, %v", stdout, err)
+	}
+	if base.LastCommand != "" {
+		t.Errorf("Mutating command should not reach base runner, got %q", base.LastCommand)
+	}
+
+	stdout, _, err = r.Run(WithOperation(context.Background(), types.OperationListInstalled), "flatpak", "list")
+	if err != nil || stdout != "listing" {
+		t.Errorf("Expected read to run through base, got %q, %v", stdout, err)
+	}
+
+	want := []string{"flatpak install -y org.gimp.GIMP (skipped)", "flatpak list (ran)"}
+	if len(recorded) != len(want) || recorded[0] != want[0] || recorded[1] != want[1] {
+		t.Errorf("recorded = %v, want %v", recorded, want)
+	}
+}
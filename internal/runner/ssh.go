@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SSHConfig describes how to reach a remote host with ssh(1).
+type SSHConfig struct {
+	// Host is the host name or ssh_config alias to connect to.
+	Host string
+
+	// User is the remote user; empty uses ssh's default.
+	User string
+
+	// Port is the remote port; zero uses ssh's default.
+	Port int
+
+	// IdentityFile is the private key to authenticate with.
+	IdentityFile string
+
+	// KnownHostsFile replaces the user's known_hosts file.
+	KnownHostsFile string
+
+	// Options are extra -o options for ssh, e.g. "ConnectTimeout=10".
+	Options []string
+}
+
+// sshRunner runs commands on a remote host through the ssh client.
+type sshRunner struct {
+	base   Runner
+	config SSHConfig
+}
+
+// NewSSHRunner wraps base so that commands run on a remote host: base is
+// asked to run `ssh [options] host -- command`. ssh runs in batch mode, so
+// it fails instead of asking for passwords or host key confirmation.
+//
+// The environment and working directory requested with WithEnv and WithDir
+// are applied on the remote side. Stdin is forwarded, and WithPTY allocates
+// a remote terminal.
+//
+// A Host or User starting with "-" would be read by ssh as an option, so
+// every command fails instead.
+func NewSSHRunner(base Runner, config SSHConfig) Runner {
+	return &sshRunner{base: base, config: config}
+}
+
+// Target implements Targeter.
+func (r *sshRunner) Target() string {
+	target := "ssh:" + r.config.Host
+	if r.config.User != "" {
+		target = "ssh:" + r.config.User + "@" + r.config.Host
+	}
+	if r.config.Port != 0 {
+		target += ":" + strconv.Itoa(r.config.Port)
+	}
+	return target
+}
+
+func (r *sshRunner) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	if strings.HasPrefix(r.config.Host, "-") {
+		return "", "", fmt.Errorf("invalid ssh host %q", r.config.Host)
+	}
+	if strings.HasPrefix(r.config.User, "-") {
+		return "", "", fmt.Errorf("invalid ssh user %q", r.config.User)
+	}
+	inv := InvocationFromContext(ctx)
+	sshArgs := r.args(inv.PTY)
+	sshArgs = append(sshArgs, "--", remoteCommand(inv, name, args))
+
+	// Env and Dir are part of the remote command; the local ssh client
+	// runs with neither.
+	ctx = withInvocation(ctx, func(inv *Invocation) {
+		inv.Env, inv.Dir = nil, ""
+	})
+	return r.base.Run(ctx, "ssh", sshArgs...)
+}
+
+// args returns the ssh arguments up to and including the destination.
+func (r *sshRunner) args(tty bool) []string {
+	args := []string{"-o", "BatchMode=yes"}
+	if tty {
+		args = append(args, "-tt")
+	}
+	if r.config.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(r.config.Port))
+	}
+	if r.config.IdentityFile != "" {
+		args = append(args, "-i", r.config.IdentityFile, "-o", "IdentitiesOnly=yes")
+	}
+	if r.config.KnownHostsFile != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+r.config.KnownHostsFile)
+	}
+	for _, opt := range r.config.Options {
+		args = append(args, "-o", opt)
+	}
+	dest := r.config.Host
+	if r.config.User != "" {
+		dest = r.config.User + "@" + dest
+	}
+	return append(args, dest)
+}
+
+// remoteCommand builds the shell command line run by the remote sshd.
+func remoteCommand(inv Invocation, name string, args []string) string {
+	var b strings.Builder
+	if inv.Dir != "" {
+		b.WriteString("cd " + shellQuote(inv.Dir) + " && ")
+	}
+	if len(inv.Env) > 0 {
+		b.WriteString("env")
+		for _, kv := range inv.Env {
+			b.WriteString(" " + shellQuote(kv))
+		}
+		b.WriteString(" ")
+	}
+	b.WriteString(shellQuote(name))
+	for _, arg := range args {
+		b.WriteString(" " + shellQuote(arg))
+	}
+	return b.String()
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSSHRunner_Args(t *testing.T) {
+	rec := &recordingFake{}
+	r := NewSSHRunner(rec, SSHConfig{
+		Host:           "web1.example.com",
+		User:           "admin",
+		Port:           2222,
+		IdentityFile:   "/keys/id_ed25519",
+		KnownHostsFile: "/keys/known_hosts",
+		Options:        []string{"ConnectTimeout=10"},
+	})
+
+	if _, _, err := r.Run(context.Background(), "flatpak", "list", "--app"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{
+		"-o", "BatchMode=yes",
+		"-p", "2222",
+		"-i", "/keys/id_ed25519", "-o", "IdentitiesOnly=yes",
+		"-o", "UserKnownHostsFile=/keys/known_hosts",
+		"-o", "ConnectTimeout=10",
+		"admin@web1.example.com",
+		"--", "flatpak list --app",
+	}
+	if rec.name != "ssh" || !slices.Equal(rec.args, want) {
+		t.Errorf("ran %s %q, want ssh %q", rec.name, rec.args, want)
+	}
+}
+
+func TestSSHRunner_Invocation(t *testing.T) {
+	rec := &recordingFake{}
+	r := NewSSHRunner(rec, SSHConfig{Host: "web1"})
+
+	ctx := WithEnv(context.Background(), "HOMEBREW_NO_AUTO_UPDATE=1")
+	ctx = WithDir(ctx, "/srv/my app")
+	ctx = WithStdin(ctx, "y\n")
+	ctx = WithPTY(ctx)
+	if _, _, err := r.Run(ctx, "brew", "install", "it's"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := `cd '/srv/my app' && env HOMEBREW_NO_AUTO_UPDATE=1 brew install 'it'\''s'`
+	if got := rec.args[len(rec.args)-1]; got != want {
+		t.Errorf("remote command = %q, want %q", got, want)
+	}
+	if !slices.Contains(rec.args, "-tt") {
+		t.Errorf("args %q lack -tt for PTY mode", rec.args)
+	}
+	if rec.inv.Env != nil || rec.inv.Dir != "" {
+		t.Errorf("local ssh got env %q, dir %q; want none", rec.inv.Env, rec.inv.Dir)
+	}
+	if rec.inv.Stdin != "y\n" || !rec.inv.PTY {
+		t.Errorf("local ssh invocation = %+v, want stdin and PTY kept", rec.inv)
+	}
+}
+
+// recordingFake remembers the last command and invocation it was asked to run.
+type recordingFake struct {
+	name string
+	args []string
+	inv  Invocation
+}
+
+func (f *recordingFake) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	f.name, f.args, f.inv = name, args, InvocationFromContext(ctx)
+	return "", "", nil
+}
+
+func TestSSHRunner_Target(t *testing.T) {
+	tests := []struct {
+		config SSHConfig
+		want   string
+	}{
+		{SSHConfig{Host: "web1"}, "ssh:web1"},
+		{SSHConfig{Host: "web1", User: "admin", Port: 2222}, "ssh:admin@web1:2222"},
+	}
+	for _, tt := range tests {
+		if got := NewSSHRunner(&recordingFake{}, tt.config).(Targeter).Target(); got != tt.want {
+			t.Errorf("Target() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSSHRunner_RejectsOptionLikeDestination(t *testing.T) {
+	for _, config := range []SSHConfig{
+		{Host: "-oProxyCommand=touch /tmp/pwned"},
+		{Host: "web1", User: "-oProxyCommand=touch /tmp/pwned"},
+	} {
+		rec := &recordingFake{}
+		if _, _, err := NewSSHRunner(rec, config).Run(context.Background(), "true"); err == nil {
+			t.Errorf("Run with %+v succeeded, want an error", config)
+		}
+		if rec.name != "" {
+			t.Errorf("Run with %+v ran %s %q", config, rec.name, rec.args)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package runner
+
+import "os/exec"
+
+// Start starts name in the background and returns without waiting for it.
+// Unlike Run it is meant for applications, which outlive the operation that
+// started them: the command is not tied to a context, gets no input, its
+// output is discarded, and where supported it runs in its own session so
+// that signals sent to pm's terminal do not reach it.
+func Start(name string, args ...string) (*exec.Cmd, error) {
+	cmd := exec.Command(name, args...)
+	detachTerminal(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
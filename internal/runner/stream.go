@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// LineFunc receives a command's output one line at a time while the
+// command runs. stderr reports which stream the line was written to.
+type LineFunc func(line string, stderr bool)
+
+type lineFuncKey struct{}
+
+// WithLineFunc returns a copy of ctx asking runners to stream output lines
+// to fn as they are written. The real runner honors it; wrappers pass it on
+// with the context. fn is never called concurrently.
+func WithLineFunc(ctx context.Context, fn LineFunc) context.Context {
+	return context.WithValue(ctx, lineFuncKey{}, fn)
+}
+
+// LineFuncFromContext returns the LineFunc recorded by WithLineFunc, if any.
+func LineFuncFromContext(ctx context.Context) (LineFunc, bool) {
+	fn, ok := ctx.Value(lineFuncKey{}).(LineFunc)
+	return fn, ok && fn != nil
+}
+
+// StreamToProgress returns a copy of ctx that reports each output line as
+// an informational progress message, with secrets redacted, so
+// long-running commands show activity before they exit. Lines that report a percentage or byte counts
+// (download and extract progress) update the current task instead.
+func StreamToProgress(ctx context.Context, helper *types.ProgressHelper) context.Context {
+	return WithLineFunc(ctx, func(line string, stderr bool) {
+		if percent, done, total, ok := parseProgress(line); ok {
+			helper.TaskProgress(percent, done, total)
+			return
+		}
+		helper.Info(Redact(ctx, line))
+	})
+}
+
+var (
+	// percentPattern matches "45%" and "45.2 %".
+	percentPattern = regexp.MustCompile(`(\d{1,3}(?:\.\d+)?)\s?%`)
+
+	// bytesPattern matches "12.3 MB / 45.6 MB" and "512kB/2MB".
+	bytesPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([kKMGT]i?B|B)\s*/\s*(\d+(?:\.\d+)?)\s*([kKMGT]i?B|B)\b`)
+)
+
+// byteUnits are the multipliers of the size units tools print.
+var byteUnits = map[string]float64{
+	"B":  1,
+	"kB": 1e3, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+	"KiB": 1 << 10, "kiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+}
+
+// parseProgress extracts the completion reported by a progress line, as
+// printed by snap, flatpak and curl (for brew downloads). The last
+// percentage on the line wins, since bars often end with it.
+func parseProgress(line string) (percent float64, done, total int64, ok bool) {
+	if m := bytesPattern.FindStringSubmatch(line); m != nil {
+		done, total = parseSize(m[1], m[2]), parseSize(m[3], m[4])
+		ok = total > 0 && done <= total
+		if !ok {
+			done, total = 0, 0
+		}
+	}
+	if ms := percentPattern.FindAllStringSubmatch(line, -1); ms != nil {
+		p, err := strconv.ParseFloat(ms[len(ms)-1][1], 64)
+		if err == nil && p <= 100 {
+			percent, ok = p, true
+		}
+	}
+	if percent == 0 && total > 0 {
+		percent = float64(done) / float64(total) * 100
+	}
+	return percent, done, total, ok
+}
+
+// parseSize converts a number and unit such as "1.5", "MB" to bytes.
+func parseSize(num, unit string) int64 {
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(n * byteUnits[unit])
+}
+
+// lineWriter splits written output into lines and passes each non-empty
+// line to fn. Carriage returns end a line too, since tools redraw progress
+// bars with them. Writers for stdout and stderr share mu so fn is called
+// sequentially.
+type lineWriter struct {
+	mu     *sync.Mutex
+	fn     LineFunc
+	stderr bool
+	buf    []byte
+	last   string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, c := range p {
+		if c == '\n' || c == '\r' {
+			w.emit()
+			continue
+		}
+		w.buf = append(w.buf, c)
+	}
+	return len(p), nil
+}
+
+// Flush emits any trailing output not terminated by a newline.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit()
+}
+
+// emit passes the buffered line to fn, skipping blank lines and redraws
+// that did not change the line. The caller must hold mu.
+func (w *lineWriter) emit() {
+	line := strings.TrimSpace(string(w.buf))
+	w.buf = w.buf[:0]
+	if line == "" || line == w.last {
+		return
+	}
+	w.last = line
+	w.fn(line, w.stderr)
+}
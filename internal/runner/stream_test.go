@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestLineWriter(t *testing.T) {
+	var got []string
+	w := &lineWriter{mu: &sync.Mutex{}, fn: func(line string, stderr bool) {
+		got = append(got, line)
+	}}
+
+	_, _ = w.Write([]byte("Downloading 10%\rDownloading 10%\rDownloading 50%\r"))
+	_, _ = w.Write([]byte("\n\nInstal"))
+	_, _ = w.Write([]byte("ling foo\ndone"))
+	w.Flush()
+
+	want := []string{"Downloading 10%", "Downloading 50%", "Installing foo", "done"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestRealRunner_StreamsLines(t *testing.T) {
+	type line struct {
+		text   string
+		stderr bool
+	}
+	var got []line
+	ctx := WithLineFunc(context.Background(), func(text string, stderr bool) {
+		got = append(got, line{text, stderr})
+	})
+
+	stdout, _, err := NewRealRunner().Run(ctx, "sh", "-c", "echo one; sleep 0.05; echo two >&2; sleep 0.05; printf three")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if stdout != "one\nthree" {
+		t.Errorf("stdout = %q, want full output", stdout)
+	}
+	want := []line{{"one", false}, {"two", true}, {"three", false}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+}
+
+func TestStreamToProgress(t *testing.T) {
+	var messages []string
+	reporter := &messageRecorder{onMessage: func(m types.ProgressMessage) { messages = append(messages, m.Text) }}
+	helper := types.NewProgressHelper(reporter, nil)
+
+	ctx := StreamToProgress(context.Background(), helper)
+	fn, ok := LineFuncFromContext(ctx)
+	if !ok {
+		t.Fatal("Expected a LineFunc in context")
+	}
+	fn("Installing foo", false)
+	if !reflect.DeepEqual(messages, []string{"Installing foo"}) {
+		t.Errorf("messages = %q", messages)
+	}
+}
+
+func TestStreamToProgress_Completion(t *testing.T) {
+	var messages []string
+	var tasks []types.ProgressTask
+	reporter := &messageRecorder{
+		onMessage: func(m types.ProgressMessage) { messages = append(messages, m.Text) },
+		onTask:    func(task types.ProgressTask) { tasks = append(tasks, task) },
+	}
+	helper := types.NewProgressHelper(reporter, nil)
+	helper.BeginTask("Running snap install")
+
+	fn, _ := LineFuncFromContext(StreamToProgress(context.Background(), helper))
+	fn(`Download snap "hello" (42) from channel "stable"   45% 1.20MB/s 2.3s`, false)
+	fn("hello 2.10 from Canonical✓ installed", false)
+
+	if len(tasks) != 2 {
+		t.Fatalf("got %d task events, want start and one update", len(tasks))
+	}
+	if update := tasks[1]; update.Percent != 45 || update.UpdatedAt.IsZero() {
+		t.Errorf("update = %+v, want 45%%", update.Completion)
+	}
+	if !reflect.DeepEqual(messages, []string{"hello 2.10 from Canonical✓ installed"}) {
+		t.Errorf("messages = %q, want only the non-progress line", messages)
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line        string
+		percent     float64
+		done, total int64
+		ok          bool
+	}{
+		{`Download snap "hello" (42) from channel "stable"   45% 1.20MB/s 2.3s`, 45, 0, 0, true},
+		{"######################################################### 78.4%", 78.4, 0, 0, true},
+		{"Installing 1/2… ████████▌ 45%  12.3 MB / 27.3 MB", 45, 12300000, 27300000, true},
+		{"Receiving objects: 512KiB/2MiB", 25, 512 << 10, 2 << 20, true},
+		{"Installing org.gimp.GIMP", 0, 0, 0, false},
+		{"Disk usage 250%", 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		percent, done, total, ok := parseProgress(tt.line)
+		if ok != tt.ok || done != tt.done || total != tt.total {
+			t.Errorf("parseProgress(%q) = %v, %d, %d, %v; want %v, %d, %d, %v",
+				tt.line, percent, done, total, ok, tt.percent, tt.done, tt.total, tt.ok)
+		}
+		if tt.percent != 0 && percent != tt.percent {
+			t.Errorf("parseProgress(%q) percent = %v, want %v", tt.line, percent, tt.percent)
+		}
+	}
+}
+
+// messageRecorder is a ProgressReporter that records messages and tasks.
+type messageRecorder struct {
+	onMessage func(types.ProgressMessage)
+	onTask    func(types.ProgressTask)
+}
+
+func (r *messageRecorder) OnAction(types.ProgressAction) {}
+func (r *messageRecorder) OnTask(task types.ProgressTask) {
+	if r.onTask != nil {
+		r.onTask(task)
+	}
+}
+func (r *messageRecorder) OnStep(types.ProgressStep)         {}
+func (r *messageRecorder) OnMessage(m types.ProgressMessage) { r.onMessage(m) }
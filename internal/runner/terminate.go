@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminate asks p to exit with SIGTERM, falling back to killing it where
+// signals are not supported.
+func terminate(p *os.Process) error {
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
+
+// killed reports whether the process was ended by SIGKILL.
+func killed(ps *os.ProcessState) bool {
+	if ps == nil {
+		return true
+	}
+	ws, ok := ps.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGKILL
+}
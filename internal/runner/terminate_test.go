@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestRealRunner_GracefulTermination(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "cleaned-up")
+	script := `trap 'touch "$0"; exit 143' TERM; echo started; while :; do sleep 0.01; done`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithLineFunc(ctx, func(line string, stderr bool) {
+		if line == "started" {
+			cancel()
+		}
+	})
+	_, _, err := NewRealRunnerWithGrace(5*time.Second).Run(ctx, "sh", "-c", script, marker)
+
+	var cancelledErr *types.CancelledError
+	if !errors.As(err, &cancelledErr) {
+		t.Fatalf("Expected CancelledError, got %v", err)
+	}
+	if !cancelledErr.Graceful || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected graceful cancellation, got %+v", cancelledErr)
+	}
+	if _, statErr := os.Stat(marker); statErr != nil {
+		t.Error("Expected the TERM handler to run")
+	}
+}
+
+func TestRealRunner_KillsAfterGrace(t *testing.T) {
+	script := `trap '' TERM; echo started; while :; do sleep 0.01; done`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithLineFunc(ctx, func(line string, stderr bool) {
+		if line == "started" {
+			cancel()
+		}
+	})
+	start := time.Now()
+	_, _, err := NewRealRunnerWithGrace(200*time.Millisecond).Run(ctx, "sh", "-c", script)
+
+	var cancelledErr *types.CancelledError
+	if !errors.As(err, &cancelledErr) {
+		t.Fatalf("Expected CancelledError, got %v", err)
+	}
+	if cancelledErr.Graceful {
+		t.Error("Expected the command to be killed")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Kill took %v", elapsed)
+	}
+}
+
+func TestRunWithExternalError_Cancelled(t *testing.T) {
+	fake := &FakeRunner{ErrResponse: &types.CancelledError{Graceful: true, Err: context.Canceled}}
+
+	_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "snap", "snap", "install", "hello")
+	var cancelledErr *types.CancelledError
+	if !errors.As(err, &cancelledErr) {
+		t.Fatalf("Expected CancelledError, got %v", err)
+	}
+	if cancelledErr.Operation != types.OperationInstall || cancelledErr.Backend != "snap" {
+		t.Errorf("Unexpected context %+v", cancelledErr)
+	}
+}
+
+func TestRunWithExternalError_Timeout(t *testing.T) {
+	fake := &FakeRunner{StdoutResponse: "Downloading 40%", ErrResponse: &types.CancelledError{Err: context.DeadlineExceeded}}
+
+	_, _, err := RunWithExternalError(context.Background(), fake, types.OperationInstall, "snap", "snap", "install", "hello")
+	var timeoutErr *types.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.Operation != types.OperationInstall || timeoutErr.Backend != "snap" || timeoutErr.PartialStdout != "Downloading 40%" {
+		t.Errorf("Unexpected context %+v", timeoutErr)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || types.IsExternalFailure(err) {
+		t.Errorf("Expected a deadline error and no external failure, got %v", err)
+	}
+}
+
+func TestRunWithExternalError_TimeoutFromCustomRunner(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	fake := &FakeRunner{ErrResponse: errors.New("signal: killed")}
+
+	_, _, err := RunWithExternalError(ctx, fake, types.OperationSearch, "flatpak", "flatpak", "search", "gimp")
+	if !types.IsTimeout(err) {
+		t.Errorf("Expected TimeoutError, got %v", err)
+	}
+}
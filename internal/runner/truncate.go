@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultOutputLimit is how many bytes of a command's stdout or stderr are
+// kept when they are attached to an error.
+const DefaultOutputLimit = 4096
+
+type outputLimitKey struct{}
+
+// WithOutputLimit returns a copy of ctx that keeps at most limit bytes of
+// command output in errors. A limit of zero or less keeps all output.
+func WithOutputLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, outputLimitKey{}, limit)
+}
+
+// outputLimit returns the limit set by WithOutputLimit, or
+// DefaultOutputLimit.
+func outputLimit(ctx context.Context) int {
+	if limit, ok := ctx.Value(outputLimitKey{}).(int); ok {
+		return limit
+	}
+	return DefaultOutputLimit
+}
+
+// sanitize prepares command output for inclusion in an error, redacting
+// secrets and truncating it to the context's output limit.
+func sanitize(ctx context.Context, s string) string {
+	return truncate(Redact(ctx, s), outputLimit(ctx))
+}
+
+// truncate shortens s to about limit bytes, keeping its head and its tail:
+// tools usually print what they were doing first and why they failed last.
+// Cuts are made on UTF-8 boundaries. A limit of zero or less disables
+// truncation.
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	head := limit / 2
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	tail := len(s) - (limit - limit/2)
+	for tail < len(s) && !utf8.RuneStart(s[tail]) {
+		tail++
+	}
+	return fmt.Sprintf("%s\n... (%d bytes truncated) ...\n%s", s[:head], tail-head, s[tail:])
+}
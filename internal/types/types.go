@@ -5,14 +5,29 @@ package types
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/frostyard/pm/progress"
 )
 
 // Core errors that backends can return.
 var (
-	ErrNotSupported = errors.New("operation not supported")
-	ErrNotAvailable = errors.New("backend not available")
+	ErrNotSupported          = errors.New("operation not supported")
+	ErrNotAvailable          = errors.New("backend not available")
+	ErrEscalationUnavailable = errors.New("privilege escalation unavailable")
+	ErrInteractionRequired   = errors.New("interaction required")
+	ErrPermissionDenied      = errors.New("permission denied")
+	ErrPackageNotFound       = errors.New("package not found")
+	ErrAlreadyInstalled      = errors.New("package already installed")
+	ErrNotInstalled          = errors.New("package not installed")
+	ErrNetwork               = errors.New("network error")
+	ErrValidation            = errors.New("invalid package reference")
+	ErrTimeout               = errors.New("operation timed out")
+	ErrConflict              = errors.New("conflicting operation in progress")
+	ErrUnsupportedArch       = errors.New("package not available for this architecture")
 )
 
 // IsNotSupported checks if an error is a NotSupported error.
@@ -60,6 +75,476 @@ func (e *NotAvailableError) Unwrap() error {
 	return ErrNotAvailable
 }
 
+// EscalationError wraps ErrEscalationUnavailable with additional context.
+type EscalationError struct {
+	Operation Operation
+	Backend   string
+	Strategy  string
+	Reason    string
+	Stderr    string
+	Hint      string
+}
+
+func (e *EscalationError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s requires elevated privileges (strategy %s): %s", ErrEscalationUnavailable, e.Operation, e.Backend, e.Strategy, e.Reason)
+	if e.Hint != "" {
+		msg = fmt.Sprintf("%s (try escalation %s)", msg, e.Hint)
+	}
+	return msg
+}
+
+func (e *EscalationError) Unwrap() error {
+	return ErrEscalationUnavailable
+}
+
+// IsEscalationUnavailable checks if an error is an EscalationError.
+func IsEscalationUnavailable(err error) bool {
+	return errors.Is(err, ErrEscalationUnavailable)
+}
+
+// PermissionDeniedError wraps ErrPermissionDenied with additional context.
+type PermissionDeniedError struct {
+	Operation Operation
+	Backend   string
+	Strategy  string
+	Reason    string
+	Stderr    string
+	Hint      string
+	Err       error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrPermissionDenied, e.Operation, e.Backend)
+	if e.Strategy != "" {
+		msg = fmt.Sprintf("%s (strategy %s)", msg, e.Strategy)
+	}
+	msg = fmt.Sprintf("%s: %s", msg, e.Reason)
+	if e.Hint != "" {
+		msg = fmt.Sprintf("%s (try escalation %s)", msg, e.Hint)
+	}
+	return msg
+}
+
+func (e *PermissionDeniedError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrPermissionDenied}
+	}
+	return []error{ErrPermissionDenied, e.Err}
+}
+
+// IsPermissionDenied checks if an error is a PermissionDeniedError.
+func IsPermissionDenied(err error) bool {
+	return errors.Is(err, ErrPermissionDenied)
+}
+
+// ConflictError wraps ErrConflict with additional context.
+type ConflictError struct {
+	Operation  Operation
+	Backend    string
+	Holder     string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *ConflictError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrConflict, e.Operation, e.Backend)
+	if e.Holder != "" {
+		msg = fmt.Sprintf("%s (held by %s)", msg, e.Holder)
+	}
+	return msg
+}
+
+func (e *ConflictError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrConflict}
+	}
+	return []error{ErrConflict, e.Err}
+}
+
+// IsConflict checks if an error is a ConflictError.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// TimeoutError reports a command stopped because its context's deadline
+// passed.
+type TimeoutError struct {
+	Operation     Operation
+	Backend       string
+	Elapsed       time.Duration
+	PartialStdout string
+	Err           error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s on %s after %s", ErrTimeout, e.Operation, e.Backend, e.Elapsed.Round(time.Millisecond))
+}
+
+func (e *TimeoutError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrTimeout}
+	}
+	return []error{ErrTimeout, e.Err}
+}
+
+// IsTimeout checks if an error is a TimeoutError.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
+// CancelledError reports a command stopped because its context was done.
+type CancelledError struct {
+	Operation Operation
+	Backend   string
+	Graceful  bool
+	Err       error
+}
+
+func (e *CancelledError) Error() string {
+	cleanup := "process killed"
+	if e.Graceful {
+		cleanup = "cleanup attempted"
+	}
+	return fmt.Sprintf("%s on %s cancelled, %s: %v", e.Operation, e.Backend, cleanup, e.Err)
+}
+
+func (e *CancelledError) Unwrap() error {
+	return e.Err
+}
+
+// InteractionRequiredError wraps ErrInteractionRequired with additional context.
+type InteractionRequiredError struct {
+	Operation Operation
+	Backend   string
+	Prompt    string
+	Expected  string
+}
+
+func (e *InteractionRequiredError) Error() string {
+	return fmt.Sprintf("%s: %s on %s is waiting for %s (prompt: %q)", ErrInteractionRequired, e.Operation, e.Backend, e.Expected, e.Prompt)
+}
+
+func (e *InteractionRequiredError) Unwrap() error {
+	return ErrInteractionRequired
+}
+
+// IsInteractionRequired checks if an error is an InteractionRequiredError.
+func IsInteractionRequired(err error) bool {
+	return errors.Is(err, ErrInteractionRequired)
+}
+
+// PackageNotFoundError wraps ErrPackageNotFound with additional context.
+type PackageNotFoundError struct {
+	Ref         PackageRef
+	Backend     string
+	Suggestions []string
+	Err         error
+}
+
+func (e *PackageNotFoundError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s", ErrPackageNotFound, e.Ref.Name, e.Backend)
+	if len(e.Suggestions) > 0 {
+		msg = fmt.Sprintf("%s (did you mean %s?)", msg, strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+func (e *PackageNotFoundError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrPackageNotFound}
+	}
+	return []error{ErrPackageNotFound, e.Err}
+}
+
+// IsPackageNotFound checks if an error is a PackageNotFoundError.
+func IsPackageNotFound(err error) bool {
+	return errors.Is(err, ErrPackageNotFound)
+}
+
+// AlreadyInstalledError wraps ErrAlreadyInstalled with additional context.
+type AlreadyInstalledError struct {
+	Ref     PackageRef
+	Backend string
+	Err     error
+}
+
+func (e *AlreadyInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s on %s", ErrAlreadyInstalled, e.Ref.Name, e.Backend)
+}
+
+func (e *AlreadyInstalledError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrAlreadyInstalled}
+	}
+	return []error{ErrAlreadyInstalled, e.Err}
+}
+
+// IsAlreadyInstalled checks if an error is an AlreadyInstalledError.
+func IsAlreadyInstalled(err error) bool {
+	return errors.Is(err, ErrAlreadyInstalled)
+}
+
+// NotInstalledError wraps ErrNotInstalled with additional context.
+type NotInstalledError struct {
+	Operation Operation
+	Ref       PackageRef
+	Backend   string
+	Err       error
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("%s: %s on %s (%s)", ErrNotInstalled, e.Ref.Name, e.Backend, e.Operation)
+}
+
+func (e *NotInstalledError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNotInstalled}
+	}
+	return []error{ErrNotInstalled, e.Err}
+}
+
+// IsNotInstalled checks if an error is a NotInstalledError.
+func IsNotInstalled(err error) bool {
+	return errors.Is(err, ErrNotInstalled)
+}
+
+// UnsupportedArchError wraps ErrUnsupportedArch with additional context.
+type UnsupportedArchError struct {
+	Ref       PackageRef
+	Backend   string
+	Arch      string
+	Available []string
+	Err       error
+}
+
+func (e *UnsupportedArchError) Error() string {
+	msg := fmt.Sprintf("%s: %s on %s is not available for %s", ErrUnsupportedArch, e.Ref.Name, e.Backend, e.Arch)
+	if len(e.Available) > 0 {
+		msg = fmt.Sprintf("%s (only for %s)", msg, strings.Join(e.Available, ", "))
+	}
+	return msg
+}
+
+func (e *UnsupportedArchError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrUnsupportedArch}
+	}
+	return []error{ErrUnsupportedArch, e.Err}
+}
+
+// IsUnsupportedArch checks if an error is an UnsupportedArchError.
+func IsUnsupportedArch(err error) bool {
+	return errors.Is(err, ErrUnsupportedArch)
+}
+
+// MatchRefs returns the packages named by the first group of matches of
+// patterns in output, in order of appearance and without duplicates. Names
+// not in pkgs are returned as PackageRefs with only a name.
+func MatchRefs(output string, pkgs []PackageRef, patterns ...*regexp.Regexp) []PackageRef {
+	type match struct {
+		pos  int
+		name string
+	}
+	var matches []match
+	for _, pattern := range patterns {
+		for _, m := range pattern.FindAllStringSubmatchIndex(output, -1) {
+			matches = append(matches, match{m[2], output[m[2]:m[3]]})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return a.pos - b.pos })
+
+	var refs []PackageRef
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if !seen[m.name] {
+			seen[m.name] = true
+			refs = append(refs, RefNamed(pkgs, m.name))
+		}
+	}
+	return refs
+}
+
+// Unskipped returns the packages in pkgs that are not the subject of an
+// AlreadyInstalledError or NotInstalledError in skipped.
+func Unskipped(pkgs []PackageRef, skipped []error) []PackageRef {
+	names := make(map[string]bool)
+	for _, err := range skipped {
+		var aiErr *AlreadyInstalledError
+		var niErr *NotInstalledError
+		switch {
+		case errors.As(err, &aiErr):
+			names[aiErr.Ref.Name] = true
+		case errors.As(err, &niErr):
+			names[niErr.Ref.Name] = true
+		}
+	}
+	var refs []PackageRef
+	for _, pkg := range pkgs {
+		if !names[pkg.Name] {
+			refs = append(refs, pkg)
+		}
+	}
+	return refs
+}
+
+// SkipInstalled splits pkgs, given installed as returned by Query for
+// them, into the packages that still need installing and an
+// *AlreadyInstalledError for each of the others.
+func SkipInstalled(backend string, pkgs []PackageRef, installed []InstalledPackage) ([]PackageRef, []error) {
+	var todo []PackageRef
+	var skipped []error
+	for _, pkg := range pkgs {
+		if isInstalled(pkg, installed) {
+			skipped = append(skipped, &AlreadyInstalledError{Ref: pkg, Backend: backend})
+		} else {
+			todo = append(todo, pkg)
+		}
+	}
+	return todo, skipped
+}
+
+// SkipNotInstalled splits pkgs, given installed as returned by Query for
+// them, into the packages that are installed and so still need
+// uninstalling, and a *NotInstalledError for each of the others.
+func SkipNotInstalled(backend string, pkgs []PackageRef, installed []InstalledPackage) ([]PackageRef, []error) {
+	var todo []PackageRef
+	var skipped []error
+	for _, pkg := range pkgs {
+		if isInstalled(pkg, installed) {
+			todo = append(todo, pkg)
+		} else {
+			skipped = append(skipped, &NotInstalledError{Operation: OperationUninstall, Ref: pkg, Backend: backend})
+		}
+	}
+	return todo, skipped
+}
+
+func isInstalled(pkg PackageRef, installed []InstalledPackage) bool {
+	for _, p := range installed {
+		if p.Ref.Name == pkg.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// SkippedFailure returns the error of a command for pkgs that failed with
+// err after reporting the packages in skipped as already installed or not
+// installed. If it skipped every package, nothing else went wrong: the
+// first of skipped is returned, wrapping err. Otherwise err is returned
+// joined with skipped, so that the failure of the other packages is not
+// mistaken for a no-op.
+func SkippedFailure(err error, pkgs []PackageRef, skipped []error) error {
+	if len(skipped) == 0 {
+		return err
+	}
+	if len(Unskipped(pkgs, skipped)) > 0 {
+		return errors.Join(append([]error{err}, skipped...)...)
+	}
+	for _, s := range skipped {
+		switch s := s.(type) {
+		case *AlreadyInstalledError:
+			s.Err = err
+		case *NotInstalledError:
+			s.Err = err
+		}
+	}
+	return skipped[0]
+}
+
+// RefNamed returns the package in pkgs called name, or a PackageRef with
+// only that name if none is.
+func RefNamed(pkgs []PackageRef, name string) PackageRef {
+	for _, pkg := range pkgs {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return PackageRef{Name: name}
+}
+
+// Network failure kinds for NetworkError.
+const (
+	NetworkDNS               = "dns"
+	NetworkTimeout           = "timeout"
+	NetworkConnectionRefused = "connection-refused"
+	NetworkConnectionReset   = "connection-reset"
+	NetworkUnreachable       = "unreachable"
+	NetworkTLS               = "tls"
+	NetworkHTTPStatus        = "http-status"
+)
+
+// NetworkError wraps ErrNetwork with additional context.
+type NetworkError struct {
+	Operation  Operation
+	Backend    string
+	Kind       string
+	StatusCode int
+	Err        error
+}
+
+func (e *NetworkError) Error() string {
+	msg := fmt.Sprintf("%s (%s): %s on %s", ErrNetwork, e.Kind, e.Operation, e.Backend)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *NetworkError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNetwork}
+	}
+	return []error{ErrNetwork, e.Err}
+}
+
+// Retryable reports whether the failure is likely transient.
+func (e *NetworkError) Retryable() bool {
+	switch e.Kind {
+	case NetworkTLS:
+		return false
+	case NetworkHTTPStatus:
+		return e.StatusCode == 408 || e.StatusCode == 429 || e.StatusCode >= 500
+	}
+	return true
+}
+
+// IsNetwork checks if an error is a NetworkError.
+func IsNetwork(err error) bool {
+	return errors.Is(err, ErrNetwork)
+}
+
+// ValidationError wraps ErrValidation with additional context.
+type ValidationError struct {
+	Operation Operation
+	Backend   string
+	Ref       PackageRef
+	Field     string
+	Value     string
+	Reason    string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s %q on %s: %s", ErrValidation, e.Field, e.Value, e.Backend, e.Reason)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// IsValidation checks if an error is a ValidationError.
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+// Failure categories for ExternalFailureError.
+const (
+	CategoryNotFound = "not-found"
+	CategoryLocked   = "locked"
+	CategoryNetwork  = "network"
+	CategoryUsage    = "usage"
+)
+
 // ExternalFailureError represents a failure from an external command or API.
 type ExternalFailureError struct {
 	Operation Operation
@@ -68,6 +553,13 @@ type ExternalFailureError struct {
 	Stderr    string
 	Payload   map[string]interface{}
 	Err       error
+	// ExitCode is the command's exit status; zero if the failure did not
+	// come from a command exiting (e.g. an API error or a signal).
+	ExitCode int
+	// Duration is how long the command ran.
+	Duration time.Duration
+	// Category is the semantic failure category, or "" if unknown.
+	Category string
 }
 
 func (e *ExternalFailureError) Error() string {
@@ -101,9 +593,53 @@ type PackageRef struct {
 
 // InstalledPackage mirrors pm.InstalledPackage for internal use.
 type InstalledPackage struct {
-	Ref     PackageRef
-	Version string
-	Status  string
+	Ref         PackageRef
+	Version     string
+	Status      string
+	Size        int64
+	InstalledAt time.Time
+}
+
+// OutdatedPackage mirrors pm.OutdatedPackage for internal use.
+type OutdatedPackage struct {
+	Ref              PackageRef
+	InstalledVersion string
+	AvailableVersion string
+	Security         bool
+}
+
+// AttestationStatus mirrors pm.AttestationStatus for internal use.
+type AttestationStatus string
+
+const (
+	AttestationVerified   AttestationStatus = "verified"
+	AttestationUnverified AttestationStatus = "unverified"
+	AttestationNotFound   AttestationStatus = "not-found"
+)
+
+// Attestation mirrors pm.Attestation for internal use.
+type Attestation struct {
+	Ref               PackageRef
+	Installed         bool
+	Version           string
+	Source            string
+	Publisher         string
+	PublisherVerified bool
+	Signed            bool
+	Digest            string
+	Status            AttestationStatus
+	Findings          []string
+}
+
+// Settle sets Status from Signed, unless it is already set.
+func (a *Attestation) Settle() {
+	if a.Status != "" {
+		return
+	}
+	a.Status = AttestationUnverified
+	if a.Signed {
+		a.Status = AttestationVerified
+	}
 }
 
 // Operation mirrors pm.Operation for internal use.
@@ -116,6 +652,12 @@ const (
 	OperationUninstall       Operation = "Uninstall"
 	OperationSearch          Operation = "Search"
 	OperationListInstalled   Operation = "ListInstalled"
+	OperationListOutdated    Operation = "ListOutdated"
+	OperationQuery           Operation = "Query"
+	OperationVerify          Operation = "Verify"
+	OperationLaunch          Operation = "Launch"
+	OperationIcon            Operation = "Icon"
+	OperationWatch           Operation = "Watch"
 )
 
 // Capability mirrors pm.Capability for internal use.
@@ -161,6 +703,14 @@ func NewProgressHelper(defaultReporter, overrideReporter ProgressReporter) *Prog
 	return progress.NewProgressHelper(defaultReporter, overrideReporter)
 }
 
+// NewOperationProgressHelper creates a progress helper like
+// NewProgressHelper whose events are stamped with backend and op.
+func NewOperationProgressHelper(backend string, op Operation, defaultReporter, overrideReporter ProgressReporter) *ProgressHelper {
+	h := progress.NewProgressHelper(defaultReporter, overrideReporter)
+	h.SetSource(backend, string(op))
+	return h
+}
+
 // Result types for operations.
 type UpdateResult struct {
 	Changed  bool
@@ -177,12 +727,14 @@ type InstallResult struct {
 	Changed           bool
 	PackagesInstalled []PackageRef
 	Messages          []ProgressMessage
+	Skipped           []error
 }
 
 type UninstallResult struct {
 	Changed             bool
 	PackagesUninstalled []PackageRef
 	Messages            []ProgressMessage
+	Skipped             []error
 }
 
 // Options types for operations.
@@ -191,6 +743,7 @@ type UpdateOptions struct {
 }
 
 type UpgradeOptions struct {
+	Packages []PackageRef
 	Progress ProgressReporter
 }
 
@@ -204,8 +757,88 @@ type UninstallOptions struct {
 
 type SearchOptions struct {
 	Progress ProgressReporter
+	Exact    bool
+
+	// Fuzzy also matches names within a few typos of the query (see
+	// rank.Fuzzy).
+	Fuzzy bool
+
+	// IncludeDescriptions also matches descriptions and keywords, not just
+	// names. Backends whose native search always matches them, like snap
+	// and flatpak, otherwise keep only the results whose name matches.
+	IncludeDescriptions bool
+
+	// OnResult, if set, is called with each result as soon as the backend
+	// finds it, and with every returned result exactly once before Search
+	// returns. Use a ResultStream to honor it.
+	OnResult func(PackageRef)
+}
+
+// ResultStream passes search results to SearchOptions.OnResult as a
+// backend finds them. Results a backend cannot stream, such as those
+// parsed only once a command has exited, are passed by Finish.
+type ResultStream struct {
+	fn   func(PackageRef)
+	sent map[PackageRef]bool
+}
+
+// NewResultStream returns a ResultStream for opts. Its methods do nothing
+// if opts.OnResult is nil.
+func NewResultStream(opts SearchOptions) *ResultStream {
+	return &ResultStream{fn: opts.OnResult, sent: make(map[PackageRef]bool)}
+}
+
+// Send passes ref on unless it has been passed already.
+func (s *ResultStream) Send(ref PackageRef) {
+	if s.fn == nil || s.sent[ref] {
+		return
+	}
+	s.sent[ref] = true
+	s.fn(ref)
+}
+
+// Finish passes on the results that have not been sent yet.
+func (s *ResultStream) Finish(results []PackageRef) {
+	for _, ref := range results {
+		s.Send(ref)
+	}
 }
 
 type ListOptions struct {
 	Progress ProgressReporter
 }
+
+type QueryOptions struct {
+	Progress ProgressReporter
+}
+
+type OutdatedOptions struct {
+	Progress ProgressReporter
+}
+
+type VerifyOptions struct {
+	Progress ProgressReporter
+}
+
+type LaunchOptions struct {
+	Args     []string
+	Progress ProgressReporter
+}
+
+type IconOptions struct {
+	Size     int
+	Progress ProgressReporter
+}
+
+// IconSource is where an application's icon is: a local file, or a URL to
+// download it from. The zero value means the application has no icon.
+type IconSource struct {
+	Path string
+	URL  string
+}
+
+// LaunchCommand is the command line that starts an installed application.
+type LaunchCommand struct {
+	Name string
+	Args []string
+}
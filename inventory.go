@@ -0,0 +1,141 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// InventoryOptions configures an Inventory.
+type InventoryOptions struct {
+	// Workers bounds how many backends are listed at once; zero or less
+	// lists them all at once.
+	Workers int
+
+	// MaxAge is how long a backend's listing is reused before Snapshot
+	// lists the backend again. Zero lists every backend on every Snapshot.
+	MaxAge time.Duration
+
+	// List is passed to every ListInstalled call.
+	List ListOptions
+}
+
+// BackendInventory is one backend's installed packages.
+type BackendInventory struct {
+	// Packages are the installed packages as of FetchedAt.
+	Packages []InstalledPackage
+
+	// FetchedAt is when Packages were listed.
+	FetchedAt time.Time
+
+	// Stale reports that the latest listing failed, so Packages come from
+	// an earlier one; Err holds the failure.
+	Stale bool
+	Err   error
+}
+
+// Inventory gathers installed packages across backends for dashboards that
+// refresh often. It lists backends concurrently, at most Workers at a time,
+// and reuses each backend's listing until it is MaxAge old, so a refresh
+// spawns processes only for the backends that are due.
+//
+// An Inventory is safe for concurrent use.
+type Inventory struct {
+	backends []Backend
+	opts     InventoryOptions
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[BackendKind]BackendInventory
+}
+
+// NewInventory creates an Inventory over the backends that implement Lister.
+func NewInventory(backends []Backend, opts InventoryOptions) *Inventory {
+	return &Inventory{
+		backends: only[Lister](backends),
+		opts:     opts,
+		now:      time.Now,
+		entries:  make(map[BackendKind]BackendInventory),
+	}
+}
+
+// Inventory creates an Inventory over m's backends.
+func (m *MultiManager) Inventory(opts InventoryOptions) *Inventory {
+	return NewInventory(m.backends, opts)
+}
+
+// Snapshot returns every backend's inventory, first listing the backends
+// whose listing is missing, stale or older than MaxAge.
+//
+// A backend whose listing fails keeps its previous listing, marked Stale. A
+// backend that has never been listed successfully has no entry; its error,
+// annotated with the backend kind, is joined into the returned error.
+func (inv *Inventory) Snapshot(ctx context.Context) (map[BackendKind]BackendInventory, error) {
+	return inv.refresh(ctx, false)
+}
+
+// Refresh is like Snapshot but lists every backend regardless of MaxAge.
+func (inv *Inventory) Refresh(ctx context.Context) (map[BackendKind]BackendInventory, error) {
+	return inv.refresh(ctx, true)
+}
+
+// Invalidate drops the listings of the given backends, or of all backends
+// if none are given, so that the next Snapshot lists them again.
+func (inv *Inventory) Invalidate(kinds ...BackendKind) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if len(kinds) == 0 {
+		clear(inv.entries)
+		return
+	}
+	for _, kind := range kinds {
+		delete(inv.entries, kind)
+	}
+}
+
+func (inv *Inventory) refresh(ctx context.Context, force bool) (map[BackendKind]BackendInventory, error) {
+	now := inv.now()
+	var due []Backend
+	inv.mu.Lock()
+	for _, b := range inv.backends {
+		e, ok := inv.entries[b.Kind]
+		if force || !ok || e.Stale || now.Sub(e.FetchedAt) >= inv.opts.MaxAge {
+			due = append(due, b)
+		}
+	}
+	inv.mu.Unlock()
+
+	// Listing failures are kept in the results rather than returned, so
+	// they can be attached to earlier listings.
+	results, ctxErr := Parallel(ctx, due, inv.opts.Workers, func(ctx context.Context, b Backend) (BackendInventory, error) {
+		pkgs, err := b.Manager.(Lister).ListInstalled(ctx, inv.opts.List)
+		return BackendInventory{Packages: pkgs, FetchedAt: inv.now(), Err: err}, nil
+	})
+
+	var errs []error
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for _, b := range due {
+		res, ok := results[b.Kind]
+		if !ok {
+			continue // not started before ctx was cancelled
+		}
+		if res.Err == nil {
+			inv.entries[b.Kind] = res
+			continue
+		}
+		if prev, ok := inv.entries[b.Kind]; ok {
+			prev.Stale, prev.Err = true, res.Err
+			inv.entries[b.Kind] = prev
+			continue
+		}
+		errs = append(errs, backendErr(b.Kind, res.Err))
+	}
+
+	snapshot := make(map[BackendKind]BackendInventory, len(inv.entries))
+	for kind, e := range inv.entries {
+		snapshot[kind] = e
+	}
+	return snapshot, errors.Join(append(errs, ctxErr)...)
+}
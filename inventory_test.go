@@ -0,0 +1,159 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingLister counts ListInstalled calls and fails while err is set.
+type countingLister struct {
+	managerOnly
+	calls atomic.Int32
+	err   atomic.Pointer[error]
+}
+
+func (c *countingLister) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	c.calls.Add(1)
+	if err := c.err.Load(); err != nil {
+		return nil, *err
+	}
+	return []InstalledPackage{{Ref: PackageRef{Name: "pkg"}}}, nil
+}
+
+func TestInventory_ReusesFreshListings(t *testing.T) {
+	a, b := &countingLister{}, &countingLister{}
+	inv := NewInventory([]Backend{
+		{Kind: "a", Manager: a},
+		{Kind: "b", Manager: b},
+		{Kind: "c", Manager: managerOnly{}},
+	}, InventoryOptions{Workers: 1, MaxAge: time.Hour})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	inv.now = func() time.Time { return now }
+
+	snap, err := inv.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if len(snap) != 2 {
+		t.Fatalf("Expected listings for the 2 listers, got %v", snap)
+	}
+	if !snap["a"].FetchedAt.Equal(now) || snap["a"].Stale {
+		t.Errorf("Unexpected metadata: %+v", snap["a"])
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := inv.Snapshot(context.Background()); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if a.calls.Load() != 1 || b.calls.Load() != 1 {
+		t.Errorf("Fresh listings should be reused, got %d and %d calls", a.calls.Load(), b.calls.Load())
+	}
+
+	inv.Invalidate("a")
+	if _, err := inv.Snapshot(context.Background()); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if a.calls.Load() != 2 || b.calls.Load() != 1 {
+		t.Errorf("Only the invalidated backend should be listed again, got %d and %d calls", a.calls.Load(), b.calls.Load())
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := inv.Snapshot(context.Background()); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if b.calls.Load() != 2 {
+		t.Errorf("Expired listing should be refreshed, got %d calls", b.calls.Load())
+	}
+
+	if _, err := inv.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if a.calls.Load() != 4 || b.calls.Load() != 3 {
+		t.Errorf("Refresh should list every backend, got %d and %d calls", a.calls.Load(), b.calls.Load())
+	}
+}
+
+func TestInventory_KeepsStaleListingOnFailure(t *testing.T) {
+	a, b := &countingLister{}, &countingLister{}
+	failure := errors.New("boom")
+	b.err.Store(&failure)
+	inv := NewInventory([]Backend{{Kind: "a", Manager: a}, {Kind: "b", Manager: b}}, InventoryOptions{})
+
+	snap, err := inv.Snapshot(context.Background())
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected never-listed failure to be returned, got %v", err)
+	}
+	if _, ok := snap["b"]; ok {
+		t.Error("Never-listed backend should have no entry")
+	}
+	if _, ok := snap["a"]; !ok {
+		t.Error("Healthy backend should have an entry")
+	}
+
+	b.err.Store(nil)
+	if _, err := inv.Snapshot(context.Background()); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	a.err.Store(&failure)
+	snap, err = inv.Snapshot(context.Background())
+	if err != nil {
+		t.Errorf("Failure with an earlier listing should not be returned, got %v", err)
+	}
+	got := snap["a"]
+	if !got.Stale || !errors.Is(got.Err, failure) || len(got.Packages) != 1 {
+		t.Errorf("Expected stale earlier listing with error, got %+v", got)
+	}
+
+	a.err.Store(nil)
+	snap, _ = inv.Snapshot(context.Background())
+	if snap["a"].Stale || snap["a"].Err != nil {
+		t.Errorf("Stale listing should be retried and cleared, got %+v", snap["a"])
+	}
+}
+
+func TestInventory_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	var backends []Backend
+	for _, b := range testBackends(6) {
+		backends = append(backends, Backend{Kind: b.Kind, Manager: listerFunc(func(ctx context.Context) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			inFlight.Add(-1)
+		})})
+	}
+	inv := NewInventory(backends, InventoryOptions{Workers: 2})
+
+	snap, err := inv.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if len(snap) != 6 {
+		t.Errorf("Expected 6 listings, got %d", len(snap))
+	}
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent listings, saw %d", peak.Load())
+	}
+}
+
+// listerFunc is a Lister that runs a function and lists nothing.
+type listerFunc func(ctx context.Context)
+
+func (listerFunc) Name() string                                           { return "lister" }
+func (listerFunc) Kind() BackendKind                                      { return "lister" }
+func (listerFunc) Available(ctx context.Context) (bool, error)            { return true, nil }
+func (listerFunc) Capabilities(ctx context.Context) ([]Capability, error) { return nil, nil }
+
+func (f listerFunc) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	f(ctx)
+	return nil, nil
+}
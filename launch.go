@@ -0,0 +1,125 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// internalLauncher is implemented by backends that can start installed
+// applications. They return the command to run; the adapter starts it.
+type internalLauncher interface {
+	LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error)
+}
+
+// Process is an application started by Launch. It keeps running after
+// Launch returns and after its context is done.
+type Process struct {
+	// Ref is the package the application belongs to.
+	Ref PackageRef
+
+	// Command is the command line that started the application, such as
+	// ["flatpak", "run", "org.gimp.GIMP"].
+	Command []string
+
+	// PID is the process ID of the command, or 0 if pm did not start it,
+	// as for Processes returned by pmtest.FakeManager.
+	PID int
+
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// startProcess starts cmd for ref. The command is reaped as soon as it
+// exits, whether or not Wait is called.
+func startProcess(ref PackageRef, cmd types.LaunchCommand) (*Process, error) {
+	c, err := runner.Start(cmd.Name, cmd.Args...)
+	if err != nil {
+		return nil, err
+	}
+	p := &Process{
+		Ref:     ref,
+		Command: append([]string{cmd.Name}, cmd.Args...),
+		PID:     c.Process.Pid,
+		cmd:     c,
+		done:    make(chan struct{}),
+	}
+	go func() {
+		p.err = c.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Wait waits for the command to exit and returns its error, like
+// exec.Cmd.Wait. Some commands exit as soon as the application is running:
+// `open` for brew casks, or `flatpak run` and `snap run` when the
+// application hands over to an instance that is already running. Wait may
+// be called any number of times, from any goroutine.
+func (p *Process) Wait() error {
+	if p.done == nil {
+		return nil
+	}
+	<-p.done
+	return p.err
+}
+
+// Kill kills the command. It does nothing if the command has exited.
+func (p *Process) Kill() error {
+	if p.cmd == nil {
+		return nil
+	}
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+	return p.cmd.Process.Kill()
+}
+
+// Launch implements Launcher. It fails with NotSupportedError if the
+// backend cannot launch applications, and in simulation or with a custom
+// runner, which may run commands elsewhere than where Launch would start
+// the application.
+func (a *backendAdapter) Launch(ctx context.Context, ref PackageRef, opts LaunchOptions) (*Process, error) {
+	launcher, ok := a.backend.(internalLauncher)
+	switch {
+	case !ok:
+		return nil, &NotSupportedError{Operation: OperationLaunch, Backend: string(a.kind)}
+	case a.simulate:
+		return nil, &NotSupportedError{Operation: OperationLaunch, Backend: string(a.kind), Reason: "applications are not launched in simulation"}
+	case a.customRunner:
+		return nil, &NotSupportedError{Operation: OperationLaunch, Backend: string(a.kind), Reason: "commands run through a custom runner"}
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	pr, summary := a.reporter(ctx, opts.Progress)
+	cmd, err := launcher.LaunchCommand(ctx, types.PackageRef{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+		Channel:   ref.Channel,
+		Kind:      ref.Kind,
+	}, types.LaunchOptions{Args: opts.Args, Progress: pr})
+	if err != nil {
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	// Start runs no runner, so apply the binary path the runner would
+	if cmd.Name == string(a.kind) && a.binaryPath != "" {
+		cmd.Name = a.binaryPath
+	}
+	p, err := startProcess(ref, cmd)
+	if err != nil {
+		err = fmt.Errorf("launch %s: %w", ref.Name, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	summary.finish(nil, 0)
+	return p, nil
+}
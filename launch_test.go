@@ -0,0 +1,88 @@
+package pm
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// launchBackend launches every package by running the test binary, which
+// exits at once when asked to run no tests.
+// With command set, it runs that command name instead.
+type launchBackend struct {
+	countingBackend
+	got     types.PackageRef
+	command string
+}
+
+func (b *launchBackend) LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error) {
+	b.got = pkg
+	name := os.Args[0]
+	if b.command != "" {
+		name = b.command
+	}
+	return types.LaunchCommand{Name: name, Args: append([]string{"-test.run=^$"}, opts.Args...)}, nil
+}
+
+func TestBackendAdapter_Launch(t *testing.T) {
+	backend := &launchBackend{}
+	mgr := Wrap(newBackendAdapter(BackendFlatpak, backend, &backendConfig{}))
+
+	p, err := mgr.Launch(context.Background(), PackageRef{Name: "org.gimp.GIMP", Channel: "beta"}, LaunchOptions{Args: []string{"-test.count=1"}})
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	if backend.got.Name != "org.gimp.GIMP" || backend.got.Channel != "beta" {
+		t.Errorf("backend launched %+v", backend.got)
+	}
+	if p.PID == 0 || p.Ref.Name != "org.gimp.GIMP" || len(p.Command) != 3 || p.Command[2] != "-test.count=1" {
+		t.Errorf("Launch() = %+v", p)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("second Wait() error = %v", err)
+	}
+	if err := p.Kill(); err != nil {
+		t.Errorf("Kill() after exit error = %v", err)
+	}
+}
+
+func TestBackendAdapter_LaunchBinaryPath(t *testing.T) {
+	// "flatpak" is not on PATH here, but its configured path is the test
+	// binary
+	backend := &launchBackend{command: "flatpak"}
+	mgr := newBackendAdapter(BackendFlatpak, backend, &backendConfig{binaryPaths: map[BackendKind]string{BackendFlatpak: os.Args[0]}})
+
+	p, err := mgr.Launch(context.Background(), PackageRef{Name: "org.gimp.GIMP"}, LaunchOptions{})
+	if err != nil {
+		t.Fatalf("Launch() error = %v", err)
+	}
+	if p.Command[0] != os.Args[0] {
+		t.Errorf("Launch() command = %q, want the configured path", p.Command)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+}
+
+func TestBackendAdapter_LaunchNotSupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend internalBackend
+		cfg     *backendConfig
+	}{
+		{"no launcher", &countingBackend{}, &backendConfig{}},
+		{"simulation", &launchBackend{}, &backendConfig{simulate: true}},
+		{"custom runner", &launchBackend{}, &backendConfig{runners: map[BackendKind]Runner{BackendFlatpak: NewExecRunner()}}},
+	}
+	for _, tt := range tests {
+		adapter := newBackendAdapter(BackendFlatpak, tt.backend, tt.cfg)
+		if _, err := adapter.Launch(context.Background(), PackageRef{Name: "org.gimp.GIMP"}, LaunchOptions{}); !IsNotSupported(err) {
+			t.Errorf("%s: Launch() error = %v, want NotSupportedError", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Call describes one operation passing through a middleware chain.
+// Middleware may inspect or modify it before calling the next handler.
+type Call struct {
+	// Operation is the operation being performed.
+	Operation Operation
+
+	// Backend names the wrapped backend (e.g. "brew").
+	Backend string
+
+	// Packages holds the packages for Install, Uninstall, Query and Verify,
+	// and the one package for Launch and Icon.
+	Packages []PackageRef
+
+	// Query holds the search query for Search.
+	Query string
+
+	// Options holds the operation's options value (UpdateOptions,
+	// InstallOptions, ...). Middleware may replace it with a modified copy
+	// of the same type.
+	Options any
+}
+
+// Handler performs a Call. The result is the operation's result value
+// (UpdateResult, InstallResult, []PackageRef, ...), or nil for the zero
+// result.
+type Handler func(ctx context.Context, call *Call) (any, error)
+
+// Middleware wraps a Handler to observe or change operations: logging,
+// metrics, policy checks, short-circuiting mutating calls, and so on.
+type Middleware func(next Handler) Handler
+
+// WrappedManager runs every package operation of a Manager through a chain
+// of middleware. Name, Kind, Available and Capabilities are passed straight
+// through.
+//
+// WrappedManager implements every optional interface; operations the
+// wrapped manager does not implement reach the end of the chain and fail
+// with NotSupportedError.
+type WrappedManager struct {
+	mgr     Manager
+	handler Handler
+}
+
+// Wrap applies middlewares to mgr. The first middleware is the outermost:
+// it sees each call first and its result last.
+func Wrap(mgr Manager, middlewares ...Middleware) *WrappedManager {
+	w := &WrappedManager{mgr: mgr}
+	h := Handler(w.dispatch)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	w.handler = h
+	return w
+}
+
+// Unwrap returns the wrapped manager.
+func (w *WrappedManager) Unwrap() Manager {
+	return w.mgr
+}
+
+// Name delegates to the wrapped manager.
+func (w *WrappedManager) Name() string {
+	return w.mgr.Name()
+}
+
+// Kind delegates to the wrapped manager.
+func (w *WrappedManager) Kind() BackendKind {
+	return w.mgr.Kind()
+}
+
+// Available delegates to the wrapped manager.
+func (w *WrappedManager) Available(ctx context.Context) (bool, error) {
+	return w.mgr.Available(ctx)
+}
+
+// InvalidateAvailability delegates to the wrapped manager if it implements
+// AvailabilityInvalidator.
+func (w *WrappedManager) InvalidateAvailability() {
+	if inv, ok := w.mgr.(AvailabilityInvalidator); ok {
+		inv.InvalidateAvailability()
+	}
+}
+
+// Capabilities delegates to the wrapped manager.
+func (w *WrappedManager) Capabilities(ctx context.Context) ([]Capability, error) {
+	return w.mgr.Capabilities(ctx)
+}
+
+// Update implements Updater.
+func (w *WrappedManager) Update(ctx context.Context, opts UpdateOptions) (UpdateResult, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationUpdateMetadata, Backend: backendName(w.mgr), Options: opts})
+	return resultAs[UpdateResult](res, err)
+}
+
+// Upgrade implements Upgrader.
+func (w *WrappedManager) Upgrade(ctx context.Context, opts UpgradeOptions) (UpgradeResult, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationUpgradePackages, Backend: backendName(w.mgr), Options: opts})
+	return resultAs[UpgradeResult](res, err)
+}
+
+// Install implements Installer.
+func (w *WrappedManager) Install(ctx context.Context, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationInstall, Backend: backendName(w.mgr), Packages: pkgs, Options: opts})
+	return resultAs[InstallResult](res, err)
+}
+
+// Uninstall implements Uninstaller.
+func (w *WrappedManager) Uninstall(ctx context.Context, pkgs []PackageRef, opts UninstallOptions) (UninstallResult, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationUninstall, Backend: backendName(w.mgr), Packages: pkgs, Options: opts})
+	return resultAs[UninstallResult](res, err)
+}
+
+// Search implements Searcher.
+func (w *WrappedManager) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationSearch, Backend: backendName(w.mgr), Query: query, Options: opts})
+	return resultAs[[]PackageRef](res, err)
+}
+
+// ListInstalled implements Lister.
+func (w *WrappedManager) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationListInstalled, Backend: backendName(w.mgr), Options: opts})
+	return resultAs[[]InstalledPackage](res, err)
+}
+
+// ListOutdated implements OutdatedLister.
+func (w *WrappedManager) ListOutdated(ctx context.Context, opts OutdatedOptions) ([]OutdatedPackage, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationListOutdated, Backend: backendName(w.mgr), Options: opts})
+	return resultAs[[]OutdatedPackage](res, err)
+}
+
+// Query implements Querier.
+func (w *WrappedManager) Query(ctx context.Context, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationQuery, Backend: backendName(w.mgr), Packages: pkgs, Options: opts})
+	return resultAs[[]InstalledPackage](res, err)
+}
+
+// Verify implements Verifier.
+func (w *WrappedManager) Verify(ctx context.Context, pkgs []PackageRef, opts VerifyOptions) ([]Attestation, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationVerify, Backend: backendName(w.mgr), Packages: pkgs, Options: opts})
+	return resultAs[[]Attestation](res, err)
+}
+
+// Launch implements Launcher.
+func (w *WrappedManager) Launch(ctx context.Context, ref PackageRef, opts LaunchOptions) (*Process, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationLaunch, Backend: backendName(w.mgr), Packages: []PackageRef{ref}, Options: opts})
+	return resultAs[*Process](res, err)
+}
+
+// Icon implements IconFetcher.
+func (w *WrappedManager) Icon(ctx context.Context, ref PackageRef, opts IconOptions) (string, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationIcon, Backend: backendName(w.mgr), Packages: []PackageRef{ref}, Options: opts})
+	return resultAs[string](res, err)
+}
+
+// Watch implements Watcher. Middleware sees the call that starts watching,
+// not the events.
+func (w *WrappedManager) Watch(ctx context.Context) (<-chan PackageEvent, error) {
+	res, err := w.handler(ctx, &Call{Operation: OperationWatch, Backend: backendName(w.mgr)})
+	return resultAs[<-chan PackageEvent](res, err)
+}
+
+// dispatch is the innermost handler; it invokes the wrapped manager.
+func (w *WrappedManager) dispatch(ctx context.Context, call *Call) (any, error) {
+	switch call.Operation {
+	case OperationUpdateMetadata:
+		return invoke(w.mgr, call, func(m Updater, opts UpdateOptions) (UpdateResult, error) {
+			return m.Update(ctx, opts)
+		})
+	case OperationUpgradePackages:
+		return invoke(w.mgr, call, func(m Upgrader, opts UpgradeOptions) (UpgradeResult, error) {
+			return m.Upgrade(ctx, opts)
+		})
+	case OperationInstall:
+		return invoke(w.mgr, call, func(m Installer, opts InstallOptions) (InstallResult, error) {
+			return m.Install(ctx, call.Packages, opts)
+		})
+	case OperationUninstall:
+		return invoke(w.mgr, call, func(m Uninstaller, opts UninstallOptions) (UninstallResult, error) {
+			return m.Uninstall(ctx, call.Packages, opts)
+		})
+	case OperationSearch:
+		return invoke(w.mgr, call, func(m Searcher, opts SearchOptions) ([]PackageRef, error) {
+			return m.Search(ctx, call.Query, opts)
+		})
+	case OperationListInstalled:
+		return invoke(w.mgr, call, func(m Lister, opts ListOptions) ([]InstalledPackage, error) {
+			return m.ListInstalled(ctx, opts)
+		})
+	case OperationListOutdated:
+		return invoke(w.mgr, call, func(m OutdatedLister, opts OutdatedOptions) ([]OutdatedPackage, error) {
+			return m.ListOutdated(ctx, opts)
+		})
+	case OperationQuery:
+		return invoke(w.mgr, call, func(m Querier, opts QueryOptions) ([]InstalledPackage, error) {
+			return m.Query(ctx, call.Packages, opts)
+		})
+	case OperationVerify:
+		return invoke(w.mgr, call, func(m Verifier, opts VerifyOptions) ([]Attestation, error) {
+			return m.Verify(ctx, call.Packages, opts)
+		})
+	case OperationLaunch:
+		return invoke(w.mgr, call, func(m Launcher, opts LaunchOptions) (*Process, error) {
+			return m.Launch(ctx, call.Packages[0], opts)
+		})
+	case OperationIcon:
+		return invoke(w.mgr, call, func(m IconFetcher, opts IconOptions) (string, error) {
+			return m.Icon(ctx, call.Packages[0], opts)
+		})
+	case OperationWatch:
+		return invoke(w.mgr, call, func(m Watcher, _ any) (<-chan PackageEvent, error) {
+			return m.Watch(ctx)
+		})
+	}
+	return nil, &NotSupportedError{Operation: call.Operation, Backend: call.Backend}
+}
+
+// invoke asserts the optional interface I and the call's options type O,
+// then runs fn.
+func invoke[I, O, R any](mgr Manager, call *Call, fn func(I, O) (R, error)) (any, error) {
+	m, err := As[I](mgr)
+	if err != nil {
+		return nil, err
+	}
+	opts, ok := call.Options.(O)
+	if !ok && call.Options != nil {
+		return nil, fmt.Errorf("middleware: %s call has options of type %T", call.Operation, call.Options)
+	}
+	return fn(m, opts)
+}
+
+// resultAs converts a handler result back to the operation's result type.
+func resultAs[R any](res any, err error) (R, error) {
+	r, ok := res.(R)
+	if !ok && res != nil && err == nil {
+		err = fmt.Errorf("middleware: unexpected result of type %T", res)
+	}
+	return r, err
+}
@@ -0,0 +1,111 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWrap_OrderAndObservation(t *testing.T) {
+	fake := &fakeManager{search: []PackageRef{{Name: "git"}}}
+	var trace []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (any, error) {
+				trace = append(trace, name+">"+string(call.Operation))
+				res, err := next(ctx, call)
+				trace = append(trace, name+"<")
+				return res, err
+			}
+		}
+	}
+
+	m := Wrap(fake, record("outer"), record("inner"))
+	res, err := m.Search(context.Background(), "git", SearchOptions{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(res) != 1 || res[0].Name != "git" {
+		t.Errorf("Unexpected results %+v", res)
+	}
+
+	want := []string{"outer>Search", "inner>Search", "inner<", "outer<"}
+	if len(trace) != len(want) {
+		t.Fatalf("trace = %v, want %v", trace, want)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Errorf("trace = %v, want %v", trace, want)
+			break
+		}
+	}
+}
+
+func TestWrap_PolicyDenial(t *testing.T) {
+	fake := &fakeManager{}
+	denied := errors.New("installs are disabled")
+	deny := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Operation == OperationInstall {
+				return nil, denied
+			}
+			return next(ctx, call)
+		}
+	}
+
+	m := Wrap(fake, deny)
+	if _, err := m.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{}); !errors.Is(err, denied) {
+		t.Errorf("Expected policy error, got %v", err)
+	}
+	if len(fake.installCalls) != 0 {
+		t.Error("Denied Install should not reach the backend")
+	}
+}
+
+func TestWrap_ModifyCall(t *testing.T) {
+	fake := &fakeManager{}
+	rename := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			call.Packages = []PackageRef{{Name: "renamed"}}
+			return next(ctx, call)
+		}
+	}
+
+	res, err := Wrap(fake, rename).Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(fake.installCalls) != 1 || fake.installCalls[0][0].Name != "renamed" {
+		t.Errorf("Expected modified packages to reach backend, got %+v", fake.installCalls)
+	}
+	if len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "renamed" {
+		t.Errorf("Unexpected result %+v", res)
+	}
+}
+
+func TestWrap_DryRunShortCircuit(t *testing.T) {
+	fake := &fakeManager{}
+	dryRun := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Operation == OperationInstall {
+				return InstallResult{}, nil
+			}
+			return next(ctx, call)
+		}
+	}
+
+	res, err := Wrap(fake, dryRun).Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{})
+	if err != nil || res.Changed || len(fake.installCalls) != 0 {
+		t.Errorf("Expected no-op install, got %+v, %v, %d calls", res, err, len(fake.installCalls))
+	}
+}
+
+func TestWrap_UnimplementedOperation(t *testing.T) {
+	m := Wrap(&fakeManager{})
+	if _, err := m.Upgrade(context.Background(), UpgradeOptions{}); !IsNotSupported(err) {
+		t.Errorf("Expected NotSupportedError, got %v", err)
+	}
+	if _, err := As[Upgrader](m); !IsNotSupported(err) {
+		t.Errorf("Expected As to see through Wrap, got %v", err)
+	}
+}
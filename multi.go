@@ -0,0 +1,307 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Backend pairs a Manager with the kind of backend it drives.
+type Backend struct {
+	// Kind identifies the backend (brew, flatpak, snap).
+	Kind BackendKind
+
+	// Manager is the backend implementation.
+	Manager Manager
+}
+
+// MultiManager fans operations out across several backends.
+//
+// Read operations (Search, ListInstalled) and system-wide operations
+// (Update, Upgrade) run against every backend that implements the
+// corresponding interface; backends that don't are skipped. Operations on
+// specific packages (Install, Uninstall) are routed to a single backend.
+//
+// Results are keyed by backend kind. Errors from individual backends do not
+// stop the remaining backends; they are joined and returned alongside the
+// results that did succeed.
+type MultiManager struct {
+	backends []Backend
+	aliases  *AliasTable
+}
+
+// NewMultiManager creates a MultiManager over the given backends.
+// Backends are consulted in the order provided.
+func NewMultiManager(backends ...Backend) *MultiManager {
+	return &MultiManager{backends: backends, aliases: DefaultAliases}
+}
+
+// SetAliases replaces the alias table used by Resolve (DefaultAliases by default).
+func (m *MultiManager) SetAliases(t *AliasTable) {
+	m.aliases = t
+}
+
+// Resolve routes a logical package name to the first backend, in priority
+// order, that has an alias entry for it. It reports false if no managed
+// backend has an entry.
+func (m *MultiManager) Resolve(name string) (BackendKind, PackageRef, bool) {
+	if m.aliases == nil {
+		return "", PackageRef{}, false
+	}
+	for _, b := range m.backends {
+		if ref, ok := m.aliases.Lookup(name, b.Kind); ok {
+			return b.Kind, ref, true
+		}
+	}
+	return "", PackageRef{}, false
+}
+
+// InvalidateAvailability forgets the cached Available result of every
+// backend that implements AvailabilityInvalidator.
+func (m *MultiManager) InvalidateAvailability() {
+	for _, b := range m.backends {
+		if inv, ok := b.Manager.(AvailabilityInvalidator); ok {
+			inv.InvalidateAvailability()
+		}
+	}
+}
+
+// Backends returns the backends managed by m, in priority order.
+func (m *MultiManager) Backends() []Backend {
+	out := make([]Backend, len(m.backends))
+	copy(out, m.backends)
+	return out
+}
+
+// Get returns the manager for the given backend kind.
+func (m *MultiManager) Get(kind BackendKind) (Manager, bool) {
+	for _, b := range m.backends {
+		if b.Kind == kind {
+			return b.Manager, true
+		}
+	}
+	return nil, false
+}
+
+// Name joins the names of m's backends, such as "Homebrew, Flatpak".
+func (m *MultiManager) Name() string {
+	names := make([]string, len(m.backends))
+	for i, b := range m.backends {
+		names[i] = b.Manager.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Kind returns "", since m spans several backends; use Backends to tell
+// them apart.
+func (m *MultiManager) Kind() BackendKind {
+	return ""
+}
+
+// Available reports whether at least one backend is available.
+func (m *MultiManager) Available(ctx context.Context) (bool, error) {
+	var errs []error
+	for _, b := range m.backends {
+		available, err := b.Manager.Available(ctx)
+		if err != nil {
+			errs = append(errs, backendErr(b.Kind, err))
+			continue
+		}
+		if available {
+			return true, nil
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// Capabilities reports an operation as supported if any backend supports it.
+func (m *MultiManager) Capabilities(ctx context.Context) ([]Capability, error) {
+	var result []Capability
+	index := make(map[Operation]int)
+	var errs []error
+	for _, b := range m.backends {
+		caps, err := b.Manager.Capabilities(ctx)
+		if err != nil {
+			errs = append(errs, backendErr(b.Kind, err))
+			continue
+		}
+		for _, c := range caps {
+			i, ok := index[c.Operation]
+			if !ok {
+				index[c.Operation] = len(result)
+				result = append(result, Capability{Operation: c.Operation, Supported: c.Supported})
+				continue
+			}
+			result[i].Supported = result[i].Supported || c.Supported
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// Update refreshes metadata on every backend that implements Updater,
+// concurrently.
+func (m *MultiManager) Update(ctx context.Context, opts UpdateOptions) (map[BackendKind]UpdateResult, error) {
+	return ParallelUpdate(ctx, m.backends, 0, opts)
+}
+
+// Upgrade upgrades packages on every backend that implements Upgrader,
+// concurrently.
+func (m *MultiManager) Upgrade(ctx context.Context, opts UpgradeOptions) (map[BackendKind]UpgradeResult, error) {
+	return ParallelUpgrade(ctx, m.backends, 0, opts)
+}
+
+// Search searches every backend that implements Searcher concurrently.
+//
+// With opts.Limit set, Search returns once Limit results have arrived in
+// total: searches still running are cancelled, and their backends have
+// neither results nor errors. Which backends fill the limit depends on which
+// answer first.
+func (m *MultiManager) Search(ctx context.Context, query string, opts SearchOptions) (map[BackendKind][]PackageRef, error) {
+	results := make(map[BackendKind][]PackageRef)
+	err := m.SearchEach(ctx, query, opts, func(kind BackendKind, pkgs []PackageRef) {
+		results[kind] = pkgs
+	})
+	return results, err
+}
+
+// SearchEach is like Search but calls fn with each backend's results as
+// soon as that backend answers, so callers can show them without waiting
+// for the slowest backend. fn is never called concurrently.
+func (m *MultiManager) SearchEach(ctx context.Context, query string, opts SearchOptions, fn func(kind BackendKind, pkgs []PackageRef)) error {
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		errs      []error
+		remaining = opts.Limit
+	)
+	// Failures are collected here rather than from Parallel, which would
+	// also report the cancellation once the limit is met.
+	_, _ = Parallel(searchCtx, only[Searcher](m.backends), 0, func(ctx context.Context, b Backend) (struct{}, error) {
+		pkgs, err := b.Manager.(Searcher).Search(ctx, query, opts)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if opts.Limit > 0 && remaining == 0 {
+			return struct{}{}, nil // the limit was met while this search ran
+		}
+		if err != nil {
+			errs = append(errs, backendErr(b.Kind, err))
+			return struct{}{}, nil
+		}
+		if opts.Limit > 0 {
+			pkgs = limitRefs(pkgs, remaining)
+			remaining -= len(pkgs)
+			if remaining == 0 {
+				cancel()
+			}
+		}
+		fn(b.Kind, pkgs)
+		return struct{}{}, nil
+	})
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ListInstalled lists installed packages on every backend that implements
+// Lister, concurrently.
+func (m *MultiManager) ListInstalled(ctx context.Context, opts ListOptions) (map[BackendKind][]InstalledPackage, error) {
+	return ParallelListInstalled(ctx, m.backends, 0, opts)
+}
+
+// ListOutdated lists the packages with updates on every backend that
+// implements OutdatedLister, concurrently.
+func (m *MultiManager) ListOutdated(ctx context.Context, opts OutdatedOptions) (map[BackendKind][]OutdatedPackage, error) {
+	return Parallel(ctx, only[OutdatedLister](m.backends), 0, func(ctx context.Context, b Backend) ([]OutdatedPackage, error) {
+		return b.Manager.(OutdatedLister).ListOutdated(ctx, opts)
+	})
+}
+
+// Install installs packages using the given backend.
+func (m *MultiManager) Install(ctx context.Context, kind BackendKind, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return InstallResult{}, &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	installer, ok := mgr.(Installer)
+	if !ok {
+		return InstallResult{}, &NotSupportedError{Operation: OperationInstall, Backend: string(kind)}
+	}
+	return installer.Install(ctx, pkgs, opts)
+}
+
+// Uninstall removes packages using the given backend.
+func (m *MultiManager) Uninstall(ctx context.Context, kind BackendKind, pkgs []PackageRef, opts UninstallOptions) (UninstallResult, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return UninstallResult{}, &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	uninstaller, ok := mgr.(Uninstaller)
+	if !ok {
+		return UninstallResult{}, &NotSupportedError{Operation: OperationUninstall, Backend: string(kind)}
+	}
+	return uninstaller.Uninstall(ctx, pkgs, opts)
+}
+
+// Query reports which of pkgs are installed, using the given backend.
+func (m *MultiManager) Query(ctx context.Context, kind BackendKind, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return nil, &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	querier, ok := mgr.(Querier)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationQuery, Backend: string(kind)}
+	}
+	return querier.Query(ctx, pkgs, opts)
+}
+
+// Verify reports the provenance of pkgs, using the given backend.
+func (m *MultiManager) Verify(ctx context.Context, kind BackendKind, pkgs []PackageRef, opts VerifyOptions) ([]Attestation, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return nil, &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	verifier, ok := mgr.(Verifier)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationVerify, Backend: string(kind)}
+	}
+	return verifier.Verify(ctx, pkgs, opts)
+}
+
+// Launch starts the application ref installs, using the given backend.
+func (m *MultiManager) Launch(ctx context.Context, kind BackendKind, ref PackageRef, opts LaunchOptions) (*Process, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return nil, &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	launcher, ok := mgr.(Launcher)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationLaunch, Backend: string(kind)}
+	}
+	return launcher.Launch(ctx, ref, opts)
+}
+
+// Icon returns the path of a cached copy of the icon of ref, using the
+// given backend.
+func (m *MultiManager) Icon(ctx context.Context, kind BackendKind, ref PackageRef, opts IconOptions) (string, error) {
+	mgr, ok := m.Get(kind)
+	if !ok {
+		return "", &NotAvailableError{Backend: string(kind), Reason: "not managed by this MultiManager"}
+	}
+	fetcher, ok := mgr.(IconFetcher)
+	if !ok {
+		return "", &NotSupportedError{Operation: OperationIcon, Backend: string(kind)}
+	}
+	return fetcher.Icon(ctx, ref, opts)
+}
+
+// backendErr annotates err with the backend it came from.
+func backendErr(kind BackendKind, err error) error {
+	return fmt.Errorf("%s: %w", kind, err)
+}
@@ -0,0 +1,310 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeManager is a configurable in-memory Manager for multi-backend tests.
+type fakeManager struct {
+	available bool
+	caps      []Capability
+	installed []InstalledPackage
+	search    []PackageRef
+	err       error
+
+	installCalls [][]PackageRef
+}
+
+func (f *fakeManager) Name() string      { return "fake" }
+func (f *fakeManager) Kind() BackendKind { return "fake" }
+
+func (f *fakeManager) Available(ctx context.Context) (bool, error) {
+	return f.available, nil
+}
+
+func (f *fakeManager) Capabilities(ctx context.Context) ([]Capability, error) {
+	return f.caps, nil
+}
+
+func (f *fakeManager) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	return f.search, f.err
+}
+
+func (f *fakeManager) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	return f.installed, f.err
+}
+
+func (f *fakeManager) Install(ctx context.Context, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	f.installCalls = append(f.installCalls, pkgs)
+	return InstallResult{Changed: true, PackagesInstalled: pkgs}, f.err
+}
+
+func TestMultiManager_Search(t *testing.T) {
+	failure := errors.New("boom")
+	m := NewMultiManager(
+		Backend{Kind: BackendFlatpak, Manager: &fakeManager{search: []PackageRef{{Name: "org.mozilla.firefox"}}}},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{err: failure}},
+		Backend{Kind: BackendBrew, Manager: &fakeManager{search: []PackageRef{{Name: "firefox", Kind: "cask"}}}},
+	)
+
+	results, err := m.Search(context.Background(), "firefox", SearchOptions{})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected joined error to wrap backend failure, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected results from 2 backends, got %d", len(results))
+	}
+	if got := results[BackendFlatpak][0].Name; got != "org.mozilla.firefox" {
+		t.Errorf("Unexpected flatpak result %q", got)
+	}
+	if _, ok := results[BackendSnap]; ok {
+		t.Error("Failed backend should not have results")
+	}
+}
+
+func TestMultiManager_SkipsUnimplementedInterfaces(t *testing.T) {
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: &fakeManager{}},
+	)
+
+	results, err := m.Upgrade(context.Background(), UpgradeOptions{})
+	if err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestMultiManager_InstallRouting(t *testing.T) {
+	flatpak := &fakeManager{}
+	m := NewMultiManager(Backend{Kind: BackendFlatpak, Manager: flatpak})
+
+	pkgs := []PackageRef{{Name: "org.gimp.GIMP"}}
+	if _, err := m.Install(context.Background(), BackendFlatpak, pkgs, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(flatpak.installCalls) != 1 {
+		t.Errorf("Expected 1 install call, got %d", len(flatpak.installCalls))
+	}
+
+	_, err := m.Install(context.Background(), BackendSnap, pkgs, InstallOptions{})
+	if !IsNotAvailable(err) {
+		t.Errorf("Expected NotAvailable for unmanaged backend, got %v", err)
+	}
+
+	_, err = m.Uninstall(context.Background(), BackendFlatpak, pkgs, UninstallOptions{})
+	if !IsNotSupported(err) {
+		t.Errorf("Expected NotSupported for missing Uninstaller, got %v", err)
+	}
+}
+
+func TestMultiManager_Capabilities(t *testing.T) {
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: &fakeManager{caps: []Capability{
+			{Operation: OperationSearch, Supported: true},
+			{Operation: OperationInstall, Supported: false},
+		}}},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{caps: []Capability{
+			{Operation: OperationInstall, Supported: true},
+		}}},
+	)
+
+	caps, err := m.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+	if !Supports(caps, OperationSearch) || !Supports(caps, OperationInstall) {
+		t.Errorf("Expected union of capabilities, got %+v", caps)
+	}
+}
+
+func TestNew_UnknownBackend(t *testing.T) {
+	if _, err := New("apt"); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+// blockingSearcher is a Manager whose Search waits for ctx, standing in for
+// a slow backend.
+type blockingSearcher struct {
+	fakeManager
+	cancelled chan struct{}
+}
+
+func (b *blockingSearcher) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	<-ctx.Done()
+	close(b.cancelled)
+	return []PackageRef{{Name: "late"}}, ctx.Err()
+}
+
+func TestMultiManager_SearchLimitCancelsStragglers(t *testing.T) {
+	slow := &blockingSearcher{cancelled: make(chan struct{})}
+	fast := &fakeManager{search: []PackageRef{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	multi := NewMultiManager(
+		Backend{Kind: BackendSnap, Manager: slow},
+		Backend{Kind: BackendFlatpak, Manager: fast},
+	)
+
+	results, err := multi.Search(context.Background(), "q", SearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Search() error = %v, want nil", err)
+	}
+	if got := results[BackendFlatpak]; len(got) != 2 || got[0].Name != "a" || got[1].Name != "b" {
+		t.Errorf("flatpak results = %v, want [a b]", got)
+	}
+	if _, ok := results[BackendSnap]; ok {
+		t.Errorf("Cancelled backend has results: %v", results[BackendSnap])
+	}
+	select {
+	case <-slow.cancelled:
+	default:
+		t.Error("Slow search was not cancelled")
+	}
+}
+
+func TestMultiManager_SearchEachConcurrent(t *testing.T) {
+	// Each backend waits for the other to start, so a sequential Search
+	// would never finish.
+	first := &barrierSearcher{name: "one", self: make(chan struct{})}
+	second := &barrierSearcher{name: "two", self: make(chan struct{})}
+	first.other, second.other = second.self, first.self
+	multi := NewMultiManager(
+		Backend{Kind: BackendSnap, Manager: first},
+		Backend{Kind: BackendFlatpak, Manager: second},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var calls []BackendKind
+	err := multi.SearchEach(ctx, "q", SearchOptions{}, func(kind BackendKind, pkgs []PackageRef) {
+		calls = append(calls, kind)
+	})
+	if err != nil {
+		t.Fatalf("SearchEach() error = %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("fn called for %v, want both backends", calls)
+	}
+}
+
+type barrierSearcher struct {
+	fakeManager
+	name        string
+	self, other chan struct{}
+}
+
+func (b *barrierSearcher) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	close(b.self)
+	select {
+	case <-b.other:
+		return []PackageRef{{Name: b.name}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// slowManager is a Manager whose operations take a while, counting how
+// many of its kind run at once across every slowManager sharing running.
+type slowManager struct {
+	fakeManager
+	running *overlap
+}
+
+type overlap struct {
+	mu        sync.Mutex
+	now, peak int
+}
+
+func (o *overlap) enter() {
+	o.mu.Lock()
+	o.now++
+	o.peak = max(o.peak, o.now)
+	o.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	o.mu.Lock()
+	o.now--
+	o.mu.Unlock()
+}
+
+func (s *slowManager) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	s.running.enter()
+	return nil, nil
+}
+
+func (s *slowManager) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	s.running.enter()
+	return nil, nil
+}
+
+func (s *slowManager) Update(ctx context.Context, opts UpdateOptions) (UpdateResult, error) {
+	s.running.enter()
+	return UpdateResult{}, nil
+}
+
+func (s *slowManager) Upgrade(ctx context.Context, opts UpgradeOptions) (UpgradeResult, error) {
+	s.running.enter()
+	return UpgradeResult{}, nil
+}
+
+func TestMultiManager_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	ops := map[string]func(m *MultiManager) (int, error){
+		"Search": func(m *MultiManager) (int, error) {
+			res, err := m.Search(ctx, "q", SearchOptions{})
+			return len(res), err
+		},
+		"ListInstalled": func(m *MultiManager) (int, error) {
+			res, err := m.ListInstalled(ctx, ListOptions{})
+			return len(res), err
+		},
+		"Update": func(m *MultiManager) (int, error) {
+			res, err := m.Update(ctx, UpdateOptions{})
+			return len(res), err
+		},
+		"Upgrade": func(m *MultiManager) (int, error) {
+			res, err := m.Upgrade(ctx, UpgradeOptions{})
+			return len(res), err
+		},
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			running := &overlap{}
+			m := NewMultiManager(
+				Backend{Kind: BackendBrew, Manager: &slowManager{running: running}},
+				Backend{Kind: BackendFlatpak, Manager: &slowManager{running: running}},
+				Backend{Kind: BackendSnap, Manager: &slowManager{running: running}},
+			)
+			if n, err := op(m); n != 3 || err != nil {
+				t.Fatalf("%s() = %d results, %v, want 3", name, n, err)
+			}
+			if running.peak != 3 {
+				t.Errorf("%s() ran %d backends at once, want all 3", name, running.peak)
+			}
+		})
+	}
+}
+
+func TestManager_NameAndKind(t *testing.T) {
+	brew := NewBrew(WithoutOperationLock())
+	if brew.Name() != "Homebrew" || brew.Kind() != BackendBrew {
+		t.Errorf("NewBrew() Name, Kind = %q, %q", brew.Name(), brew.Kind())
+	}
+	for _, mgr := range []Manager{Wrap(brew), Strict(brew)} {
+		if mgr.Name() != "Homebrew" || mgr.Kind() != BackendBrew {
+			t.Errorf("%T does not delegate: Name, Kind = %q, %q", mgr, mgr.Name(), mgr.Kind())
+		}
+	}
+
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: brew},
+		Backend{Kind: BackendFlatpak, Manager: NewFlatpak(WithoutOperationLock())},
+	)
+	if m.Name() != "Homebrew, Flatpak" || m.Kind() != "" {
+		t.Errorf("MultiManager Name, Kind = %q, %q", m.Name(), m.Kind())
+	}
+}
@@ -0,0 +1,150 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NotifierOptions configures NewNotifier.
+type NotifierOptions struct {
+	// AppName is the application notifications are sent on behalf of;
+	// empty means "pm".
+	AppName string
+
+	// FailuresOnly suppresses notifications for operations that succeeded.
+	FailuresOnly bool
+
+	// Runner runs the notification commands; nil means NewExecRunner.
+	Runner Runner
+
+	// OnError, if set, receives failures to send a notification, which are
+	// otherwise ignored.
+	OnError func(err error)
+}
+
+// notifiedVerbs are the operations notifications are sent for, with the
+// verb describing what they did to packages.
+var notifiedVerbs = map[Operation]string{
+	OperationInstall:         "installed",
+	OperationUninstall:       "removed",
+	OperationUpgradePackages: "upgraded",
+}
+
+// notifyTimeout bounds how long sending one notification may take.
+const notifyTimeout = 5 * time.Second
+
+// notifier is the ProgressReporter returned by NewNotifier.
+type notifier struct {
+	opts NotifierOptions
+}
+
+// NewNotifier returns a ProgressReporter that announces the outcome of
+// Install, Uninstall and Upgrade operations as desktop notifications, such
+// as "3 packages upgraded" or "Upgrade failed", for tray applications.
+// Attach it like any reporter, with WithProgress, an operation's Progress
+// option or MultiReporter. Operations that changed nothing or were
+// cancelled are not announced.
+//
+// On Linux notifications are sent to the freedesktop notification service
+// on D-Bus with notify-send, or gdbus if notify-send is not installed. On
+// macOS they are sent with terminal-notifier if it is installed, and
+// osascript otherwise. Elsewhere nothing is sent. Notifications are sent
+// synchronously, from the goroutine reporting the end of the operation.
+func NewNotifier(opts NotifierOptions) ProgressReporter {
+	if opts.AppName == "" {
+		opts.AppName = "pm"
+	}
+	if opts.Runner == nil {
+		opts.Runner = NewExecRunner()
+	}
+	return &notifier{opts: opts}
+}
+
+func (n *notifier) OnAction(action ProgressAction) {
+	s := action.Summary
+	verb, ok := notifiedVerbs[Operation(action.Operation)]
+	if s == nil || !ok {
+		return
+	}
+	var title, body string
+	switch {
+	case s.Outcome == OutcomeFailure:
+		title = fmt.Sprintf("%s failed", action.Name)
+		body = s.Error
+	case s.Outcome == OutcomeSuccess && s.PackagesChanged > 0 && !n.opts.FailuresOnly:
+		title = fmt.Sprintf("%d %s %s", s.PackagesChanged, plural(s.PackagesChanged, "package", "packages"), verb)
+	default:
+		return
+	}
+	if action.Backend != "" {
+		if body != "" {
+			body = action.Backend + ": " + body
+		} else {
+			body = "with " + action.Backend
+		}
+	}
+	if err := n.send(title, body, s.Outcome == OutcomeFailure); err != nil && n.opts.OnError != nil {
+		n.opts.OnError(err)
+	}
+}
+
+func (n *notifier) OnTask(ProgressTask)       {}
+func (n *notifier) OnStep(ProgressStep)       {}
+func (n *notifier) OnMessage(ProgressMessage) {}
+
+// send shows a notification with the first available tool.
+func (n *notifier) send(title, body string, urgent bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	var name string
+	var args []string
+	switch currentPlatform().OS {
+	case "linux":
+		if _, err := lookPath("notify-send"); err == nil {
+			name, args = "notify-send", []string{"--app-name=" + n.opts.AppName}
+			if urgent {
+				args = append(args, "--urgency=critical")
+			}
+			args = append(args, title, body)
+			break
+		}
+		// org.freedesktop.Notifications.Notify(app_name, replaces_id,
+		// app_icon, summary, body, actions, hints, expire_timeout)
+		name, args = "gdbus", []string{
+			"call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			n.opts.AppName, "0", "", title, body, "[]", "{}", "-1",
+		}
+	case "darwin":
+		if _, err := lookPath("terminal-notifier"); err == nil {
+			name, args = "terminal-notifier", []string{"-title", n.opts.AppName, "-subtitle", title, "-message", body}
+			break
+		}
+		name, args = "osascript", []string{"-e", fmt.Sprintf("display notification %s with title %s subtitle %s",
+			appleScriptString(body), appleScriptString(n.opts.AppName), appleScriptString(title))}
+	default:
+		return nil
+	}
+	if _, stderr, err := n.opts.Runner.Run(ctx, name, args...); err != nil {
+		return fmt.Errorf("notify with %s: %w: %s", name, err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// plural returns one if n is 1, and many otherwise.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
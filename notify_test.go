@@ -0,0 +1,67 @@
+package pm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNotifier(t *testing.T) {
+	var sent []string
+	r := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		sent = append(sent, name+" "+strings.Join(args, " "))
+		return "", "", nil
+	})
+	upgraded := ProgressAction{Name: "Upgrade", Backend: "snap", Operation: string(OperationUpgradePackages), Summary: &ActionSummary{Outcome: OutcomeSuccess, PackagesChanged: 3}}
+	failed := ProgressAction{Name: "Install", Backend: "flatpak", Operation: string(OperationInstall), Summary: &ActionSummary{Outcome: OutcomeFailure, Error: "no remote"}}
+	unchanged := ProgressAction{Name: "Upgrade", Backend: "snap", Operation: string(OperationUpgradePackages), Summary: &ActionSummary{Outcome: OutcomeSuccess}}
+	searched := ProgressAction{Name: "Search", Operation: string(OperationSearch), Summary: &ActionSummary{Outcome: OutcomeFailure}}
+
+	tests := []struct {
+		name      string
+		platform  PlatformInfo
+		installed []string
+		opts      NotifierOptions
+		want      []string
+	}{
+		{
+			name:      "notify-send",
+			platform:  PlatformInfo{OS: "linux"},
+			installed: []string{"notify-send"},
+			want: []string{
+				"notify-send --app-name=pm 3 packages upgraded with snap",
+				"notify-send --app-name=pm --urgency=critical Install failed flatpak: no remote",
+			},
+		},
+		{
+			name:     "gdbus",
+			platform: PlatformInfo{OS: "linux"},
+			opts:     NotifierOptions{AppName: "Updater", FailuresOnly: true},
+			want: []string{
+				"gdbus call --session --dest org.freedesktop.Notifications --object-path /org/freedesktop/Notifications --method org.freedesktop.Notifications.Notify Updater 0  Install failed flatpak: no remote [] {} -1",
+			},
+		},
+		{
+			name:     "osascript",
+			platform: PlatformInfo{OS: "darwin"},
+			want: []string{
+				`osascript -e display notification "with snap" with title "pm" subtitle "3 packages upgraded"`,
+				`osascript -e display notification "flatpak: no remote" with title "pm" subtitle "Install failed"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPlatform(t, tt.platform, tt.installed...)
+			sent = nil
+			tt.opts.Runner = r
+			n := NewNotifier(tt.opts)
+			for _, action := range []ProgressAction{upgraded, failed, unchanged, searched} {
+				n.OnAction(action)
+			}
+			if strings.Join(sent, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("sent %q, want %q", sent, tt.want)
+			}
+		})
+	}
+}
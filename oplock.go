@@ -0,0 +1,46 @@
+package pm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// backendLocks holds one lock per backend kind and target, shared by every
+// manager in the process. Package managers guard their state with global
+// locks (the snapd change queue, the Homebrew lock, the flatpak repo lock),
+// so two mutating calls against the same backend would collide even when
+// issued through different Manager values.
+var backendLocks sync.Map // backendLockKey -> chan struct{}
+
+// backendLockKey identifies a backend on one machine or container. target
+// is the runner's Target for SSH and container runners, and "" for the
+// local machine; other runners given to WithRunner count as local.
+type backendLockKey struct {
+	kind   BackendKind
+	target string
+}
+
+// acquireBackendLock waits until the lock for kind on target is free or ctx
+// is done. It returns a release function and the time spent waiting.
+func acquireBackendLock(ctx context.Context, kind BackendKind, target string) (func(), time.Duration, error) {
+	v, _ := backendLocks.LoadOrStore(backendLockKey{kind, target}, make(chan struct{}, 1))
+	lock := v.(chan struct{})
+
+	start := time.Now()
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, time.Since(start), nil
+	case <-ctx.Done():
+		return nil, time.Since(start), ctx.Err()
+	}
+}
+
+// WithoutOperationLock disables per-backend serialization of mutating
+// operations. Use this only when callers already coordinate access, or when
+// the backend tolerates concurrent mutations.
+func WithoutOperationLock() ConstructorOption {
+	return func(config *backendConfig) {
+		config.noLock = true
+	}
+}
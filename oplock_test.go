@@ -0,0 +1,127 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// blockingBackend blocks Install until release is closed.
+type blockingBackend struct {
+	countingBackend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return types.InstallResult{Changed: true}, nil
+}
+
+func newBlockingBackend() *blockingBackend {
+	return &blockingBackend{started: make(chan struct{}, 4), release: make(chan struct{})}
+}
+
+func TestBackendAdapter_SerializesMutations(t *testing.T) {
+	backend := newBlockingBackend()
+	first := newBackendAdapter("test-serialize", backend, &backendConfig{})
+	second := newBackendAdapter("test-serialize", backend, &backendConfig{})
+	ctx := context.Background()
+
+	go func() { _, _ = first.Install(ctx, []PackageRef{{Name: "a"}}, InstallOptions{}) }()
+	<-backend.started
+
+	done := make(chan InstallResult)
+	go func() {
+		res, _ := second.Install(ctx, []PackageRef{{Name: "b"}}, InstallOptions{})
+		done <- res
+	}()
+
+	select {
+	case <-backend.started:
+		t.Fatal("Second install started while the first held the backend lock")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(backend.release)
+	<-backend.started
+	res := <-done
+	if res.Meta.QueueWait < 20*time.Millisecond {
+		t.Errorf("Expected QueueWait >= 20ms, got %v", res.Meta.QueueWait)
+	}
+}
+
+func TestBackendAdapter_LockWaitRespectsContext(t *testing.T) {
+	backend := newBlockingBackend()
+	defer close(backend.release)
+	first := newBackendAdapter("test-cancel", backend, &backendConfig{})
+	second := newBackendAdapter("test-cancel", backend, &backendConfig{})
+
+	go func() { _, _ = first.Install(context.Background(), []PackageRef{{Name: "a"}}, InstallOptions{}) }()
+	<-backend.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := second.Install(ctx, []PackageRef{{Name: "b"}}, InstallOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded while queued, got %v", err)
+	}
+}
+
+func TestBackendAdapter_WithoutOperationLock(t *testing.T) {
+	backend := newBlockingBackend()
+	cfg := &backendConfig{}
+	WithoutOperationLock()(cfg)
+	first := newBackendAdapter("test-nolock", backend, cfg)
+	second := newBackendAdapter("test-nolock", backend, cfg)
+	ctx := context.Background()
+
+	go func() { _, _ = first.Install(ctx, []PackageRef{{Name: "a"}}, InstallOptions{}) }()
+	go func() { _, _ = second.Install(ctx, []PackageRef{{Name: "b"}}, InstallOptions{}) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-backend.started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected both installs to run concurrently without the lock")
+		}
+	}
+	close(backend.release)
+}
+
+func TestBackendAdapter_LocksPerTarget(t *testing.T) {
+	backend := newBlockingBackend()
+	remote := func(host string) *backendConfig {
+		return &backendConfig{runners: map[BackendKind]Runner{
+			"test-target": NewSSHRunner(SSHConfig{Host: host}),
+		}}
+	}
+	local := newBackendAdapter("test-target", backend, &backendConfig{})
+	web1 := newBackendAdapter("test-target", backend, remote("web1"))
+	web2 := newBackendAdapter("test-target", backend, remote("web2"))
+	ctx := context.Background()
+
+	for _, a := range []*backendAdapter{local, web1, web2} {
+		go func() { _, _ = a.Install(ctx, []PackageRef{{Name: "a"}}, InstallOptions{}) }()
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-backend.started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected installs on different targets to run concurrently")
+		}
+	}
+
+	go func() { _, _ = web1.Install(ctx, []PackageRef{{Name: "b"}}, InstallOptions{}) }()
+	select {
+	case <-backend.started:
+		t.Fatal("Second install on web1 started while the first held its lock")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(backend.release)
+	<-backend.started
+}
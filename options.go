@@ -1,5 +1,14 @@
 package pm
 
+import "time"
+
+// OperationMeta carries execution details about a mutating operation.
+type OperationMeta struct {
+	// QueueWait is how long the operation waited for other operations on
+	// the same backend to finish before it started.
+	QueueWait time.Duration
+}
+
 // UpdateOptions provides options for Update operations.
 //
 // Update operations refresh package metadata/indexes without modifying
@@ -22,6 +31,9 @@ type UpdateResult struct {
 
 	// Messages contains summary messages from the operation.
 	Messages []ProgressMessage
+
+	// Meta contains execution details such as queue wait time.
+	Meta OperationMeta
 }
 
 // UpgradeOptions provides options for Upgrade operations.
@@ -29,6 +41,11 @@ type UpdateResult struct {
 // Upgrade operations install newer versions of packages that are already installed.
 // This is analogous to 'apt upgrade' or 'brew upgrade'.
 type UpgradeOptions struct {
+	// Packages restricts the upgrade to these installed packages, such as
+	// a subset of what ListOutdated reported; empty means all of them.
+	// Packages that are up to date are left alone.
+	Packages []PackageRef
+
 	// Progress is an optional progress reporter.
 	Progress ProgressReporter
 }
@@ -51,12 +68,21 @@ type UpgradeResult struct {
 
 	// Messages contains summary messages from the operation.
 	Messages []ProgressMessage
+
+	// Meta contains execution details such as queue wait time.
+	Meta OperationMeta
 }
 
 // InstallOptions provides options for Install operations.
 type InstallOptions struct {
 	// Progress is an optional progress reporter.
 	Progress ProgressReporter
+
+	// ContinueOnError runs the operation one package at a time and carries
+	// on past failures instead of stopping at the first. The result lists
+	// the packages that failed in Failed, and the returned error joins a
+	// *PackageError for each of them.
+	ContinueOnError bool
 }
 
 // InstallResult is the result of an Install operation.
@@ -67,14 +93,31 @@ type InstallResult struct {
 	// PackagesInstalled lists packages that were installed.
 	PackagesInstalled []PackageRef
 
+	// Skipped holds an *AlreadyInstalledError for each requested package
+	// that was already installed and therefore left alone.
+	Skipped []error
+
+	// Failed lists packages that could not be installed. It is only
+	// filled in with ContinueOnError.
+	Failed []PackageRef
+
 	// Messages contains summary messages from the operation.
 	Messages []ProgressMessage
+
+	// Meta contains execution details such as queue wait time.
+	Meta OperationMeta
 }
 
 // UninstallOptions provides options for Uninstall operations.
 type UninstallOptions struct {
 	// Progress is an optional progress reporter.
 	Progress ProgressReporter
+
+	// ContinueOnError runs the operation one package at a time and carries
+	// on past failures instead of stopping at the first. The result lists
+	// the packages that failed in Failed, and the returned error joins a
+	// *PackageError for each of them.
+	ContinueOnError bool
 }
 
 // UninstallResult is the result of an Uninstall operation.
@@ -85,18 +128,119 @@ type UninstallResult struct {
 	// PackagesUninstalled lists packages that were uninstalled.
 	PackagesUninstalled []PackageRef
 
+	// Skipped holds a *NotInstalledError for each requested package that
+	// was not installed and therefore left alone.
+	Skipped []error
+
+	// Failed lists packages that could not be uninstalled. It is only
+	// filled in with ContinueOnError.
+	Failed []PackageRef
+
 	// Messages contains summary messages from the operation.
 	Messages []ProgressMessage
+
+	// Meta contains execution details such as queue wait time.
+	Meta OperationMeta
 }
 
 // SearchOptions provides options for Search operations.
 type SearchOptions struct {
 	// Progress is an optional progress reporter.
 	Progress ProgressReporter
+
+	// Exact returns only the package named exactly query, for lookups of a
+	// known name. Backends answer it with a lighter request where they can;
+	// brew fetches the single formula instead of the full formula list.
+	Exact bool
+
+	// Limit caps the number of results; 0 means no limit. MultiManager
+	// applies it across backends and cancels the searches still running
+	// once Limit results have arrived.
+	Limit int
+
+	// IncludeDescriptions also matches the query against what packages
+	// do, not just their names: brew formula descriptions, flatpak
+	// appstream summaries and keywords, and snap summaries. By default
+	// only packages whose name matches are returned.
+	IncludeDescriptions bool
+
+	// Unranked returns results in the order the backend produced them.
+	// By default results are ranked by relevance to the query (exact name
+	// matches, then prefix, substring and fuzzy matches, with popular
+	// packages first within each) before Limit is applied, and backends
+	// that match names themselves, like brew, also return names within a
+	// few typos of the query. Streamed results (see SearchSeq) arrive in
+	// the order they are found either way.
+	Unranked bool
 }
 
 // ListOptions provides options for ListInstalled operations.
 type ListOptions struct {
 	// Progress is an optional progress reporter.
 	Progress ProgressReporter
+
+	// SortBy orders the listing; empty keeps the order the backend lists
+	// packages in. Packages that tie, or lack the field, are ordered by
+	// name, and those lacking it come last.
+	SortBy ListSort
+
+	// Descending reverses the order SortBy gives.
+	Descending bool
+}
+
+// ListSort is a field ListInstalled can sort by.
+type ListSort string
+
+const (
+	// SortByName sorts by package name, ignoring case.
+	SortByName ListSort = "name"
+
+	// SortByVersion sorts by version, as version.Compare orders them.
+	SortByVersion ListSort = "version"
+
+	// SortBySize sorts by InstalledPackage.Size, smallest first.
+	SortBySize ListSort = "size"
+
+	// SortByInstallDate sorts by InstalledPackage.InstalledAt, oldest first.
+	SortByInstallDate ListSort = "install-date"
+)
+
+// QueryOptions provides options for Query operations.
+type QueryOptions struct {
+	// Progress is an optional progress reporter.
+	Progress ProgressReporter
+}
+
+// OutdatedOptions provides options for ListOutdated operations.
+type OutdatedOptions struct {
+	// Progress is an optional progress reporter.
+	Progress ProgressReporter
+}
+
+// VerifyOptions provides options for Verify operations.
+type VerifyOptions struct {
+	// Progress is an optional progress reporter.
+	Progress ProgressReporter
+}
+
+// LaunchOptions provides options for Launch operations.
+type LaunchOptions struct {
+	// Args are passed to the application.
+	Args []string
+
+	// Progress is an optional progress reporter.
+	Progress ProgressReporter
+}
+
+// IconOptions provides options for Icon operations.
+type IconOptions struct {
+	// Size is the preferred icon size in pixels; zero means
+	// DefaultIconSize. Backends whose icons come in one size ignore it.
+	Size int
+
+	// Refresh fetches the icon again even if the cache holds it.
+	Refresh bool
+
+	// Progress is an optional progress reporter.
+	Progress ProgressReporter
 }
@@ -0,0 +1,43 @@
+package pm
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// internalOutdatedLister is implemented by backends that can list pending
+// updates without applying them.
+type internalOutdatedLister interface {
+	ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error)
+}
+
+// ListOutdated implements OutdatedLister. It fails with NotSupportedError
+// if the backend cannot list pending updates.
+func (a *backendAdapter) ListOutdated(ctx context.Context, opts OutdatedOptions) ([]OutdatedPackage, error) {
+	lister, ok := a.backend.(internalOutdatedLister)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationListOutdated, Backend: string(a.kind)}
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalRes, err := lister.ListOutdated(ctx, types.OutdatedOptions{Progress: pr})
+	if err != nil {
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	result := make([]OutdatedPackage, len(internalRes))
+	for i, p := range internalRes {
+		result[i] = OutdatedPackage{
+			Ref:              convertPackageRef(p.Ref),
+			InstalledVersion: p.InstalledVersion,
+			AvailableVersion: p.AvailableVersion,
+			Security:         p.Security,
+		}
+	}
+	summary.finish(nil, 0)
+	return result, nil
+}
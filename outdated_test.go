@@ -0,0 +1,61 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// outdatedBackend reports fixed pending updates, or fails with err.
+type outdatedBackend struct {
+	countingBackend
+	outdated []types.OutdatedPackage
+	err      error
+}
+
+func (b *outdatedBackend) ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error) {
+	return b.outdated, b.err
+}
+
+func TestBackendAdapter_ListOutdated(t *testing.T) {
+	backend := &outdatedBackend{outdated: []types.OutdatedPackage{
+		{Ref: types.PackageRef{Name: "jq", Kind: "formula"}, InstalledVersion: "1.6", AvailableVersion: "1.7.1"},
+	}}
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, &backendConfig{}))
+
+	got, err := mgr.ListOutdated(context.Background(), OutdatedOptions{})
+	if err != nil {
+		t.Fatalf("ListOutdated() error = %v", err)
+	}
+	want := OutdatedPackage{Ref: PackageRef{Name: "jq", Kind: "formula"}, InstalledVersion: "1.6", AvailableVersion: "1.7.1"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ListOutdated() = %+v, want [%+v]", got, want)
+	}
+
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, &backendConfig{})
+	if _, err := adapter.ListOutdated(context.Background(), OutdatedOptions{}); !IsNotSupported(err) {
+		t.Errorf("ListOutdated() error = %v, want NotSupportedError", err)
+	}
+}
+
+func TestMultiManager_ListOutdated(t *testing.T) {
+	cfg := &backendConfig{}
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: newBackendAdapter(BackendBrew, &outdatedBackend{outdated: []types.OutdatedPackage{
+			{Ref: types.PackageRef{Name: "jq"}, InstalledVersion: "1.6", AvailableVersion: "1.7.1"},
+		}}, cfg)},
+		Backend{Kind: BackendSnap, Manager: newBackendAdapter(BackendSnap, &outdatedBackend{
+			err: &types.ExternalFailureError{Operation: types.OperationListOutdated, Backend: "snap", Err: errors.New("snapd API returned status 500")},
+		}, cfg)},
+	)
+
+	results, err := m.ListOutdated(context.Background(), OutdatedOptions{})
+	if !IsExternalFailure(err) {
+		t.Errorf("ListOutdated() error = %v, want the snap failure", err)
+	}
+	if len(results) != 1 || len(results[BackendBrew]) != 1 || results[BackendBrew][0].Ref.Name != "jq" {
+		t.Errorf("ListOutdated() = %+v, want jq for brew only", results)
+	}
+}
@@ -0,0 +1,106 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Parallel runs fn against every backend concurrently, using at most workers
+// goroutines at a time (workers <= 0 means one per backend).
+//
+// Results are keyed by backend kind. As with MultiManager, a failing backend
+// does not stop the others: its error is annotated with the backend kind and
+// joined into the returned error, and it has no entry in the result map.
+//
+// fn receives a context derived from ctx. If ctx is cancelled, backends that
+// have not started yet are skipped and ctx.Err() is included in the returned
+// error; backends already running observe the cancellation through their context.
+func Parallel[T any](ctx context.Context, backends []Backend, workers int, fn func(ctx context.Context, b Backend) (T, error)) (map[BackendKind]T, error) {
+	if workers <= 0 || workers > len(backends) {
+		workers = len(backends)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[BackendKind]T, len(backends))
+		errs    []error
+	)
+	sem := make(chan struct{}, workers)
+
+schedule:
+	for _, b := range backends {
+		// Check first: select picks randomly when both cases are ready.
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break schedule
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(b Backend) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := fn(ctx, b)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, backendErr(b.Kind, err))
+				return
+			}
+			results[b.Kind] = res
+		}(b)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// ParallelSearch searches every backend that implements Searcher concurrently.
+func ParallelSearch(ctx context.Context, backends []Backend, workers int, query string, opts SearchOptions) (map[BackendKind][]PackageRef, error) {
+	return Parallel(ctx, only[Searcher](backends), workers, func(ctx context.Context, b Backend) ([]PackageRef, error) {
+		return b.Manager.(Searcher).Search(ctx, query, opts)
+	})
+}
+
+// ParallelListInstalled lists installed packages on every backend that
+// implements Lister concurrently.
+func ParallelListInstalled(ctx context.Context, backends []Backend, workers int, opts ListOptions) (map[BackendKind][]InstalledPackage, error) {
+	return Parallel(ctx, only[Lister](backends), workers, func(ctx context.Context, b Backend) ([]InstalledPackage, error) {
+		return b.Manager.(Lister).ListInstalled(ctx, opts)
+	})
+}
+
+// ParallelUpdate refreshes metadata on every backend that implements Updater concurrently.
+func ParallelUpdate(ctx context.Context, backends []Backend, workers int, opts UpdateOptions) (map[BackendKind]UpdateResult, error) {
+	return Parallel(ctx, only[Updater](backends), workers, func(ctx context.Context, b Backend) (UpdateResult, error) {
+		return b.Manager.(Updater).Update(ctx, opts)
+	})
+}
+
+// ParallelUpgrade upgrades packages on every backend that implements Upgrader concurrently.
+func ParallelUpgrade(ctx context.Context, backends []Backend, workers int, opts UpgradeOptions) (map[BackendKind]UpgradeResult, error) {
+	return Parallel(ctx, only[Upgrader](backends), workers, func(ctx context.Context, b Backend) (UpgradeResult, error) {
+		return b.Manager.(Upgrader).Upgrade(ctx, opts)
+	})
+}
+
+// only returns the backends whose manager implements interface I.
+func only[I any](backends []Backend) []Backend {
+	var out []Backend
+	for _, b := range backends {
+		if _, ok := b.Manager.(I); ok {
+			out = append(out, b)
+		}
+	}
+	return out
+}
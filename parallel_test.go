@@ -0,0 +1,105 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testBackends(n int) []Backend {
+	kinds := []BackendKind{"a", "b", "c", "d", "e", "f"}
+	backends := make([]Backend, n)
+	for i := range backends {
+		backends[i] = Backend{Kind: kinds[i], Manager: &fakeManager{}}
+	}
+	return backends
+}
+
+func TestParallel_BoundsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	results, err := Parallel(context.Background(), testBackends(6), 2, func(ctx context.Context, b Backend) (string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return string(b.Kind), nil
+	})
+	if err != nil {
+		t.Fatalf("Parallel() error = %v", err)
+	}
+	if len(results) != 6 {
+		t.Errorf("Expected 6 results, got %d", len(results))
+	}
+	if results["c"] != "c" {
+		t.Errorf("Results not keyed by backend: %v", results)
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent workers, saw %d", peak)
+	}
+}
+
+func TestParallel_AggregatesErrors(t *testing.T) {
+	failure := errors.New("boom")
+	results, err := Parallel(context.Background(), testBackends(3), 0, func(ctx context.Context, b Backend) (int, error) {
+		if b.Kind == "b" {
+			return 0, failure
+		}
+		return 1, nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected joined error to wrap failure, got %v", err)
+	}
+	if _, ok := results["b"]; ok {
+		t.Error("Failed backend should not have a result")
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 results, got %d", len(results))
+	}
+}
+
+func TestParallel_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls int32
+	_, err := Parallel(ctx, testBackends(4), 1, func(ctx context.Context, b Backend) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected no work after cancellation, got %d calls", calls)
+	}
+}
+
+func TestParallelSearch_SkipsNonSearchers(t *testing.T) {
+	backends := []Backend{
+		{Kind: BackendFlatpak, Manager: &fakeManager{search: []PackageRef{{Name: "org.gimp.GIMP"}}}},
+		{Kind: BackendSnap, Manager: managerOnly{}},
+	}
+
+	results, err := ParallelSearch(context.Background(), backends, 0, "gimp", SearchOptions{})
+	if err != nil {
+		t.Fatalf("ParallelSearch() error = %v", err)
+	}
+	if len(results) != 1 || len(results[BackendFlatpak]) != 1 {
+		t.Errorf("Unexpected results %v", results)
+	}
+}
+
+// managerOnly implements Manager and none of the optional interfaces.
+type managerOnly struct{}
+
+func (managerOnly) Name() string                                           { return "managerOnly" }
+func (managerOnly) Kind() BackendKind                                      { return "" }
+func (managerOnly) Available(ctx context.Context) (bool, error)            { return true, nil }
+func (managerOnly) Capabilities(ctx context.Context) ([]Capability, error) { return nil, nil }
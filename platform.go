@@ -0,0 +1,303 @@
+package pm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// PlatformInfo describes the system pm is running on.
+type PlatformInfo struct {
+	// OS and Arch are runtime.GOOS and runtime.GOARCH, such as "linux"
+	// and "amd64".
+	OS   string
+	Arch string
+
+	// Distro is the ID from /etc/os-release, such as "ubuntu" or "fedora",
+	// and DistroLike its ID_LIKE list, such as ["debian"]. Both are empty
+	// off Linux.
+	Distro     string
+	DistroLike []string
+
+	// DistroVersion is the VERSION_ID from /etc/os-release, such as
+	// "24.04".
+	DistroVersion string
+
+	// DistroName is the PRETTY_NAME from /etc/os-release, such as
+	// "Ubuntu 24.04 LTS", for display.
+	DistroName string
+
+	// WSL reports that this is Linux running under the Windows Subsystem
+	// for Linux.
+	WSL bool
+}
+
+// Platform returns the system pm is running on. It is detected once per
+// process.
+func Platform() PlatformInfo {
+	return currentPlatform()
+}
+
+// currentPlatform is replaced in tests.
+var currentPlatform = sync.OnceValue(func() PlatformInfo {
+	return detectPlatform(runtime.GOOS, runtime.GOARCH, os.ReadFile, os.Getenv)
+})
+
+// detectPlatform builds the PlatformInfo for goos and goarch, reading
+// system files and environment variables with the given functions.
+func detectPlatform(goos, goarch string, readFile func(string) ([]byte, error), getenv func(string) string) PlatformInfo {
+	p := PlatformInfo{OS: goos, Arch: goarch}
+	if goos != "linux" {
+		return p
+	}
+	data, err := readFile("/etc/os-release")
+	if err != nil {
+		data, _ = readFile("/usr/lib/os-release")
+	}
+	release := parseOSRelease(data)
+	p.Distro = release["ID"]
+	p.DistroLike = strings.Fields(release["ID_LIKE"])
+	p.DistroVersion = release["VERSION_ID"]
+	p.DistroName = release["PRETTY_NAME"]
+
+	// WSL sets WSL_DISTRO_NAME, and its kernels name Microsoft in their
+	// release string
+	kernel, _ := readFile("/proc/sys/kernel/osrelease")
+	p.WSL = getenv("WSL_DISTRO_NAME") != "" || bytes.Contains(bytes.ToLower(kernel), []byte("microsoft"))
+	return p
+}
+
+// parseOSRelease parses the KEY=value lines of os-release(5), unquoting
+// values.
+func parseOSRelease(data []byte) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// is reports whether the distribution is id or derives from it.
+func (p PlatformInfo) is(id string) bool {
+	return p.Distro == id || slices.Contains(p.DistroLike, id)
+}
+
+// unsupported returns why kind's tool cannot run on this platform, or "" if
+// it can.
+func (p PlatformInfo) unsupported(kind BackendKind) string {
+	switch kind {
+	case BackendBrew:
+		if p.OS != "darwin" && p.OS != "linux" {
+			return "Homebrew runs only on macOS and Linux, not " + p.OS
+		}
+	case BackendFlatpak, BackendSnap:
+		if p.OS != "linux" {
+			return string(kind) + " runs only on Linux, not " + p.OS
+		}
+	}
+	return ""
+}
+
+// installHint suggests how to install kind's tool on this platform.
+func (p PlatformInfo) installHint(kind BackendKind) string {
+	if kind == BackendBrew {
+		return "install it from https://brew.sh or set its path with WithBrewPath"
+	}
+	pkg := string(kind)
+	if kind == BackendSnap {
+		pkg = "snapd"
+	}
+	hint := "install " + pkg + " with the distribution's package manager"
+	switch {
+	case p.is("debian") || p.is("ubuntu"):
+		hint = "install it with `sudo apt install " + pkg + "`"
+	case p.is("fedora") || p.is("rhel"):
+		hint = "install it with `sudo dnf install " + pkg + "`"
+	case p.is("arch") && kind != BackendSnap: // snapd is only in the AUR
+		hint = "install it with `sudo pacman -S " + pkg + "`"
+	case p.is("suse"):
+		hint = "install it with `sudo zypper install " + pkg + "`"
+	}
+	if kind == BackendSnap && p.WSL {
+		hint += "; under WSL, snapd also needs systemd to be enabled"
+	}
+	return hint
+}
+
+// gateReason returns why kind's backend cannot run here: its tool does not
+// run on this platform, or it is not installed. It returns "" if it can,
+// and also for custom runners, which may run commands elsewhere, and
+// simulation, which runs none that change the system. An explicit binary
+// path is trusted.
+func (c *backendConfig) gateReason(kind BackendKind) string {
+	if c.runners[kind] != nil || c.simulate {
+		return ""
+	}
+	p := currentPlatform()
+	if reason := p.unsupported(kind); reason != "" {
+		return reason
+	}
+	if _, ok := c.binaryPaths[kind]; ok {
+		return ""
+	}
+	if _, err := lookPath(string(kind)); err == nil || discoverBinary(kind) != "" {
+		return ""
+	}
+	return string(kind) + " is not installed (not on PATH or in its usual locations); " + p.installHint(kind)
+}
+
+// apiOperations are the operations a backend answers from a web or local
+// API, without its command-line tool. Available checks the same API.
+var apiOperations = map[BackendKind][]types.Operation{
+	BackendBrew: {types.OperationSearch},
+	BackendSnap: {types.OperationListOutdated, types.OperationQuery, types.OperationVerify, types.OperationIcon},
+}
+
+// gatedBackend stands in for a backend that cannot run here. Operations
+// fail with a NotAvailableError giving the reason, before anything is run,
+// except those the backend answers from an API when only its tool is
+// missing.
+type gatedBackend struct {
+	internalBackend
+	kind   BackendKind
+	reason string
+	api    []types.Operation
+}
+
+// gate wraps backend in a gatedBackend if kind cannot run here.
+func (c *backendConfig) gate(kind BackendKind, backend internalBackend) internalBackend {
+	reason := c.gateReason(kind)
+	if reason == "" {
+		return backend
+	}
+	g := &gatedBackend{internalBackend: backend, kind: kind, reason: reason}
+	if currentPlatform().unsupported(kind) == "" {
+		g.api = apiOperations[kind]
+	}
+	return g
+}
+
+func (g *gatedBackend) err() error {
+	return &types.NotAvailableError{Backend: string(g.kind), Reason: g.reason}
+}
+
+func (g *gatedBackend) Available(ctx context.Context) (bool, error) {
+	if len(g.api) > 0 {
+		return g.internalBackend.Available(ctx)
+	}
+	return false, g.err()
+}
+
+// Capabilities reports every operation unsupported, with the reason in
+// Notes, except those answered from an API.
+func (g *gatedBackend) Capabilities(ctx context.Context) ([]types.Capability, error) {
+	ops := []types.Operation{
+		types.OperationSearch,
+		types.OperationUpdateMetadata,
+		types.OperationUpgradePackages,
+		types.OperationInstall,
+		types.OperationUninstall,
+		types.OperationListInstalled,
+		types.OperationListOutdated,
+		types.OperationQuery,
+		types.OperationVerify,
+		types.OperationLaunch,
+		types.OperationIcon,
+		types.OperationWatch,
+	}
+	caps := make([]types.Capability, len(ops))
+	for i, op := range ops {
+		caps[i] = types.Capability{Operation: op, Notes: g.reason}
+		if slices.Contains(g.api, op) {
+			caps[i] = types.Capability{Operation: op, Supported: true, Notes: "via API; " + g.reason}
+		}
+	}
+	return caps, nil
+}
+
+func (g *gatedBackend) Update(ctx context.Context, opts types.UpdateOptions) (types.UpdateResult, error) {
+	return types.UpdateResult{}, g.err()
+}
+
+func (g *gatedBackend) Upgrade(ctx context.Context, opts types.UpgradeOptions) (types.UpgradeResult, error) {
+	return types.UpgradeResult{}, g.err()
+}
+
+func (g *gatedBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	return types.InstallResult{}, g.err()
+}
+
+func (g *gatedBackend) Uninstall(ctx context.Context, pkgs []types.PackageRef, opts types.UninstallOptions) (types.UninstallResult, error) {
+	return types.UninstallResult{}, g.err()
+}
+
+func (g *gatedBackend) Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error) {
+	if slices.Contains(g.api, types.OperationSearch) {
+		return g.internalBackend.Search(ctx, query, opts)
+	}
+	return nil, g.err()
+}
+
+func (g *gatedBackend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
+	return nil, g.err()
+}
+
+// ListOutdated implements internalOutdatedLister.
+func (g *gatedBackend) ListOutdated(ctx context.Context, opts types.OutdatedOptions) ([]types.OutdatedPackage, error) {
+	if lister, ok := g.internalBackend.(internalOutdatedLister); ok && slices.Contains(g.api, types.OperationListOutdated) {
+		return lister.ListOutdated(ctx, opts)
+	}
+	return nil, g.err()
+}
+
+// Query implements internalQuerier.
+func (g *gatedBackend) Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error) {
+	if querier, ok := g.internalBackend.(internalQuerier); ok && slices.Contains(g.api, types.OperationQuery) {
+		return querier.Query(ctx, pkgs, opts)
+	}
+	return nil, g.err()
+}
+
+// Verify implements internalVerifier.
+func (g *gatedBackend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	if verifier, ok := g.internalBackend.(internalVerifier); ok && slices.Contains(g.api, types.OperationVerify) {
+		return verifier.Verify(ctx, pkgs, opts)
+	}
+	return nil, g.err()
+}
+
+// LaunchCommand implements internalLauncher.
+func (g *gatedBackend) LaunchCommand(ctx context.Context, pkg types.PackageRef, opts types.LaunchOptions) (types.LaunchCommand, error) {
+	return types.LaunchCommand{}, g.err()
+}
+
+// IconSource implements internalIconFetcher.
+func (g *gatedBackend) IconSource(ctx context.Context, pkg types.PackageRef, opts types.IconOptions) (types.IconSource, error) {
+	if fetcher, ok := g.internalBackend.(internalIconFetcher); ok && slices.Contains(g.api, types.OperationIcon) {
+		return fetcher.IconSource(ctx, pkg, opts)
+	}
+	return types.IconSource{}, g.err()
+}
+
+// Popularity implements internalPopularity, for ranking API search results.
+func (g *gatedBackend) Popularity(ctx context.Context) (map[string]int, error) {
+	if p, ok := g.internalBackend.(internalPopularity); ok && slices.Contains(g.api, types.OperationSearch) {
+		return p.Popularity(ctx)
+	}
+	return nil, g.err()
+}
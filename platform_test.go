@@ -0,0 +1,108 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	files := map[string]string{
+		"/etc/os-release":            "PRETTY_NAME=\"Ubuntu 24.04 LTS\"\nID=ubuntu\nID_LIKE=debian\nVERSION_ID=\"24.04\"\n",
+		"/proc/sys/kernel/osrelease": "5.15.153.1-microsoft-standard-WSL2\n",
+	}
+	readFile := func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return []byte(data), nil
+		}
+		return nil, fs.ErrNotExist
+	}
+	getenv := func(string) string { return "" }
+
+	p := detectPlatform("linux", "amd64", readFile, getenv)
+	if p.Distro != "ubuntu" || p.DistroVersion != "24.04" || p.DistroName != "Ubuntu 24.04 LTS" || !p.is("debian") || !p.WSL {
+		t.Errorf("detectPlatform(linux) = %+v", p)
+	}
+
+	delete(files, "/proc/sys/kernel/osrelease")
+	if p := detectPlatform("linux", "arm64", readFile, getenv); p.WSL {
+		t.Error("Detected WSL on a native kernel")
+	}
+
+	if p := detectPlatform("darwin", "arm64", readFile, getenv); p.Distro != "" || p.OS != "darwin" || p.Arch != "arm64" {
+		t.Errorf("detectPlatform(darwin) = %+v, want no distribution", p)
+	}
+}
+
+// withPlatform makes the constructors see p and find only the tools in
+// installed until the test ends.
+func withPlatform(t *testing.T, p PlatformInfo, installed ...string) {
+	oldPlatform, oldLookPath, oldFallbacks := currentPlatform, lookPath, binaryFallbacks
+	t.Cleanup(func() { currentPlatform, lookPath, binaryFallbacks = oldPlatform, oldLookPath, oldFallbacks })
+	currentPlatform = func() PlatformInfo { return p }
+	lookPath = func(name string) (string, error) {
+		for _, tool := range installed {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+	binaryFallbacks = nil
+}
+
+func TestGate_UnsupportedOS(t *testing.T) {
+	withPlatform(t, PlatformInfo{OS: "darwin", Arch: "arm64"}, "snap")
+
+	mgr := NewSnap(WithoutOperationLock())
+	ok, err := mgr.Available(context.Background())
+	if ok || !IsNotAvailable(err) || !strings.Contains(err.Error(), "only on Linux") {
+		t.Errorf("Available() = %v, %v; want NotAvailable on macOS", ok, err)
+	}
+	_, err = Must[Installer](mgr).Install(context.Background(), []PackageRef{{Name: "hello-world"}}, InstallOptions{})
+	if !IsNotAvailable(err) {
+		t.Errorf("Install() error = %v, want NotAvailable", err)
+	}
+
+	// A custom runner may run commands on another system
+	mgr = NewSnap(WithRunner(RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "", nil
+	})), WithoutOperationLock())
+	if _, err := Must[Installer](mgr).Install(context.Background(), []PackageRef{{Name: "hello-world"}}, InstallOptions{}); IsNotAvailable(err) {
+		t.Errorf("Install() with a custom runner error = %v, want it to run", err)
+	}
+}
+
+func TestGate_ToolNotInstalled(t *testing.T) {
+	withPlatform(t, PlatformInfo{OS: "linux", Arch: "amd64", Distro: "fedora"})
+
+	mgr := NewFlatpak(WithoutOperationLock())
+	_, err := mgr.Available(context.Background())
+	if !IsNotAvailable(err) || !strings.Contains(err.Error(), "sudo dnf install flatpak") {
+		t.Errorf("Available() error = %v, want NotAvailable with an install hint", err)
+	}
+
+	// brew still searches the Formulae API without brew installed
+	mgr = NewBrew(WithFormulaeBaseURL("http://127.0.0.1:1"), WithoutOperationLock())
+	caps, err := mgr.Capabilities(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range caps {
+		if c.Supported != (c.Operation == OperationSearch) {
+			t.Errorf("Capability %s supported = %v (%s)", c.Operation, c.Supported, c.Notes)
+		}
+	}
+	_, err = Must[Lister](mgr).ListInstalled(context.Background(), ListOptions{})
+	if !IsNotAvailable(err) || !strings.Contains(err.Error(), "https://brew.sh") {
+		t.Errorf("ListInstalled() error = %v, want NotAvailable with an install hint", err)
+	}
+
+	// An explicit path is trusted
+	mgr = NewFlatpak(WithFlatpakPath("/opt/flatpak/bin/flatpak"), WithoutOperationLock())
+	if _, err := mgr.Available(context.Background()); err != nil && strings.Contains(err.Error(), "not installed") {
+		t.Errorf("Available() with a binary path error = %v", err)
+	}
+}
@@ -0,0 +1,493 @@
+// Package pmtest provides a scriptable fake pm backend, so applications
+// using pm can unit-test their flows without real package managers.
+//
+// A FakeManager behaves like a small package manager: it has a catalog of
+// packages that can be searched and installed, a set of installed packages,
+// and a list of pending upgrades. Individual methods can be replaced with
+// the *Func fields, made to fail with Fail, and every call is recorded:
+//
+//	fake := pmtest.NewFakeManager()
+//	fake.Catalog = []pm.PackageRef{{Name: "jq"}, {Name: "ripgrep"}}
+//	fake.Fail("Uninstall", errors.New("boom"))
+//
+//	runMyFlow(ctx, fake)
+//
+//	if calls := fake.CallsTo("Install"); len(calls) != 1 {
+//		t.Errorf("expected one install, got %v", calls)
+//	}
+package pmtest
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/frostyard/pm"
+)
+
+// Compile-time interface checks.
+var (
+	_ pm.Manager        = (*FakeManager)(nil)
+	_ pm.Updater        = (*FakeManager)(nil)
+	_ pm.Upgrader       = (*FakeManager)(nil)
+	_ pm.Installer      = (*FakeManager)(nil)
+	_ pm.Uninstaller    = (*FakeManager)(nil)
+	_ pm.Searcher       = (*FakeManager)(nil)
+	_ pm.Lister         = (*FakeManager)(nil)
+	_ pm.OutdatedLister = (*FakeManager)(nil)
+	_ pm.Querier        = (*FakeManager)(nil)
+	_ pm.Verifier       = (*FakeManager)(nil)
+	_ pm.Launcher       = (*FakeManager)(nil)
+	_ pm.IconFetcher    = (*FakeManager)(nil)
+	_ pm.Watcher        = (*FakeManager)(nil)
+)
+
+// Call records one method call on a FakeManager.
+type Call struct {
+	// Method is the name of the method, e.g. "Install".
+	Method string
+
+	// Packages are the packages passed to Install, Uninstall, Query or
+	// Verify, or in UpgradeOptions, or the one passed to Launch or Icon.
+	Packages []pm.PackageRef
+
+	// Query is the query passed to Search.
+	Query string
+
+	// Options is the options value passed to the method, e.g. an
+	// pm.InstallOptions; nil for Available, Capabilities and Watch.
+	Options any
+}
+
+// FakeManager is an in-memory pm backend implementing every pm interface.
+// Set its fields before use; afterwards, use its methods, which are safe for
+// concurrent use.
+type FakeManager struct {
+	// BackendKind is returned by Kind and Name and reported in typed
+	// errors. Defaults to "fake".
+	BackendKind pm.BackendKind
+
+	// Unavailable makes Available report false.
+	Unavailable bool
+
+	// Caps is returned by Capabilities. Nil means every operation is
+	// supported.
+	Caps []pm.Capability
+
+	// Catalog lists the packages Search finds and Install accepts. If it is
+	// empty, Install accepts any package.
+	Catalog []pm.PackageRef
+
+	// Installed lists the installed packages. Install adds to it and
+	// Uninstall removes from it.
+	Installed []pm.InstalledPackage
+
+	// Upgrades lists installed packages with a newer version available.
+	// ListOutdated reports them, and Upgrade reports and removes those it
+	// upgrades.
+	Upgrades []pm.PackageRef
+
+	// MetadataStale makes the next Update report Changed.
+	MetadataStale bool
+
+	// The *Func fields, if set, replace the default behaviour of the
+	// corresponding method. Calls are still recorded and injected errors
+	// still take precedence.
+	AvailableFunc     func(ctx context.Context) (bool, error)
+	CapabilitiesFunc  func(ctx context.Context) ([]pm.Capability, error)
+	UpdateFunc        func(ctx context.Context, opts pm.UpdateOptions) (pm.UpdateResult, error)
+	UpgradeFunc       func(ctx context.Context, opts pm.UpgradeOptions) (pm.UpgradeResult, error)
+	InstallFunc       func(ctx context.Context, pkgs []pm.PackageRef, opts pm.InstallOptions) (pm.InstallResult, error)
+	UninstallFunc     func(ctx context.Context, pkgs []pm.PackageRef, opts pm.UninstallOptions) (pm.UninstallResult, error)
+	SearchFunc        func(ctx context.Context, query string, opts pm.SearchOptions) ([]pm.PackageRef, error)
+	ListInstalledFunc func(ctx context.Context, opts pm.ListOptions) ([]pm.InstalledPackage, error)
+	ListOutdatedFunc  func(ctx context.Context, opts pm.OutdatedOptions) ([]pm.OutdatedPackage, error)
+	QueryFunc         func(ctx context.Context, pkgs []pm.PackageRef, opts pm.QueryOptions) ([]pm.InstalledPackage, error)
+	VerifyFunc        func(ctx context.Context, pkgs []pm.PackageRef, opts pm.VerifyOptions) ([]pm.Attestation, error)
+	LaunchFunc        func(ctx context.Context, ref pm.PackageRef, opts pm.LaunchOptions) (*pm.Process, error)
+	IconFunc          func(ctx context.Context, ref pm.PackageRef, opts pm.IconOptions) (string, error)
+	WatchFunc         func(ctx context.Context) (<-chan pm.PackageEvent, error)
+
+	mu     sync.Mutex
+	calls  []Call
+	errors map[string][]error
+}
+
+// NewFakeManager returns an available FakeManager with no packages.
+func NewFakeManager() *FakeManager {
+	return &FakeManager{}
+}
+
+// Fail makes the next calls to method fail with errs, one error per call in
+// order. Once they are used up, the method behaves normally again. A nil
+// error lets that call succeed.
+func (f *FakeManager) Fail(method string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errors == nil {
+		f.errors = make(map[string][]error)
+	}
+	f.errors[method] = append(f.errors[method], errs...)
+}
+
+// Calls returns all calls made so far, in order.
+func (f *FakeManager) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made so far to method, in order.
+func (f *FakeManager) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets recorded calls and pending injected errors.
+func (f *FakeManager) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+	f.errors = nil
+}
+
+// record records c and returns the error to fail it with, if any: an
+// injected error, or the context's error.
+func (f *FakeManager) record(ctx context.Context, c Call) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, c)
+	if errs := f.errors[c.Method]; len(errs) > 0 {
+		f.errors[c.Method] = errs[1:]
+		if errs[0] != nil {
+			return errs[0]
+		}
+	}
+	return ctx.Err()
+}
+
+func (f *FakeManager) backend() string {
+	return string(f.Kind())
+}
+
+// Name implements pm.Manager.
+func (f *FakeManager) Name() string {
+	return f.backend()
+}
+
+// Kind implements pm.Manager.
+func (f *FakeManager) Kind() pm.BackendKind {
+	if f.BackendKind == "" {
+		return "fake"
+	}
+	return f.BackendKind
+}
+
+// Available implements pm.Manager.
+func (f *FakeManager) Available(ctx context.Context) (bool, error) {
+	if err := f.record(ctx, Call{Method: "Available"}); err != nil {
+		return false, err
+	}
+	if f.AvailableFunc != nil {
+		return f.AvailableFunc(ctx)
+	}
+	return !f.Unavailable, nil
+}
+
+// Capabilities implements pm.Manager.
+func (f *FakeManager) Capabilities(ctx context.Context) ([]pm.Capability, error) {
+	if err := f.record(ctx, Call{Method: "Capabilities"}); err != nil {
+		return nil, err
+	}
+	if f.CapabilitiesFunc != nil {
+		return f.CapabilitiesFunc(ctx)
+	}
+	if f.Caps != nil {
+		return f.Caps, nil
+	}
+	var caps []pm.Capability
+	for _, op := range []pm.Operation{
+		pm.OperationUpdateMetadata,
+		pm.OperationUpgradePackages,
+		pm.OperationInstall,
+		pm.OperationUninstall,
+		pm.OperationSearch,
+		pm.OperationListInstalled,
+		pm.OperationListOutdated,
+		pm.OperationQuery,
+		pm.OperationVerify,
+		pm.OperationLaunch,
+		pm.OperationIcon,
+		pm.OperationWatch,
+	} {
+		caps = append(caps, pm.Capability{Operation: op, Supported: true})
+	}
+	return caps, nil
+}
+
+// Update implements pm.Updater. It reports Changed once after
+// MetadataStale is set.
+func (f *FakeManager) Update(ctx context.Context, opts pm.UpdateOptions) (pm.UpdateResult, error) {
+	if err := f.record(ctx, Call{Method: "Update", Options: opts}); err != nil {
+		return pm.UpdateResult{}, err
+	}
+	if f.UpdateFunc != nil {
+		return f.UpdateFunc(ctx, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changed := f.MetadataStale
+	f.MetadataStale = false
+	return pm.UpdateResult{Changed: changed}, nil
+}
+
+// Upgrade implements pm.Upgrader. It reports and clears Upgrades or, if
+// opts.Packages is set, those of them it names.
+func (f *FakeManager) Upgrade(ctx context.Context, opts pm.UpgradeOptions) (pm.UpgradeResult, error) {
+	if err := f.record(ctx, Call{Method: "Upgrade", Packages: opts.Packages, Options: opts}); err != nil {
+		return pm.UpgradeResult{}, err
+	}
+	if f.UpgradeFunc != nil {
+		return f.UpgradeFunc(ctx, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var upgraded, pending []pm.PackageRef
+	for _, ref := range f.Upgrades {
+		if len(opts.Packages) == 0 || indexOf(opts.Packages, ref.Name) >= 0 {
+			upgraded = append(upgraded, ref)
+		} else {
+			pending = append(pending, ref)
+		}
+	}
+	f.Upgrades = pending
+	return pm.UpgradeResult{Changed: len(upgraded) > 0, PackagesChanged: upgraded}, nil
+}
+
+// Install implements pm.Installer. Packages not in a non-empty Catalog
+// fail the call with a *pm.PackageNotFoundError; packages already installed
+// are reported in Skipped as a *pm.AlreadyInstalledError.
+func (f *FakeManager) Install(ctx context.Context, pkgs []pm.PackageRef, opts pm.InstallOptions) (pm.InstallResult, error) {
+	if err := f.record(ctx, Call{Method: "Install", Packages: pkgs, Options: opts}); err != nil {
+		return pm.InstallResult{}, err
+	}
+	if f.InstallFunc != nil {
+		return f.InstallFunc(ctx, pkgs, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, pkg := range pkgs {
+		if len(f.Catalog) > 0 && indexOf(f.Catalog, pkg.Name) < 0 {
+			return pm.InstallResult{}, &pm.PackageNotFoundError{Ref: pkg, Backend: f.backend()}
+		}
+	}
+	var res pm.InstallResult
+	for _, pkg := range pkgs {
+		if f.installedIndex(pkg.Name) >= 0 {
+			res.Skipped = append(res.Skipped, &pm.AlreadyInstalledError{Ref: pkg, Backend: f.backend()})
+			continue
+		}
+		f.Installed = append(f.Installed, pm.InstalledPackage{Ref: pkg, Status: "installed"})
+		res.PackagesInstalled = append(res.PackagesInstalled, pkg)
+	}
+	res.Changed = len(res.PackagesInstalled) > 0
+	return res, nil
+}
+
+// Uninstall implements pm.Uninstaller. Packages that are not installed are
+// reported in Skipped as a *pm.NotInstalledError.
+func (f *FakeManager) Uninstall(ctx context.Context, pkgs []pm.PackageRef, opts pm.UninstallOptions) (pm.UninstallResult, error) {
+	if err := f.record(ctx, Call{Method: "Uninstall", Packages: pkgs, Options: opts}); err != nil {
+		return pm.UninstallResult{}, err
+	}
+	if f.UninstallFunc != nil {
+		return f.UninstallFunc(ctx, pkgs, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res pm.UninstallResult
+	for _, pkg := range pkgs {
+		i := f.installedIndex(pkg.Name)
+		if i < 0 {
+			res.Skipped = append(res.Skipped, &pm.NotInstalledError{Operation: pm.OperationUninstall, Ref: pkg, Backend: f.backend()})
+			continue
+		}
+		f.Installed = append(f.Installed[:i:i], f.Installed[i+1:]...)
+		res.PackagesUninstalled = append(res.PackagesUninstalled, pkg)
+	}
+	res.Changed = len(res.PackagesUninstalled) > 0
+	return res, nil
+}
+
+// Search implements pm.Searcher. It returns the Catalog entries whose name
+// contains query, ignoring case.
+func (f *FakeManager) Search(ctx context.Context, query string, opts pm.SearchOptions) ([]pm.PackageRef, error) {
+	if err := f.record(ctx, Call{Method: "Search", Query: query, Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.SearchFunc != nil {
+		return f.SearchFunc(ctx, query, opts)
+	}
+	results := []pm.PackageRef{}
+	if query == "" {
+		return results, nil
+	}
+	for _, pkg := range f.Catalog {
+		if opts.Limit > 0 && len(results) == opts.Limit {
+			break
+		}
+		if strings.Contains(strings.ToLower(pkg.Name), strings.ToLower(query)) {
+			results = append(results, pkg)
+		}
+	}
+	return results, nil
+}
+
+// ListInstalled implements pm.Lister.
+func (f *FakeManager) ListInstalled(ctx context.Context, opts pm.ListOptions) ([]pm.InstalledPackage, error) {
+	if err := f.record(ctx, Call{Method: "ListInstalled", Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.ListInstalledFunc != nil {
+		return f.ListInstalledFunc(ctx, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	installed := append([]pm.InstalledPackage(nil), f.Installed...)
+	if err := pm.SortInstalled(installed, opts.SortBy, opts.Descending); err != nil {
+		return nil, err
+	}
+	return installed, nil
+}
+
+// ListOutdated implements pm.OutdatedLister. By default it reports
+// Upgrades, with the installed versions from Installed and no available
+// versions.
+func (f *FakeManager) ListOutdated(ctx context.Context, opts pm.OutdatedOptions) ([]pm.OutdatedPackage, error) {
+	if err := f.record(ctx, Call{Method: "ListOutdated", Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.ListOutdatedFunc != nil {
+		return f.ListOutdatedFunc(ctx, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	outdated := []pm.OutdatedPackage{}
+	for _, ref := range f.Upgrades {
+		pkg := pm.OutdatedPackage{Ref: ref}
+		if i := f.installedIndex(ref.Name); i >= 0 {
+			pkg.InstalledVersion = f.Installed[i].Version
+		}
+		outdated = append(outdated, pkg)
+	}
+	return outdated, nil
+}
+
+// Query implements pm.Querier.
+func (f *FakeManager) Query(ctx context.Context, pkgs []pm.PackageRef, opts pm.QueryOptions) ([]pm.InstalledPackage, error) {
+	if err := f.record(ctx, Call{Method: "Query", Packages: pkgs, Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.QueryFunc != nil {
+		return f.QueryFunc(ctx, pkgs, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	installed := []pm.InstalledPackage{}
+	for _, pkg := range pkgs {
+		if i := f.installedIndex(pkg.Name); i >= 0 {
+			installed = append(installed, f.Installed[i])
+		}
+	}
+	return installed, nil
+}
+
+// Verify implements pm.Verifier. By default installed packages are
+// attested as verified and the others as not found.
+func (f *FakeManager) Verify(ctx context.Context, pkgs []pm.PackageRef, opts pm.VerifyOptions) ([]pm.Attestation, error) {
+	if err := f.record(ctx, Call{Method: "Verify", Packages: pkgs, Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.VerifyFunc != nil {
+		return f.VerifyFunc(ctx, pkgs, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attestations := make([]pm.Attestation, len(pkgs))
+	for i, pkg := range pkgs {
+		attestations[i] = pm.Attestation{Ref: pkg, Status: pm.AttestationNotFound}
+		if j := f.installedIndex(pkg.Name); j >= 0 {
+			attestations[i] = pm.Attestation{Ref: pkg, Installed: true, Version: f.Installed[j].Version, Signed: true, Status: pm.AttestationVerified}
+		}
+	}
+	return attestations, nil
+}
+
+// Launch implements pm.Launcher. By default it starts nothing: it returns
+// a Process without a PID for an installed package, and a
+// *pm.NotInstalledError otherwise.
+func (f *FakeManager) Launch(ctx context.Context, ref pm.PackageRef, opts pm.LaunchOptions) (*pm.Process, error) {
+	if err := f.record(ctx, Call{Method: "Launch", Packages: []pm.PackageRef{ref}, Options: opts}); err != nil {
+		return nil, err
+	}
+	if f.LaunchFunc != nil {
+		return f.LaunchFunc(ctx, ref, opts)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.installedIndex(ref.Name) < 0 {
+		return nil, &pm.NotInstalledError{Operation: pm.OperationLaunch, Ref: ref, Backend: f.backend()}
+	}
+	return &pm.Process{Ref: ref}, nil
+}
+
+// Icon implements pm.IconFetcher. By default no package has an icon.
+func (f *FakeManager) Icon(ctx context.Context, ref pm.PackageRef, opts pm.IconOptions) (string, error) {
+	if err := f.record(ctx, Call{Method: "Icon", Packages: []pm.PackageRef{ref}, Options: opts}); err != nil {
+		return "", err
+	}
+	if f.IconFunc != nil {
+		return f.IconFunc(ctx, ref, opts)
+	}
+	return "", nil
+}
+
+// Watch implements pm.Watcher. By default nothing changes: the channel
+// receives no events and is closed once ctx is done.
+func (f *FakeManager) Watch(ctx context.Context) (<-chan pm.PackageEvent, error) {
+	if err := f.record(ctx, Call{Method: "Watch"}); err != nil {
+		return nil, err
+	}
+	if f.WatchFunc != nil {
+		return f.WatchFunc(ctx)
+	}
+	events := make(chan pm.PackageEvent)
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
+
+func (f *FakeManager) installedIndex(name string) int {
+	for i, p := range f.Installed {
+		if p.Ref.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOf(pkgs []pm.PackageRef, name string) int {
+	for i, p := range pkgs {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,135 @@
+package pmtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
+)
+
+func TestFakeManager_InstallUninstall(t *testing.T) {
+	fake := pmtest.NewFakeManager()
+	fake.BackendKind = pm.BackendBrew
+	fake.Catalog = []pm.PackageRef{{Name: "jq"}, {Name: "ripgrep"}}
+	fake.Installed = []pm.InstalledPackage{{Ref: pm.PackageRef{Name: "jq"}, Version: "1.7"}}
+	ctx := context.Background()
+
+	res, err := fake.Install(ctx, []pm.PackageRef{{Name: "jq"}, {Name: "ripgrep"}}, pm.InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !res.Changed || len(res.PackagesInstalled) != 1 || res.PackagesInstalled[0].Name != "ripgrep" {
+		t.Errorf("Unexpected install result %+v", res)
+	}
+	if len(res.Skipped) != 1 || !pm.IsAlreadyInstalled(res.Skipped[0]) {
+		t.Errorf("Expected jq to be skipped, got %v", res.Skipped)
+	}
+
+	var notFound *pm.PackageNotFoundError
+	if _, err := fake.Install(ctx, []pm.PackageRef{{Name: "nosuchthing"}}, pm.InstallOptions{}); !errors.As(err, &notFound) || notFound.Backend != "brew" {
+		t.Errorf("Expected PackageNotFoundError, got %v", err)
+	}
+
+	ures, err := fake.Uninstall(ctx, []pm.PackageRef{{Name: "jq"}, {Name: "wget"}}, pm.UninstallOptions{})
+	if err != nil || !ures.Changed || len(ures.PackagesUninstalled) != 1 || len(ures.Skipped) != 1 || !pm.IsNotInstalled(ures.Skipped[0]) {
+		t.Errorf("Unexpected uninstall result %+v, %v", ures, err)
+	}
+
+	installed, _ := fake.ListInstalled(ctx, pm.ListOptions{})
+	if len(installed) != 1 || installed[0].Ref.Name != "ripgrep" {
+		t.Errorf("Unexpected installed packages %+v", installed)
+	}
+
+	queried, err := fake.Query(ctx, []pm.PackageRef{{Name: "ripgrep"}, {Name: "jq"}}, pm.QueryOptions{})
+	if err != nil || len(queried) != 1 || queried[0].Ref.Name != "ripgrep" {
+		t.Errorf("Unexpected query result %+v, %v", queried, err)
+	}
+}
+
+func TestFakeManager_UpdateUpgradeSearch(t *testing.T) {
+	fake := pmtest.NewFakeManager()
+	fake.Catalog = []pm.PackageRef{{Name: "Firefox"}, {Name: "thunderbird"}}
+	fake.Upgrades = []pm.PackageRef{{Name: "thunderbird"}}
+	fake.MetadataStale = true
+	ctx := context.Background()
+
+	if res, _ := fake.Update(ctx, pm.UpdateOptions{}); !res.Changed {
+		t.Error("Expected the first Update to refresh metadata")
+	}
+	if res, _ := fake.Update(ctx, pm.UpdateOptions{}); res.Changed {
+		t.Error("Expected the second Update to be a no-op")
+	}
+	if res, _ := fake.Upgrade(ctx, pm.UpgradeOptions{}); !res.Changed || len(res.PackagesChanged) != 1 {
+		t.Errorf("Unexpected upgrade result %+v", res)
+	}
+	if res, _ := fake.Upgrade(ctx, pm.UpgradeOptions{}); res.Changed {
+		t.Error("Expected nothing left to upgrade")
+	}
+	if results, _ := fake.Search(ctx, "fire", pm.SearchOptions{}); len(results) != 1 || results[0].Name != "Firefox" {
+		t.Errorf("Unexpected search results %+v", results)
+	}
+	caps, _ := fake.Capabilities(ctx)
+	if len(caps) != 12 {
+		t.Errorf("Expected every operation to be supported, got %+v", caps)
+	}
+}
+
+func TestFakeManager_FailAndCalls(t *testing.T) {
+	fake := pmtest.NewFakeManager()
+	boom := errors.New("boom")
+	fake.Fail("Search", boom, nil)
+	ctx := context.Background()
+
+	if _, err := fake.Search(ctx, "jq", pm.SearchOptions{}); !errors.Is(err, boom) {
+		t.Errorf("Expected the injected error, got %v", err)
+	}
+	if _, err := fake.Search(ctx, "jq", pm.SearchOptions{}); err != nil {
+		t.Errorf("Expected the second call to succeed, got %v", err)
+	}
+	if _, err := fake.Search(ctx, "jq", pm.SearchOptions{}); err != nil {
+		t.Errorf("Expected injected errors to be used up, got %v", err)
+	}
+
+	calls := fake.CallsTo("Search")
+	if len(calls) != 3 || calls[0].Query != "jq" {
+		t.Errorf("Unexpected calls %+v", calls)
+	}
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Error("Expected Reset to forget calls")
+	}
+}
+
+func TestFakeManager_FuncOverrideAndCancellation(t *testing.T) {
+	fake := pmtest.NewFakeManager()
+	fake.InstallFunc = func(ctx context.Context, pkgs []pm.PackageRef, opts pm.InstallOptions) (pm.InstallResult, error) {
+		return pm.InstallResult{}, &pm.ConflictError{Operation: pm.OperationInstall, Backend: "fake"}
+	}
+	if _, err := fake.Install(context.Background(), []pm.PackageRef{{Name: "jq"}}, pm.InstallOptions{}); !pm.IsConflict(err) {
+		t.Errorf("Expected the override's error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fake.ListInstalled(ctx, pm.ListOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(fake.Calls()) != 2 {
+		t.Errorf("Expected both calls to be recorded, got %+v", fake.Calls())
+	}
+}
+
+func ExampleFakeManager() {
+	fake := pmtest.NewFakeManager()
+	fake.Catalog = []pm.PackageRef{{Name: "jq"}}
+
+	// The code under test only sees a pm.Installer.
+	var installer pm.Installer = fake
+	res, err := installer.Install(context.Background(), []pm.PackageRef{{Name: "jq"}}, pm.InstallOptions{})
+
+	fmt.Println(res.Changed, err, len(fake.CallsTo("Install")))
+	// Output: true <nil> 1
+}
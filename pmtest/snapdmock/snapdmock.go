@@ -0,0 +1,389 @@
+// Package snapdmock provides a fake snapd that serves the subset of the
+// snapd REST API pm uses over a Unix socket, so the snap backend can be
+// tested without snapd:
+//
+//	srv, err := snapdmock.NewServer(filepath.Join(t.TempDir(), "snapd.socket"))
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer srv.Close()
+//	srv.AddStore(snapdmock.Snap{Name: "hello-world", Version: "6.4"})
+//
+//	mgr := pm.NewSnap(pm.WithSnapdEndpoint(srv.Socket()), pm.WithRunner(fake))
+//
+// It implements GET /v2/system-info, GET /v2/find, GET /v2/snaps,
+// GET /v2/snaps/{name}, POST /v2/snaps/{name} (install, remove and refresh),
+// and GET /v2/changes/{id}. Actions are asynchronous as in snapd: they
+// return a change that progresses from "Do" through "Doing" to "Done" as it
+// is polled, and take effect when it is done.
+package snapdmock
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Snap describes a snap in the store or installed. It is encoded in
+// snapd's format, where the publisher is an object and an installed snap's
+// channel is also reported as its tracking channel.
+type Snap struct {
+	Name      string
+	Version   string
+	Revision  string
+	Channel   string
+	Publisher string
+	Summary   string
+}
+
+// snapJSON is Snap as snapd encodes it.
+type snapJSON struct {
+	Name            string         `json:"name"`
+	Version         string         `json:"version"`
+	Revision        string         `json:"revision,omitempty"`
+	Channel         string         `json:"channel,omitempty"`
+	TrackingChannel string         `json:"tracking-channel,omitempty"`
+	Publisher       *publisherJSON `json:"publisher,omitempty"`
+	Summary         string         `json:"summary,omitempty"`
+}
+
+type publisherJSON struct {
+	Username string `json:"username"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Snap) MarshalJSON() ([]byte, error) {
+	v := snapJSON{
+		Name:            s.Name,
+		Version:         s.Version,
+		Revision:        s.Revision,
+		Channel:         s.Channel,
+		TrackingChannel: s.Channel,
+		Summary:         s.Summary,
+	}
+	if s.Publisher != "" {
+		v.Publisher = &publisherJSON{Username: s.Publisher}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Snap) UnmarshalJSON(data []byte) error {
+	var v snapJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Snap{Name: v.Name, Version: v.Version, Revision: v.Revision, Channel: v.Channel, Summary: v.Summary}
+	if s.Channel == "" {
+		s.Channel = v.TrackingChannel
+	}
+	if v.Publisher != nil {
+		s.Publisher = v.Publisher.Username
+	}
+	return nil
+}
+
+// Change is an asynchronous snapd operation.
+type Change struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	Summary string `json:"summary"`
+	Status  string `json:"status"`
+	Ready   bool   `json:"ready"`
+	Err     string `json:"err,omitempty"`
+
+	snap   string
+	action string
+	polls  int
+	apply  func()
+}
+
+// Failure is an error response returned instead of handling a request.
+type Failure struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Kind is the snapd error kind, e.g. "snap-not-found".
+	Kind string
+
+	// Message is the error message.
+	Message string
+}
+
+// Server is a fake snapd listening on a Unix socket. Its methods are safe
+// for concurrent use.
+type Server struct {
+	// Version is reported by /v2/system-info. Defaults to "2.61".
+	Version string
+
+	// ChangeSteps is how many times a change is polled before it is done.
+	// Defaults to 2, so a change is seen as "Doing" once.
+	ChangeSteps int
+
+	socket string
+	http   *http.Server
+	done   chan struct{}
+
+	mu        sync.Mutex
+	store     []Snap
+	installed []Snap
+	changes   []*Change
+	failures  map[string][]Failure
+	requests  []string
+}
+
+// NewServer starts a fake snapd listening on the Unix socket at path.
+func NewServer(path string) (*Server, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Version:     "2.61",
+		ChangeSteps: 2,
+		socket:      path,
+		done:        make(chan struct{}),
+		failures:    make(map[string][]Failure),
+	}
+	s.http = &http.Server{Handler: s}
+	go func() {
+		defer close(s.done)
+		_ = s.http.Serve(l)
+	}()
+	return s, nil
+}
+
+// Socket returns the path of the server's socket, for pm.WithSnapdEndpoint.
+func (s *Server) Socket() string {
+	return s.socket
+}
+
+// Close stops the server and removes its socket.
+func (s *Server) Close() error {
+	err := s.http.Close()
+	<-s.done
+	if rmErr := os.Remove(s.socket); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// AddStore adds snaps to the store, where find and install see them.
+func (s *Server) AddStore(snaps ...Snap) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = append(s.store, snaps...)
+}
+
+// AddInstalled marks snaps as installed.
+func (s *Server) AddInstalled(snaps ...Snap) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.installed = append(s.installed, snaps...)
+}
+
+// Installed returns the installed snaps.
+func (s *Server) Installed() []Snap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.installed)
+}
+
+// Changes returns the changes started so far.
+func (s *Server) Changes() []Change {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := make([]Change, 0, len(s.changes))
+	for _, c := range s.changes {
+		changes = append(changes, *c)
+	}
+	return changes
+}
+
+// Requests returns the requests served so far, as "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// Fail makes the next requests to path (such as "/v2/find") fail, one
+// failure per request, in order.
+func (s *Server) Fail(path string, failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], failures...)
+}
+
+// ServeHTTP implements http.Handler, so the server can also be mounted on
+// an httptest.Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	if pending := s.failures[r.URL.Path]; len(pending) > 0 {
+		s.failures[r.URL.Path] = pending[1:]
+		writeError(w, pending[0].Status, pending[0].Kind, pending[0].Message)
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/v2/system-info" && r.Method == http.MethodGet:
+		writeSync(w, map[string]any{
+			"series":      "16",
+			"version":     s.Version,
+			"confinement": "strict",
+			"locations":   map[string]string{"snap-mount-dir": "/snap", "snap-bin-dir": "/snap/bin"},
+		})
+	case path == "/v2/find" && r.Method == http.MethodGet:
+		s.find(w, r)
+	case path == "/v2/snaps" && r.Method == http.MethodGet:
+		writeSync(w, s.installed)
+	case strings.HasPrefix(path, "/v2/snaps/") && r.Method == http.MethodGet:
+		name := strings.TrimPrefix(path, "/v2/snaps/")
+		if i := s.indexInstalled(name); i >= 0 {
+			writeSync(w, s.installed[i])
+			return
+		}
+		writeError(w, http.StatusNotFound, "snap-not-found", "snap not installed")
+	case strings.HasPrefix(path, "/v2/snaps/") && r.Method == http.MethodPost:
+		s.action(w, r, strings.TrimPrefix(path, "/v2/snaps/"))
+	case strings.HasPrefix(path, "/v2/changes/") && r.Method == http.MethodGet:
+		s.change(w, strings.TrimPrefix(path, "/v2/changes/"))
+	default:
+		writeError(w, http.StatusNotFound, "", "not found")
+	}
+}
+
+// find serves /v2/find?q=query and /v2/find?name=exact.
+func (s *Server) find(w http.ResponseWriter, r *http.Request) {
+	query, exact := r.URL.Query().Get("q"), r.URL.Query().Get("name")
+	var found []Snap
+	for _, snap := range s.store {
+		if exact != "" && snap.Name == exact || exact == "" && strings.Contains(snap.Name+" "+snap.Summary, query) {
+			found = append(found, snap)
+		}
+	}
+	if len(found) == 0 {
+		writeError(w, http.StatusNotFound, "snap-not-found", "no snaps found for \""+query+exact+"\"")
+		return
+	}
+	writeSync(w, found)
+}
+
+// action starts an install, remove or refresh change for name.
+func (s *Server) action(w http.ResponseWriter, r *http.Request, name string) {
+	var body struct {
+		Action  string `json:"action"`
+		Channel string `json:"channel"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "", "cannot decode request body: "+err.Error())
+		return
+	}
+
+	installed := s.indexInstalled(name)
+	var apply func()
+	switch body.Action {
+	case "install":
+		if installed >= 0 {
+			writeError(w, http.StatusBadRequest, "snap-already-installed", "snap \""+name+"\" is already installed")
+			return
+		}
+		i := slices.IndexFunc(s.store, func(snap Snap) bool { return snap.Name == name })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "snap-not-found", "snap \""+name+"\" not found")
+			return
+		}
+		snap := s.store[i]
+		if body.Channel != "" {
+			snap.Channel = body.Channel
+		}
+		apply = func() { s.installed = append(s.installed, snap) }
+	case "remove", "refresh":
+		if installed < 0 {
+			writeError(w, http.StatusBadRequest, "snap-not-installed", "snap \""+name+"\" is not installed")
+			return
+		}
+		if body.Action == "remove" {
+			apply = func() {
+				s.installed = slices.DeleteFunc(s.installed, func(snap Snap) bool { return snap.Name == name })
+			}
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "", "unknown action \""+body.Action+"\"")
+		return
+	}
+
+	change := &Change{
+		ID:      strconv.Itoa(len(s.changes) + 1),
+		Kind:    body.Action + "-snap",
+		Summary: strings.ToUpper(body.Action[:1]) + body.Action[1:] + " \"" + name + "\" snap",
+		Status:  "Do",
+		snap:    name,
+		action:  body.Action,
+		apply:   apply,
+	}
+	s.changes = append(s.changes, change)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type": "async", "status-code": http.StatusAccepted, "status": "Accepted", "change": change.ID,
+	})
+}
+
+// change reports a change, advancing it by one step.
+func (s *Server) change(w http.ResponseWriter, id string) {
+	i := slices.IndexFunc(s.changes, func(c *Change) bool { return c.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "", "cannot find change with id \""+id+"\"")
+		return
+	}
+	c := s.changes[i]
+	if !c.Ready {
+		c.polls++
+		switch {
+		case c.polls >= s.ChangeSteps:
+			c.Status, c.Ready = "Done", true
+			if c.apply != nil {
+				c.apply()
+			}
+		default:
+			c.Status = "Doing"
+		}
+	}
+	writeSync(w, c)
+}
+
+func (s *Server) indexInstalled(name string) int {
+	return slices.IndexFunc(s.installed, func(snap Snap) bool { return snap.Name == name })
+}
+
+// writeSync writes a synchronous snapd response.
+func writeSync(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type": "sync", "status-code": http.StatusOK, "status": "OK", "result": result,
+	})
+}
+
+// writeError writes a snapd error response.
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	result := map[string]any{"message": message}
+	if kind != "" {
+		result["kind"] = kind
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type": "error", "status-code": status, "status": http.StatusText(status), "result": result,
+	})
+}
@@ -0,0 +1,146 @@
+package snapdmock_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest/snapdmock"
+)
+
+func newServer(t *testing.T) *snapdmock.Server {
+	t.Helper()
+	srv, err := snapdmock.NewServer(filepath.Join(t.TempDir(), "snapd.socket"))
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	return srv
+}
+
+// response is the snapd response envelope.
+type response struct {
+	Type       string          `json:"type"`
+	StatusCode int             `json:"status-code"`
+	Change     string          `json:"change"`
+	Result     json.RawMessage `json:"result"`
+}
+
+func request(t *testing.T, srv *snapdmock.Server, method, path, body string) response {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", srv.Socket())
+		},
+	}}
+	req, err := http.NewRequest(method, "http://localhost"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.StatusCode != resp.StatusCode {
+		t.Errorf("status-code = %d, HTTP status %d", r.StatusCode, resp.StatusCode)
+	}
+	return r
+}
+
+func TestServer_Find(t *testing.T) {
+	srv := newServer(t)
+	srv.AddStore(snapdmock.Snap{Name: "hello-world", Version: "6.4"}, snapdmock.Snap{Name: "firefox", Version: "124.0"})
+
+	r := request(t, srv, http.MethodGet, "/v2/find?q=hello", "")
+	var snaps []snapdmock.Snap
+	if err := json.Unmarshal(r.Result, &snaps); err != nil {
+		t.Fatal(err)
+	}
+	if r.Type != "sync" || len(snaps) != 1 || snaps[0].Name != "hello-world" {
+		t.Errorf("find = %s %s", r.Type, r.Result)
+	}
+
+	if r := request(t, srv, http.MethodGet, "/v2/find?name=missing", ""); r.Type != "error" || r.StatusCode != http.StatusNotFound {
+		t.Errorf("find missing = %d %s", r.StatusCode, r.Result)
+	}
+}
+
+func TestServer_AsyncInstall(t *testing.T) {
+	srv := newServer(t)
+	srv.ChangeSteps = 3
+	srv.AddStore(snapdmock.Snap{Name: "hello-world", Version: "6.4"})
+
+	r := request(t, srv, http.MethodPost, "/v2/snaps/hello-world", `{"action":"install","channel":"edge"}`)
+	if r.Type != "async" || r.Change == "" {
+		t.Fatalf("install = %+v", r)
+	}
+
+	var statuses []string
+	for range 4 {
+		var change snapdmock.Change
+		if err := json.Unmarshal(request(t, srv, http.MethodGet, "/v2/changes/"+r.Change, "").Result, &change); err != nil {
+			t.Fatal(err)
+		}
+		statuses = append(statuses, change.Status)
+		if !change.Ready && len(srv.Installed()) > 0 {
+			t.Error("Snap installed before the change was done")
+		}
+	}
+	if got := strings.Join(statuses, ","); got != "Doing,Doing,Done,Done" {
+		t.Errorf("Change statuses = %s", got)
+	}
+	if installed := srv.Installed(); len(installed) != 1 || installed[0].Channel != "edge" {
+		t.Errorf("Installed() = %+v", installed)
+	}
+
+	if r := request(t, srv, http.MethodPost, "/v2/snaps/hello-world", `{"action":"install"}`); r.StatusCode != http.StatusBadRequest || !strings.Contains(string(r.Result), "snap-already-installed") {
+		t.Errorf("second install = %d %s", r.StatusCode, r.Result)
+	}
+}
+
+func TestServer_Remove(t *testing.T) {
+	srv := newServer(t)
+	srv.ChangeSteps = 1
+	srv.AddInstalled(snapdmock.Snap{Name: "hello-world", Version: "6.4"})
+
+	r := request(t, srv, http.MethodPost, "/v2/snaps/hello-world", `{"action":"remove"}`)
+	request(t, srv, http.MethodGet, "/v2/changes/"+r.Change, "")
+	if installed := srv.Installed(); len(installed) != 0 {
+		t.Errorf("Installed() = %+v after remove", installed)
+	}
+	if r := request(t, srv, http.MethodGet, "/v2/snaps/hello-world", ""); r.StatusCode != http.StatusNotFound {
+		t.Errorf("GET removed snap = %d", r.StatusCode)
+	}
+}
+
+func TestServer_WithSnapBackend(t *testing.T) {
+	srv := newServer(t)
+	fake := pm.RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "", nil
+	})
+	mgr := pm.NewSnap(pm.WithSnapdEndpoint(srv.Socket()), pm.WithRunner(fake), pm.WithoutOperationLock())
+
+	if ok, err := mgr.Available(context.Background()); !ok || err != nil {
+		t.Fatalf("Available() = %v, %v; want true, nil", ok, err)
+	}
+
+	srv.Fail("/v2/system-info", snapdmock.Failure{Status: http.StatusForbidden, Kind: "login-required", Message: "access denied"})
+	mgr.(pm.AvailabilityInvalidator).InvalidateAvailability()
+	_, err := mgr.Available(context.Background())
+	if !pm.IsPermissionDenied(err) {
+		t.Fatalf("Available() error = %v, want PermissionDeniedError", err)
+	}
+	if got := srv.Requests(); len(got) != 2 || got[1] != "GET /v2/system-info" {
+		t.Errorf("Requests() = %q", got)
+	}
+}
@@ -0,0 +1,290 @@
+package pmtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm"
+)
+
+// StressOptions configures Stress.
+type StressOptions struct {
+	// Workers is the number of goroutines calling the manager at once.
+	// Defaults to 8.
+	Workers int
+
+	// Iterations is the number of operations each worker performs.
+	// Defaults to 20.
+	Iterations int
+
+	// Packages are installed and uninstalled by the workers. Without them,
+	// only Update, Upgrade, Search and ListInstalled are called.
+	Packages []pm.PackageRef
+
+	// Seed seeds the choice of operations, so a failing mix can be
+	// reproduced.
+	Seed uint64
+}
+
+// Stress calls mgr's operations from many goroutines at once, in a random
+// mix, and fails t if any of them panics or returns an unexpected error.
+// Errors a package manager legitimately reports under contention, such as
+// AlreadyInstalledError when two workers install the same package, are
+// expected. Run it with -race, together with a ChaosReporter and a
+// RunnerProbe, to check a backend for data races, corrupted progress
+// streams and broken serialization:
+//
+//	probe := &pmtest.RunnerProbe{Runner: fakeRunner}
+//	chaos := pmtest.NewChaosReporter(time.Millisecond)
+//	mgr := pm.NewFlatpak(pm.WithRunner(probe), pm.WithProgress(chaos))
+//	pmtest.Stress(t, mgr, pmtest.StressOptions{Packages: pkgs})
+//	if err := chaos.Err(); err != nil {
+//		t.Error(err)
+//	}
+//	if n := probe.MaxConcurrentMutations(); n > 1 {
+//		t.Errorf("%d mutating commands ran at once", n)
+//	}
+func Stress(t testing.TB, mgr pm.Manager, opts StressOptions) {
+	t.Helper()
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 20
+	}
+
+	ops := []string{"Update", "Upgrade", "Search", "ListInstalled"}
+	if len(opts.Packages) > 0 {
+		ops = append(ops, "Install", "Uninstall")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, opts.Workers*opts.Iterations)
+	for w := range opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(opts.Seed, uint64(w)))
+			for range opts.Iterations {
+				op := ops[rng.IntN(len(ops))]
+				var pkgs []pm.PackageRef
+				if len(opts.Packages) > 0 {
+					pkgs = []pm.PackageRef{opts.Packages[rng.IntN(len(opts.Packages))]}
+				}
+				if err := call(mgr, op, pkgs); err != nil {
+					errs <- fmt.Errorf("worker %d: %s: %w", w, op, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// call runs one operation, turning a panic into an error and dropping
+// errors expected under contention.
+func call(mgr pm.Manager, op string, pkgs []pm.PackageRef) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx := context.Background()
+	switch op {
+	case "Update":
+		if u, ok := mgr.(pm.Updater); ok {
+			_, err = u.Update(ctx, pm.UpdateOptions{})
+		}
+	case "Upgrade":
+		if u, ok := mgr.(pm.Upgrader); ok {
+			_, err = u.Upgrade(ctx, pm.UpgradeOptions{})
+		}
+	case "Search":
+		if s, ok := mgr.(pm.Searcher); ok {
+			_, err = s.Search(ctx, "stress", pm.SearchOptions{})
+		}
+	case "ListInstalled":
+		if l, ok := mgr.(pm.Lister); ok {
+			_, err = l.ListInstalled(ctx, pm.ListOptions{})
+		}
+	case "Install":
+		if i, ok := mgr.(pm.Installer); ok {
+			var res pm.InstallResult
+			res, err = i.Install(ctx, pkgs, pm.InstallOptions{})
+			if len(res.Skipped) == len(pkgs) {
+				return nil
+			}
+		}
+	case "Uninstall":
+		if u, ok := mgr.(pm.Uninstaller); ok {
+			var res pm.UninstallResult
+			res, err = u.Uninstall(ctx, pkgs, pm.UninstallOptions{})
+			if len(res.Skipped) == len(pkgs) {
+				return nil
+			}
+		}
+	}
+	if pm.IsNotSupported(err) || pm.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+// ChaosReporter is a progress reporter for stress tests. It sleeps for a
+// random time of up to MaxDelay in every callback, to widen race windows,
+// and checks that the event stream stays well formed while operations run
+// concurrently: every action, task and step starts once and ends at most
+// once, after it started; tasks belong to running actions and steps to
+// running tasks; and no event refers to an item that has ended.
+type ChaosReporter struct {
+	// MaxDelay bounds the delay in each callback; zero disables delays.
+	MaxDelay time.Duration
+
+	mu     sync.Mutex
+	open   map[string]bool // ID -> still running
+	events int
+	errs   []error
+}
+
+// NewChaosReporter returns a ChaosReporter delaying callbacks by up to
+// maxDelay.
+func NewChaosReporter(maxDelay time.Duration) *ChaosReporter {
+	return &ChaosReporter{MaxDelay: maxDelay, open: make(map[string]bool)}
+}
+
+// Events returns the number of events received.
+func (r *ChaosReporter) Events() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events
+}
+
+// Err returns the problems found in the event stream, including items that
+// were started but never ended, or nil.
+func (r *ChaosReporter) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := r.errs
+	for id, running := range r.open {
+		if running {
+			errs = append(errs, fmt.Errorf("%s never ended", id))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *ChaosReporter) delay() {
+	if r.MaxDelay > 0 {
+		time.Sleep(rand.N(r.MaxDelay))
+	}
+}
+
+// event checks one event for item id of the given kind, whose parent (if
+// any) must be running; it must be called with mu held.
+func (r *ChaosReporter) event(kind, id, parentKind, parent string, started, ended time.Time) {
+	r.events++
+	if id == "" {
+		r.errs = append(r.errs, fmt.Errorf("%s event without an ID", kind))
+		return
+	}
+	if parent != "" && !r.open[parent] {
+		r.errs = append(r.errs, fmt.Errorf("%s %s refers to %s %s, which is not running", kind, id, parentKind, parent))
+	}
+	running, seen := r.open[id]
+	switch {
+	case !seen && !ended.IsZero():
+		r.errs = append(r.errs, fmt.Errorf("%s %s ended without starting", kind, id))
+	case seen && !running:
+		r.errs = append(r.errs, fmt.Errorf("%s %s reported after it ended", kind, id))
+	case !ended.IsZero() && ended.Before(started):
+		r.errs = append(r.errs, fmt.Errorf("%s %s ended before it started", kind, id))
+	}
+	r.open[id] = ended.IsZero()
+}
+
+func (r *ChaosReporter) OnAction(action pm.ProgressAction) {
+	r.delay()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("action", action.ID, "", "", action.StartedAt, action.EndedAt)
+}
+
+func (r *ChaosReporter) OnTask(task pm.ProgressTask) {
+	r.delay()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("task", task.ID, "action", task.ActionID, task.StartedAt, task.EndedAt)
+}
+
+func (r *ChaosReporter) OnStep(step pm.ProgressStep) {
+	r.delay()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("step", step.ID, "task", step.TaskID, step.StartedAt, step.EndedAt)
+}
+
+func (r *ChaosReporter) OnMessage(msg pm.ProgressMessage) {
+	r.delay()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events++
+	for _, id := range []string{msg.ActionID, msg.TaskID, msg.StepID} {
+		if id != "" && !r.open[id] {
+			r.errs = append(r.errs, fmt.Errorf("message %q refers to %s, which is not running", msg.Text, id))
+		}
+	}
+}
+
+// RunnerProbe wraps a runner and measures how many mutating commands
+// (those run for Update, Upgrade, Install or Uninstall) run at the same
+// time, to check that a backend serializes its mutations.
+type RunnerProbe struct {
+	// Runner runs the commands.
+	Runner pm.Runner
+
+	// Delay is added to every mutating command, so overlapping calls are
+	// caught even when Runner returns immediately.
+	Delay time.Duration
+
+	mu     sync.Mutex
+	active int
+	max    int
+}
+
+// Run implements pm.Runner.
+func (p *RunnerProbe) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	op, _ := pm.RunnerOperation(ctx)
+	if op != pm.OperationUpdateMetadata && op != pm.OperationUpgradePackages && op != pm.OperationInstall && op != pm.OperationUninstall {
+		return p.Runner.Run(ctx, name, args...)
+	}
+
+	p.mu.Lock()
+	p.active++
+	p.max = max(p.max, p.active)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.active--
+		p.mu.Unlock()
+	}()
+	if p.Delay > 0 {
+		time.Sleep(p.Delay)
+	}
+	return p.Runner.Run(ctx, name, args...)
+}
+
+// MaxConcurrentMutations returns the largest number of mutating commands
+// seen running at once.
+func (p *RunnerProbe) MaxConcurrentMutations() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.max
+}
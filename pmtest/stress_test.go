@@ -0,0 +1,94 @@
+package pmtest_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm"
+	"github.com/frostyard/pm/pmtest"
+)
+
+func TestStress_FakeManager(t *testing.T) {
+	fake := pmtest.NewFakeManager()
+	fake.Catalog = []pm.PackageRef{{Name: "jq"}, {Name: "ripgrep"}}
+	pmtest.Stress(t, fake, pmtest.StressOptions{Packages: fake.Catalog, Seed: 1})
+}
+
+// TestStress_Backends runs the in-tree backends concurrently, each checked
+// for corrupted progress streams and overlapping mutations.
+func TestStress_Backends(t *testing.T) {
+	formulae := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"name":"jq"},{"name":"stress"}]`)
+	}))
+	defer formulae.Close()
+
+	fake := pm.RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "", nil
+	})
+	chaos := pmtest.NewChaosReporter(100 * time.Microsecond)
+
+	backends := []struct {
+		name string
+		new  func(...pm.ConstructorOption) pm.Manager
+		pkgs []pm.PackageRef
+	}{
+		{"brew", pm.NewBrew, []pm.PackageRef{{Name: "jq"}, {Name: "wget"}}},
+		{"flatpak", pm.NewFlatpak, []pm.PackageRef{{Name: "org.gimp.GIMP"}, {Name: "org.mozilla.firefox"}}},
+		{"snap", pm.NewSnap, []pm.PackageRef{{Name: "firefox"}, {Name: "hello-world"}}},
+	}
+	probes := make([]*pmtest.RunnerProbe, len(backends))
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		probes[i] = &pmtest.RunnerProbe{Runner: fake, Delay: 50 * time.Microsecond}
+		mgr := b.new(pm.WithRunner(probes[i]), pm.WithProgress(chaos), pm.WithFormulaeBaseURL(formulae.URL))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pmtest.Stress(t, mgr, pmtest.StressOptions{Packages: b.pkgs, Seed: uint64(i)})
+		}()
+	}
+	wg.Wait()
+
+	if err := chaos.Err(); err != nil {
+		t.Errorf("Progress stream corrupted: %v", err)
+	}
+	if chaos.Events() == 0 {
+		t.Error("Expected progress events")
+	}
+	for i, p := range probes {
+		if n := p.MaxConcurrentMutations(); n != 1 {
+			t.Errorf("%s: %d mutating commands ran at once, want 1", backends[i].name, n)
+		}
+	}
+}
+
+func TestRunnerProbe_DetectsOverlap(t *testing.T) {
+	fake := pm.RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		return "", "", nil
+	})
+	probe := &pmtest.RunnerProbe{Runner: fake, Delay: 20 * time.Millisecond}
+	mgr := pm.NewFlatpak(pm.WithRunner(probe), pm.WithoutOperationLock())
+	pmtest.Stress(t, mgr, pmtest.StressOptions{Workers: 4, Iterations: 2, Packages: []pm.PackageRef{{Name: "org.gimp.GIMP"}}})
+
+	if n := probe.MaxConcurrentMutations(); n < 2 {
+		t.Errorf("MaxConcurrentMutations() = %d without the operation lock, want overlap", n)
+	}
+}
+
+func TestChaosReporter(t *testing.T) {
+	r := pmtest.NewChaosReporter(0)
+	start := time.Now()
+	r.OnAction(pm.ProgressAction{ID: "a", StartedAt: start})
+	r.OnTask(pm.ProgressTask{ID: "t", ActionID: "a", StartedAt: start})
+	r.OnAction(pm.ProgressAction{ID: "a", StartedAt: start, EndedAt: start.Add(time.Second)})
+	r.OnTask(pm.ProgressTask{ID: "t2", ActionID: "a", StartedAt: start})
+
+	if err := r.Err(); err == nil {
+		t.Error("Expected errors for a task outliving its action")
+	}
+}
@@ -1,6 +1,12 @@
 package pm
 
-import "github.com/frostyard/pm/progress"
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/frostyard/pm/progress"
+)
 
 // Re-export progress types for backward compatibility
 type (
@@ -16,6 +22,40 @@ type (
 	// ProgressStep represents a step within a task.
 	ProgressStep = progress.ProgressStep
 
+	// Completion describes how far a task or step has progressed.
+	Completion = progress.Completion
+
+	// ActionSummary is the final state of an action.
+	ActionSummary = progress.ActionSummary
+
+	// Outcome is how an action ended.
+	Outcome = progress.Outcome
+
+	// Estimator computes smoothed rates and ETAs from completion updates.
+	Estimator = progress.Estimator
+
+	// Estimate is the transfer rate and remaining time of a task or step.
+	Estimate = progress.Estimate
+
+	// WriterOptions configures NewWriterReporter.
+	WriterOptions = progress.WriterOptions
+
+	// Event is a single progress event of any kind.
+	Event = progress.Event
+
+	// EventKind identifies which progress callback an Event corresponds to.
+	EventKind = progress.EventKind
+
+	// Subscription is a ProgressReporter that delivers events on a channel.
+	Subscription = progress.Subscription
+
+	// CancelRequester is implemented by reporters that can cancel the
+	// operation they report on.
+	CancelRequester = progress.CancelRequester
+
+	// CancelSignal is a CancelRequester to embed in a reporter.
+	CancelSignal = progress.CancelSignal
+
 	// ProgressMessage is a message emitted during progress.
 	ProgressMessage = progress.ProgressMessage
 
@@ -33,7 +73,100 @@ const (
 	SeverityError   = progress.SeverityError
 )
 
+// Re-export action outcomes
+const (
+	OutcomeSuccess   = progress.OutcomeSuccess
+	OutcomeFailure   = progress.OutcomeFailure
+	OutcomeCancelled = progress.OutcomeCancelled
+)
+
+// Re-export event kinds
+const (
+	EventAction  = progress.EventAction
+	EventTask    = progress.EventTask
+	EventStep    = progress.EventStep
+	EventMessage = progress.EventMessage
+)
+
 // NewProgressHelper creates a new progress helper with progress reporting.
 func NewProgressHelper(defaultReporter, overrideReporter ProgressReporter) *ProgressHelper {
 	return progress.NewProgressHelper(defaultReporter, overrideReporter)
 }
+
+// NewEstimator returns an Estimator for computing rates and ETAs in a
+// ProgressReporter.
+func NewEstimator() *Estimator {
+	return progress.NewEstimator()
+}
+
+// NewWriterReporter returns a ProgressReporter that writes events to w as
+// indented, human-readable lines.
+func NewWriterReporter(w io.Writer, opts WriterOptions) ProgressReporter {
+	return progress.NewWriterReporter(w, opts)
+}
+
+// NewJSONReporter returns a ProgressReporter that writes every event to w
+// as one JSON object per line.
+func NewJSONReporter(w io.Writer) ProgressReporter {
+	return progress.NewJSONReporter(w)
+}
+
+// ReplayJSON delivers the events written by NewJSONReporter to reporter.
+func ReplayJSON(r io.Reader, reporter ProgressReporter) error {
+	return progress.ReplayJSON(r, reporter)
+}
+
+// Subscribe returns a ProgressReporter whose events are received from a
+// channel. Call Close on it when the operation is done.
+func Subscribe() *Subscription {
+	return progress.Subscribe()
+}
+
+// MultiReporter returns a ProgressReporter that forwards every event to
+// each of reporters.
+func MultiReporter(reporters ...ProgressReporter) ProgressReporter {
+	return progress.Multi(reporters...)
+}
+
+// FilterSeverity returns a ProgressReporter that forwards to r only the
+// messages of at least minSeverity, along with all actions, tasks and
+// steps.
+func FilterSeverity(r ProgressReporter, minSeverity Severity) ProgressReporter {
+	return progress.Filter(r, minSeverity)
+}
+
+// FilterEvents returns a ProgressReporter that forwards to r only the
+// events of the given kinds.
+func FilterEvents(r ProgressReporter, keep ...EventKind) ProgressReporter {
+	return progress.FilterEvents(r, keep...)
+}
+
+// Throttle returns a ProgressReporter that forwards completion updates of
+// each task and step to r at most once per minInterval, always delivering
+// actions, messages, and start and end events.
+func Throttle(r ProgressReporter, minInterval time.Duration) ProgressReporter {
+	return progress.Throttle(r, minInterval)
+}
+
+// NewCancelSignal returns a CancelSignal that has not been cancelled.
+func NewCancelSignal() *CancelSignal {
+	return progress.NewCancelSignal()
+}
+
+type progressKey struct{}
+
+// ContextWithProgress returns a copy of ctx carrying a default progress
+// reporter. Operations run with the returned context report to it when
+// neither their options nor the constructor (WithProgress) set a reporter,
+// so a framework can inject progress reporting once, for instance in
+// request middleware, instead of at every call site.
+func ContextWithProgress(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, reporter)
+}
+
+// ProgressFromContext returns the reporter set by ContextWithProgress, or
+// nil if there is none.
+func ProgressFromContext(ctx context.Context) ProgressReporter {
+	pr, _ := ctx.Value(progressKey{}).(ProgressReporter)
+	return pr
+}
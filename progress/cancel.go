@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"reflect"
+	"sync"
+)
+
+// CancelRequester is implemented by reporters that let the user cancel the
+// operation they report on, such as a UI with a Cancel button. Operations
+// given such a reporter cancel their context once the channel is closed.
+type CancelRequester interface {
+	// CancelRequested returns a channel that is closed when cancellation
+	// is requested. A nil channel means cancellation is never requested.
+	CancelRequested() <-chan struct{}
+}
+
+// CancelRequests returns the cancellation channel of r, or nil if r does
+// not implement CancelRequester.
+func CancelRequests(r ProgressReporter) <-chan struct{} {
+	if c, ok := r.(CancelRequester); ok {
+		return c.CancelRequested()
+	}
+	return nil
+}
+
+// CancelSignal is a ready-made CancelRequester to embed in a reporter:
+//
+//	type guiReporter struct {
+//		*progress.CancelSignal
+//		...
+//	}
+//
+// and call Cancel from the Cancel button's handler. The zero value is not
+// usable; create one with NewCancelSignal.
+type CancelSignal struct {
+	once sync.Once
+	ch   chan struct{}
+}
+
+// NewCancelSignal returns a CancelSignal that has not been cancelled.
+func NewCancelSignal() *CancelSignal {
+	return &CancelSignal{ch: make(chan struct{})}
+}
+
+// Cancel requests cancellation. It is safe to call more than once.
+func (s *CancelSignal) Cancel() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// CancelRequested implements CancelRequester.
+func (s *CancelSignal) CancelRequested() <-chan struct{} {
+	return s.ch
+}
+
+// anyCancelRequest returns a channel closed when any of reporters requests
+// cancellation, or nil if none of them can. Merging several channels
+// starts a goroutine that runs until one of them is closed.
+func anyCancelRequest(reporters []ProgressReporter) <-chan struct{} {
+	var cases []reflect.SelectCase
+	for _, r := range reporters {
+		if ch := CancelRequests(r); ch != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)})
+		}
+	}
+	switch len(cases) {
+	case 0:
+		return nil
+	case 1:
+		return cases[0].Chan.Interface().(<-chan struct{})
+	}
+	merged := make(chan struct{})
+	go func() {
+		reflect.Select(cases)
+		close(merged)
+	}()
+	return merged
+}
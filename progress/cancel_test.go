@@ -0,0 +1,75 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+// cancellableReporter is a capturingReporter with a Cancel button.
+type cancellableReporter struct {
+	capturingReporter
+	*CancelSignal
+}
+
+func TestCancelSignal(t *testing.T) {
+	s := NewCancelSignal()
+	select {
+	case <-s.CancelRequested():
+		t.Fatal("Expected no cancellation yet")
+	default:
+	}
+
+	s.Cancel()
+	s.Cancel()
+	select {
+	case <-s.CancelRequested():
+	default:
+		t.Fatal("Expected cancellation")
+	}
+}
+
+func TestCancelRequests_Wrappers(t *testing.T) {
+	if CancelRequests(&capturingReporter{}) != nil {
+		t.Error("Expected nil channel for a reporter without cancellation")
+	}
+	if CancelRequests(Multi(&capturingReporter{}, Filter(&capturingReporter{}, SeverityError))) != nil {
+		t.Error("Expected nil channel when no wrapped reporter can cancel")
+	}
+
+	for name, wrap := range map[string]func(ProgressReporter) ProgressReporter{
+		"Filter":         func(r ProgressReporter) ProgressReporter { return Filter(r, SeverityError) },
+		"FilterEvents":   func(r ProgressReporter) ProgressReporter { return FilterEvents(r, EventAction) },
+		"MakeThreadSafe": MakeThreadSafe,
+		"Multi":          func(r ProgressReporter) ProgressReporter { return Multi(&capturingReporter{}, r) },
+	} {
+		r := &cancellableReporter{CancelSignal: NewCancelSignal()}
+		ch := CancelRequests(wrap(r))
+		if ch == nil {
+			t.Errorf("%s: expected a cancellation channel", name)
+			continue
+		}
+		r.Cancel()
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Errorf("%s: cancellation not passed on", name)
+		}
+	}
+}
+
+func TestMulti_MergesCancellation(t *testing.T) {
+	a := &cancellableReporter{CancelSignal: NewCancelSignal()}
+	b := &cancellableReporter{CancelSignal: NewCancelSignal()}
+	m := Multi(a, b)
+	ch := CancelRequests(m)
+	if ch != CancelRequests(m) {
+		t.Error("Expected the merged channel to be created once")
+	}
+
+	b.Cancel()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("Expected cancellation from the second reporter")
+	}
+}
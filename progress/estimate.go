@@ -0,0 +1,122 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSmoothing is the weight NewEstimator gives the latest rate sample
+// when averaging it with earlier ones.
+const DefaultSmoothing = 0.3
+
+// Estimate is the transfer rate and remaining time of a task or step.
+type Estimate struct {
+	// BytesPerSecond is the smoothed byte rate, zero if byte counts are not
+	// reported.
+	BytesPerSecond float64
+
+	// PercentPerSecond is the smoothed completion rate.
+	PercentPerSecond float64
+
+	// Remaining is the estimated time until completion.
+	Remaining time.Duration
+
+	// Known is false until two completion updates have been seen, and for
+	// indeterminate or stalled work.
+	Known bool
+}
+
+// Estimator computes smoothed rates and ETAs from the completion updates of
+// tasks and steps, so reporters don't have to. Feed it every event a
+// reporter receives; it is safe for concurrent use.
+type Estimator struct {
+	mu        sync.Mutex
+	smoothing float64
+	entries   map[string]*estimate
+}
+
+type estimate struct {
+	last     Completion
+	bytes    float64
+	percent  float64
+	measured bool
+}
+
+// NewEstimator returns an Estimator using DefaultSmoothing.
+func NewEstimator() *Estimator {
+	return NewEstimatorWithSmoothing(DefaultSmoothing)
+}
+
+// NewEstimatorWithSmoothing returns an Estimator that weighs the latest
+// rate sample by smoothing (between 0 and 1; higher reacts faster).
+func NewEstimatorWithSmoothing(smoothing float64) *Estimator {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = DefaultSmoothing
+	}
+	return &Estimator{smoothing: smoothing, entries: make(map[string]*estimate)}
+}
+
+// Task records a task event and returns the task's current estimate. The
+// task is forgotten once it ends.
+func (e *Estimator) Task(task ProgressTask) Estimate {
+	return e.update(task.ID, task.Completion, !task.EndedAt.IsZero())
+}
+
+// Step records a step event and returns the step's current estimate. The
+// step is forgotten once it ends.
+func (e *Estimator) Step(step ProgressStep) Estimate {
+	return e.update(step.ID, step.Completion, !step.EndedAt.IsZero())
+}
+
+func (e *Estimator) update(id string, c Completion, ended bool) Estimate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ended {
+		delete(e.entries, id)
+		return Estimate{Known: true}
+	}
+	if c.UpdatedAt.IsZero() || c.Indeterminate {
+		return Estimate{}
+	}
+
+	entry, ok := e.entries[id]
+	if !ok {
+		e.entries[id] = &estimate{last: c}
+		return Estimate{}
+	}
+
+	dt := c.UpdatedAt.Sub(entry.last.UpdatedAt).Seconds()
+	if dt > 0 {
+		if c.Percent < entry.last.Percent || c.BytesDone < entry.last.BytesDone {
+			// The tool restarted its counter (e.g. the next download);
+			// keep the rates and measure from here.
+			entry.last = c
+			return entry.result(c)
+		}
+		bytes := float64(c.BytesDone-entry.last.BytesDone) / dt
+		percent := (c.Percent - entry.last.Percent) / dt
+		if entry.measured {
+			bytes = e.smoothing*bytes + (1-e.smoothing)*entry.bytes
+			percent = e.smoothing*percent + (1-e.smoothing)*entry.percent
+		}
+		entry.bytes, entry.percent, entry.measured = bytes, percent, true
+		entry.last = c
+	}
+	return entry.result(c)
+}
+
+// result turns the smoothed rates into an Estimate for completion c.
+func (entry *estimate) result(c Completion) Estimate {
+	est := Estimate{BytesPerSecond: entry.bytes, PercentPerSecond: entry.percent}
+	switch {
+	case !entry.measured:
+	case c.BytesTotal > 0 && entry.bytes > 0:
+		est.Remaining = time.Duration(float64(c.BytesTotal-c.BytesDone) / entry.bytes * float64(time.Second))
+		est.Known = true
+	case entry.percent > 0:
+		est.Remaining = time.Duration((100 - c.Percent) / entry.percent * float64(time.Second))
+		est.Known = true
+	}
+	return est
+}
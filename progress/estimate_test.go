@@ -0,0 +1,81 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimator_Bytes(t *testing.T) {
+	e := NewEstimatorWithSmoothing(0.5)
+	start := time.Now()
+	task := ProgressTask{ID: "t1", StartedAt: start}
+
+	if est := e.Task(task); est.Known {
+		t.Error("Expected unknown estimate for start event")
+	}
+
+	update := func(after time.Duration, done int64) Estimate {
+		task.Completion = Completion{UpdatedAt: start.Add(after), BytesDone: done, BytesTotal: 1000}
+		return e.Task(task)
+	}
+
+	if est := update(time.Second, 100); est.Known {
+		t.Error("Expected unknown estimate after a single update")
+	}
+	est := update(2*time.Second, 300)
+	if !est.Known || est.BytesPerSecond != 200 || est.Remaining != 3500*time.Millisecond {
+		t.Errorf("Unexpected estimate: %+v", est)
+	}
+	// 100 B/s now, smoothed with the previous 200 B/s.
+	est = update(3*time.Second, 400)
+	if est.BytesPerSecond != 150 || est.Remaining != 4*time.Second {
+		t.Errorf("Unexpected smoothed estimate: %+v", est)
+	}
+
+	task.EndedAt = start.Add(4 * time.Second)
+	e.Task(task)
+	if len(e.entries) != 0 {
+		t.Error("Expected ended task to be forgotten")
+	}
+}
+
+func TestEstimator_Percent(t *testing.T) {
+	e := NewEstimator()
+	start := time.Now()
+	step := ProgressStep{ID: "s1"}
+
+	step.Completion = Completion{UpdatedAt: start, Percent: 10}
+	e.Step(step)
+	step.Completion = Completion{UpdatedAt: start.Add(2 * time.Second), Percent: 30}
+	est := e.Step(step)
+
+	if !est.Known || est.PercentPerSecond != 10 || est.Remaining != 7*time.Second || est.BytesPerSecond != 0 {
+		t.Errorf("Unexpected estimate: %+v", est)
+	}
+}
+
+func TestEstimator_Indeterminate(t *testing.T) {
+	e := NewEstimator()
+	task := ProgressTask{ID: "t1", Completion: Completion{UpdatedAt: time.Now(), Indeterminate: true}}
+
+	if est := e.Task(task); est.Known {
+		t.Errorf("Expected unknown estimate for indeterminate task, got %+v", est)
+	}
+}
+
+func TestEstimator_CounterReset(t *testing.T) {
+	e := NewEstimator()
+	start := time.Now()
+	task := ProgressTask{ID: "t1"}
+
+	for i, pct := range []float64{50, 90, 10, 30} {
+		task.Completion = Completion{UpdatedAt: start.Add(time.Duration(i) * time.Second), Percent: pct}
+		est := e.Task(task)
+		if i >= 1 && !est.Known {
+			t.Errorf("update %d: expected a known estimate, got %+v", i, est)
+		}
+		if est.PercentPerSecond < 0 {
+			t.Errorf("update %d: negative rate %v", i, est.PercentPerSecond)
+		}
+	}
+}
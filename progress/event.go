@@ -0,0 +1,43 @@
+package progress
+
+// EventKind identifies which progress callback an Event corresponds to.
+type EventKind string
+
+const (
+	// EventAction is an OnAction event.
+	EventAction EventKind = "action"
+
+	// EventTask is an OnTask event.
+	EventTask EventKind = "task"
+
+	// EventStep is an OnStep event.
+	EventStep EventKind = "step"
+
+	// EventMessage is an OnMessage event.
+	EventMessage EventKind = "message"
+)
+
+// Event is a single progress event: exactly one of Action, Task, Step and
+// Message is set, as indicated by Kind.
+type Event struct {
+	Kind    EventKind        `json:"kind"`
+	Action  *ProgressAction  `json:"action,omitempty"`
+	Task    *ProgressTask    `json:"task,omitempty"`
+	Step    *ProgressStep    `json:"step,omitempty"`
+	Message *ProgressMessage `json:"message,omitempty"`
+}
+
+// Dispatch delivers e to the matching method of r. Events with an unknown
+// kind or a missing payload are ignored.
+func (e Event) Dispatch(r ProgressReporter) {
+	switch {
+	case e.Kind == EventAction && e.Action != nil:
+		r.OnAction(*e.Action)
+	case e.Kind == EventTask && e.Task != nil:
+		r.OnTask(*e.Task)
+	case e.Kind == EventStep && e.Step != nil:
+		r.OnStep(*e.Step)
+	case e.Kind == EventMessage && e.Message != nil:
+		r.OnMessage(*e.Message)
+	}
+}
@@ -0,0 +1,90 @@
+package progress
+
+// severityRank orders severities from least to most severe.
+var severityRank = map[Severity]int{
+	SeverityInfo:    0,
+	SeverityWarning: 1,
+	SeverityError:   2,
+}
+
+// AtLeast reports whether s is at least as severe as min. Unknown
+// severities are treated as errors, so they are never hidden.
+func (s Severity) AtLeast(min Severity) bool {
+	rank, ok := severityRank[s]
+	if !ok {
+		rank = severityRank[SeverityError]
+	}
+	return rank >= severityRank[min]
+}
+
+// severityFilter drops messages below a minimum severity.
+type severityFilter struct {
+	ProgressReporter
+	min Severity
+}
+
+// Filter returns a ProgressReporter that forwards messages of at least
+// minSeverity to r and drops the others, e.g. Filter(r, SeverityWarning)
+// hides the per-line output of commands. Actions, tasks and steps are
+// forwarded unchanged.
+func Filter(r ProgressReporter, minSeverity Severity) ProgressReporter {
+	return &severityFilter{ProgressReporter: getProgressReporter(r), min: minSeverity}
+}
+
+// CancelRequested passes on cancellation requested by the wrapped reporter.
+func (f *severityFilter) CancelRequested() <-chan struct{} {
+	return CancelRequests(f.ProgressReporter)
+}
+
+func (f *severityFilter) OnMessage(msg ProgressMessage) {
+	if msg.Severity.AtLeast(f.min) {
+		f.ProgressReporter.OnMessage(msg)
+	}
+}
+
+// kindFilter forwards only events of selected kinds.
+type kindFilter struct {
+	r    ProgressReporter
+	keep map[EventKind]bool
+}
+
+// FilterEvents returns a ProgressReporter that forwards only events of the
+// given kinds to r. For example, to keep actions and errors only:
+//
+//	FilterEvents(Filter(r, SeverityError), EventAction, EventMessage)
+func FilterEvents(r ProgressReporter, keep ...EventKind) ProgressReporter {
+	f := &kindFilter{r: getProgressReporter(r), keep: make(map[EventKind]bool)}
+	for _, kind := range keep {
+		f.keep[kind] = true
+	}
+	return f
+}
+
+func (f *kindFilter) OnAction(action ProgressAction) {
+	if f.keep[EventAction] {
+		f.r.OnAction(action)
+	}
+}
+
+func (f *kindFilter) OnTask(task ProgressTask) {
+	if f.keep[EventTask] {
+		f.r.OnTask(task)
+	}
+}
+
+func (f *kindFilter) OnStep(step ProgressStep) {
+	if f.keep[EventStep] {
+		f.r.OnStep(step)
+	}
+}
+
+func (f *kindFilter) OnMessage(msg ProgressMessage) {
+	if f.keep[EventMessage] {
+		f.r.OnMessage(msg)
+	}
+}
+
+// CancelRequested passes on cancellation requested by the wrapped reporter.
+func (f *kindFilter) CancelRequested() <-chan struct{} {
+	return CancelRequests(f.r)
+}
@@ -0,0 +1,64 @@
+package progress
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	r := &capturingReporter{}
+	helper := NewProgressHelper(Filter(r, SeverityWarning), nil)
+
+	helper.BeginAction("Install")
+	helper.Info("line of output")
+	helper.Warning("deprecated")
+	helper.Error("failed")
+	helper.EndAction()
+
+	if len(r.actions) != 2 {
+		t.Errorf("Expected actions to pass through, got %d", len(r.actions))
+	}
+	if len(r.messages) != 2 || r.messages[0].Severity != SeverityWarning || r.messages[1].Severity != SeverityError {
+		t.Errorf("Unexpected messages: %+v", r.messages)
+	}
+}
+
+func TestFilterEvents(t *testing.T) {
+	r := &capturingReporter{}
+	helper := NewProgressHelper(FilterEvents(Filter(r, SeverityError), EventAction, EventMessage), nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.BeginStep("Verify")
+	helper.Warning("slow mirror")
+	helper.Error("checksum mismatch")
+	helper.EndStep()
+	helper.EndTask()
+	helper.EndAction()
+
+	if len(r.actions) != 2 || len(r.tasks) != 0 || len(r.steps) != 0 {
+		t.Errorf("Expected only actions, got %d actions, %d tasks, %d steps", len(r.actions), len(r.tasks), len(r.steps))
+	}
+	if len(r.messages) != 1 || r.messages[0].Text != "checksum mismatch" {
+		t.Errorf("Unexpected messages: %+v", r.messages)
+	}
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	tests := []struct {
+		s, min Severity
+		want   bool
+	}{
+		{SeverityInfo, SeverityInfo, true},
+		{SeverityInfo, SeverityWarning, false},
+		{SeverityError, SeverityWarning, true},
+		{"Critical", SeverityError, true},
+	}
+	for _, tt := range tests {
+		if got := tt.s.AtLeast(tt.min); got != tt.want {
+			t.Errorf("%s.AtLeast(%s) = %v, want %v", tt.s, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestFilter_NilReporter(t *testing.T) {
+	Filter(nil, SeverityInfo).OnMessage(ProgressMessage{Severity: SeverityError})
+	FilterEvents(nil, EventMessage).OnMessage(ProgressMessage{})
+}
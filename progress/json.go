@@ -0,0 +1,99 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// jsonReporter writes each event as one JSON object per line.
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONReporter returns a ProgressReporter that writes every event to w
+// as a JSON-encoded Event followed by a newline (JSON Lines), for piping
+// progress to another process or a log system. ReplayJSON reads the
+// stream back. The reporter is safe for concurrent use; write errors are
+// ignored.
+func NewJSONReporter(w io.Writer) ProgressReporter {
+	return &jsonReporter{enc: json.NewEncoder(w)}
+}
+
+func (r *jsonReporter) OnAction(action ProgressAction) {
+	r.write(Event{Kind: EventAction, Action: &action})
+}
+
+func (r *jsonReporter) OnTask(task ProgressTask) {
+	r.write(Event{Kind: EventTask, Task: &task})
+}
+
+func (r *jsonReporter) OnStep(step ProgressStep) {
+	r.write(Event{Kind: EventStep, Step: &step})
+}
+
+func (r *jsonReporter) OnMessage(msg ProgressMessage) {
+	r.write(Event{Kind: EventMessage, Message: &msg})
+}
+
+func (r *jsonReporter) write(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(e)
+}
+
+// JSONDecoder reads events written by NewJSONReporter.
+type JSONDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// maxJSONLine bounds the length of a single encoded event.
+const maxJSONLine = 1 << 20
+
+// NewJSONDecoder returns a decoder reading JSON Lines events from r.
+func NewJSONDecoder(r io.Reader) *JSONDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLine)
+	return &JSONDecoder{scanner: scanner}
+}
+
+// Next returns the next event, or io.EOF at the end of the stream. Blank
+// lines are skipped.
+func (d *JSONDecoder) Next() (Event, error) {
+	for d.scanner.Scan() {
+		d.line++
+		data := d.scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			return Event{}, fmt.Errorf("progress event on line %d: %w", d.line, err)
+		}
+		return e, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// ReplayJSON reads the events written by NewJSONReporter from r and
+// delivers them to reporter in order, until the end of the stream.
+func ReplayJSON(r io.Reader, reporter ProgressReporter) error {
+	d := NewJSONDecoder(r)
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e.Dispatch(reporter)
+	}
+}
@@ -0,0 +1,85 @@
+package progress
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONReporter_RoundTrip(t *testing.T) {
+	var buf strings.Builder
+	helper := NewProgressHelper(NewJSONReporter(&buf), nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.TaskProgress(0, 250, 1000)
+	helper.BeginStep("Verify")
+	helper.Warning("Signature check skipped")
+	helper.EndStep()
+	helper.EndTask()
+	helper.EndAction()
+
+	if n := strings.Count(buf.String(), "\n"); n != 8 {
+		t.Fatalf("Expected 8 lines, got %d:\n%s", n, buf.String())
+	}
+
+	got := &capturingReporter{}
+	if err := ReplayJSON(strings.NewReader(buf.String()), got); err != nil {
+		t.Fatalf("ReplayJSON() error = %v", err)
+	}
+
+	if len(got.actions) != 2 || len(got.tasks) != 3 || len(got.steps) != 2 || len(got.messages) != 1 {
+		t.Fatalf("Unexpected event counts: %d actions, %d tasks, %d steps, %d messages",
+			len(got.actions), len(got.tasks), len(got.steps), len(got.messages))
+	}
+	if got.actions[0].Name != "Install" || got.tasks[0].ActionID != got.actions[0].ID {
+		t.Errorf("Unexpected action/task: %+v %+v", got.actions[0], got.tasks[0])
+	}
+	if c := got.tasks[1].Completion; c.Percent != 25 || c.BytesDone != 250 || c.BytesTotal != 1000 {
+		t.Errorf("Completion not preserved: %+v", c)
+	}
+	if got.messages[0].Severity != SeverityWarning || got.messages[0].StepID != got.steps[0].ID {
+		t.Errorf("Unexpected message: %+v", got.messages[0])
+	}
+}
+
+func TestJSONReporter_PreservesTimestamps(t *testing.T) {
+	var buf strings.Builder
+	action := ProgressAction{ID: "a1", Name: "Update", StartedAt: time.Now(), RunID: "run-1"}
+	NewJSONReporter(&buf).OnAction(action)
+
+	d := NewJSONDecoder(strings.NewReader(buf.String()))
+	e, err := d.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if e.Kind != EventAction || !e.Action.StartedAt.Equal(action.StartedAt) || e.Action.RunID != "run-1" {
+		t.Errorf("Unexpected event: %+v", e.Action)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestJSONDecoder_Errors(t *testing.T) {
+	d := NewJSONDecoder(strings.NewReader("\n{\"kind\":\"message\",\"message\":{\"Text\":\"hi\"}}\nnot json\n"))
+
+	e, err := d.Next()
+	if err != nil || e.Message == nil || e.Message.Text != "hi" {
+		t.Fatalf("Next() = %+v, %v", e, err)
+	}
+	if _, err := d.Next(); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error naming line 3, got %v", err)
+	}
+}
+
+func TestEvent_DispatchIgnoresMismatchedPayload(t *testing.T) {
+	r := &capturingReporter{}
+	Event{Kind: EventTask}.Dispatch(r)
+	Event{Kind: "unknown", Task: &ProgressTask{}}.Dispatch(r)
+	if !reflect.DeepEqual(r, &capturingReporter{}) {
+		t.Error("Expected no events to be dispatched")
+	}
+}
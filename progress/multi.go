@@ -0,0 +1,61 @@
+package progress
+
+import "sync"
+
+// multiReporter forwards every event to several reporters.
+type multiReporter struct {
+	reporters []ProgressReporter
+
+	cancelOnce sync.Once
+	cancel     <-chan struct{}
+}
+
+// Multi returns a ProgressReporter that forwards every event to each of
+// reporters in order, e.g. a log file, a UI and metrics. Nil reporters are
+// skipped.
+//
+// Multi is safe for concurrent use as long as the reporters are, as
+// ProgressReporter requires; wrap one with MakeThreadSafe if it is not.
+// Cancellation requested by any of the reporters is passed on.
+func Multi(reporters ...ProgressReporter) ProgressReporter {
+	m := &multiReporter{}
+	for _, r := range reporters {
+		if inner, ok := r.(*multiReporter); ok {
+			m.reporters = append(m.reporters, inner.reporters...)
+		} else if r != nil {
+			m.reporters = append(m.reporters, r)
+		}
+	}
+	return m
+}
+
+func (m *multiReporter) OnAction(action ProgressAction) {
+	for _, r := range m.reporters {
+		r.OnAction(action)
+	}
+}
+
+func (m *multiReporter) OnTask(task ProgressTask) {
+	for _, r := range m.reporters {
+		r.OnTask(task)
+	}
+}
+
+func (m *multiReporter) OnStep(step ProgressStep) {
+	for _, r := range m.reporters {
+		r.OnStep(step)
+	}
+}
+
+func (m *multiReporter) OnMessage(msg ProgressMessage) {
+	for _, r := range m.reporters {
+		r.OnMessage(msg)
+	}
+}
+
+// CancelRequested implements CancelRequester, firing when any of the
+// reporters requests cancellation.
+func (m *multiReporter) CancelRequested() <-chan struct{} {
+	m.cancelOnce.Do(func() { m.cancel = anyCancelRequest(m.reporters) })
+	return m.cancel
+}
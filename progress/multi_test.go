@@ -0,0 +1,50 @@
+package progress
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMulti(t *testing.T) {
+	a, b, c := &capturingReporter{}, &capturingReporter{}, &capturingReporter{}
+	helper := NewProgressHelper(Multi(a, nil, Multi(b, c)), nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.BeginStep("Verify")
+	helper.Info("ok")
+	helper.EndStep()
+	helper.EndTask()
+	helper.EndAction()
+
+	for i, r := range []*capturingReporter{a, b, c} {
+		if len(r.actions) != 2 || len(r.tasks) != 2 || len(r.steps) != 2 || len(r.messages) != 1 {
+			t.Errorf("reporter %d: got %d actions, %d tasks, %d steps, %d messages",
+				i, len(r.actions), len(r.tasks), len(r.steps), len(r.messages))
+		}
+	}
+	if a.actions[0].ID != c.actions[0].ID {
+		t.Error("Expected all reporters to receive the same events")
+	}
+}
+
+func TestMulti_Concurrent(t *testing.T) {
+	a, b := &capturingReporter{}, &capturingReporter{}
+	m := Multi(a, b)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.OnMessage(ProgressMessage{Text: "line"})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(a.messages) != 1000 || len(b.messages) != 1000 {
+		t.Errorf("got %d and %d messages, want 1000 each", len(a.messages), len(b.messages))
+	}
+}
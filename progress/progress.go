@@ -1,6 +1,9 @@
 package progress
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Severity represents the severity level of a progress message.
 type Severity string
@@ -35,32 +38,138 @@ type ProgressMessage struct {
 
 	// StepID is the optional associated step ID.
 	StepID string
+
+	// RunID is the optional correlation ID of the run that emitted the
+	// message.
+	RunID string
+
+	// Backend is the optional name of the backend that emitted the message
+	// (e.g. "flatpak").
+	Backend string `json:",omitempty"`
+
+	// Operation is the optional operation that emitted the message (e.g.
+	// "Install").
+	Operation string `json:",omitempty"`
 }
 
 // ProgressAction represents a high-level action in a long-running operation.
+//
+// Backend and Operation identify the source of an action and of its
+// tasks and steps; they are empty for events not emitted by a backend.
 type ProgressAction struct {
 	ID        string
 	Name      string
 	StartedAt time.Time
 	EndedAt   time.Time
+	RunID     string
+	Backend   string `json:",omitempty"`
+	Operation string `json:",omitempty"`
+
+	// Summary describes how the action went. It is set on the end event
+	// by reporters that know the outcome (pm sets it for every operation),
+	// and nil otherwise.
+	Summary *ActionSummary `json:",omitempty"`
+}
+
+// Outcome is how an action ended.
+type Outcome string
+
+const (
+	// OutcomeSuccess means the action completed without error.
+	OutcomeSuccess Outcome = "success"
+
+	// OutcomeFailure means the action failed.
+	OutcomeFailure Outcome = "failure"
+
+	// OutcomeCancelled means the action's context was cancelled or timed
+	// out.
+	OutcomeCancelled Outcome = "cancelled"
+)
+
+// ActionSummary is the final state of an action, so UIs can render it
+// without inspecting the operation's result.
+type ActionSummary struct {
+	Outcome Outcome
+
+	// PackagesChanged is the number of packages installed, upgraded or
+	// removed.
+	PackagesChanged int
+
+	// Duration is how long the action ran.
+	Duration time.Duration
+
+	// Error is the error message for failed and cancelled actions.
+	Error string `json:",omitempty"`
+}
+
+// String describes the summary, e.g. "2 packages changed in 3.1s" or
+// "failure after 1.2s: exit status 1".
+func (s ActionSummary) String() string {
+	d := s.Duration.Round(100 * time.Millisecond)
+	if s.Outcome != OutcomeSuccess {
+		if s.Error == "" {
+			return fmt.Sprintf("%s after %s", s.Outcome, d)
+		}
+		return fmt.Sprintf("%s after %s: %s", s.Outcome, d, s.Error)
+	}
+	switch s.PackagesChanged {
+	case 0:
+		return fmt.Sprintf("done in %s", d)
+	case 1:
+		return fmt.Sprintf("1 package changed in %s", d)
+	}
+	return fmt.Sprintf("%d packages changed in %s", s.PackagesChanged, d)
 }
 
 // ProgressTask represents a task within an action.
+//
+// A task is reported when it starts and when it ends, and in between each
+// time its completion changes; such updates have UpdatedAt set.
 type ProgressTask struct {
 	ID        string
 	ActionID  string
 	Name      string
 	StartedAt time.Time
 	EndedAt   time.Time
+	RunID     string
+	Backend   string `json:",omitempty"`
+	Operation string `json:",omitempty"`
+	Completion
 }
 
-// ProgressStep represents a step within a task.
+// ProgressStep represents a step within a task. Like tasks, steps may be
+// reported with completion updates while they run.
 type ProgressStep struct {
 	ID        string
 	TaskID    string
 	Name      string
 	StartedAt time.Time
 	EndedAt   time.Time
+	RunID     string
+	Backend   string `json:",omitempty"`
+	Operation string `json:",omitempty"`
+	Completion
+}
+
+// Completion describes how far a task or step has progressed. All fields
+// are optional: they stay zero until the underlying tool reports progress.
+type Completion struct {
+	// UpdatedAt is when the completion was last updated; zero on the
+	// start event and for tasks that never report progress.
+	UpdatedAt time.Time
+
+	// Percent is the completion from 0 to 100.
+	Percent float64
+
+	// BytesDone is the number of bytes transferred or processed so far.
+	BytesDone int64
+
+	// BytesTotal is the expected number of bytes, or zero if unknown.
+	BytesTotal int64
+
+	// Indeterminate is true when the work is known to be in progress but
+	// its completion cannot be measured.
+	Indeterminate bool
 }
 
 // ProgressReporter is the interface for receiving progress updates.
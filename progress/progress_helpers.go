@@ -1,6 +1,8 @@
 package progress
 
 import (
+	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +12,14 @@ import (
 // It tracks the current action/task/step context and handles ID generation.
 type ProgressHelper struct {
 	reporter      ProgressReporter
+	backend       string
+	operation     string
 	currentAction *ProgressAction
 	currentTask   *ProgressTask
 	currentStep   *ProgressStep
+
+	clock func() time.Time
+	newID func() string
 }
 
 // NewProgressHelper creates a new progress helper with progress reporting.
@@ -28,6 +35,60 @@ func NewProgressHelper(defaultReporter, overrideReporter ProgressReporter) *Prog
 	}
 }
 
+// SetSource sets the backend and operation stamped on every event the
+// helper emits from now on, so UIs showing several backends can tell their
+// events apart.
+func (h *ProgressHelper) SetSource(backend, operation string) {
+	h.backend = backend
+	h.operation = operation
+}
+
+// SetClock sets the function the helper reads event times from, instead of
+// time.Now, so tests can snapshot progress streams. A nil clock restores
+// time.Now.
+func (h *ProgressHelper) SetClock(clock func() time.Time) {
+	h.clock = clock
+}
+
+// SetIDGenerator sets the function the helper takes action, task and step
+// IDs from, instead of random UUIDs. A nil generator restores UUIDs.
+func (h *ProgressHelper) SetIDGenerator(newID func() string) {
+	h.newID = newID
+}
+
+// SequentialIDs returns an ID generator yielding prefix-1, prefix-2, and so
+// on. It is safe for concurrent use.
+func SequentialIDs(prefix string) func() string {
+	var n atomic.Uint64
+	return func() string {
+		return fmt.Sprintf("%s-%d", prefix, n.Add(1))
+	}
+}
+
+// StepClock returns a clock that reads start on its first call and
+// advances by step on every later one, for deterministic timestamps. It is
+// safe for concurrent use.
+func StepClock(start time.Time, step time.Duration) func() time.Time {
+	var n atomic.Int64
+	return func() time.Time {
+		return start.Add(time.Duration(n.Add(1)-1) * step)
+	}
+}
+
+func (h *ProgressHelper) now() time.Time {
+	if h.clock != nil {
+		return h.clock()
+	}
+	return time.Now()
+}
+
+func (h *ProgressHelper) id() string {
+	if h.newID != nil {
+		return h.newID()
+	}
+	return uuid.New().String()
+}
+
 // BeginAction starts a new action and returns its ID.
 func (h *ProgressHelper) BeginAction(name string) string {
 	if h.reporter == nil {
@@ -35,9 +96,11 @@ func (h *ProgressHelper) BeginAction(name string) string {
 	}
 
 	action := ProgressAction{
-		ID:        uuid.New().String(),
+		ID:        h.id(),
 		Name:      name,
-		StartedAt: time.Now(),
+		StartedAt: h.now(),
+		Backend:   h.backend,
+		Operation: h.operation,
 	}
 	h.currentAction = &action
 	h.reporter.OnAction(action)
@@ -50,7 +113,7 @@ func (h *ProgressHelper) EndAction() {
 		return
 	}
 
-	h.currentAction.EndedAt = time.Now()
+	h.currentAction.EndedAt = h.now()
 	h.reporter.OnAction(*h.currentAction)
 	h.currentAction = nil
 	h.currentTask = nil
@@ -69,10 +132,12 @@ func (h *ProgressHelper) BeginTask(name string) string {
 	}
 
 	task := ProgressTask{
-		ID:        uuid.New().String(),
+		ID:        h.id(),
 		ActionID:  actionID,
 		Name:      name,
-		StartedAt: time.Now(),
+		StartedAt: h.now(),
+		Backend:   h.backend,
+		Operation: h.operation,
 	}
 	h.currentTask = &task
 	h.reporter.OnTask(task)
@@ -85,7 +150,7 @@ func (h *ProgressHelper) EndTask() {
 		return
 	}
 
-	h.currentTask.EndedAt = time.Now()
+	h.currentTask.EndedAt = h.now()
 	h.reporter.OnTask(*h.currentTask)
 	h.currentTask = nil
 	h.currentStep = nil
@@ -103,10 +168,12 @@ func (h *ProgressHelper) BeginStep(name string) string {
 	}
 
 	step := ProgressStep{
-		ID:        uuid.New().String(),
+		ID:        h.id(),
 		TaskID:    taskID,
 		Name:      name,
-		StartedAt: time.Now(),
+		StartedAt: h.now(),
+		Backend:   h.backend,
+		Operation: h.operation,
 	}
 	h.currentStep = &step
 	h.reporter.OnStep(step)
@@ -119,11 +186,70 @@ func (h *ProgressHelper) EndStep() {
 		return
 	}
 
-	h.currentStep.EndedAt = time.Now()
+	h.currentStep.EndedAt = h.now()
 	h.reporter.OnStep(*h.currentStep)
 	h.currentStep = nil
 }
 
+// TaskProgress reports the completion of the current task. If percent is
+// zero and bytesTotal is known, the percentage is derived from the byte
+// counts; pass zero for unknown byte counts.
+func (h *ProgressHelper) TaskProgress(percent float64, bytesDone, bytesTotal int64) {
+	if h.reporter == nil || h.currentTask == nil {
+		return
+	}
+
+	h.currentTask.Completion = newCompletion(h.now(), percent, bytesDone, bytesTotal)
+	h.reporter.OnTask(*h.currentTask)
+}
+
+// TaskIndeterminate reports that the current task is making progress that
+// cannot be measured.
+func (h *ProgressHelper) TaskIndeterminate() {
+	if h.reporter == nil || h.currentTask == nil {
+		return
+	}
+
+	h.currentTask.Completion = Completion{UpdatedAt: h.now(), Indeterminate: true}
+	h.reporter.OnTask(*h.currentTask)
+}
+
+// StepProgress reports the completion of the current step, like
+// TaskProgress.
+func (h *ProgressHelper) StepProgress(percent float64, bytesDone, bytesTotal int64) {
+	if h.reporter == nil || h.currentStep == nil {
+		return
+	}
+
+	h.currentStep.Completion = newCompletion(h.now(), percent, bytesDone, bytesTotal)
+	h.reporter.OnStep(*h.currentStep)
+}
+
+// StepIndeterminate reports that the current step is making progress that
+// cannot be measured.
+func (h *ProgressHelper) StepIndeterminate() {
+	if h.reporter == nil || h.currentStep == nil {
+		return
+	}
+
+	h.currentStep.Completion = Completion{UpdatedAt: h.now(), Indeterminate: true}
+	h.reporter.OnStep(*h.currentStep)
+}
+
+// newCompletion builds a Completion, deriving the percentage from the byte
+// counts when it is not given and clamping it to 0-100.
+func newCompletion(at time.Time, percent float64, bytesDone, bytesTotal int64) Completion {
+	if percent == 0 && bytesTotal > 0 {
+		percent = float64(bytesDone) / float64(bytesTotal) * 100
+	}
+	return Completion{
+		UpdatedAt:  at,
+		Percent:    min(max(percent, 0), 100),
+		BytesDone:  bytesDone,
+		BytesTotal: bytesTotal,
+	}
+}
+
 // Info emits an informational message.
 func (h *ProgressHelper) Info(text string) {
 	h.message(SeverityInfo, text)
@@ -148,7 +274,9 @@ func (h *ProgressHelper) message(severity Severity, text string) {
 	msg := ProgressMessage{
 		Severity:  severity,
 		Text:      text,
-		Timestamp: time.Now(),
+		Timestamp: h.now(),
+		Backend:   h.backend,
+		Operation: h.operation,
 	}
 
 	if h.currentAction != nil {
@@ -368,3 +368,117 @@ func TestProgressHelper_OrphanedEvents(t *testing.T) {
 		}
 	})
 }
+
+func TestProgressHelper_Completion(t *testing.T) {
+	reporter := &capturingReporter{}
+	helper := NewProgressHelper(reporter, nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.TaskProgress(0, 512<<10, 2<<20)
+	helper.TaskProgress(150, 0, 0)
+	helper.BeginStep("Extract")
+	helper.StepIndeterminate()
+	helper.EndStep()
+	helper.EndTask()
+	helper.EndAction()
+
+	if len(reporter.tasks) != 4 {
+		t.Fatalf("Expected 4 task events (start, 2 updates, end), got %d", len(reporter.tasks))
+	}
+	if reporter.tasks[0].UpdatedAt != (time.Time{}) {
+		t.Error("Start event should not have UpdatedAt set")
+	}
+	first := reporter.tasks[1]
+	if first.Percent != 25 || first.BytesDone != 512<<10 || first.BytesTotal != 2<<20 || first.UpdatedAt.IsZero() {
+		t.Errorf("Unexpected first update: %+v", first.Completion)
+	}
+	if reporter.tasks[2].Percent != 100 {
+		t.Errorf("Expected percent clamped to 100, got %v", reporter.tasks[2].Percent)
+	}
+	if reporter.tasks[3].EndedAt.IsZero() || reporter.tasks[3].ID != first.ID {
+		t.Error("Expected end event for the same task")
+	}
+
+	if len(reporter.steps) != 3 || !reporter.steps[1].Indeterminate {
+		t.Errorf("Expected an indeterminate step update, got %+v", reporter.steps)
+	}
+}
+
+func TestProgressHelper_CompletionWithoutTask(t *testing.T) {
+	reporter := &capturingReporter{}
+	helper := NewProgressHelper(reporter, nil)
+
+	helper.TaskProgress(50, 0, 0)
+	helper.StepProgress(50, 0, 0)
+	helper.TaskIndeterminate()
+
+	if len(reporter.tasks) != 0 || len(reporter.steps) != 0 {
+		t.Error("Expected no events without a current task or step")
+	}
+	NewProgressHelper(nil, nil).TaskProgress(50, 0, 0)
+}
+
+func TestProgressHelper_Source(t *testing.T) {
+	reporter := &capturingReporter{}
+	helper := NewProgressHelper(reporter, nil)
+	helper.SetSource("flatpak", "Install")
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.BeginStep("Fetch")
+	helper.Info("fetching")
+	helper.EndStep()
+	helper.EndTask()
+	helper.EndAction()
+
+	for _, a := range reporter.actions {
+		if a.Backend != "flatpak" || a.Operation != "Install" {
+			t.Errorf("Action source = %q/%q, want flatpak/Install", a.Backend, a.Operation)
+		}
+	}
+	for _, task := range reporter.tasks {
+		if task.Backend != "flatpak" || task.Operation != "Install" {
+			t.Errorf("Task source = %q/%q, want flatpak/Install", task.Backend, task.Operation)
+		}
+	}
+	for _, s := range reporter.steps {
+		if s.Backend != "flatpak" || s.Operation != "Install" {
+			t.Errorf("Step source = %q/%q, want flatpak/Install", s.Backend, s.Operation)
+		}
+	}
+	if len(reporter.messages) != 1 || reporter.messages[0].Backend != "flatpak" || reporter.messages[0].Operation != "Install" {
+		t.Errorf("Expected a message stamped with the source, got %+v", reporter.messages)
+	}
+}
+
+func TestProgressHelper_ClockAndIDs(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	reporter := &capturingReporter{}
+	h := NewProgressHelper(reporter, nil)
+	h.SetClock(StepClock(start, time.Second))
+	h.SetIDGenerator(SequentialIDs("id"))
+
+	h.BeginAction("Install")
+	h.BeginTask("Download")
+	h.TaskProgress(50, 0, 0)
+	h.Info("halfway")
+	h.EndTask()
+	h.EndAction()
+
+	if len(reporter.actions) != 2 || reporter.actions[1].ID != "id-1" {
+		t.Fatalf("actions = %+v", reporter.actions)
+	}
+	if got := reporter.actions[1].EndedAt.Sub(reporter.actions[1].StartedAt); got != 5*time.Second {
+		t.Errorf("Action duration = %v, want 5s", got)
+	}
+	if task := reporter.tasks[0]; task.ID != "id-2" || task.ActionID != "id-1" || !task.StartedAt.Equal(start.Add(time.Second)) {
+		t.Errorf("task = %+v", task)
+	}
+	if got := reporter.tasks[1].Completion.UpdatedAt; !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Completion.UpdatedAt = %v", got)
+	}
+	if msg := reporter.messages[0]; msg.TaskID != "id-2" || !msg.Timestamp.Equal(start.Add(3*time.Second)) {
+		t.Errorf("message = %+v", msg)
+	}
+}
@@ -50,6 +50,11 @@ func (t *threadSafeProgressReporter) OnMessage(msg ProgressMessage) {
 	t.reporter.OnMessage(msg)
 }
 
+// CancelRequested passes on cancellation requested by the wrapped reporter.
+func (t *threadSafeProgressReporter) CancelRequested() <-chan struct{} {
+	return CancelRequests(t.reporter)
+}
+
 // MakeThreadSafe wraps a ProgressReporter to make it safe for concurrent use.
 // If the reporter is already known to be thread-safe, this is unnecessary.
 func MakeThreadSafe(p ProgressReporter) ProgressReporter {
@@ -0,0 +1,78 @@
+package progress
+
+import "sync"
+
+// subscriptionBuffer is how many events a Subscription holds before the
+// reporting side waits for the consumer.
+const subscriptionBuffer = 64
+
+// Subscription is a ProgressReporter that delivers events on a channel,
+// for consumers built around select loops (TUIs, servers) instead of
+// callbacks.
+//
+// Events are never dropped: once the buffer is full, the operation
+// reporting progress waits until the consumer catches up or the
+// subscription is closed.
+type Subscription struct {
+	events chan Event
+	done   chan struct{}
+	mu     sync.RWMutex
+	once   sync.Once
+}
+
+// Subscribe returns a new Subscription. Pass it as the ProgressReporter of
+// an operation and receive from Events; call Close when done.
+func Subscribe() *Subscription {
+	return &Subscription{
+		events: make(chan Event, subscriptionBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed by
+// Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops delivery and closes the Events channel. Events reported
+// after Close are discarded. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		// Wait for senders unblocked by done before closing the channel.
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		close(s.events)
+	})
+}
+
+func (s *Subscription) OnAction(action ProgressAction) {
+	s.send(Event{Kind: EventAction, Action: &action})
+}
+
+func (s *Subscription) OnTask(task ProgressTask) {
+	s.send(Event{Kind: EventTask, Task: &task})
+}
+
+func (s *Subscription) OnStep(step ProgressStep) {
+	s.send(Event{Kind: EventStep, Step: &step})
+}
+
+func (s *Subscription) OnMessage(msg ProgressMessage) {
+	s.send(Event{Kind: EventMessage, Message: &msg})
+}
+
+func (s *Subscription) send(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	select {
+	case s.events <- e:
+	case <-s.done:
+	}
+}
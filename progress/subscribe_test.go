@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	sub := Subscribe()
+
+	go func() {
+		helper := NewProgressHelper(sub, nil)
+		helper.BeginAction("Install")
+		helper.BeginTask("Download")
+		helper.Info("Fetching")
+		helper.EndTask()
+		helper.EndAction()
+		sub.Close()
+	}()
+
+	var kinds []EventKind
+	for e := range sub.Events() {
+		kinds = append(kinds, e.Kind)
+	}
+
+	want := []EventKind{EventAction, EventTask, EventMessage, EventTask, EventAction}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("kinds = %v, want %v", kinds, want)
+			break
+		}
+	}
+}
+
+func TestSubscribe_CloseUnblocksReporters(t *testing.T) {
+	sub := Subscribe()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2*subscriptionBuffer; j++ {
+				sub.OnMessage(ProgressMessage{Text: "line"})
+			}
+		}()
+	}
+
+	// Nobody reads: reporters fill the buffer and block until Close.
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+	sub.Close()
+
+	finished := make(chan struct{})
+	go func() { wg.Wait(); close(finished) }()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("reporters still blocked after Close")
+	}
+
+	sub.OnAction(ProgressAction{Name: "after close"})
+	n := 0
+	for range sub.Events() {
+		n++
+	}
+	if n > subscriptionBuffer {
+		t.Errorf("received %d events, want at most the buffer size", n)
+	}
+}
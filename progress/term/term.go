@@ -0,0 +1,393 @@
+// Package term renders progress events on a terminal, with spinners and
+// progress bars for running actions, tasks and steps.
+//
+// On a TTY, running work is shown in a live region at the bottom of the
+// screen that is redrawn in place; finished work, warnings and errors
+// scroll above it. Elsewhere (pipes, files, TERM=dumb), events are written
+// as plain lines by progress.NewWriterReporter.
+package term
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/frostyard/pm/progress"
+)
+
+// spinnerFrames are drawn in turn for running work.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const (
+	// defaultWidth is used when the terminal width is unknown.
+	defaultWidth = 80
+
+	// barWidth is the number of cells in a progress bar.
+	barWidth = 20
+
+	// refreshInterval is how often spinners advance.
+	refreshInterval = 100 * time.Millisecond
+)
+
+// Options configures NewWithOptions.
+type Options struct {
+	// Interactive forces the live display on (true) or off (false). If
+	// nil, it is used when the writer is a terminal and TERM is not "dumb".
+	Interactive *bool
+
+	// Width is the terminal width in columns. If zero, $COLUMNS is used,
+	// or 80.
+	Width int
+}
+
+// Reporter is a progress.ProgressReporter that renders to a terminal. It is
+// safe for concurrent use. Call Close when the operations it reports on are
+// done, to stop the spinner and clear the live region.
+type Reporter struct {
+	plain progress.ProgressReporter
+
+	mu     sync.Mutex
+	w      io.Writer
+	width  int
+	frame  int
+	drawn  int
+	nodes  []*node
+	status string
+	est    *progress.Estimator
+	stop   chan struct{}
+	closed bool
+}
+
+// node is a running action, task or step.
+type node struct {
+	id, parent string
+	level      int
+	name       string
+	started    time.Time
+	completion progress.Completion
+	estimate   progress.Estimate
+	failed     bool
+	summary    *progress.ActionSummary
+}
+
+// New returns a Reporter writing to w, with the live display enabled when w
+// is a terminal.
+func New(w io.Writer) *Reporter {
+	return NewWithOptions(w, Options{})
+}
+
+// NewWithOptions returns a Reporter writing to w configured by opts.
+func NewWithOptions(w io.Writer, opts Options) *Reporter {
+	interactive := isTerminal(w)
+	if opts.Interactive != nil {
+		interactive = *opts.Interactive
+	}
+	if !interactive {
+		return &Reporter{plain: progress.NewWriterReporter(w, progress.WriterOptions{Completion: true})}
+	}
+
+	width := opts.Width
+	if width <= 0 {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if width < 20 {
+		width = defaultWidth
+	}
+	return &Reporter{w: w, width: width, est: progress.NewEstimator()}
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Close stops the spinner and erases the live region. Events reported
+// after Close are written as if the display were not interactive.
+func (r *Reporter) Close() {
+	if r.plain != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.stopTicker()
+	r.nodes = nil
+	r.redraw()
+	r.plain = progress.NewWriterReporter(r.w, progress.WriterOptions{Completion: true})
+}
+
+func (r *Reporter) OnAction(action progress.ProgressAction) {
+	if r.delegate(func(p progress.ProgressReporter) { p.OnAction(action) }) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if action.EndedAt.IsZero() {
+		r.add(&node{id: action.ID, name: action.Name, started: action.StartedAt})
+		return
+	}
+	if n := r.find(action.ID); n != nil && action.Summary != nil {
+		n.summary = action.Summary
+	}
+	r.finish(action.ID, action.EndedAt)
+}
+
+func (r *Reporter) OnTask(task progress.ProgressTask) {
+	if r.delegate(func(p progress.ProgressReporter) { p.OnTask(task) }) {
+		return
+	}
+	estimate := r.est.Task(task)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onNode(task.ID, task.ActionID, 1, task.Name, task.StartedAt, task.EndedAt, task.Completion, estimate)
+}
+
+func (r *Reporter) OnStep(step progress.ProgressStep) {
+	if r.delegate(func(p progress.ProgressReporter) { p.OnStep(step) }) {
+		return
+	}
+	estimate := r.est.Step(step)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onNode(step.ID, step.TaskID, 2, step.Name, step.StartedAt, step.EndedAt, step.Completion, estimate)
+}
+
+func (r *Reporter) OnMessage(msg progress.ProgressMessage) {
+	if r.delegate(func(p progress.ProgressReporter) { p.OnMessage(msg) }) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch msg.Severity {
+	case progress.SeverityInfo:
+		// Command output changes too quickly to keep; show the latest line
+		// under the running work instead.
+		r.status = msg.Text
+		r.redraw()
+	case progress.SeverityWarning:
+		r.redraw("    ⚠ " + msg.Text)
+	default:
+		for _, id := range []string{msg.StepID, msg.TaskID, msg.ActionID} {
+			if n := r.find(id); n != nil {
+				n.failed = true
+			}
+		}
+		r.redraw("    ✗ " + msg.Text)
+	}
+}
+
+// delegate passes an event to the plain reporter, if the display is not
+// interactive, and reports whether it did.
+func (r *Reporter) delegate(fn func(progress.ProgressReporter)) bool {
+	r.mu.Lock()
+	plain := r.plain
+	r.mu.Unlock()
+	if plain == nil {
+		return false
+	}
+	fn(plain)
+	return true
+}
+
+// onNode handles a task or step event. The caller must hold mu.
+func (r *Reporter) onNode(id, parent string, level int, name string, started, ended time.Time, c progress.Completion, est progress.Estimate) {
+	switch {
+	case !ended.IsZero():
+		r.finish(id, ended)
+	case c.UpdatedAt.IsZero():
+		r.add(&node{id: id, parent: parent, level: level, name: name, started: started})
+	default:
+		if n := r.find(id); n != nil {
+			n.completion, n.estimate = c, est
+			r.redraw()
+		}
+	}
+}
+
+// add starts displaying n. The caller must hold mu.
+func (r *Reporter) add(n *node) {
+	r.nodes = append(r.nodes, n)
+	r.startTicker()
+	r.redraw()
+}
+
+// finish removes the node with id and its children from the live region
+// and prints it as done. The caller must hold mu.
+func (r *Reporter) finish(id string, ended time.Time) {
+	n := r.find(id)
+	if n == nil {
+		return
+	}
+	removed := map[string]bool{id: true}
+	kept := r.nodes[:0]
+	for _, m := range r.nodes {
+		if removed[m.parent] {
+			removed[m.id] = true
+			continue
+		}
+		if m.id != id {
+			kept = append(kept, m)
+		}
+	}
+	r.nodes = kept
+	if len(r.nodes) == 0 {
+		r.status = ""
+		r.stopTicker()
+	}
+
+	mark := "✓"
+	if n.failed {
+		mark = "✗"
+	}
+	line := fmt.Sprintf("%s%s %s", indent(n.level), mark, n.name)
+	switch {
+	case n.summary != nil:
+		line = fmt.Sprintf("%s%s %s: %s", indent(n.level), outcomeMark(n.summary.Outcome), n.name, n.summary)
+	case !n.started.IsZero() && ended.After(n.started):
+		line += fmt.Sprintf(" (%s)", ended.Sub(n.started).Round(100*time.Millisecond))
+	}
+	r.redraw(line)
+}
+
+// find returns the running node with id, or nil. The caller must hold mu.
+func (r *Reporter) find(id string) *node {
+	if id == "" {
+		return nil
+	}
+	for _, n := range r.nodes {
+		if n.id == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// redraw erases the live region, prints lines above it, and draws it
+// again. The caller must hold mu.
+func (r *Reporter) redraw(lines ...string) {
+	var b strings.Builder
+	if r.drawn > 0 {
+		fmt.Fprintf(&b, "\r\x1b[%dA\x1b[J", r.drawn)
+	}
+	for _, line := range lines {
+		b.WriteString(r.fit(line) + "\n")
+	}
+
+	r.drawn = 0
+	spinner := spinnerFrames[r.frame%len(spinnerFrames)]
+	for _, n := range r.nodes {
+		b.WriteString(r.fit(indent(n.level)+spinner+" "+n.name+describe(n)) + "\n")
+		r.drawn++
+	}
+	if r.status != "" && len(r.nodes) > 0 {
+		b.WriteString(r.fit("    "+r.status) + "\n")
+		r.drawn++
+	}
+	_, _ = io.WriteString(r.w, b.String())
+}
+
+// fit truncates line to the terminal width, so each line takes exactly one
+// row and the region can be erased reliably.
+func (r *Reporter) fit(line string) string {
+	if utf8.RuneCountInString(line) < r.width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:r.width-2]) + "…"
+}
+
+// startTicker starts advancing the spinner. The caller must hold mu.
+func (r *Reporter) startTicker() {
+	if r.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	r.stop = stop
+	go func() {
+		t := time.NewTicker(refreshInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				r.mu.Lock()
+				r.frame++
+				r.redraw()
+				r.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// stopTicker stops the spinner. The caller must hold mu.
+func (r *Reporter) stopTicker() {
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// describe renders a node's completion: a bar with percentage, byte
+// counts, rate and ETA, as far as they are known.
+func describe(n *node) string {
+	c := n.completion
+	if c.UpdatedAt.IsZero() || c.Indeterminate {
+		return ""
+	}
+	filled := int(c.Percent / 100 * barWidth)
+	s := fmt.Sprintf("  [%s%s] %3.0f%%", strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled), c.Percent)
+	if c.BytesTotal > 0 {
+		s += fmt.Sprintf("  %s / %s", formatBytes(c.BytesDone), formatBytes(c.BytesTotal))
+	}
+	if e := n.estimate; e.Known {
+		if e.BytesPerSecond > 0 {
+			s += fmt.Sprintf("  %s/s", formatBytes(int64(e.BytesPerSecond)))
+		}
+		s += fmt.Sprintf("  ETA %s", e.Remaining.Round(time.Second))
+	}
+	return s
+}
+
+// outcomeMark returns the symbol for an action outcome.
+func outcomeMark(o progress.Outcome) string {
+	switch o {
+	case progress.OutcomeSuccess:
+		return "✓"
+	case progress.OutcomeCancelled:
+		return "⊘"
+	}
+	return "✗"
+}
+
+// indent returns the indentation for a nesting level.
+func indent(level int) string {
+	return strings.Repeat("  ", level)
+}
+
+// formatBytes renders n with a decimal unit, e.g. "12.3 MB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,141 @@
+package term
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/progress"
+)
+
+// syncBuffer is a strings.Builder safe for use by the spinner goroutine.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+var escapes = regexp.MustCompile(`\r?\x1b\[[0-9]*[AJ]`)
+
+func TestReporter_Interactive(t *testing.T) {
+	var out syncBuffer
+	on := true
+	r := NewWithOptions(&out, Options{Interactive: &on, Width: 100})
+	defer r.Close()
+	helper := progress.NewProgressHelper(r, nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.Info("Fetching org.gimp.GIMP")
+	helper.TaskProgress(0, 5_000_000, 20_000_000)
+
+	screen := out.String()
+	if !strings.Contains(screen, "Fetching org.gimp.GIMP") {
+		t.Errorf("Expected status line in output:\n%s", screen)
+	}
+	if !strings.Contains(screen, "[█████░░░░░░░░░░░░░░░]  25%  5.0 MB / 20.0 MB") {
+		t.Errorf("Expected progress bar in output:\n%s", screen)
+	}
+
+	helper.Warning("mirror is slow")
+	helper.EndTask()
+	helper.Error("post-install hook failed")
+	helper.EndAction()
+
+	// The final redraw leaves only permanent lines after the last erase.
+	final := out.String()
+	final = final[strings.LastIndex(final, "\x1b[J")+len("\x1b[J"):]
+	if !regexp.MustCompile(`^✗ Install( \(.*\))?\n$`).MatchString(final) {
+		t.Errorf("Expected failed action as last line, got %q", final)
+	}
+	plain := escapes.ReplaceAllString(out.String(), "")
+	for _, want := range []string{"    ⚠ mirror is slow", "  ✓ Download", "    ✗ post-install hook failed"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("Expected %q in output:\n%s", want, plain)
+		}
+	}
+}
+
+func TestReporter_Summary(t *testing.T) {
+	var out syncBuffer
+	on := true
+	r := NewWithOptions(&out, Options{Interactive: &on})
+	defer r.Close()
+	start := time.Now()
+
+	r.OnAction(progress.ProgressAction{ID: "a", Name: "Install", StartedAt: start})
+	r.OnAction(progress.ProgressAction{ID: "a", Name: "Install", StartedAt: start, EndedAt: start.Add(time.Second),
+		Summary: &progress.ActionSummary{Outcome: progress.OutcomeSuccess, PackagesChanged: 2, Duration: time.Second}})
+
+	if !strings.HasSuffix(out.String(), "✓ Install: 2 packages changed in 1s\n") {
+		t.Errorf("Expected summary line, got %q", out.String())
+	}
+}
+
+func TestReporter_Spinner(t *testing.T) {
+	var out syncBuffer
+	on := true
+	r := NewWithOptions(&out, Options{Interactive: &on})
+	r.OnAction(progress.ProgressAction{ID: "a", Name: "Update", StartedAt: time.Now()})
+
+	time.Sleep(3 * refreshInterval)
+	r.Close()
+
+	frames := 0
+	for _, f := range spinnerFrames {
+		if strings.Contains(out.String(), f+" Update") {
+			frames++
+		}
+	}
+	if frames < 2 {
+		t.Errorf("Expected the spinner to advance, saw %d frames", frames)
+	}
+
+	// After Close, events are written as plain lines.
+	before := len(out.String())
+	r.OnAction(progress.ProgressAction{ID: "b", Name: "Upgrade"})
+	if got := out.String()[before:]; got != "→ Upgrade\n" {
+		t.Errorf("Expected plain output after Close, got %q", got)
+	}
+}
+
+func TestReporter_Plain(t *testing.T) {
+	var out strings.Builder
+	r := New(&out)
+	defer r.Close()
+	helper := progress.NewProgressHelper(r, nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	helper.TaskProgress(50, 0, 0)
+	helper.EndTask()
+	helper.EndAction()
+
+	want := "→ Install\n  • Download\n    50%\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestReporter_Fit(t *testing.T) {
+	r := &Reporter{width: 20}
+	if got := r.fit(strings.Repeat("x", 30)); len([]rune(got)) != 19 || !strings.HasSuffix(got, "…") {
+		t.Errorf("fit() = %q, want 19 runes ending in an ellipsis", got)
+	}
+	if got := r.fit("short"); got != "short" {
+		t.Errorf("fit() = %q, want unchanged", got)
+	}
+}
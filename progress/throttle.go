@@ -0,0 +1,123 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// throttle coalesces completion updates of tasks and steps.
+type throttle struct {
+	r        ProgressReporter
+	interval time.Duration
+
+	mu      sync.Mutex
+	last    map[string]time.Time
+	pending map[string]*pendingUpdate
+}
+
+// pendingUpdate is the latest held-back update of one task or step,
+// delivered when its timer fires unless a newer event supersedes it.
+type pendingUpdate struct {
+	timer   *time.Timer
+	deliver func()
+}
+
+// Throttle returns a ProgressReporter that forwards completion updates of
+// each task and step to r at most once per minInterval. Updates arriving
+// faster are coalesced: only the most recent one is delivered, at the end
+// of the interval. Actions, messages, and the start and end events of
+// tasks and steps are always forwarded immediately, and an end event
+// discards any update still held back for it.
+//
+// Events are forwarded to r one at a time. If minInterval is zero or
+// less, r is returned unchanged.
+func Throttle(r ProgressReporter, minInterval time.Duration) ProgressReporter {
+	if minInterval <= 0 {
+		return getProgressReporter(r)
+	}
+	return &throttle{
+		r:        getProgressReporter(r),
+		interval: minInterval,
+		last:     make(map[string]time.Time),
+		pending:  make(map[string]*pendingUpdate),
+	}
+}
+
+func (t *throttle) OnAction(action ProgressAction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.r.OnAction(action)
+}
+
+func (t *throttle) OnTask(task ProgressTask) {
+	t.event("task:"+task.ID, isUpdate(task.Completion, task.EndedAt), !task.EndedAt.IsZero(), func() {
+		t.r.OnTask(task)
+	})
+}
+
+func (t *throttle) OnStep(step ProgressStep) {
+	t.event("step:"+step.ID, isUpdate(step.Completion, step.EndedAt), !step.EndedAt.IsZero(), func() {
+		t.r.OnStep(step)
+	})
+}
+
+func (t *throttle) OnMessage(msg ProgressMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.r.OnMessage(msg)
+}
+
+// CancelRequested passes on cancellation requested by the wrapped reporter.
+func (t *throttle) CancelRequested() <-chan struct{} {
+	return CancelRequests(t.r)
+}
+
+// event delivers or holds back one task or step event identified by key.
+func (t *throttle) event(key string, update, ended bool, deliver func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !update {
+		if p, ok := t.pending[key]; ok {
+			p.timer.Stop()
+			delete(t.pending, key)
+		}
+		if ended {
+			delete(t.last, key)
+		}
+		deliver()
+		return
+	}
+
+	if p, ok := t.pending[key]; ok {
+		p.deliver = deliver
+		return
+	}
+	wait := t.interval - time.Since(t.last[key])
+	if wait <= 0 {
+		t.last[key] = time.Now()
+		deliver()
+		return
+	}
+	p := &pendingUpdate{deliver: deliver}
+	p.timer = time.AfterFunc(wait, func() { t.flush(key, p) })
+	t.pending[key] = p
+}
+
+// flush delivers p if it is still the pending update for key.
+func (t *throttle) flush(key string, p *pendingUpdate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending[key] != p {
+		return
+	}
+	delete(t.pending, key)
+	t.last[key] = time.Now()
+	p.deliver()
+}
+
+// isUpdate reports whether an event is a completion update rather than a
+// start or end event.
+func isUpdate(c Completion, endedAt time.Time) bool {
+	return !c.UpdatedAt.IsZero() && endedAt.IsZero()
+}
@@ -0,0 +1,67 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	r := &capturingReporter{}
+	helper := NewProgressHelper(Throttle(r, time.Hour), nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Download")
+	for i := 1; i <= 100; i++ {
+		helper.TaskProgress(float64(i), 0, 0)
+	}
+	helper.Error("mirror failed")
+	helper.EndTask()
+	helper.EndAction()
+
+	// Start, the first update, and the end; the other 99 updates are
+	// coalesced and then superseded by the end event.
+	if len(r.tasks) != 3 {
+		t.Fatalf("Expected 3 task events, got %d", len(r.tasks))
+	}
+	if r.tasks[1].Percent != 1 || r.tasks[2].EndedAt.IsZero() {
+		t.Errorf("Unexpected task events: %+v", r.tasks)
+	}
+	if len(r.actions) != 2 || len(r.messages) != 1 {
+		t.Errorf("Expected actions and messages to pass through, got %d actions, %d messages", len(r.actions), len(r.messages))
+	}
+}
+
+func TestThrottle_TrailingUpdate(t *testing.T) {
+	r := &capturingReporter{}
+	helper := NewProgressHelper(Throttle(r, 20*time.Millisecond), nil)
+
+	helper.BeginTask("Download")
+	helper.BeginStep("Fetch")
+	helper.StepProgress(10, 0, 0)
+	helper.StepProgress(20, 0, 0)
+	helper.StepProgress(30, 0, 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.mu.Lock()
+		n := len(r.steps)
+		r.mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.steps) != 3 || r.steps[1].Percent != 10 || r.steps[2].Percent != 30 {
+		t.Errorf("Expected the first and the latest update, got %+v", r.steps)
+	}
+}
+
+func TestThrottle_Disabled(t *testing.T) {
+	r := &capturingReporter{}
+	if Throttle(r, 0) != ProgressReporter(r) {
+		t.Error("Expected Throttle with no interval to return the reporter")
+	}
+}
@@ -0,0 +1,148 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriterOptions configures NewWriterReporter.
+type WriterOptions struct {
+	// Timestamps prefixes every line with the event time (15:04:05).
+	Timestamps bool
+
+	// Quiet suppresses informational messages; warnings and errors, and
+	// the action/task/step structure, are still written.
+	Quiet bool
+
+	// Completion writes a line for task and step completion updates each
+	// time they advance by at least ten percent.
+	Completion bool
+}
+
+// writerReporter renders progress events as indented lines.
+type writerReporter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	opts WriterOptions
+	// shown is the last completion decile written per task or step ID.
+	shown map[string]int
+}
+
+// NewWriterReporter returns a ProgressReporter that writes events to w as
+// human-readable lines, indented by level:
+//
+//	→ Install
+//	  • Running flatpak install
+//	    ℹ Installing org.gimp.GIMP
+//
+// Only starts are written for tasks and steps, and for actions also their
+// end if it carries an ActionSummary. The reporter is safe for concurrent
+// use; write errors are ignored.
+func NewWriterReporter(w io.Writer, opts WriterOptions) ProgressReporter {
+	return &writerReporter{w: w, opts: opts, shown: make(map[string]int)}
+}
+
+func (r *writerReporter) OnAction(action ProgressAction) {
+	switch {
+	case action.EndedAt.IsZero():
+		r.printf(action.StartedAt, 0, "→ %s", action.Name)
+	case action.Summary != nil:
+		r.printf(action.EndedAt, 0, "%s %s: %s", outcomeMark(action.Summary.Outcome), action.Name, action.Summary)
+	}
+}
+
+// outcomeMark returns the symbol for an action outcome.
+func outcomeMark(o Outcome) string {
+	switch o {
+	case OutcomeSuccess:
+		return "✓"
+	case OutcomeCancelled:
+		return "⊘"
+	}
+	return "✗"
+}
+
+func (r *writerReporter) OnTask(task ProgressTask) {
+	r.node(task.ID, task.Name, 1, "•", task.StartedAt, task.EndedAt, task.Completion)
+}
+
+func (r *writerReporter) OnStep(step ProgressStep) {
+	r.node(step.ID, step.Name, 2, "-", step.StartedAt, step.EndedAt, step.Completion)
+}
+
+// node writes the start of a task or step, or a completion update.
+func (r *writerReporter) node(id, name string, level int, bullet string, started, ended time.Time, c Completion) {
+	switch {
+	case !ended.IsZero():
+		r.mu.Lock()
+		delete(r.shown, id)
+		r.mu.Unlock()
+	case c.UpdatedAt.IsZero():
+		r.printf(started, level, "%s %s", bullet, name)
+	case r.opts.Completion && !c.Indeterminate:
+		decile := int(c.Percent) / 10
+		r.mu.Lock()
+		last, seen := r.shown[id]
+		r.shown[id] = decile
+		r.mu.Unlock()
+		if seen && decile <= last {
+			return
+		}
+		r.printf(c.UpdatedAt, level+1, "%s", formatCompletion(c))
+	}
+}
+
+func (r *writerReporter) OnMessage(msg ProgressMessage) {
+	prefix := "ℹ"
+	switch msg.Severity {
+	case SeverityInfo:
+		if r.opts.Quiet {
+			return
+		}
+	case SeverityWarning:
+		prefix = "⚠"
+	case SeverityError:
+		prefix = "✗"
+	}
+	r.printf(msg.Timestamp, 2, "%s %s", prefix, msg.Text)
+}
+
+func (r *writerReporter) printf(at time.Time, level int, format string, args ...interface{}) {
+	line := strings.Repeat("  ", level) + fmt.Sprintf(format, args...)
+	if r.opts.Timestamps {
+		if at.IsZero() {
+			at = time.Now()
+		}
+		line = at.Format("15:04:05") + " " + line
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = fmt.Fprintln(r.w, line)
+}
+
+// formatCompletion renders a completion as "45%" or "45% (12.3 MB / 27.3 MB)".
+func formatCompletion(c Completion) string {
+	s := fmt.Sprintf("%.0f%%", c.Percent)
+	if c.BytesTotal > 0 {
+		s += fmt.Sprintf(" (%s / %s)", formatBytes(c.BytesDone), formatBytes(c.BytesTotal))
+	}
+	return s
+}
+
+// formatBytes renders n with a decimal unit, e.g. "12.3 MB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,104 @@
+package progress
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterReporter(t *testing.T) {
+	var buf strings.Builder
+	helper := NewProgressHelper(NewWriterReporter(&buf, WriterOptions{}), nil)
+
+	helper.BeginAction("Install")
+	helper.BeginTask("Running flatpak install")
+	helper.Info("Installing org.gimp.GIMP")
+	helper.TaskProgress(50, 0, 0)
+	helper.BeginStep("Verify")
+	helper.Warning("Signature check skipped")
+	helper.EndStep()
+	helper.Error("Install failed")
+	helper.EndTask()
+	helper.EndAction()
+
+	want := `→ Install
+  • Running flatpak install
+    ℹ Installing org.gimp.GIMP
+    - Verify
+    ⚠ Signature check skipped
+    ✗ Install failed
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriterReporter_Options(t *testing.T) {
+	var buf strings.Builder
+	r := NewWriterReporter(&buf, WriterOptions{Quiet: true, Completion: true, Timestamps: true})
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	task := ProgressTask{ID: "t", Name: "Download", StartedAt: at}
+	r.OnTask(task)
+	r.OnMessage(ProgressMessage{Severity: SeverityInfo, Text: "hidden", Timestamp: at})
+	for _, done := range []int64{100, 150, 500} {
+		task.Completion = Completion{UpdatedAt: at, Percent: float64(done) / 10, BytesDone: done * 1000, BytesTotal: 1000000}
+		r.OnTask(task)
+	}
+
+	want := `15:04:05   • Download
+15:04:05     10% (100.0 kB / 1.0 MB)
+15:04:05     50% (500.0 kB / 1.0 MB)
+`
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:             "0 B",
+		999:           "999 B",
+		1000:          "1.0 kB",
+		12_300_000:    "12.3 MB",
+		2_500_000_000: "2.5 GB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestWriterReporter_Summary(t *testing.T) {
+	var buf strings.Builder
+	r := NewWriterReporter(&buf, WriterOptions{})
+	start := time.Now()
+
+	r.OnAction(ProgressAction{ID: "a", Name: "Install", StartedAt: start})
+	r.OnAction(ProgressAction{ID: "a", Name: "Install", StartedAt: start, EndedAt: start.Add(time.Second)})
+	r.OnAction(ProgressAction{ID: "b", Name: "Install", StartedAt: start, EndedAt: start.Add(1500 * time.Millisecond),
+		Summary: &ActionSummary{Outcome: OutcomeFailure, Duration: 1500 * time.Millisecond, Error: "exit status 1"}})
+
+	want := "→ Install\n✗ Install: failure after 1.5s: exit status 1\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestActionSummary_String(t *testing.T) {
+	tests := []struct {
+		s    ActionSummary
+		want string
+	}{
+		{ActionSummary{Outcome: OutcomeSuccess, Duration: 3140 * time.Millisecond}, "done in 3.1s"},
+		{ActionSummary{Outcome: OutcomeSuccess, PackagesChanged: 1, Duration: time.Second}, "1 package changed in 1s"},
+		{ActionSummary{Outcome: OutcomeSuccess, PackagesChanged: 2, Duration: time.Second}, "2 packages changed in 1s"},
+		{ActionSummary{Outcome: OutcomeCancelled, Duration: time.Second}, "cancelled after 1s"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package pm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextWithProgress(t *testing.T) {
+	if pr := ProgressFromContext(context.Background()); pr != nil {
+		t.Errorf("ProgressFromContext() = %v, want nil", pr)
+	}
+
+	tests := []struct {
+		name      string
+		construct bool
+		override  bool
+		wantCtx   bool
+	}{
+		{name: "context only", wantCtx: true},
+		{name: "constructor wins", construct: true},
+		{name: "options win", override: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromCtx, other := &runIDCollector{}, &runIDCollector{}
+			cfg := &backendConfig{}
+			if tt.construct {
+				cfg.progress = other
+			}
+			opts := InstallOptions{}
+			if tt.override {
+				opts.Progress = other
+			}
+			mgr := Wrap(newBackendAdapter(BackendBrew, &progressBackend{}, cfg))
+
+			ctx := ContextWithProgress(context.Background(), fromCtx)
+			if _, err := mgr.Install(ctx, []PackageRef{{Name: "git"}}, opts); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if got := len(fromCtx.ids) > 0; got != tt.wantCtx {
+				t.Errorf("context reporter received %d events, want some: %t", len(fromCtx.ids), tt.wantCtx)
+			}
+			if got := len(other.ids) > 0; got == tt.wantCtx {
+				t.Errorf("explicit reporter received %d events, want some: %t", len(other.ids), !tt.wantCtx)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package pm
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// internalQuerier is implemented by backends that can check specific
+// packages without listing everything installed.
+type internalQuerier interface {
+	Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error)
+}
+
+// Query implements Querier. It fails with NotSupportedError if the backend
+// cannot query packages individually.
+func (a *backendAdapter) Query(ctx context.Context, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error) {
+	querier, ok := a.backend.(internalQuerier)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationQuery, Backend: string(a.kind)}
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	internalPkgs := make([]types.PackageRef, len(pkgs))
+	for i, p := range pkgs {
+		internalPkgs[i] = types.PackageRef{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+			Channel:   p.Channel,
+			Kind:      p.Kind,
+		}
+	}
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalRes, err := querier.Query(ctx, internalPkgs, types.QueryOptions{Progress: pr})
+	if err != nil {
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	result := make([]InstalledPackage, len(internalRes))
+	for i, p := range internalRes {
+		result[i] = convertInstalledPackage(p)
+	}
+	summary.finish(nil, 0)
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package pm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// queryBackend answers Query from its installed list and fails
+// ListInstalled, so tests notice any fallback to listing everything.
+type queryBackend struct {
+	countingBackend
+	queries [][]types.PackageRef
+}
+
+func (b *queryBackend) Query(ctx context.Context, pkgs []types.PackageRef, opts types.QueryOptions) ([]types.InstalledPackage, error) {
+	b.queries = append(b.queries, pkgs)
+	var out []types.InstalledPackage
+	for _, p := range b.installed {
+		for _, want := range pkgs {
+			if want.Name == p.Ref.Name {
+				out = append(out, p)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (b *queryBackend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
+	return nil, &types.ExternalFailureError{Operation: types.OperationListInstalled, Backend: "brew", Stderr: "listed everything"}
+}
+
+func TestBackendAdapter_Query(t *testing.T) {
+	backend := &queryBackend{countingBackend: countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git", Kind: "formula"}, Version: "2.47.0"},
+		{Ref: types.PackageRef{Name: "jq", Kind: "formula"}, Version: "1.7.1"},
+	}}}
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, &backendConfig{}))
+
+	got, err := mgr.Query(context.Background(), []PackageRef{{Name: "git"}, {Name: "wget"}}, QueryOptions{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Ref.Name != "git" || got[0].Version != "2.47.0" {
+		t.Errorf("Query() = %+v, want git 2.47.0", got)
+	}
+}
+
+func TestBackendAdapter_QueryNotSupported(t *testing.T) {
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, &backendConfig{})
+	if _, err := adapter.Query(context.Background(), []PackageRef{{Name: "git"}}, QueryOptions{}); !IsNotSupported(err) {
+		t.Errorf("Query() error = %v, want NotSupportedError", err)
+	}
+}
+
+func TestAuditLog_QueriesOnlyChangedPackages(t *testing.T) {
+	backend := &queryBackend{countingBackend: countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git"}, Version: "2.47.0"},
+	}}}
+	var entries []AuditEntry
+	cfg := &backendConfig{}
+	WithAuditLog(AuditFunc(func(e AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	}))(cfg)
+	WithoutOperationLock()(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	if _, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(backend.queries) != 1 || len(backend.queries[0]) != 1 || backend.queries[0][0].Name != "git" {
+		t.Errorf("Queries = %+v, want one for git", backend.queries)
+	}
+	if len(entries) != 1 || len(entries[0].Changed) != 1 || entries[0].Changed[0].Version != "2.47.0" {
+		t.Errorf("Audit entries = %+v, want git 2.47.0", entries)
+	}
+}
+
+func TestMultiManager_Query(t *testing.T) {
+	backend := &queryBackend{countingBackend: countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git"}, Version: "2.47.0"},
+	}}}
+	multi := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: newBackendAdapter(BackendBrew, backend, &backendConfig{})},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{}},
+	)
+	ctx := context.Background()
+
+	if got, err := multi.Query(ctx, BackendBrew, []PackageRef{{Name: "git"}}, QueryOptions{}); err != nil || len(got) != 1 {
+		t.Errorf("Query(brew) = %v, %v; want git", got, err)
+	}
+	if _, err := multi.Query(ctx, BackendSnap, []PackageRef{{Name: "git"}}, QueryOptions{}); !IsNotSupported(err) {
+		t.Errorf("Query(snap) error = %v, want NotSupportedError", err)
+	}
+	if _, err := multi.Query(ctx, BackendFlatpak, nil, QueryOptions{}); !IsNotAvailable(err) {
+		t.Errorf("Query(flatpak) error = %v, want NotAvailableError", err)
+	}
+}
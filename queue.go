@@ -0,0 +1,451 @@
+package pm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JobState is the state of a queued Job.
+type JobState string
+
+const (
+	// JobPending means the job waits to be run.
+	JobPending JobState = "pending"
+
+	// JobRunning means the job's operation is running.
+	JobRunning JobState = "running"
+
+	// JobSucceeded and JobFailed mean the operation finished; a failed
+	// job's Error holds the failure.
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+
+	// JobCancelled means the job was cancelled before it ran.
+	JobCancelled JobState = "cancelled"
+)
+
+// Job is an operation in a Queue.
+type Job struct {
+	// ID identifies the job in its queue.
+	ID string `json:"id"`
+
+	// Backend and Operation say what to run: OperationInstall or
+	// OperationUninstall of Packages, OperationUpgradePackages or
+	// OperationUpdateMetadata.
+	Backend   BackendKind  `json:"backend"`
+	Operation Operation    `json:"operation"`
+	Packages  []PackageRef `json:"packages,omitempty"`
+
+	State JobState `json:"state"`
+
+	// Attempts is how many times the job was started. A job started more
+	// than once was interrupted, by a crash or a reboot, and resumed. Runs
+	// stopped by cancelling Run's context are not counted.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Changed lists the packages the operation changed, once it finished.
+	Changed []PackageRef `json:"changed,omitempty"`
+
+	// Error is the failure of a failed job.
+	Error string `json:"error,omitempty"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+}
+
+// Finished reports whether the job succeeded, failed or was cancelled.
+func (j Job) Finished() bool {
+	return j.State == JobSucceeded || j.State == JobFailed || j.State == JobCancelled
+}
+
+// QueueOptions configures a Queue.
+type QueueOptions struct {
+	// Path is the file the queue is kept in; empty means DefaultQueuePath.
+	Path string
+
+	// MaxAttempts is how many times a job is started before it is failed
+	// instead of resumed again, so a job that keeps crashing the process
+	// does not do so forever. Zero or less means 3.
+	MaxAttempts int
+
+	// Progress is passed to every operation the queue runs.
+	Progress ProgressReporter
+}
+
+// DefaultQueuePath returns where queues are kept by default:
+// /var/lib/pm/queue.json for root, and otherwise pm/queue.json in
+// $XDG_STATE_HOME or ~/.local/state.
+func DefaultQueuePath() string {
+	if os.Geteuid() == 0 {
+		return "/var/lib/pm/queue.json"
+	}
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "pm", "queue.json")
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "pm", "queue.json")
+}
+
+// Queue is a persistent queue of operations, for background agents that
+// must carry out requested changes even if they are restarted. Every
+// change to a job is written to disk before the queue goes on, so a job
+// that was running when the process stopped is resumed by the next Run.
+// Package operations are idempotent, so resuming an Install that
+// completed, or partly completed, is safe: a resumed Install or Uninstall
+// that finds every package installed or uninstalled already succeeds.
+//
+// A Queue is safe for concurrent use, but only one process may use its
+// file at a time.
+type Queue struct {
+	multi *MultiManager
+	path  string
+	opts  QueueOptions
+	now   func() time.Time
+
+	runMu sync.Mutex // held by Run
+
+	mu   sync.Mutex
+	jobs []Job
+	next int
+}
+
+// queueFile is the on-disk form of a Queue.
+type queueFile struct {
+	Next int   `json:"next"`
+	Jobs []Job `json:"jobs"`
+}
+
+// OpenQueue opens the queue kept at opts.Path, creating it on first use,
+// whose jobs run on the given backends. Jobs that were running when the
+// queue was last used are pending again.
+func OpenQueue(backends []Backend, opts QueueOptions) (*Queue, error) {
+	if opts.Path == "" {
+		opts.Path = DefaultQueuePath()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	q := &Queue{multi: NewMultiManager(backends...), path: opts.Path, opts: opts, now: time.Now}
+
+	data, err := os.ReadFile(q.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return q, nil
+	case err != nil:
+		return nil, fmt.Errorf("open queue: %w", err)
+	}
+	var f queueFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("open queue %s: %w", q.path, err)
+	}
+	q.jobs, q.next = f.Jobs, f.Next
+	for i := range q.jobs {
+		if q.jobs[i].State == JobRunning {
+			q.jobs[i].State = JobPending
+		}
+	}
+	return q, nil
+}
+
+// OpenQueue opens a queue whose jobs run on m's backends.
+func (m *MultiManager) OpenQueue(opts QueueOptions) (*Queue, error) {
+	return OpenQueue(m.backends, opts)
+}
+
+// Enqueue adds a job running op on the given backend and saves the queue.
+// op must be OperationInstall or OperationUninstall with at least one
+// package, or OperationUpgradePackages or OperationUpdateMetadata without
+// any. The job runs on the next Run.
+func (q *Queue) Enqueue(kind BackendKind, op Operation, pkgs ...PackageRef) (Job, error) {
+	if _, ok := q.multi.Get(kind); !ok {
+		return Job{}, &NotAvailableError{Backend: string(kind), Reason: "not managed by this queue"}
+	}
+	switch op {
+	case OperationInstall, OperationUninstall:
+		if len(pkgs) == 0 {
+			return Job{}, &ValidationError{Operation: op, Backend: string(kind), Field: "Packages", Reason: "no packages given"}
+		}
+	case OperationUpgradePackages, OperationUpdateMetadata:
+		if len(pkgs) > 0 {
+			return Job{}, &ValidationError{Operation: op, Backend: string(kind), Field: "Packages", Reason: "operation takes no packages"}
+		}
+	default:
+		return Job{}, &ValidationError{Operation: op, Backend: string(kind), Field: "Operation", Value: string(op), Reason: "operation cannot be queued"}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.next++
+	job := Job{
+		ID:        strconv.Itoa(q.next),
+		Backend:   kind,
+		Operation: op,
+		Packages:  pkgs,
+		State:     JobPending,
+		CreatedAt: q.now(),
+	}
+	q.jobs = append(q.jobs, job)
+	if err := q.save(); err != nil {
+		q.jobs = q.jobs[:len(q.jobs)-1]
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Job returns the job with the given ID.
+func (q *Queue) Job(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.find(id); i >= 0 {
+		return q.jobs[i], true
+	}
+	return Job{}, false
+}
+
+// Jobs returns every job in the order they were enqueued.
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Job(nil), q.jobs...)
+}
+
+// Cancel cancels the pending job with the given ID. Jobs that have started
+// cannot be cancelled.
+func (q *Queue) Cancel(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.find(id)
+	if i < 0 {
+		return fmt.Errorf("cancel job %s: no such job", id)
+	}
+	if q.jobs[i].State != JobPending {
+		return fmt.Errorf("cancel job %s: job is %s", id, q.jobs[i].State)
+	}
+	prev := q.jobs[i]
+	q.jobs[i].State, q.jobs[i].FinishedAt = JobCancelled, q.now()
+	if err := q.save(); err != nil {
+		q.jobs[i] = prev
+		return err
+	}
+	return nil
+}
+
+// Prune removes the finished jobs from the queue.
+func (q *Queue) Prune() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	prev := q.jobs
+	var kept []Job
+	for _, job := range q.jobs {
+		if !job.Finished() {
+			kept = append(kept, job)
+		}
+	}
+	q.jobs = kept
+	if err := q.save(); err != nil {
+		q.jobs = prev
+		return err
+	}
+	return nil
+}
+
+// Run runs the pending jobs one at a time, oldest first, including jobs
+// enqueued while it runs, and returns once none is left. The failures of
+// jobs are recorded in them and returned joined, annotated with the job
+// ID. If ctx is cancelled, the running job stays pending, to be resumed
+// by the next Run without counting the attempt, and Run returns ctx's
+// error. Only one Run proceeds at a
+// time; others wait for it.
+func (q *Queue) Run(ctx context.Context) error {
+	q.runMu.Lock()
+	defer q.runMu.Unlock()
+
+	var errs []error
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		job, ok, err := q.start()
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		if !ok {
+			return errors.Join(errs...)
+		}
+
+		changed, runErr := q.run(ctx, job)
+		if ctxErr := ctx.Err(); ctxErr != nil && runErr != nil {
+			if err := q.update(job.ID, func(j *Job) { j.State, j.Attempts = JobPending, j.Attempts-1 }); err != nil {
+				errs = append(errs, err)
+			}
+			return errors.Join(append(errs, ctxErr)...)
+		}
+		err = q.update(job.ID, func(j *Job) {
+			j.State, j.Changed, j.FinishedAt = JobSucceeded, changed, q.now()
+			if runErr != nil {
+				j.State, j.Error = JobFailed, runErr.Error()
+			}
+		})
+		if runErr != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.ID, runErr))
+		}
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+	}
+}
+
+// start marks the oldest pending job running and returns it. Jobs that
+// have been started MaxAttempts times already are failed instead.
+func (q *Queue) start() (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.jobs {
+		job := &q.jobs[i]
+		if job.State != JobPending {
+			continue
+		}
+		prev := *job
+		if job.Attempts >= q.opts.MaxAttempts {
+			job.State, job.FinishedAt = JobFailed, q.now()
+			job.Error = fmt.Sprintf("interrupted %d times", job.Attempts)
+		} else {
+			job.State, job.StartedAt = JobRunning, q.now()
+			job.Attempts++
+		}
+		if err := q.save(); err != nil {
+			*job = prev
+			return Job{}, false, err
+		}
+		if job.State == JobRunning {
+			return *job, true, nil
+		}
+	}
+	return Job{}, false, nil
+}
+
+// run runs job's operation and returns the packages it changed.
+func (q *Queue) run(ctx context.Context, job Job) ([]PackageRef, error) {
+	mgr, ok := q.multi.Get(job.Backend)
+	if !ok {
+		return nil, &NotAvailableError{Backend: string(job.Backend), Reason: "not managed by this queue"}
+	}
+	switch job.Operation {
+	case OperationInstall:
+		res, err := q.multi.Install(ctx, job.Backend, job.Packages, InstallOptions{Progress: q.opts.Progress})
+		if resumedDone(job, err, res.Skipped) && IsAlreadyInstalled(err) {
+			err = nil
+		}
+		return res.PackagesInstalled, err
+	case OperationUninstall:
+		res, err := q.multi.Uninstall(ctx, job.Backend, job.Packages, UninstallOptions{Progress: q.opts.Progress})
+		if resumedDone(job, err, res.Skipped) && IsNotInstalled(err) {
+			err = nil
+		}
+		return res.PackagesUninstalled, err
+	case OperationUpgradePackages:
+		upgrader, ok := mgr.(Upgrader)
+		if !ok {
+			return nil, &NotSupportedError{Operation: job.Operation, Backend: string(job.Backend)}
+		}
+		res, err := upgrader.Upgrade(ctx, UpgradeOptions{Progress: q.opts.Progress})
+		return res.PackagesChanged, err
+	case OperationUpdateMetadata:
+		updater, ok := mgr.(Updater)
+		if !ok {
+			return nil, &NotSupportedError{Operation: job.Operation, Backend: string(job.Backend)}
+		}
+		_, err := updater.Update(ctx, UpdateOptions{Progress: q.opts.Progress})
+		return nil, err
+	}
+	return nil, &NotSupportedError{Operation: job.Operation, Backend: string(job.Backend), Reason: "operation cannot be queued"}
+}
+
+// resumedDone reports whether job was resumed and its operation failed only
+// because it skipped every package, as when the interrupted attempt got
+// through them before the process stopped.
+func resumedDone(job Job, err error, skipped []error) bool {
+	return err != nil && job.Attempts > 1 && len(skipped) >= len(job.Packages)
+}
+
+// update applies fn to the job with the given ID and saves the queue.
+func (q *Queue) update(id string, fn func(*Job)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.find(id)
+	if i < 0 {
+		return nil // pruned meanwhile
+	}
+	fn(&q.jobs[i])
+	return q.save()
+}
+
+// find returns the index of the job with the given ID, or -1; it must be
+// called with mu held.
+func (q *Queue) find(id string) int {
+	for i, job := range q.jobs {
+		if job.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// save writes the queue to its file; it must be called with mu held.
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(queueFile{Next: q.next, Jobs: q.jobs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(q.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("save queue: %w", err)
+	}
+	// Write to a temp file and rename so a crash never leaves a partial queue.
+	tmp, err := os.CreateTemp(dir, ".queue-*")
+	if err != nil {
+		return fmt.Errorf("save queue: %w", err)
+	}
+	_, werr := tmp.Write(data)
+	serr := tmp.Sync()
+	cerr := tmp.Close()
+	if err := errors.Join(werr, serr, cerr); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("save queue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("save queue: %w", err)
+	}
+	if err := syncDir(dir); err != nil {
+		return fmt.Errorf("save queue: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes dir, so a rename into it survives a crash. Windows
+// cannot sync directories, and does not need to.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	serr := d.Sync()
+	return errors.Join(serr, d.Close())
+}
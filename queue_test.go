@@ -0,0 +1,176 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// skippingManager fails installs and uninstalls the way backends do when
+// every package was installed or uninstalled already, or with err if set.
+// Installs call cancel first, if set.
+type skippingManager struct {
+	fakeManager
+	err    error
+	cancel context.CancelFunc
+}
+
+func (m *skippingManager) Install(ctx context.Context, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.err != nil {
+		return InstallResult{}, m.err
+	}
+	skipped := &AlreadyInstalledError{Ref: pkgs[0], Backend: "fake"}
+	return InstallResult{Skipped: []error{skipped}}, skipped
+}
+
+func (m *skippingManager) Uninstall(ctx context.Context, pkgs []PackageRef, opts UninstallOptions) (UninstallResult, error) {
+	if m.err != nil {
+		return UninstallResult{}, m.err
+	}
+	skipped := &NotInstalledError{Ref: pkgs[0], Backend: "fake"}
+	return UninstallResult{Skipped: []error{skipped}}, skipped
+}
+
+func TestQueue(t *testing.T) {
+	fake := &fakeManager{}
+	backends := []Backend{{Kind: BackendFlatpak, Manager: fake}}
+	opts := QueueOptions{Path: filepath.Join(t.TempDir(), "queue.json"), MaxAttempts: 2}
+
+	q, err := OpenQueue(backends, opts)
+	if err != nil {
+		t.Fatalf("OpenQueue() error = %v", err)
+	}
+	if _, err := q.Enqueue(BackendFlatpak, OperationSearch); !IsValidation(err) {
+		t.Errorf("Enqueue(Search) error = %v, want a ValidationError", err)
+	}
+	if _, err := q.Enqueue(BackendSnap, OperationUpgradePackages); !IsNotAvailable(err) {
+		t.Errorf("Enqueue(snap) error = %v, want a NotAvailableError", err)
+	}
+	install, err := q.Enqueue(BackendFlatpak, OperationInstall, PackageRef{Name: "org.gimp.GIMP"})
+	if err != nil {
+		t.Fatalf("Enqueue(Install) error = %v", err)
+	}
+	upgrade, _ := q.Enqueue(BackendFlatpak, OperationUpgradePackages)
+	cancelled, _ := q.Enqueue(BackendFlatpak, OperationUninstall, PackageRef{Name: "org.gnome.Maps"})
+	if err := q.Cancel(cancelled.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	// The process stops while the install is running
+	if _, ok, err := q.start(); !ok || err != nil {
+		t.Fatalf("start() = %t, %v", ok, err)
+	}
+	q, err = OpenQueue(backends, opts)
+	if err != nil {
+		t.Fatalf("OpenQueue() error = %v", err)
+	}
+	if job, _ := q.Job(install.ID); job.State != JobPending || job.Attempts != 1 {
+		t.Errorf("resumed job = %+v, want pending after one attempt", job)
+	}
+
+	err = q.Run(context.Background())
+	if !IsNotSupported(err) {
+		t.Errorf("Run() error = %v, want the upgrade's NotSupportedError", err)
+	}
+	if len(fake.installCalls) != 1 {
+		t.Errorf("Run() installed %d times, want once", len(fake.installCalls))
+	}
+	job, _ := q.Job(install.ID)
+	if job.State != JobSucceeded || job.Attempts != 2 || len(job.Changed) != 1 || job.FinishedAt.IsZero() {
+		t.Errorf("install job = %+v, want succeeded on the second attempt", job)
+	}
+	if job, _ := q.Job(upgrade.ID); job.State != JobFailed || job.Error == "" {
+		t.Errorf("upgrade job = %+v, want failed", job)
+	}
+	if job, _ := q.Job(cancelled.ID); job.State != JobCancelled {
+		t.Errorf("cancelled job = %+v, want cancelled", job)
+	}
+
+	// A job interrupted MaxAttempts times is failed rather than resumed
+	again, _ := q.Enqueue(BackendFlatpak, OperationInstall, PackageRef{Name: "org.gimp.GIMP"})
+	for range opts.MaxAttempts {
+		_, _, _ = q.start()
+		q, _ = OpenQueue(backends, opts)
+	}
+	if err := q.Run(context.Background()); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if job, _ := q.Job(again.ID); job.State != JobFailed || len(fake.installCalls) != 1 {
+		t.Errorf("job = %+v after %d installs, want failed without installing", job, len(fake.installCalls))
+	}
+
+	if err := q.Prune(); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if jobs := q.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() = %+v after Prune, want none", jobs)
+	}
+	if next, _ := q.Enqueue(BackendFlatpak, OperationUpdateMetadata); next.ID != "5" {
+		t.Errorf("Enqueue() ID = %q after Prune, want 5", next.ID)
+	}
+}
+
+func TestQueue_ResumeSkipped(t *testing.T) {
+	backends := []Backend{{Kind: BackendFlatpak, Manager: &skippingManager{}}}
+	opts := QueueOptions{Path: filepath.Join(t.TempDir(), "queue.json")}
+	q, err := OpenQueue(backends, opts)
+	if err != nil {
+		t.Fatalf("OpenQueue() error = %v", err)
+	}
+	fresh, _ := q.Enqueue(BackendFlatpak, OperationInstall, PackageRef{Name: "org.gimp.GIMP"})
+	if err := q.Run(context.Background()); !IsAlreadyInstalled(err) {
+		t.Errorf("Run() error = %v, want the fresh job's AlreadyInstalledError", err)
+	}
+	if job, _ := q.Job(fresh.ID); job.State != JobFailed {
+		t.Errorf("fresh job = %+v, want failed", job)
+	}
+
+	// The process stops while both jobs are running, after they got
+	// through their packages
+	install, _ := q.Enqueue(BackendFlatpak, OperationInstall, PackageRef{Name: "org.gimp.GIMP"})
+	uninstall, _ := q.Enqueue(BackendFlatpak, OperationUninstall, PackageRef{Name: "org.gnome.Maps"})
+	_, _, _ = q.start()
+	q, _ = OpenQueue(backends, opts)
+	_, _, _ = q.start()
+	_, _, _ = q.start()
+	q, _ = OpenQueue(backends, opts)
+
+	if err := q.Run(context.Background()); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	for _, id := range []string{install.ID, uninstall.ID} {
+		if job, _ := q.Job(id); job.State != JobSucceeded {
+			t.Errorf("resumed job = %+v, want succeeded", job)
+		}
+	}
+}
+
+func TestQueue_CancelNotCounted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := &skippingManager{err: context.Canceled, cancel: cancel}
+	backends := []Backend{{Kind: BackendFlatpak, Manager: mgr}}
+	opts := QueueOptions{Path: filepath.Join(t.TempDir(), "queue.json"), MaxAttempts: 1}
+	q, err := OpenQueue(backends, opts)
+	if err != nil {
+		t.Fatalf("OpenQueue() error = %v", err)
+	}
+	install, _ := q.Enqueue(BackendFlatpak, OperationInstall, PackageRef{Name: "org.gimp.GIMP"})
+
+	if err := q.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+	if job, _ := q.Job(install.ID); job.State != JobPending || job.Attempts != 0 {
+		t.Errorf("cancelled job = %+v, want pending without attempts", job)
+	}
+
+	// Within MaxAttempts, the job still runs
+	mgr.err, mgr.cancel = errors.New("boom"), nil
+	_ = q.Run(context.Background())
+	if job, _ := q.Job(install.ID); job.State != JobFailed || job.Error != "boom" || job.Attempts != 1 {
+		t.Errorf("job = %+v, want failed by its run", job)
+	}
+}
@@ -0,0 +1,33 @@
+package pm
+
+import (
+	"context"
+	"slices"
+
+	"github.com/frostyard/pm/internal/rank"
+)
+
+// internalPopularity is implemented by backends that know how popular their
+// packages are, keyed by name, to break ties when ranking search results.
+type internalPopularity interface {
+	Popularity(ctx context.Context) (map[string]int, error)
+}
+
+// rankRefs returns refs ordered by relevance to query: exact name matches,
+// then prefix, substring and fuzzy matches, then results the backend matched
+// on something other than the name. Within each tier, popular packages come
+// first where the backend reports popularity. refs is not modified.
+func (a *backendAdapter) rankRefs(ctx context.Context, query string, refs []PackageRef) []PackageRef {
+	if len(refs) < 2 {
+		return refs
+	}
+	var popularity map[string]int
+	if p, ok := a.backend.(internalPopularity); ok {
+		// Popularity only refines the order, so rank without it rather
+		// than fail the search
+		popularity, _ = p.Popularity(ctx)
+	}
+	refs = slices.Clone(refs)
+	rank.Sort(refs, func(ref PackageRef) string { return ref.Name }, query, popularity)
+	return refs
+}
@@ -0,0 +1,61 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// popularBackend is a countingBackend that reports package popularity.
+type popularBackend struct {
+	countingBackend
+	popularity map[string]int
+}
+
+func (b *popularBackend) Popularity(ctx context.Context) (map[string]int, error) {
+	return b.popularity, nil
+}
+
+func TestBackendAdapter_SearchRanking(t *testing.T) {
+	backend := &popularBackend{
+		countingBackend: countingBackend{results: []types.PackageRef{
+			{Name: "lazygit"}, {Name: "git-lfs"}, {Name: "gti"}, {Name: "git-delta"}, {Name: "git"},
+		}},
+		popularity: map[string]int{"git-delta": 10, "git-lfs": 20},
+	}
+	cfg := &backendConfig{}
+	WithCache(NewMemoryCache())(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	names := func(refs []PackageRef) string {
+		var names []string
+		for _, ref := range refs {
+			names = append(names, ref.Name)
+		}
+		return fmt.Sprint(names)
+	}
+	tests := []struct {
+		opts SearchOptions
+		want string
+	}{
+		{SearchOptions{}, "[git git-lfs git-delta lazygit gti]"},
+		{SearchOptions{Limit: 2}, "[git git-lfs]"},
+		{SearchOptions{Unranked: true}, "[lazygit git-lfs gti git-delta git]"},
+		// A cached result is ranked again
+		{SearchOptions{}, "[git git-lfs git-delta lazygit gti]"},
+	}
+	for _, tt := range tests {
+		res, err := adapter.Search(context.Background(), "git", tt.opts)
+		if err != nil {
+			t.Fatalf("Search(%+v) error = %v", tt.opts, err)
+		}
+		if got := names(res); got != tt.want {
+			t.Errorf("Search(%+v) = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+	if backend.searchCalls != 2 {
+		t.Errorf("Expected 2 backend searches, got %d", backend.searchCalls)
+	}
+}
@@ -0,0 +1,109 @@
+package pm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ref is a package reference qualified by the backend it belongs to.
+// Its text form is produced by String and parsed by ParseRef:
+//
+//	snap:firefox/stable
+//	flatpak:flathub/org.gimp.GIMP//stable
+//	brew:cask/visual-studio-code
+//
+// A reference without a "backend:" prefix is a bare package name.
+type Ref struct {
+	// Backend is the backend the package belongs to, or empty if unqualified.
+	Backend BackendKind
+
+	// Package is the backend-specific package reference.
+	Package PackageRef
+}
+
+// ParseRef parses the text form of a package reference.
+//
+// The part after the backend prefix is interpreted per backend:
+//   - snap: name[/channel], where channel may itself contain a slash
+//     (e.g. snap:lxd/5.21/stable).
+//   - flatpak: [remote/]id[//branch].
+//   - brew: [cask/|formula/][tap/]name, where tap is user/repo.
+func ParseRef(s string) (Ref, error) {
+	prefix, rest, qualified := strings.Cut(s, ":")
+	if !qualified {
+		if s == "" {
+			return Ref{}, fmt.Errorf("invalid package reference %q: empty name", s)
+		}
+		return Ref{Package: PackageRef{Name: s}}, nil
+	}
+
+	kind := BackendKind(prefix)
+	var pkg PackageRef
+	switch kind {
+	case BackendSnap:
+		pkg.Name, pkg.Channel, _ = strings.Cut(rest, "/")
+	case BackendFlatpak:
+		ref, branch, _ := strings.Cut(rest, "//")
+		pkg.Channel = branch
+		if i := strings.LastIndex(ref, "/"); i >= 0 {
+			pkg.Namespace, pkg.Name = ref[:i], ref[i+1:]
+		} else {
+			pkg.Name = ref
+		}
+	case BackendBrew:
+		name := rest
+		for _, k := range []string{"cask", "formula"} {
+			if after, ok := strings.CutPrefix(name, k+"/"); ok {
+				pkg.Kind, name = k, after
+				break
+			}
+		}
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			pkg.Namespace, name = name[:i], name[i+1:]
+		}
+		pkg.Name = name
+	default:
+		return Ref{}, fmt.Errorf("invalid package reference %q: unknown backend %q", s, prefix)
+	}
+
+	if pkg.Name == "" {
+		return Ref{}, fmt.Errorf("invalid package reference %q: empty name", s)
+	}
+	return Ref{Backend: kind, Package: pkg}, nil
+}
+
+// String returns the canonical text form of r, which ParseRef accepts.
+func (r Ref) String() string {
+	p := r.Package
+	var b strings.Builder
+	if r.Backend != "" {
+		b.WriteString(string(r.Backend))
+		b.WriteByte(':')
+	}
+	switch r.Backend {
+	case BackendSnap:
+		b.WriteString(p.Name)
+		if p.Channel != "" {
+			b.WriteString("/" + p.Channel)
+		}
+	case BackendFlatpak:
+		if p.Namespace != "" {
+			b.WriteString(p.Namespace + "/")
+		}
+		b.WriteString(p.Name)
+		if p.Channel != "" {
+			b.WriteString("//" + p.Channel)
+		}
+	case BackendBrew:
+		if p.Kind != "" {
+			b.WriteString(p.Kind + "/")
+		}
+		if p.Namespace != "" {
+			b.WriteString(p.Namespace + "/")
+		}
+		b.WriteString(p.Name)
+	default:
+		b.WriteString(p.Name)
+	}
+	return b.String()
+}
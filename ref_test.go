@@ -0,0 +1,43 @@
+package pm
+
+import (
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Ref
+	}{
+		{"snap:firefox", Ref{BackendSnap, PackageRef{Name: "firefox"}}},
+		{"snap:firefox/stable", Ref{BackendSnap, PackageRef{Name: "firefox", Channel: "stable"}}},
+		{"snap:lxd/5.21/stable", Ref{BackendSnap, PackageRef{Name: "lxd", Channel: "5.21/stable"}}},
+		{"flatpak:org.gimp.GIMP", Ref{BackendFlatpak, PackageRef{Name: "org.gimp.GIMP"}}},
+		{"flatpak:flathub/org.gimp.GIMP//stable", Ref{BackendFlatpak, PackageRef{Name: "org.gimp.GIMP", Namespace: "flathub", Channel: "stable"}}},
+		{"brew:wget", Ref{BackendBrew, PackageRef{Name: "wget"}}},
+		{"brew:cask/visual-studio-code", Ref{BackendBrew, PackageRef{Name: "visual-studio-code", Kind: "cask"}}},
+		{"brew:formula/hashicorp/tap/terraform", Ref{BackendBrew, PackageRef{Name: "terraform", Namespace: "hashicorp/tap", Kind: "formula"}}},
+		{"firefox", Ref{"", PackageRef{Name: "firefox"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRef(tt.in)
+		if err != nil {
+			t.Errorf("ParseRef(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.in {
+			t.Errorf("ParseRef(%q).String() = %q", tt.in, s)
+		}
+	}
+}
+
+func TestParseRef_Errors(t *testing.T) {
+	for _, in := range []string{"", "apt:vim", "snap:", "flatpak:flathub/", "brew:cask/"} {
+		if _, err := ParseRef(in); err == nil {
+			t.Errorf("ParseRef(%q) expected error", in)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package pm
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/frostyard/pm/internal/httpretry"
+)
+
+// RetryPolicy configures retries for backends that talk to HTTP APIs
+// (the Homebrew Formulae API and the snapd REST socket).
+//
+// Only idempotent requests (GET, HEAD) are retried. A request is retried when
+// it fails with a transport error or returns one of RetryableStatusCodes;
+// cancellation and deadline errors are never retried. A Retry-After header
+// given in seconds overrides the computed backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+
+	// Multiplier scales the delay after each attempt (default 2).
+	Multiplier float64
+
+	// RetryableStatusCodes lists HTTP status codes that trigger a retry.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns a policy suitable for provisioning runs:
+// three attempts with exponential backoff starting at 500ms, retrying on
+// 429 and 5xx gateway/availability errors.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy enables retries with backoff for HTTP-based backend calls.
+// Without this option, HTTP requests are attempted once.
+func WithRetryPolicy(p RetryPolicy) ConstructorOption {
+	return func(config *backendConfig) {
+		config.retry = &p
+	}
+}
+
+// httpClient returns an HTTP client for a backend that sends requests
+// through base, wrapped with the configured retry policy and simulation
+// mode. It returns nil when nothing about the client is configured, so
+// backends fall back to their own default clients.
+func (c *backendConfig) httpClient(kind BackendKind, base http.RoundTripper) *http.Client {
+	if c.retry == nil && !c.simulate && !c.customHTTP() {
+		return nil
+	}
+	transport := base
+	if c.retry != nil {
+		transport = httpretry.Wrap(transport, httpretry.Policy{
+			MaxAttempts:          c.retry.MaxAttempts,
+			InitialBackoff:       c.retry.InitialBackoff,
+			MaxBackoff:           c.retry.MaxBackoff,
+			Multiplier:           c.retry.Multiplier,
+			RetryableStatusCodes: c.retry.RetryableStatusCodes,
+		})
+	}
+	if c.simulate {
+		transport = &simulationTransport{base: transport, kind: kind, rec: c.simulation}
+	}
+	client := &http.Client{Transport: transport}
+	if c.client != nil {
+		*client = *c.client
+		client.Transport = transport
+	}
+	return client
+}
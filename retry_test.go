@@ -0,0 +1,28 @@
+package pm
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/frostyard/pm/internal/httpretry"
+)
+
+func TestBackendConfig_HTTPClient(t *testing.T) {
+	cfg := &backendConfig{}
+	if c := cfg.httpClient(BackendBrew, http.DefaultTransport); c != nil {
+		t.Error("Expected nil client without a retry policy")
+	}
+
+	WithRetryPolicy(DefaultRetryPolicy())(cfg)
+	c := cfg.httpClient(BackendBrew, http.DefaultTransport)
+	if c == nil {
+		t.Fatal("Expected client with a retry policy")
+	}
+	tr, ok := c.Transport.(*httpretry.Transport)
+	if !ok {
+		t.Fatalf("Expected retrying transport, got %T", c.Transport)
+	}
+	if tr.Policy.MaxAttempts != 3 {
+		t.Errorf("Expected MaxAttempts=3, got %d", tr.Policy.MaxAttempts)
+	}
+}
@@ -0,0 +1,92 @@
+package pm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+type runIDKey struct{}
+
+// ContextWithRunID returns a copy of ctx carrying a correlation ID.
+//
+// Operations run with the returned context stamp the ID on every
+// ProgressAction, ProgressTask, ProgressStep and ProgressMessage they
+// report, on ExternalFailureError, and on audit log entries, so the logs,
+// progress streams and errors of a multi-operation orchestration can be
+// stitched together.
+func ContextWithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunIDFromContext returns the correlation ID set by ContextWithRunID, or
+// "" if there is none.
+func RunIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// reporter returns the progress reporter for one call (see progressFor),
+// stamped with the context's run ID. The returned summarizer must be
+// finished once the operation's outcome is known; both are nil if there is
+// no reporter.
+func (a *backendAdapter) reporter(ctx context.Context, override ProgressReporter) (types.ProgressReporter, *summarizer) {
+	pr := a.progressFor(ctx, override)
+	if pr == nil {
+		return nil, nil
+	}
+	if id := RunIDFromContext(ctx); id != "" {
+		pr = &runIDReporter{id: id, pr: pr}
+	}
+	s := &summarizer{pr: pr}
+	if a.clock != nil || a.newID != nil {
+		return convertProgressReporter(a.stamping(s)), s
+	}
+	return convertProgressReporter(s), s
+}
+
+// convertError converts err with the package-level convertError and stamps
+// the context's run ID on an ExternalFailureError.
+func (a *backendAdapter) convertError(ctx context.Context, err error) error {
+	err = convertError(err)
+	var extErr *ExternalFailureError
+	if errors.As(err, &extErr) && extErr.RunID == "" {
+		extErr.RunID = RunIDFromContext(ctx)
+	}
+	return err
+}
+
+// runIDReporter sets RunID on every event that does not already have one.
+type runIDReporter struct {
+	id string
+	pr ProgressReporter
+}
+
+func (r *runIDReporter) OnAction(action ProgressAction) {
+	if action.RunID == "" {
+		action.RunID = r.id
+	}
+	r.pr.OnAction(action)
+}
+
+func (r *runIDReporter) OnTask(task ProgressTask) {
+	if task.RunID == "" {
+		task.RunID = r.id
+	}
+	r.pr.OnTask(task)
+}
+
+func (r *runIDReporter) OnStep(step ProgressStep) {
+	if step.RunID == "" {
+		step.RunID = r.id
+	}
+	r.pr.OnStep(step)
+}
+
+func (r *runIDReporter) OnMessage(msg ProgressMessage) {
+	if msg.RunID == "" {
+		msg.RunID = r.id
+	}
+	r.pr.OnMessage(msg)
+}
@@ -0,0 +1,153 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// progressBackend emits one action, task, step and message per Install
+// through the reporter it was given, then returns installErr.
+type progressBackend struct {
+	countingBackend
+}
+
+func (b *progressBackend) Install(ctx context.Context, pkgs []types.PackageRef, opts types.InstallOptions) (types.InstallResult, error) {
+	h := types.NewProgressHelper(nil, opts.Progress)
+	h.BeginAction("Install")
+	h.BeginTask("Download")
+	h.BeginStep("git")
+	h.Info("fetching")
+	h.EndStep()
+	h.EndTask()
+	h.EndAction()
+	return b.countingBackend.Install(ctx, pkgs, opts)
+}
+
+// runIDCollector records the RunID of every event it receives.
+type runIDCollector struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (c *runIDCollector) add(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids = append(c.ids, id)
+}
+
+func (c *runIDCollector) OnAction(a ProgressAction)   { c.add(a.RunID) }
+func (c *runIDCollector) OnTask(t ProgressTask)       { c.add(t.RunID) }
+func (c *runIDCollector) OnStep(s ProgressStep)       { c.add(s.RunID) }
+func (c *runIDCollector) OnMessage(m ProgressMessage) { c.add(m.RunID) }
+
+func TestRunIDFromContext(t *testing.T) {
+	if id := RunIDFromContext(context.Background()); id != "" {
+		t.Errorf("RunIDFromContext() = %q, want empty", id)
+	}
+	if id := RunIDFromContext(ContextWithRunID(context.Background(), "run-1")); id != "run-1" {
+		t.Errorf("RunIDFromContext() = %q, want run-1", id)
+	}
+}
+
+func TestRunID_StampsProgress(t *testing.T) {
+	tests := []struct {
+		name        string
+		constructed bool
+	}{
+		{"per-call reporter", false},
+		{"construction-time reporter", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &runIDCollector{}
+			cfg := &backendConfig{noLock: true}
+			opts := InstallOptions{Progress: collector}
+			if tt.constructed {
+				WithProgress(collector)(cfg)
+				opts = InstallOptions{}
+			}
+			adapter := newBackendAdapter(BackendBrew, &progressBackend{}, cfg)
+
+			ctx := ContextWithRunID(context.Background(), "run-42")
+			if _, err := adapter.Install(ctx, []PackageRef{{Name: "git"}}, opts); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if len(collector.ids) != 7 {
+				t.Fatalf("Expected 7 events, got %d", len(collector.ids))
+			}
+			for i, id := range collector.ids {
+				if id != "run-42" {
+					t.Errorf("event %d RunID = %q, want run-42", i, id)
+				}
+			}
+		})
+	}
+}
+
+func TestRunID_StampsExternalFailure(t *testing.T) {
+	backend := &progressBackend{countingBackend{installErr: &types.ExternalFailureError{
+		Operation: types.OperationInstall,
+		Backend:   "brew",
+		Err:       errors.New("exit status 1"),
+	}}}
+	adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{noLock: true})
+
+	ctx := ContextWithRunID(context.Background(), "run-7")
+	_, err := adapter.Install(ctx, []PackageRef{{Name: "git"}}, InstallOptions{})
+	var extErr *ExternalFailureError
+	if !errors.As(err, &extErr) {
+		t.Fatalf("Expected *ExternalFailureError, got %v", err)
+	}
+	if extErr.RunID != "run-7" {
+		t.Errorf("RunID = %q, want run-7", extErr.RunID)
+	}
+	if !strings.Contains(err.Error(), "[run run-7]") {
+		t.Errorf("Error() should mention the run ID: %s", err)
+	}
+}
+
+func TestRunID_Audit(t *testing.T) {
+	var entry AuditEntry
+	cfg := &backendConfig{noLock: true}
+	WithAuditLog(AuditFunc(func(e AuditEntry) error {
+		entry = e
+		return nil
+	}))(cfg)
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, cfg)
+
+	ctx := ContextWithRunID(context.Background(), "run-9")
+	if _, err := adapter.Install(ctx, []PackageRef{{Name: "git"}}, InstallOptions{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if entry.RunID != "run-9" {
+		t.Errorf("AuditEntry.RunID = %q, want run-9", entry.RunID)
+	}
+}
+
+// sourceCollector records the Backend and Operation of every message.
+type sourceCollector struct {
+	runIDCollector
+	sources []string
+}
+
+func (c *sourceCollector) OnMessage(m ProgressMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = append(c.sources, m.Backend+"/"+m.Operation)
+}
+
+func TestProgress_MessageSource(t *testing.T) {
+	collector := &sourceCollector{}
+	pr := convertProgressReporter(collector)
+	h := types.NewOperationProgressHelper("snap", types.OperationInstall, pr, nil)
+	h.Info("installing")
+
+	if len(collector.sources) != 1 || collector.sources[0] != "snap/Install" {
+		t.Errorf("sources = %v, want [snap/Install]", collector.sources)
+	}
+}
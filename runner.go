@@ -0,0 +1,197 @@
+package pm
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/frostyard/pm/internal/runner"
+)
+
+// Runner executes the commands of CLI-based backends (flatpak, snap, and
+// brew for mutating operations).
+//
+// Implementations must be safe for concurrent use. Return a non-nil error
+// when the command fails (e.g. *exec.ExitError for a non-zero exit status);
+// stdout and stderr are still used to build error details.
+type Runner interface {
+	// Run executes a command and returns stdout, stderr, and any error.
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr string, err error)
+}
+
+// RunnerFunc is an adapter to allow the use of ordinary functions as Runners.
+type RunnerFunc func(ctx context.Context, name string, args ...string) (stdout, stderr string, err error)
+
+// Run calls f(ctx, name, args...).
+func (f RunnerFunc) Run(ctx context.Context, name string, args ...string) (string, string, error) {
+	return f(ctx, name, args...)
+}
+
+// NewExecRunner returns the default Runner, which executes commands with
+// os/exec. Commands never receive input on stdin, and output is streamed to
+// progress reporters while the command runs. Custom runners can wrap it to
+// add logging or to rewrite commands; they must pass ctx through unchanged.
+func NewExecRunner() Runner {
+	return runner.NewRealRunner()
+}
+
+// WithTerminationGrace sets how long a command is given to exit after
+// SIGTERM when its operation's context is cancelled, before it is killed.
+// The default is 10 seconds; zero or less kills cancelled commands
+// immediately. It applies to the default executor, not to runners set with
+// WithRunner.
+func WithTerminationGrace(d time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		config.terminationGrace = &d
+	}
+}
+
+// DefaultOutputLimit is how many bytes of a command's stdout and stderr are
+// kept in errors such as ExternalFailureError unless WithOutputLimit says
+// otherwise.
+const DefaultOutputLimit = runner.DefaultOutputLimit
+
+// WithOutputLimit sets how many bytes of a command's stdout and stderr are
+// kept when they are attached to errors (ExternalFailureError,
+// EscalationError, PermissionDeniedError). Longer output keeps its
+// beginning and its end, where tools usually report what they were doing
+// and why they failed. A limit of zero or less keeps all output.
+//
+// If kinds are given, the limit applies only to those backends.
+func WithOutputLimit(limit int, kinds ...BackendKind) ConstructorOption {
+	return func(config *backendConfig) {
+		if config.outputLimits == nil {
+			config.outputLimits = make(map[BackendKind]int)
+		}
+		if len(kinds) == 0 {
+			kinds = AllBackends()
+		}
+		for _, kind := range kinds {
+			config.outputLimits[kind] = limit
+		}
+	}
+}
+
+// outputLimit returns the output limit configured for kind, or nil for
+// the default.
+func (c *backendConfig) outputLimit(kind BackendKind) *int {
+	if limit, ok := c.outputLimits[kind]; ok {
+		return &limit
+	}
+	return nil
+}
+
+// WithRedaction adds patterns whose matches are replaced with
+// "[REDACTED]" in command output before it is attached to errors (Stdout,
+// Stderr and Payload) or reported as progress. They apply in addition to
+// the built-in patterns, which remove credentials in URLs, Authorization
+// headers, token query parameters, secret-looking environment assignments
+// and GitHub tokens.
+func WithRedaction(patterns ...*regexp.Regexp) ConstructorOption {
+	return func(config *backendConfig) {
+		config.redactPatterns = append(config.redactPatterns, patterns...)
+	}
+}
+
+// WithoutDefaultRedaction turns off the built-in redaction patterns, for
+// callers that need output verbatim. Patterns added with WithRedaction
+// still apply.
+func WithoutDefaultRedaction() ConstructorOption {
+	return func(config *backendConfig) {
+		config.noRedaction = true
+	}
+}
+
+// redactions returns the redactions to apply to command output, or nil for
+// the default.
+func (c *backendConfig) redactions() []runner.Redaction {
+	if len(c.redactPatterns) == 0 && !c.noRedaction {
+		return nil
+	}
+	redactions := []runner.Redaction{}
+	if !c.noRedaction {
+		redactions = append(redactions, runner.DefaultRedactions...)
+	}
+	for _, p := range c.redactPatterns {
+		redactions = append(redactions, runner.Redaction{Pattern: p, Replacement: runner.Redacted})
+	}
+	return redactions
+}
+
+// WithRunner replaces the command executor. If kinds are given, the runner
+// is used only for those backends; otherwise it is used for all of them.
+//
+// Escalation, simulation and binary path overrides still apply on top of r,
+// so r sees the final command line (e.g. `sudo -n snap install ...`).
+func WithRunner(r Runner, kinds ...BackendKind) ConstructorOption {
+	return func(config *backendConfig) {
+		if config.runners == nil {
+			config.runners = make(map[BackendKind]Runner)
+		}
+		if len(kinds) == 0 {
+			kinds = AllBackends()
+		}
+		for _, kind := range kinds {
+			config.runners[kind] = r
+		}
+	}
+}
+
+// RunnerOperation returns the operation a Runner is being called for, so
+// custom runners can distinguish read-only queries from mutations. It
+// reports false when the command is not tied to an operation (e.g.
+// availability checks).
+func RunnerOperation(ctx context.Context) (Operation, bool) {
+	op, ok := runner.OperationFromContext(ctx)
+	return Operation(op), ok
+}
+
+// Invocation holds the per-command settings a backend requested for a
+// Runner call, in addition to the command line.
+type Invocation struct {
+	// Env lists KEY=VALUE entries to add to the inherited environment
+	// (e.g. HOMEBREW_NO_AUTO_UPDATE=1).
+	Env []string
+
+	// Dir is the working directory; empty means the current directory.
+	Dir string
+
+	// Stdin is the input to feed the command; empty means none.
+	Stdin string
+
+	// PTY asks for stdout and stderr to be attached to a pseudo-terminal,
+	// for tools that only report progress to a terminal.
+	PTY bool
+}
+
+// RunnerInvocation returns the environment, working directory, stdin and
+// terminal mode a backend requested for the command a Runner is called with. Custom runners
+// that don't delegate to NewExecRunner should honor them.
+func RunnerInvocation(ctx context.Context) Invocation {
+	inv := runner.InvocationFromContext(ctx)
+	return Invocation{Env: inv.Env, Dir: inv.Dir, Stdin: inv.Stdin, PTY: inv.PTY}
+}
+
+// NewRecordingRunner wraps base so that every command it runs, with its
+// stdout, stderr and exit status, is saved to the JSON fixture file at path.
+// The file is rewritten after each command. Combine it with WithRunner to
+// capture the behavior of a real system once and replay it in tests with
+// NewReplayRunner.
+func NewRecordingRunner(base Runner, path string) Runner {
+	return runner.NewRecordingRunner(base, path)
+}
+
+// NewReplayRunner returns a Runner that serves the commands recorded at
+// path by NewRecordingRunner instead of running them.
+//
+// Each command is answered by the next unused recording of the same command
+// line, and the last one is repeated once they run out. Commands that were
+// not recorded fail. Recorded failures are returned as errors with the
+// original message and an ExitCode() int method.
+func NewReplayRunner(path string) (Runner, error) {
+	c, err := runner.LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return runner.NewReplayRunner(c), nil
+}
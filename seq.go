@@ -0,0 +1,210 @@
+package pm
+
+import (
+	"context"
+	"iter"
+	"sync"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// SeqLister is implemented by managers that can list installed packages one
+// at a time, so callers can render a large listing as it arrives instead of
+// holding all of it in memory.
+type SeqLister interface {
+	ListInstalledSeq(ctx context.Context, opts ListOptions) iter.Seq2[InstalledPackage, error]
+}
+
+// SeqSearcher is implemented by managers that can return search results one
+// at a time.
+type SeqSearcher interface {
+	SearchSeq(ctx context.Context, query string, opts SearchOptions) iter.Seq2[PackageRef, error]
+}
+
+// ListInstalledSeq lists mgr's installed packages as an iterator. Managers
+// that implement SeqLister produce packages as they go; for any other
+// Lister the iterator ranges over the ListInstalled slice.
+//
+// A failure is yielded once, as the last pair, with a zero package. If mgr
+// does not implement Lister, that failure is a NotSupportedError.
+func ListInstalledSeq(ctx context.Context, mgr Manager, opts ListOptions) iter.Seq2[InstalledPackage, error] {
+	if s, ok := mgr.(SeqLister); ok {
+		return s.ListInstalledSeq(ctx, opts)
+	}
+	return func(yield func(InstalledPackage, error) bool) {
+		lister, err := As[Lister](mgr)
+		if err != nil {
+			yield(InstalledPackage{}, err)
+			return
+		}
+		pkgs, err := lister.ListInstalled(ctx, opts)
+		sliceSeq(pkgs, err)(yield)
+	}
+}
+
+// SearchSeq searches mgr as an iterator. Managers that implement
+// SeqSearcher produce results as they go; for any other Searcher the
+// iterator ranges over the Search slice.
+//
+// A failure is yielded once, as the last pair, with a zero ref. If mgr does
+// not implement Searcher, that failure is a NotSupportedError.
+func SearchSeq(ctx context.Context, mgr Manager, query string, opts SearchOptions) iter.Seq2[PackageRef, error] {
+	if s, ok := mgr.(SeqSearcher); ok {
+		return s.SearchSeq(ctx, query, opts)
+	}
+	return func(yield func(PackageRef, error) bool) {
+		searcher, err := As[Searcher](mgr)
+		if err != nil {
+			yield(PackageRef{}, err)
+			return
+		}
+		refs, err := searcher.Search(ctx, query, opts)
+		sliceSeq(refs, err)(yield)
+	}
+}
+
+// sliceSeq yields each of items, then err if it is not nil.
+func sliceSeq[T any](items []T, err error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err != nil {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// ListInstalledSeq implements SeqLister. It converts the backend's listing
+// one package at a time rather than building a second slice, unless
+// opts.SortBy needs the whole listing to sort.
+func (a *backendAdapter) ListInstalledSeq(ctx context.Context, opts ListOptions) iter.Seq2[InstalledPackage, error] {
+	return func(yield func(InstalledPackage, error) bool) {
+		if opts.SortBy != "" {
+			sliceSeq(a.ListInstalled(ctx, opts))(yield)
+			return
+		}
+		ctx, cancel := a.operationContext(ctx, opts.Progress)
+		defer cancel()
+		pr, summary := a.reporter(ctx, opts.Progress)
+		internalRes, err := a.backend.ListInstalled(ctx, types.ListOptions{Progress: pr})
+		if err != nil {
+			err = a.convertError(ctx, err)
+			summary.finish(err, 0)
+			yield(InstalledPackage{}, err)
+			return
+		}
+		summary.finish(nil, 0)
+		for _, p := range internalRes {
+			if !yield(convertInstalledPackage(p), nil) {
+				return
+			}
+		}
+	}
+}
+
+// SearchSeq implements SeqSearcher. Results are yielded as the backend finds
+// them: brew as it decodes the Formulae API's list, snap and flatpak as
+// their CLIs print each row. Stopping the range early, or reaching
+// opts.Limit, cancels the search.
+func (a *backendAdapter) SearchSeq(ctx context.Context, query string, opts SearchOptions) iter.Seq2[PackageRef, error] {
+	return func(yield func(PackageRef, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		refs := make(chan PackageRef)
+		errc := make(chan error, 1)
+		go func() {
+			_, err := a.search(ctx, query, opts, func(ref PackageRef) {
+				select {
+				case refs <- ref:
+				case <-ctx.Done():
+				}
+			})
+			errc <- err
+			close(refs)
+		}()
+
+		n := 0
+		for ref := range refs {
+			if !yield(ref, nil) {
+				return
+			}
+			if n++; opts.Limit > 0 && n >= opts.Limit {
+				return
+			}
+		}
+		if err := <-errc; err != nil {
+			yield(PackageRef{}, err)
+		}
+	}
+}
+
+// SearchResult is a search result from one of a MultiManager's backends.
+type SearchResult struct {
+	// Backend is the backend that found Package.
+	Backend BackendKind
+
+	// Package is the result.
+	Package PackageRef
+}
+
+// SearchStream searches every backend that implements Searcher
+// concurrently and yields results as each backend finds them (see
+// SearchSeq), so typeahead UIs can render before the slowest backend has
+// finished.
+//
+// A backend's failure is yielded as a pair whose SearchResult names only
+// the Backend; the other backends carry on. With opts.Limit set, the stream
+// ends once Limit results have been yielded in total. Stopping the range
+// early cancels the searches still running.
+func (m *MultiManager) SearchStream(ctx context.Context, query string, opts SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type item struct {
+			res SearchResult
+			err error
+		}
+		items := make(chan item)
+		var wg sync.WaitGroup
+		for _, b := range only[Searcher](m.backends) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ref, err := range SearchSeq(ctx, b.Manager, query, opts) {
+					it := item{res: SearchResult{Backend: b.Kind, Package: ref}}
+					if err != nil {
+						it = item{res: SearchResult{Backend: b.Kind}, err: backendErr(b.Kind, err)}
+					}
+					select {
+					case items <- it:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(items)
+		}()
+
+		n := 0
+		for it := range items {
+			if !yield(it.res, it.err) {
+				return
+			}
+			if it.err != nil {
+				continue
+			}
+			if n++; opts.Limit > 0 && n >= opts.Limit {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestListInstalledSeq_Adapter(t *testing.T) {
+	backend := &countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "git"}, Version: "2.45"},
+		{Ref: types.PackageRef{Name: "jq"}, Version: "1.7"},
+		{Ref: types.PackageRef{Name: "wget"}, Version: "1.24"},
+	}}
+	adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{})
+
+	var names []string
+	for pkg, err := range ListInstalledSeq(context.Background(), adapter, ListOptions{}) {
+		if err != nil {
+			t.Fatalf("ListInstalledSeq() error = %v", err)
+		}
+		names = append(names, pkg.Ref.Name)
+		if len(names) == 2 {
+			break
+		}
+	}
+	if len(names) != 2 || names[0] != "git" || names[1] != "jq" {
+		t.Errorf("Expected the first two packages, got %v", names)
+	}
+}
+
+func TestListInstalledSeq_Fallback(t *testing.T) {
+	failure := errors.New("boom")
+	mgr := &fakeManager{installed: []InstalledPackage{{Ref: PackageRef{Name: "a"}}}, err: failure}
+
+	var got []error
+	for _, err := range ListInstalledSeq(context.Background(), Wrap(mgr), ListOptions{}) {
+		got = append(got, err)
+	}
+	if len(got) == 0 || !errors.Is(got[len(got)-1], failure) {
+		t.Errorf("Expected the error as the last pair, got %v", got)
+	}
+
+	for _, err := range ListInstalledSeq(context.Background(), managerOnly{}, ListOptions{}) {
+		if !IsNotSupported(err) {
+			t.Errorf("Expected NotSupportedError, got %v", err)
+		}
+	}
+}
+
+func TestSearchSeq(t *testing.T) {
+	mgr := &fakeManager{search: []PackageRef{{Name: "git"}, {Name: "git-lfs"}}}
+
+	var names []string
+	for ref, err := range SearchSeq(context.Background(), mgr, "git", SearchOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchSeq() error = %v", err)
+		}
+		names = append(names, ref.Name)
+	}
+	if len(names) != 2 {
+		t.Errorf("Expected 2 results, got %v", names)
+	}
+
+	for _, err := range SearchSeq(context.Background(), managerOnly{}, "git", SearchOptions{}) {
+		if !IsNotSupported(err) {
+			t.Errorf("Expected NotSupportedError, got %v", err)
+		}
+	}
+}
+
+// streamingBackend sends its first result as soon as Search is called, then
+// blocks until the search is cancelled.
+type streamingBackend struct {
+	countingBackend
+	cancelled chan error
+}
+
+func (b *streamingBackend) Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error) {
+	opts.OnResult(b.results[0])
+	<-ctx.Done()
+	b.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestSearchSeq_AdapterStreams(t *testing.T) {
+	backend := &streamingBackend{
+		countingBackend: countingBackend{results: []types.PackageRef{{Name: "git"}, {Name: "git-lfs"}}},
+		cancelled:       make(chan error, 1),
+	}
+	adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{})
+
+	var names []string
+	for ref, err := range SearchSeq(context.Background(), adapter, "git", SearchOptions{Limit: 1}) {
+		if err != nil {
+			t.Fatalf("SearchSeq() error = %v", err)
+		}
+		names = append(names, ref.Name)
+	}
+	if len(names) != 1 || names[0] != "git" {
+		t.Errorf("Expected the streamed result, got %v", names)
+	}
+	if err := <-backend.cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the search to be cancelled, got %v", err)
+	}
+}
+
+func TestSearchSeq_AdapterWithoutStreaming(t *testing.T) {
+	backend := &countingBackend{results: []types.PackageRef{{Name: "git"}, {Name: "git-lfs"}}}
+	adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{})
+
+	var names []string
+	for ref, err := range SearchSeq(context.Background(), adapter, "git", SearchOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchSeq() error = %v", err)
+		}
+		names = append(names, ref.Name)
+	}
+	if len(names) != 2 {
+		t.Errorf("Expected the results the backend did not stream, got %v", names)
+	}
+}
+
+func TestMultiManager_SearchStream(t *testing.T) {
+	failure := errors.New("snapd unavailable")
+	m := NewMultiManager(
+		Backend{Kind: BackendFlatpak, Manager: &fakeManager{search: []PackageRef{{Name: "org.mozilla.firefox"}}}},
+		Backend{Kind: BackendSnap, Manager: &fakeManager{err: failure}},
+		Backend{Kind: BackendBrew, Manager: &fakeManager{search: []PackageRef{{Name: "firefox", Kind: "cask"}}}},
+	)
+
+	found := make(map[BackendKind]string)
+	var errs []error
+	for res, err := range m.SearchStream(context.Background(), "firefox", SearchOptions{}) {
+		if err != nil {
+			if res.Backend != BackendSnap {
+				t.Errorf("Expected the failure to name snap, got %q", res.Backend)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		found[res.Backend] = res.Package.Name
+	}
+	if found[BackendFlatpak] != "org.mozilla.firefox" || found[BackendBrew] != "firefox" || len(found) != 2 {
+		t.Errorf("Unexpected results: %v", found)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], failure) {
+		t.Errorf("Expected the snap failure, got %v", errs)
+	}
+
+	n := 0
+	for _, err := range m.SearchStream(context.Background(), "firefox", SearchOptions{Limit: 1}) {
+		if err == nil {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("Expected Limit to cap the stream at 1 result, got %d", n)
+	}
+}
@@ -0,0 +1,143 @@
+package pm
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/frostyard/pm/internal/runner"
+	"github.com/frostyard/pm/internal/types"
+)
+
+// SimulatedAction is a command or API call seen by a backend in simulation mode.
+type SimulatedAction struct {
+	// Backend is the backend that issued the action.
+	Backend BackendKind
+
+	// Operation is the operation the action belongs to, if known.
+	Operation Operation
+
+	// Command is the command line, for command actions.
+	Command []string
+
+	// Method and URL describe the request, for API calls.
+	Method string
+	URL    string
+
+	// Executed reports whether the action actually ran. Read-only commands
+	// and requests run normally so that searches and listings stay accurate;
+	// anything that would change the system is only recorded.
+	Executed bool
+}
+
+// String returns the command line or "METHOD URL" for the action.
+func (a SimulatedAction) String() string {
+	if len(a.Command) > 0 {
+		return strings.Join(a.Command, " ")
+	}
+	return a.Method + " " + a.URL
+}
+
+// SimulationRecorder collects the actions performed in simulation mode.
+// It is safe for concurrent use and may be shared between backends.
+type SimulationRecorder struct {
+	mu      sync.Mutex
+	actions []SimulatedAction
+}
+
+// NewSimulationRecorder creates an empty recorder.
+func NewSimulationRecorder() *SimulationRecorder {
+	return &SimulationRecorder{}
+}
+
+// Actions returns the recorded actions in the order they occurred.
+func (r *SimulationRecorder) Actions() []SimulatedAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]SimulatedAction, len(r.actions))
+	copy(out, r.actions)
+	return out
+}
+
+// Skipped returns the recorded actions that were not executed, i.e. what a
+// real run would have changed.
+func (r *SimulationRecorder) Skipped() []SimulatedAction {
+	var out []SimulatedAction
+	for _, a := range r.Actions() {
+		if !a.Executed {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Reset discards all recorded actions.
+func (r *SimulationRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = nil
+}
+
+func (r *SimulationRecorder) add(a SimulatedAction) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, a)
+}
+
+// WithSimulation runs the backend in dry-run mode. Commands and API calls
+// that would change the system are not executed: they are recorded in rec
+// and the operation returns a synthesized "no change" result. Read-only
+// operations run normally. rec may be nil to simulate without recording.
+//
+//	rec := pm.NewSimulationRecorder()
+//	mgr := pm.NewFlatpak(pm.WithSimulation(rec))
+//	mgr.(pm.Installer).Install(ctx, pkgs, pm.InstallOptions{})
+//	for _, a := range rec.Skipped() {
+//	    fmt.Println("would run:", a)
+//	}
+func WithSimulation(rec *SimulationRecorder) ConstructorOption {
+	return func(config *backendConfig) {
+		config.simulate = true
+		config.simulation = rec
+	}
+}
+
+// simulationRunner wraps r so mutating commands are recorded instead of run.
+func (c *backendConfig) simulationRunner(kind BackendKind, r runner.Runner) runner.Runner {
+	return runner.NewSimulatingRunner(r, func(op types.Operation, cmd []string, executed bool) {
+		c.simulation.add(SimulatedAction{Backend: kind, Operation: Operation(op), Command: cmd, Executed: executed})
+	})
+}
+
+// simulationTransport records API calls and answers requests that could
+// change state with an empty success response instead of sending them.
+type simulationTransport struct {
+	base http.RoundTripper
+	kind BackendKind
+	rec  *SimulationRecorder
+}
+
+func (t *simulationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	readOnly := req.Method == "" || req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions
+	t.rec.add(SimulatedAction{Backend: t.kind, Method: req.Method, URL: req.URL.String(), Executed: readOnly})
+	if readOnly {
+		return t.base.RoundTrip(req)
+	}
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package pm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithSimulation_RecordsSkippedCommands(t *testing.T) {
+	rec := NewSimulationRecorder()
+	mgr := NewFlatpak(WithSimulation(rec), WithoutOperationLock())
+
+	res, err := Must[Installer](mgr).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if res.Changed {
+		t.Error("Simulated install should report no change")
+	}
+
+	skipped := rec.Skipped()
+	if len(skipped) != 1 {
+		t.Fatalf("Expected 1 skipped action, got %+v", rec.Actions())
+	}
+	a := skipped[0]
+	if a.Backend != BackendFlatpak || a.Operation != OperationInstall || a.Command[0] != "flatpak" {
+		t.Errorf("Unexpected action %+v", a)
+	}
+	if !strings.Contains(a.String(), "org.gimp.GIMP") {
+		t.Errorf("Expected command line to name the package, got %q", a.String())
+	}
+
+	rec.Reset()
+	if len(rec.Actions()) != 0 {
+		t.Error("Expected no actions after Reset")
+	}
+}
+
+func TestSimulationTransport(t *testing.T) {
+	var posts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			atomic.AddInt32(&posts, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	rec := NewSimulationRecorder()
+	cfg := &backendConfig{}
+	WithSimulation(rec)(cfg)
+	client := cfg.httpClient(BackendSnap, http.DefaultTransport)
+
+	resp, err := client.Get(srv.URL + "/v2/find")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	resp, err = client.Post(srv.URL+"/v2/snaps/hello", "application/json", strings.NewReader(`{"action":"install"}`))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if posts != 0 {
+		t.Error("Simulated POST should not reach the server")
+	}
+	actions := rec.Actions()
+	if len(actions) != 2 || !actions[0].Executed || actions[1].Executed || actions[1].Method != http.MethodPost {
+		t.Errorf("Unexpected actions %+v", actions)
+	}
+}
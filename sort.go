@@ -0,0 +1,73 @@
+package pm
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/frostyard/pm/version"
+)
+
+// SortInstalled sorts pkgs in place as ListOptions.SortBy and Descending
+// do, for callers that merge listings from several backends. It returns a
+// ValidationError if by is not a known ListSort; an empty by leaves pkgs
+// unchanged.
+func SortInstalled(pkgs []InstalledPackage, by ListSort, descending bool) error {
+	// has reports whether a package has the field; key compares two that do
+	var has func(InstalledPackage) bool
+	var key func(a, b InstalledPackage) int
+	switch by {
+	case "":
+		return nil
+	case SortByName:
+	case SortByVersion:
+		has = func(p InstalledPackage) bool { return p.Version != "" }
+		key = func(a, b InstalledPackage) int { return version.Compare(a.Version, b.Version) }
+	case SortBySize:
+		has = func(p InstalledPackage) bool { return p.Size != 0 }
+		key = func(a, b InstalledPackage) int { return cmp.Compare(a.Size, b.Size) }
+	case SortByInstallDate:
+		has = func(p InstalledPackage) bool { return !p.InstalledAt.IsZero() }
+		key = func(a, b InstalledPackage) int { return a.InstalledAt.Compare(b.InstalledAt) }
+	default:
+		return &ValidationError{
+			Operation: OperationListInstalled,
+			Field:     "SortBy",
+			Value:     string(by),
+			Reason:    "unknown sort field",
+		}
+	}
+
+	compare := func(a, b InstalledPackage) int {
+		if has != nil {
+			switch ha, hb := has(a), has(b); {
+			case ha && !hb:
+				return -1
+			case hb && !ha:
+				return 1
+			case ha:
+				c := key(a, b)
+				if descending {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+		}
+		c := cmp.Or(
+			strings.Compare(strings.ToLower(a.Ref.Name), strings.ToLower(b.Ref.Name)),
+			strings.Compare(a.Ref.Name, b.Ref.Name),
+		)
+		if descending && by == SortByName {
+			c = -c
+		}
+		return c
+	}
+	// Backends that already list in the requested order, as brew and snap
+	// do by name, then cost only the check
+	if !slices.IsSortedFunc(pkgs, compare) {
+		slices.SortStableFunc(pkgs, compare)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+func TestSortInstalled(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	pkgs := []InstalledPackage{
+		{Ref: PackageRef{Name: "wget"}, Version: "1.21.4", Size: 4_000, InstalledAt: day(3)},
+		{Ref: PackageRef{Name: "Git"}, Version: "2.9.0", Size: 60_000, InstalledAt: day(1)},
+		{Ref: PackageRef{Name: "jq"}, Version: "1.7"},
+		{Ref: PackageRef{Name: "curl"}, Version: "2.10.0", Size: 4_000, InstalledAt: day(2)},
+	}
+	tests := []struct {
+		by         ListSort
+		descending bool
+		want       string
+	}{
+		{"", false, "[wget Git jq curl]"},
+		{SortByName, false, "[curl Git jq wget]"},
+		{SortByName, true, "[wget jq Git curl]"},
+		{SortByVersion, false, "[jq wget Git curl]"},
+		{SortByVersion, true, "[curl Git wget jq]"},
+		// Packages without a size come last either way
+		{SortBySize, false, "[curl wget Git jq]"},
+		{SortBySize, true, "[Git curl wget jq]"},
+		{SortByInstallDate, false, "[Git curl wget jq]"},
+	}
+	for _, tt := range tests {
+		sorted := append([]InstalledPackage(nil), pkgs...)
+		if err := SortInstalled(sorted, tt.by, tt.descending); err != nil {
+			t.Fatalf("SortInstalled(%q) error = %v", tt.by, err)
+		}
+		var names []string
+		for _, p := range sorted {
+			names = append(names, p.Ref.Name)
+		}
+		if got := fmt.Sprint(names); got != tt.want {
+			t.Errorf("SortInstalled(%q, descending %v) = %v, want %v", tt.by, tt.descending, got, tt.want)
+		}
+	}
+
+	if err := SortInstalled(pkgs, "popularity", false); !IsValidation(err) {
+		t.Errorf("SortInstalled(popularity) error = %v, want a ValidationError", err)
+	}
+}
+
+func TestBackendAdapter_ListInstalledSortBy(t *testing.T) {
+	backend := &countingBackend{installed: []types.InstalledPackage{
+		{Ref: types.PackageRef{Name: "wget"}, Version: "1.21.4"},
+		{Ref: types.PackageRef{Name: "git"}, Version: "2.44.0"},
+	}}
+	adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{})
+
+	pkgs, err := adapter.ListInstalled(context.Background(), ListOptions{SortBy: SortByName})
+	if err != nil || len(pkgs) != 2 || pkgs[0].Ref.Name != "git" {
+		t.Errorf("ListInstalled(SortByName) = %v, %v; want git first", pkgs, err)
+	}
+	var names []string
+	for pkg, err := range adapter.ListInstalledSeq(context.Background(), ListOptions{SortBy: SortByVersion, Descending: true}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, pkg.Ref.Name)
+	}
+	if fmt.Sprint(names) != "[git wget]" {
+		t.Errorf("ListInstalledSeq(SortByVersion, Descending) = %v, want [git wget]", names)
+	}
+
+	_, err = adapter.ListInstalled(context.Background(), ListOptions{SortBy: "size-on-disk"})
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || valErr.Backend != "brew" || valErr.Field != "SortBy" {
+		t.Errorf("ListInstalled(unknown SortBy) error = %v, want a ValidationError for brew", err)
+	}
+}
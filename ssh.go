@@ -0,0 +1,51 @@
+package pm
+
+import "github.com/frostyard/pm/internal/runner"
+
+// SSHConfig describes a remote host for NewSSHRunner.
+type SSHConfig struct {
+	// Host is the host name or ssh_config alias to connect to.
+	Host string
+
+	// User is the remote user; empty uses ssh's default.
+	User string
+
+	// Port is the remote port; zero uses ssh's default.
+	Port int
+
+	// IdentityFile is the private key to authenticate with.
+	IdentityFile string
+
+	// KnownHostsFile replaces the user's known_hosts file.
+	KnownHostsFile string
+
+	// Options are extra ssh -o options, e.g. "ConnectTimeout=10".
+	Options []string
+}
+
+// NewSSHRunner returns a Runner that executes commands on a remote host
+// with the system ssh client, so one controller can manage packages on many
+// machines without an agent. Use it with WithRunner:
+//
+//	mgr := pm.NewFlatpak(pm.WithRunner(pm.NewSSHRunner(pm.SSHConfig{Host: "web1"})))
+//
+// ssh runs in batch mode: keys must be set up and the host key known, since
+// ssh fails instead of prompting. Escalation prefixes such as `sudo -n` are
+// run remotely, but whether a command needs one is decided by the local
+// user: when pm itself runs as root, connect as root too.
+//
+// Brew searches use the Formulae API and snap availability is checked on
+// the local snapd socket; only commands run remotely.
+//
+// A Host or User starting with "-" would be taken by ssh as an option, so
+// every command run through such a runner fails.
+func NewSSHRunner(config SSHConfig) Runner {
+	return runner.NewSSHRunner(runner.NewRealRunner(), runner.SSHConfig{
+		Host:           config.Host,
+		User:           config.User,
+		Port:           config.Port,
+		IdentityFile:   config.IdentityFile,
+		KnownHostsFile: config.KnownHostsFile,
+		Options:        config.Options,
+	})
+}
@@ -0,0 +1,277 @@
+package pm
+
+import (
+	"context"
+	"fmt"
+)
+
+// StrictManager wraps a Manager so that every operation is checked against
+// the backend's Capabilities before it runs. Unsupported calls return a
+// NotSupportedError immediately without running anything.
+//
+// StrictManager implements every optional interface; whether an operation
+// actually works is decided by the wrapped manager's type and capabilities.
+type StrictManager struct {
+	mgr Manager
+}
+
+// Strict wraps mgr with capability enforcement.
+func Strict(mgr Manager) *StrictManager {
+	return &StrictManager{mgr: mgr}
+}
+
+// Unwrap returns the wrapped manager.
+func (s *StrictManager) Unwrap() Manager {
+	return s.mgr
+}
+
+// Name delegates to the wrapped manager.
+func (s *StrictManager) Name() string {
+	return s.mgr.Name()
+}
+
+// Kind delegates to the wrapped manager.
+func (s *StrictManager) Kind() BackendKind {
+	return s.mgr.Kind()
+}
+
+// Available delegates to the wrapped manager.
+func (s *StrictManager) Available(ctx context.Context) (bool, error) {
+	return s.mgr.Available(ctx)
+}
+
+// Capabilities delegates to the wrapped manager.
+func (s *StrictManager) Capabilities(ctx context.Context) ([]Capability, error) {
+	return s.mgr.Capabilities(ctx)
+}
+
+// check returns a NotSupportedError unless the wrapped manager reports op as supported.
+func (s *StrictManager) check(ctx context.Context, op Operation) error {
+	caps, err := s.mgr.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	if Supports(caps, op) {
+		return nil
+	}
+	reason := "not reported by Capabilities"
+	if c := GetCapability(caps, op); c != nil && c.Notes != "" {
+		reason = c.Notes
+	}
+	return &NotSupportedError{Operation: op, Backend: backendName(s.mgr), Reason: reason}
+}
+
+// Update implements Updater.
+func (s *StrictManager) Update(ctx context.Context, opts UpdateOptions) (UpdateResult, error) {
+	updater, err := As[Updater](s.mgr)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	if err := s.check(ctx, OperationUpdateMetadata); err != nil {
+		return UpdateResult{}, err
+	}
+	return updater.Update(ctx, opts)
+}
+
+// Upgrade implements Upgrader.
+func (s *StrictManager) Upgrade(ctx context.Context, opts UpgradeOptions) (UpgradeResult, error) {
+	upgrader, err := As[Upgrader](s.mgr)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+	if err := s.check(ctx, OperationUpgradePackages); err != nil {
+		return UpgradeResult{}, err
+	}
+	return upgrader.Upgrade(ctx, opts)
+}
+
+// Install implements Installer.
+func (s *StrictManager) Install(ctx context.Context, pkgs []PackageRef, opts InstallOptions) (InstallResult, error) {
+	installer, err := As[Installer](s.mgr)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	if err := s.check(ctx, OperationInstall); err != nil {
+		return InstallResult{}, err
+	}
+	return installer.Install(ctx, pkgs, opts)
+}
+
+// Uninstall implements Uninstaller.
+func (s *StrictManager) Uninstall(ctx context.Context, pkgs []PackageRef, opts UninstallOptions) (UninstallResult, error) {
+	uninstaller, err := As[Uninstaller](s.mgr)
+	if err != nil {
+		return UninstallResult{}, err
+	}
+	if err := s.check(ctx, OperationUninstall); err != nil {
+		return UninstallResult{}, err
+	}
+	return uninstaller.Uninstall(ctx, pkgs, opts)
+}
+
+// Search implements Searcher.
+func (s *StrictManager) Search(ctx context.Context, query string, opts SearchOptions) ([]PackageRef, error) {
+	searcher, err := As[Searcher](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationSearch); err != nil {
+		return nil, err
+	}
+	return searcher.Search(ctx, query, opts)
+}
+
+// ListInstalled implements Lister.
+func (s *StrictManager) ListInstalled(ctx context.Context, opts ListOptions) ([]InstalledPackage, error) {
+	lister, err := As[Lister](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationListInstalled); err != nil {
+		return nil, err
+	}
+	return lister.ListInstalled(ctx, opts)
+}
+
+// ListOutdated implements OutdatedLister.
+func (s *StrictManager) ListOutdated(ctx context.Context, opts OutdatedOptions) ([]OutdatedPackage, error) {
+	lister, err := As[OutdatedLister](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationListOutdated); err != nil {
+		return nil, err
+	}
+	return lister.ListOutdated(ctx, opts)
+}
+
+// Query implements Querier.
+func (s *StrictManager) Query(ctx context.Context, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error) {
+	querier, err := As[Querier](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationQuery); err != nil {
+		return nil, err
+	}
+	return querier.Query(ctx, pkgs, opts)
+}
+
+// Verify implements Verifier.
+func (s *StrictManager) Verify(ctx context.Context, pkgs []PackageRef, opts VerifyOptions) ([]Attestation, error) {
+	verifier, err := As[Verifier](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationVerify); err != nil {
+		return nil, err
+	}
+	return verifier.Verify(ctx, pkgs, opts)
+}
+
+// Launch implements Launcher.
+func (s *StrictManager) Launch(ctx context.Context, ref PackageRef, opts LaunchOptions) (*Process, error) {
+	launcher, err := As[Launcher](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationLaunch); err != nil {
+		return nil, err
+	}
+	return launcher.Launch(ctx, ref, opts)
+}
+
+// Icon implements IconFetcher.
+func (s *StrictManager) Icon(ctx context.Context, ref PackageRef, opts IconOptions) (string, error) {
+	fetcher, err := As[IconFetcher](s.mgr)
+	if err != nil {
+		return "", err
+	}
+	if err := s.check(ctx, OperationIcon); err != nil {
+		return "", err
+	}
+	return fetcher.Icon(ctx, ref, opts)
+}
+
+// Watch implements Watcher.
+func (s *StrictManager) Watch(ctx context.Context) (<-chan PackageEvent, error) {
+	watcher, err := As[Watcher](s.mgr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, OperationWatch); err != nil {
+		return nil, err
+	}
+	return watcher.Watch(ctx)
+}
+
+// As asserts that mgr implements the optional interface T, such as
+// Installer or Querier. If it does not, As returns a NotSupportedError for
+// the corresponding operation.
+//
+// Managers wrapped by Strict or Wrap are unwrapped first, so As reports
+// what the underlying backend implements.
+func As[T any](mgr Manager) (T, error) {
+	if u, ok := mgr.(interface{ Unwrap() Manager }); ok {
+		if _, err := As[T](u.Unwrap()); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	if v, ok := mgr.(T); ok {
+		return v, nil
+	}
+	var zero T
+	return zero, &NotSupportedError{Operation: operationFor[T](), Backend: backendName(mgr), Reason: "interface not implemented"}
+}
+
+// Must is like As but panics if mgr does not implement T. It is intended for
+// backends known to implement T, such as those returned by NewBrew,
+// NewFlatpak and NewSnap.
+func Must[T any](mgr Manager) T {
+	v, err := As[T](mgr)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// operationFor maps an optional interface to the operation it provides.
+func operationFor[T any]() Operation {
+	switch any((*T)(nil)).(type) {
+	case *Updater:
+		return OperationUpdateMetadata
+	case *Upgrader:
+		return OperationUpgradePackages
+	case *Installer:
+		return OperationInstall
+	case *Uninstaller:
+		return OperationUninstall
+	case *Searcher:
+		return OperationSearch
+	case *Lister:
+		return OperationListInstalled
+	case *OutdatedLister:
+		return OperationListOutdated
+	case *Querier:
+		return OperationQuery
+	case *Verifier:
+		return OperationVerify
+	case *Launcher:
+		return OperationLaunch
+	case *IconFetcher:
+		return OperationIcon
+	case *Watcher:
+		return OperationWatch
+	}
+	var zero T
+	return Operation(fmt.Sprintf("%T", &zero)[1:])
+}
+
+// backendName returns the backend kind of mgr if known, for error messages.
+func backendName(mgr Manager) string {
+	if kind := mgr.Kind(); kind != "" {
+		return string(kind)
+	}
+	return fmt.Sprintf("%T", mgr)
+}
@@ -0,0 +1,114 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStrict_BlocksUnsupportedOperations(t *testing.T) {
+	fake := &fakeManager{caps: []Capability{
+		{Operation: OperationSearch, Supported: true},
+		{Operation: OperationInstall, Supported: false, Notes: "read-only image"},
+	}}
+	m := Strict(fake)
+	ctx := context.Background()
+
+	if _, err := m.Search(ctx, "git", SearchOptions{}); err != nil {
+		t.Errorf("Search() error = %v", err)
+	}
+
+	_, err := m.Install(ctx, []PackageRef{{Name: "git"}}, InstallOptions{})
+	var nse *NotSupportedError
+	if !errors.As(err, &nse) || nse.Operation != OperationInstall || nse.Reason != "read-only image" {
+		t.Errorf("Expected NotSupportedError with capability notes, got %v", err)
+	}
+	if len(fake.installCalls) != 0 {
+		t.Error("Unsupported Install should not reach the backend")
+	}
+
+	// Supported by capabilities but not implemented counts as unsupported too.
+	if _, err := m.Update(ctx, UpdateOptions{}); !IsNotSupported(err) {
+		t.Errorf("Expected NotSupportedError for missing Updater, got %v", err)
+	}
+	// Implemented but not listed in capabilities.
+	if _, err := m.ListInstalled(ctx, ListOptions{}); !IsNotSupported(err) {
+		t.Errorf("Expected NotSupportedError for unlisted ListInstalled, got %v", err)
+	}
+}
+
+func TestAs(t *testing.T) {
+	fake := &fakeManager{}
+
+	if _, err := As[Searcher](fake); err != nil {
+		t.Errorf("As[Searcher] error = %v", err)
+	}
+
+	_, err := As[Upgrader](fake)
+	var nse *NotSupportedError
+	if !errors.As(err, &nse) || nse.Operation != OperationUpgradePackages {
+		t.Errorf("Expected NotSupportedError for Upgrade, got %v", err)
+	}
+
+	// Strict implements every interface, but As reports what the wrapped backend supports.
+	if _, err := As[Upgrader](Strict(fake)); !IsNotSupported(err) {
+		t.Errorf("Expected As to see through Strict, got %v", err)
+	}
+	if _, err := As[Searcher](Strict(fake)); err != nil {
+		t.Errorf("As[Searcher](Strict) error = %v", err)
+	}
+}
+
+func TestMust(t *testing.T) {
+	_ = Must[Installer](NewBrew())
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected Must to panic for a missing interface")
+		}
+	}()
+	Must[Updater](&fakeManager{})
+}
+
+// querierManager is a fakeManager that also implements Querier.
+type querierManager struct {
+	fakeManager
+}
+
+func (q *querierManager) Query(ctx context.Context, pkgs []PackageRef, opts QueryOptions) ([]InstalledPackage, error) {
+	return nil, nil
+}
+
+func TestStrict_OptionalInterfaces(t *testing.T) {
+	fake := &querierManager{fakeManager{caps: []Capability{{Operation: OperationQuery, Supported: true}}}}
+	m := Strict(fake)
+	ctx := context.Background()
+
+	if _, err := As[Querier](m); err != nil {
+		t.Fatalf("As[Querier](Strict) error = %v", err)
+	}
+	if _, err := m.Query(ctx, []PackageRef{{Name: "jq"}}, QueryOptions{}); err != nil {
+		t.Errorf("Query() error = %v", err)
+	}
+
+	// Not implemented by the backend
+	_, err := m.Verify(ctx, []PackageRef{{Name: "jq"}}, VerifyOptions{})
+	var nse *NotSupportedError
+	if !errors.As(err, &nse) || nse.Operation != OperationVerify {
+		t.Errorf("Verify() error = %v, want a NotSupportedError for Verify", err)
+	}
+	for op, err := range map[Operation]error{
+		OperationListOutdated: second(As[OutdatedLister](m)),
+		OperationLaunch:       second(As[Launcher](m)),
+		OperationIcon:         second(As[IconFetcher](m)),
+		OperationWatch:        second(As[Watcher](m)),
+	} {
+		if !errors.As(err, &nse) || nse.Operation != op {
+			t.Errorf("As() error = %v, want a NotSupportedError for %s", err, op)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
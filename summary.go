@@ -0,0 +1,65 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// summarizer holds back the end event of the action an operation reports
+// until the operation has returned, then reports it with an ActionSummary.
+type summarizer struct {
+	pr ProgressReporter
+
+	mu   sync.Mutex
+	held *ProgressAction
+}
+
+// finish reports the held action end, if any, summarizing err and the
+// number of packages changed. It is a no-op on a nil summarizer.
+func (s *summarizer) finish(err error, changed int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	action := s.held
+	s.held = nil
+	s.mu.Unlock()
+	if action == nil {
+		return
+	}
+
+	summary := &ActionSummary{
+		Outcome:         OutcomeSuccess,
+		PackagesChanged: changed,
+		Duration:        action.EndedAt.Sub(action.StartedAt),
+	}
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		summary.Outcome, summary.Error = OutcomeCancelled, err.Error()
+	case err != nil:
+		summary.Outcome, summary.Error = OutcomeFailure, err.Error()
+	}
+	action.Summary = summary
+	s.pr.OnAction(*action)
+}
+
+func (s *summarizer) OnAction(action ProgressAction) {
+	if action.EndedAt.IsZero() {
+		s.pr.OnAction(action)
+		return
+	}
+	s.mu.Lock()
+	prev := s.held
+	s.held = &action
+	s.mu.Unlock()
+	// Backends report one action per operation; should one report more,
+	// only the last is summarized.
+	if prev != nil {
+		s.pr.OnAction(*prev)
+	}
+}
+
+func (s *summarizer) OnTask(task ProgressTask)      { s.pr.OnTask(task) }
+func (s *summarizer) OnStep(step ProgressStep)      { s.pr.OnStep(step) }
+func (s *summarizer) OnMessage(msg ProgressMessage) { s.pr.OnMessage(msg) }
@@ -0,0 +1,87 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// actionRecorder records action events.
+type actionRecorder struct {
+	mu      sync.Mutex
+	actions []ProgressAction
+	last    string
+}
+
+func (r *actionRecorder) OnAction(a ProgressAction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions = append(r.actions, a)
+	r.last = "action"
+}
+func (r *actionRecorder) OnTask(ProgressTask)       { r.last = "task" }
+func (r *actionRecorder) OnStep(ProgressStep)       { r.last = "step" }
+func (r *actionRecorder) OnMessage(ProgressMessage) { r.last = "message" }
+
+func TestActionSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		installErr  error
+		wantOutcome Outcome
+		wantChanged int
+	}{
+		{"success", nil, OutcomeSuccess, 2},
+		{"failure", &types.ExternalFailureError{Operation: types.OperationInstall, Backend: "brew", Err: errors.New("exit status 1")}, OutcomeFailure, 0},
+		{"cancelled", &types.CancelledError{Operation: types.OperationInstall, Backend: "brew", Err: context.Canceled}, OutcomeCancelled, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &actionRecorder{}
+			backend := &progressBackend{countingBackend{installErr: tt.installErr}}
+			adapter := newBackendAdapter(BackendBrew, backend, &backendConfig{noLock: true})
+
+			_, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}, {Name: "wget"}}, InstallOptions{Progress: rec})
+			if (err != nil) != (tt.installErr != nil) {
+				t.Fatalf("Install() error = %v", err)
+			}
+
+			if len(rec.actions) != 2 || rec.last != "action" {
+				t.Fatalf("Expected start and end action, with the end last; got %d actions, last event %s", len(rec.actions), rec.last)
+			}
+			if rec.actions[0].Summary != nil {
+				t.Error("Start event should not carry a summary")
+			}
+			summary := rec.actions[1].Summary
+			if summary == nil {
+				t.Fatal("Expected a summary on the end event")
+			}
+			if summary.Outcome != tt.wantOutcome || summary.PackagesChanged != tt.wantChanged {
+				t.Errorf("Summary = %+v, want outcome %s with %d changed", summary, tt.wantOutcome, tt.wantChanged)
+			}
+			if summary.Duration != rec.actions[1].EndedAt.Sub(rec.actions[1].StartedAt) {
+				t.Errorf("Summary.Duration = %v, want the action's duration", summary.Duration)
+			}
+			if (summary.Error != "") != (err != nil) {
+				t.Errorf("Summary.Error = %q for error %v", summary.Error, err)
+			}
+		})
+	}
+}
+
+func TestSummarizer_NoAction(t *testing.T) {
+	rec := &actionRecorder{}
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, &backendConfig{noLock: true})
+
+	if _, err := adapter.Install(context.Background(), []PackageRef{{Name: "git"}}, InstallOptions{Progress: rec}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if len(rec.actions) != 0 {
+		t.Errorf("Expected no events from a backend that reports none, got %d", len(rec.actions))
+	}
+
+	var s *summarizer
+	s.finish(nil, 0)
+}
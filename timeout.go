@@ -0,0 +1,31 @@
+package pm
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout sets a default deadline for each operation. It applies only
+// when the caller's context has no deadline of its own, so an explicit
+// context.WithTimeout or WithDeadline always wins. A duration of zero or
+// less disables the default.
+//
+// The deadline covers the whole operation, including time spent waiting for
+// other operations on the same backend (see OperationMeta.QueueWait).
+func WithTimeout(d time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		config.timeout = d
+	}
+}
+
+// withDeadline applies the adapter's default timeout to ctx if it has no
+// deadline. The returned cancel function must always be called.
+func (a *backendAdapter) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, a.timeout)
+}
@@ -0,0 +1,85 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// deadlineBackend records the deadline of the context each Search receives
+// and blocks until that context is done.
+type deadlineBackend struct {
+	countingBackend
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (b *deadlineBackend) Search(ctx context.Context, query string, opts types.SearchOptions) ([]types.PackageRef, error) {
+	b.deadline, b.hasDeadline = ctx.Deadline()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithTimeout_AppliesDefaultDeadline(t *testing.T) {
+	backend := &deadlineBackend{}
+	cfg := &backendConfig{}
+	WithTimeout(20 * time.Millisecond)(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	_, err := adapter.Search(context.Background(), "git", SearchOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if !backend.hasDeadline {
+		t.Error("Expected backend context to carry a deadline")
+	}
+}
+
+func TestWithTimeout_CallerDeadlineWins(t *testing.T) {
+	backend := &deadlineBackend{}
+	cfg := &backendConfig{}
+	WithTimeout(time.Hour)(cfg)
+	adapter := newBackendAdapter(BackendBrew, backend, cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	want, _ := ctx.Deadline()
+
+	_, _ = adapter.Search(ctx, "git", SearchOptions{})
+	if !backend.deadline.Equal(want) {
+		t.Errorf("deadline = %v, want caller's %v", backend.deadline, want)
+	}
+}
+
+func TestWithTimeout_Disabled(t *testing.T) {
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, &backendConfig{})
+
+	ctx, cancel := adapter.withDeadline(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline without WithTimeout")
+	}
+}
+
+func TestWithTimeout_ReturnsTimeoutError(t *testing.T) {
+	blocking := RunnerFunc(func(ctx context.Context, name string, args ...string) (string, string, error) {
+		<-ctx.Done()
+		return "Installing 1/2", "", ctx.Err()
+	})
+	mgr := NewFlatpak(WithRunner(blocking), WithTimeout(20*time.Millisecond), WithoutOperationLock())
+
+	_, err := mgr.(Installer).Install(context.Background(), []PackageRef{{Name: "org.gimp.GIMP"}}, InstallOptions{})
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+	if timeoutErr.Backend != "flatpak" || timeoutErr.Operation != OperationInstall || timeoutErr.Elapsed <= 0 || timeoutErr.PartialStdout != "Installing 1/2" {
+		t.Errorf("Unexpected %+v", timeoutErr)
+	}
+	if !IsTimeout(err) || !errors.Is(err, context.DeadlineExceeded) || IsExternalFailure(err) {
+		t.Errorf("Unexpected classification of %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package pm
 
+import "time"
+
 // Operation represents a package manager operation type.
 type Operation string
 
@@ -22,8 +24,26 @@ const (
 	// OperationListInstalled lists installed packages.
 	OperationListInstalled Operation = "ListInstalled"
 
+	// OperationListOutdated lists installed packages that have updates.
+	OperationListOutdated Operation = "ListOutdated"
+
 	// OperationListAvailable lists available packages (if supported).
 	OperationListAvailable Operation = "ListAvailable"
+
+	// OperationQuery reports the installed state of specific packages.
+	OperationQuery Operation = "Query"
+
+	// OperationVerify reports the provenance of specific packages.
+	OperationVerify Operation = "Verify"
+
+	// OperationLaunch starts an installed application.
+	OperationLaunch Operation = "Launch"
+
+	// OperationIcon fetches the icon of an application.
+	OperationIcon Operation = "Icon"
+
+	// OperationWatch watches for changes to installed packages.
+	OperationWatch Operation = "Watch"
 )
 
 // PackageRef identifies a package in a backend-agnostic way.
@@ -51,6 +71,91 @@ type InstalledPackage struct {
 
 	// Status is the installation status (e.g., "installed", "held", "disabled").
 	Status string
+
+	// Size is the installed size in bytes, or 0 if the backend does not
+	// report it.
+	Size int64
+
+	// InstalledAt is when the package was installed, or the zero time if
+	// the backend does not report it.
+	InstalledAt time.Time
+}
+
+// OutdatedPackage is an installed package with a newer version available.
+type OutdatedPackage struct {
+	// Ref is the package reference.
+	Ref PackageRef
+
+	// InstalledVersion is the version installed now, or "" if the backend
+	// does not report it.
+	InstalledVersion string
+
+	// AvailableVersion is the version an upgrade would install, or "" if
+	// the backend does not report it.
+	AvailableVersion string
+
+	// Security reports that the update fixes a security issue. It is set
+	// only when the backend's metadata says so; brew, flatpak and snap
+	// publish no such marking, so their updates never have it.
+	Security bool
+}
+
+// AttestationStatus summarizes an Attestation.
+type AttestationStatus string
+
+const (
+	// AttestationVerified means the backend checks the package's content
+	// against a signed or checksummed source.
+	AttestationVerified AttestationStatus = "verified"
+
+	// AttestationUnverified means nothing vouches for the package's
+	// content, e.g. a snap installed with --dangerous, a flatpak remote
+	// without GPG verification, or a formula built from source.
+	AttestationUnverified AttestationStatus = "unverified"
+
+	// AttestationNotFound means the backend knows no such package.
+	AttestationNotFound AttestationStatus = "not-found"
+)
+
+// Attestation reports the provenance of one package.
+type Attestation struct {
+	// Ref is the package.
+	Ref PackageRef
+
+	// Installed reports whether the package is installed. If it is not,
+	// the attestation describes what Install would fetch.
+	Installed bool
+
+	// Version is the installed version, or the one Install would fetch.
+	Version string
+
+	// Source is where the package comes from: "snap store" (or "local"
+	// for sideloaded snaps), the flatpak remote, or the brew tap.
+	Source string
+
+	// Publisher is who published the package, where the backend records
+	// it: the snap publisher's username.
+	Publisher string
+
+	// PublisherVerified reports that the store has verified the
+	// publisher's identity (snap's verified and starred publishers).
+	PublisherVerified bool
+
+	// Signed reports that the package's content is checked against its
+	// source: snap assertions, flatpak remote GPG signatures, brew bottle
+	// and cask checksums.
+	Signed bool
+
+	// Digest is the content digest checked, if the backend reports one:
+	// the flatpak commit or the brew bottle's or cask's SHA-256.
+	Digest string
+
+	// Status summarizes the attestation.
+	Status AttestationStatus
+
+	// Findings explain the status and note anything a careful user should
+	// know, e.g. an unverified publisher or a third-party tap.
+	Findings []string
 }
 
 // Capability represents an operation that a backend supports.
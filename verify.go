@@ -0,0 +1,58 @@
+package pm
+
+import (
+	"context"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// internalVerifier is implemented by backends that can report the
+// provenance of packages.
+type internalVerifier interface {
+	Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error)
+}
+
+// Verify implements Verifier. It fails with NotSupportedError if the
+// backend cannot attest packages.
+func (a *backendAdapter) Verify(ctx context.Context, pkgs []PackageRef, opts VerifyOptions) ([]Attestation, error) {
+	verifier, ok := a.backend.(internalVerifier)
+	if !ok {
+		return nil, &NotSupportedError{Operation: OperationVerify, Backend: string(a.kind)}
+	}
+
+	ctx, cancel := a.operationContext(ctx, opts.Progress)
+	defer cancel()
+	internalPkgs := make([]types.PackageRef, len(pkgs))
+	for i, p := range pkgs {
+		internalPkgs[i] = types.PackageRef{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+			Channel:   p.Channel,
+			Kind:      p.Kind,
+		}
+	}
+	pr, summary := a.reporter(ctx, opts.Progress)
+	internalRes, err := verifier.Verify(ctx, internalPkgs, types.VerifyOptions{Progress: pr})
+	if err != nil {
+		err = a.convertError(ctx, err)
+		summary.finish(err, 0)
+		return nil, err
+	}
+	result := make([]Attestation, len(internalRes))
+	for i, at := range internalRes {
+		result[i] = Attestation{
+			Ref:               convertPackageRef(at.Ref),
+			Installed:         at.Installed,
+			Version:           at.Version,
+			Source:            at.Source,
+			Publisher:         at.Publisher,
+			PublisherVerified: at.PublisherVerified,
+			Signed:            at.Signed,
+			Digest:            at.Digest,
+			Status:            AttestationStatus(at.Status),
+			Findings:          at.Findings,
+		}
+	}
+	summary.finish(nil, 0)
+	return result, nil
+}
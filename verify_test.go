@@ -0,0 +1,43 @@
+package pm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// verifyBackend attests every package it is asked about as an unsigned
+// formula.
+type verifyBackend struct {
+	countingBackend
+}
+
+func (b *verifyBackend) Verify(ctx context.Context, pkgs []types.PackageRef, opts types.VerifyOptions) ([]types.Attestation, error) {
+	var out []types.Attestation
+	for _, p := range pkgs {
+		at := types.Attestation{Ref: p, Source: "acme/tools", Findings: []string{"from third-party tap acme/tools"}}
+		at.Settle()
+		out = append(out, at)
+	}
+	return out, nil
+}
+
+func TestBackendAdapter_Verify(t *testing.T) {
+	mgr := Wrap(newBackendAdapter(BackendBrew, &verifyBackend{}, &backendConfig{}))
+
+	got, err := mgr.Verify(context.Background(), []PackageRef{{Name: "widget"}}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Ref.Name != "widget" || got[0].Status != AttestationUnverified || len(got[0].Findings) != 1 {
+		t.Errorf("Verify() = %+v, want one unverified attestation for widget", got)
+	}
+}
+
+func TestBackendAdapter_VerifyNotSupported(t *testing.T) {
+	adapter := newBackendAdapter(BackendBrew, &countingBackend{}, &backendConfig{})
+	if _, err := adapter.Verify(context.Background(), []PackageRef{{Name: "git"}}, VerifyOptions{}); !IsNotSupported(err) {
+		t.Errorf("Verify() error = %v, want NotSupportedError", err)
+	}
+}
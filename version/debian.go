@@ -0,0 +1,79 @@
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareDebian implements dpkg's version ordering.
+func compareDebian(a, b string) int {
+	aEpoch, aUp, aRev := splitDebian(a)
+	bEpoch, bUp, bRev := splitDebian(b)
+	if c := compareInt(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := verrevcmp(aUp, bUp); c != 0 {
+		return c
+	}
+	return verrevcmp(aRev, bRev)
+}
+
+// splitDebian splits [epoch:]upstream[-revision].
+func splitDebian(v string) (epoch int, upstream, revision string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// verrevcmp alternates between comparing non-digit prefixes (with dpkg's
+// character ordering) and numeric runs.
+func verrevcmp(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ac, bc := 0, 0
+			if a != "" && !isDigit(a[0]) {
+				ac = order(a[0])
+			}
+			if b != "" && !isDigit(b[0]) {
+				bc = order(b[0])
+			}
+			if ac != bc {
+				return compareInt(ac, bc)
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		var an, bn string
+		an, a = leadingDigits(a)
+		bn, b = leadingDigits(b)
+		if c := compareNumeric(an, bn); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// order ranks a non-digit character: ~ sorts before everything (even the
+// end of the string), then letters, then other characters.
+func order(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+func leadingDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
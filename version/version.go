@@ -0,0 +1,211 @@
+// Package version compares package version strings the way each backend
+// orders them, so callers can tell whether a candidate is newer than what is
+// installed without resorting to string comparison.
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Scheme is a version ordering.
+type Scheme int
+
+const (
+	// Generic orders semver-like versions (1.2.10 > 1.2.9, 1.0.0-rc1 < 1.0.0).
+	// It is used for flatpak and snap version strings.
+	Generic Scheme = iota
+
+	// Brew is Generic with Homebrew's _N package revision suffix (1.2.3_1 > 1.2.3).
+	Brew
+
+	// Debian follows dpkg ordering: [epoch:]upstream[-revision], with ~
+	// sorting before anything else.
+	Debian
+
+	// SnapRevision orders snap revisions, which are integers optionally
+	// prefixed with "x" for locally installed snaps.
+	SnapRevision
+)
+
+// ForBackend returns the version scheme used by the named backend
+// ("brew", "flatpak", "snap", "apt"). Unknown backends use Generic.
+func ForBackend(backend string) Scheme {
+	switch backend {
+	case "brew":
+		return Brew
+	case "apt", "deb", "dpkg":
+		return Debian
+	default:
+		return Generic
+	}
+}
+
+// Compare returns -1, 0 or +1 depending on whether a is older than, equal
+// to, or newer than b under scheme s.
+func (s Scheme) Compare(a, b string) int {
+	switch s {
+	case Brew:
+		return compareBrew(a, b)
+	case Debian:
+		return compareDebian(a, b)
+	case SnapRevision:
+		return compareRevision(a, b)
+	default:
+		return compareGeneric(a, b)
+	}
+}
+
+// Compare compares a and b using the Generic scheme.
+func Compare(a, b string) int {
+	return Generic.Compare(a, b)
+}
+
+// Newer reports whether candidate is newer than installed under the
+// named backend's scheme.
+func Newer(backend, candidate, installed string) bool {
+	return ForBackend(backend).Compare(candidate, installed) > 0
+}
+
+func compareGeneric(a, b string) int {
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+	aMain, aPre, _ := strings.Cut(a, "-")
+	bMain, bPre, _ := strings.Cut(b, "-")
+	if c := compareTokens(aMain, bMain); c != 0 {
+		return c
+	}
+	// A release sorts after any of its pre-releases.
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareTokens(aPre, bPre)
+}
+
+func compareBrew(a, b string) int {
+	aVer, aRev := splitBrewRevision(a)
+	bVer, bRev := splitBrewRevision(b)
+	if c := compareGeneric(aVer, bVer); c != 0 {
+		return c
+	}
+	return compareInt(aRev, bRev)
+}
+
+// splitBrewRevision splits "1.2.3_1" into "1.2.3" and 1.
+func splitBrewRevision(v string) (string, int) {
+	i := strings.LastIndex(v, "_")
+	if i < 0 {
+		return v, 0
+	}
+	rev, err := strconv.Atoi(v[i+1:])
+	if err != nil {
+		return v, 0
+	}
+	return v[:i], rev
+}
+
+func compareRevision(a, b string) int {
+	ai, _ := strconv.Atoi(strings.TrimPrefix(a, "x"))
+	bi, _ := strconv.Atoi(strings.TrimPrefix(b, "x"))
+	return compareInt(ai, bi)
+}
+
+// compareTokens compares versions split into runs of digits and letters;
+// other characters only separate tokens. Numbers compare numerically and
+// sort after letters. Trailing zero components are insignificant
+// (1.0 == 1.0.0), but any other extra component makes a version newer.
+func compareTokens(a, b string) int {
+	at, bt := tokenize(a), tokenize(b)
+	for i := 0; i < len(at) || i < len(bt); i++ {
+		switch {
+		case i >= len(at):
+			if isZero(bt[i:]) {
+				return 0
+			}
+			return -1
+		case i >= len(bt):
+			if isZero(at[i:]) {
+				return 0
+			}
+			return 1
+		}
+		if c := compareToken(at[i], bt[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func tokenize(v string) []string {
+	var tokens []string
+	start := -1
+	for i := 0; i <= len(v); i++ {
+		if start >= 0 && (i == len(v) || class(v[i]) != class(v[start])) {
+			tokens = append(tokens, v[start:i])
+			start = -1
+		}
+		if i < len(v) && class(v[i]) != 0 && start < 0 {
+			start = i
+		}
+	}
+	return tokens
+}
+
+// class is 1 for digits, 2 for letters, and 0 for separators.
+func class(c byte) int {
+	switch {
+	case isDigit(c):
+		return 1
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return 2
+	}
+	return 0
+}
+
+func compareToken(a, b string) int {
+	aNum, bNum := isDigit(a[0]), isDigit(b[0])
+	switch {
+	case aNum && bNum:
+		return compareNumeric(a, b)
+	case aNum:
+		return 1
+	case bNum:
+		return -1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func isZero(tokens []string) bool {
+	for _, t := range tokens {
+		if strings.TrimLeft(t, "0") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumeric compares digit strings of any length.
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInt(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
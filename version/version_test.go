@@ -0,0 +1,51 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		scheme Scheme
+		a, b   string
+		want   int
+	}{
+		{Generic, "1.2.10", "1.2.9", 1},
+		{Generic, "1.0", "1.0.0", 0},
+		{Generic, "v2.0.0", "2.0.0", 0},
+		{Generic, "1.0.0-rc1", "1.0.0", -1},
+		{Generic, "1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{Generic, "1.0.0+build5", "1.0.0", 0},
+		{Generic, "1.1.1w", "1.1.1", 1},
+		{Generic, "130.0.1", "131.0", -1},
+		{Brew, "1.2.3_1", "1.2.3", 1},
+		{Brew, "1.2.4", "1.2.3_2", 1},
+		{Brew, "1.2.3_10", "1.2.3_9", 1},
+		{Debian, "1:1.0", "2.0", 1},
+		{Debian, "1.0~rc1", "1.0", -1},
+		{Debian, "1.0-1", "1.0-2", -1},
+		{Debian, "2.30-0ubuntu1", "2.30-0ubuntu1.1", -1},
+		{Debian, "1.0a", "1.0+", -1},
+		{Debian, "1.0", "1.0", 0},
+		{SnapRevision, "2017", "987", 1},
+		{SnapRevision, "x2", "x1", 1},
+	}
+	for _, tt := range tests {
+		if got := tt.scheme.Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) with scheme %d = %d, want %d", tt.a, tt.b, tt.scheme, got, tt.want)
+		}
+		if got := tt.scheme.Compare(tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) with scheme %d = %d, want %d", tt.b, tt.a, tt.scheme, got, -tt.want)
+		}
+	}
+}
+
+func TestNewer(t *testing.T) {
+	if !Newer("brew", "3.0.0_1", "3.0.0") {
+		t.Error("Expected brew revision bump to be newer")
+	}
+	if Newer("flatpak", "1.0", "1.0.0") {
+		t.Error("Expected equal versions not to be newer")
+	}
+	if !Newer("apt", "1:0.9", "1.0") {
+		t.Error("Expected higher epoch to be newer")
+	}
+}
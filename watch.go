@@ -0,0 +1,231 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// DefaultWatchInterval is how often Watch checks backends that can tell
+// cheaply whether anything changed, flatpak and snap, when
+// WithWatchInterval is not given.
+const DefaultWatchInterval = 2 * time.Second
+
+// DefaultWatchPollInterval is how often Watch lists the installed packages
+// of backends that cannot tell whether anything changed, such as brew, when
+// WithWatchInterval is not given.
+const DefaultWatchPollInterval = time.Minute
+
+// internalChangeDetector is implemented by backends that can tell cheaply
+// whether their installed packages changed. ChangeToken returns a token
+// that differs whenever they may have; Watch lists the packages only then.
+// It fails with types.ErrNotSupported if it cannot tell, and Watch polls.
+type internalChangeDetector interface {
+	ChangeToken(ctx context.Context) (string, error)
+}
+
+// WithWatchInterval sets how often Watch checks for changes, instead of
+// DefaultWatchInterval or DefaultWatchPollInterval.
+func WithWatchInterval(d time.Duration) ConstructorOption {
+	return func(config *backendConfig) {
+		config.watchInterval = d
+	}
+}
+
+// PackageEventType is what happened to a package, as reported by Watch.
+type PackageEventType string
+
+const (
+	// PackageInstalled means the package was installed.
+	PackageInstalled PackageEventType = "installed"
+
+	// PackageRemoved means the package was uninstalled.
+	PackageRemoved PackageEventType = "removed"
+
+	// PackageUpgraded means a newer version of the package was installed.
+	PackageUpgraded PackageEventType = "upgraded"
+
+	// PackageDowngraded means an older version of the package was
+	// installed, as by a snap revert.
+	PackageDowngraded PackageEventType = "downgraded"
+)
+
+// PackageEvent is a change to an installed package, reported by Watch.
+type PackageEvent struct {
+	Backend BackendKind
+	Type    PackageEventType
+
+	// Package is the package as installed now or, for PackageRemoved, as
+	// it was last listed.
+	Package InstalledPackage
+
+	// PreviousVersion is the version before an upgrade or downgrade.
+	PreviousVersion string
+}
+
+// Watch implements Watcher. It lists the installed packages once, failing
+// if that fails, and then again at every interval, comparing each listing
+// with the one before (see Diff). flatpak and snap are listed only when
+// their change token says something changed. A listing that fails is tried
+// again at the next interval. The listings report no progress; only the
+// events are.
+func (a *backendAdapter) Watch(ctx context.Context) (<-chan PackageEvent, error) {
+	var token string
+	detector, _ := a.backend.(internalChangeDetector)
+	if detector != nil {
+		var err error
+		token, err = detector.ChangeToken(ctx)
+		switch {
+		case errors.Is(err, types.ErrNotSupported):
+			detector = nil
+		case err != nil:
+			return nil, a.convertError(ctx, err)
+		}
+	}
+	interval := a.watchInterval
+	if interval <= 0 {
+		interval = DefaultWatchPollInterval
+		if detector != nil {
+			interval = DefaultWatchInterval
+		}
+	}
+	before, err := a.listQuietly(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan PackageEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if detector != nil {
+				next, err := detector.ChangeToken(ctx)
+				if err != nil || next == token {
+					continue
+				}
+				token = next
+			}
+			after, err := a.listQuietly(ctx)
+			if err != nil {
+				continue
+			}
+			for _, ev := range packageEvents(a.kind, before, after) {
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			before = after
+		}
+	}()
+	return events, nil
+}
+
+// listQuietly lists the installed packages for Watch without reporting
+// progress, so that polling doesn't show up as an operation every interval.
+// The backends fall back to their own reporter when given none, so they are
+// given quietReporter.
+func (a *backendAdapter) listQuietly(ctx context.Context) ([]InstalledPackage, error) {
+	ctx, cancel := a.withDeadline(ctx)
+	defer cancel()
+	internalRes, err := a.backend.ListInstalled(ctx, types.ListOptions{Progress: quietReporter{}})
+	if err != nil {
+		return nil, a.convertError(ctx, err)
+	}
+	result := make([]InstalledPackage, len(internalRes))
+	for i, p := range internalRes {
+		result[i] = convertInstalledPackage(p)
+	}
+	return result, nil
+}
+
+// quietReporter discards all progress.
+type quietReporter struct{}
+
+func (quietReporter) OnAction(types.ProgressAction)   {}
+func (quietReporter) OnTask(types.ProgressTask)       {}
+func (quietReporter) OnStep(types.ProgressStep)       {}
+func (quietReporter) OnMessage(types.ProgressMessage) {}
+
+// packageEvents returns the events that turn the listing before into after,
+// installs first, then removals, upgrades and downgrades.
+func packageEvents(kind BackendKind, before, after []InstalledPackage) []PackageEvent {
+	changes := Diff(before, after)
+	current := make(map[PackageRef]InstalledPackage, len(after))
+	for _, p := range after {
+		current[p.Ref] = p
+	}
+
+	var events []PackageEvent
+	for _, p := range changes.Added {
+		events = append(events, PackageEvent{Backend: kind, Type: PackageInstalled, Package: p})
+	}
+	for _, p := range changes.Removed {
+		events = append(events, PackageEvent{Backend: kind, Type: PackageRemoved, Package: p})
+	}
+	for _, c := range changes.Upgraded {
+		events = append(events, PackageEvent{Backend: kind, Type: PackageUpgraded, Package: current[c.Ref], PreviousVersion: c.From})
+	}
+	for _, c := range changes.Downgraded {
+		events = append(events, PackageEvent{Backend: kind, Type: PackageDowngraded, Package: current[c.Ref], PreviousVersion: c.From})
+	}
+	return events
+}
+
+// Watch watches every backend that implements Watcher and merges their
+// events into one channel, which is closed once ctx is done. A backend
+// whose Watch fails is left out and its error, annotated with the backend
+// kind, is joined into the returned error; the channel is nil only if no
+// backend could be watched.
+func (m *MultiManager) Watch(ctx context.Context) (<-chan PackageEvent, error) {
+	var (
+		chans []<-chan PackageEvent
+		errs  []error
+	)
+	for _, b := range only[Watcher](m.backends) {
+		ch, err := b.Manager.(Watcher).Watch(ctx)
+		if err != nil {
+			errs = append(errs, backendErr(b.Kind, err))
+			continue
+		}
+		chans = append(chans, ch)
+	}
+	if len(chans) == 0 {
+		if len(errs) == 0 {
+			return nil, &NotSupportedError{Operation: OperationWatch, Backend: m.Name()}
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	events := make(chan PackageEvent)
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range ch {
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events, errors.Join(errs...)
+}
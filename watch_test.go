@@ -0,0 +1,206 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frostyard/pm/internal/types"
+)
+
+// watchBackend lists installed packages that tests change while it is
+// watched, and counts the listings. Like the real backends, it reports an
+// action for each listing to opts.Progress or else to its own reporter.
+type watchBackend struct {
+	countingBackend
+
+	mu        sync.Mutex
+	installed []types.InstalledPackage
+	token     int
+	listings  int
+	progress  types.ProgressReporter
+}
+
+func (b *watchBackend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listings++
+	helper := types.NewOperationProgressHelper("brew", types.OperationListInstalled, b.progress, opts.Progress)
+	helper.BeginAction("ListInstalled")
+	helper.EndAction()
+	return append([]types.InstalledPackage(nil), b.installed...), nil
+}
+
+// set replaces the installed packages and bumps the change token, which
+// tokenBackend reports.
+func (b *watchBackend) set(pkgs ...types.InstalledPackage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.installed = pkgs
+	b.token++
+}
+
+func (b *watchBackend) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.listings
+}
+
+// tokenBackend is a watchBackend that reports a change token.
+type tokenBackend struct {
+	watchBackend
+}
+
+func (b *tokenBackend) ChangeToken(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(rune('a' + b.token)), nil
+}
+
+func installedPkg(name, version string) types.InstalledPackage {
+	return types.InstalledPackage{Ref: types.PackageRef{Name: name}, Version: version}
+}
+
+// nextEvent returns the next event on events, failing the test if none
+// comes.
+func nextEvent(t *testing.T, events <-chan PackageEvent) PackageEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("events closed early")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	return PackageEvent{}
+}
+
+func TestBackendAdapter_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &watchBackend{}
+	backend.set(installedPkg("jq", "1.7.0"), installedPkg("wget", "1.24"))
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, &backendConfig{watchInterval: 10 * time.Millisecond}))
+
+	events, err := mgr.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	backend.set(installedPkg("jq", "1.7.1"), installedPkg("git", "2.45"))
+
+	want := []PackageEvent{
+		{Backend: BackendBrew, Type: PackageInstalled, Package: InstalledPackage{Ref: PackageRef{Name: "git"}, Version: "2.45"}},
+		{Backend: BackendBrew, Type: PackageRemoved, Package: InstalledPackage{Ref: PackageRef{Name: "wget"}, Version: "1.24"}},
+		{Backend: BackendBrew, Type: PackageUpgraded, Package: InstalledPackage{Ref: PackageRef{Name: "jq"}, Version: "1.7.1"}, PreviousVersion: "1.7.0"},
+	}
+	for _, w := range want {
+		if ev := nextEvent(t, events); ev != w {
+			t.Errorf("event = %+v, want %+v", ev, w)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestBackendAdapter_WatchChangeToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &tokenBackend{}
+	backend.set(installedPkg("firefox", "125.0"))
+	mgr := Wrap(newBackendAdapter(BackendSnap, backend, &backendConfig{watchInterval: 5 * time.Millisecond}))
+
+	events, err := mgr.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := backend.count(); n != 1 {
+		t.Errorf("backend listed %d times with an unchanged token, want 1", n)
+	}
+
+	backend.set(installedPkg("firefox", "124.0"))
+	want := PackageEvent{Backend: BackendSnap, Type: PackageDowngraded, Package: InstalledPackage{Ref: PackageRef{Name: "firefox"}, Version: "124.0"}, PreviousVersion: "125.0"}
+	if ev := nextEvent(t, events); ev != want {
+		t.Errorf("event = %+v, want %+v", ev, want)
+	}
+}
+
+func TestBackendAdapter_WatchReportsNoProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := &watchBackend{}
+	backend.set(installedPkg("jq", "1.7.0"))
+	rec := &actionRecorder{}
+	backend.progress = convertProgressReporter(rec)
+	mgr := Wrap(newBackendAdapter(BackendBrew, backend, &backendConfig{watchInterval: 5 * time.Millisecond, progress: rec}))
+
+	events, err := mgr.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	backend.set(installedPkg("jq", "1.7.1"))
+	nextEvent(t, events)
+	time.Sleep(20 * time.Millisecond)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.actions) != 0 || rec.last != "" {
+		t.Errorf("Watch reported %d actions and a %q, want no progress", len(rec.actions), rec.last)
+	}
+}
+
+func TestBackendAdapter_WatchListFails(t *testing.T) {
+	adapter := newBackendAdapter(BackendBrew, &failingListBackend{&countingBackend{}}, &backendConfig{})
+	if _, err := adapter.Watch(context.Background()); err == nil {
+		t.Error("Watch() succeeded although listing failed")
+	}
+}
+
+// failingListBackend fails to list installed packages.
+type failingListBackend struct {
+	*countingBackend
+}
+
+func (b *failingListBackend) ListInstalled(ctx context.Context, opts types.ListOptions) ([]types.InstalledPackage, error) {
+	return nil, errors.New("brew: command not found")
+}
+
+func TestMultiManager_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	brew := &watchBackend{}
+	flatpak := &watchBackend{}
+	cfg := &backendConfig{watchInterval: 10 * time.Millisecond}
+	m := NewMultiManager(
+		Backend{Kind: BackendBrew, Manager: newBackendAdapter(BackendBrew, brew, cfg)},
+		Backend{Kind: BackendFlatpak, Manager: newBackendAdapter(BackendFlatpak, flatpak, cfg)},
+		Backend{Kind: BackendSnap, Manager: newBackendAdapter(BackendSnap, &failingListBackend{&countingBackend{}}, cfg)},
+	)
+
+	events, err := m.Watch(ctx)
+	if events == nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if err == nil {
+		t.Error("Watch() error = nil, want the snap listing failure")
+	}
+
+	brew.set(installedPkg("jq", "1.7.1"))
+	if ev := nextEvent(t, events); ev.Backend != BackendBrew || ev.Type != PackageInstalled {
+		t.Errorf("event = %+v, want jq installed with brew", ev)
+	}
+	flatpak.set(installedPkg("org.gimp.GIMP", "2.10.38"))
+	if ev := nextEvent(t, events); ev.Backend != BackendFlatpak || ev.Package.Ref.Name != "org.gimp.GIMP" {
+		t.Errorf("event = %+v, want GIMP installed with flatpak", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}